@@ -268,6 +268,9 @@ BINARIES:=./istioctl/cmd/istioctl \
   ./pilot/cmd/pilot-agent \
   ./pkg/test/echo/cmd/client \
   ./pkg/test/echo/cmd/server \
+  ./pkg/test/extauthz/cmd/extauthz \
+  ./pkg/test/oidc/cmd/oidc \
+  ./pkg/test/externalca/cmd/externalca \
   ./operator/cmd/operator \
   ./cni/cmd/istio-cni \
   ./cni/cmd/istio-cni-repair \
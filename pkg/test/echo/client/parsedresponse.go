@@ -29,17 +29,25 @@ import (
 )
 
 var (
-	requestIDFieldRegex      = regexp.MustCompile("(?i)" + string(response.RequestIDField) + "=(.*)")
-	serviceVersionFieldRegex = regexp.MustCompile(string(response.ServiceVersionField) + "=(.*)")
-	servicePortFieldRegex    = regexp.MustCompile(string(response.ServicePortField) + "=(.*)")
-	statusCodeFieldRegex     = regexp.MustCompile(string(response.StatusCodeField) + "=(.*)")
-	hostFieldRegex           = regexp.MustCompile(string(response.HostField) + "=(.*)")
-	hostnameFieldRegex       = regexp.MustCompile(string(response.HostnameField) + "=(.*)")
-	responseHeaderFieldRegex = regexp.MustCompile(string(response.ResponseHeader) + "=(.*)")
-	URLFieldRegex            = regexp.MustCompile(string(response.URLField) + "=(.*)")
-	ClusterFieldRegex        = regexp.MustCompile(string(response.ClusterField) + "=(.*)")
+	requestIDFieldRegex      = regexp.MustCompile("(?i)" + fieldRegex(response.RequestIDField).String())
+	serviceVersionFieldRegex = fieldRegex(response.ServiceVersionField)
+	servicePortFieldRegex    = fieldRegex(response.ServicePortField)
+	statusCodeFieldRegex     = fieldRegex(response.StatusCodeField)
+	hostFieldRegex           = fieldRegex(response.HostField)
+	hostnameFieldRegex       = fieldRegex(response.HostnameField)
+	responseHeaderFieldRegex = fieldRegex(response.ResponseHeader)
+	URLFieldRegex            = fieldRegex(response.URLField)
+	ClusterFieldRegex        = fieldRegex(response.ClusterField)
+	resolvedAddressRegex     = fieldRegex(response.ResolvedAddressField)
 )
 
+// fieldRegex matches the given field at the start of a line, after the optional "[<request>]" or
+// "[<request> body]" prefix written by the forwarder. The fields of the next hop of a call chain are prefixed
+// with "[forwarded]", so they are never mistaken for the fields of this response.
+func fieldRegex(f response.Field) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^(?:\[\d+(?: body)?\] )?` + string(f) + "=(.*)")
+}
+
 // ParsedResponse represents a response to a single echo request.
 type ParsedResponse struct {
 	// Body is the body of the response
@@ -60,6 +68,8 @@ type ParsedResponse struct {
 	Hostname string
 	// The cluster where the server is deployed.
 	Cluster string
+	// ResolvedAddresses are the addresses the host was resolved to by a DNS request.
+	ResolvedAddresses []string
 	// RawResponse gives a map of all values returned in the response (headers, etc)
 	RawResponse map[string]string
 }
@@ -275,6 +285,12 @@ func ParseForwardedResponse(resp *proto.ForwardEchoResponse) ParsedResponses {
 	return responses
 }
 
+// ParseResponse parses the output of a single echo request, such as the response an echo server writes to a raw
+// TCP connection.
+func ParseResponse(output string) *ParsedResponse {
+	return parseResponse(output)
+}
+
 func parseResponse(output string) *ParsedResponse {
 	out := ParsedResponse{
 		Body: output,
@@ -320,6 +336,10 @@ func parseResponse(output string) *ParsedResponse {
 		out.Cluster = match[1]
 	}
 
+	for _, m := range resolvedAddressRegex.FindAllStringSubmatch(output, -1) {
+		out.ResolvedAddresses = append(out.ResolvedAddresses, m[1])
+	}
+
 	out.RawResponse = map[string]string{}
 
 	matches := responseHeaderFieldRegex.FindAllStringSubmatch(output, -1)
@@ -0,0 +1,58 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseResponseIgnoresForwardedFields(t *testing.T) {
+	output := `[0] Url=http://b:80/
+[0] StatusCode=200
+[0 body] X-Request-Id=abc
+[0 body] ServiceVersion=v1
+[0 body] Hostname=b-v1
+[0 body] ResponseHeader=X-Hop:b
+[0 body] ForwardedURL=http://c:80/
+[0 body] ForwardedStatusCode=503
+[0 body] [forwarded] ServiceVersion=v2
+[0 body] [forwarded] Hostname=c-v2
+[0 body] [forwarded] StatusCode=503
+[0 body] [forwarded] ResponseHeader=X-Hop:c
+[0 body] [forwarded] ResolvedAddress=10.0.0.1
+`
+	r := ParseResponse(output)
+	if r.Code != "200" || r.Version != "v1" || r.Hostname != "b-v1" || r.ID != "abc" {
+		t.Fatalf("expected the fields of the first hop, got:\n%s", r)
+	}
+	if r.RawResponse["X-Hop"] != "b" {
+		t.Fatalf("expected the response header of the first hop, got %v", r.RawResponse)
+	}
+	if len(r.ResolvedAddresses) != 0 {
+		t.Fatalf("expected no resolved addresses, got %v", r.ResolvedAddresses)
+	}
+}
+
+func TestParseResponseUnprefixed(t *testing.T) {
+	output := "ServiceVersion=v1\nStatusCode=200\nResolvedAddress=10.0.0.1\nResolvedAddress=10.0.0.2\n"
+	r := ParseResponse(output)
+	if r.Code != "200" || r.Version != "v1" {
+		t.Fatalf("unexpected response:\n%s", r)
+	}
+	if want := []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(r.ResolvedAddresses, want) {
+		t.Fatalf("expected resolved addresses %v, got %v", want, r.ResolvedAddresses)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// ShadowHostSuffix is added by Envoy to the host of the requests it mirrors to a shadow cluster.
+const ShadowHostSuffix = "-shadow"
+
+// requestLogRegex matches the line the echo server logs for every request it handles, see RequestLog.
+var requestLogRegex = regexp.MustCompile(`Handled request: protocol=(\S+) mirrored=(true|false) host=(\S*) id=(.*)$`)
+
+// IsShadowHost returns true if the host, which may include a port, is the host of a mirrored request.
+func IsShadowHost(host string) bool {
+	if strings.HasSuffix(host, ShadowHostSuffix) {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return strings.HasSuffix(h, ShadowHostSuffix)
+	}
+	return false
+}
+
+// RequestLog returns the line logged by the echo server for a handled request. The id is the URL of HTTP
+// requests and the message of gRPC requests, so that tests can find the requests they sent.
+func RequestLog(protocol, host, id string) string {
+	return fmt.Sprintf("Handled request: protocol=%s mirrored=%v host=%s id=%s", protocol, IsShadowHost(host), host, id)
+}
+
+// RequestCounts is the number of requests handled by echo servers, by whether they were mirrored.
+type RequestCounts struct {
+	Direct   int
+	Mirrored int
+}
+
+// Total number of requests.
+func (c RequestCounts) Total() int {
+	return c.Direct + c.Mirrored
+}
+
+// Add returns the sum of the counts.
+func (c RequestCounts) Add(other RequestCounts) RequestCounts {
+	return RequestCounts{Direct: c.Direct + other.Direct, Mirrored: c.Mirrored + other.Mirrored}
+}
+
+// CountRequests counts the requests in the logs of an echo server whose id contains the given string.
+func CountRequests(logs, id string) RequestCounts {
+	var out RequestCounts
+	for _, line := range strings.Split(logs, "\n") {
+		m := requestLogRegex.FindStringSubmatch(line)
+		if m == nil || !strings.Contains(m[4], id) {
+			continue
+		}
+		if m[2] == "true" {
+			out.Mirrored++
+		} else {
+			out.Direct++
+		}
+	}
+	return out
+}
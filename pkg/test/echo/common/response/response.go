@@ -42,4 +42,11 @@ const (
 	MethodField         Field = "Method"
 	ResponseHeader      Field = "ResponseHeader"
 	ClusterField        Field = "Cluster"
+
+	// ResolvedAddressField is an address the host was resolved to, returned once per address for DNS requests.
+	ResolvedAddressField Field = "ResolvedAddress"
+
+	// Fields describing the next hop of a call chain, see common.ForwardHeader.
+	ForwardedURLField        Field = "ForwardedURL"
+	ForwardedStatusCodeField Field = "ForwardedStatusCode"
 )
@@ -0,0 +1,47 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "net/http"
+
+// ForwardHeader is a request header holding a comma separated list of HTTP URLs. An echo server receiving a
+// request with this header calls the first URL, passing the remaining URLs on in the same header, so that a
+// single call produces a call chain through all of the listed services.
+const ForwardHeader = "X-Echo-Forward"
+
+// TraceHeaders are the request headers carrying trace context, which an application must copy from an incoming
+// request to the requests it makes while serving it for the proxies to correlate them into a single trace.
+var TraceHeaders = []string{
+	"X-Request-Id",
+	"X-B3-Traceid",
+	"X-B3-Spanid",
+	"X-B3-Parentspanid",
+	"X-B3-Sampled",
+	"X-B3-Flags",
+	"B3",
+	"X-Ot-Span-Context",
+	"Traceparent",
+	"Tracestate",
+	"Baggage",
+}
+
+// PropagateTraceHeaders copies the trace context headers from an incoming request to an outgoing one.
+func PropagateTraceHeaders(from, to http.Header) {
+	for _, h := range TraceHeaders {
+		if v := from.Values(h); len(v) > 0 {
+			to[h] = v
+		}
+	}
+}
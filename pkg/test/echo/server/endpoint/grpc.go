@@ -148,6 +148,7 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 	}
 
 	epLog.Infof("GRPC Request:\n  Host: %s\n  Message: %s\n  Headers: %v\n", host, req.GetMessage(), md)
+	epLog.Info(common.RequestLog("GRPC", host, req.GetMessage()))
 
 	portNumber := 0
 	if h.Port != nil {
@@ -19,6 +19,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
@@ -193,6 +194,7 @@ type codeAndSlices struct {
 func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	epLog.Infof("HTTP Request:\n  Method: %s\n  URL: %v,\n  Host: %s\n  Headers: %v",
 		r.Method, r.URL, r.Host, r.Header)
+	epLog.Info(common.RequestLog("HTTP", r.Host, r.URL.String()))
 	defer common.Metrics.HTTPRequests.With(common.PortLabel.Value(strconv.Itoa(h.Port.Port))).Increment()
 	if !h.IsServerReady() {
 		// Handle readiness probe failure.
@@ -221,19 +223,31 @@ func (h *httpHandler) echo(w http.ResponseWriter, r *http.Request) {
 		writeError(&body, "ParseForm() error: "+err.Error())
 	}
 
+	// If the request has the X-Echo-Forward header, continue the call chain before responding.
+	var fwd *forwardResult
+	if hops := r.Header.Get(common.ForwardHeader); hops != "" {
+		fwd = forward(r, hops)
+	}
+
 	// If the request has form ?headers=name:value[,name:value]* return those headers in response
 	if err := setHeaderResponseFromHeaders(r, w); err != nil {
 		writeError(&body, "response headers error: "+err.Error())
 	}
 
-	// If the request has form ?codes=code[:chance][,code[:chance]]* return those codes, rather than 200
-	// For example, ?codes=500:1,200:1 returns 500 1/2 times and 200 1/2 times
-	// For example, ?codes=500:90,200:10 returns 500 90% of times and 200 10% of times
-	if err := setResponseFromCodes(r, w); err != nil {
+	if fwd != nil && !fwd.ok() {
+		// Fail the whole chain, so that the caller sees the failure of any hop.
+		w.WriteHeader(http.StatusBadGateway)
+	} else if err := setResponseFromCodes(r, w); err != nil {
+		// If the request has form ?codes=code[:chance][,code[:chance]]* return those codes, rather than 200
+		// For example, ?codes=500:1,200:1 returns 500 1/2 times and 200 1/2 times
+		// For example, ?codes=500:90,200:10 returns 500 90% of times and 200 10% of times
 		writeError(&body, "codes error: "+err.Error())
 	}
 
 	h.addResponsePayload(r, &body)
+	if fwd != nil {
+		fwd.write(&body)
+	}
 
 	w.Header().Set("Content-Type", "application/text")
 	if _, err := w.Write(body.Bytes()); err != nil {
@@ -413,3 +427,65 @@ func validateCodeAndSlices(codecount string) (codeAndSlices, error) {
 
 	return codeAndSlices{n, count}, nil
 }
+
+// forwardResult is the outcome of forwarding a request to the next hop of a call chain.
+type forwardResult struct {
+	url  string
+	code int
+	body []byte
+	err  error
+}
+
+func (f *forwardResult) ok() bool {
+	return f.err == nil && f.code == http.StatusOK
+}
+
+// write adds the result to the response body. The body of the next hop is included with every line prefixed,
+// so that the fields of this hop are always the first ones found when parsing the response.
+// nolint: interfacer
+func (f *forwardResult) write(body *bytes.Buffer) {
+	writeField(body, response.ForwardedURLField, f.url)
+	if f.err != nil {
+		writeError(body, "forward error: "+f.err.Error())
+		return
+	}
+	writeField(body, response.ForwardedStatusCodeField, strconv.Itoa(f.code))
+	for _, line := range strings.Split(string(f.body), "\n") {
+		if line != "" {
+			_, _ = body.WriteString("[forwarded] " + line + "\n")
+		}
+	}
+}
+
+// forward calls the first of the given comma separated hops, passing the rest of them and the trace context
+// of the incoming request along.
+func forward(r *http.Request, hops string) *forwardResult {
+	next, rest := hops, ""
+	if i := strings.Index(hops, ","); i >= 0 {
+		next, rest = hops[:i], hops[i+1:]
+	}
+	out := &forwardResult{url: next}
+
+	ctx, cancel := context.WithTimeout(r.Context(), common.DefaultRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+	if err != nil {
+		out.err = err
+		return out
+	}
+	common.PropagateTraceHeaders(r.Header, req.Header)
+	if rest != "" {
+		req.Header.Set(common.ForwardHeader, rest)
+	}
+
+	epLog.Infof("Forwarding request to %s", next)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		out.err = err
+		return out
+	}
+	defer func() { _ = resp.Body.Close() }()
+	out.code = resp.StatusCode
+	out.body, out.err = ioutil.ReadAll(resp.Body)
+	return out
+}
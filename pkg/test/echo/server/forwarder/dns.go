@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+var _ protocol = &dnsProtocol{}
+
+// dnsProtocol resolves the host of the URL, through the DNS servers of the workload. If DNS capture is
+// enabled for the sidecar, the queries are answered by its DNS proxy.
+type dnsProtocol struct {
+	resolver *net.Resolver
+}
+
+func (c *dnsProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	msgBuilder := strings.Builder{}
+	msgBuilder.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	addrs, err := c.resolver.LookupHost(ctx, u.Hostname())
+	if err != nil {
+		return msgBuilder.String(), err
+	}
+	msgBuilder.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.StatusCodeField, response.StatusCodeOK))
+	for _, addr := range addrs {
+		msgBuilder.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.ResolvedAddressField, addr))
+	}
+	return msgBuilder.String(), nil
+}
+
+func (c *dnsProtocol) Close() error {
+	return nil
+}
@@ -212,6 +212,10 @@ func newProtocol(cfg Config) (protocol, error) {
 
 			},
 		}, nil
+	case scheme.DNS:
+		return &dnsProtocol{
+			resolver: &net.Resolver{PreferGo: true},
+		}, nil
 	}
 
 	return nil, fmt.Errorf("unrecognized protocol %q", u.String())
@@ -46,6 +46,10 @@ var (
 	// nolint: golint, stylecheck
 	TAG Variable = "TAG"
 
+	// VARIANT is the variant of the Docker images, such as distroless or fips.
+	// nolint: golint, stylecheck
+	VARIANT Variable = "VARIANT"
+
 	// BITNAMIHUB is the Docker registry to be used for the bitnami images.
 	// nolint: golint
 	BITNAMIHUB Variable = "BITNAMIHUB"
@@ -59,6 +63,10 @@ var (
 	// nolint: golint, stylecheck
 	KUBECONFIG Variable = "KUBECONFIG"
 
+	// GATEWAY_CONFORMANCE_DIR is the location of a checkout of the upstream Gateway API conformance suite.
+	// nolint: golint, stylecheck
+	GATEWAY_CONFORMANCE_DIR Variable = "GATEWAY_CONFORMANCE_DIR"
+
 	// IstioSrc is the location of istio source ($TOP/src/istio.io/istio
 	IstioSrc = REPO_ROOT.ValueOrDefaultFunc(getDefaultIstioSrc)
 
@@ -85,8 +93,32 @@ var (
 
 	// OtelCollectorInstallFilePath is the OpenTelemetry installation file.
 	OtelCollectorInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/opentelemetry/opentelemetry-collector.yaml")
+	// LokiInstallFilePath is the Loki and Fluent Bit installation file.
+	LokiInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/loki/loki.yaml")
+	// SPIREInstallFilePath is the SPIRE server and agent installation file.
+	SPIREInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/spire/spire.yaml")
+	// ExtAuthzInstallFilePath is the ext_authz test server installation file.
+	ExtAuthzInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/authz/authz.yaml")
+	// OIDCInstallFilePath is the mock OIDC provider installation file.
+	OIDCInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/oidc/oidc.yaml")
+	// ExternalCAInstallFilePath is the external CA test server installation file.
+	ExternalCAInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/externalca/externalca.yaml")
+	// RateLimitInstallFilePath is the Envoy rate limit service installation file.
+	RateLimitInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/ratelimit/ratelimit.yaml")
+	// LoadGenInstallFilePath is the load generator installation file.
+	LoadGenInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/loadgen/loadgen.yaml")
+	// WasmInstallFilePath is the Wasm module server installation file.
+	WasmInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/wasm/wasm.yaml")
+	// ExternalServiceInstallFilePath is the installation file of the services outside of the mesh.
+	ExternalServiceInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/externalservice/externalservice.yaml")
+	// ExternalServiceDNSInstallFilePath is the installation file of the DNS server of the services outside of the mesh.
+	ExternalServiceDNSInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/externalservice/dns.yaml")
 	// RedisInstallFilePath is the redis installation file.
 	RedisInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/redis/redis.yaml")
+	// HTTPProxyInstallFilePath is the forward HTTP proxy installation file.
+	HTTPProxyInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/httpproxy/httpproxy.yaml")
+	// NetemInstallFilePath is the network impairment daemon set installation file.
+	NetemInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/chaos/netem.yaml")
 
 	// StackdriverInstallFilePath is the stackdriver installation file.
 	StackdriverInstallFilePath = path.Join(IstioSrc, "pkg/test/framework/components/stackdriver/stackdriver.yaml")
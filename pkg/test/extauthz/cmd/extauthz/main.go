@@ -0,0 +1,109 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/pkg/test/extauthz"
+	"istio.io/pkg/log"
+)
+
+var (
+	grpcPort    int
+	httpPort    int
+	adminPort   int
+	defaultDeny bool
+
+	loggingOptions = log.DefaultOptions()
+
+	rootCmd = &cobra.Command{
+		Use:               "extauthz",
+		Short:             "Envoy external authorization test server.",
+		SilenceUsage:      true,
+		PersistentPreRunE: configureLogging,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := extauthz.NewServer(extauthz.Config{DefaultDeny: defaultDeny})
+
+			grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+			if err != nil {
+				return err
+			}
+			grpcServer := grpc.NewServer()
+			authv3.RegisterAuthorizationServer(grpcServer, s)
+			go func() {
+				if err := grpcServer.Serve(grpcListener); err != nil {
+					log.Errorf("gRPC server failed: %v", err)
+				}
+			}()
+			defer grpcServer.Stop()
+
+			serve := func(port int, h http.Handler) *http.Server {
+				srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: h}
+				go func() {
+					if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+						log.Errorf("HTTP server on port %d failed: %v", port, err)
+					}
+				}()
+				return srv
+			}
+			httpServer := serve(httpPort, s.HTTPHandler())
+			defer httpServer.Close()
+			adminServer := serve(adminPort, s.AdminHandler())
+			defer adminServer.Close()
+			log.Infof("serving gRPC ext_authz on %d, HTTP ext_authz on %d and admin API on %d", grpcPort, httpPort, adminPort)
+
+			// Wait for the process to be shutdown.
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			<-sigs
+			return nil
+		},
+	}
+)
+
+func configureLogging(_ *cobra.Command, _ []string) error {
+	if err := log.Configure(loggingOptions); err != nil {
+		return err
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&grpcPort, "grpc", 9000, "gRPC ext_authz port")
+	rootCmd.PersistentFlags().IntVar(&httpPort, "http", 8000, "HTTP ext_authz port")
+	rootCmd.PersistentFlags().IntVar(&adminPort, "admin", 8080, "Admin API port")
+	rootCmd.PersistentFlags().BoolVar(&defaultDeny, "default-deny", false, "Deny requests matching no rule")
+
+	loggingOptions.AttachCobraFlags(rootCmd)
+
+	cmd.AddFlags(rootCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(-1)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+)
+
+var _ authv3.AuthorizationServer = &Server{}
+
+// Check implements the gRPC ext_authz API.
+func (s *Server) Check(ctx context.Context, in *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	attrs := in.GetAttributes()
+	httpAttrs := attrs.GetRequest().GetHttp()
+	req := CheckRequest{
+		Time:      time.Now(),
+		Protocol:  ProtocolGRPC,
+		Method:    httpAttrs.GetMethod(),
+		Host:      httpAttrs.GetHost(),
+		Path:      httpAttrs.GetPath(),
+		Headers:   make(map[string]string, len(httpAttrs.GetHeaders())),
+		Principal: strings.TrimPrefix(attrs.GetSource().GetPrincipal(), "spiffe://"),
+	}
+	for k, v := range httpAttrs.GetHeaders() {
+		req.Headers[strings.ToLower(k)] = v
+	}
+
+	rule := s.decide(ctx, req)
+	headers := make([]*corev3.HeaderValueOption, 0, len(rule.Headers))
+	for k, v := range rule.Headers {
+		headers = append(headers, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: k, Value: v},
+		})
+	}
+	if !rule.Deny {
+		return &authv3.CheckResponse{
+			Status: &status.Status{Code: int32(codes.OK)},
+			HttpResponse: &authv3.CheckResponse_OkResponse{
+				OkResponse: &authv3.OkHttpResponse{
+					Headers:         headers,
+					HeadersToRemove: rule.RemoveHeaders,
+				},
+			},
+		}, nil
+	}
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(codes.PermissionDenied)},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode(rule.denyStatus())},
+				Headers: headers,
+				Body:    rule.DenyBody,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extauthz implements a configurable Envoy external authorization server for tests. It serves both the
+// gRPC and the HTTP ext_authz APIs, decides every check request with a list of rules that can be changed at
+// runtime, and records the check requests it received, so that tests can inspect them.
+package extauthz
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProtocolGRPC is the protocol of check requests received on the gRPC API.
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP is the protocol of check requests received on the HTTP API.
+	ProtocolHTTP = "http"
+
+	// defaultRuleName is the name of the rule applied to requests matching no other rule.
+	defaultRuleName = "default"
+)
+
+// Config of the server.
+type Config struct {
+	// Rules are evaluated in order, and the first one matching a check request decides it.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// DefaultDeny denies the requests matching no rule. Otherwise they are allowed.
+	DefaultDeny bool `json:"defaultDeny,omitempty"`
+}
+
+// Rule decides the outcome of the check requests it matches.
+type Rule struct {
+	// Name of the rule, recorded in the check requests it decided.
+	Name string `json:"name"`
+
+	// Match selects the check requests the rule applies to. An empty Match selects all requests.
+	Match Match `json:"match,omitempty"`
+
+	// Deny denies the matched requests. Otherwise they are allowed.
+	Deny bool `json:"deny,omitempty"`
+
+	// DenyStatus is the HTTP status code returned to the client for denied requests. Defaults to 403.
+	DenyStatus int `json:"denyStatus,omitempty"`
+
+	// DenyBody is the body returned to the client for denied requests.
+	DenyBody string `json:"denyBody,omitempty"`
+
+	// Headers are added to the request sent upstream if the request is allowed, or to the response returned to
+	// the client if it is denied.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// RemoveHeaders are removed from the request sent upstream if the request is allowed. Only supported by the
+	// gRPC API.
+	RemoveHeaders []string `json:"removeHeaders,omitempty"`
+
+	// DelayMillis delays the response to the check request, in order to test timeouts.
+	DelayMillis int `json:"delayMillis,omitempty"`
+}
+
+// Match selects check requests. All of the set fields must match.
+type Match struct {
+	// PathPrefix of the request path.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Headers the request must have, with the given values. Names are case insensitive.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Principal is the source principal of the request, e.g. "cluster.local/ns/a/sa/default". Only available with
+	// the gRPC API, for requests received over mTLS.
+	Principal string `json:"principal,omitempty"`
+}
+
+// CheckRequest is a check request received by the server.
+type CheckRequest struct {
+	Time     time.Time `json:"time"`
+	Protocol string    `json:"protocol"`
+
+	// Attributes of the request being authorized.
+	Method    string            `json:"method"`
+	Host      string            `json:"host"`
+	Path      string            `json:"path"`
+	Headers   map[string]string `json:"headers"`
+	Principal string            `json:"principal,omitempty"`
+
+	// Rule is the name of the rule which decided the request.
+	Rule    string `json:"rule"`
+	Allowed bool   `json:"allowed"`
+}
+
+// Header returns the value of the given request header. The name is case insensitive.
+func (r CheckRequest) Header(name string) string {
+	return r.Headers[strings.ToLower(name)]
+}
+
+// Decide returns the rule deciding the given request.
+func (c Config) Decide(r CheckRequest) Rule {
+	for _, rule := range c.Rules {
+		if rule.Match.matches(r) {
+			return rule
+		}
+	}
+	return Rule{Name: defaultRuleName, Deny: c.DefaultDeny}
+}
+
+func (m Match) matches(r CheckRequest) bool {
+	if m.PathPrefix != "" && !strings.HasPrefix(r.Path, m.PathPrefix) {
+		return false
+	}
+	if m.Principal != "" && m.Principal != r.Principal {
+		return false
+	}
+	for k, v := range m.Headers {
+		if r.Header(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Rule) denyStatus() int {
+	if r.DenyStatus == 0 {
+		return http.StatusForbidden
+	}
+	return r.DenyStatus
+}
+
+func (r Rule) delay() time.Duration {
+	return time.Duration(r.DelayMillis) * time.Millisecond
+}
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+)
+
+// maxRequests is the number of check requests kept by the server. Older ones are dropped.
+const maxRequests = 1000
+
+var authzLog = log.RegisterScope("extauthz", "ext_authz test server", 0)
+
+// Server decides check requests according to its Config, and records them.
+type Server struct {
+	mu       sync.Mutex
+	config   Config
+	requests []CheckRequest
+}
+
+// NewServer returns a server with the given initial config.
+func NewServer(config Config) *Server {
+	return &Server{config: config}
+}
+
+// SetConfig replaces the config of the server.
+func (s *Server) SetConfig(config Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// Config returns the current config of the server.
+func (s *Server) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// Requests returns the check requests received so far, oldest first.
+func (s *Server) Requests() []CheckRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CheckRequest{}, s.requests...)
+}
+
+// ClearRequests forgets all of the check requests received so far.
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// decide decides and records the given check request, and then waits for the delay of the deciding rule.
+func (s *Server) decide(ctx context.Context, r CheckRequest) Rule {
+	s.mu.Lock()
+	rule := s.config.Decide(r)
+	r.Rule = rule.Name
+	r.Allowed = !rule.Deny
+	if len(s.requests) >= maxRequests {
+		s.requests = s.requests[1:]
+	}
+	s.requests = append(s.requests, r)
+	s.mu.Unlock()
+
+	authzLog.Infof("%s check request %s %s%s decided by rule %q: allowed=%v",
+		r.Protocol, r.Method, r.Host, r.Path, r.Rule, r.Allowed)
+	if d := rule.delay(); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+	return rule
+}
+
+// HTTPHandler returns the handler of the HTTP ext_authz API. Envoy sends the original request method, path and
+// headers, and allows the request if the response has status 200.
+func (s *Server) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := CheckRequest{
+			Time:     time.Now(),
+			Protocol: ProtocolHTTP,
+			Method:   r.Method,
+			Host:     r.Host,
+			Path:     r.URL.RequestURI(),
+			Headers:  make(map[string]string, len(r.Header)),
+		}
+		for k := range r.Header {
+			req.Headers[strings.ToLower(k)] = r.Header.Get(k)
+		}
+		rule := s.decide(r.Context(), req)
+		for k, v := range rule.Headers {
+			w.Header().Set(k, v)
+		}
+		if !rule.Deny {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(rule.denyStatus())
+		_, _ = w.Write([]byte(rule.DenyBody))
+	})
+}
+
+// AdminHandler returns the handler of the admin API, which is used by tests to control the server:
+//
+//	GET /config      returns the config as JSON
+//	PUT /config      replaces the config with the JSON in the request body
+//	GET /requests    returns the received check requests as a JSON list
+//	DELETE /requests clears the received check requests
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, s.Config())
+		case http.MethodPut:
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var config Config
+			if err := json.Unmarshal(body, &config); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.SetConfig(config)
+			authzLog.Infof("config updated: %s", body)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/requests", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, s.Requests())
+		case http.MethodDelete:
+			s.ClearRequests()
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,152 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDecide(t *testing.T) {
+	c := Config{
+		Rules: []Rule{
+			{Name: "deny-admin", Match: Match{PathPrefix: "/admin"}, Deny: true},
+			{Name: "allow-header", Match: Match{Headers: map[string]string{"X-User": "alice"}}},
+			{Name: "allow-principal", Match: Match{Principal: "cluster.local/ns/a/sa/a"}},
+		},
+		DefaultDeny: true,
+	}
+	cases := []struct {
+		req  CheckRequest
+		rule string
+	}{
+		{CheckRequest{Path: "/admin/users", Headers: map[string]string{"x-user": "alice"}}, "deny-admin"},
+		{CheckRequest{Path: "/", Headers: map[string]string{"x-user": "alice"}}, "allow-header"},
+		{CheckRequest{Path: "/", Principal: "cluster.local/ns/a/sa/a"}, "allow-principal"},
+		{CheckRequest{Path: "/", Headers: map[string]string{"x-user": "bob"}}, defaultRuleName},
+	}
+	for _, tc := range cases {
+		if got := c.Decide(tc.req); got.Name != tc.rule {
+			t.Errorf("%+v: expected rule %s, got %s", tc.req, tc.rule, got.Name)
+		}
+	}
+	if !c.Decide(CheckRequest{}).Deny {
+		t.Error("expected default deny")
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	s := NewServer(Config{Rules: []Rule{
+		{
+			Name:       "deny",
+			Match:      Match{PathPrefix: "/deny"},
+			Deny:       true,
+			DenyStatus: http.StatusUnauthorized,
+			DenyBody:   "denied by test",
+			Headers:    map[string]string{"x-ext-authz-reason": "test"},
+		},
+		{
+			Name:    "allow",
+			Headers: map[string]string{"x-ext-authz-user": "alice"},
+		},
+	}})
+	h := s.HTTPHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deny/me", nil))
+	if w.Code != http.StatusUnauthorized || w.Body.String() != "denied by test" || w.Header().Get("x-ext-authz-reason") != "test" {
+		t.Fatalf("unexpected deny response: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/allowed?x=1", nil)
+	r.Header.Set("X-Request-Id", "1234")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("x-ext-authz-user") != "alice" {
+		t.Fatalf("unexpected allow response: %d %v", w.Code, w.Header())
+	}
+
+	reqs := s.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d", len(reqs))
+	}
+	if reqs[0].Rule != "deny" || reqs[0].Allowed {
+		t.Errorf("unexpected first request: %+v", reqs[0])
+	}
+	if reqs[1].Rule != "allow" || !reqs[1].Allowed || reqs[1].Method != http.MethodPost ||
+		reqs[1].Path != "/allowed?x=1" || reqs[1].Header("x-request-id") != "1234" || reqs[1].Protocol != ProtocolHTTP {
+		t.Errorf("unexpected second request: %+v", reqs[1])
+	}
+}
+
+func TestDelay(t *testing.T) {
+	s := NewServer(Config{Rules: []Rule{{Name: "slow", DelayMillis: 50}}})
+	start := time.Now()
+	s.decide(context.Background(), CheckRequest{})
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("expected the decision to be delayed, took %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	s.decide(ctx, CheckRequest{})
+	if d := time.Since(start); d >= 50*time.Millisecond {
+		t.Fatalf("expected a canceled check not to be delayed, took %v", d)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	s := NewServer(Config{})
+	h := s.AdminHandler()
+
+	want := Config{Rules: []Rule{{Name: "deny", Deny: true}}, DefaultDeny: true}
+	b, _ := json.Marshal(want)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/config", bytes.NewReader(b)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to set config: %d %s", w.Code, w.Body.String())
+	}
+	if got := s.Config(); len(got.Rules) != 1 || got.Rules[0].Name != "deny" || !got.DefaultDeny {
+		t.Fatalf("unexpected config: %+v", got)
+	}
+
+	s.HTTPHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests", nil))
+	var reqs []CheckRequest
+	if err := json.Unmarshal(w.Body.Bytes(), &reqs); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 1 || reqs[0].Rule != "deny" {
+		t.Fatalf("unexpected requests: %+v", reqs)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/requests", nil))
+	if len(s.Requests()) != 0 {
+		t.Fatal("expected requests to be cleared")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/config", bytes.NewReader([]byte("not json"))))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid config to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "istio.io/api/security/v1alpha1"
+	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/pkg/test/externalca"
+	"istio.io/pkg/log"
+)
+
+var (
+	grpcPort  int
+	adminPort int
+	certDir   string
+	maxTTL    time.Duration
+
+	loggingOptions = log.DefaultOptions()
+
+	rootCmd = &cobra.Command{
+		Use:               "externalca",
+		Short:             "External CA test server implementing the Istio CA gRPC API.",
+		SilenceUsage:      true,
+		PersistentPreRunE: configureLogging,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			read := func(name string) ([]byte, error) {
+				return ioutil.ReadFile(filepath.Join(certDir, name))
+			}
+			certPEM, err := read("ca-cert.pem")
+			if err != nil {
+				return err
+			}
+			keyPEM, err := read("ca-key.pem")
+			if err != nil {
+				return err
+			}
+			chainPEM, err := read("cert-chain.pem")
+			if err != nil {
+				return err
+			}
+			ca, err := externalca.NewCA(externalca.Config{MaxTTL: maxTTL}, certPEM, keyPEM, chainPEM)
+			if err != nil {
+				return err
+			}
+
+			// The CA certificate doubles as the serving certificate. Its SAN holds the DNS names of the service,
+			// and it chains to the mesh root, which the workloads use to verify the CA.
+			serving, err := tls.X509KeyPair(chainPEM, keyPEM)
+			if err != nil {
+				return err
+			}
+			grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+			if err != nil {
+				return err
+			}
+			grpcServer := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&serving)))
+			pb.RegisterIstioCertificateServiceServer(grpcServer, ca)
+			go func() {
+				if err := grpcServer.Serve(grpcListener); err != nil {
+					log.Errorf("gRPC server failed: %v", err)
+				}
+			}()
+			defer grpcServer.Stop()
+
+			adminServer := &http.Server{Addr: fmt.Sprintf(":%d", adminPort), Handler: ca.AdminHandler()}
+			go func() {
+				if err := adminServer.ListenAndServe(); err != http.ErrServerClosed {
+					log.Errorf("HTTP server failed: %v", err)
+				}
+			}()
+			defer adminServer.Close()
+			log.Infof("serving Istio CA API on %d and admin API on %d", grpcPort, adminPort)
+
+			// Wait for the process to be shutdown.
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			<-sigs
+			return nil
+		},
+	}
+)
+
+func configureLogging(_ *cobra.Command, _ []string) error {
+	if err := log.Configure(loggingOptions); err != nil {
+		return err
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&grpcPort, "grpc", 15012, "Istio CA gRPC port")
+	rootCmd.PersistentFlags().IntVar(&adminPort, "admin", 8080, "Admin API port")
+	rootCmd.PersistentFlags().StringVar(&certDir, "cert-dir", "/etc/externalca",
+		"Directory holding ca-cert.pem, ca-key.pem and cert-chain.pem of the CA")
+	rootCmd.PersistentFlags().DurationVar(&maxTTL, "max-ttl", 24*time.Hour, "Maximum lifetime of issued certificates")
+
+	loggingOptions.AttachCobraFlags(rootCmd)
+
+	cmd.AddFlags(rootCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(-1)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "istio.io/api/security/v1alpha1"
+)
+
+var _ pb.IstioCertificateServiceServer = &CA{}
+
+// CreateCertificate implements the Istio CA gRPC API.
+func (c *CA) CreateCertificate(ctx context.Context, req *pb.IstioCertificateRequest) (*pb.IstioCertificateResponse, error) {
+	var cluster string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		// The client sends the cluster of the workload alongside its token.
+		cluster = strings.Join(md.Get("ClusterID"), ",")
+	}
+	chain, err := c.Sign([]byte(req.Csr), time.Duration(req.ValidityDuration)*time.Second, cluster)
+	if err != nil {
+		caLog.Errorf("failed to sign CSR: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "failed to sign CSR: %v", err)
+	}
+	return &pb.IstioCertificateResponse{CertChain: chain}, nil
+}
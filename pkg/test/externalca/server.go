@@ -0,0 +1,203 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalca implements a CA for tests which issues workload certificates through the Istio CA gRPC API.
+// It stands in for an external CA, such as Vault, which workloads request their certificates from instead of
+// istiod. Every issued certificate is recorded, so that tests can check which CA a workload was issued by.
+package externalca
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+// IssuedPath is the path of the admin API listing the issued certificates.
+const IssuedPath = "/issued"
+
+var caLog = log.RegisterScope("externalca", "external CA test server", 0)
+
+// Config for the CA.
+type Config struct {
+	// MaxTTL caps the lifetime of the issued certificates. Requests for a longer lifetime are shortened.
+	MaxTTL time.Duration
+	// DefaultTTL is the lifetime of the issued certificates when the request does not ask for one.
+	DefaultTTL time.Duration
+}
+
+// Issued describes a certificate issued by the CA.
+type Issued struct {
+	SerialNumber string    `json:"serialNumber"`
+	Identities   []string  `json:"identities"`
+	Cluster      string    `json:"cluster,omitempty"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+}
+
+// CA signs CSRs with its signing certificate. The certificate chain of the CA is appended to every issued
+// certificate, so the signing certificate may be an intermediate of the mesh root.
+type CA struct {
+	cfg         Config
+	signingCert *x509.Certificate
+	signingKey  crypto.PrivateKey
+	// chain holds the PEM encoded certificates from the signing certificate up to the root.
+	chain []string
+
+	mu     sync.Mutex
+	issued []Issued
+}
+
+// NewCA creates a CA from the PEM encoded signing certificate and key, and the chain from the signing certificate
+// to the root. The chain may omit the signing certificate.
+func NewCA(cfg Config, certPEM, keyPEM, chainPEM []byte) (*CA, error) {
+	if cfg.DefaultTTL == 0 {
+		cfg.DefaultTTL = 24 * time.Hour
+	}
+	if cfg.MaxTTL == 0 {
+		cfg.MaxTTL = cfg.DefaultTTL
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %v", err)
+	}
+	chain, err := splitPEM(chainPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate chain: %v", err)
+	}
+	if len(chain) == 0 || !chain[0].Equal(cert) {
+		chain = append([]*x509.Certificate{cert}, chain...)
+	}
+	c := &CA{
+		cfg:         cfg,
+		signingCert: cert,
+		signingKey:  key,
+	}
+	for _, cert := range chain {
+		c.chain = append(c.chain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+	return c, nil
+}
+
+func splitPEM(in []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, in = pem.Decode(in)
+		if block == nil {
+			return out, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cert)
+	}
+}
+
+// Sign issues a certificate for the PEM encoded CSR, with the identities requested in its SAN. It returns the PEM
+// encoded certificate chain, starting with the issued certificate and ending with the root. Like a CA which
+// trusts its callers, it does not authenticate the requested identities.
+func (c *CA) Sign(csrPEM []byte, ttl time.Duration, cluster string) ([]string, error) {
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %v", err)
+	}
+	ids, err := util.ExtractIDs(csr.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract identities from the CSR: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("CSR requests no identities")
+	}
+	if ttl <= 0 {
+		ttl = c.cfg.DefaultTTL
+	}
+	if ttl > c.cfg.MaxTTL {
+		ttl = c.cfg.MaxTTL
+	}
+	der, err := util.GenCertFromCSR(csr, c.signingCert, csr.PublicKey, c.signingKey, ids, ttl, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the CSR: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.issued = append(c.issued, Issued{
+		SerialNumber: cert.SerialNumber.String(),
+		Identities:   ids,
+		Cluster:      cluster,
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	})
+	c.mu.Unlock()
+	caLog.Infof("issued certificate %s for %v, valid until %v", cert.SerialNumber, ids, cert.NotAfter)
+
+	out := []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+	return append(out, c.chain...), nil
+}
+
+// Issued returns the certificates issued by the CA, in the order they were issued.
+func (c *CA) Issued() []Issued {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Issued{}, c.issued...)
+}
+
+// ClearIssued forgets the issued certificates.
+func (c *CA) ClearIssued() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issued = nil
+}
+
+// AdminHandler serves the admin API, which lists the issued certificates on GET and clears them on DELETE.
+func (c *CA) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(IssuedPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(c.Issued()); err != nil {
+				caLog.Errorf("failed writing issued certificates: %v", err)
+			}
+		case http.MethodDelete:
+			c.ClearIssued()
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {})
+	return mux
+}
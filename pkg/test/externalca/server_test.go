@@ -0,0 +1,187 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const workloadID = "spiffe://cluster.local/ns/default/sa/default"
+
+func newTestCA(t *testing.T, cfg Config) (*CA, *x509.CertPool) {
+	t.Helper()
+	rootCert, rootKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "root",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := util.ParsePemEncodedCertificate(rootCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := util.ParsePemEncodedKey(rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, caKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:        "external",
+		TTL:        time.Hour,
+		IsCA:       true,
+		SignerCert: root,
+		SignerPriv: signer,
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := NewCA(cfg, caCert, caKey, rootCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return ca, roots
+}
+
+func newCSR(t *testing.T, host string) []byte {
+	t.Helper()
+	csr, _, err := util.GenCSR(util.CertOptions{Host: host, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func parseChain(t *testing.T, chain []string) []*x509.Certificate {
+	t.Helper()
+	out := make([]*x509.Certificate, 0, len(chain))
+	for _, c := range chain {
+		block, _ := pem.Decode([]byte(c))
+		if block == nil {
+			t.Fatalf("invalid PEM in chain: %q", c)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, cert)
+	}
+	return out
+}
+
+func TestSign(t *testing.T) {
+	ca, roots := newTestCA(t, Config{MaxTTL: time.Hour})
+
+	chain, err := ca.Sign(newCSR(t, workloadID), 2*time.Hour, "cluster-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Like istiod, the chain holds the workload certificate, the CA certificate and the root.
+	if len(chain) != 3 {
+		t.Fatalf("expected a chain of 3 certificates, got %d", len(chain))
+	}
+	certs := parseChain(t, chain)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(certs[1])
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		t.Fatalf("issued certificate does not chain to the root: %v", err)
+	}
+	if got := certs[0].URIs; len(got) != 1 || got[0].String() != workloadID {
+		t.Fatalf("expected SAN %s, got %v", workloadID, got)
+	}
+	if ttl := certs[0].NotAfter.Sub(certs[0].NotBefore); ttl > time.Hour {
+		t.Fatalf("expected the lifetime to be capped to 1h, got %v", ttl)
+	}
+
+	issued := ca.Issued()
+	if len(issued) != 1 {
+		t.Fatalf("expected 1 issued certificate, got %v", issued)
+	}
+	want := Issued{
+		SerialNumber: certs[0].SerialNumber.String(),
+		Identities:   []string{workloadID},
+		Cluster:      "cluster-1",
+		NotBefore:    certs[0].NotBefore,
+		NotAfter:     certs[0].NotAfter,
+	}
+	if !reflect.DeepEqual(issued[0], want) {
+		t.Fatalf("got issued %+v, want %+v", issued[0], want)
+	}
+
+	ca.ClearIssued()
+	if issued := ca.Issued(); len(issued) != 0 {
+		t.Fatalf("expected no issued certificates after clearing, got %v", issued)
+	}
+}
+
+func TestSignInvalid(t *testing.T) {
+	ca, _ := newTestCA(t, Config{})
+	if _, err := ca.Sign([]byte("not a csr"), 0, ""); err == nil {
+		t.Fatal("expected invalid CSR to be rejected")
+	}
+	if len(ca.Issued()) != 0 {
+		t.Fatal("expected no certificates to be issued")
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	ca, _ := newTestCA(t, Config{})
+	if _, err := ca.Sign(newCSR(t, workloadID), 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(ca.AdminHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + IssuedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issued []Issued
+	if err := json.NewDecoder(resp.Body).Decode(&issued); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(issued) != 1 || issued[0].Identities[0] != workloadID {
+		t.Fatalf("unexpected issued certificates: %+v", issued)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+IssuedPath, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(ca.Issued()) != 0 {
+		t.Fatal("expected issued certificates to be cleared")
+	}
+}
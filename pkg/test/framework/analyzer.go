@@ -33,6 +33,8 @@ type commonAnalyzer struct {
 	labels                  label.Set
 	minCusters, maxClusters int
 	skip                    string
+	requiredEnvVersion      string
+	capabilities            []resource.Capability
 }
 
 func newCommonAnalyzer() commonAnalyzer {
@@ -50,8 +52,7 @@ type suiteAnalyzer struct {
 	osExit func(int)
 
 	commonAnalyzer
-	envFactoryCalls    int
-	requiredEnvVersion string
+	envFactoryCalls int
 }
 
 func newSuiteAnalyzer(testID string, fn mRunFn, osExit func(int)) Suite {
@@ -103,11 +104,20 @@ func (s *suiteAnalyzer) RequireEnvironmentVersion(version string) Suite {
 	return s
 }
 
+func (s *suiteAnalyzer) RequireCapabilities(caps ...resource.Capability) Suite {
+	s.capabilities = append(s.capabilities, caps...)
+	return s
+}
+
 func (s *suiteAnalyzer) Setup(fn resource.SetupFn) Suite {
 	// TODO track setup fns?
 	return s
 }
 
+func (s *suiteAnalyzer) Soak(fn SoakConfigFn) Suite {
+	return s
+}
+
 func (s *suiteAnalyzer) Run() {
 	s.osExit(s.run())
 }
@@ -130,6 +140,8 @@ func (s *suiteAnalyzer) track() *suiteAnalysis {
 		Labels:           s.labels.All(),
 		MultiCluster:     s.maxClusters != 1,
 		MultiClusterOnly: s.minCusters > 1,
+		MinKubeVersion:   s.requiredEnvVersion,
+		Capabilities:     s.capabilities,
 		Tests:            map[string]*testAnalysis{},
 	}
 }
@@ -206,6 +218,16 @@ func (t *testAnalyzer) RequiresSingleCluster() Test {
 	return t
 }
 
+func (t *testAnalyzer) RequiresKubernetesVersion(version string) Test {
+	t.requiredEnvVersion = version
+	return t
+}
+
+func (t *testAnalyzer) RequiresCapabilities(caps ...resource.Capability) Test {
+	t.capabilities = append(t.capabilities, caps...)
+	return t
+}
+
 func (t *testAnalyzer) Run(_ func(ctx TestContext)) {
 	defer t.track()
 	if t.hasRun {
@@ -237,6 +259,8 @@ func (t *testAnalyzer) track() {
 		Invalid:          t.goTest.Failed(),
 		MultiCluster:     t.maxClusters != 1 && analysis.MultiCluster,
 		MultiClusterOnly: t.minCusters > 1 || analysis.MultiClusterOnly,
+		MinKubeVersion:   t.requiredEnvVersion,
+		Capabilities:     t.capabilities,
 		NotImplemented:   t.notImplemented,
 	})
 }
@@ -26,6 +26,7 @@ import (
 
 	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/scopes"
 )
 
@@ -94,6 +95,8 @@ type suiteAnalysis struct {
 	Labels           []label.Instance         `yaml:"labels,omitempty"`
 	MultiCluster     bool                     `yaml:"multicluster,omitempty"`
 	MultiClusterOnly bool                     `yaml:"multiclusterOnly,omitempty"`
+	MinKubeVersion   string                   `yaml:"minKubeVersion,omitempty"`
+	Capabilities     []resource.Capability    `yaml:"capabilities,omitempty"`
 	Tests            map[string]*testAnalysis `yaml:"tests"`
 }
 
@@ -109,5 +112,7 @@ type testAnalysis struct {
 	Invalid          bool                          `yaml:"invalid,omitempty"`
 	MultiCluster     bool                          `yaml:"multicluster,omitempty"`
 	MultiClusterOnly bool                          `yaml:"multiclusterOnly,omitempty"`
+	MinKubeVersion   string                        `yaml:"minKubeVersion,omitempty"`
+	Capabilities     []resource.Capability         `yaml:"capabilities,omitempty"`
 	NotImplemented   bool                          `yaml:"notImplemented,omitempty"`
 }
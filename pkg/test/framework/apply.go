@@ -0,0 +1,277 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+const (
+	// maxFieldManagerLength is the maximum length of a field manager accepted by the API server.
+	maxFieldManagerLength = 128
+	fieldManagerPrefix    = "istio-test-"
+)
+
+var (
+	invalidFieldManagerChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+	// applied tracks the objects applied with pruning enabled, so that a later apply with the same
+	// field manager can remove the objects it no longer contains.
+	applied = newAppliedObjects()
+)
+
+// objectKey identifies an applied object.
+type objectKey struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// appliedObject is an object parsed from config yaml, along with the information needed to apply or delete it.
+type appliedObject struct {
+	key      objectKey
+	resource schema.GroupVersionResource
+	contents string
+}
+
+// appliedSetKey identifies a set of objects applied by one field manager, to one namespace of a cluster.
+type appliedSetKey struct {
+	cluster      string
+	namespace    string
+	fieldManager string
+}
+
+type appliedObjects struct {
+	mu   sync.Mutex
+	sets map[appliedSetKey]map[objectKey]schema.GroupVersionResource
+}
+
+func newAppliedObjects() *appliedObjects {
+	return &appliedObjects{
+		sets: make(map[appliedSetKey]map[objectKey]schema.GroupVersionResource),
+	}
+}
+
+// stale returns the objects previously recorded for the set that are missing from objects.
+func (a *appliedObjects) stale(set appliedSetKey, objects []appliedObject) map[objectKey]schema.GroupVersionResource {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	current := make(map[objectKey]bool, len(objects))
+	for _, o := range objects {
+		current[o.key] = true
+	}
+	out := make(map[objectKey]schema.GroupVersionResource)
+	for k, gvr := range a.sets[set] {
+		if !current[k] {
+			out[k] = gvr
+		}
+	}
+	return out
+}
+
+// record replaces the objects recorded for the set. Objects that could not be pruned are kept, so that
+// they are retried by the next apply.
+func (a *appliedObjects) record(set appliedSetKey, objects []appliedObject,
+	notPruned map[objectKey]schema.GroupVersionResource) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := make(map[objectKey]schema.GroupVersionResource, len(objects)+len(notPruned))
+	for _, o := range objects {
+		s[o.key] = o.resource
+	}
+	for k, gvr := range notPruned {
+		s[k] = gvr
+	}
+	a.sets[set] = s
+}
+
+// forget removes the given objects from every set recorded for the cluster and namespace.
+func (a *appliedObjects) forget(cluster, namespace string, objects []appliedObject) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for set, s := range a.sets {
+		if set.cluster != cluster || set.namespace != namespace {
+			continue
+		}
+		for _, o := range objects {
+			delete(s, o.key)
+		}
+	}
+}
+
+// fieldManagerFor returns a valid field manager name derived from the given test name.
+func fieldManagerFor(name string) string {
+	fm := fieldManagerPrefix + invalidFieldManagerChars.ReplaceAllString(name, "-")
+	if len(fm) > maxFieldManagerLength {
+		fm = fm[:maxFieldManagerLength]
+	}
+	return fm
+}
+
+func (c *configManager) fieldManager() string {
+	if c.opts.FieldManager != "" {
+		return c.opts.FieldManager
+	}
+	if named, ok := c.ctx.(interface{ Name() string }); ok {
+		return fieldManagerFor(named.Name())
+	}
+	return fieldManagerFor(c.ctx.Settings().TestID)
+}
+
+// applyYAMLWithOptions applies the config to each cluster, using server-side apply and pruning as requested
+// by the apply options.
+func (c *configManager) applyYAMLWithOptions(ns string, yamlText ...string) error {
+	fieldManager := c.fieldManager()
+	var yamlFiles []string
+	if !c.opts.ServerSide {
+		var err error
+		if yamlFiles, err = c.ctx.WriteYAML(c.prefix, yamlText...); err != nil {
+			return err
+		}
+	}
+	for _, cl := range c.clusters {
+		objects, err := parseObjects(cl, ns, yamlText...)
+		if err != nil {
+			return fmt.Errorf("failed parsing YAML for cluster %s: %v", cl.Name(), err)
+		}
+
+		if c.opts.ServerSide {
+			for _, o := range objects {
+				if err := serverSideApply(cl, fieldManager, o); err != nil {
+					return fmt.Errorf("failed applying YAML to cluster %s: %v", cl.Name(), err)
+				}
+			}
+		} else if err := cl.ApplyYAMLFiles(ns, yamlFiles...); err != nil {
+			return fmt.Errorf("failed applying YAML to cluster %s: %v", cl.Name(), err)
+		}
+
+		if c.opts.Prune {
+			set := appliedSetKey{cluster: cl.Name(), namespace: ns, fieldManager: fieldManager}
+			notPruned, err := prune(cl, applied.stale(set, objects))
+			applied.record(set, objects, notPruned)
+			if err != nil {
+				return fmt.Errorf("failed pruning config in cluster %s: %v", cl.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// forgetApplied stops tracking the objects in the given config for pruning, after they have been deleted.
+func (c *configManager) forgetApplied(cluster resource.Cluster, ns string, yamlText ...string) {
+	objects, err := parseObjects(cluster, ns, yamlText...)
+	if err != nil {
+		scopes.Framework.Warnf("failed parsing deleted config for cluster %s: %v", cluster.Name(), err)
+		return
+	}
+	applied.forget(cluster.Name(), ns, objects)
+}
+
+// parseObjects splits the config into objects, resolving the resource and effective namespace of each.
+func parseObjects(cluster resource.Cluster, ns string, yamlText ...string) ([]appliedObject, error) {
+	mapper, err := cluster.UtilFactory().ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	defaultNamespace := ns
+	if defaultNamespace == "" {
+		if defaultNamespace, _, err = cluster.UtilFactory().ToRawKubeConfigLoader().Namespace(); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []appliedObject
+	for _, text := range yamlText {
+		parts, err := yml.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parts {
+			d := p.Descriptor
+			gvk := schema.GroupVersionKind{Group: d.Group, Version: d.APIVersion, Kind: d.Kind}
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return nil, err
+			}
+			namespace := ""
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				namespace = d.Metadata.Namespace
+				if namespace == "" {
+					namespace = defaultNamespace
+				} else if ns != "" && namespace != ns {
+					return nil, fmt.Errorf("the namespace of %s %s (%s) does not match the namespace %s",
+						d.Kind, d.Metadata.Name, namespace, ns)
+				}
+			}
+			out = append(out, appliedObject{
+				key: objectKey{
+					group:     d.Group,
+					kind:      d.Kind,
+					namespace: namespace,
+					name:      d.Metadata.Name,
+				},
+				resource: mapping.Resource,
+				contents: p.Contents,
+			})
+		}
+	}
+	return out, nil
+}
+
+func serverSideApply(cluster resource.Cluster, fieldManager string, o appliedObject) error {
+	data, err := yaml.YAMLToJSON([]byte(o.contents))
+	if err != nil {
+		return err
+	}
+	force := true
+	_, err = cluster.Dynamic().Resource(o.resource).Namespace(o.key.namespace).Patch(context.TODO(), o.key.name,
+		types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+	if err != nil {
+		return fmt.Errorf("%s %s/%s: %v", o.key.kind, o.key.namespace, o.key.name, err)
+	}
+	return nil
+}
+
+// prune deletes the given objects, returning the ones that could not be deleted.
+func prune(cluster resource.Cluster,
+	stale map[objectKey]schema.GroupVersionResource) (map[objectKey]schema.GroupVersionResource, error) {
+	var errs error
+	notPruned := make(map[objectKey]schema.GroupVersionResource)
+	for k, gvr := range stale {
+		scopes.Framework.Debugf("pruning %s %s/%s from cluster %s", k.kind, k.namespace, k.name, cluster.Name())
+		err := cluster.Dynamic().Resource(gvr).Namespace(k.namespace).Delete(context.TODO(), k.name, metav1.DeleteOptions{})
+		if err != nil && !kubeErrors.IsNotFound(err) {
+			notPruned[k] = gvr
+			errs = multierror.Append(errs, fmt.Errorf("%s %s/%s: %v", k.kind, k.namespace, k.name, err))
+		}
+	}
+	return notPruned, errs
+}
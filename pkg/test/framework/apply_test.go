@@ -0,0 +1,155 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/cmd/util"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+// applyCluster records the config files applied to it, and prunes from a fake dynamic client.
+type applyCluster struct {
+	resource.FakeCluster
+	factory util.Factory
+	dynamic *dynamicfake.FakeDynamicClient
+	applied []string
+}
+
+func (c *applyCluster) UtilFactory() util.Factory {
+	return c.factory
+}
+
+func (c *applyCluster) Dynamic() dynamic.Interface {
+	return c.dynamic
+}
+
+func (c *applyCluster) ApplyYAMLFiles(_ string, yamlFiles ...string) error {
+	for _, f := range yamlFiles {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		c.applied = append(c.applied, string(b))
+	}
+	return nil
+}
+
+// restMapperFactory only provides the REST mapper of the factory.
+type restMapperFactory struct {
+	util.Factory
+	mapper meta.RESTMapper
+}
+
+func (f restMapperFactory) ToRESTMapper() (meta.RESTMapper, error) {
+	return f.mapper, nil
+}
+
+// fileWriterContext only provides the file writer of the context.
+type fileWriterContext struct {
+	resource.Context
+	yml.FileWriter
+}
+
+func TestAppliedObjects(t *testing.T) {
+	g := NewWithT(t)
+	gvr := schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1alpha3", Resource: "virtualservices"}
+	object := func(name string) appliedObject {
+		return appliedObject{
+			key:      objectKey{group: gvr.Group, kind: "VirtualService", namespace: "ns", name: name},
+			resource: gvr,
+		}
+	}
+	set := appliedSetKey{cluster: "primary", namespace: "ns", fieldManager: "istio-test-TestFoo"}
+	other := appliedSetKey{cluster: "primary", namespace: "ns", fieldManager: "istio-test-TestBar"}
+
+	a := newAppliedObjects()
+	g.Expect(a.stale(set, []appliedObject{object("a"), object("b")})).To(BeEmpty())
+	a.record(set, []appliedObject{object("a"), object("b")}, nil)
+	a.record(other, []appliedObject{object("c")}, nil)
+
+	// Only objects recorded by the same field manager are stale.
+	g.Expect(a.stale(set, []appliedObject{object("a")})).To(Equal(map[objectKey]schema.GroupVersionResource{
+		object("b").key: gvr,
+	}))
+
+	// Objects that failed to be pruned are retried.
+	a.record(set, []appliedObject{object("a")}, map[objectKey]schema.GroupVersionResource{object("b").key: gvr})
+	g.Expect(a.stale(set, []appliedObject{object("a")})).To(HaveLen(1))
+
+	// Deleted objects are no longer tracked.
+	a.forget("primary", "ns", []appliedObject{object("b"), object("c")})
+	g.Expect(a.stale(set, nil)).To(Equal(map[objectKey]schema.GroupVersionResource{
+		object("a").key: gvr,
+	}))
+	g.Expect(a.stale(other, nil)).To(BeEmpty())
+}
+
+func TestFieldManagerFor(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(fieldManagerFor("TestTraffic/virtualservice/shifting 50%")).
+		To(Equal("istio-test-TestTraffic-virtualservice-shifting-50-"))
+	g.Expect(fieldManagerFor(strings.Repeat("a", 200))).To(HaveLen(maxFieldManagerLength))
+}
+
+func TestApplyYAMLDirPrune(t *testing.T) {
+	g := NewWithT(t)
+	dir, err := ioutil.TempDir("", "apply")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = os.RemoveAll(dir) }()
+	configDir := filepath.Join(dir, "config")
+	g.Expect(os.Mkdir(configDir, 0755)).To(Succeed())
+	names := []string{"a", "b", "c"}
+	for _, name := range names {
+		config := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\n", name)
+		g.Expect(ioutil.WriteFile(filepath.Join(configDir, name+".yaml"), []byte(config), 0644)).To(Succeed())
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	cluster := &applyCluster{
+		FakeCluster: resource.FakeCluster{NameValue: "primary"},
+		factory:     restMapperFactory{mapper: mapper},
+		dynamic:     dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+	}
+	c := &configManager{
+		ctx:      fileWriterContext{FileWriter: yml.NewFileWriter(dir)},
+		clusters: []resource.Cluster{cluster},
+		opts:     resource.ApplyOptions{Prune: true, FieldManager: "istio-test-TestApplyYAMLDirPrune"},
+	}
+	g.Expect(c.ApplyYAMLDir("ns", configDir)).To(Succeed())
+
+	// The objects of every file are applied and kept.
+	g.Expect(cluster.applied).To(HaveLen(len(names)))
+	for _, action := range cluster.dynamic.Actions() {
+		g.Expect(action.GetVerb()).NotTo(Equal("delete"))
+	}
+	set := appliedSetKey{cluster: "primary", namespace: "ns", fieldManager: c.opts.FieldManager}
+	g.Expect(applied.stale(set, nil)).To(HaveLen(len(names)))
+}
@@ -0,0 +1,339 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+// BenchmarkResultsFile is the name of the file, in the run directory of the suite, where the results of the
+// benchmarks are written in the format of "go test -bench", so that they can be compared with benchstat.
+const BenchmarkResultsFile = "benchmarks.txt"
+
+// Benchmark allows the benchmark author to specify benchmark-related metadata in a fluent-style, before
+// commencing execution against the environment of the suite.
+type Benchmark interface {
+	// Label applies the given labels to this benchmark.
+	Label(labels ...label.Instance) Benchmark
+	// RequiresSingleCluster skips the benchmark unless the environment contains exactly one cluster.
+	RequiresSingleCluster() Benchmark
+	// Run the benchmark, supplied as a lambda. Go may call it several times with an increasing b.N, each time
+	// with a new context.
+	Run(fn func(ctx BenchmarkContext))
+}
+
+// BenchmarkContext is the context of a single run of a benchmark. Resources tracked by it are cleaned up at the
+// end of the run, so components shared by all the runs are best set up by the suite.
+type BenchmarkContext interface {
+	resource.Context
+	test.Failer
+
+	// N is the number of iterations the run must perform.
+	N() int
+
+	// Methods controlling the timer of the underlying *testing.B.
+	ResetTimer()
+	StartTimer()
+	StopTimer()
+
+	// ReportMetric adds a custom metric to the results of the run, as in *testing.B.
+	ReportMetric(n float64, unit string)
+
+	// WorkDir allocated for this run.
+	WorkDir() string
+
+	Log(args ...interface{})
+	Logf(format string, args ...interface{})
+	Name() string
+	Skip(args ...interface{})
+}
+
+type benchmarkImpl struct {
+	b                    *testing.B
+	s                    *suiteContext
+	labels               []label.Instance
+	requireSingleCluster bool
+}
+
+// NewBenchmark returns a new wrapper for running a Go benchmark with the components of the suite. The suite
+// must have been started with NewSuite, which also runs the benchmarks selected with -bench.
+func NewBenchmark(b *testing.B) Benchmark {
+	rtMu.Lock()
+	defer rtMu.Unlock()
+
+	if rt == nil {
+		panic("call to scope without running the test framework")
+	}
+	return &benchmarkImpl{
+		b: b,
+		s: rt.suiteContext(),
+	}
+}
+
+func (bm *benchmarkImpl) Label(labels ...label.Instance) Benchmark {
+	bm.labels = append(bm.labels, labels...)
+	return bm
+}
+
+func (bm *benchmarkImpl) RequiresSingleCluster() Benchmark {
+	bm.requireSingleCluster = true
+	return bm
+}
+
+func (bm *benchmarkImpl) Run(fn func(ctx BenchmarkContext)) {
+	b := bm.b
+	if bm.s.skipped {
+		b.Skip("Skipped because parent Suite was skipped.")
+	}
+	allLabels := bm.s.suiteLabels.Merge(label.NewSet(bm.labels...))
+	if !bm.s.settings.Selector.Selects(allLabels) {
+		b.Skipf("Skipping: label mismatch: labels=%v, filter=%v", allLabels, bm.s.settings.Selector)
+	}
+	if n := len(bm.s.Environment().Clusters()); bm.requireSingleCluster && n != 1 {
+		b.Skipf("Skipping %q: requires a single cluster, got %d", b.Name(), n)
+	}
+
+	ctx, err := newBenchmarkContext(b, bm.s)
+	if err != nil {
+		b.Fatal(err)
+	}
+	scopes.Framework.Infof("=== BEGIN: Benchmark: '%s[%s]' (N=%d) ===", bm.s.settings.TestID, b.Name(), b.N)
+	defer ctx.done()
+
+	ctx.StartTimer()
+	fn(ctx)
+	ctx.StopTimer()
+
+	if b.Failed() || b.Skipped() {
+		return
+	}
+	res := ctx.result()
+	scopes.Framework.Infof("=== DONE: Benchmark: '%s[%s]' %s ===", bm.s.settings.TestID, b.Name(), res)
+	if err := bm.s.registerBenchmark(res); err != nil {
+		b.Errorf("failed writing benchmark results: %v", err)
+	}
+}
+
+// benchmarkResult is the result of a benchmark run.
+type benchmarkResult struct {
+	name    string
+	n       int
+	elapsed time.Duration
+	metrics map[string]float64
+}
+
+// String formats the result as a line of "go test -bench" output.
+func (r benchmarkResult) String() string {
+	name := r.name
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		name = fmt.Sprintf("%s-%d", name, procs)
+	}
+	fields := []string{name, fmt.Sprint(r.n)}
+	if r.n > 0 {
+		fields = append(fields, fmt.Sprintf("%d ns/op", r.elapsed.Nanoseconds()/int64(r.n)))
+	}
+	units := make([]string, 0, len(r.metrics))
+	for unit := range r.metrics {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	for _, unit := range units {
+		fields = append(fields, fmt.Sprintf("%g %s", r.metrics[unit], unit))
+	}
+	return strings.Join(fields, "\t")
+}
+
+// registerBenchmark records the result of the last run of a benchmark, and rewrites the results file.
+func (s *suiteContext) registerBenchmark(r benchmarkResult) error {
+	s.benchmarkMu.Lock()
+	defer s.benchmarkMu.Unlock()
+	if s.benchmarks == nil {
+		s.benchmarks = make(map[string]string)
+	}
+	// Only the last run of each benchmark, with the largest b.N, is kept.
+	s.benchmarks[r.name] = r.String()
+
+	names := make([]string, 0, len(s.benchmarks))
+	for name := range s.benchmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sb := &strings.Builder{}
+	for _, name := range names {
+		sb.WriteString(s.benchmarks[name])
+		sb.WriteString("\n")
+	}
+	return ioutil.WriteFile(path.Join(s.settings.RunDir(), BenchmarkResultsFile), []byte(sb.String()), os.ModePerm)
+}
+
+var _ BenchmarkContext = &benchmarkContext{}
+
+// benchmarkContext for the currently running benchmark.
+type benchmarkContext struct {
+	yml.FileWriter
+	*testing.B
+
+	id      string
+	suite   *suiteContext
+	scope   *scope
+	workDir string
+
+	// The timer of the run, which mirrors the one of the *testing.B as it is not accessible.
+	timerMu sync.Mutex
+	timerOn bool
+	start   time.Time
+	elapsed time.Duration
+	metrics map[string]float64
+}
+
+func newBenchmarkContext(b *testing.B, s *suiteContext) (*benchmarkContext, error) {
+	id := s.allocateContextID(b.Name())
+	workDir := path.Join(s.settings.RunDir(), b.Name(), fmt.Sprintf("_benchmark_context_%d", b.N))
+	if err := os.MkdirAll(workDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating work dir %q: %v", workDir, err)
+	}
+	return &benchmarkContext{
+		FileWriter: yml.NewFileWriter(workDir),
+		B:          b,
+		id:         id,
+		suite:      s,
+		scope:      newScope(fmt.Sprintf("[%s]", id), s.globalScope),
+		workDir:    workDir,
+		metrics:    make(map[string]float64),
+	}, nil
+}
+
+func (c *benchmarkContext) N() int {
+	return c.B.N
+}
+
+func (c *benchmarkContext) ResetTimer() {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
+	c.B.ResetTimer()
+	if c.timerOn {
+		c.start = time.Now()
+	}
+	c.elapsed = 0
+}
+
+func (c *benchmarkContext) StartTimer() {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
+	c.B.StartTimer()
+	if !c.timerOn {
+		c.start = time.Now()
+		c.timerOn = true
+	}
+}
+
+func (c *benchmarkContext) StopTimer() {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
+	c.B.StopTimer()
+	if c.timerOn {
+		c.elapsed += time.Since(c.start)
+		c.timerOn = false
+	}
+}
+
+func (c *benchmarkContext) ReportMetric(n float64, unit string) {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
+	c.B.ReportMetric(n, unit)
+	c.metrics[unit] = n
+}
+
+func (c *benchmarkContext) result() benchmarkResult {
+	c.timerMu.Lock()
+	defer c.timerMu.Unlock()
+	return benchmarkResult{
+		name:    c.B.Name(),
+		n:       c.B.N,
+		elapsed: c.elapsed,
+		metrics: c.metrics,
+	}
+}
+
+func (c *benchmarkContext) WorkDir() string {
+	return c.workDir
+}
+
+func (c *benchmarkContext) Settings() *resource.Settings {
+	return c.suite.settings
+}
+
+func (c *benchmarkContext) TrackResource(r resource.Resource) resource.ID {
+	id := c.suite.allocateResourceID(c.id, r)
+	rid := &resourceID{id: id}
+	c.scope.add(r, rid)
+	return rid
+}
+
+func (c *benchmarkContext) GetResource(ref interface{}) error {
+	return c.scope.get(ref)
+}
+
+func (c *benchmarkContext) Environment() resource.Environment {
+	return c.suite.environment
+}
+
+func (c *benchmarkContext) Clusters() resource.Clusters {
+	return c.Environment().Clusters()
+}
+
+func (c *benchmarkContext) CreateDirectory(name string) (string, error) {
+	dir := filepath.Join(c.workDir, name)
+	return dir, os.Mkdir(dir, os.ModePerm)
+}
+
+func (c *benchmarkContext) CreateTmpDirectory(prefix string) (string, error) {
+	return ioutil.TempDir(c.workDir, prefix)
+}
+
+func (c *benchmarkContext) Config(clusters ...resource.Cluster) resource.ConfigManager {
+	return newConfigManager(c, clusters)
+}
+
+// Cleanup runs the given function at the end of the run.
+func (c *benchmarkContext) Cleanup(fn func()) {
+	c.scope.addCloser(&closer{fn: func() error {
+		fn()
+		return nil
+	}})
+}
+
+func (c *benchmarkContext) done() {
+	c.StopTimer()
+	if err := c.scope.done(c.suite.settings.NoCleanup); err != nil {
+		c.Logf("error scope cleanup: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBenchmarkResultString(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	r := benchmarkResult{
+		name:    "BenchmarkPush/services=10",
+		n:       20,
+		elapsed: 2 * time.Second,
+		metrics: map[string]float64{"pushes/op": 1.5, "acks/op": 3},
+	}
+	want := "BenchmarkPush/services=10-4\t20\t100000000 ns/op\t3 acks/op\t1.5 pushes/op"
+	if got := r.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accesslog provides helpers for enabling, collecting and asserting on the Envoy access logs of
+// test workloads and gateways.
+package accesslog
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Encoding is the format in which Envoy writes access logs.
+type Encoding string
+
+const (
+	// Text is the default Istio text format.
+	Text Encoding = "TEXT"
+	// JSON is the default Istio JSON format.
+	JSON Encoding = "JSON"
+)
+
+// Enable returns a setup function for istio.Setup that makes every proxy in the mesh write access logs to
+// stdout using the given encoding.
+func Enable(encoding Encoding) istio.SetupConfigFn {
+	return func(_ resource.Context, cfg *istio.Config) {
+		if cfg == nil {
+			return
+		}
+		cfg.Values["meshConfig.accessLogFile"] = "/dev/stdout"
+		cfg.Values["meshConfig.accessLogEncoding"] = string(encoding)
+	}
+}
+
+// Source is anything which can provide the logs of a proxy container, for example an echo.Sidecar or an
+// ingress.Instance. An echo.Workload is not one: its logs are those of the application container.
+type Source interface {
+	Logs() (string, error)
+}
+
+// Fetch returns all access log entries currently in the logs of the given source.
+func Fetch(src Source) ([]Entry, error) {
+	logs, err := src.Logs()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(logs), nil
+}
+
+// Matcher selects access log entries.
+type Matcher struct {
+	desc  string
+	match func(e Entry) bool
+}
+
+func (m Matcher) String() string {
+	return m.desc
+}
+
+// NewMatcher returns a Matcher which uses the given function. The description is used in error messages.
+func NewMatcher(desc string, match func(e Entry) bool) Matcher {
+	return Matcher{desc: desc, match: match}
+}
+
+// ResponseCode matches entries with the given response code.
+func ResponseCode(code int) Matcher {
+	return NewMatcher(fmt.Sprintf("response_code=%d", code), func(e Entry) bool {
+		return e.ResponseCode == code
+	})
+}
+
+// ResponseFlags matches entries with the given response flags, for example "NR" or "UF,URX".
+func ResponseFlags(flags string) Matcher {
+	return NewMatcher(fmt.Sprintf("response_flags=%s", flags), func(e Entry) bool {
+		return e.ResponseFlags == flags
+	})
+}
+
+// RouteName matches entries which were routed by the route with the given name.
+func RouteName(name string) Matcher {
+	return NewMatcher(fmt.Sprintf("route_name=%s", name), func(e Entry) bool {
+		return e.RouteName == name
+	})
+}
+
+// Authority matches entries for requests with the given :authority header.
+func Authority(authority string) Matcher {
+	return NewMatcher(fmt.Sprintf("authority=%s", authority), func(e Entry) bool {
+		return e.Authority == authority
+	})
+}
+
+// PathPrefix matches entries for requests whose path starts with the given prefix.
+func PathPrefix(prefix string) Matcher {
+	return NewMatcher(fmt.Sprintf("path=%s*", prefix), func(e Entry) bool {
+		return strings.HasPrefix(e.Path, prefix)
+	})
+}
+
+// UpstreamClusterPrefix matches entries whose upstream cluster starts with the given prefix, for example
+// "inbound|" or "outbound|80||b.".
+func UpstreamClusterPrefix(prefix string) Matcher {
+	return NewMatcher(fmt.Sprintf("upstream_cluster=%s*", prefix), func(e Entry) bool {
+		return strings.HasPrefix(e.UpstreamCluster, prefix)
+	})
+}
+
+// Peer matches entries where the given IP is on the other end of the connection: either the upstream host
+// the request was sent to, or the downstream address it was received from.
+func Peer(ip string) Matcher {
+	return NewMatcher(fmt.Sprintf("peer=%s", ip), func(e Entry) bool {
+		return hostOf(e.UpstreamHost) == ip || hostOf(e.DownstreamRemoteAddress) == ip
+	})
+}
+
+// RequestID matches the entry for the request with the given x-request-id.
+func RequestID(id string) Matcher {
+	return NewMatcher(fmt.Sprintf("request_id=%s", id), func(e Entry) bool {
+		return e.RequestID == id
+	})
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// Filter returns the entries matching all of the given matchers.
+func Filter(entries []Entry, matchers ...Matcher) []Entry {
+	var out []Entry
+outer:
+	for _, e := range entries {
+		for _, m := range matchers {
+			if !m.match(e) {
+				continue outer
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func describe(matchers []Matcher) string {
+	descs := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		descs = append(descs, m.desc)
+	}
+	return strings.Join(descs, ", ")
+}
+
+// Watcher follows the access log of a source from the time it was created, so that assertions only
+// consider requests made afterwards.
+type Watcher struct {
+	src  Source
+	skip int
+}
+
+// NewWatcher returns a Watcher for the given source. Entries already logged are ignored.
+func NewWatcher(src Source) (*Watcher, error) {
+	entries, err := Fetch(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{src: src, skip: len(entries)}, nil
+}
+
+// NewWatcherOrFail calls NewWatcher and fails the test if it returns an error.
+func NewWatcherOrFail(t test.Failer, src Source) *Watcher {
+	t.Helper()
+	w, err := NewWatcher(src)
+	if err != nil {
+		t.Fatalf("accesslog.NewWatcherOrFail: %v", err)
+	}
+	return w
+}
+
+// Entries returns the entries logged since the watcher was created.
+func (w *Watcher) Entries() ([]Entry, error) {
+	entries, err := Fetch(w.src)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < w.skip {
+		// The proxy was restarted and the log started over.
+		return entries, nil
+	}
+	return entries[w.skip:], nil
+}
+
+// Expect waits until at least count entries matching all of the given matchers have been logged.
+func (w *Watcher) Expect(count int, matchers ...Matcher) error {
+	return retry.UntilSuccess(func() error {
+		entries, err := w.Entries()
+		if err != nil {
+			return err
+		}
+		if got := len(Filter(entries, matchers...)); got < count {
+			return fmt.Errorf("expected %d access log entries matching [%s], got %d. Entries:\n%s",
+				count, describe(matchers), got, joinEntries(entries))
+		}
+		return nil
+	})
+}
+
+// ExpectOrFail calls Expect and fails the test if it returns an error.
+func (w *Watcher) ExpectOrFail(t test.Failer, count int, matchers ...Matcher) {
+	t.Helper()
+	if err := w.Expect(count, matchers...); err != nil {
+		t.Fatalf("accesslog.ExpectOrFail: %v", err)
+	}
+}
+
+// ExpectNone checks that no entry matching all of the given matchers has been logged. Since logs are
+// written asynchronously, callers should first Expect an entry for a request sent after the one that
+// should not have been logged.
+func (w *Watcher) ExpectNone(matchers ...Matcher) error {
+	entries, err := w.Entries()
+	if err != nil {
+		return err
+	}
+	if matched := Filter(entries, matchers...); len(matched) > 0 {
+		return fmt.Errorf("expected no access log entries matching [%s], got:\n%s",
+			describe(matchers), joinEntries(matched))
+	}
+	return nil
+}
+
+// ExpectNoneOrFail calls ExpectNone and fails the test if it returns an error.
+func (w *Watcher) ExpectNoneOrFail(t test.Failer, matchers ...Matcher) {
+	t.Helper()
+	if err := w.ExpectNone(matchers...); err != nil {
+		t.Fatalf("accesslog.ExpectNoneOrFail: %v", err)
+	}
+}
+
+func joinEntries(entries []Entry) string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.Raw)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,102 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"testing"
+	"time"
+)
+
+const logs = `2021-03-01T00:00:00.000000Z	info	Envoy proxy is ready
+[2021-03-01T00:00:01.000Z] "GET /path?x=y HTTP/1.1" 200 - "-" 0 42 3 2 "-" "Go-http-client/1.1" "req-1" "b:80" "10.0.0.2:8080" outbound|80||b.ns.svc.cluster.local 10.0.0.1:40000 10.96.0.10:80 10.0.0.1:39999 - default
+[2021-03-01T00:00:02.000Z] "- - -" 0 UF,URX "-" 0 0 1 - "-" "-" "-" "-" "10.0.0.3:9090" outbound|9090||c.ns.svc.cluster.local - 10.96.0.11:9090 10.0.0.1:40001 - -
+{"authority":"b:80","bytes_received":0,"bytes_sent":7,"duration":5,"method":"POST","path":"/json","protocol":"HTTP/2",` +
+	`"request_id":"req-2","response_code":503,"response_flags":"NR","route_name":"-","upstream_host":null,"downstream_remote_address":"10.0.0.5:1234"}
+{"level":"info","msg":"not an access log"}
+`
+
+func TestParse(t *testing.T) {
+	entries := Parse(logs)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %v", len(entries), entries)
+	}
+
+	http := entries[0]
+	if http.Method != "GET" || http.Path != "/path?x=y" || http.Protocol != "HTTP/1.1" {
+		t.Errorf("unexpected request line: %+v", http)
+	}
+	if http.ResponseCode != 200 || http.ResponseFlags != "" || http.BytesSent != 42 || http.Duration != 3*time.Millisecond {
+		t.Errorf("unexpected response fields: %+v", http)
+	}
+	if http.UserAgent != "Go-http-client/1.1" || http.RouteName != "default" || http.RequestedServerName != "" {
+		t.Errorf("unexpected fields: %+v", http)
+	}
+
+	tcp := entries[1]
+	if tcp.Method != "" || tcp.ResponseCode != 0 || tcp.ResponseFlags != "UF,URX" || tcp.UpstreamLocalAddress != "" {
+		t.Errorf("unexpected tcp entry: %+v", tcp)
+	}
+
+	js := entries[2]
+	if js.ResponseCode != 503 || js.ResponseFlags != "NR" || js.RouteName != "" || js.UpstreamHost != "" || js.BytesSent != 7 {
+		t.Errorf("unexpected json entry: %+v", js)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := Parse(logs)
+	cases := []struct {
+		name     string
+		matchers []Matcher
+		want     int
+	}{
+		{"none", nil, 3},
+		{"response code", []Matcher{ResponseCode(200)}, 1},
+		{"route", []Matcher{RouteName("default"), ResponseCode(200)}, 1},
+		{"mismatch", []Matcher{RouteName("default"), ResponseCode(503)}, 0},
+		{"upstream peer", []Matcher{Peer("10.0.0.3")}, 1},
+		{"downstream peer", []Matcher{Peer("10.0.0.5")}, 1},
+		{"cluster", []Matcher{UpstreamClusterPrefix("outbound|")}, 2},
+		{"flags", []Matcher{ResponseFlags("NR"), RequestID("req-2")}, 1},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(Filter(entries, tt.matchers...)); got != tt.want {
+				t.Fatalf("expected %d entries matching [%s], got %d", tt.want, describe(tt.matchers), got)
+			}
+		})
+	}
+}
+
+type fakeSource struct {
+	logs string
+}
+
+func (f *fakeSource) Logs() (string, error) {
+	return f.logs, nil
+}
+
+func TestWatcher(t *testing.T) {
+	src := &fakeSource{logs: logs}
+	w := NewWatcherOrFail(t, src)
+	if err := w.ExpectNone(ResponseCode(200)); err != nil {
+		t.Fatal(err)
+	}
+	src.logs += `[2021-03-01T00:00:03.000Z] "GET / HTTP/1.1" 200 - "-" 0 1 1 1 "-" "-" "req-3" "b" "-" - - - - - -` + "\n"
+	w.ExpectOrFail(t, 1, ResponseCode(200), RequestID("req-3"))
+	if err := w.ExpectNone(RequestID("req-3")); err == nil {
+		t.Fatal("expected new entry to be matched")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single Envoy access log entry produced by the default Istio text or JSON format.
+// Fields that Envoy logged as "-" are left empty.
+type Entry struct {
+	StartTime                      string
+	Method                         string
+	Path                           string
+	Protocol                       string
+	ResponseCode                   int
+	ResponseFlags                  string
+	UpstreamTransportFailureReason string
+	BytesReceived                  int64
+	BytesSent                      int64
+	Duration                       time.Duration
+	UpstreamServiceTime            string
+	XForwardedFor                  string
+	UserAgent                      string
+	RequestID                      string
+	Authority                      string
+	UpstreamHost                   string
+	UpstreamCluster                string
+	UpstreamLocalAddress           string
+	DownstreamLocalAddress         string
+	DownstreamRemoteAddress        string
+	RequestedServerName            string
+	RouteName                      string
+
+	// Raw is the log line the entry was parsed from.
+	Raw string
+}
+
+func (e Entry) String() string {
+	return e.Raw
+}
+
+// Parse extracts the access log entries from the logs of a proxy container. Both the default text format
+// and the JSON format are understood; lines that are not access log entries (for example agent or Envoy
+// logs) are skipped.
+func Parse(logs string) []Entry {
+	var out []Entry
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		var (
+			e  Entry
+			ok bool
+		)
+		switch {
+		case strings.HasPrefix(line, "{"):
+			e, ok = parseJSON(line)
+		case strings.HasPrefix(line, "["):
+			e, ok = parseText(line)
+		}
+		if ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// textFields is the number of fields in EnvoyTextLogFormat.
+const textFields = 20
+
+// parseText parses a line in the default Istio text format:
+//
+//	[%START_TIME%] "%REQ(:METHOD)% %REQ(X-ENVOY-ORIGINAL-PATH?:PATH)% %PROTOCOL%" %RESPONSE_CODE% ...
+func parseText(line string) (Entry, bool) {
+	f := tokenize(line)
+	if len(f) != textFields {
+		return Entry{}, false
+	}
+	code, err := parseInt(f[2])
+	if err != nil {
+		return Entry{}, false
+	}
+	e := Entry{
+		StartTime:                      f[0],
+		ResponseCode:                   int(code),
+		ResponseFlags:                  f[3],
+		UpstreamTransportFailureReason: f[4],
+		UpstreamServiceTime:            f[8],
+		XForwardedFor:                  f[9],
+		UserAgent:                      f[10],
+		RequestID:                      f[11],
+		Authority:                      f[12],
+		UpstreamHost:                   f[13],
+		UpstreamCluster:                f[14],
+		UpstreamLocalAddress:           f[15],
+		DownstreamLocalAddress:         f[16],
+		DownstreamRemoteAddress:        f[17],
+		RequestedServerName:            f[18],
+		RouteName:                      f[19],
+		Raw:                            line,
+	}
+	if req := strings.SplitN(f[1], " ", 3); len(req) == 3 {
+		e.Method, e.Path, e.Protocol = normalize(req[0]), normalize(req[1]), normalize(req[2])
+	}
+	e.BytesReceived, _ = parseInt(f[5])
+	e.BytesSent, _ = parseInt(f[6])
+	d, _ := parseInt(f[7])
+	e.Duration = time.Duration(d) * time.Millisecond
+	return e, true
+}
+
+// tokenize splits a text log line into its fields. Bracketed and quoted fields are returned without the
+// surrounding delimiters and may contain spaces. "-" is normalized to the empty string.
+func tokenize(line string) []string {
+	var out []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ':
+			i++
+			continue
+		case '[', '"':
+			end := byte('"')
+			if line[i] == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(line[i+1:], end)
+			if j < 0 {
+				return nil
+			}
+			out = append(out, normalize(line[i+1:i+1+j]))
+			i += j + 2
+		default:
+			j := strings.IndexByte(line[i:], ' ')
+			if j < 0 {
+				j = len(line) - i
+			}
+			out = append(out, normalize(line[i:i+j]))
+			i += j
+		}
+	}
+	return out
+}
+
+// parseJSON parses a line in the default Istio JSON format, as enabled by accessLogEncoding: JSON.
+func parseJSON(line string) (Entry, bool) {
+	raw := map[string]interface{}{}
+	d := json.NewDecoder(bytes.NewBufferString(line))
+	d.UseNumber()
+	if err := d.Decode(&raw); err != nil {
+		return Entry{}, false
+	}
+	if _, ok := raw["response_code"]; !ok {
+		return Entry{}, false
+	}
+	get := func(k string) string {
+		v, ok := raw[k]
+		if !ok || v == nil {
+			return ""
+		}
+		return normalize(fmt.Sprint(v))
+	}
+	code, err := parseInt(get("response_code"))
+	if err != nil {
+		return Entry{}, false
+	}
+	e := Entry{
+		StartTime:                      get("start_time"),
+		Method:                         get("method"),
+		Path:                           get("path"),
+		Protocol:                       get("protocol"),
+		ResponseCode:                   int(code),
+		ResponseFlags:                  get("response_flags"),
+		UpstreamTransportFailureReason: get("upstream_transport_failure_reason"),
+		UpstreamServiceTime:            get("upstream_service_time"),
+		XForwardedFor:                  get("x_forwarded_for"),
+		UserAgent:                      get("user_agent"),
+		RequestID:                      get("request_id"),
+		Authority:                      get("authority"),
+		UpstreamHost:                   get("upstream_host"),
+		UpstreamCluster:                get("upstream_cluster"),
+		UpstreamLocalAddress:           get("upstream_local_address"),
+		DownstreamLocalAddress:         get("downstream_local_address"),
+		DownstreamRemoteAddress:        get("downstream_remote_address"),
+		RequestedServerName:            get("requested_server_name"),
+		RouteName:                      get("route_name"),
+		Raw:                            line,
+	}
+	e.BytesReceived, _ = parseInt(get("bytes_received"))
+	e.BytesSent, _ = parseInt(get("bytes_sent"))
+	d2, _ := parseInt(get("duration"))
+	e.Duration = time.Duration(d2) * time.Millisecond
+	return e, true
+}
+
+func normalize(v string) string {
+	if v == "-" {
+		return ""
+	}
+	return v
+}
+
+// parseInt parses an integer field. Envoy logs "-" (normalized to empty) when there is no value, which
+// is treated as 0.
+func parseInt(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
@@ -0,0 +1,99 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/extauthz"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// API selects which of the ext_authz APIs of the server a proxy uses.
+type API string
+
+const (
+	// GRPC is the envoy.service.auth.v3.Authorization gRPC API.
+	GRPC API = "grpc"
+	// HTTP is the raw HTTP API, where the check request is a copy of the original request.
+	HTTP API = "http"
+)
+
+// Instance represents an ext_authz server deployed on kube. The server decides check requests according to its
+// extauthz.Config, which can be changed at any time, and records every check request it receives.
+//
+// This version of Istio does not support the CUSTOM AuthorizationPolicy action, so proxies are pointed at the
+// server with EnvoyFilter.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the server is deployed in.
+	Namespace() namespace.Instance
+
+	// GRPCAddress is the in-cluster host:port of the gRPC ext_authz API.
+	GRPCAddress() string
+
+	// HTTPAddress is the in-cluster host:port of the HTTP ext_authz API.
+	HTTPAddress() string
+
+	// SetConfig replaces the rules of the server.
+	SetConfig(c extauthz.Config) error
+
+	// SetConfigOrFail calls SetConfig and fails the test if an error is returned.
+	SetConfigOrFail(t test.Failer, c extauthz.Config)
+
+	// Requests returns the check requests received by the server, oldest first.
+	Requests() ([]extauthz.CheckRequest, error)
+
+	// RequestsOrFail calls Requests and fails the test if an error is returned.
+	RequestsOrFail(t test.Failer) []extauthz.CheckRequest
+
+	// ClearRequests forgets the check requests received so far.
+	ClearRequests() error
+
+	// WaitForRequests waits until the server has received at least count check requests for which match returns
+	// true, and returns them. A nil match matches all requests.
+	WaitForRequests(count int, match func(extauthz.CheckRequest) bool, opts ...retry.Option) ([]extauthz.CheckRequest, error)
+
+	// EnvoyFilter returns an EnvoyFilter, with the given name, that has the inbound listeners of the workloads
+	// matching selector check every HTTP request with this server using the given API. It is meant to be applied
+	// in the namespace of the workloads.
+	EnvoyFilter(name string, selector map[string]string, api API) string
+}
+
+// Config for the ext_authz component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Initial configuration of the server. If empty, all requests are allowed.
+	Server extauthz.Config
+}
+
+// New deploys an ext_authz server and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("authz.NewOrFail: %v", err)
+	}
+	return i
+}
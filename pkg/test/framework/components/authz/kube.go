@@ -0,0 +1,301 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/extauthz"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "ext-authz"
+	grpcPort    = 9000
+	httpPort    = 8000
+	adminPort   = 8080
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	ns        namespace.Instance
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-ext-authz",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.ExtAuthzInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	yaml, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.BaseTag(),
+		"ImagePullPolicy": s.PullPolicy,
+		"GRPCPort":        grpcPort,
+		"HTTPPort":        httpPort,
+		"AdminPort":       adminPort,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The deployment is removed along with the namespace.
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.ns.Name(), "app="+serviceName))
+	if err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, adminPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	c.address = fmt.Sprintf("http://%s", forwarder.Address())
+	scopes.Framework.Debugf("initialized ext_authz port forwarder: %v", forwarder.Address())
+
+	if err := c.SetConfig(cfg.Server); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) host() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, c.ns.Name())
+}
+
+func (c *kubeComponent) GRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.host(), grpcPort)
+}
+
+func (c *kubeComponent) HTTPAddress() string {
+	return fmt.Sprintf("%s:%d", c.host(), httpPort)
+}
+
+// do sends a request to the admin API of the server, and decodes the JSON response into out, if not nil.
+func (c *kubeComponent) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.address+path, body)
+	if err != nil {
+		return err
+	}
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s returned non-ok status %v: %s", method, path, resp.StatusCode, respBody)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *kubeComponent) SetConfig(cfg extauthz.Config) error {
+	return c.do(http.MethodPut, "/config", cfg, nil)
+}
+
+func (c *kubeComponent) SetConfigOrFail(t test.Failer, cfg extauthz.Config) {
+	t.Helper()
+	if err := c.SetConfig(cfg); err != nil {
+		t.Fatalf("authz.SetConfigOrFail: %v", err)
+	}
+}
+
+func (c *kubeComponent) Requests() ([]extauthz.CheckRequest, error) {
+	var out []extauthz.CheckRequest
+	if err := c.do(http.MethodGet, "/requests", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kubeComponent) RequestsOrFail(t test.Failer) []extauthz.CheckRequest {
+	t.Helper()
+	out, err := c.Requests()
+	if err != nil {
+		t.Fatalf("authz.RequestsOrFail: %v", err)
+	}
+	return out
+}
+
+func (c *kubeComponent) ClearRequests() error {
+	return c.do(http.MethodDelete, "/requests", nil, nil)
+}
+
+func (c *kubeComponent) WaitForRequests(count int, match func(extauthz.CheckRequest) bool,
+	opts ...retry.Option) ([]extauthz.CheckRequest, error) {
+	out, err := retry.Do(func() (interface{}, bool, error) {
+		all, err := c.Requests()
+		if err != nil {
+			return nil, false, err
+		}
+		var matched []extauthz.CheckRequest
+		for _, r := range all {
+			if match == nil || match(r) {
+				matched = append(matched, r)
+			}
+		}
+		if len(matched) < count {
+			return nil, false, fmt.Errorf("expected at least %d matching check requests, got %d", count, len(matched))
+		}
+		return matched, true, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out.([]extauthz.CheckRequest), nil
+}
+
+func (c *kubeComponent) EnvoyFilter(name string, selector map[string]string, api API) string {
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	labels := &strings.Builder{}
+	for _, k := range keys {
+		fmt.Fprintf(labels, "\n      %s: %q", k, selector[k])
+	}
+
+	var service string
+	if api == HTTP {
+		service = fmt.Sprintf(`
+            http_service:
+              server_uri:
+                uri: http://%s
+                cluster: outbound|%d||%s
+                timeout: 10s
+              authorization_request:
+                allowed_headers:
+                  patterns:
+                  - prefix: x-
+              authorization_response:
+                allowed_upstream_headers:
+                  patterns:
+                  - prefix: x-ext-authz`, c.HTTPAddress(), httpPort, c.host())
+	} else {
+		service = fmt.Sprintf(`
+            grpc_service:
+              envoy_grpc:
+                cluster_name: outbound|%d||%s
+              timeout: 10s
+            transport_api_version: V3`, grpcPort, c.host())
+	}
+
+	return fmt.Sprintf(`apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: %s
+spec:
+  workloadSelector:
+    labels:%s
+  configPatches:
+  - applyTo: HTTP_FILTER
+    match:
+      context: SIDECAR_INBOUND
+      listener:
+        filterChain:
+          filter:
+            name: envoy.filters.network.http_connection_manager
+            subFilter:
+              name: envoy.filters.http.router
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: envoy.filters.http.ext_authz
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.http.ext_authz.v3.ExtAuthz
+          failure_mode_allow: false%s
+`, name, labels, service)
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos disrupts the environment the tests run in, to verify how the mesh behaves under adverse conditions.
+package chaos
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Impairment of the network traffic, applied with netem.
+type Impairment struct {
+	// Latency added to each packet.
+	Latency time.Duration
+
+	// Jitter is the random variation of the Latency.
+	Jitter time.Duration
+
+	// Loss is the percentage of packets dropped, between 0 and 100.
+	Loss float64
+}
+
+// IsZero returns true if the impairment does not affect the traffic.
+func (i Impairment) IsZero() bool {
+	return i.Latency == 0 && i.Jitter == 0 && i.Loss == 0
+}
+
+func (i Impairment) String() string {
+	return fmt.Sprintf("latency=%v jitter=%v loss=%v%%", i.Latency, i.Jitter, i.Loss)
+}
+
+// netemArgs returns the netem parameters of the impairment.
+func (i Impairment) netemArgs() ([]string, error) {
+	if i.Latency < 0 || i.Jitter < 0 || i.Loss < 0 || i.Loss > 100 {
+		return nil, fmt.Errorf("invalid network impairment: %v", i)
+	}
+	if i.Jitter > 0 && i.Latency == 0 {
+		return nil, fmt.Errorf("network impairment jitter requires a latency: %v", i)
+	}
+	var args []string
+	if i.Latency > 0 {
+		args = append(args, "delay", fmt.Sprintf("%dus", i.Latency.Microseconds()))
+		if i.Jitter > 0 {
+			args = append(args, fmt.Sprintf("%dus", i.Jitter.Microseconds()))
+		}
+	}
+	if i.Loss > 0 {
+		args = append(args, "loss", strconv.FormatFloat(i.Loss, 'f', -1, 64)+"%")
+	}
+	return args, nil
+}
+
+// Network impairs the traffic leaving the nodes of a cluster towards given destinations, typically the other
+// clusters of the environment. The impairment applies in one direction only: to impair a round trip between two
+// clusters symmetrically, impair both of them.
+type Network interface {
+	resource.Resource
+
+	// Destinations whose traffic is impaired, as CIDRs.
+	Destinations() []string
+
+	// Impair applies the impairment to the traffic, replacing the current one.
+	Impair(i Impairment) error
+
+	// ImpairOrFail calls Impair and fails the test if an error is returned.
+	ImpairOrFail(t test.Failer, i Impairment)
+
+	// Restore removes the impairment of the traffic. It is also restored when the Network is closed.
+	Restore() error
+
+	// RestoreOrFail calls Restore and fails the test if an error is returned.
+	RestoreOrFail(t test.Failer)
+}
+
+// NetworkConfig for impairing the network.
+type NetworkConfig struct {
+	// Cluster whose outgoing traffic is impaired.
+	Cluster resource.Cluster
+
+	// Destinations whose traffic is impaired, as IPs or CIDRs. Defaults to the east-west traffic: the nodes, pods
+	// and Istio load balancers of the other clusters of the environment.
+	Destinations []string
+
+	// Impairment applied once the Network is created. It can be changed with Impair.
+	Impairment Impairment
+}
+
+// NewNetwork deploys a privileged daemon set to the nodes of the cluster, which applies the impairments with tc.
+func NewNetwork(ctx resource.Context, c NetworkConfig) (Network, error) {
+	return newKubeNetwork(ctx, c)
+}
+
+// NewNetworkOrFail calls NewNetwork and fails the test if an error is returned.
+func NewNetworkOrFail(t test.Failer, ctx resource.Context, c NetworkConfig) Network {
+	t.Helper()
+	n, err := NewNetwork(ctx, c)
+	if err != nil {
+		t.Fatalf("chaos.NewNetworkOrFail: %v", err)
+	}
+	return n
+}
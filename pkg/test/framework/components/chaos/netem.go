@@ -0,0 +1,344 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	netemContainer = "netem"
+	netemSelector  = "app=netem"
+
+	netemReadyTimeout = 2 * time.Minute
+)
+
+var (
+	_ Network   = &kubeNetwork{}
+	_ io.Closer = &kubeNetwork{}
+)
+
+type kubeNetwork struct {
+	id           resource.ID
+	ns           namespace.Instance
+	cluster      resource.Cluster
+	destinations []string
+	// devices maps the daemon set pods to the interface of the default route of their node.
+	devices  map[string]string
+	impaired bool
+}
+
+func newKubeNetwork(ctx resource.Context, cfg NetworkConfig) (Network, error) {
+	c := &kubeNetwork{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		devices: map[string]string{},
+	}
+
+	var err error
+	c.destinations, err = destinationCIDRs(cfg.Destinations)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.destinations) == 0 {
+		if c.destinations, err = eastWestDestinations(ctx, c.cluster); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.destinations) == 0 {
+		return nil, fmt.Errorf("no destinations to impair the traffic of from %s", c.cluster.Name())
+	}
+
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "netem",
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Tracked after the namespace, so that the impairment is removed before the daemon set is deleted.
+	c.id = ctx.TrackResource(c)
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.NetemInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.BaseTag(),
+		"ImagePullPolicy": s.PullPolicy,
+		"Container":       netemContainer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), manifest); err != nil {
+		return nil, err
+	}
+	if err := c.waitForPods(); err != nil {
+		return nil, err
+	}
+
+	if !cfg.Impairment.IsZero() {
+		if err := c.Impair(cfg.Impairment); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// waitForPods waits for a ready daemon set pod on every node, and finds the interface each of them impairs.
+func (c *kubeNetwork) waitForPods() error {
+	nodes, err := c.cluster.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var pods []kubeApiCore.Pod
+	if err := retry.UntilSuccess(func() error {
+		pods, err = testKube.CheckPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), netemSelector))
+		if err != nil {
+			return err
+		}
+		if len(pods) < len(nodes.Items) {
+			return fmt.Errorf("%d of %d netem pods are ready", len(pods), len(nodes.Items))
+		}
+		return nil
+	}, retry.Timeout(netemReadyTimeout)); err != nil {
+		return fmt.Errorf("failed waiting for netem pods in %s: %v", c.cluster.Name(), err)
+	}
+
+	for _, p := range pods {
+		out, err := c.exec(p.Name, "ip -o route show default")
+		if err != nil {
+			return err
+		}
+		dev, err := parseDefaultDevice(out)
+		if err != nil {
+			return fmt.Errorf("node %s: %v", p.Spec.NodeName, err)
+		}
+		c.devices[p.Name] = dev
+	}
+	return nil
+}
+
+func (c *kubeNetwork) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeNetwork) Destinations() []string {
+	return c.destinations
+}
+
+func (c *kubeNetwork) Impair(i Impairment) error {
+	if i.IsZero() {
+		return c.Restore()
+	}
+	scopes.Framework.Infof("impairing traffic from %s to %v: %v", c.cluster.Name(), c.destinations, i)
+	for pod, dev := range c.devices {
+		cmds, err := impairCommands(dev, c.destinations, i)
+		if err != nil {
+			return err
+		}
+		if err := c.clear(pod, dev); err != nil {
+			return err
+		}
+		c.impaired = true
+		for _, cmd := range cmds {
+			if _, err := c.exec(pod, cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *kubeNetwork) ImpairOrFail(t test.Failer, i Impairment) {
+	t.Helper()
+	if err := c.Impair(i); err != nil {
+		t.Fatalf("chaos.ImpairOrFail: %v", err)
+	}
+}
+
+func (c *kubeNetwork) Restore() error {
+	if !c.impaired {
+		return nil
+	}
+	scopes.Framework.Infof("restoring traffic from %s to %v", c.cluster.Name(), c.destinations)
+	for pod, dev := range c.devices {
+		if err := c.clear(pod, dev); err != nil {
+			return err
+		}
+	}
+	c.impaired = false
+	return nil
+}
+
+func (c *kubeNetwork) RestoreOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.Restore(); err != nil {
+		t.Fatalf("chaos.RestoreOrFail: %v", err)
+	}
+}
+
+// clear deletes the root queueing discipline of the device, which fails if there is none.
+func (c *kubeNetwork) clear(pod, dev string) error {
+	out, err := c.exec(pod, "tc qdisc show dev "+dev)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, "qdisc prio 1: root") {
+		return nil
+	}
+	_, err = c.exec(pod, "tc qdisc del dev "+dev+" root")
+	return err
+}
+
+func (c *kubeNetwork) exec(pod, cmd string) (string, error) {
+	stdout, stderr, err := c.cluster.PodExec(pod, c.ns.Name(), netemContainer, cmd)
+	if err != nil {
+		return "", fmt.Errorf("%q failed: %v: %s", cmd, err, stderr)
+	}
+	return stdout, nil
+}
+
+// Close implements io.Closer.
+func (c *kubeNetwork) Close() error {
+	return c.Restore()
+}
+
+// impairCommands returns the tc commands impairing the traffic leaving the device towards the destinations. A
+// fourth band is added to the default prio queueing discipline, which the default priority map does not use, and
+// only the filtered traffic is sent through its netem queue.
+func impairCommands(dev string, destinations []string, i Impairment) ([]string, error) {
+	netem, err := i.netemArgs()
+	if err != nil {
+		return nil, err
+	}
+	cmds := []string{
+		fmt.Sprintf("tc qdisc add dev %s root handle 1: prio bands 4", dev),
+		fmt.Sprintf("tc qdisc add dev %s parent 1:4 handle 40: netem %s", dev, strings.Join(netem, " ")),
+	}
+	for _, d := range destinations {
+		proto, match := "ip", "ip"
+		if strings.Contains(d, ":") {
+			proto, match = "ipv6", "ip6"
+		}
+		cmds = append(cmds, fmt.Sprintf("tc filter add dev %s parent 1: protocol %s prio 1 u32 match %s dst %s flowid 1:4",
+			dev, proto, match, d))
+	}
+	return cmds, nil
+}
+
+// parseDefaultDevice returns the interface of the default route, from the output of "ip -o route show default".
+func parseDefaultDevice(out string) (string, error) {
+	fields := strings.Fields(out)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("no default route found in %q", out)
+}
+
+// destinationCIDRs validates the destinations, converting IPs to single address CIDRs.
+func destinationCIDRs(destinations []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, d := range destinations {
+		if d == "" {
+			continue
+		}
+		if !strings.Contains(d, "/") {
+			ip := net.ParseIP(d)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid destination %q", d)
+			}
+			if ip.To4() != nil {
+				d += "/32"
+			} else {
+				d += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination %q: %v", d, err)
+		}
+		if !seen[cidr.String()] {
+			seen[cidr.String()] = true
+			out = append(out, cidr.String())
+		}
+	}
+	return out, nil
+}
+
+// eastWestDestinations returns the addresses the east-west traffic leaving the cluster is sent to: the nodes
+// and pods of the other clusters, for clusters on the same network, and the load balancers of the Istio
+// gateways, for the others.
+func eastWestDestinations(ctx resource.Context, cluster resource.Cluster) ([]string, error) {
+	cfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, c := range ctx.Clusters() {
+		if c.Name() == cluster.Name() {
+			continue
+		}
+		nodes, err := c.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes.Items {
+			for _, a := range n.Status.Addresses {
+				if a.Type == kubeApiCore.NodeInternalIP || a.Type == kubeApiCore.NodeExternalIP {
+					addrs = append(addrs, a.Address)
+				}
+			}
+			addrs = append(addrs, n.Spec.PodCIDRs...)
+		}
+		svcs, err := c.CoreV1().Services(cfg.SystemNamespace).List(context.TODO(), kubeApiMeta.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range svcs.Items {
+			for _, ing := range s.Status.LoadBalancer.Ingress {
+				addrs = append(addrs, ing.IP)
+			}
+		}
+	}
+	return destinationCIDRs(addrs)
+}
@@ -0,0 +1,91 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestImpairCommands(t *testing.T) {
+	cmds, err := impairCommands("eth0", []string{"10.0.0.0/16", "fd00::/64"}, Impairment{
+		Latency: 100 * time.Millisecond,
+		Jitter:  1500 * time.Microsecond,
+		Loss:    2.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"tc qdisc add dev eth0 root handle 1: prio bands 4",
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 100000us 1500us loss 2.5%",
+		"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 10.0.0.0/16 flowid 1:4",
+		"tc filter add dev eth0 parent 1: protocol ipv6 prio 1 u32 match ip6 dst fd00::/64 flowid 1:4",
+	}
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Fatalf("expected %v, got %v", expected, cmds)
+	}
+}
+
+func TestNetemArgs(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       Impairment
+		expected []string
+		err      bool
+	}{
+		{"latency", Impairment{Latency: time.Second}, []string{"delay", "1000000us"}, false},
+		{"loss", Impairment{Loss: 10}, []string{"loss", "10%"}, false},
+		{"jitter without latency", Impairment{Jitter: time.Millisecond}, nil, true},
+		{"negative latency", Impairment{Latency: -time.Millisecond}, nil, true},
+		{"loss over 100", Impairment{Loss: 101}, nil, true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.in.netemArgs()
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseDefaultDevice(t *testing.T) {
+	dev, err := parseDefaultDevice("default via 172.18.0.1 dev eth0 proto static metric 100\n")
+	if err != nil || dev != "eth0" {
+		t.Fatalf("expected eth0, got %q: %v", dev, err)
+	}
+	if _, err := parseDefaultDevice(""); err == nil {
+		t.Fatal("expected error without default route")
+	}
+}
+
+func TestDestinationCIDRs(t *testing.T) {
+	got, err := destinationCIDRs([]string{"10.1.2.3", "10.244.1.7/24", "fd00::1", "", "10.1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"10.1.2.3/32", "10.244.1.0/24", "fd00::1/128"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if _, err := destinationCIDRs([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expected error for invalid destination")
+	}
+}
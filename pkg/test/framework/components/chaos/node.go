@@ -0,0 +1,304 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const defaultRescheduleTimeout = 3 * time.Minute
+
+// NodeConfig selects the node to disrupt, as the node hosting a pod of the test workloads. For example, the
+// east-west gateway is selected with the "istio=eastwestgateway" selector in the Istio system namespace.
+type NodeConfig struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Namespace of the selected pods.
+	Namespace string
+
+	// Selector of the pods, as a label selector. The node of the first selected pod is disrupted.
+	Selector string
+
+	// Timeout to wait for the selected pods to be evicted, and rescheduled on other nodes. Defaults to 3m.
+	Timeout time.Duration
+}
+
+// Node is a disrupted node. It is restored when closed.
+type Node interface {
+	resource.Resource
+
+	// Name of the node.
+	Name() string
+
+	// Restore makes the node schedulable again, re-registering it first if it was deleted. Pods evicted from it
+	// are not moved back.
+	Restore() error
+
+	// RestoreOrFail calls Restore and fails the test if an error is returned.
+	RestoreOrFail(t test.Failer)
+}
+
+var (
+	_ Node      = &kubeNode{}
+	_ io.Closer = &kubeNode{}
+)
+
+type kubeNode struct {
+	id      resource.ID
+	cfg     NodeConfig
+	cluster resource.Cluster
+	name    string
+	// deleted is a copy of the node, if it was deleted, used to register it again.
+	deleted  *kubeApiCore.Node
+	restored bool
+}
+
+// DrainNode cordons the node hosting the selected pods, evicts its pods as "kubectl drain" does, and waits for
+// the selected pods to be ready on other nodes.
+func DrainNode(ctx resource.Context, cfg NodeConfig) (Node, error) {
+	n, err := newKubeNode(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	scopes.Framework.Infof("draining node %s of %s", n.name, n.cluster.Name())
+	if err := n.cordon(true); err != nil {
+		return nil, err
+	}
+	pods, err := n.pods()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods {
+		if !evictable(p) {
+			continue
+		}
+		p := p
+		// Evictions are rejected while they would violate a PodDisruptionBudget, until the pods are replaced.
+		if err := retry.UntilSuccess(func() error {
+			err := n.cluster.CoreV1().Pods(p.Namespace).Evict(context.TODO(), &policyv1beta1.Eviction{
+				ObjectMeta: kubeApiMeta.ObjectMeta{Name: p.Name, Namespace: p.Namespace},
+			})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}, retry.Timeout(cfg.Timeout)); err != nil {
+			return nil, fmt.Errorf("failed evicting pod %s/%s from node %s: %v", p.Namespace, p.Name, n.name, err)
+		}
+	}
+	return n, n.waitForRescheduling()
+}
+
+// DrainNodeOrFail calls DrainNode and fails the test if an error is returned.
+func DrainNodeOrFail(t test.Failer, ctx resource.Context, cfg NodeConfig) Node {
+	t.Helper()
+	n, err := DrainNode(ctx, cfg)
+	if err != nil {
+		t.Fatalf("chaos.DrainNodeOrFail: %v", err)
+	}
+	return n
+}
+
+// DeleteNode simulates the failure of the node hosting the selected pods: the node is deleted, its pods are
+// forcibly deleted without waiting for them to terminate, and the selected pods are waited for to be ready on
+// other nodes. Unlike a drain, disruption budgets and graceful termination are not honored.
+func DeleteNode(ctx resource.Context, cfg NodeConfig) (Node, error) {
+	n, err := newKubeNode(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	scopes.Framework.Infof("deleting node %s of %s", n.name, n.cluster.Name())
+	node, err := n.cluster.CoreV1().Nodes().Get(context.TODO(), n.name, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := n.pods()
+	if err != nil {
+		return nil, err
+	}
+	if err := n.cluster.CoreV1().Nodes().Delete(context.TODO(), n.name, kubeApiMeta.DeleteOptions{}); err != nil {
+		return nil, err
+	}
+	n.deleted = node
+	zero := int64(0)
+	for _, p := range pods {
+		err := n.cluster.CoreV1().Pods(p.Namespace).Delete(context.TODO(), p.Name, kubeApiMeta.DeleteOptions{
+			GracePeriodSeconds: &zero,
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed deleting pod %s/%s of node %s: %v", p.Namespace, p.Name, n.name, err)
+		}
+	}
+	return n, n.waitForRescheduling()
+}
+
+// DeleteNodeOrFail calls DeleteNode and fails the test if an error is returned.
+func DeleteNodeOrFail(t test.Failer, ctx resource.Context, cfg NodeConfig) Node {
+	t.Helper()
+	n, err := DeleteNode(ctx, cfg)
+	if err != nil {
+		t.Fatalf("chaos.DeleteNodeOrFail: %v", err)
+	}
+	return n
+}
+
+func newKubeNode(ctx resource.Context, cfg NodeConfig) (*kubeNode, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultRescheduleTimeout
+	}
+	n := &kubeNode{
+		cfg:     cfg,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	pods, err := testKube.CheckPodsAreReady(testKube.NewPodMustFetch(n.cluster, cfg.Namespace, cfg.Selector))
+	if err != nil {
+		return nil, fmt.Errorf("no ready pods to disrupt the node of: %v", err)
+	}
+	n.name = pods[0].Spec.NodeName
+
+	nodes, err := n.cluster.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	schedulable := 0
+	for _, node := range nodes.Items {
+		if node.Name != n.name && !node.Spec.Unschedulable {
+			schedulable++
+		}
+	}
+	if schedulable == 0 {
+		return nil, fmt.Errorf("node %s is the only schedulable node of %s", n.name, n.cluster.Name())
+	}
+
+	// Tracked before the disruption, so that the node is restored even if it fails.
+	n.id = ctx.TrackResource(n)
+	return n, nil
+}
+
+func (n *kubeNode) ID() resource.ID {
+	return n.id
+}
+
+func (n *kubeNode) Name() string {
+	return n.name
+}
+
+// pods returns the pods scheduled on the node.
+func (n *kubeNode) pods() ([]kubeApiCore.Pod, error) {
+	pods, err := n.cluster.CoreV1().Pods("").List(context.TODO(), kubeApiMeta.ListOptions{
+		FieldSelector: "spec.nodeName=" + n.name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// evictable returns false for the pods "kubectl drain" leaves on the node: daemon set pods, which would be
+// recreated on it, and static pods, which are managed by the kubelet.
+func evictable(p kubeApiCore.Pod) bool {
+	if _, mirror := p.Annotations[kubeApiCore.MirrorPodAnnotationKey]; mirror {
+		return false
+	}
+	for _, o := range p.OwnerReferences {
+		if o.Controller != nil && *o.Controller && o.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return p.Status.Phase != kubeApiCore.PodSucceeded && p.Status.Phase != kubeApiCore.PodFailed
+}
+
+func (n *kubeNode) cordon(unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%v}}`, unschedulable)
+	_, err := n.cluster.CoreV1().Nodes().Patch(context.TODO(), n.name, types.StrategicMergePatchType, []byte(patch),
+		kubeApiMeta.PatchOptions{})
+	return err
+}
+
+// waitForRescheduling waits for the selected pods to be ready, and none of them to be on the disrupted node.
+func (n *kubeNode) waitForRescheduling() error {
+	err := retry.UntilSuccess(func() error {
+		pods, err := testKube.CheckPodsAreReady(testKube.NewPodMustFetch(n.cluster, n.cfg.Namespace, n.cfg.Selector))
+		if err != nil {
+			return err
+		}
+		for _, p := range pods {
+			if p.Spec.NodeName == n.name {
+				return fmt.Errorf("pod %s/%s is still on node %s", p.Namespace, p.Name, n.name)
+			}
+		}
+		return nil
+	}, retry.Timeout(n.cfg.Timeout))
+	if err != nil {
+		return fmt.Errorf("pods %q were not rescheduled from node %s: %v", n.cfg.Selector, n.name, err)
+	}
+	return nil
+}
+
+func (n *kubeNode) Restore() error {
+	if n.restored {
+		return nil
+	}
+	scopes.Framework.Infof("restoring node %s of %s", n.name, n.cluster.Name())
+	if n.deleted != nil {
+		node := &kubeApiCore.Node{
+			ObjectMeta: kubeApiMeta.ObjectMeta{
+				Name:        n.deleted.Name,
+				Labels:      n.deleted.Labels,
+				Annotations: n.deleted.Annotations,
+			},
+			Spec: n.deleted.Spec,
+		}
+		// The kubelet reports the status of the node again once it is registered.
+		if _, err := n.cluster.CoreV1().Nodes().Create(context.TODO(), node, kubeApiMeta.CreateOptions{}); err != nil &&
+			!errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed registering node %s again: %v", n.name, err)
+		}
+		n.deleted = nil
+	}
+	if err := n.cordon(false); err != nil {
+		return fmt.Errorf("failed uncordoning node %s: %v", n.name, err)
+	}
+	n.restored = true
+	return nil
+}
+
+func (n *kubeNode) RestoreOrFail(t test.Failer) {
+	t.Helper()
+	if err := n.Restore(); err != nil {
+		t.Fatalf("chaos.RestoreOrFail: %v", err)
+	}
+}
+
+// Close implements io.Closer.
+func (n *kubeNode) Close() error {
+	return n.Restore()
+}
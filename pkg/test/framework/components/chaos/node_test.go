@@ -0,0 +1,65 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"testing"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvictable(t *testing.T) {
+	controller := true
+	cases := []struct {
+		name     string
+		pod      kubeApiCore.Pod
+		expected bool
+	}{
+		{
+			name: "deployment pod",
+			pod: kubeApiCore.Pod{ObjectMeta: kubeApiMeta.ObjectMeta{
+				OwnerReferences: []kubeApiMeta.OwnerReference{{Kind: "ReplicaSet", Controller: &controller}},
+			}},
+			expected: true,
+		},
+		{
+			name: "daemon set pod",
+			pod: kubeApiCore.Pod{ObjectMeta: kubeApiMeta.ObjectMeta{
+				OwnerReferences: []kubeApiMeta.OwnerReference{{Kind: "DaemonSet", Controller: &controller}},
+			}},
+			expected: false,
+		},
+		{
+			name: "static pod",
+			pod: kubeApiCore.Pod{ObjectMeta: kubeApiMeta.ObjectMeta{
+				Annotations: map[string]string{kubeApiCore.MirrorPodAnnotationKey: "hash"},
+			}},
+			expected: false,
+		},
+		{
+			name:     "completed pod",
+			pod:      kubeApiCore.Pod{Status: kubeApiCore.PodStatus{Phase: kubeApiCore.PodSucceeded}},
+			expected: false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evictable(tt.pod); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
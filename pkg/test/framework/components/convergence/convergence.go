@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convergence measures the time it takes for a configuration change to be pushed to, and acknowledged by,
+// the proxies of the mesh.
+package convergence
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Type of xDS resources.
+type Type string
+
+const (
+	Cluster  Type = "cluster"
+	Listener Type = "listener"
+	Route    Type = "route"
+	Endpoint Type = "endpoint"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultPollInterval = 100 * time.Millisecond
+)
+
+// Instance measures the convergence of the proxies connected to istiod, by polling the xDS nonces sent and
+// acknowledged for each of them on the debug endpoint of istiod. Latencies are only as precise as the poll
+// interval.
+type Instance interface {
+	resource.Resource
+
+	// Measure applies the change, and waits for all the selected proxies to have acknowledged a push for it. The
+	// result is also written as JSON to the working directory, named after the label.
+	Measure(label string, change func() error) (Result, error)
+
+	// MeasureOrFail calls Measure and fails the test if an error is returned. Proxies that did not converge
+	// are reported in the result, not as an error.
+	MeasureOrFail(t test.Failer, label string, change func() error) Result
+}
+
+// Config for measuring convergence.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Proxies whose convergence is measured, by node ID. For example, the node ID of echo workloads is returned
+	// by Sidecar().NodeID(). If empty, all the proxies connected when a measure starts are selected.
+	Proxies []string
+
+	// Types of resources a proxy must have acknowledged to converge. A proxy converges once at least one of them
+	// was pushed and all are acknowledged. Defaults to all types.
+	Types []Type
+
+	// Timeout after which proxies that did not converge are reported. Defaults to 30s.
+	Timeout time.Duration
+
+	// PollInterval of the istiod debug endpoint. Defaults to 100ms.
+	PollInterval time.Duration
+}
+
+// New returns an Instance measuring convergence with the given configuration.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	if len(c.Types) == 0 {
+		c.Types = []Type{Cluster, Listener, Route, Endpoint}
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("convergence.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,91 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convergence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	start := time.Unix(0, 0)
+	baseline := []syncStatus{
+		{ProxyID: "a", ListenerSent: "1", ListenerAcked: "1", RouteSent: "1", RouteAcked: "1"},
+		{ProxyID: "b", ListenerSent: "1", ListenerAcked: "1", RouteSent: "1", RouteAcked: "1"},
+		{ProxyID: "c", ListenerSent: "1", ListenerAcked: "1", RouteSent: "1", RouteAcked: "1"},
+	}
+	tr := newTracker([]Type{Listener, Route}, []string{"a", "b"}, baseline, start)
+
+	// a is pushed but has not acknowledged the routes yet, b is not pushed.
+	if tr.observe([]syncStatus{
+		{ProxyID: "a", ListenerSent: "2", ListenerAcked: "2", RouteSent: "2", RouteAcked: "1"},
+		baseline[1],
+		{ProxyID: "c", ListenerSent: "2", ListenerAcked: "2", RouteSent: "1", RouteAcked: "1"},
+	}, start.Add(time.Second)) {
+		t.Fatal("unexpected convergence")
+	}
+	if tr.observe([]syncStatus{
+		{ProxyID: "a", ListenerSent: "2", ListenerAcked: "2", RouteSent: "2", RouteAcked: "2"},
+		baseline[1],
+	}, start.Add(2*time.Second)) {
+		t.Fatal("unexpected convergence of b")
+	}
+
+	r := tr.result("test")
+	if r.Converged() || len(r.Unconverged) != 1 || r.Unconverged[0] != "b" {
+		t.Fatalf("unexpected unconverged proxies: %v", r.Unconverged)
+	}
+	if r.Latencies["a"] != 2*time.Second {
+		t.Fatalf("unexpected latency of a: %v", r.Latencies["a"])
+	}
+	if _, ok := r.Latencies["c"]; ok {
+		t.Fatal("c is not tracked")
+	}
+
+	if !tr.observe([]syncStatus{
+		{ProxyID: "b", ListenerSent: "1", ListenerAcked: "1", RouteSent: "3", RouteAcked: "3"},
+	}, start.Add(3*time.Second)) {
+		t.Fatal("expected convergence")
+	}
+	if err := tr.result("test").Check(50, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.result("test").Check(100, 2*time.Second); err == nil {
+		t.Fatal("expected error for max latency above limit")
+	}
+}
+
+func TestParseSyncz(t *testing.T) {
+	statuses, err := parseSyncz([]byte(`[{"proxy": "sidecar~10.0.0.1~a.ns~ns.svc.cluster.local",
+		"cluster_sent": "x", "cluster_acked": "x", "endpoint_sent": "y"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 status, got %d", len(statuses))
+	}
+	if sent, acked := statuses[0].nonces(Endpoint); sent != "y" || acked != "" {
+		t.Fatalf("unexpected endpoint nonces %q/%q", sent, acked)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	r := Result{Latencies: map[string]time.Duration{"a": 1, "b": 2, "c": 3, "d": 4}}
+	for p, want := range map[float64]time.Duration{0: 1, 50: 2, 75: 3, 90: 4, 100: 4} {
+		if got := r.Percentile(p); got != want {
+			t.Errorf("p%v: got %v, want %v", p, got, want)
+		}
+	}
+}
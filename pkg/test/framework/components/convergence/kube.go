@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convergence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+// istiodDebugPort is the port istiod serves its debug endpoints on.
+const istiodDebugPort = 8080
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+// unsafeChars matches the characters of measure labels that are replaced in file names.
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type kubeComponent struct {
+	id      resource.ID
+	cfg     Config
+	cluster resource.Cluster
+	istioNs string
+	dir     string
+	client  http.Client
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cfg:     cfg,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		client: http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+	c.id = ctx.TrackResource(c)
+
+	icfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.istioNs = icfg.SystemNamespace
+	if c.dir, err = ctx.CreateTmpDirectory("convergence"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+// forwardIstiod opens port forwards to the debug port of every istiod pod, as each proxy is connected to one
+// of them only.
+func (c *kubeComponent) forwardIstiod() ([]istioKube.PortForwarder, error) {
+	pods, err := c.cluster.PodsForSelector(context.TODO(), c.istioNs, "app=istiod")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing istiod pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no istiod pods in %s", c.istioNs)
+	}
+	var out []istioKube.PortForwarder
+	for _, p := range pods.Items {
+		f, err := c.cluster.NewPortForwarder(p.Name, p.Namespace, "", 0, istiodDebugPort)
+		if err == nil {
+			err = f.Start()
+		}
+		if err != nil {
+			closeAll(out)
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func closeAll(forwarders []istioKube.PortForwarder) {
+	for _, f := range forwarders {
+		f.Close()
+	}
+}
+
+// syncz returns the sync status of the proxies connected to all the istiod pods.
+func (c *kubeComponent) syncz(forwarders []istioKube.PortForwarder) ([]syncStatus, error) {
+	var out []syncStatus
+	for _, f := range forwarders {
+		resp, err := c.client.Get(fmt.Sprintf("http://%s/debug/syncz", f.Address()))
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("syncz returned non-ok status: %v", resp.StatusCode)
+		}
+		statuses, err := parseSyncz(body)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, statuses...)
+	}
+	return out, nil
+}
+
+func (c *kubeComponent) Measure(label string, change func() error) (Result, error) {
+	forwarders, err := c.forwardIstiod()
+	if err != nil {
+		return Result{}, err
+	}
+	defer closeAll(forwarders)
+
+	baseline, err := c.syncz(forwarders)
+	if err != nil {
+		return Result{}, err
+	}
+	start := time.Now()
+	t := newTracker(c.cfg.Types, c.cfg.Proxies, baseline, start)
+	if err := change(); err != nil {
+		return Result{}, err
+	}
+
+	for !time.Now().After(start.Add(c.cfg.Timeout)) {
+		statuses, err := c.syncz(forwarders)
+		if err != nil {
+			return Result{}, err
+		}
+		if t.observe(statuses, time.Now()) {
+			break
+		}
+		time.Sleep(c.cfg.PollInterval)
+	}
+
+	r := t.result(label)
+	scopes.Framework.Infof("xds convergence %s", r)
+	if err := c.write(r); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// write the result as JSON to the working directory.
+func (c *kubeComponent) write(r Result) error {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(c.dir, unsafeChars.ReplaceAllString(r.Label, "-")+".json")
+	return ioutil.WriteFile(file, out, 0644)
+}
+
+func (c *kubeComponent) MeasureOrFail(t test.Failer, label string, change func() error) Result {
+	t.Helper()
+	r, err := c.Measure(label, change)
+	if err != nil {
+		t.Fatalf("convergence.MeasureOrFail: %v", err)
+	}
+	return r
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convergence
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Result of a convergence measure.
+type Result struct {
+	// Label of the measure.
+	Label string `json:"label"`
+	// Latencies from the start of the change to its acknowledgement, by proxy node ID.
+	Latencies map[string]time.Duration `json:"latencies"`
+	// Unconverged are the node IDs of the proxies that did not acknowledge the change before the timeout.
+	Unconverged []string `json:"unconverged,omitempty"`
+}
+
+// Converged returns whether all the proxies acknowledged the change.
+func (r Result) Converged() bool {
+	return len(r.Unconverged) == 0
+}
+
+// Percentile returns the convergence latency at the given percentile, in [0, 100], among the proxies that converged.
+func (r Result) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, 0, len(r.Latencies))
+	for _, l := range r.Latencies {
+		sorted = append(sorted, l)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	// Nearest rank.
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Max returns the latency of the slowest proxy that converged.
+func (r Result) Max() time.Duration {
+	return r.Percentile(100)
+}
+
+// Check returns an error if some proxies did not converge, or if the latency at the given percentile exceeds max.
+func (r Result) Check(p float64, max time.Duration) error {
+	if !r.Converged() {
+		return fmt.Errorf("%s: %d proxies did not converge: %s", r.Label, len(r.Unconverged),
+			strings.Join(r.Unconverged, ", "))
+	}
+	if len(r.Latencies) == 0 {
+		return fmt.Errorf("%s: no proxies were measured", r.Label)
+	}
+	if l := r.Percentile(p); l > max {
+		return fmt.Errorf("%s: p%v convergence latency %v above %v (%s)", r.Label, p, l, max, r)
+	}
+	return nil
+}
+
+// CheckOrFail calls Check and fails the test if an error is returned.
+func (r Result) CheckOrFail(t test.Failer, p float64, max time.Duration) {
+	t.Helper()
+	if err := r.Check(p, max); err != nil {
+		t.Fatalf("convergence.CheckOrFail: %v", err)
+	}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s: %d proxies converged (p50=%v p90=%v max=%v), %d did not", r.Label, len(r.Latencies),
+		r.Percentile(50), r.Percentile(90), r.Max(), len(r.Unconverged))
+}
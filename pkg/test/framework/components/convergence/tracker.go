@@ -0,0 +1,136 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convergence
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// syncStatus is the status of a proxy reported by the /debug/syncz endpoint of istiod.
+type syncStatus struct {
+	ProxyID       string `json:"proxy,omitempty"`
+	ClusterSent   string `json:"cluster_sent,omitempty"`
+	ClusterAcked  string `json:"cluster_acked,omitempty"`
+	ListenerSent  string `json:"listener_sent,omitempty"`
+	ListenerAcked string `json:"listener_acked,omitempty"`
+	RouteSent     string `json:"route_sent,omitempty"`
+	RouteAcked    string `json:"route_acked,omitempty"`
+	EndpointSent  string `json:"endpoint_sent,omitempty"`
+	EndpointAcked string `json:"endpoint_acked,omitempty"`
+}
+
+func parseSyncz(body []byte) ([]syncStatus, error) {
+	var out []syncStatus
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("failed parsing syncz: %v", err)
+	}
+	return out, nil
+}
+
+// nonces returns the last nonces sent and acknowledged for the given type.
+func (s syncStatus) nonces(t Type) (sent, acked string) {
+	switch t {
+	case Cluster:
+		return s.ClusterSent, s.ClusterAcked
+	case Listener:
+		return s.ListenerSent, s.ListenerAcked
+	case Route:
+		return s.RouteSent, s.RouteAcked
+	case Endpoint:
+		return s.EndpointSent, s.EndpointAcked
+	}
+	return "", ""
+}
+
+// tracker records when each proxy converges after a change.
+type tracker struct {
+	types     []Type
+	start     time.Time
+	baseline  map[string]syncStatus
+	latencies map[string]time.Duration
+}
+
+// newTracker tracks the given proxies, or all the proxies of the baseline if none is given, from the given start.
+func newTracker(types []Type, proxies []string, baseline []syncStatus, start time.Time) *tracker {
+	t := &tracker{
+		types:     types,
+		start:     start,
+		baseline:  make(map[string]syncStatus),
+		latencies: make(map[string]time.Duration),
+	}
+	for _, s := range baseline {
+		t.baseline[s.ProxyID] = s
+	}
+	if len(proxies) > 0 {
+		selected := make(map[string]syncStatus, len(proxies))
+		for _, p := range proxies {
+			// Proxies that are not connected yet have an empty baseline.
+			selected[p] = t.baseline[p]
+		}
+		t.baseline = selected
+	}
+	return t
+}
+
+// converged returns whether the status shows that a push happened since the baseline, and that all the tracked
+// types are acknowledged.
+func (t *tracker) converged(s syncStatus) bool {
+	pushed := false
+	base := t.baseline[s.ProxyID]
+	for _, typ := range t.types {
+		sent, acked := s.nonces(typ)
+		if sent != acked {
+			return false
+		}
+		if baseSent, _ := base.nonces(typ); sent != baseSent {
+			pushed = true
+		}
+	}
+	return pushed
+}
+
+// observe records the proxies that converged in the given statuses, observed at the given time, and returns
+// whether all the tracked proxies converged.
+func (t *tracker) observe(statuses []syncStatus, at time.Time) bool {
+	for _, s := range statuses {
+		if _, tracked := t.baseline[s.ProxyID]; !tracked {
+			continue
+		}
+		if _, done := t.latencies[s.ProxyID]; done {
+			continue
+		}
+		if t.converged(s) {
+			t.latencies[s.ProxyID] = at.Sub(t.start)
+		}
+	}
+	return len(t.latencies) == len(t.baseline)
+}
+
+func (t *tracker) result(label string) Result {
+	r := Result{
+		Label:     label,
+		Latencies: t.latencies,
+	}
+	for p := range t.baseline {
+		if _, ok := t.latencies[p]; !ok {
+			r.Unconverged = append(r.Unconverged, p)
+		}
+	}
+	sort.Strings(r.Unconverged)
+	return r
+}
@@ -40,6 +40,7 @@ var (
 	SidecarBootstrapOverride     = workloadAnnotation(annotation.SidecarBootstrapOverride.Name, "")
 	SidecarVolumeMount           = workloadAnnotation(annotation.SidecarUserVolumeMount.Name, "")
 	SidecarVolume                = workloadAnnotation(annotation.SidecarUserVolume.Name, "")
+	SidecarStatsInclusionRegexps = workloadAnnotation(annotation.SidecarStatsInclusionRegexps.Name, "")
 )
 
 type AnnotationValue struct {
@@ -73,11 +73,29 @@ type CallOptions struct {
 	// (without proxy) from naked client to test certificates issued by custom CA instead of the Istio self-signed CA.
 	Cert, Key, CaCert string
 
+	// Trace, if set, starts the call with the given trace context instead of letting the first proxy on the path
+	// start a new trace. This allows finding the trace of the call in the tracing backend afterwards.
+	Trace *TraceContext
+
+	// Chain is a list of further hops for the call. The Target forwards the request to the first hop, which
+	// forwards it to the second and so on, propagating the trace context, so that a call from a to Target b with
+	// Chain [c] produces the call chain a -> b -> c. The call and all hops must use HTTP.
+	Chain []Hop
+
 	// Validators is a list of validators for server responses. If empty, only the number of responses received
-	// will be verified.
+	// will be verified. Checks are turned into validators with Check.Validator.
 	Validators Validators
 }
 
+// Hop is a single hop of a call chain. See CallOptions.Chain.
+type Hop struct {
+	// Target instance of the hop. Required.
+	Target Instance
+
+	// PortName of the port on the Target to call. Defaults to "http".
+	PortName string
+}
+
 // Validator validates that the given responses are expected.
 type Validator func(client.ParsedResponses) error
 
@@ -122,7 +140,7 @@ func (all Validators) With(v Validator) Validators {
 
 var _ Validator = ValidateOK
 
-// ValidateOK is a Validator that calls CheckOK on the given responses.
+// ValidateOK is a Validator that checks that all the responses are successful.
 func ValidateOK(responses client.ParsedResponses) error {
-	return responses.CheckOK()
+	return IsOK().Validator()(responses)
 }
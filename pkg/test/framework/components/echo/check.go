@@ -0,0 +1,294 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Check is a composable predicate on the responses of a call. A check either applies to each response, such as a
+// check of the response code, or to all the responses together, such as a check of their count. Checks are
+// combined with And, Or and Not, and turned into a Validator with Validator, which reports which responses failed
+// which check, with their actual values.
+type Check struct {
+	// Description of the expectation, such as `code == 200`.
+	Description string
+
+	// response checks a single response, and returns its actual value. It is nil for checks of all the responses.
+	response func(r *client.ParsedResponse) (ok bool, actual string)
+	// all checks all the responses together, and returns their actual value.
+	all func(responses client.ParsedResponses) (ok bool, actual string)
+}
+
+// ResponseCheck returns a Check of each response. The check function returns whether the response matches the
+// expectation, and the actual value it checked, which is reported when it doesn't.
+func ResponseCheck(description string, check func(r *client.ParsedResponse) (ok bool, actual string)) Check {
+	return Check{Description: description, response: check}
+}
+
+// AggregateCheck returns a Check of all the responses together, such as a check of their count or distribution.
+func AggregateCheck(description string, check func(responses client.ParsedResponses) (ok bool, actual string)) Check {
+	return Check{Description: description, all: check}
+}
+
+func (c Check) isResponseCheck() bool {
+	return c.response != nil
+}
+
+// eval checks all the responses together. A check of each response passes if all the responses pass, and reports
+// the first failing response.
+func (c Check) eval(responses client.ParsedResponses) (bool, string) {
+	if !c.isResponseCheck() {
+		return c.all(responses)
+	}
+	for i, r := range responses {
+		if ok, actual := c.response(r); !ok {
+			return false, fmt.Sprintf("response[%d]: %s", i, actual)
+		}
+	}
+	return true, fmt.Sprintf("all %d responses", len(responses))
+}
+
+// And returns a Check which passes if all the checks pass. It is a check of each response if all the checks are.
+func And(checks ...Check) Check {
+	return combine("&&", checks, func(results []bool) bool {
+		for _, ok := range results {
+			if !ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or returns a Check which passes if any of the checks passes. It is a check of each response if all the checks
+// are, so that each response must pass one of the checks.
+func Or(checks ...Check) Check {
+	return combine("||", checks, func(results []bool) bool {
+		for _, ok := range results {
+			if ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not returns a Check which passes if the check fails. It is a check of each response if the check is.
+func Not(check Check) Check {
+	description := "!(" + check.Description + ")"
+	if check.isResponseCheck() {
+		return ResponseCheck(description, func(r *client.ParsedResponse) (bool, string) {
+			ok, actual := check.response(r)
+			return !ok, actual
+		})
+	}
+	return AggregateCheck(description, func(responses client.ParsedResponses) (bool, string) {
+		ok, actual := check.all(responses)
+		return !ok, actual
+	})
+}
+
+func combine(operator string, checks []Check, combineResults func([]bool) bool) Check {
+	descriptions := make([]string, 0, len(checks))
+	perResponse := true
+	for _, c := range checks {
+		descriptions = append(descriptions, "("+c.Description+")")
+		perResponse = perResponse && c.isResponseCheck()
+	}
+	description := strings.Join(descriptions, " "+operator+" ")
+
+	// evalAll returns the results of the checks, and the actual values of the failed ones.
+	evalAll := func(eval func(Check) (bool, string)) (bool, string) {
+		results := make([]bool, 0, len(checks))
+		var actuals []string
+		for _, c := range checks {
+			ok, actual := eval(c)
+			results = append(results, ok)
+			if !ok {
+				actuals = append(actuals, actual)
+			}
+		}
+		return combineResults(results), strings.Join(actuals, ", ")
+	}
+	if perResponse {
+		return ResponseCheck(description, func(r *client.ParsedResponse) (bool, string) {
+			return evalAll(func(c Check) (bool, string) {
+				return c.response(r)
+			})
+		})
+	}
+	return AggregateCheck(description, func(responses client.ParsedResponses) (bool, string) {
+		return evalAll(func(c Check) (bool, string) {
+			return c.eval(responses)
+		})
+	})
+}
+
+// Mismatch is a response, or all the responses, failing a check.
+type Mismatch struct {
+	// Response is the index of the failing response, or -1 for a check of all the responses.
+	Response int
+	// Expected is the description of the failed check.
+	Expected string
+	// Actual is the value which failed the check.
+	Actual string
+}
+
+func (m Mismatch) String() string {
+	target := "responses"
+	if m.Response >= 0 {
+		target = fmt.Sprintf("response[%d]", m.Response)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", target, m.Expected, m.Actual)
+}
+
+// CheckError is the error of a failed Check. It lists every failing response.
+type CheckError struct {
+	Mismatches []Mismatch
+	Responses  client.ParsedResponses
+}
+
+func (e *CheckError) Error() string {
+	lines := make([]string, 0, len(e.Mismatches)+1)
+	lines = append(lines, fmt.Sprintf("%d of %d responses failed the checks:", len(e.failedResponses()), len(e.Responses)))
+	for _, m := range e.Mismatches {
+		lines = append(lines, "  "+m.String())
+	}
+	// The first failing response is usually enough to diagnose the others.
+	if m := e.Mismatches[0]; m.Response >= 0 {
+		lines = append(lines, fmt.Sprintf("response[%d]:\n%s", m.Response, e.Responses[m.Response]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *CheckError) failedResponses() map[int]bool {
+	out := map[int]bool{}
+	for _, m := range e.Mismatches {
+		if m.Response < 0 {
+			for i := range e.Responses {
+				out[i] = true
+			}
+			return out
+		}
+		out[m.Response] = true
+	}
+	return out
+}
+
+// Validator returns a Validator which fails with a *CheckError if the check fails, or if there is no response.
+func (c Check) Validator() Validator {
+	return func(responses client.ParsedResponses) error {
+		if len(responses) == 0 {
+			return fmt.Errorf("no responses received")
+		}
+		var mismatches []Mismatch
+		if c.isResponseCheck() {
+			for i, r := range responses {
+				if ok, actual := c.response(r); !ok {
+					mismatches = append(mismatches, Mismatch{Response: i, Expected: c.Description, Actual: actual})
+				}
+			}
+		} else if ok, actual := c.all(responses); !ok {
+			mismatches = append(mismatches, Mismatch{Response: -1, Expected: c.Description, Actual: actual})
+		}
+		if len(mismatches) == 0 {
+			return nil
+		}
+		return &CheckError{Mismatches: mismatches, Responses: responses}
+	}
+}
+
+// fieldCheck returns a Check that a field of each response has the expected value.
+func fieldCheck(field, expected string, get func(r *client.ParsedResponse) string) Check {
+	return ResponseCheck(fmt.Sprintf("%s == %q", field, expected), func(r *client.ParsedResponse) (bool, string) {
+		actual := get(r)
+		return actual == expected, fmt.Sprintf("%s %q", field, actual)
+	})
+}
+
+// CodeIs returns a Check that each response has the given status code.
+func CodeIs(code string) Check {
+	return fieldCheck("code", code, func(r *client.ParsedResponse) string {
+		return r.Code
+	})
+}
+
+// IsOK returns a Check that each response is successful.
+func IsOK() Check {
+	return ResponseCheck("code == 200", func(r *client.ParsedResponse) (bool, string) {
+		return r.IsOK(), fmt.Sprintf("code %q", r.Code)
+	})
+}
+
+// HostIs returns a Check that each response was for the given host.
+func HostIs(host string) Check {
+	return fieldCheck("host", host, func(r *client.ParsedResponse) string {
+		return r.Host
+	})
+}
+
+// PortIs returns a Check that each response came from the given port.
+func PortIs(port int) Check {
+	return fieldCheck("port", strconv.Itoa(port), func(r *client.ParsedResponse) string {
+		return r.Port
+	})
+}
+
+// VersionIs returns a Check that each response came from the given version of the workloads.
+func VersionIs(version string) Check {
+	return fieldCheck("version", version, func(r *client.ParsedResponse) string {
+		return r.Version
+	})
+}
+
+// ClusterIs returns a Check that each response came from the given cluster.
+func ClusterIs(cluster string) Check {
+	return fieldCheck("cluster", cluster, func(r *client.ParsedResponse) string {
+		return r.Cluster
+	})
+}
+
+// HeaderIs returns a Check that each response has the given value for the key of its raw response, such as a
+// request header echoed by the server.
+func HeaderIs(key, value string) Check {
+	return fieldCheck("header "+key, value, func(r *client.ParsedResponse) string {
+		return r.RawResponse[key]
+	})
+}
+
+// CountIs returns a Check that there is the given number of responses.
+func CountIs(count int) Check {
+	return AggregateCheck(fmt.Sprintf("%d responses", count), func(responses client.ParsedResponses) (bool, string) {
+		return len(responses) == count, fmt.Sprintf("%d responses", len(responses))
+	})
+}
+
+// ReachedClusters returns a Check that the responses came from all the given clusters.
+func ReachedClusters(clusters resource.Clusters) Check {
+	return AggregateCheck(fmt.Sprintf("responses from %v", clusters.Names()),
+		func(responses client.ParsedResponses) (bool, string) {
+			err := responses.CheckReachedClusters(clusters)
+			if err != nil {
+				return false, err.Error()
+			}
+			return true, fmt.Sprintf("responses from %v", clusters.Names())
+		})
+}
@@ -0,0 +1,116 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+func TestCheck(t *testing.T) {
+	responses := client.ParsedResponses{
+		{Code: "200", Cluster: "cluster-0", Version: "v1"},
+		{Code: "503", Cluster: "cluster-1", Version: "v1"},
+		{Code: "200", Cluster: "cluster-1", Version: "v2"},
+	}
+	cases := []struct {
+		name       string
+		check      Check
+		mismatches []Mismatch
+	}{
+		{
+			name:  "response check",
+			check: IsOK(),
+			mismatches: []Mismatch{
+				{Response: 1, Expected: "code == 200", Actual: `code "503"`},
+			},
+		},
+		{
+			name:  "and",
+			check: And(IsOK(), VersionIs("v1")),
+			mismatches: []Mismatch{
+				{Response: 1, Expected: `(code == 200) && (version == "v1")`, Actual: `code "503"`},
+				{Response: 2, Expected: `(code == 200) && (version == "v1")`, Actual: `version "v2"`},
+			},
+		},
+		{
+			name:  "or",
+			check: Or(ClusterIs("cluster-0"), VersionIs("v2")),
+			mismatches: []Mismatch{
+				{Response: 1, Expected: `(cluster == "cluster-0") || (version == "v2")`, Actual: `cluster "cluster-1", version "v1"`},
+			},
+		},
+		{
+			name:  "not",
+			check: Not(ClusterIs("cluster-1")),
+			mismatches: []Mismatch{
+				{Response: 1, Expected: `!(cluster == "cluster-1")`, Actual: `cluster "cluster-1"`},
+				{Response: 2, Expected: `!(cluster == "cluster-1")`, Actual: `cluster "cluster-1"`},
+			},
+		},
+		{
+			name:  "aggregate",
+			check: And(CountIs(3), IsOK()),
+			mismatches: []Mismatch{
+				{Response: -1, Expected: "(3 responses) && (code == 200)", Actual: `response[1]: code "503"`},
+			},
+		},
+		{
+			name:  "passing",
+			check: Or(CountIs(2), Not(CodeIs("404"))),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.check.Validator()(responses)
+			if len(c.mismatches) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var checkErr *CheckError
+			if !errors.As(err, &checkErr) {
+				t.Fatalf("expected a check error, got %v", err)
+			}
+			if len(checkErr.Mismatches) != len(c.mismatches) {
+				t.Fatalf("got mismatches %v, want %v", checkErr.Mismatches, c.mismatches)
+			}
+			for i, m := range checkErr.Mismatches {
+				if m != c.mismatches[i] {
+					t.Fatalf("got mismatch %v, want %v", m, c.mismatches[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckErrorMessage(t *testing.T) {
+	err := IsOK().Validator()(client.ParsedResponses{{Code: "200"}, {Code: "503", Body: "upstream reset"}})
+	if err == nil {
+		t.Fatal("expected the check to fail")
+	}
+	for _, want := range []string{"1 of 2 responses failed", `response[1]: expected code == 200, got code "503"`, "upstream reset"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in the error, got:\n%v", want, err)
+		}
+	}
+	if err := IsOK().Validator()(nil); err == nil {
+		t.Fatal("expected no responses to fail the check")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package churn exercises proxies with connection and endpoint churn in the background while a test runs its
+// assertions: many short lived connections are opened in parallel while the endpoints of the destination are
+// rotated, and the memory of selected proxies is compared before and after.
+package churn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	defaultWorkers        = 4
+	defaultRotateInterval = 20 * time.Second
+	// memoryStat is the gauge of the memory allocated by Envoy.
+	memoryStat = "envoy_server_memory_allocated"
+)
+
+// Config of a churn Generator.
+type Config struct {
+	// Traffic sent by each worker. As the echo client opens new connections for every call, each request is
+	// sent over a new connection.
+	Traffic traffic.Config
+
+	// Workers is the number of parallel traffic generators. Defaults to 4.
+	Workers int
+
+	// Rotate is called every RotateInterval to change the endpoints of the destination, for example with
+	// ScaleRotator. Nothing is rotated if nil.
+	Rotate func() error
+
+	// RotateInterval defaults to 20s.
+	RotateInterval time.Duration
+
+	// Sidecars whose memory is sampled when the generator starts and stops.
+	Sidecars []echo.Sidecar
+}
+
+// Result of a churn Generator run.
+type Result struct {
+	// Traffic is the sum of the results of all the workers.
+	Traffic traffic.Result
+	// Rotations is the number of rotations that succeeded.
+	Rotations int
+	// RotationError holds the rotation failures, or is nil if all succeeded.
+	RotationError error
+	// MemoryBefore and MemoryAfter are the bytes allocated by each of the sampled sidecars, by node ID, when the
+	// generator started and stopped.
+	MemoryBefore map[string]float64
+	MemoryAfter  map[string]float64
+}
+
+// CheckMemoryGrowth checks that the memory allocated by each of the sampled sidecars did not grow by more than
+// the given factor, for example 1.5 for 50%.
+func (r Result) CheckMemoryGrowth(maxFactor float64) error {
+	var errs error
+	for id, before := range r.MemoryBefore {
+		after, ok := r.MemoryAfter[id]
+		if !ok || before == 0 {
+			continue
+		}
+		if after/before > maxFactor {
+			errs = multierror.Append(errs, fmt.Errorf("memory of %s grew from %.0f to %.0f bytes, above %.2fx",
+				id, before, after, maxFactor))
+		}
+	}
+	return errs
+}
+
+// CheckMemoryGrowthOrFail calls CheckMemoryGrowth and fails the test if an error is returned.
+func (r Result) CheckMemoryGrowthOrFail(t test.Failer, maxFactor float64) {
+	t.Helper()
+	if err := r.CheckMemoryGrowth(maxFactor); err != nil {
+		t.Fatalf("churn.CheckMemoryGrowthOrFail: %v", err)
+	}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s, %d rotations", r.Traffic, r.Rotations)
+}
+
+// Generator churns connections and endpoints in the background until stopped.
+type Generator interface {
+	// Start churning. It must only be called once.
+	Start() (Generator, error)
+
+	// Stop churning, and return the result once the last request and rotation completed.
+	Stop() (Result, error)
+}
+
+// NewGenerator returns a Generator with the given configuration.
+func NewGenerator(cfg Config) Generator {
+	if cfg.Workers == 0 {
+		cfg.Workers = defaultWorkers
+	}
+	if cfg.RotateInterval == 0 {
+		cfg.RotateInterval = defaultRotateInterval
+	}
+	return &generator{
+		Config:  cfg,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+type generator struct {
+	Config
+
+	workers []traffic.Generator
+	result  Result
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (g *generator) Start() (Generator, error) {
+	var err error
+	if g.result.MemoryBefore, err = SampleMemory(g.Sidecars); err != nil {
+		return nil, err
+	}
+	for i := 0; i < g.Workers; i++ {
+		g.workers = append(g.workers, traffic.NewGenerator(g.Traffic).Start())
+	}
+	go func() {
+		defer close(g.stopped)
+		if g.Rotate == nil {
+			<-g.stop
+			return
+		}
+		t := time.NewTicker(g.RotateInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-g.stop:
+				return
+			case <-t.C:
+				if err := g.Rotate(); err != nil {
+					g.result.RotationError = multierror.Append(g.result.RotationError, err)
+					scopes.Framework.Warnf("endpoint rotation failed: %v", err)
+					continue
+				}
+				g.result.Rotations++
+			}
+		}
+	}()
+	return g, nil
+}
+
+func (g *generator) Stop() (Result, error) {
+	close(g.stop)
+	<-g.stopped
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, w := range g.workers {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := w.Stop()
+			mu.Lock()
+			defer mu.Unlock()
+			g.result.Traffic.TotalRequests += r.TotalRequests
+			g.result.Traffic.SuccessfulRequests += r.SuccessfulRequests
+			if r.Error != nil {
+				g.result.Traffic.Error = multierror.Append(g.result.Traffic.Error, r.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var err error
+	g.result.MemoryAfter, err = SampleMemory(g.Sidecars)
+	scopes.Framework.Infof("churn generator stopped: %s", g.result)
+	return g.result, err
+}
+
+// SampleMemory returns the bytes allocated by each of the sidecars, by node ID.
+func SampleMemory(sidecars []echo.Sidecar) (map[string]float64, error) {
+	out := make(map[string]float64, len(sidecars))
+	for _, s := range sidecars {
+		stats, err := s.Stats()
+		if err != nil {
+			return nil, fmt.Errorf("failed getting stats of %s: %v", s.NodeID(), err)
+		}
+		mf, ok := stats[memoryStat]
+		if !ok || len(mf.GetMetric()) == 0 {
+			return nil, fmt.Errorf("no %s stat for %s", memoryStat, s.NodeID())
+		}
+		out[s.NodeID()] = mf.GetMetric()[0].GetGauge().GetValue()
+	}
+	return out, nil
+}
+
+// ScaleRotator returns a Rotate function that cycles the deployment through the given numbers of replicas, for
+// example 1, 3 to add and remove endpoints. It does not wait for the pods to be ready, so that endpoints change
+// while requests are in flight. The deployment of an echo subset is named <service>-<version>.
+func ScaleRotator(cluster resource.Cluster, ns, deployment string, replicas ...int) func() error {
+	i := 0
+	return func() error {
+		if len(replicas) == 0 {
+			return nil
+		}
+		next := replicas[i%len(replicas)]
+		i++
+		deployments := cluster.AppsV1().Deployments(ns)
+		s, err := deployments.GetScale(context.TODO(), deployment, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return err
+		}
+		s.Spec.Replicas = int32(next)
+		if _, err := deployments.UpdateScale(context.TODO(), deployment, s, kubeApiMeta.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed scaling %s/%s to %d: %v", ns, deployment, next, err)
+		}
+		scopes.Framework.Infof("scaled %s/%s to %d replicas", ns, deployment, next)
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package churn
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+)
+
+type fakeCaller struct {
+	calls int32
+}
+
+func (c *fakeCaller) Call(echo.CallOptions) (client.ParsedResponses, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return client.ParsedResponses{{Code: "200"}}, nil
+}
+
+func TestGenerator(t *testing.T) {
+	caller := &fakeCaller{}
+	rotations := 0
+	g, err := NewGenerator(Config{
+		Traffic: traffic.Config{Source: caller, Interval: time.Millisecond},
+		Workers: 2,
+		Rotate: func() error {
+			rotations++
+			if rotations%2 == 0 {
+				return errors.New("scale failed")
+			}
+			return nil
+		},
+		RotateInterval: 5 * time.Millisecond,
+	}).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	result, err := g.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Traffic.TotalRequests == 0 || int32(result.Traffic.TotalRequests) != atomic.LoadInt32(&caller.calls) {
+		t.Fatalf("expected all %d calls of both workers to be counted, got %d", caller.calls, result.Traffic.TotalRequests)
+	}
+	if result.Rotations == 0 || result.Rotations != (rotations+1)/2 {
+		t.Fatalf("expected %d successful rotations, got %d", (rotations+1)/2, result.Rotations)
+	}
+	if rotations > 1 && result.RotationError == nil {
+		t.Fatal("expected rotation failures to be reported")
+	}
+}
+
+func TestCheckMemoryGrowth(t *testing.T) {
+	r := Result{
+		MemoryBefore: map[string]float64{"a": 100, "b": 100, "c": 0},
+		MemoryAfter:  map[string]float64{"a": 120, "b": 200, "c": 500},
+	}
+	if err := r.CheckMemoryGrowth(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckMemoryGrowth(1.5); err == nil {
+		t.Fatal("expected growth of b to fail the check")
+	}
+}
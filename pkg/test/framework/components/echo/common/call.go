@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/echo/client"
@@ -187,6 +188,23 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 		opts.Headers = make(http.Header)
 	}
 
+	if opts.Trace != nil {
+		for k, v := range opts.Trace.Headers() {
+			opts.Headers[k] = v
+		}
+	}
+
+	if len(opts.Chain) > 0 {
+		if opts.Scheme != scheme.HTTP {
+			return fmt.Errorf("callOptions: a call chain requires scheme %s, got %s", scheme.HTTP, opts.Scheme)
+		}
+		hops, err := chainURLs(opts.Chain)
+		if err != nil {
+			return err
+		}
+		opts.Headers.Set(common.ForwardHeader, strings.Join(hops, ","))
+	}
+
 	if opts.Host == "" {
 		// No host specified, use the fully qualified domain name for the service.
 		opts.Host = opts.Target.Config().FQDN()
@@ -213,6 +231,38 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 	return nil
 }
 
+// chainURLs returns the URLs the hops of a call chain are called with.
+func chainURLs(chain []echo.Hop) ([]string, error) {
+	out := make([]string, 0, len(chain))
+	for i, hop := range chain {
+		if hop.Target == nil {
+			return nil, fmt.Errorf("callOptions: chain hop %d has no target", i)
+		}
+		name := hop.PortName
+		if name == "" {
+			name = "http"
+		}
+		var port *echo.Port
+		for _, p := range hop.Target.Config().Ports {
+			if p.Name == name {
+				p := p
+				port = &p
+				break
+			}
+		}
+		if port == nil {
+			return nil, fmt.Errorf("callOptions: no port named %s available in chain hop %s", name, hop.Target.Config().Service)
+		}
+		if port.Protocol != protocol.HTTP {
+			return nil, fmt.Errorf("callOptions: port %s of chain hop %s must use HTTP, got %s",
+				name, hop.Target.Config().Service, port.Protocol)
+		}
+		host := net.JoinHostPort(hop.Target.Config().FQDN(), strconv.Itoa(port.ServicePort))
+		out = append(out, fmt.Sprintf("http://%s/", host))
+	}
+	return out, nil
+}
+
 func schemeForPort(port *echo.Port) (scheme.Instance, error) {
 	switch port.Protocol {
 	case protocol.GRPC, protocol.GRPCWeb, protocol.HTTP2:
@@ -41,9 +41,14 @@ type Config struct {
 	// Version indicates the version path for calls to the Echo application.
 	Version string
 
-	// Locality (k8s only) indicates the locality of the deployed app.
+	// Locality (k8s only) indicates the locality of the deployed app, set through the istio-locality label.
+	// Either the region.zone.subzone or region/zone/subzone form may be used. See Locality.
 	Locality string
 
+	// NodeSelector (k8s only) restricts the nodes the app is scheduled to. It can be used to place the app
+	// into the locality of labeled nodes, see Locality.NodeSelector.
+	NodeSelector map[string]string
+
 	// Headless (k8s only) indicates that no ClusterIP should be specified.
 	Headless bool
 
@@ -85,6 +90,11 @@ type Config struct {
 
 	// The set of environment variables to set for `DeployAsVM` instances.
 	VMEnvironment map[string]string
+
+	// If enabled, the `DeployAsVM` instances run in docker containers outside of Kubernetes rather than in pods,
+	// and are onboarded with the files generated by `istioctl x workload entry configure`, as a real VM would.
+	// The containers are attached to the docker network of the cluster nodes, as with kind.
+	SimulatedVM bool
 }
 
 // SubsetConfig is the config for a group of Subsets (e.g. Kubernetes deployment).
@@ -93,6 +103,8 @@ type SubsetConfig struct {
 	Version string
 	// Annotations provides metadata hints for deployment of the instance.
 	Annotations Annotations
+	// Locality of the deployment, overriding the Locality of the Config.
+	Locality string
 	// TODO: port more into workload config.
 }
 
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/echo/proto"
+)
+
+// autoAllocatedRange is the range addresses are allocated from to ServiceEntries without addresses, for
+// proxies with DNS capture enabled.
+var autoAllocatedRange = &net.IPNet{IP: net.IPv4(240, 240, 0, 0), Mask: net.CIDRMask(16, 32)}
+
+// IsAutoAllocatedAddress returns true if the address was auto allocated by Istio to a ServiceEntry.
+func IsAutoAllocatedAddress(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && autoAllocatedRange.Contains(ip)
+}
+
+// ResolveFrom resolves the name from the workload, through its DNS servers. If DNS capture is enabled
+// for the sidecar of the workload, the query is answered by its DNS proxy.
+func ResolveFrom(w Workload, name string) ([]string, error) {
+	resp, err := w.ForwardEcho(context.TODO(), &proto.ForwardEchoRequest{
+		Url:   fmt.Sprintf("%s://%s", scheme.DNS, name),
+		Count: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving %s from %s: %v", name, w.Address(), err)
+	}
+	if len(resp) != 1 {
+		return nil, fmt.Errorf("failed resolving %s from %s: expected 1 response, got %d", name, w.Address(), len(resp))
+	}
+	addrs := resp[0].ResolvedAddresses
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// Resolve resolves the name from every workload of the instances, and returns the addresses it was
+// resolved to. It fails if the workloads did not all resolve the name to the same addresses.
+func Resolve(from Instances, name string) ([]string, error) {
+	var out []string
+	var first string
+	for _, i := range from {
+		workloads, err := i.Workloads()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get workloads of %s: %v", i.Config().Service, err)
+		}
+		for _, w := range workloads {
+			addrs, err := ResolveFrom(w, name)
+			if err != nil {
+				return nil, err
+			}
+			if len(addrs) == 0 {
+				return nil, fmt.Errorf("%s resolved to no addresses from %s", name, w.Address())
+			}
+			if out == nil {
+				out, first = addrs, w.Address()
+				continue
+			}
+			if strings.Join(addrs, ",") != strings.Join(out, ",") {
+				return nil, fmt.Errorf("%s resolved to %v from %s, but to %v from %s", name, addrs, w.Address(), out, first)
+			}
+		}
+	}
+	if out == nil {
+		return nil, fmt.Errorf("no workloads to resolve %s from", name)
+	}
+	return out, nil
+}
+
+// ResolveOrFail calls Resolve and fails the test if an error is returned.
+func ResolveOrFail(t test.Failer, from Instances, name string) []string {
+	t.Helper()
+	addrs, err := Resolve(from, name)
+	if err != nil {
+		t.Fatalf("echo.ResolveOrFail: %v", err)
+	}
+	return addrs
+}
+
+// CheckAutoAllocatedVIP resolves the host of a ServiceEntry without addresses from every workload of the
+// instances, the given number of times. It checks that the host always resolves to the same single address,
+// auto allocated by Istio, and returns it.
+func CheckAutoAllocatedVIP(from Instances, host string, times int) (string, error) {
+	var vip string
+	for n := 0; n < times || n == 0; n++ {
+		addrs, err := Resolve(from, host)
+		if err != nil {
+			return "", err
+		}
+		if len(addrs) != 1 || !IsAutoAllocatedAddress(addrs[0]) {
+			return "", fmt.Errorf("expected %s to resolve to an auto allocated address in %s, got %v",
+				host, autoAllocatedRange, addrs)
+		}
+		if vip != "" && addrs[0] != vip {
+			return "", fmt.Errorf("auto allocated address of %s changed from %s to %s", host, vip, addrs[0])
+		}
+		vip = addrs[0]
+	}
+	return vip, nil
+}
+
+// CheckAutoAllocatedVIPOrFail calls CheckAutoAllocatedVIP and fails the test if an error is returned.
+func CheckAutoAllocatedVIPOrFail(t test.Failer, from Instances, host string, times int) string {
+	t.Helper()
+	vip, err := CheckAutoAllocatedVIP(from, host, times)
+	if err != nil {
+		t.Fatalf("echo.CheckAutoAllocatedVIPOrFail: %v", err)
+	}
+	return vip
+}
@@ -44,7 +44,8 @@ import (
 //        call `source.WaitUntilCallable(destination)`.
 type Builder interface {
 	// With adds a new Echo configuration to the Builder. Once built, the instance
-	// pointer will be updated to point at the new Instance.
+	// pointer will be updated to point at the new Instance. Configs targeting a
+	// config-only cluster are skipped if the pointer is nil, and fail Build otherwise.
 	With(i *Instance, cfg Config) Builder
 
 	// Build and initialize all Echo Instances. Upon returning, the Instance pointers
@@ -91,6 +92,10 @@ type WorkloadPort struct {
 
 	// ServerFirst determines whether the port will use server first communication, meaning the client will not send the first byte.
 	ServerFirst bool
+
+	// AppProtocol is set as the appProtocol of the Kubernetes service port, which takes precedence over the
+	// port name when selecting the protocol of the port. It is not set by default.
+	AppProtocol string
 }
 
 // Port exposed by an Echo Instance
@@ -115,6 +120,10 @@ type Port struct {
 
 	// ServerFirst determines whether the port will use server first communication, meaning the client will not send the first byte.
 	ServerFirst bool
+
+	// AppProtocol is set as the appProtocol of the Kubernetes service port, which takes precedence over the
+	// port name when selecting the protocol of the port. It is not set by default.
+	AppProtocol string
 }
 
 // Workload provides an interface for a single deployed echo server.
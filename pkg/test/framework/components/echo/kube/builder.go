@@ -23,6 +23,7 @@ import (
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	kubeEnv "istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/kube"
 	"istio.io/istio/pkg/test/scopes"
@@ -35,6 +36,8 @@ type builder struct {
 	ctx        resource.Context
 	references []*echo.Instance
 	configs    []echo.Config
+	// errs are the errors of the configs added, returned by Build.
+	errs error
 }
 
 func NewBuilder(ctx resource.Context) echo.Builder {
@@ -44,12 +47,28 @@ func NewBuilder(ctx resource.Context) echo.Builder {
 }
 
 func (b *builder) With(i *echo.Instance, cfg echo.Config) echo.Builder {
+	// Config-only clusters cannot run workloads, so tests deploying to every cluster skip them. The instance
+	// would never be assigned, so only configs without an instance pointer are skipped.
+	if env, ok := b.ctx.Environment().(*kubeEnv.Environment); ok {
+		if cluster := b.ctx.Clusters().GetOrDefault(cfg.Cluster); env.IsConfigOnlyCluster(cluster) {
+			if i != nil {
+				b.errs = multierror.Append(b.errs, fmt.Errorf("echo %s cannot be deployed to config-only cluster %s",
+					cfg.Service, cluster.Name()))
+				return b
+			}
+			scopes.Framework.Infof("skipping echo %s in config-only cluster %s", cfg.Service, cluster.Name())
+			return b
+		}
+	}
 	b.references = append(b.references, i)
 	b.configs = append(b.configs, cfg)
 	return b
 }
 
 func (b *builder) Build() (echo.Instances, error) {
+	if b.errs != nil {
+		return nil, b.errs
+	}
 	t0 := time.Now()
 	instances, err := b.newInstances()
 	if err != nil {
@@ -108,6 +127,14 @@ func (b *builder) initializeInstances(instances []echo.Instance) error {
 		// Run the waits in parallel.
 		go func() {
 			defer wg.Done()
+			if inst.Config().SimulatedVM {
+				if err := inst.(*instance).initializeSimulatedVMs(); err != nil {
+					aggregateErrMux.Lock()
+					aggregateErr = multierror.Append(aggregateErr, fmt.Errorf("initialize %v/%v: %v", inst.ID(), inst.Config().Service, err))
+					aggregateErrMux.Unlock()
+				}
+				return
+			}
 			selector := "app"
 			if inst.Config().DeployAsVM {
 				selector = "istio.io/test-vm"
@@ -60,6 +60,9 @@ spec:
   - name: {{ $p.Name }}
     port: {{ $p.ServicePort }}
     targetPort: {{ $p.InstancePort }}
+{{- if $p.AppProtocol }}
+    appProtocol: {{ $p.AppProtocol }}
+{{- end }}
 {{- end }}
   selector:
     app: {{ .Service }}
@@ -79,16 +82,16 @@ spec:
     matchLabels:
       app: {{ $.Service }}
       version: {{ $subset.Version }}
-{{- if ne $.Locality "" }}
-      istio-locality: {{ $.Locality }}
+{{- if ne $subset.Locality "" }}
+      istio-locality: {{ $subset.Locality }}
 {{- end }}
   template:
     metadata:
       labels:
         app: {{ $.Service }}
         version: {{ $subset.Version }}
-{{- if ne $.Locality "" }}
-        istio-locality: {{ $.Locality }}
+{{- if ne $subset.Locality "" }}
+        istio-locality: {{ $subset.Locality }}
 {{- end }}
       annotations:
         prometheus.io/scrape: "true"
@@ -99,6 +102,16 @@ spec:
     spec:
 {{- if $.ServiceAccount }}
       serviceAccountName: {{ $.Service }}
+{{- end }}
+{{- if $.PullSecret }}
+      imagePullSecrets:
+      - name: {{ $.PullSecret }}
+{{- end }}
+{{- if $.NodeSelector }}
+      nodeSelector:
+{{- range $k, $v := $.NodeSelector }}
+        {{ $k }}: {{ printf "%q" $v }}
+{{- end }}
 {{- end }}
       containers:
       - name: app
@@ -241,6 +254,10 @@ spec:
           value: "1"
       # Disable service account mount, to mirror VM
       automountServiceAccountToken: false
+{{- if $.PullSecret }}
+      imagePullSecrets:
+      - name: {{ $.PullSecret }}
+{{- end }}
       containers:
       - name: istio-proxy
         image: {{ $.Hub }}/{{ $.VM.Image }}:{{ $.Tag }}
@@ -402,6 +419,11 @@ func generateYAMLWithSettings(
 		if cfg.Subsets[i].Version == "" {
 			cfg.Subsets[i].Version = "v1"
 		}
+		if cfg.Subsets[i].Locality == "" {
+			cfg.Subsets[i].Locality = cfg.Locality
+		}
+		// Kubernetes labels can't contain "/"
+		cfg.Subsets[i].Locality = strings.ReplaceAll(cfg.Subsets[i].Locality, "/", ".")
 	}
 
 	var vmImage, istiodIP, istiodPort string
@@ -431,12 +453,14 @@ func generateYAMLWithSettings(
 	}
 	params := map[string]interface{}{
 		"Hub":                settings.Hub,
-		"Tag":                strings.TrimSuffix(settings.Tag, "-distroless"),
+		"Tag":                settings.BaseTag(),
 		"PullPolicy":         settings.PullPolicy,
+		"PullSecret":         settings.PullSecretName(),
 		"Service":            cfg.Service,
 		"Version":            cfg.Version,
 		"Headless":           cfg.Headless,
 		"Locality":           cfg.Locality,
+		"NodeSelector":       cfg.NodeSelector,
 		"ServiceAccount":     cfg.ServiceAccount,
 		"Ports":              cfg.Ports,
 		"WorkloadOnlyPorts":  cfg.WorkloadOnlyPorts,
@@ -60,6 +60,9 @@ type instance struct {
 	ctx       resource.Context
 	tls       *echoCommon.TLSSettings
 	cluster   resource.Cluster
+
+	// vms are the docker containers of SimulatedVM instances.
+	vms []*simulatedVM
 }
 
 func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err error) {
@@ -98,13 +101,21 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 			cfg.FQDN(), err)
 	}
 
-	// Deploy the YAML.
-	if err = ctx.Config(c.cluster).ApplyYAML(cfg.Namespace.Name(), deploymentYAML); err != nil {
+	if cfg.SimulatedVM {
+		// The VMs run outside of the cluster, in place of the deployment.
+		if !cfg.DeployAsVM {
+			return nil, fmt.Errorf("echo %s: SimulatedVM requires DeployAsVM", cfg.FQDN())
+		}
+		if err := c.deploySimulatedVMs(); err != nil {
+			return nil, err
+		}
+	} else if err = ctx.Config(c.cluster).ApplyYAML(cfg.Namespace.Name(), deploymentYAML); err != nil {
+		// Deploy the YAML.
 		return nil, fmt.Errorf("failed deploying echo %s to cluster %s: %v",
 			cfg.FQDN(), c.cluster.Name(), err)
 	}
 
-	if cfg.DeployAsVM {
+	if cfg.DeployAsVM && !cfg.SimulatedVM {
 		serviceAccount := cfg.Service
 		if !cfg.ServiceAccount {
 			serviceAccount = "default"
@@ -133,7 +144,7 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 		}
 	}
 
-	if cfg.DeployAsVM {
+	if cfg.DeployAsVM && !cfg.SimulatedVM {
 		var pods *kubeCore.PodList
 		if err := retry.UntilSuccess(func() error {
 			pods, err = c.cluster.PodsForSelector(context.TODO(), cfg.Namespace.Name(),
@@ -160,19 +171,7 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 
 		// One workload entry for each VM pod
 		for _, vmPod := range pods.Items {
-			wle := fmt.Sprintf(`
-apiVersion: networking.istio.io/v1alpha3
-kind: WorkloadEntry
-metadata:
-  name: %s
-spec:
-  address: %s
-  serviceAccount: %s
-  network: %q
-  labels:
-    app: %s
-    version: %s
-`, vmPod.Name, vmPod.Status.PodIP, serviceAccount, cfg.Cluster.NetworkName(), cfg.Service, vmPod.Labels["istio.io/test-vm-version"])
+			wle := workloadEntryYAML(cfg, vmPod.Name, vmPod.Status.PodIP, serviceAccount, vmPod.Labels["istio.io/test-vm-version"])
 			// Deploy the workload entry.
 			if err = ctx.Config().ApplyYAML(cfg.Namespace.Name(), wle); err != nil {
 				return nil, fmt.Errorf("failed deploying workload entry: %v", err)
@@ -201,6 +200,43 @@ spec:
 	return c, nil
 }
 
+// deploySimulatedVMs starts the simulated VMs of the instance, and registers them with workload entries.
+func (c *instance) deploySimulatedVMs() error {
+	vms, err := deploySimulatedVMs(c.ctx, c.cfg, c.cluster)
+	// Track the VMs even on failure, so that they are removed on close.
+	c.vms = vms
+	if err != nil {
+		return fmt.Errorf("failed deploying simulated VMs of echo %s: %v", c.cfg.FQDN(), err)
+	}
+	serviceAccount := c.cfg.Service
+	if !c.cfg.ServiceAccount {
+		serviceAccount = "default"
+	}
+	for _, vm := range vms {
+		wle := workloadEntryYAML(c.cfg, vm.name, vm.ip, serviceAccount, vm.version)
+		if err := c.ctx.Config().ApplyYAML(c.cfg.Namespace.Name(), wle); err != nil {
+			return fmt.Errorf("failed deploying workload entry: %v", err)
+		}
+	}
+	return nil
+}
+
+func workloadEntryYAML(cfg echo.Config, name, address, serviceAccount, version string) string {
+	return fmt.Sprintf(`
+apiVersion: networking.istio.io/v1alpha3
+kind: WorkloadEntry
+metadata:
+  name: %s
+spec:
+  address: %s
+  serviceAccount: %s
+  network: %q
+  labels:
+    app: %s
+    version: %s
+`, name, address, serviceAccount, cfg.Cluster.NetworkName(), cfg.Service, version)
+}
+
 func createServiceAccountToken(client kubernetes.Interface, ns string, serviceAccount string) (string, error) {
 	scopes.Framework.Debugf("Creating service account token for: %s/%s", ns, serviceAccount)
 
@@ -325,11 +361,37 @@ func (c *instance) initialize(pods []kubeCore.Pod) error {
 	return nil
 }
 
+// initializeSimulatedVMs creates the workloads of the simulated VMs, once their sidecar is ready.
+func (c *instance) initializeSimulatedVMs() error {
+	if c.workloads != nil {
+		// Already ready.
+		return nil
+	}
+
+	workloads := make([]*workload, 0, len(c.vms))
+	for _, vm := range c.vms {
+		if err := vm.waitReady(c.cfg.ReadinessTimeout); err != nil {
+			return fmt.Errorf("simulated VM %s is not ready: %v", vm.name, err)
+		}
+		w, err := newSimulatedVMWorkload(vm, c.grpcPort, c.tls, c.ctx)
+		if err != nil {
+			return err
+		}
+		workloads = append(workloads, w)
+	}
+	c.workloads = workloads
+	return nil
+}
+
 func (c *instance) Close() (err error) {
 	for _, w := range c.workloads {
 		err = multierror.Append(err, w.Close()).ErrorOrNil()
 	}
 	c.workloads = nil
+	for _, vm := range c.vms {
+		err = multierror.Append(err, vm.Close()).ErrorOrNil()
+	}
+	c.vms = nil
 	return
 }
 
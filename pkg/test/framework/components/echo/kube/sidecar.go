@@ -48,6 +48,9 @@ type sidecar struct {
 	podNamespace string
 	podName      string
 	cluster      resource.Cluster
+
+	// vm is set instead of the pod for simulated VMs.
+	vm *simulatedVM
 }
 
 func newSidecar(pod kubeCore.Pod, cluster resource.Cluster) (*sidecar, error) {
@@ -56,9 +59,27 @@ func newSidecar(pod kubeCore.Pod, cluster resource.Cluster) (*sidecar, error) {
 		podName:      pod.Name,
 		cluster:      cluster,
 	}
+	if err := sidecar.init(); err != nil {
+		return nil, err
+	}
+	return sidecar, nil
+}
 
+func newSimulatedVMSidecar(vm *simulatedVM) (*sidecar, error) {
+	sidecar := &sidecar{
+		podName: vm.name,
+		vm:      vm,
+	}
+	if err := sidecar.init(); err != nil {
+		return nil, err
+	}
+	return sidecar, nil
+}
+
+// init waits for the bootstrap of Envoy, and extracts its node ID.
+func (s *sidecar) init() error {
 	// Extract the node ID from Envoy.
-	if err := sidecar.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
+	if err := s.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
 		for _, c := range cfg.Configs {
 			if c.TypeUrl == "type.googleapis.com/envoy.admin.v3.BootstrapConfigDump" {
 				cd := envoyAdmin.BootstrapConfigDump{}
@@ -66,16 +87,16 @@ func newSidecar(pod kubeCore.Pod, cluster resource.Cluster) (*sidecar, error) {
 					return false, err
 				}
 
-				sidecar.nodeID = cd.Bootstrap.Node.Id
+				s.nodeID = cd.Bootstrap.Node.Id
 				return true, nil
 			}
 		}
 		return false, errors.New("envoy Bootstrap not found in config dump")
 	}); err != nil {
-		return nil, err
+		return err
 	}
 
-	return sidecar, nil
+	return nil
 }
 
 func (s *sidecar) NodeID() string {
@@ -181,7 +202,7 @@ func (s *sidecar) StatsOrFail(t test.Failer) map[string]*dto.MetricFamily {
 func (s *sidecar) proxyStats() (map[string]*dto.MetricFamily, error) {
 	// Exec onto the pod and make a curl request to the admin port, writing
 	command := "pilot-agent request GET /stats/prometheus"
-	stdout, stderr, err := s.cluster.PodExec(s.podName, s.podNamespace, proxyContainerName, command)
+	stdout, stderr, err := s.exec(command)
 	if err != nil {
 		return nil, fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
 			s.podNamespace, s.podName, err, command, stdout+stderr)
@@ -198,7 +219,7 @@ func (s *sidecar) proxyStats() (map[string]*dto.MetricFamily, error) {
 func (s *sidecar) adminRequest(path string, out proto.Message) error {
 	// Exec onto the pod and make a curl request to the admin port, writing
 	command := fmt.Sprintf("pilot-agent request GET %s", path)
-	stdout, stderr, err := s.cluster.PodExec(s.podName, s.podNamespace, proxyContainerName, command)
+	stdout, stderr, err := s.exec(command)
 	if err != nil {
 		return fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
 			s.podNamespace, s.podName, err, command, stdout+stderr)
@@ -211,7 +232,18 @@ func (s *sidecar) adminRequest(path string, out proto.Message) error {
 	return nil
 }
 
+// exec runs the command in the proxy container, or in the simulated VM.
+func (s *sidecar) exec(command string) (string, string, error) {
+	if s.vm != nil {
+		return s.vm.exec(command)
+	}
+	return s.cluster.PodExec(s.podName, s.podNamespace, proxyContainerName, command)
+}
+
 func (s *sidecar) Logs() (string, error) {
+	if s.vm != nil {
+		return s.vm.logs()
+	}
 	return s.cluster.PodLogs(context.TODO(), s.podName, s.podNamespace, proxyContainerName, false)
 }
 
@@ -0,0 +1,286 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	kubeCore "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/istioctl"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/shell"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	// simulatedVMNetwork is the docker network of the kind cluster nodes, which simulated VMs are attached to.
+	simulatedVMNetwork = "kind"
+	// simulatedVMConfigDir is where the onboarding files are mounted in simulated VMs.
+	simulatedVMConfigDir = "/etc/istio-vm"
+
+	workloadGroupYaml = `
+apiVersion: networking.istio.io/v1alpha3
+kind: WorkloadGroup
+metadata:
+  name: {{ .Service }}
+  namespace: {{ .Namespace }}
+spec:
+  metadata:
+    labels:
+      app: {{ .Service }}
+      version: {{ .Version }}
+  template:
+    serviceAccount: {{ .ServiceAccount }}
+    network: "{{ .Network }}"
+`
+
+	// simulatedVMScript installs the onboarding files where the VM documentation puts them, then starts the
+	// sidecar and the echo server, as a VM would with systemd.
+	simulatedVMScript = `
+set -e
+sudo mkdir -p /etc/certs /var/run/secrets/tokens /etc/istio/config /etc/istio/proxy /var/lib/istio/envoy
+sudo cp {{ .ConfigDir }}/root-cert.pem /etc/certs/root-cert.pem
+sudo cp {{ .ConfigDir }}/istio-token /var/run/secrets/tokens/istio-token
+sudo cp {{ .ConfigDir }}/cluster.env /var/lib/istio/envoy/cluster.env
+sudo cp {{ .ConfigDir }}/mesh.yaml /etc/istio/config/mesh
+sudo sh -c 'cat {{ .ConfigDir }}/hosts >> /etc/hosts'
+sudo chown -R istio-proxy /var/lib/istio /etc/certs /etc/istio/proxy /etc/istio/config /var/run/secrets
+
+# Reach istiod through its remote address, and block the standard inbound ports, as the pod based VMs do.
+sudo sh -c 'echo ISTIO_PILOT_PORT={{ .IstiodPort }} >> /var/lib/istio/envoy/cluster.env'
+sudo sh -c 'echo ISTIO_LOCAL_EXCLUDE_PORTS="15090,15021,15020" >> /var/lib/istio/envoy/cluster.env'
+sudo sh -c 'echo ISTIO_META_PROXY_XDS_VIA_AGENT=true >> /var/lib/istio/envoy/cluster.env'
+sudo sh -c 'echo ISTIO_META_DNS_CAPTURE=true >> /var/lib/istio/envoy/cluster.env'
+
+# Route the pod subnets through the nodes, so that the VM can reach pods directly.
+{{- range $cidr, $node := .Routes }}
+sudo ip route add {{ $cidr }} via {{ $node }}
+{{- end }}
+
+export ISTIO_AGENT_FLAGS="--concurrency 2"
+sudo -E /usr/local/bin/istio-start.sh&
+exec /usr/local/bin/server --cluster "{{ .Cluster }}" --version "{{ .Version }}"
+{{- range $i, $p := .ContainerPorts }}
+{{- if eq .Protocol "GRPC" }} --grpc
+{{- else if eq .Protocol "TCP" }} --tcp
+{{- else }} --port
+{{- end }} "{{ $p.Port }}"
+{{- if $p.ServerFirst }} --server-first={{ $p.Port }}{{ end }}
+{{- end }}
+`
+)
+
+var simulatedVMTemplate = template.Must(template.New("simulated_vm").Parse(simulatedVMScript))
+
+// simulatedVM is a docker container acting as a VM outside of Kubernetes: it has no kubelet, service account
+// token mount or cluster DNS, and is onboarded with the files generated by istioctl.
+type simulatedVM struct {
+	name    string
+	version string
+	ip      string
+}
+
+// SimulatedVMsSupported returns an error if VMs cannot be simulated for the cluster: docker must be available
+// locally, and every node of the cluster must be a container on the kind docker network.
+func SimulatedVMsSupported(cluster resource.Cluster) error {
+	out, err := docker("network", "inspect", simulatedVMNetwork, "--format", "{{ range .Containers }}{{ .Name }} {{ end }}")
+	if err != nil {
+		return err
+	}
+	containers := map[string]bool{}
+	for _, c := range strings.Fields(out) {
+		containers[c] = true
+	}
+	nodes, err := cluster.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes.Items {
+		if !containers[n.Name] {
+			return fmt.Errorf("node %s of %s is not on the %s docker network", n.Name, cluster.Name(), simulatedVMNetwork)
+		}
+	}
+	return nil
+}
+
+// deploySimulatedVMs starts a simulated VM for each subset of the config, and returns them once they are running.
+func deploySimulatedVMs(ctx resource.Context, cfg echo.Config, cluster resource.Cluster) ([]*simulatedVM, error) {
+	if err := SimulatedVMsSupported(cluster); err != nil {
+		return nil, fmt.Errorf("cannot simulate VMs: %v", err)
+	}
+	settings, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	ist, err := istio.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := ist.RemoteDiscoveryAddressFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := podRoutes(cluster)
+	if err != nil {
+		return nil, err
+	}
+	ctl, err := istioctl.New(ctx, istioctl.Config{Cluster: cluster})
+	if err != nil {
+		return nil, err
+	}
+	vmImage := cfg.VMImage
+	if vmImage == "" {
+		vmImage = DefaultVMImage
+	}
+	serviceAccount := cfg.Service
+	if !cfg.ServiceAccount {
+		serviceAccount = "default"
+	}
+
+	var out []*simulatedVM
+	for _, subset := range cfg.Subsets {
+		version := subset.Version
+		if version == "" {
+			version = "v1"
+		}
+		dir, err := ctx.CreateTmpDirectory(fmt.Sprintf("vm-%s-%s", cfg.Service, version))
+		if err != nil {
+			return out, err
+		}
+
+		// Onboard the VM the way users do: generate its configuration from a WorkloadGroup.
+		wg, err := tmpl.Evaluate(workloadGroupYaml, map[string]string{
+			"Service":        cfg.Service,
+			"Namespace":      cfg.Namespace.Name(),
+			"Version":        version,
+			"ServiceAccount": serviceAccount,
+			"Network":        cluster.NetworkName(),
+		})
+		if err != nil {
+			return out, err
+		}
+		wgFile := filepath.Join(dir, "workloadgroup.yaml")
+		if err := ioutil.WriteFile(wgFile, []byte(wg), os.ModePerm); err != nil {
+			return out, err
+		}
+		if _, stderr, err := ctl.Invoke([]string{"x", "workload", "entry", "configure", "-f", wgFile, "-o", dir,
+			"--clusterID", cluster.Name(), "--ingressIP", addr.IP.String()}); err != nil {
+			return out, fmt.Errorf("failed generating the configuration of VM %s/%s: %v: %s", cfg.Service, version, err, stderr)
+		}
+
+		script, err := tmpl.Execute(simulatedVMTemplate, map[string]interface{}{
+			"ConfigDir":      simulatedVMConfigDir,
+			"IstiodPort":     strconv.Itoa(addr.Port),
+			"Routes":         routes,
+			"Cluster":        cluster.Name(),
+			"Version":        version,
+			"ContainerPorts": getContainerPorts(cfg.Ports),
+		})
+		if err != nil {
+			return out, err
+		}
+
+		vm := &simulatedVM{
+			name:    fmt.Sprintf("%s-%s-%s", cfg.Service, version, cfg.Namespace.Name()),
+			version: version,
+		}
+		// Remove leftovers of a previous run.
+		_, _ = docker("rm", "-f", vm.name)
+		args := []string{"run", "-d", "--name", vm.name, "--hostname", vm.name, "--network", simulatedVMNetwork,
+			"--cap-add", "NET_ADMIN", "--user", "1338:1338", "-v", dir + ":" + simulatedVMConfigDir + ":ro"}
+		for k, v := range cfg.VMEnvironment {
+			args = append(args, "-e", k+"="+v)
+		}
+		args = append(args, "--entrypoint", "bash", settings.Hub+"/"+vmImage+":"+settings.BaseTag(), "-c", script)
+		if _, err := docker(args...); err != nil {
+			return out, err
+		}
+		out = append(out, vm)
+
+		ip, err := docker("inspect", vm.name, "--format", "{{ .NetworkSettings.Networks."+simulatedVMNetwork+".IPAddress }}")
+		if err != nil {
+			return out, err
+		}
+		vm.ip = strings.TrimSpace(ip)
+	}
+	return out, nil
+}
+
+// podRoutes returns the pod subnet of each node of the cluster, with the address of the node.
+func podRoutes(cluster resource.Cluster) (map[string]string, error) {
+	nodes, err := cluster.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, n := range nodes.Items {
+		for _, a := range n.Status.Addresses {
+			if a.Type == kubeCore.NodeInternalIP && n.Spec.PodCIDR != "" {
+				out[n.Spec.PodCIDR] = a.Address
+			}
+		}
+	}
+	return out, nil
+}
+
+// waitReady waits until the sidecar of the VM is ready.
+func (vm *simulatedVM) waitReady(timeout time.Duration) error {
+	return retry.UntilSuccess(func() error {
+		_, _, err := vm.exec("pilot-agent request GET ready")
+		return err
+	}, retry.Timeout(timeout), retry.Delay(time.Second))
+}
+
+// exec runs the command, split on spaces, in the VM.
+func (vm *simulatedVM) exec(command string) (string, string, error) {
+	out, err := shell.ExecuteArgs(nil, false, "docker", append([]string{"exec", vm.name}, strings.Fields(command)...)...)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return out, string(exitErr.Stderr), err
+	}
+	return out, "", err
+}
+
+// logs returns the output of both the echo server and the sidecar.
+func (vm *simulatedVM) logs() (string, error) {
+	return shell.ExecuteArgs(nil, true, "docker", "logs", vm.name)
+}
+
+func (vm *simulatedVM) Close() error {
+	_, err := docker("rm", "-f", vm.name)
+	return err
+}
+
+func docker(args ...string) (string, error) {
+	out, err := shell.ExecuteArgs(nil, true, "docker", args...)
+	if err != nil {
+		return "", fmt.Errorf("docker %s failed: %v: %s", args[0], err, out)
+	}
+	return out, nil
+}
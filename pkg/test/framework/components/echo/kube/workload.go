@@ -17,6 +17,8 @@ package kube
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -48,6 +50,9 @@ type workload struct {
 	sidecar   *sidecar
 	cluster   resource.Cluster
 	ctx       resource.Context
+
+	// vm is set instead of pod for simulated VMs.
+	vm *simulatedVM
 }
 
 func newWorkload(pod kubeCore.Pod, sidecared bool, grpcPort uint16, cluster resource.Cluster,
@@ -94,6 +99,26 @@ func newWorkload(pod kubeCore.Pod, sidecared bool, grpcPort uint16, cluster reso
 	}, nil
 }
 
+// newSimulatedVMWorkload returns a workload for the simulated VM, whose echo server is reached directly on the
+// docker network.
+func newSimulatedVMWorkload(vm *simulatedVM, grpcPort uint16, tls *common.TLSSettings, ctx resource.Context) (*workload, error) {
+	c, err := client.New(net.JoinHostPort(vm.ip, strconv.Itoa(int(grpcPort))), tls)
+	if err != nil {
+		return nil, fmt.Errorf("grpc client: %v", err)
+	}
+	s, err := newSimulatedVMSidecar(vm)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &workload{
+		Instance: c,
+		sidecar:  s,
+		ctx:      ctx,
+		vm:       vm,
+	}, nil
+}
+
 func (w *workload) Close() (err error) {
 	if w.Instance != nil {
 		err = multierror.Append(err, w.Instance.Close()).ErrorOrNil()
@@ -114,18 +139,31 @@ func (w *workload) checkDeprecation() error {
 	}
 
 	info := fmt.Sprintf("pod: %s/%s", w.pod.Namespace, w.pod.Name)
+	if w.vm != nil {
+		info = fmt.Sprintf("vm: %s", w.vm.name)
+	}
 	return errors.FindDeprecatedMessagesInEnvoyLog(logs, info)
 }
 
 func (w *workload) Address() string {
+	if w.vm != nil {
+		return w.vm.ip
+	}
 	return w.pod.Status.PodIP
 }
 
 func (w *workload) Sidecar() echo.Sidecar {
+	if w.sidecar == nil {
+		// Return an untyped nil, so that callers can check for workloads without a sidecar.
+		return nil
+	}
 	return w.sidecar
 }
 
 func (w *workload) Logs() (string, error) {
+	if w.vm != nil {
+		return w.vm.logs()
+	}
 	return w.cluster.PodLogs(context.TODO(), w.pod.Name, w.pod.Namespace, appContainerName, false)
 }
 
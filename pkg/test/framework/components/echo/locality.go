@@ -0,0 +1,205 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+// Well known node labels that Istio reads the locality of a pod from, if it does not have an
+// istio-locality label.
+const (
+	NodeRegionLabel = "topology.kubernetes.io/region"
+	NodeZoneLabel   = "topology.kubernetes.io/zone"
+)
+
+// Locality of a workload.
+type Locality struct {
+	Region  string
+	Zone    string
+	Subzone string
+}
+
+// ParseLocality parses a locality of the form region/zone/subzone. The istio-locality label form,
+// using "." as separator, is accepted as well.
+func ParseLocality(s string) Locality {
+	if !strings.Contains(s, "/") {
+		s = strings.ReplaceAll(s, ".", "/")
+	}
+	parts := strings.SplitN(s, "/", 3)
+	l := Locality{Region: parts[0]}
+	if len(parts) > 1 {
+		l.Zone = parts[1]
+	}
+	if len(parts) > 2 {
+		l.Subzone = parts[2]
+	}
+	return l
+}
+
+// String returns the locality in the region/zone/subzone form used by Istio configuration.
+func (l Locality) String() string {
+	return strings.TrimRight(strings.Join([]string{l.Region, l.Zone, l.Subzone}, "/"), "/")
+}
+
+// Label returns the locality in the form used by the istio-locality label, which may be set as the
+// Locality of Config or SubsetConfig.
+func (l Locality) Label() string {
+	return strings.ReplaceAll(l.String(), "/", ".")
+}
+
+// NodeSelector returns a node selector for the nodes in the region and zone of the locality. It can be
+// used as the NodeSelector of Config, instead of a Locality override, on clusters with labeled nodes.
+func (l Locality) NodeSelector() map[string]string {
+	out := map[string]string{}
+	if l.Region != "" {
+		out[NodeRegionLabel] = l.Region
+	}
+	if l.Zone != "" {
+		out[NodeZoneLabel] = l.Zone
+	}
+	return out
+}
+
+// hostnamePrefix returns the prefix of the hostnames of the pods of the subset, which are reported in the
+// responses of the echo server.
+func hostnamePrefix(service string, s SubsetConfig) string {
+	version := s.Version
+	if version == "" {
+		version = "v1"
+	}
+	return fmt.Sprintf("%s-%s-", service, version)
+}
+
+// localities maps the hostname prefix of the pods of each subset of the targets to the locality of the subset.
+func localities(targets Instances) map[string]string {
+	out := map[string]string{}
+	for _, t := range targets {
+		cfg := t.Config()
+		subsets := cfg.Subsets
+		if len(subsets) == 0 {
+			// Instances configured with the Version and Locality of the Config have a single subset.
+			subsets = []SubsetConfig{{Version: cfg.Version, Locality: cfg.Locality}}
+		}
+		for _, s := range subsets {
+			l := s.Locality
+			if l == "" {
+				l = cfg.Locality
+			}
+			out[hostnamePrefix(cfg.Service, s)] = ParseLocality(l).String()
+		}
+	}
+	return out
+}
+
+func localityDistribution(byPrefix map[string]string, responses client.ParsedResponses) (map[string]int, error) {
+	out := map[string]int{}
+	for i, r := range responses {
+		match := ""
+		for prefix := range byPrefix {
+			if strings.HasPrefix(r.Hostname, prefix) && len(prefix) > len(match) {
+				match = prefix
+			}
+		}
+		if match == "" {
+			return nil, fmt.Errorf("response[%d] from %s does not belong to any of the targets", i, r.Hostname)
+		}
+		out[byPrefix[match]]++
+	}
+	return out, nil
+}
+
+// LocalityDistribution returns the number of responses received from each locality. Responses are attributed
+// to the locality of the subset of the targets they came from, which must have been deployed with a locality.
+func LocalityDistribution(targets Instances, responses client.ParsedResponses) (map[string]int, error) {
+	return localityDistribution(localities(targets), responses)
+}
+
+// ValidateLocality returns a Validator that checks that all responses came from the targets in the
+// given locality.
+func ValidateLocality(targets Instances, locality string) Validator {
+	want := ParseLocality(locality).String()
+	return func(responses client.ParsedResponses) error {
+		got, err := LocalityDistribution(targets, responses)
+		if err != nil {
+			return err
+		}
+		if len(responses) == 0 || got[want] != len(responses) {
+			return fmt.Errorf("expected all responses from %s, got %v", want, got)
+		}
+		return nil
+	}
+}
+
+// ValidateLocalityDistribution returns a Validator that checks that the responses were distributed across
+// localities according to the given percentages, allowing the given percent of error on each of them.
+func ValidateLocalityDistribution(targets Instances, percentages map[string]int, precisionPct int) Validator {
+	want := map[string]int{}
+	for l, pct := range percentages {
+		want[ParseLocality(l).String()] = pct
+	}
+	return func(responses client.ParsedResponses) error {
+		got, err := LocalityDistribution(targets, responses)
+		if err != nil {
+			return err
+		}
+		return checkLocalityDistribution(got, want, len(responses), precisionPct)
+	}
+}
+
+func checkLocalityDistribution(got, percentages map[string]int, total, precisionPct int) error {
+	all := map[string]struct{}{}
+	for l := range got {
+		all[l] = struct{}{}
+	}
+	for l := range percentages {
+		all[l] = struct{}{}
+	}
+	var errs []string
+	for l := range all {
+		expected := total * percentages[l] / 100
+		precision := total * precisionPct / 100
+		if got[l] < expected-precision || got[l] > expected+precision {
+			errs = append(errs, fmt.Sprintf("%s: expected %d±%d, got %d", l, expected, precision, got[l]))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("unexpected locality distribution of %d responses: %s", total, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateFailoverOrder returns a Validator that checks that all responses came from the first locality
+// in the failover order that has any of the targets. Tests exercising failover pass the targets which are
+// still available, so that the expected locality moves down the order as localities become unavailable.
+func ValidateFailoverOrder(targets Instances, order ...string) Validator {
+	return func(responses client.ParsedResponses) error {
+		available := map[string]struct{}{}
+		for _, l := range localities(targets) {
+			available[l] = struct{}{}
+		}
+		for _, l := range order {
+			if _, ok := available[ParseLocality(l).String()]; ok {
+				return ValidateLocality(targets, l)(responses)
+			}
+		}
+		return fmt.Errorf("none of the localities %v has any of the targets", order)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+// configInstance is an Instance which only provides its Config.
+type configInstance struct {
+	Instance
+	cfg Config
+}
+
+func (i configInstance) Config() Config {
+	return i.cfg
+}
+
+func TestLocalityDistribution(t *testing.T) {
+	targets := Instances{
+		configInstance{cfg: Config{
+			Service:  "a",
+			Locality: "region.zone1",
+			Subsets: []SubsetConfig{
+				{Version: "v1"},
+				{Version: "v2", Locality: "region.zone2"},
+			},
+		}},
+		// Without subsets, the Version and Locality of the Config are used.
+		configInstance{cfg: Config{
+			Service:  "b",
+			Version:  "v3",
+			Locality: "region/zone3/subzone",
+		}},
+	}
+	responses := client.ParsedResponses{
+		{Hostname: "a-v1-7d4f8b9c5-x2x7k"},
+		{Hostname: "a-v2-6c8d7f5b4-q9w8e"},
+		{Hostname: "a-v2-6c8d7f5b4-q9w8e"},
+		{Hostname: "b-v3-5b6c7d8e9-z1y2x"},
+	}
+	got, err := LocalityDistribution(targets, responses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"region/zone1": 1, "region/zone2": 2, "region/zone3/subzone": 1}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	if err := ValidateLocality(targets[1:], "region.zone3.subzone")(responses[3:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LocalityDistribution(targets[1:], responses); err == nil {
+		t.Fatal("expected an error for the responses of other targets")
+	}
+}
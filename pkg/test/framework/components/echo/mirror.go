@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common"
+)
+
+// CountRequests returns the number of requests received by the workloads of the instances whose URL (HTTP)
+// or message (gRPC) contains the given id, split by whether they were mirrored to the instances.
+func CountRequests(instances Instances, id string) (common.RequestCounts, error) {
+	var out common.RequestCounts
+	for _, i := range instances {
+		workloads, err := i.Workloads()
+		if err != nil {
+			return out, fmt.Errorf("failed to get workloads of %s: %v", i.Config().Service, err)
+		}
+		for _, w := range workloads {
+			logs, err := w.Logs()
+			if err != nil {
+				return out, fmt.Errorf("failed getting logs of %s: %v", i.Config().Service, err)
+			}
+			out = out.Add(common.CountRequests(logs, id))
+		}
+	}
+	return out, nil
+}
+
+// CheckMirrorPercentage checks that the mirror instances received the given percentage of the requests with the
+// given id that were received by the destination instances, within the tolerance in percentage points. All the
+// requests received by the mirror must have been mirrored, and none of those received by the destination.
+func CheckMirrorPercentage(dest, mirror Instances, id string, percentage, tolerance float64) error {
+	destCounts, err := CountRequests(dest, id)
+	if err != nil {
+		return err
+	}
+	mirrorCounts, err := CountRequests(mirror, id)
+	if err != nil {
+		return err
+	}
+	if destCounts.Direct == 0 {
+		return fmt.Errorf("destination received no requests with id %s", id)
+	}
+	if destCounts.Mirrored > 0 {
+		return fmt.Errorf("destination received %d mirrored requests with id %s", destCounts.Mirrored, id)
+	}
+	if mirrorCounts.Direct > 0 {
+		return fmt.Errorf("mirror received %d requests with id %s which were not mirrored", mirrorCounts.Direct, id)
+	}
+	actual := float64(mirrorCounts.Mirrored) / float64(destCounts.Direct) * 100
+	if math.Abs(actual-percentage) > tolerance {
+		return fmt.Errorf("unexpected mirror traffic: expected %g%%, got %.1f%% (tolerance: %g%%, id: %s)",
+			percentage, actual, tolerance, id)
+	}
+	return nil
+}
+
+// ValidateMirrorDiscarded returns a Validator that checks that none of the responses came from the mirror
+// instances. Envoy discards the responses to mirrored requests, so the caller only sees the destination.
+func ValidateMirrorDiscarded(mirror Instances) Validator {
+	var prefixes []string
+	for _, i := range mirror {
+		for _, s := range i.Config().Subsets {
+			prefixes = append(prefixes, hostnamePrefix(i.Config().Service, s))
+		}
+	}
+	return func(responses client.ParsedResponses) error {
+		return responses.Check(func(idx int, r *client.ParsedResponse) error {
+			if common.IsShadowHost(r.Host) {
+				return fmt.Errorf("response[%d] is the response to a mirrored request to %s", idx, r.Host)
+			}
+			for _, p := range prefixes {
+				if strings.HasPrefix(r.Hostname, p) {
+					return fmt.Errorf("response[%d] came from mirror %s", idx, r.Hostname)
+				}
+			}
+			return nil
+		})
+	}
+}
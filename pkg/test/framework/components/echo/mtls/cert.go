@@ -0,0 +1,129 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"istio.io/istio/pkg/spiffe"
+)
+
+// ParsePEMChain parses all certificates in the given PEM data, in order.
+func ParsePEMChain(data []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cert)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return out, nil
+}
+
+// SPIFFEID returns the SPIFFE ID in the URI SANs of the certificate, or an empty string if it has none.
+func SPIFFEID(cert *x509.Certificate) string {
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// CheckSPIFFEID checks that the leaf of the chain has the given SPIFFE ID.
+func CheckSPIFFEID(chain []*x509.Certificate, want string) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	if got := SPIFFEID(chain[0]); got != want {
+		return fmt.Errorf("expected SPIFFE ID %s, got %q", want, got)
+	}
+	return nil
+}
+
+// CheckTrustDomain checks that the SPIFFE ID of the leaf of the chain is in the given trust domain.
+func CheckTrustDomain(chain []*x509.Certificate, want string) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	id := SPIFFEID(chain[0])
+	td, err := spiffe.GetTrustDomainFromURISAN(id)
+	if err != nil {
+		return err
+	}
+	if td != want {
+		return fmt.Errorf("expected %s to be in trust domain %s", id, want)
+	}
+	return nil
+}
+
+// CheckIssuedBy checks that the chain is valid and chains up to one of the given PEM encoded root certificates.
+// Intermediate CAs are taken from the chain.
+func CheckIssuedBy(chain []*x509.Certificate, rootsPEM ...[]byte) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	roots := x509.NewCertPool()
+	for _, r := range rootsPEM {
+		if !roots.AppendCertsFromPEM(r) {
+			return fmt.Errorf("failed to parse root certificate")
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("certificate of %s is not issued by the expected CA: %v", SPIFFEID(chain[0]), err)
+	}
+	return nil
+}
+
+// CheckLifetime checks that the leaf of the chain is currently valid, and that its total lifetime is between min
+// and max. A zero max is not checked.
+func CheckLifetime(chain []*x509.Certificate, min, max time.Duration) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	leaf := chain[0]
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate of %s is not valid now: valid from %v to %v", SPIFFEID(leaf), leaf.NotBefore, leaf.NotAfter)
+	}
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	if lifetime < min || (max > 0 && lifetime > max) {
+		return fmt.Errorf("certificate of %s has lifetime %v, expected between %v and %v", SPIFFEID(leaf), lifetime, min, max)
+	}
+	return nil
+}
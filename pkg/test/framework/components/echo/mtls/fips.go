@@ -0,0 +1,209 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// TLSStatsRegexp is a value for the echo.SidecarStatsInclusionRegexps annotation which has the sidecar record the
+// TLS ciphers and curves it negotiates. Envoy does not record them by default.
+const TLSStatsRegexp = `.*ssl\.(ciphers|curves)\..*`
+
+var (
+	// FIPSCiphers are the FIPS approved TLS cipher suites, including the TLS 1.3 ones.
+	FIPSCiphers = []string{
+		"ECDHE-ECDSA-AES128-GCM-SHA256",
+		"ECDHE-RSA-AES128-GCM-SHA256",
+		"ECDHE-ECDSA-AES256-GCM-SHA384",
+		"ECDHE-RSA-AES256-GCM-SHA384",
+		"TLS_AES_128_GCM_SHA256",
+		"TLS_AES_256_GCM_SHA384",
+	}
+	// FIPSCurves are the FIPS approved key exchange curves.
+	FIPSCurves = []string{
+		"P-256",
+		"P-384",
+	}
+)
+
+// TLSStats counts the TLS handshakes of a sidecar by negotiated cipher and curve. The names are those of the
+// Prometheus stats, where Envoy replaces '-' with '_'.
+type TLSStats struct {
+	Ciphers map[string]float64
+	Curves  map[string]float64
+}
+
+// SidecarTLSStats returns the TLS stats of the sidecar, which must have the TLSStatsRegexp annotation.
+func SidecarTLSStats(s echo.Sidecar) (TLSStats, error) {
+	stats, err := s.Stats()
+	if err != nil {
+		return TLSStats{}, err
+	}
+	return parseTLSStats(stats), nil
+}
+
+// parseTLSStats collects the ssl.ciphers.<cipher> and ssl.curves.<curve> counters of all listeners and clusters.
+// Depending on the Envoy tag extraction rules, the cipher or curve is either a label or part of the stat name.
+func parseTLSStats(stats map[string]*dto.MetricFamily) TLSStats {
+	out := TLSStats{Ciphers: map[string]float64{}, Curves: map[string]float64{}}
+	for name, mf := range stats {
+		for _, kind := range []struct {
+			stat  string
+			label string
+			into  map[string]float64
+		}{
+			{stat: "ssl_ciphers", label: "cipher", into: out.Ciphers},
+			{stat: "ssl_curves", label: "curve", into: out.Curves},
+		} {
+			i := strings.Index(name, kind.stat)
+			if i < 0 {
+				continue
+			}
+			suffix := strings.TrimPrefix(name[i+len(kind.stat):], "_")
+			for _, m := range mf.Metric {
+				value := suffix
+				if value == "" {
+					for _, l := range m.Label {
+						if strings.Contains(l.GetName(), kind.label) {
+							value = l.GetValue()
+						}
+					}
+				}
+				if value == "" || m.GetCounter().GetValue() == 0 {
+					continue
+				}
+				kind.into[statName(value)] += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return out
+}
+
+func statName(s string) string {
+	return strings.ReplaceAll(s, "-", "_")
+}
+
+// Sub returns the handshakes counted since before.
+func (s TLSStats) Sub(before TLSStats) TLSStats {
+	sub := func(a, b map[string]float64) map[string]float64 {
+		out := map[string]float64{}
+		for k, v := range a {
+			if d := v - b[k]; d > 0 {
+				out[k] = d
+			}
+		}
+		return out
+	}
+	return TLSStats{Ciphers: sub(s.Ciphers, before.Ciphers), Curves: sub(s.Curves, before.Curves)}
+}
+
+func (s TLSStats) add(o TLSStats) {
+	for k, v := range o.Ciphers {
+		s.Ciphers[k] += v
+	}
+	for k, v := range o.Curves {
+		s.Curves[k] += v
+	}
+}
+
+// CheckFIPS checks that at least one handshake was counted, and that all of them negotiated FIPS approved ciphers
+// and curves.
+func CheckFIPS(s TLSStats) error {
+	if len(s.Ciphers) == 0 {
+		return fmt.Errorf("no TLS handshakes recorded, does the sidecar have the TLS stats annotation?")
+	}
+	var violations []string
+	for _, c := range notIn(s.Ciphers, FIPSCiphers) {
+		violations = append(violations, "cipher "+c)
+	}
+	for _, c := range notIn(s.Curves, FIPSCurves) {
+		violations = append(violations, "curve "+c)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("negotiated TLS parameters which are not FIPS approved: %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
+
+func notIn(got map[string]float64, approved []string) []string {
+	allowed := make(map[string]bool, len(approved))
+	for _, a := range approved {
+		allowed[statName(a)] = true
+	}
+	var out []string
+	for k := range got {
+		if !allowed[k] {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CheckFIPSCall makes the call and checks that the client sidecar only negotiated FIPS approved ciphers and curves
+// for it. The workloads of from must have the TLSStatsRegexp annotation.
+func CheckFIPSCall(from echo.Instance, opts echo.CallOptions) error {
+	workloads, err := from.Workloads()
+	if err != nil {
+		return err
+	}
+	stats := func() (TLSStats, error) {
+		out := TLSStats{Ciphers: map[string]float64{}, Curves: map[string]float64{}}
+		for _, w := range workloads {
+			if w.Sidecar() == nil {
+				return TLSStats{}, fmt.Errorf("%s has no sidecar", from.Config().Service)
+			}
+			s, err := SidecarTLSStats(w.Sidecar())
+			if err != nil {
+				return TLSStats{}, err
+			}
+			out.add(s)
+		}
+		return out, nil
+	}
+
+	before, err := stats()
+	if err != nil {
+		return err
+	}
+	resp, err := from.Call(opts)
+	if err != nil {
+		return err
+	}
+	if err := resp.CheckOK(); err != nil {
+		return err
+	}
+	after, err := stats()
+	if err != nil {
+		return err
+	}
+	return CheckFIPS(after.Sub(before))
+}
+
+// CheckFIPSCallOrFail calls CheckFIPSCall and fails the test if an error is returned.
+func CheckFIPSCallOrFail(t test.Failer, from echo.Instance, opts echo.CallOptions) {
+	t.Helper()
+	if err := CheckFIPSCall(from, opts); err != nil {
+		t.Fatalf("mtls.CheckFIPSCallOrFail: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counter(value float64, labels ...string) *dto.Metric {
+	m := &dto.Metric{Counter: &dto.Counter{Value: proto.Float64(value)}}
+	for i := 0; i+1 < len(labels); i += 2 {
+		m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(labels[i]), Value: proto.String(labels[i+1])})
+	}
+	return m
+}
+
+func TestTLSStats(t *testing.T) {
+	stats := map[string]*dto.MetricFamily{
+		"envoy_cluster_ssl_ciphers": {Metric: []*dto.Metric{
+			counter(2, "cluster_name", "outbound|80||b", "envoy_ssl_cipher_suite", "ECDHE-RSA-AES128-GCM-SHA256"),
+		}},
+		"envoy_listener_ssl_ciphers_ECDHE_RSA_AES128_GCM_SHA256": {Metric: []*dto.Metric{counter(1)}},
+		"envoy_listener_ssl_curves_X25519":                       {Metric: []*dto.Metric{counter(3)}},
+		"envoy_listener_ssl_curves_P_256":                        {Metric: []*dto.Metric{counter(0)}},
+		"envoy_cluster_upstream_cx_total":                        {Metric: []*dto.Metric{counter(5)}},
+	}
+	got := parseTLSStats(stats)
+	if got.Ciphers["ECDHE_RSA_AES128_GCM_SHA256"] != 3 || len(got.Ciphers) != 1 {
+		t.Fatalf("unexpected ciphers: %v", got.Ciphers)
+	}
+	if got.Curves["X25519"] != 3 || len(got.Curves) != 1 {
+		t.Fatalf("unexpected curves: %v", got.Curves)
+	}
+	if err := CheckFIPS(got); err == nil {
+		t.Fatal("expected X25519 to be rejected")
+	}
+
+	before := TLSStats{Ciphers: map[string]float64{}, Curves: map[string]float64{"X25519": 3}}
+	if err := CheckFIPS(got.Sub(before)); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckFIPS(got.Sub(got)); err == nil {
+		t.Fatal("expected no handshakes to be rejected")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mtls inspects the certificates actually presented in the mTLS handshakes between echo workloads, so that
+// tests can assert on identities, issuing CAs and certificate lifetimes rather than inferring mTLS from a
+// successful call.
+package mtls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	secretsTypeURL = "type.googleapis.com/envoy.admin.v3.SecretsConfigDump"
+	// workloadSecret is the SDS resource name of the workload certificate.
+	workloadSecret = "default"
+)
+
+// EnvoyFilter returns an EnvoyFilter, with the given name, that has the inbound listeners of the workloads matching
+// selector forward the full client certificate and chain in the x-forwarded-client-cert header. The echo server
+// reflects the header in its response, which is what Inspect relies on. It is meant to be applied in the
+// namespace of the workloads.
+func EnvoyFilter(name string, selector map[string]string) string {
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	labels := &strings.Builder{}
+	for _, k := range keys {
+		fmt.Fprintf(labels, "\n      %s: %q", k, selector[k])
+	}
+	return fmt.Sprintf(`apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: %s
+spec:
+  workloadSelector:
+    labels:%s
+  configPatches:
+  - applyTo: NETWORK_FILTER
+    match:
+      context: SIDECAR_INBOUND
+      listener:
+        filterChain:
+          filter:
+            name: envoy.filters.network.http_connection_manager
+    patch:
+      operation: MERGE
+      value:
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
+          forward_client_cert_details: APPEND_FORWARD
+          set_current_client_cert_details:
+            subject: true
+            dns: true
+            uri: true
+            cert: true
+            chain: true
+`, name, labels)
+}
+
+// Handshake describes the mTLS handshake of a request, as seen by the server sidecar.
+type Handshake struct {
+	// ServerID is the SPIFFE ID of the certificate presented by the server sidecar.
+	ServerID string
+	// PeerChain is the certificate chain presented by the client sidecar, leaf first.
+	PeerChain []*x509.Certificate
+}
+
+// PeerID returns the SPIFFE ID of the certificate presented by the client sidecar.
+func (h Handshake) PeerID() string {
+	if len(h.PeerChain) == 0 {
+		return ""
+	}
+	return SPIFFEID(h.PeerChain[0])
+}
+
+// HandshakeFromResponse returns the handshake of the request which produced the given echo response, from the
+// x-forwarded-client-cert header reflected by the server. If the server sidecar does not have EnvoyFilter applied,
+// only ServerID is set.
+func HandshakeFromResponse(r *client.ParsedResponse) (Handshake, error) {
+	header, ok := r.RawResponse[XFCCHeader]
+	if !ok {
+		return Handshake{}, fmt.Errorf("no %s header in the response, was the request sent over mTLS?", XFCCHeader)
+	}
+	elements, err := ParseXFCC(header)
+	if err != nil {
+		return Handshake{}, err
+	}
+	if len(elements) == 0 {
+		return Handshake{}, fmt.Errorf("empty %s header", XFCCHeader)
+	}
+	// The last element is added by the sidecar of the server.
+	e := elements[len(elements)-1]
+	h := Handshake{
+		ServerID:  e.By,
+		PeerChain: e.Chain,
+	}
+	if len(h.PeerChain) == 0 && e.Cert != nil {
+		h.PeerChain = []*x509.Certificate{e.Cert}
+	}
+	return h, nil
+}
+
+// Inspect calls the target with the given options and returns the handshake of every response. The target must
+// have EnvoyFilter applied to get the certificates of the client.
+func Inspect(from echo.Instance, opts echo.CallOptions) ([]Handshake, error) {
+	resp, err := from.Call(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.CheckOK(); err != nil {
+		return nil, err
+	}
+	out := make([]Handshake, 0, len(resp))
+	for _, r := range resp {
+		h, err := HandshakeFromResponse(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(h.PeerChain) == 0 {
+			return nil, fmt.Errorf("no client certificate in the response, is the mtls.EnvoyFilter applied to %s?",
+				opts.Target.Config().Service)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// InspectOrFail calls Inspect and fails the test if an error is returned.
+func InspectOrFail(t test.Failer, from echo.Instance, opts echo.CallOptions) []Handshake {
+	t.Helper()
+	out, err := Inspect(from, opts)
+	if err != nil {
+		t.Fatalf("mtls.InspectOrFail: %v", err)
+	}
+	return out
+}
+
+// WorkloadChain returns the certificate chain the given sidecar presents to its peers, leaf first, as found in
+// the SDS secrets of its config dump.
+func WorkloadChain(s echo.Sidecar) ([]*x509.Certificate, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	return workloadChain(cfg)
+}
+
+func workloadChain(cfg *envoyAdmin.ConfigDump) ([]*x509.Certificate, error) {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != secretsTypeURL {
+			continue
+		}
+		dump := &envoyAdmin.SecretsConfigDump{}
+		if err := ptypes.UnmarshalAny(c, dump); err != nil {
+			return nil, err
+		}
+		for _, s := range dump.DynamicActiveSecrets {
+			if s.Name != workloadSecret {
+				continue
+			}
+			secret := &tls.Secret{}
+			if err := ptypes.UnmarshalAny(s.GetSecret(), secret); err != nil {
+				return nil, err
+			}
+			return ParsePEMChain(secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes())
+		}
+	}
+	return nil, fmt.Errorf("no active %q secret found in the config dump", workloadSecret)
+}
@@ -0,0 +1,119 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// XFCCHeader is the header the server sidecar uses to pass details of the client certificate to the application.
+const XFCCHeader = "X-Forwarded-Client-Cert"
+
+// XFCCElement is a single element of an x-forwarded-client-cert header, describing the client certificate
+// presented to one proxy. See
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#x-forwarded-client-cert
+type XFCCElement struct {
+	// By is the SAN (the SPIFFE ID in Istio) of the certificate of the proxy which added the element.
+	By string
+	// Hash is the SHA 256 digest of the client certificate.
+	Hash string
+	// Subject of the client certificate.
+	Subject string
+	// URI SANs of the client certificate.
+	URI []string
+	// DNS SANs of the client certificate.
+	DNS []string
+	// Cert is the client certificate. It is only set if the proxy is configured to forward it, see EnvoyFilter.
+	Cert *x509.Certificate
+	// Chain is the certificate chain presented by the client, leaf first. It is only set if the proxy is configured
+	// to forward it, see EnvoyFilter.
+	Chain []*x509.Certificate
+}
+
+// ParseXFCC parses the value of an x-forwarded-client-cert header. Elements are returned in the order they were
+// added, so the last one describes the client of the proxy closest to the application.
+func ParseXFCC(header string) ([]XFCCElement, error) {
+	var out []XFCCElement
+	for _, element := range splitQuoted(header, ',') {
+		if strings.TrimSpace(element) == "" {
+			continue
+		}
+		e := XFCCElement{}
+		for _, pair := range splitQuoted(element, ';') {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid XFCC pair %q", pair)
+			}
+			key, value := kv[0], strings.Trim(kv[1], `"`)
+			switch strings.ToLower(key) {
+			case "by":
+				e.By = value
+			case "hash":
+				e.Hash = value
+			case "subject":
+				e.Subject = value
+			case "uri":
+				e.URI = append(e.URI, value)
+			case "dns":
+				e.DNS = append(e.DNS, value)
+			case "cert":
+				chain, err := parseURLEncodedPEM(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid XFCC Cert: %v", err)
+				}
+				if len(chain) != 1 {
+					return nil, fmt.Errorf("invalid XFCC Cert: expected a single certificate, got %d", len(chain))
+				}
+				e.Cert = chain[0]
+			case "chain":
+				chain, err := parseURLEncodedPEM(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid XFCC Chain: %v", err)
+				}
+				e.Chain = chain
+			}
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// splitQuoted splits s at every sep which is not enclosed in double quotes.
+func splitQuoted(s string, sep rune) []string {
+	var out []string
+	quoted := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+func parseURLEncodedPEM(value string) ([]*x509.Certificate, error) {
+	decoded, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePEMChain([]byte(decoded))
+}
@@ -0,0 +1,188 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCert(t *testing.T, parent *testCA, template *x509.Certificate) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Minute)
+		template.NotAfter = time.Now().Add(24 * time.Hour)
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func newCA(t *testing.T, parent *testCA, name string) *testCA {
+	return newCert(t, parent, &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{name}},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+}
+
+func newWorkload(t *testing.T, parent *testCA, id string, lifetime time.Duration) *testCA {
+	u, err := url.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Add(-time.Minute)
+	return newCert(t, parent, &x509.Certificate{
+		URIs:        []*url.URL{u},
+		NotBefore:   now,
+		NotAfter:    now.Add(lifetime),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	})
+}
+
+// urlEncode encodes PEM the way Envoy does in the XFCC header.
+func urlEncode(pem []byte) string {
+	return strings.NewReplacer("\n", "%0A", " ", "%20", "+", "%2B", "/", "%2F", "=", "%3D").Replace(string(pem))
+}
+
+func TestXFCC(t *testing.T) {
+	root := newCA(t, nil, "root")
+	intermediate := newCA(t, root, "intermediate")
+	leaf := newWorkload(t, intermediate, "spiffe://cluster.local/ns/a/sa/a", 24*time.Hour)
+	chain := append(append([]byte{}, leaf.pem...), intermediate.pem...)
+
+	header := `By=spiffe://cluster.local/ns/x/sa/x;URI=spiffe://cluster.local/ns/gw/sa/gw,` +
+		`By=spiffe://cluster.local/ns/b/sa/b;Hash=abc;Subject="";URI=spiffe://cluster.local/ns/a/sa/a;` +
+		`Cert="` + urlEncode(leaf.pem) + `";Chain="` + urlEncode(chain) + `"`
+
+	elements, err := ParseXFCC(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(elements))
+	}
+	if elements[0].By != "spiffe://cluster.local/ns/x/sa/x" || elements[0].Cert != nil {
+		t.Fatalf("unexpected first element: %+v", elements[0])
+	}
+	e := elements[1]
+	if e.By != "spiffe://cluster.local/ns/b/sa/b" || e.Hash != "abc" ||
+		len(e.URI) != 1 || e.URI[0] != "spiffe://cluster.local/ns/a/sa/a" {
+		t.Fatalf("unexpected second element: %+v", e)
+	}
+	if e.Cert == nil || !e.Cert.Equal(leaf.cert) {
+		t.Fatal("expected the client certificate to be parsed")
+	}
+	if len(e.Chain) != 2 || !e.Chain[1].Equal(intermediate.cert) {
+		t.Fatalf("expected the client chain to be parsed, got %d certificates", len(e.Chain))
+	}
+
+	if err := CheckSPIFFEID(e.Chain, "spiffe://cluster.local/ns/a/sa/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSPIFFEID(e.Chain, "spiffe://cluster.local/ns/b/sa/b"); err == nil {
+		t.Fatal("expected SPIFFE ID mismatch")
+	}
+	if err := CheckIssuedBy(e.Chain, root.pem); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckIssuedBy(e.Chain, newCA(t, nil, "other").pem); err == nil {
+		t.Fatal("expected chain not to verify against another root")
+	}
+	if err := CheckIssuedBy(e.Chain[:1], root.pem); err == nil {
+		t.Fatal("expected chain without intermediate not to verify")
+	}
+}
+
+func TestParseXFCCInvalid(t *testing.T) {
+	for _, h := range []string{
+		"By",
+		`By=a;Cert="not-a-cert"`,
+	} {
+		if _, err := ParseXFCC(h); err == nil {
+			t.Errorf("expected %q to be rejected", h)
+		}
+	}
+}
+
+func TestCheckLifetime(t *testing.T) {
+	root := newCA(t, nil, "root")
+	leaf := newWorkload(t, root, "spiffe://cluster.local/ns/a/sa/a", 24*time.Hour)
+	chain := []*x509.Certificate{leaf.cert}
+
+	if err := CheckLifetime(chain, time.Hour, 25*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLifetime(chain, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLifetime(chain, 0, time.Hour); err == nil {
+		t.Fatal("expected lifetime above max to fail")
+	}
+	if err := CheckLifetime(chain, 48*time.Hour, 0); err == nil {
+		t.Fatal("expected lifetime below min to fail")
+	}
+
+	// Already expired.
+	expired := newWorkload(t, root, "spiffe://cluster.local/ns/a/sa/a", time.Second)
+	if err := CheckLifetime([]*x509.Certificate{expired.cert}, 0, 0); err == nil {
+		t.Fatal("expected expired certificate to fail")
+	}
+}
+
+func TestCheckTrustDomain(t *testing.T) {
+	root := newCA(t, nil, "root")
+	leaf := newWorkload(t, root, "spiffe://td-a.local/ns/a/sa/a", time.Hour)
+	chain := []*x509.Certificate{leaf.cert}
+
+	if err := CheckTrustDomain(chain, "td-a.local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckTrustDomain(chain, "cluster.local"); err == nil {
+		t.Fatal("expected trust domain mismatch to fail")
+	}
+}
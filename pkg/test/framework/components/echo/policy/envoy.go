@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbachttp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	rbactcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+)
+
+const (
+	listenersTypeURL = "type.googleapis.com/envoy.admin.v3.ListenersConfigDump"
+	// virtualInbound is the listener of the sidecar which receives all inbound traffic.
+	virtualInbound = "virtualInbound"
+)
+
+var rbacActions = map[string]rbacpb.RBAC_Action{
+	"ALLOW": rbacpb.RBAC_ALLOW,
+	"DENY":  rbacpb.RBAC_DENY,
+	"AUDIT": rbacpb.RBAC_LOG,
+}
+
+// check returns an error unless the given AuthorizationPolicies and per port mTLS modes are all enforced by the
+// inbound listener in the config dump.
+func check(dump *envoyAdmin.ConfigDump, authz []Policy, modes map[uint32]string) error {
+	l, err := inboundListener(dump)
+	if err != nil {
+		return err
+	}
+	if len(authz) > 0 {
+		rules, err := rbacRules(l)
+		if err != nil {
+			return err
+		}
+		for _, p := range authz {
+			if err := checkAuthorization(rules, p); err != nil {
+				return err
+			}
+		}
+	}
+	for port, mode := range modes {
+		if err := checkMode(l, port, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inboundListener(dump *envoyAdmin.ConfigDump) (*listener.Listener, error) {
+	for _, c := range dump.Configs {
+		if c.TypeUrl != listenersTypeURL {
+			continue
+		}
+		listeners := &envoyAdmin.ListenersConfigDump{}
+		if err := ptypes.UnmarshalAny(c, listeners); err != nil {
+			return nil, err
+		}
+		for _, dl := range listeners.DynamicListeners {
+			if dl.Name != virtualInbound || dl.GetActiveState().GetListener() == nil {
+				continue
+			}
+			l := &listener.Listener{}
+			if err := ptypes.UnmarshalAny(dl.GetActiveState().GetListener(), l); err != nil {
+				return nil, err
+			}
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("no active %s listener found in the config dump", virtualInbound)
+}
+
+// rbacRules returns the rules of all HTTP and network RBAC filters in the filter chains of the listener.
+func rbacRules(l *listener.Listener) ([]*rbacpb.RBAC, error) {
+	var out []*rbacpb.RBAC
+	for _, fc := range l.FilterChains {
+		for _, f := range fc.Filters {
+			switch f.Name {
+			case wellknown.RoleBasedAccessControl:
+				r := &rbactcp.RBAC{}
+				if err := ptypes.UnmarshalAny(f.GetTypedConfig(), r); err != nil {
+					return nil, err
+				}
+				if r.Rules != nil {
+					out = append(out, r.Rules)
+				}
+			case wellknown.HTTPConnectionManager:
+				h := &hcm.HttpConnectionManager{}
+				if err := ptypes.UnmarshalAny(f.GetTypedConfig(), h); err != nil {
+					return nil, err
+				}
+				for _, hf := range h.HttpFilters {
+					if hf.Name != wellknown.HTTPRoleBasedAccessControl {
+						continue
+					}
+					r := &rbachttp.RBAC{}
+					if err := ptypes.UnmarshalAny(hf.GetTypedConfig(), r); err != nil {
+						return nil, err
+					}
+					if r.Rules != nil {
+						out = append(out, r.Rules)
+					}
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// checkAuthorization checks that one of the RBAC filters has the action of the policy and, if the policy has any
+// rules, contains one of them. Istiod names the rules ns[<namespace>]-policy[<name>]-rule[<index>].
+func checkAuthorization(rules []*rbacpb.RBAC, p Policy) error {
+	prefix := fmt.Sprintf("ns[%s]-policy[%s]-rule[", p.Namespace, p.Name)
+	for _, r := range rules {
+		if r.Action != rbacActions[p.Action] {
+			continue
+		}
+		if p.Rules == 0 {
+			return nil
+		}
+		for name := range r.Policies {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%v is not in the %s RBAC filters of the %s listener", p, p.Action, virtualInbound)
+}
+
+// checkMode checks that the filter chains of the inbound listener for the given port match the mTLS mode. Chains
+// with a TLS transport socket terminate mTLS, all others accept plaintext.
+func checkMode(l *listener.Listener, port uint32, mode string) error {
+	var mTLS, plaintext bool
+	for _, fc := range l.FilterChains {
+		if fc.GetFilterChainMatch().GetDestinationPort().GetValue() != port {
+			continue
+		}
+		if fc.GetTransportSocket().GetName() == wellknown.TransportSocketTls {
+			mTLS = true
+		} else {
+			plaintext = true
+		}
+	}
+	if !mTLS && !plaintext {
+		// Not served by a filter chain of its own, nothing to check.
+		return nil
+	}
+	var ok bool
+	switch mode {
+	case "STRICT":
+		ok = mTLS && !plaintext
+	case "PERMISSIVE":
+		ok = mTLS && plaintext
+	case "DISABLE":
+		ok = !mTLS && plaintext
+	default:
+		return fmt.Errorf("unsupported mTLS mode %q", mode)
+	}
+	if !ok {
+		return fmt.Errorf("inbound filter chains of port %d do not match mTLS mode %s (mTLS: %v, plaintext: %v)",
+			port, mode, mTLS, plaintext)
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy waits for security policies to be enforced by the sidecars of echo workloads. Rather than
+// sleeping after applying a policy, tests can poll the Envoy configuration of the affected proxies until the RBAC
+// filters generated for an AuthorizationPolicy, or the inbound TLS modes required by a PeerAuthentication, are in
+// place.
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+// Kinds of policies supported by WaitForPolicy.
+const (
+	AuthorizationPolicy = "AuthorizationPolicy"
+	PeerAuthentication  = "PeerAuthentication"
+)
+
+// Policy is an AuthorizationPolicy or PeerAuthentication, reduced to the fields that determine how it shows up in
+// the Envoy configuration of the workloads it selects.
+type Policy struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Selector is the matchLabels of the workload selector. Empty for namespace and mesh wide policies.
+	Selector map[string]string
+
+	// Action is the action of an AuthorizationPolicy, defaulted to ALLOW.
+	Action string
+	// Rules is the number of rules of an AuthorizationPolicy.
+	Rules int
+
+	// Mode is the mTLS mode of a PeerAuthentication. UNSET is normalized to an empty string.
+	Mode string
+	// PortLevelModes are the mTLS modes of a PeerAuthentication per workload port.
+	PortLevelModes map[uint32]string
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%s %s/%s", p.Kind, p.Namespace, p.Name)
+}
+
+type mtls struct {
+	Mode string `json:"mode"`
+}
+
+// policyResource is the subset of AuthorizationPolicy and PeerAuthentication needed to build a Policy.
+type policyResource struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Selector *struct {
+			MatchLabels map[string]string `json:"matchLabels"`
+		} `json:"selector"`
+		Action        string          `json:"action"`
+		Rules         []interface{}   `json:"rules"`
+		Mtls          *mtls           `json:"mtls"`
+		PortLevelMtls map[uint32]mtls `json:"portLevelMtls"`
+	} `json:"spec"`
+}
+
+// Parse returns the AuthorizationPolicy and PeerAuthentication resources in the given yaml. Resources without a
+// namespace are placed in ns, as they would be when applied to it. Other kinds are ignored.
+func Parse(ns, policyYAML string) ([]Policy, error) {
+	var out []Policy
+	for _, part := range yml.SplitString(policyYAML) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		r := policyResource{}
+		if err := yaml.Unmarshal([]byte(part), &r); err != nil {
+			return nil, fmt.Errorf("failed parsing policy: %v", err)
+		}
+		if r.Kind != AuthorizationPolicy && r.Kind != PeerAuthentication {
+			continue
+		}
+		p := Policy{
+			Kind:      r.Kind,
+			Name:      r.Metadata.Name,
+			Namespace: r.Metadata.Namespace,
+			Action:    r.Spec.Action,
+			Rules:     len(r.Spec.Rules),
+		}
+		if p.Namespace == "" {
+			p.Namespace = ns
+		}
+		if r.Spec.Selector != nil {
+			p.Selector = r.Spec.Selector.MatchLabels
+		}
+		if p.Kind == AuthorizationPolicy {
+			if p.Action == "" {
+				p.Action = "ALLOW"
+			}
+			if _, ok := rbacActions[p.Action]; !ok {
+				return nil, fmt.Errorf("%v: unsupported action %q", p, p.Action)
+			}
+		}
+		if r.Spec.Mtls != nil {
+			p.Mode = normalizeMode(r.Spec.Mtls.Mode)
+			if !validModes[p.Mode] {
+				return nil, fmt.Errorf("%v: unsupported mTLS mode %q", p, r.Spec.Mtls.Mode)
+			}
+		}
+		if len(r.Spec.PortLevelMtls) > 0 {
+			p.PortLevelModes = make(map[uint32]string, len(r.Spec.PortLevelMtls))
+			for port, m := range r.Spec.PortLevelMtls {
+				mode := normalizeMode(m.Mode)
+				if !validModes[mode] {
+					return nil, fmt.Errorf("%v: unsupported mTLS mode %q for port %d", p, m.Mode, port)
+				}
+				if mode != "" {
+					p.PortLevelModes[port] = mode
+				}
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+var validModes = map[string]bool{
+	"":           true,
+	"STRICT":     true,
+	"PERMISSIVE": true,
+	"DISABLE":    true,
+}
+
+func normalizeMode(mode string) string {
+	if mode == "UNSET" {
+		return ""
+	}
+	return mode
+}
+
+// Selects returns true if the policy applies to the workloads of the given echo instance. Policies in the root
+// namespace apply to all namespaces. Only the app and version labels of the echo workloads are known, so a
+// selector on any other label is assumed not to select the instance. A version selector selects the instance if
+// any of its subsets has that version.
+func (p Policy) Selects(c echo.Config, rootNamespace string) bool {
+	ns := ""
+	if c.Namespace != nil {
+		ns = c.Namespace.Name()
+	}
+	if p.Namespace != ns && p.Namespace != rootNamespace {
+		return false
+	}
+	for k, v := range p.Selector {
+		switch k {
+		case "app":
+			if v != c.Service {
+				return false
+			}
+		case "version":
+			found := false
+			for _, s := range c.Subsets {
+				if s.Version == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// peerAuthenticationModes returns the mTLS mode of each of the given ports, as determined by the most specific of
+// the PeerAuthentication policies selecting the instance: workload, then namespace, then mesh wide. Ports whose
+// mode is inherited from a policy not in the list are omitted.
+func peerAuthenticationModes(policies []Policy, c echo.Config, rootNamespace string, ports []uint32) map[uint32]string {
+	var workload, namespace, mesh *Policy
+	for i := range policies {
+		p := &policies[i]
+		if p.Kind != PeerAuthentication || !p.Selects(c, rootNamespace) {
+			continue
+		}
+		switch {
+		case len(p.Selector) > 0 && workload == nil:
+			workload = p
+		case len(p.Selector) == 0 && p.Namespace != rootNamespace && namespace == nil:
+			namespace = p
+		case len(p.Selector) == 0 && p.Namespace == rootNamespace && mesh == nil:
+			mesh = p
+		}
+	}
+	out := map[uint32]string{}
+	for _, port := range ports {
+		for _, p := range []*Policy{workload, namespace, mesh} {
+			if p == nil {
+				continue
+			}
+			if mode, ok := p.PortLevelModes[port]; ok {
+				out[port] = mode
+				break
+			}
+			if p.Mode != "" {
+				out[port] = p.Mode
+				break
+			}
+		}
+	}
+	return out
+}
+
+// WaitForPolicy waits until every AuthorizationPolicy and PeerAuthentication in policyYAML, as applied to ns, is
+// enforced by the sidecars of all workloads of the targets it selects. Workloads without a sidecar are skipped.
+//
+// An AuthorizationPolicy is enforced once the inbound listener has an RBAC filter with the policy's action
+// containing one of its rules. A rule rejected by istiod never shows up, so the wait times out. A policy without
+// rules, such as allow-nothing, generates no named RBAC policy, so an RBAC filter with its action is enough.
+//
+// A PeerAuthentication is enforced once the inbound filter chains of each workload port match the effective mode:
+// STRICT only accepts mTLS, PERMISSIVE accepts both mTLS and plaintext and DISABLE only plaintext. Ports with no
+// inbound filter chain of their own, such as workload only ports, are not checked.
+func WaitForPolicy(ctx resource.Context, ns, policyYAML string, targets echo.Instances, options ...retry.Option) error {
+	policies, err := Parse(ns, policyYAML)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	cfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	rootNamespace := cfg.SystemNamespace
+
+	for _, target := range targets {
+		c := target.Config()
+		var authz []Policy
+		for _, p := range policies {
+			if p.Kind == AuthorizationPolicy && p.Selects(c, rootNamespace) {
+				authz = append(authz, p)
+			}
+		}
+		ports := make([]uint32, 0, len(c.Ports))
+		for _, port := range c.Ports {
+			if port.InstancePort > 0 {
+				ports = append(ports, uint32(port.InstancePort))
+			}
+		}
+		modes := peerAuthenticationModes(policies, c, rootNamespace, ports)
+		if len(authz) == 0 && len(modes) == 0 {
+			continue
+		}
+
+		workloads, err := target.Workloads()
+		if err != nil {
+			return err
+		}
+		for _, w := range workloads {
+			s := w.Sidecar()
+			if s == nil {
+				continue
+			}
+			if err := s.WaitForConfig(func(dump *envoyAdmin.ConfigDump) (bool, error) {
+				return true, check(dump, authz, modes)
+			}, options...); err != nil {
+				return fmt.Errorf("policies not enforced by %s: %v", s.NodeID(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// WaitForPolicyOrFail calls WaitForPolicy and fails the test if an error is returned.
+func WaitForPolicyOrFail(t test.Failer, ctx resource.Context, ns, policyYAML string, targets echo.Instances,
+	options ...retry.Option) {
+	t.Helper()
+	if err := WaitForPolicy(ctx, ns, policyYAML, targets, options...); err != nil {
+		t.Fatalf("policy.WaitForPolicyOrFail: %v", err)
+	}
+}
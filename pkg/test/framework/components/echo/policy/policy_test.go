@@ -0,0 +1,285 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbachttp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/wrappers"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+type fakeNamespace string
+
+func (n fakeNamespace) Name() string {
+	return string(n)
+}
+
+const policies = `
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: default
+spec:
+  mtls:
+    mode: STRICT
+---
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: per-port
+spec:
+  selector:
+    matchLabels:
+      app: b
+  mtls:
+    mode: DISABLE
+  portLevelMtls:
+    8090:
+      mode: STRICT
+---
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: mesh
+  namespace: istio-system
+spec:
+  mtls:
+    mode: PERMISSIVE
+---
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: deny-b
+spec:
+  action: DENY
+  selector:
+    matchLabels:
+      app: b
+  rules:
+  - to:
+    - operation:
+        paths: ["/deny"]
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: ignored
+spec:
+  host: "*.local"
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse("ns", policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 policies, got %v", got)
+	}
+	if got[0].Namespace != "ns" || got[0].Mode != "STRICT" {
+		t.Errorf("unexpected namespace wide policy: %+v", got[0])
+	}
+	if got[1].Selector["app"] != "b" || got[1].Mode != "DISABLE" || got[1].PortLevelModes[8090] != "STRICT" {
+		t.Errorf("unexpected workload policy: %+v", got[1])
+	}
+	if got[2].Namespace != "istio-system" {
+		t.Errorf("unexpected mesh wide policy: %+v", got[2])
+	}
+	if got[3].Kind != AuthorizationPolicy || got[3].Action != "DENY" || got[3].Rules != 1 {
+		t.Errorf("unexpected authorization policy: %+v", got[3])
+	}
+
+	if _, err := Parse("ns", "kind: PeerAuthentication\nspec:\n  mtls:\n    mode: BOGUS\n"); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+	if _, err := Parse("ns", "kind: AuthorizationPolicy\nspec:\n  action: CUSTOM\n"); err == nil {
+		t.Error("expected an unsupported action to be rejected")
+	}
+}
+
+func TestPeerAuthenticationModes(t *testing.T) {
+	p, err := Parse("ns", policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports := []uint32{8090, 8091}
+	cases := []struct {
+		name   string
+		config echo.Config
+		want   map[uint32]string
+	}{
+		{
+			name:   "workload",
+			config: echo.Config{Namespace: fakeNamespace("ns"), Service: "b"},
+			want:   map[uint32]string{8090: "STRICT", 8091: "DISABLE"},
+		},
+		{
+			name:   "namespace",
+			config: echo.Config{Namespace: fakeNamespace("ns"), Service: "a"},
+			want:   map[uint32]string{8090: "STRICT", 8091: "STRICT"},
+		},
+		{
+			name:   "mesh",
+			config: echo.Config{Namespace: fakeNamespace("other"), Service: "b"},
+			want:   map[uint32]string{8090: "PERMISSIVE", 8091: "PERMISSIVE"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := peerAuthenticationModes(p, tt.config, "istio-system", ports)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for port, mode := range tt.want {
+				if got[port] != mode {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func toAny(t *testing.T, m proto.Message) *any.Any {
+	t.Helper()
+	a, err := ptypes.MarshalAny(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func filterChain(t *testing.T, port uint32, mTLS bool, rbac *rbacpb.RBAC) *listener.FilterChain {
+	t.Helper()
+	fc := &listener.FilterChain{
+		FilterChainMatch: &listener.FilterChainMatch{DestinationPort: &wrappers.UInt32Value{Value: port}},
+	}
+	if mTLS {
+		fc.TransportSocket = &core.TransportSocket{
+			Name:       wellknown.TransportSocketTls,
+			ConfigType: &core.TransportSocket_TypedConfig{TypedConfig: toAny(t, &tls.DownstreamTlsContext{})},
+		}
+	}
+	h := &hcm.HttpConnectionManager{}
+	if rbac != nil {
+		h.HttpFilters = append(h.HttpFilters, &hcm.HttpFilter{
+			Name:       wellknown.HTTPRoleBasedAccessControl,
+			ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: toAny(t, &rbachttp.RBAC{Rules: rbac})},
+		})
+	}
+	fc.Filters = []*listener.Filter{{
+		Name:       wellknown.HTTPConnectionManager,
+		ConfigType: &listener.Filter_TypedConfig{TypedConfig: toAny(t, h)},
+	}}
+	return fc
+}
+
+func configDump(t *testing.T, chains ...*listener.FilterChain) *envoyAdmin.ConfigDump {
+	t.Helper()
+	listeners := &envoyAdmin.ListenersConfigDump{
+		DynamicListeners: []*envoyAdmin.ListenersConfigDump_DynamicListener{{
+			Name: virtualInbound,
+			ActiveState: &envoyAdmin.ListenersConfigDump_DynamicListenerState{
+				Listener: toAny(t, &listener.Listener{Name: virtualInbound, FilterChains: chains}),
+			},
+		}},
+	}
+	return &envoyAdmin.ConfigDump{Configs: []*any.Any{toAny(t, listeners)}}
+}
+
+func TestCheck(t *testing.T) {
+	deny := &rbacpb.RBAC{
+		Action:   rbacpb.RBAC_DENY,
+		Policies: map[string]*rbacpb.Policy{"ns[ns]-policy[deny-b]-rule[0]": {}},
+	}
+	authz := []Policy{{Kind: AuthorizationPolicy, Name: "deny-b", Namespace: "ns", Action: "DENY", Rules: 1}}
+	cases := []struct {
+		name    string
+		dump    *envoyAdmin.ConfigDump
+		authz   []Policy
+		modes   map[uint32]string
+		wantErr bool
+	}{
+		{
+			name:  "strict",
+			dump:  configDump(t, filterChain(t, 8090, true, nil)),
+			modes: map[uint32]string{8090: "STRICT"},
+		},
+		{
+			name:    "strict not yet pushed",
+			dump:    configDump(t, filterChain(t, 8090, true, nil), filterChain(t, 8090, false, nil)),
+			modes:   map[uint32]string{8090: "STRICT"},
+			wantErr: true,
+		},
+		{
+			name:  "permissive",
+			dump:  configDump(t, filterChain(t, 8090, true, nil), filterChain(t, 8090, false, nil)),
+			modes: map[uint32]string{8090: "PERMISSIVE"},
+		},
+		{
+			name:  "disable",
+			dump:  configDump(t, filterChain(t, 8090, false, nil)),
+			modes: map[uint32]string{8090: "DISABLE", 9999: "STRICT"},
+		},
+		{
+			name:  "authorization",
+			dump:  configDump(t, filterChain(t, 8090, false, deny)),
+			authz: authz,
+		},
+		{
+			name:    "authorization not yet pushed",
+			dump:    configDump(t, filterChain(t, 8090, false, nil)),
+			authz:   authz,
+			wantErr: true,
+		},
+		{
+			name:    "authorization with other action",
+			dump:    configDump(t, filterChain(t, 8090, false, &rbacpb.RBAC{Policies: deny.Policies})),
+			authz:   authz,
+			wantErr: true,
+		},
+		{
+			name:  "authorization without rules",
+			dump:  configDump(t, filterChain(t, 8090, false, &rbacpb.RBAC{})),
+			authz: []Policy{{Kind: AuthorizationPolicy, Name: "allow-nothing", Namespace: "ns", Action: "ALLOW"}},
+		},
+		{
+			name:    "no inbound listener",
+			dump:    &envoyAdmin.ConfigDump{},
+			modes:   map[uint32]string{8090: "STRICT"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := check(tt.dump, tt.authz, tt.modes)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
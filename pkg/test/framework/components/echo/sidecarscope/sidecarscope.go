@@ -0,0 +1,307 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sidecarscope generates Sidecar resources restricting the egress hosts of the workloads of a namespace,
+// and checks that the clusters of their proxies shrink accordingly. Istiod only pushes the services a Sidecar
+// allows, so a proxy scoped to a few hosts should have no outbound cluster for any other service.
+package sidecarscope
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+// DefaultName is the name of the namespace wide Sidecar, applied to the workloads without a Sidecar of their own.
+const DefaultName = "default"
+
+// Sidecar is a Sidecar resource restricting the egress hosts of the workloads of a namespace.
+type Sidecar struct {
+	// Name of the Sidecar. Defaults to DefaultName.
+	Name      string
+	Namespace string
+	// Selector is the matchLabels of the workload selector. Empty for the namespace wide Sidecar.
+	Selector map[string]string
+	// Hosts are the egress hosts, in the "namespace/dnsName" form of the Sidecar API. The namespace may be "*" for
+	// any namespace or "." for the namespace of the Sidecar, and the dnsName may start with a "*" wildcard.
+	Hosts []string
+}
+
+// ForNamespace returns the namespace wide Sidecar of ns, allowing egress only to the given hosts.
+func ForNamespace(ns string, hosts ...string) Sidecar {
+	return Sidecar{
+		Namespace: ns,
+		Hosts:     hosts,
+	}
+}
+
+// ServiceHosts returns the egress hosts allowing the services of the given echo instances.
+func ServiceHosts(instances ...echo.Instance) []string {
+	out := make([]string, 0, len(instances))
+	for _, i := range instances {
+		c := i.Config()
+		ns := "*"
+		if c.Namespace != nil {
+			ns = c.Namespace.Name()
+		}
+		out = append(out, ns+"/"+c.FQDN())
+	}
+	return out
+}
+
+// NamespaceHosts returns the egress hosts allowing all of the services of the given namespaces.
+func NamespaceHosts(namespaces ...string) []string {
+	out := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		out = append(out, ns+"/*")
+	}
+	return out
+}
+
+const sidecarTemplate = `
+apiVersion: networking.istio.io/v1alpha3
+kind: Sidecar
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+{{- if .Selector }}
+  workloadSelector:
+    labels:
+{{- range $k, $v := .Selector }}
+      {{ $k }}: "{{ $v }}"
+{{- end }}
+{{- end }}
+  egress:
+  - hosts:
+{{- range .Hosts }}
+    - "{{ . }}"
+{{- end }}
+`
+
+// YAML returns the Sidecar resource.
+func (s Sidecar) YAML() string {
+	if s.Name == "" {
+		s.Name = DefaultName
+	}
+	return tmpl.MustEvaluate(sidecarTemplate, s)
+}
+
+// HostNamespaces are the namespaces of services which are not Kubernetes services, such as the hosts of
+// ServiceEntries, by hostname.
+type HostNamespaces map[string]string
+
+// namespaceOf returns the namespace of the service with the given hostname. The namespace of a Kubernetes service
+// is taken from its hostname, and the namespace of any other service is looked up in namespaces.
+func namespaceOf(hostname string, namespaces HostNamespaces) string {
+	if parts := strings.Split(hostname, "."); len(parts) > 2 && parts[2] == "svc" {
+		return parts[1]
+	}
+	return namespaces[hostname]
+}
+
+// Allows returns true if the egress hosts of the Sidecar include the service with the given hostname, declared in
+// the given namespace. A service whose namespace is unknown is only allowed by the hosts of any namespace.
+func (s Sidecar) Allows(serviceNamespace, hostname string) bool {
+	for _, h := range s.Hosts {
+		ns, dnsName := "*", h
+		if idx := strings.Index(h, "/"); idx >= 0 {
+			ns, dnsName = h[:idx], h[idx+1:]
+		}
+		switch ns {
+		case "~":
+			continue
+		case ".":
+			ns = s.Namespace
+		}
+		if ns != "*" && ns != serviceNamespace {
+			continue
+		}
+		if matchesDNSName(dnsName, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesDNSName(pattern, hostname string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*") {
+		return strings.HasSuffix(hostname, pattern[1:])
+	}
+	return pattern == hostname
+}
+
+// Selects returns true if the Sidecar applies to the workloads of the given echo instance. Only the app and
+// version labels of the echo workloads are known, so a selector on any other label is assumed not to select the
+// instance.
+func (s Sidecar) Selects(c echo.Config) bool {
+	if c.Namespace == nil || c.Namespace.Name() != s.Namespace {
+		return false
+	}
+	for k, v := range s.Selector {
+		switch k {
+		case "app":
+			if v != c.Service {
+				return false
+			}
+		case "version":
+			found := false
+			for _, subset := range c.Subsets {
+				if subset.Version == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// OutboundHosts returns the sorted hostnames of the outbound clusters of an Envoy.
+func OutboundHosts(clusters *envoyAdmin.Clusters) []string {
+	hosts := map[string]bool{}
+	for _, c := range clusters.GetClusterStatuses() {
+		// Outbound clusters are named outbound|<port>|<subset>|<hostname>.
+		parts := strings.Split(c.Name, "|")
+		if len(parts) == 4 && parts[0] == "outbound" {
+			hosts[parts[3]] = true
+		}
+	}
+	out := make([]string, 0, len(hosts))
+	for h := range hosts {
+		out = append(out, h)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Snapshot holds the outbound hosts of sidecars, by node ID, to compare them after applying a Sidecar.
+type Snapshot map[string][]string
+
+// TakeSnapshot records the outbound hosts of the sidecars of all workloads of the given instances.
+func TakeSnapshot(instances echo.Instances) (Snapshot, error) {
+	out := Snapshot{}
+	for _, i := range instances {
+		workloads, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workloads {
+			s := w.Sidecar()
+			if s == nil {
+				continue
+			}
+			clusters, err := s.Clusters()
+			if err != nil {
+				return nil, err
+			}
+			out[s.NodeID()] = OutboundHosts(clusters)
+		}
+	}
+	return out, nil
+}
+
+// TakeSnapshotOrFail calls TakeSnapshot and fails the test if an error is returned.
+func TakeSnapshotOrFail(t test.Failer, instances echo.Instances) Snapshot {
+	t.Helper()
+	s, err := TakeSnapshot(instances)
+	if err != nil {
+		t.Fatalf("sidecarscope.TakeSnapshotOrFail: %v", err)
+	}
+	return s
+}
+
+// checkScope returns an error unless all the outbound hosts are allowed by the Sidecar. If the hosts before the
+// Sidecar was applied are known, scoping must not have added any.
+func checkScope(s Sidecar, namespaces HostNamespaces, before, after []string) error {
+	known := map[string]bool{}
+	for _, h := range before {
+		known[h] = true
+	}
+	var disallowed, added []string
+	for _, h := range after {
+		if !s.Allows(namespaceOf(h, namespaces), h) {
+			disallowed = append(disallowed, h)
+		}
+		if before != nil && !known[h] {
+			added = append(added, h)
+		}
+	}
+	if len(disallowed) > 0 {
+		return fmt.Errorf("%d of %d outbound hosts are not allowed by the Sidecar: %v", len(disallowed), len(after), disallowed)
+	}
+	if len(added) > 0 {
+		return fmt.Errorf("outbound hosts were added by the Sidecar: %v", added)
+	}
+	return nil
+}
+
+// WaitForScope waits until the outbound hosts of the sidecars of all workloads selected by the Sidecar are
+// allowed by it. The namespaces of the outbound hosts which are not Kubernetes services must be given, or they are
+// only allowed by the hosts of any namespace. For the sidecars in before, the hosts must also be a subset of the
+// snapshot: together, the checks assert the cluster set shrank to what the Sidecar allows. Instances the Sidecar
+// does not select are skipped.
+func WaitForScope(s Sidecar, namespaces HostNamespaces, before Snapshot, instances echo.Instances,
+	options ...retry.Option) error {
+	for _, i := range instances {
+		if !s.Selects(i.Config()) {
+			continue
+		}
+		workloads, err := i.Workloads()
+		if err != nil {
+			return err
+		}
+		for _, w := range workloads {
+			sc := w.Sidecar()
+			if sc == nil {
+				continue
+			}
+			err := retry.UntilSuccess(func() error {
+				clusters, err := sc.Clusters()
+				if err != nil {
+					return err
+				}
+				return checkScope(s, namespaces, before[sc.NodeID()], OutboundHosts(clusters))
+			}, options...)
+			if err != nil {
+				return fmt.Errorf("sidecar %s not scoped: %v", sc.NodeID(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// WaitForScopeOrFail calls WaitForScope and fails the test if an error is returned.
+func WaitForScopeOrFail(t test.Failer, s Sidecar, namespaces HostNamespaces, before Snapshot, instances echo.Instances,
+	options ...retry.Option) {
+	t.Helper()
+	if err := WaitForScope(s, namespaces, before, instances, options...); err != nil {
+		t.Fatalf("sidecarscope.WaitForScopeOrFail: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecarscope
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+type fakeNamespace string
+
+func (n fakeNamespace) Name() string {
+	return string(n)
+}
+
+func TestYAML(t *testing.T) {
+	s := Sidecar{
+		Namespace: "app",
+		Selector:  map[string]string{"app": "a"},
+		Hosts:     []string{"./*", "istio-system/*"},
+	}
+	var got struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			WorkloadSelector struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"workloadSelector"`
+			Egress []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"egress"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(s.YAML()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != "Sidecar" || got.Metadata.Name != DefaultName || got.Metadata.Namespace != "app" {
+		t.Fatalf("unexpected resource %+v", got)
+	}
+	if !reflect.DeepEqual(got.Spec.WorkloadSelector.Labels, s.Selector) {
+		t.Fatalf("got selector %v, want %v", got.Spec.WorkloadSelector.Labels, s.Selector)
+	}
+	if len(got.Spec.Egress) != 1 || !reflect.DeepEqual(got.Spec.Egress[0].Hosts, s.Hosts) {
+		t.Fatalf("got egress %+v, want hosts %v", got.Spec.Egress, s.Hosts)
+	}
+}
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		hosts     []string
+		namespace string
+		hostname  string
+		want      bool
+	}{
+		{[]string{"app/b.app.svc.cluster.local"}, "app", "b.app.svc.cluster.local", true},
+		{[]string{"app/b.app.svc.cluster.local"}, "app", "c.app.svc.cluster.local", false},
+		{[]string{"./*"}, "app", "c.app.svc.cluster.local", true},
+		{[]string{"./*"}, "other", "c.other.svc.cluster.local", false},
+		{[]string{"*/*.other.svc.cluster.local"}, "other", "c.other.svc.cluster.local", true},
+		{[]string{"other/*"}, "app", "c.app.svc.cluster.local", false},
+		{[]string{"~/*"}, "app", "c.app.svc.cluster.local", false},
+		{[]string{"other/*.example.com"}, "other", "www.example.com", true},
+		{[]string{"other/*.example.com"}, "other", "www.example.org", false},
+		{[]string{"./*"}, "other", "www.example.com", false},
+		// A service whose namespace is unknown is only allowed by the hosts of any namespace.
+		{[]string{"other/*.example.com"}, "", "www.example.com", false},
+		{[]string{"*/*.example.com"}, "", "www.example.com", true},
+	}
+	for _, c := range cases {
+		s := ForNamespace("app", c.hosts...)
+		if got := s.Allows(c.namespace, c.hostname); got != c.want {
+			t.Errorf("%v allows %s/%s: got %v, want %v", c.hosts, c.namespace, c.hostname, got, c.want)
+		}
+	}
+}
+
+func TestSelects(t *testing.T) {
+	c := echo.Config{
+		Service:   "a",
+		Namespace: fakeNamespace("app"),
+		Subsets:   []echo.SubsetConfig{{Version: "v1"}},
+	}
+	cases := []struct {
+		name string
+		s    Sidecar
+		want bool
+	}{
+		{"namespace wide", Sidecar{Namespace: "app"}, true},
+		{"other namespace", Sidecar{Namespace: "other"}, false},
+		{"app", Sidecar{Namespace: "app", Selector: map[string]string{"app": "a", "version": "v1"}}, true},
+		{"other app", Sidecar{Namespace: "app", Selector: map[string]string{"app": "b"}}, false},
+		{"unknown label", Sidecar{Namespace: "app", Selector: map[string]string{"team": "x"}}, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Selects(c); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutboundHosts(t *testing.T) {
+	clusters := &envoyAdmin.Clusters{}
+	for _, name := range []string{
+		"outbound|80||b.app.svc.cluster.local",
+		"outbound|80|v1|b.app.svc.cluster.local",
+		"outbound|9090||a.other.svc.cluster.local",
+		"inbound|8080||",
+		"BlackHoleCluster",
+		"PassthroughCluster",
+	} {
+		clusters.ClusterStatuses = append(clusters.ClusterStatuses, &envoyAdmin.ClusterStatus{Name: name})
+	}
+	want := []string{"a.other.svc.cluster.local", "b.app.svc.cluster.local"}
+	if got := OutboundHosts(clusters); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	s := ForNamespace("app", "./*")
+	namespaces := HostNamespaces{"www.example.com": "app", "www.example.org": "other"}
+	before := []string{"a.app.svc.cluster.local", "b.app.svc.cluster.local", "c.other.svc.cluster.local",
+		"www.example.com", "www.example.org"}
+	cases := []struct {
+		name   string
+		before []string
+		after  []string
+		err    string
+	}{
+		{"shrunk", before, []string{"a.app.svc.cluster.local", "b.app.svc.cluster.local", "www.example.com"}, ""},
+		{"service entry of other namespace", before, []string{"www.example.org"}, "not allowed"},
+		{"unknown service entry", nil, []string{"www.example.net"}, "not allowed"},
+		{"not pushed yet", before, before, "not allowed"},
+		{"added", before, []string{"d.app.svc.cluster.local"}, "added"},
+		{"no snapshot", nil, []string{"d.app.svc.cluster.local"}, ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkScope(s, namespaces, tt.before, tt.after)
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sniffing checks how proxies select the protocol of service ports. It provides echo ports whose protocol
+// is declared by their name, by their appProtocol, or not at all, and a matrix of calls with the protocol the
+// client proxy is expected to handle each of them with.
+package sniffing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+// Outcome is the way the client proxy handles a request.
+type Outcome string
+
+const (
+	// HTTP requests are parsed and routed by the proxy.
+	HTTP Outcome = "http"
+	// TCP requests are forwarded as opaque bytes.
+	TCP Outcome = "tcp"
+)
+
+// routedHeader is added by proxies to the requests they route as HTTP, and never to the ones they forward as TCP.
+const routedHeader = "x-envoy-attempt-count"
+
+// Ports of the sniffing echo deployment. The protocol of the "auto-" ports is detected from the traffic, the one
+// of the "app-" ports is set by their appProtocol, and the one of the other ports by their name. The "-http"
+// ports declared as TCP are served by HTTP servers, to check that HTTP traffic to them is not parsed.
+var Ports = []echo.Port{
+	{Name: "http", Protocol: protocol.HTTP, ServicePort: 80, InstancePort: 18080},
+	{Name: "grpc", Protocol: protocol.GRPC, ServicePort: 7070, InstancePort: 17070},
+	{Name: "tcp", Protocol: protocol.TCP, ServicePort: 9090, InstancePort: 19090},
+	{Name: "tcp-http", Protocol: protocol.HTTP, ServicePort: 9093, InstancePort: 19093},
+	{Name: "auto-http", Protocol: protocol.HTTP, ServicePort: 81, InstancePort: 18081},
+	{Name: "auto-grpc", Protocol: protocol.GRPC, ServicePort: 7071, InstancePort: 17071},
+	{Name: "auto-tcp", Protocol: protocol.TCP, ServicePort: 9091, InstancePort: 19091},
+	{Name: "app-http", Protocol: protocol.HTTP, ServicePort: 82, InstancePort: 18082, AppProtocol: "http"},
+	{Name: "app-grpc", Protocol: protocol.GRPC, ServicePort: 7072, InstancePort: 17072, AppProtocol: "grpc"},
+	{Name: "app-tcp", Protocol: protocol.TCP, ServicePort: 9092, InstancePort: 19092, AppProtocol: "tcp"},
+	{Name: "app-tcp-http", Protocol: protocol.HTTP, ServicePort: 9094, InstancePort: 19094, AppProtocol: "tcp"},
+}
+
+// Config returns the configuration of an echo deployment exposing all the Ports.
+func Config(service string, ns namespace.Instance) echo.Config {
+	return echo.Config{
+		Service:   service,
+		Namespace: ns,
+		Ports:     Ports,
+		Subsets:   []echo.SubsetConfig{{}},
+	}
+}
+
+// Case is a call to a port of the sniffing deployment.
+type Case struct {
+	// Port is the name of the called port.
+	Port string
+	// Scheme of the request.
+	Scheme scheme.Instance
+	// Expected is the way the client proxy is expected to handle the request.
+	Expected Outcome
+	// Skip is set to the reason the case is skipped, if any.
+	Skip string
+}
+
+func (c Case) String() string {
+	return fmt.Sprintf("%s over %s", c.Scheme, c.Port)
+}
+
+// Matrix of calls to the Ports with their expected outcome. HTTP requests to ports declared as TCP are not parsed
+// by the proxy, while the protocol of the other ports is either declared or detected.
+var Matrix = []Case{
+	{Port: "http", Scheme: scheme.HTTP, Expected: HTTP},
+	{Port: "grpc", Scheme: scheme.GRPC, Expected: HTTP},
+	{Port: "tcp", Scheme: scheme.TCP, Expected: TCP},
+	{Port: "tcp-http", Scheme: scheme.HTTP, Expected: TCP},
+	{Port: "auto-http", Scheme: scheme.HTTP, Expected: HTTP},
+	{Port: "auto-grpc", Scheme: scheme.GRPC, Expected: HTTP},
+	{Port: "auto-tcp", Scheme: scheme.TCP, Expected: TCP, Skip: "https://github.com/istio/istio/issues/26798"},
+	{Port: "app-http", Scheme: scheme.HTTP, Expected: HTTP},
+	{Port: "app-grpc", Scheme: scheme.GRPC, Expected: HTTP},
+	{Port: "app-tcp", Scheme: scheme.TCP, Expected: TCP},
+	{Port: "app-tcp-http", Scheme: scheme.HTTP, Expected: TCP},
+}
+
+// Validate returns a Validator that checks that the requests were handled by the client proxy as expected.
+// Requests sent with the TCP scheme carry no headers, so only their success is checked.
+func Validate(c Case) echo.Validator {
+	return func(responses client.ParsedResponses) error {
+		if c.Scheme == scheme.TCP {
+			return nil
+		}
+		for i, r := range responses {
+			if got := outcome(r); got != c.Expected {
+				return fmt.Errorf("%s: response[%d] was handled as %s, expected %s", c, i, got, c.Expected)
+			}
+		}
+		return nil
+	}
+}
+
+// outcome returns the way the request of the response was handled by the client proxy.
+func outcome(r *client.ParsedResponse) Outcome {
+	for k := range r.RawResponse {
+		if strings.EqualFold(k, routedHeader) {
+			return HTTP
+		}
+	}
+	return TCP
+}
+
+// Run calls the destination from the source with each of the cases in a sub test, and checks they are handled
+// as expected. The destination must expose the Ports.
+func Run(ctx framework.TestContext, source, destination echo.Instance, cases []Case) {
+	for _, c := range cases {
+		c := c
+		ctx.NewSubTest(fmt.Sprintf("%s %s->%s", c, source.Config().Service, destination.Config().Service)).
+			Run(func(ctx framework.TestContext) {
+				if c.Skip != "" {
+					ctx.Skip(c.Skip)
+				}
+				source.CallWithRetryOrFail(ctx, echo.CallOptions{
+					Target:     destination,
+					PortName:   c.Port,
+					Scheme:     c.Scheme,
+					Timeout:    5 * time.Second,
+					Validators: echo.NewValidators().WithOK().With(Validate(c)),
+				})
+			})
+	}
+}
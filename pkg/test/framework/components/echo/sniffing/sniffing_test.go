@@ -0,0 +1,64 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sniffing
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+)
+
+func TestValidate(t *testing.T) {
+	routed := client.ParsedResponses{{RawResponse: map[string]string{"X-Envoy-Attempt-Count": "1"}}}
+	grpcRouted := client.ParsedResponses{{RawResponse: map[string]string{"x-envoy-attempt-count": "1"}}}
+	forwarded := client.ParsedResponses{{RawResponse: map[string]string{"User-Agent": "Go-http-client/1.1"}}}
+
+	cases := []struct {
+		c         Case
+		responses client.ParsedResponses
+		valid     bool
+	}{
+		{Case{Port: "http", Scheme: scheme.HTTP, Expected: HTTP}, routed, true},
+		{Case{Port: "grpc", Scheme: scheme.GRPC, Expected: HTTP}, grpcRouted, true},
+		{Case{Port: "auto-http", Scheme: scheme.HTTP, Expected: HTTP}, forwarded, false},
+		{Case{Port: "tcp-http", Scheme: scheme.HTTP, Expected: TCP}, forwarded, true},
+		{Case{Port: "tcp-http", Scheme: scheme.HTTP, Expected: TCP}, routed, false},
+		{Case{Port: "tcp", Scheme: scheme.TCP, Expected: TCP}, client.ParsedResponses{{}}, true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.c.String(), func(t *testing.T) {
+			err := Validate(tt.c)(tt.responses)
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	ports := map[string]bool{}
+	for _, p := range Ports {
+		ports[p.Name] = true
+	}
+	for _, c := range Matrix {
+		if !ports[c.Port] {
+			t.Errorf("case %s calls unknown port %s", c, c.Port)
+		}
+	}
+}
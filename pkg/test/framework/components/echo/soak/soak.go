@@ -0,0 +1,202 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package soak provides the scenarios and checks of echo based soak suites. Scenarios send traffic or churn
+// config for a while, and checks assert that the sidecars do not leak memory and keep renewing their certificates
+// as the soak run goes on. See framework.SoakConfig.
+package soak
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/churn"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// SidecarsFunc returns the sidecars to check. It is called for every check, so that the sidecars of pods
+// replaced during the soak run are picked up.
+type SidecarsFunc func() ([]echo.Sidecar, error)
+
+// Sidecars returns the sidecars of all workloads of the given instances.
+func Sidecars(instances echo.Instances) SidecarsFunc {
+	return func() ([]echo.Sidecar, error) {
+		var out []echo.Sidecar
+		for _, i := range instances {
+			workloads, err := i.Workloads()
+			if err != nil {
+				return nil, err
+			}
+			for _, w := range workloads {
+				if s := w.Sidecar(); s != nil {
+					out = append(out, s)
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+// Traffic returns a scenario sending requests with the given configuration for the given duration, which fails
+// if less than minSuccessRate of them succeeded.
+func Traffic(name string, cfg traffic.Config, duration time.Duration, minSuccessRate float64) framework.SoakScenario {
+	return framework.SoakScenario{
+		Name: name,
+		Run: func(resource.Context) error {
+			g := traffic.NewGenerator(cfg).Start()
+			time.Sleep(duration)
+			return g.Stop().CheckSuccessRate(minSuccessRate)
+		},
+	}
+}
+
+// ConfigChurn returns a scenario applying each of the given configs to ns, then deleting them, in order, waiting
+// the given interval after each change.
+func ConfigChurn(name, ns string, interval time.Duration, configs ...string) framework.SoakScenario {
+	return framework.SoakScenario{
+		Name: name,
+		Run: func(ctx resource.Context) error {
+			for _, c := range configs {
+				if err := ctx.Config().ApplyYAML(ns, c); err != nil {
+					return err
+				}
+				time.Sleep(interval)
+			}
+			for i := len(configs) - 1; i >= 0; i-- {
+				if err := ctx.Config().DeleteYAML(ns, configs[i]); err != nil {
+					return err
+				}
+				time.Sleep(interval)
+			}
+			return nil
+		},
+	}
+}
+
+// memoryBaseline tracks the memory first sampled for each sidecar.
+type memoryBaseline struct {
+	mu       sync.Mutex
+	baseline map[string]float64
+}
+
+// check records the first sample of each sidecar, and returns an error for the sidecars whose memory grew by more
+// than maxFactor since.
+func (m *memoryBaseline) check(samples map[string]float64, maxFactor float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs error
+	for node, v := range samples {
+		before, ok := m.baseline[node]
+		if !ok {
+			m.baseline[node] = v
+			continue
+		}
+		if before > 0 && v > before*maxFactor {
+			errs = multierror.Append(errs, fmt.Errorf("memory of %s grew from %.0f to %.0f bytes, more than %.2fx",
+				node, before, v, maxFactor))
+		}
+	}
+	return errs
+}
+
+// ProxyMemory returns a check failing when the memory allocated by one of the sidecars grew by more than maxFactor,
+// for example 1.5 for 50%, since it was first checked.
+func ProxyMemory(sidecars SidecarsFunc, maxFactor float64) framework.SoakCheck {
+	m := &memoryBaseline{baseline: map[string]float64{}}
+	return framework.SoakCheck{
+		Name: "proxy-memory",
+		Check: func(resource.Context) (map[string]float64, error) {
+			s, err := sidecars()
+			if err != nil {
+				return nil, err
+			}
+			samples, err := churn.SampleMemory(s)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[string]float64, len(samples))
+			for node, v := range samples {
+				values[node+"/memoryBytes"] = v
+			}
+			return values, m.check(samples, maxFactor)
+		},
+	}
+}
+
+// certRenewals tracks the workload certificate of each sidecar.
+type certRenewals struct {
+	mu       sync.Mutex
+	serials  map[string]string
+	renewals map[string]int
+}
+
+// observe records the serial of the leaf certificate of the sidecar, counting renewals. It returns an error if the
+// certificate expired, which means it was not renewed in time.
+func (c *certRenewals) observe(node, serial string, notAfter, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, ok := c.serials[node]; ok && previous != serial {
+		c.renewals[node]++
+	}
+	c.serials[node] = serial
+	if !now.Before(notAfter) {
+		return fmt.Errorf("certificate %s of %s expired at %v", serial, node, notAfter)
+	}
+	return nil
+}
+
+func (c *certRenewals) count(node string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.renewals[node]
+}
+
+// CertRenewal returns a check failing when the workload certificate of one of the sidecars expired, as it should
+// be renewed well before. The number of renewals and the remaining validity of each certificate are reported.
+func CertRenewal(sidecars SidecarsFunc) framework.SoakCheck {
+	c := &certRenewals{serials: map[string]string{}, renewals: map[string]int{}}
+	return framework.SoakCheck{
+		Name: "cert-renewal",
+		Check: func(resource.Context) (map[string]float64, error) {
+			s, err := sidecars()
+			if err != nil {
+				return nil, err
+			}
+			values := map[string]float64{}
+			var errs error
+			for _, sc := range s {
+				chain, err := mtls.WorkloadChain(sc)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("failed getting the certificate of %s: %v", sc.NodeID(), err))
+					continue
+				}
+				leaf := chain[0]
+				now := time.Now()
+				if err := c.observe(sc.NodeID(), leaf.SerialNumber.String(), leaf.NotAfter, now); err != nil {
+					errs = multierror.Append(errs, err)
+				}
+				values[sc.NodeID()+"/certRemainingSeconds"] = leaf.NotAfter.Sub(now).Seconds()
+				values[sc.NodeID()+"/certRenewals"] = float64(c.count(sc.NodeID()))
+			}
+			return values, errs
+		},
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soak
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryBaseline(t *testing.T) {
+	m := &memoryBaseline{baseline: map[string]float64{}}
+	if err := m.check(map[string]float64{"a": 100, "b": 100}, 1.5); err != nil {
+		t.Fatalf("unexpected error for the baseline: %v", err)
+	}
+	if err := m.check(map[string]float64{"a": 150, "b": 120}, 1.5); err != nil {
+		t.Fatalf("unexpected error within bounds: %v", err)
+	}
+	// A sidecar seen for the first time, for example after its pod was replaced, gets its own baseline.
+	if err := m.check(map[string]float64{"a": 140, "c": 1000}, 1.5); err != nil {
+		t.Fatalf("unexpected error for a new sidecar: %v", err)
+	}
+	err := m.check(map[string]float64{"a": 151, "c": 1000}, 1.5)
+	if err == nil || !strings.Contains(err.Error(), "memory of a grew from 100 to 151 bytes") {
+		t.Fatalf("expected growth of a to be reported, got %v", err)
+	}
+}
+
+func TestCertRenewals(t *testing.T) {
+	c := &certRenewals{serials: map[string]string{}, renewals: map[string]int{}}
+	now := time.Now()
+	for _, serial := range []string{"1", "1", "2", "2", "3"} {
+		if err := c.observe("a", serial, now.Add(time.Hour), now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := c.count("a"); got != 2 {
+		t.Fatalf("expected 2 renewals, got %d", got)
+	}
+	err := c.observe("a", "3", now.Add(-time.Second), now)
+	if err == nil || !strings.Contains(err.Error(), "certificate 3 of a expired") {
+		t.Fatalf("expected expiry to be reported, got %v", err)
+	}
+}
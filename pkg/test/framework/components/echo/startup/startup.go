@@ -0,0 +1,274 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package startup measures how long newly created echo pods take to get their injected sidecar ready and to
+// receive their first successful call through the mesh, so that injection and startup regressions can be caught
+// with threshold assertions.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	sidecarContainer    = "istio-proxy"
+	defaultTimeout      = 3 * time.Minute
+	defaultPollInterval = 500 * time.Millisecond
+	defaultCallCount    = 10
+)
+
+// Phase of the startup of a pod.
+type Phase string
+
+const (
+	// SidecarStarted is when the istio-proxy container started running.
+	SidecarStarted Phase = "sidecar-started"
+	// Ready is when the pod, and therefore its sidecar, became ready.
+	Ready Phase = "ready"
+	// FirstCall is when the pod first answered a call from the source through the mesh.
+	FirstCall Phase = "first-call"
+)
+
+// Config of a startup measure.
+type Config struct {
+	// Cluster where the pods are created.
+	Cluster resource.Cluster
+
+	// Namespace and Selector of the pods, for example "app=b". Pods that already exist when the measure starts are
+	// ignored.
+	Namespace string
+	Selector  string
+
+	// Replicas is the number of new pods to wait for. Defaults to 1.
+	Replicas int
+
+	// Source optionally calls the pods with Options, in which case the FirstCall phase is measured. Calls are
+	// load balanced, so Options.Count, which defaults to 10, should be large enough to reach every replica.
+	Source  traffic.Caller
+	Options echo.CallOptions
+
+	// Timeout defaults to 3m, PollInterval to 500ms.
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// Timing of the startup of a pod.
+type Timing struct {
+	Pod            string
+	Created        time.Time
+	SidecarStarted time.Time
+	Ready          time.Time
+	FirstCall      time.Time
+}
+
+// Duration returns the time from the creation of the pod to the given phase, or 0 if it was not reached. Kubernetes
+// timestamps have a resolution of a second, and the first call is timed with the clock of the test runner.
+func (t Timing) Duration(p Phase) time.Duration {
+	var at time.Time
+	switch p {
+	case SidecarStarted:
+		at = t.SidecarStarted
+	case Ready:
+		at = t.Ready
+	case FirstCall:
+		at = t.FirstCall
+	}
+	if at.IsZero() {
+		return 0
+	}
+	return at.Sub(t.Created)
+}
+
+// Result of a startup measure.
+type Result struct {
+	Timings []Timing
+}
+
+// Percentile returns the time to reach the given phase at the given percentile, in [0, 100], among the pods that
+// reached it.
+func (r Result) Percentile(phase Phase, p float64) time.Duration {
+	var sorted []time.Duration
+	for _, t := range r.Timings {
+		if d := t.Duration(phase); d != 0 {
+			sorted = append(sorted, d)
+		}
+	}
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	// Nearest rank.
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Check returns an error if no pods were measured, or if the time to reach the given phase at the given percentile
+// exceeds max.
+func (r Result) Check(phase Phase, p float64, max time.Duration) error {
+	if len(r.Timings) == 0 {
+		return fmt.Errorf("no pods were measured")
+	}
+	if d := r.Percentile(phase, p); d > max {
+		return fmt.Errorf("p%v time to %s %v above %v (%s)", p, phase, d, max, r)
+	}
+	return nil
+}
+
+// CheckOrFail calls Check and fails the test if an error is returned.
+func (r Result) CheckOrFail(t test.Failer, phase Phase, p float64, max time.Duration) {
+	t.Helper()
+	if err := r.Check(phase, p, max); err != nil {
+		t.Fatalf("startup.CheckOrFail: %v", err)
+	}
+}
+
+func (r Result) String() string {
+	var phases []string
+	for _, p := range []Phase{SidecarStarted, Ready, FirstCall} {
+		phases = append(phases, fmt.Sprintf("%s p50=%v max=%v", p, r.Percentile(p, 50), r.Percentile(p, 100)))
+	}
+	return fmt.Sprintf("%d pods: %s", len(r.Timings), strings.Join(phases, ", "))
+}
+
+// Measure calls create, which is expected to create new pods matching the configured selector, for example by
+// deploying or scaling an echo service, and returns how long they took to start.
+func Measure(cfg Config, create func() error) (Result, error) {
+	if cfg.Replicas == 0 {
+		cfg.Replicas = 1
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.Options.Count == 0 {
+		cfg.Options.Count = defaultCallCount
+	}
+
+	existing := map[string]struct{}{}
+	pods, err := cfg.Cluster.PodsForSelector(context.TODO(), cfg.Namespace, cfg.Selector)
+	if err != nil {
+		return Result{}, err
+	}
+	for _, pod := range pods.Items {
+		existing[pod.Name] = struct{}{}
+	}
+
+	if err := create(); err != nil {
+		return Result{}, err
+	}
+
+	timings := map[string]*Timing{}
+	err = retry.UntilSuccess(func() error {
+		pods, err := cfg.Cluster.PodsForSelector(context.TODO(), cfg.Namespace, cfg.Selector)
+		if err != nil {
+			return err
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if _, ok := existing[pod.Name]; ok || pod.DeletionTimestamp != nil {
+				continue
+			}
+			t, ok := timings[pod.Name]
+			if !ok {
+				t = &Timing{Pod: pod.Name, Created: pod.CreationTimestamp.Time}
+				timings[pod.Name] = t
+			}
+			updateTiming(t, pod)
+		}
+		if len(timings) < cfg.Replicas {
+			return fmt.Errorf("%d of %d pods created", len(timings), cfg.Replicas)
+		}
+
+		if cfg.Source != nil {
+			resp, _ := cfg.Source.Call(cfg.Options)
+			now := time.Now()
+			for _, r := range resp {
+				if t, ok := timings[r.Hostname]; ok && t.FirstCall.IsZero() {
+					t.FirstCall = now
+				}
+			}
+		}
+
+		var pending []string
+		for name, t := range timings {
+			if t.Ready.IsZero() || (cfg.Source != nil && t.FirstCall.IsZero()) {
+				pending = append(pending, name)
+			}
+		}
+		if len(pending) > 0 {
+			sort.Strings(pending)
+			return fmt.Errorf("pods not started yet: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}, retry.Timeout(cfg.Timeout), retry.Delay(cfg.PollInterval))
+
+	out := Result{}
+	for _, t := range timings {
+		out.Timings = append(out.Timings, *t)
+	}
+	sort.Slice(out.Timings, func(i, j int) bool {
+		return out.Timings[i].Pod < out.Timings[j].Pod
+	})
+	scopes.Framework.Infof("measured startup of %s/%s: %s", cfg.Namespace, cfg.Selector, out)
+	return out, err
+}
+
+// MeasureOrFail calls Measure and fails the test if an error is returned.
+func MeasureOrFail(t test.Failer, cfg Config, create func() error) Result {
+	t.Helper()
+	r, err := Measure(cfg, create)
+	if err != nil {
+		t.Fatalf("startup.MeasureOrFail: %v", err)
+	}
+	return r
+}
+
+// updateTiming records the phases the pod reached since it was last seen.
+func updateTiming(t *Timing, pod *corev1.Pod) {
+	if t.SidecarStarted.IsZero() {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == sidecarContainer && cs.State.Running != nil {
+				t.SidecarStarted = cs.State.Running.StartedAt.Time
+			}
+		}
+	}
+	if t.Ready.IsZero() {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				t.Ready = c.LastTransitionTime.Time
+			}
+		}
+	}
+}
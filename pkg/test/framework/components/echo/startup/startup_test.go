@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResult(t *testing.T) {
+	created := time.Unix(1000, 0)
+	timing := func(name string, ready, firstCall time.Duration) Timing {
+		t := Timing{Pod: name, Created: created, Ready: created.Add(ready)}
+		if firstCall != 0 {
+			t.FirstCall = created.Add(firstCall)
+		}
+		return t
+	}
+	r := Result{Timings: []Timing{
+		timing("a", 2*time.Second, 3*time.Second),
+		timing("b", 4*time.Second, 0),
+		timing("c", 6*time.Second, 9*time.Second),
+	}}
+
+	if got := r.Percentile(Ready, 50); got != 4*time.Second {
+		t.Fatalf("expected p50 ready of 4s, got %v", got)
+	}
+	if got := r.Percentile(Ready, 100); got != 6*time.Second {
+		t.Fatalf("expected max ready of 6s, got %v", got)
+	}
+	// Pods that did not reach a phase are not counted.
+	if got := r.Percentile(FirstCall, 50); got != 3*time.Second {
+		t.Fatalf("expected p50 first call of 3s, got %v", got)
+	}
+	if got := r.Percentile(SidecarStarted, 50); got != 0 {
+		t.Fatalf("expected no sidecar start time, got %v", got)
+	}
+
+	if err := r.Check(Ready, 90, 6*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Check(FirstCall, 90, 5*time.Second); err == nil {
+		t.Fatal("expected first call check to fail")
+	}
+	if err := (Result{}).Check(Ready, 50, time.Minute); err == nil {
+		t.Fatal("expected check without pods to fail")
+	}
+}
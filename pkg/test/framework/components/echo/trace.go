@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TraceContext is the context of a trace started by the test itself. Calls made with it (see CallOptions.Trace)
+// carry the context in the B3 and W3C trace context formats, so that whichever tracer the proxies use
+// continues the trace, which can then be found in the tracing backend by its ID.
+type TraceContext struct {
+	// TraceID is the 128 bit trace ID, as 32 lowercase hex characters.
+	TraceID string
+
+	// SpanID is the 64 bit ID of the (unreported) span of the test, as 16 lowercase hex characters.
+	SpanID string
+
+	// Baggage is sent in the W3C baggage header, if not empty.
+	Baggage map[string]string
+}
+
+// NewTraceContext returns a context for a new, sampled trace with a random ID.
+func NewTraceContext() *TraceContext {
+	return &TraceContext{
+		TraceID: randomHex(16),
+		SpanID:  randomHex(8),
+	}
+}
+
+// WithBaggage returns the context with the given baggage item added.
+func (c *TraceContext) WithBaggage(key, value string) *TraceContext {
+	if c.Baggage == nil {
+		c.Baggage = map[string]string{}
+	}
+	c.Baggage[key] = value
+	return c
+}
+
+// Headers returns the request headers carrying the context.
+func (c *TraceContext) Headers() http.Header {
+	h := http.Header{}
+	h.Set("x-b3-traceid", c.TraceID)
+	h.Set("x-b3-spanid", c.SpanID)
+	h.Set("x-b3-sampled", "1")
+	h.Set("traceparent", fmt.Sprintf("00-%s-%s-01", c.TraceID, c.SpanID))
+	if len(c.Baggage) > 0 {
+		items := make([]string, 0, len(c.Baggage))
+		for k, v := range c.Baggage {
+			items = append(items, k+"="+url.QueryEscape(v))
+		}
+		sort.Strings(items)
+		h.Set("baggage", strings.Join(items, ","))
+	}
+	return h
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// The system random number generator is never expected to fail.
+		panic(fmt.Sprintf("failed generating random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
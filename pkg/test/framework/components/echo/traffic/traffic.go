@@ -0,0 +1,180 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package traffic sends echo requests in the background while a test disrupts the mesh, and reports how many of
+// them failed.
+package traffic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	defaultInterval = 100 * time.Millisecond
+	// maxErrors is the number of errors kept in the result. The rest is only counted.
+	maxErrors = 10
+)
+
+// Caller sends a single request. It is satisfied by echo.Instance.
+type Caller interface {
+	Call(options echo.CallOptions) (client.ParsedResponses, error)
+}
+
+// Config of a Generator.
+type Config struct {
+	// Source of the requests.
+	Source Caller
+
+	// Options of every request.
+	Options echo.CallOptions
+
+	// Interval between requests. Defaults to 100ms.
+	Interval time.Duration
+
+	// Check is applied to the responses of every request. Defaults to checking for a 200 response.
+	Check func(client.ParsedResponses) error
+}
+
+// Result of a Generator run.
+type Result struct {
+	TotalRequests      int
+	SuccessfulRequests int
+	// Error holds the first failures, or is nil if all requests succeeded.
+	Error error
+}
+
+// SuccessRate returns the fraction of requests that succeeded.
+func (r Result) SuccessRate() float64 {
+	if r.TotalRequests == 0 {
+		return 0
+	}
+	return float64(r.SuccessfulRequests) / float64(r.TotalRequests)
+}
+
+// CheckSuccessRate checks that at least the given fraction of requests succeeded, and that requests were sent at all.
+func (r Result) CheckSuccessRate(minRate float64) error {
+	if r.TotalRequests == 0 {
+		return fmt.Errorf("no requests were sent")
+	}
+	if rate := r.SuccessRate(); rate < minRate {
+		return fmt.Errorf("success rate %.4f (%d/%d) is below %.4f: %v",
+			rate, r.SuccessfulRequests, r.TotalRequests, minRate, r.Error)
+	}
+	return nil
+}
+
+// CheckSuccessRateOrFail calls CheckSuccessRate and fails the test if an error is returned.
+func (r Result) CheckSuccessRateOrFail(t test.Failer, minRate float64) {
+	t.Helper()
+	if err := r.CheckSuccessRate(minRate); err != nil {
+		t.Fatalf("traffic.CheckSuccessRateOrFail: %v", err)
+	}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d/%d requests succeeded", r.SuccessfulRequests, r.TotalRequests)
+}
+
+// Generator sends requests in the background until stopped.
+type Generator interface {
+	// Start sending requests. It must only be called once.
+	Start() Generator
+
+	// Stop sending requests, and return the result once the last request completed.
+	Stop() Result
+}
+
+// NewGenerator returns a Generator with the given configuration.
+func NewGenerator(cfg Config) Generator {
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.Check == nil {
+		cfg.Check = func(resp client.ParsedResponses) error {
+			return resp.CheckOK()
+		}
+	}
+	return &generator{
+		Config:  cfg,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+type generator struct {
+	Config
+
+	mu      sync.Mutex
+	result  Result
+	errors  int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (g *generator) Start() Generator {
+	go func() {
+		defer close(g.stopped)
+		t := time.NewTicker(g.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-g.stop:
+				return
+			case <-t.C:
+				g.send()
+			}
+		}
+	}()
+	return g
+}
+
+func (g *generator) send() {
+	resp, err := g.Source.Call(g.Options)
+	if err == nil {
+		err = g.Check(resp)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.result.TotalRequests++
+	if err == nil {
+		g.result.SuccessfulRequests++
+		return
+	}
+	g.errors++
+	if g.errors <= maxErrors {
+		g.result.Error = multierror.Append(g.result.Error, fmt.Errorf("request %d at %s: %v",
+			g.result.TotalRequests, time.Now().Format(time.RFC3339Nano), err))
+	}
+	scopes.Framework.Debugf("traffic generator request failed: %v", err)
+}
+
+func (g *generator) Stop() Result {
+	close(g.stop)
+	<-g.stopped
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	scopes.Framework.Infof("traffic generator stopped: %s", g.result)
+	return g.result
+}
@@ -0,0 +1,84 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traffic
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+type fakeCaller struct {
+	calls int32
+	// fail returns whether the n-th call fails.
+	fail func(n int32) bool
+}
+
+func (c *fakeCaller) Call(echo.CallOptions) (client.ParsedResponses, error) {
+	n := atomic.AddInt32(&c.calls, 1)
+	if c.fail(n) {
+		return nil, errors.New("connection reset")
+	}
+	return client.ParsedResponses{{Code: "200"}}, nil
+}
+
+func TestGenerator(t *testing.T) {
+	caller := &fakeCaller{fail: func(n int32) bool { return n%2 == 0 }}
+	g := NewGenerator(Config{Source: caller, Interval: time.Millisecond}).Start()
+	time.Sleep(50 * time.Millisecond)
+	result := g.Stop()
+
+	if result.TotalRequests == 0 || int32(result.TotalRequests) != atomic.LoadInt32(&caller.calls) {
+		t.Fatalf("expected all %d calls to be counted, got %d", caller.calls, result.TotalRequests)
+	}
+	if result.SuccessfulRequests != (result.TotalRequests+1)/2 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if result.Error == nil {
+		t.Fatal("expected failures to be reported")
+	}
+	if err := result.CheckSuccessRate(0.4); err != nil {
+		t.Fatal(err)
+	}
+	if err := result.CheckSuccessRate(1); err == nil {
+		t.Fatal("expected success rate check to fail")
+	}
+}
+
+func TestGeneratorCheck(t *testing.T) {
+	caller := &fakeCaller{fail: func(int32) bool { return false }}
+	g := NewGenerator(Config{
+		Source:   caller,
+		Interval: time.Millisecond,
+		Check: func(client.ParsedResponses) error {
+			return errors.New("wrong version")
+		},
+	}).Start()
+	time.Sleep(10 * time.Millisecond)
+	result := g.Stop()
+	if result.SuccessfulRequests != 0 || result.TotalRequests == 0 {
+		t.Fatalf("expected all requests to fail the check, got %v", result)
+	}
+}
+
+func TestResultNoRequests(t *testing.T) {
+	if err := (Result{}).CheckSuccessRate(0); err == nil {
+		t.Fatal("expected a run without requests to fail")
+	}
+}
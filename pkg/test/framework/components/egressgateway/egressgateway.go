@@ -0,0 +1,118 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egressgateway
+
+import (
+	"fmt"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// DefaultName is the name of the gateway deployment and service, if none is configured.
+	DefaultName = "egress-test-gateway"
+)
+
+// Instance is a dedicated egress gateway deployed for a test.
+type Instance interface {
+	resource.Resource
+
+	// Name of the gateway deployment and service. The gateway pods carry the label istio=<Name>.
+	Name() string
+
+	// Namespace the gateway is deployed to.
+	Namespace() namespace.Instance
+
+	// ServiceHost returns the fully qualified host name of the gateway service.
+	ServiceHost() string
+
+	// AddRoute applies the configuration routing the traffic for the host through the gateway. The
+	// configuration is removed when the gateway is closed.
+	AddRoute(r Route) error
+
+	// AddRouteOrFail calls AddRoute and fails the test if an error is returned.
+	AddRouteOrFail(t test.Failer, r Route)
+
+	// Traffic returns the traffic sent by the gateway to the given host and port, summed across all the
+	// gateway pods.
+	Traffic(host string, port int) (Traffic, error)
+
+	// CheckTransit runs the call and verifies that it caused traffic to the given host and port to leave the
+	// mesh through the gateway.
+	CheckTransit(host string, port int, call func() error) error
+
+	// CheckTransitOrFail calls CheckTransit and fails the test if an error is returned.
+	CheckTransitOrFail(t test.Failer, host string, port int, call func() error)
+
+	// Logs returns the logs of the gateway proxies.
+	Logs() (string, error)
+}
+
+// Config for the egress gateway.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Name of the gateway. Defaults to DefaultName.
+	Name string
+
+	// Namespace to deploy the gateway to. If not set, a new namespace is created.
+	Namespace namespace.Instance
+}
+
+// Traffic sent by the gateway to an upstream host.
+type Traffic struct {
+	// Requests is the number of HTTP requests sent upstream.
+	Requests int
+	// Connections is the number of connections opened upstream. It is the only counter updated for TCP traffic.
+	Connections int
+}
+
+// Sub returns the traffic sent since the given snapshot was taken.
+func (t Traffic) Sub(before Traffic) Traffic {
+	return Traffic{
+		Requests:    t.Requests - before.Requests,
+		Connections: t.Connections - before.Connections,
+	}
+}
+
+func (t Traffic) String() string {
+	return fmt.Sprintf("requests=%d connections=%d", t.Requests, t.Connections)
+}
+
+// New deploys an egress gateway and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	if c.Name == "" {
+		c.Name = DefaultName
+	}
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("egressgateway.NewOrFail: %v", err)
+	}
+	return i
+}
+
+// transitRetry is used by CheckTransit to wait for the stats of the gateway to be updated.
+var transitRetry = []retry.Option{retry.Timeout(30 * time.Second), retry.Delay(time.Second)}
@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egressgateway
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/config/protocol"
+)
+
+func TestParseTraffic(t *testing.T) {
+	statsJSON := `{"stats":[
+{"name":"cluster.outbound|80||example.com.upstream_rq_total","value":7},
+{"name":"cluster.outbound|80||example.com.upstream_cx_total","value":2},
+{"name":"cluster.outbound|443||example.com.upstream_cx_total","value":5},
+{"name":"cluster.outbound|80||other.example.com.upstream_rq_total","value":3},
+{"name":"cluster.outbound|80||example.com.upstream_rq_time"}
+]}`
+	got, err := parseTraffic(statsJSON, "example.com", 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Traffic{Requests: 7, Connections: 2}); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := parseTraffic("not json", "example.com", 80); err == nil {
+		t.Fatal("expected error for invalid stats")
+	}
+}
+
+func TestRouteYAML(t *testing.T) {
+	cases := []struct {
+		name     string
+		route    Route
+		contains []string
+		excludes []string
+	}{
+		{
+			name:  "http",
+			route: Route{Host: "example.com", Port: 80, ServiceEntry: true},
+			contains: []string{
+				"kind: ServiceEntry", "name: example-com", "istio: egress", "number: 80", "protocol: HTTP",
+				"mode: DISABLE", "http:", "host: egress.ns.svc.cluster.local",
+			},
+			excludes: []string{"ISTIO_MUTUAL", "tcp:"},
+		},
+		{
+			name:     "http mtls",
+			route:    Route{Host: "example.com", Port: 80, MutualTLS: true},
+			contains: []string{"number: 443", "protocol: HTTPS", "mode: ISTIO_MUTUAL", "sni: example.com"},
+			excludes: []string{"kind: ServiceEntry", "mode: DISABLE"},
+		},
+		{
+			name:     "tcp",
+			route:    Route{Name: "db", Host: "db.example.com", Port: 3306, Protocol: protocol.TCP},
+			contains: []string{"name: db", "number: 31400", "protocol: TCP", "tcp:"},
+			excludes: []string{"http:"},
+		},
+		{
+			name:     "tcp mtls",
+			route:    Route{Host: "db.example.com", Port: 3306, Protocol: protocol.TCP, MutualTLS: true},
+			contains: []string{"number: 443", "protocol: TLS", "mode: ISTIO_MUTUAL", "tcp:"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			yaml, err := RouteYAML(tt.route, "egress", "egress.ns.svc.cluster.local")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(yaml, s) {
+					t.Errorf("expected %q in:\n%s", s, yaml)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(yaml, s) {
+					t.Errorf("unexpected %q in:\n%s", s, yaml)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteYAMLInvalid(t *testing.T) {
+	for _, r := range []Route{
+		{Port: 80},
+		{Host: "example.com"},
+		{Host: "example.com", Port: 80, Protocol: protocol.UDP},
+	} {
+		if _, err := RouteYAML(r, "egress", "egress.ns.svc.cluster.local"); err == nil {
+			t.Errorf("expected error for %+v", r)
+		}
+	}
+}
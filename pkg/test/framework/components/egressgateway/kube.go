@@ -0,0 +1,283 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egressgateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/istioctl"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	proxyContainerName = "istio-proxy"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+// operatorTemplate deploys only the egress gateway. The outbound cluster stats are included so that
+// traffic through the gateway can be verified.
+const operatorTemplate = `
+apiVersion: install.istio.io/v1alpha1
+kind: IstioOperator
+spec:
+  profile: empty
+  components:
+    egressGateways:
+    - name: {{ .Name }}
+      namespace: {{ .Namespace }}
+      enabled: true
+      label:
+        istio: {{ .Name }}
+        app: {{ .Name }}
+      k8s:
+        podAnnotations:
+          sidecar.istio.io/statsInclusionPrefixes: cluster.outbound
+        service:
+          ports:
+          - name: http2
+            port: 80
+            targetPort: 8080
+          - name: https
+            port: 443
+            targetPort: 8443
+          - name: tcp
+            port: 31400
+            targetPort: 31400
+`
+
+type kubeComponent struct {
+	id      resource.ID
+	name    string
+	ns      namespace.Instance
+	cluster resource.Cluster
+	ctx     resource.Context
+
+	mu     sync.Mutex
+	routes []string
+	close  func()
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		name:    cfg.Name,
+		ns:      cfg.Namespace,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		ctx:     ctx,
+	}
+	c.id = ctx.TrackResource(c)
+
+	if c.ns == nil {
+		var err error
+		if c.ns, err = namespace.New(ctx, namespace.Config{Prefix: "egress"}); err != nil {
+			return nil, err
+		}
+	}
+
+	istioCfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	imgSettings, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+
+	iop, err := tmpl.Evaluate(operatorTemplate, map[string]string{
+		"Name":      c.name,
+		"Namespace": c.ns.Name(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := ctx.CreateTmpDirectory("egressgateway")
+	if err != nil {
+		return nil, err
+	}
+	iopFile := filepath.Join(workDir, c.name+".yaml")
+	if err := ioutil.WriteFile(iopFile, []byte(iop), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	istioCtl, err := istioctl.New(ctx, istioctl.Config{Cluster: c.cluster})
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"manifest", "generate",
+		"--istioNamespace", istioCfg.SystemNamespace,
+		"--manifests", filepath.Join(env.IstioSrc, "manifests"),
+		"--set", "hub=" + imgSettings.Hub,
+		"--set", "tag=" + imgSettings.Tag,
+		"--set", "values.global.imagePullPolicy=" + imgSettings.PullPolicy,
+		"-f", iopFile,
+	}
+	scopes.Framework.Infof("Deploying egress gateway %s in %s: %v", c.name, c.cluster.Name(), args)
+	gwYaml, stderr, err := istioCtl.Invoke(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed generating egress gateway manifest: %v: %s", err, stderr)
+	}
+
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), gwYaml); err != nil {
+		return nil, err
+	}
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML(c.ns.Name(), gwYaml)
+	}
+
+	fetchFn := testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "istio="+c.name)
+	if _, err := testKube.WaitUntilPodsAreReady(fetchFn); err != nil {
+		return nil, fmt.Errorf("failed waiting for egress gateway %s to become ready: %v", c.name, err)
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Name() string {
+	return c.name
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) ServiceHost() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", c.name, c.ns.Name())
+}
+
+func (c *kubeComponent) AddRoute(r Route) error {
+	yaml, err := RouteYAML(r, c.name, c.ServiceHost())
+	if err != nil {
+		return err
+	}
+	if err := c.ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.routes = append(c.routes, yaml)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *kubeComponent) AddRouteOrFail(t test.Failer, r Route) {
+	t.Helper()
+	if err := c.AddRoute(r); err != nil {
+		t.Fatalf("egressgateway.AddRouteOrFail: %v", err)
+	}
+}
+
+func (c *kubeComponent) Traffic(host string, port int) (Traffic, error) {
+	pods, err := c.cluster.PodsForSelector(context.TODO(), c.ns.Name(), "istio="+c.name)
+	if err != nil {
+		return Traffic{}, fmt.Errorf("unable to get egress gateway pods: %v", err)
+	}
+	var total Traffic
+	for _, pod := range pods.Items {
+		stdout, stderr, err := c.cluster.PodExec(pod.Name, pod.Namespace, proxyContainerName,
+			"pilot-agent request GET stats?format=json")
+		if err != nil {
+			return Traffic{}, fmt.Errorf("failed to get stats of %s: %v: %s", pod.Name, err, stderr)
+		}
+		t, err := parseTraffic(stdout, host, port)
+		if err != nil {
+			return Traffic{}, fmt.Errorf("failed to parse stats of %s: %v", pod.Name, err)
+		}
+		total.Requests += t.Requests
+		total.Connections += t.Connections
+	}
+	return total, nil
+}
+
+func (c *kubeComponent) CheckTransit(host string, port int, call func() error) error {
+	before, err := c.Traffic(host, port)
+	if err != nil {
+		return err
+	}
+	if err := call(); err != nil {
+		return err
+	}
+	return retry.UntilSuccess(func() error {
+		after, err := c.Traffic(host, port)
+		if err != nil {
+			return err
+		}
+		sent := after.Sub(before)
+		if sent.Requests <= 0 && sent.Connections <= 0 {
+			return fmt.Errorf("no traffic to %s:%d went through egress gateway %s (%s)", host, port, c.name, sent)
+		}
+		scopes.Framework.Debugf("egress gateway %s sent to %s:%d: %s", c.name, host, port, sent)
+		return nil
+	}, transitRetry...)
+}
+
+func (c *kubeComponent) CheckTransitOrFail(t test.Failer, host string, port int, call func() error) {
+	t.Helper()
+	if err := c.CheckTransit(host, port, call); err != nil {
+		t.Fatalf("egressgateway.CheckTransitOrFail: %v", err)
+	}
+}
+
+func (c *kubeComponent) Logs() (string, error) {
+	pods, err := c.cluster.PodsForSelector(context.TODO(), c.ns.Name(), "istio="+c.name)
+	if err != nil {
+		return "", fmt.Errorf("unable to get egress gateway pods: %v", err)
+	}
+	var sb strings.Builder
+	for _, pod := range pods.Items {
+		logs, err := c.cluster.PodLogs(context.TODO(), pod.Name, pod.Namespace, proxyContainerName, false)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(logs)
+	}
+	return sb.String(), nil
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	c.mu.Lock()
+	routes := c.routes
+	c.routes = nil
+	c.mu.Unlock()
+	for _, yaml := range routes {
+		_ = c.ctx.Config(c.cluster).DeleteYAML(c.ns.Name(), yaml)
+	}
+	if c.close != nil {
+		c.close()
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egressgateway
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+// Ports of the gateway service, see the IstioOperator used to deploy it.
+const (
+	httpPort = 80
+	tlsPort  = 443
+	tcpPort  = 31400
+)
+
+// Route describes traffic for a host that leaves the mesh through the egress gateway.
+type Route struct {
+	// Name of the generated resources. Defaults to the host, with dots replaced by dashes.
+	Name string
+
+	// Host the traffic is destined to.
+	Host string
+
+	// Port of the host.
+	Port int
+
+	// Protocol of the traffic. HTTP and TCP based protocols are supported. Defaults to HTTP.
+	Protocol protocol.Instance
+
+	// ServiceEntry adds a DNS resolved ServiceEntry for the host. It is needed for hosts outside of the
+	// mesh, unless the outbound traffic policy is ALLOW_ANY.
+	ServiceEntry bool
+
+	// MutualTLS makes sidecars use ISTIO_MUTUAL for the traffic sent to the gateway, which is otherwise
+	// plaintext.
+	MutualTLS bool
+}
+
+type routeParams struct {
+	Route
+	Gateway      string
+	GatewayHost  string
+	GatewayPort  int
+	ServerName   string
+	ServerProto  protocol.Instance
+	PortName     string
+	PortProtocol protocol.Instance
+	TCP          bool
+}
+
+func (r Route) params(gateway, gatewayHost string) (routeParams, error) {
+	if r.Host == "" {
+		return routeParams{}, fmt.Errorf("egress route requires a host")
+	}
+	if r.Port == 0 {
+		return routeParams{}, fmt.Errorf("egress route for %s requires a port", r.Host)
+	}
+	if r.Protocol == "" {
+		r.Protocol = protocol.HTTP
+	}
+	if r.Name == "" {
+		r.Name = strings.ReplaceAll(r.Host, ".", "-")
+	}
+	p := routeParams{
+		Route:        r,
+		Gateway:      gateway,
+		GatewayHost:  gatewayHost,
+		PortName:     strings.ToLower(string(r.Protocol)),
+		PortProtocol: r.Protocol,
+	}
+	switch {
+	case r.Protocol.IsHTTP() && r.MutualTLS:
+		p.GatewayPort, p.ServerName, p.ServerProto = tlsPort, "https", protocol.HTTPS
+	case r.Protocol.IsHTTP():
+		p.GatewayPort, p.ServerName, p.ServerProto = httpPort, "http", protocol.HTTP
+	case r.Protocol.IsTCP() && r.MutualTLS:
+		p.GatewayPort, p.ServerName, p.ServerProto, p.TCP = tlsPort, "tls", protocol.TLS, true
+	case r.Protocol.IsTCP():
+		p.GatewayPort, p.ServerName, p.ServerProto, p.TCP = tcpPort, "tcp", protocol.TCP, true
+	default:
+		return routeParams{}, fmt.Errorf("egress route for %s: unsupported protocol %s", r.Host, r.Protocol)
+	}
+	return p, nil
+}
+
+const routeTemplate = `
+{{- if .ServiceEntry }}
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: {{ .Name }}
+spec:
+  hosts:
+  - {{ .Host }}
+  ports:
+  - number: {{ .Port }}
+    name: {{ .PortName }}
+    protocol: {{ .PortProtocol }}
+  resolution: DNS
+  location: MESH_EXTERNAL
+---
+{{- end }}
+apiVersion: networking.istio.io/v1alpha3
+kind: Gateway
+metadata:
+  name: {{ .Name }}
+spec:
+  selector:
+    istio: {{ .Gateway }}
+  servers:
+  - port:
+      number: {{ .GatewayPort }}
+      name: {{ .ServerName }}
+      protocol: {{ .ServerProto }}
+    hosts:
+    - {{ .Host }}
+{{- if .MutualTLS }}
+    tls:
+      mode: ISTIO_MUTUAL
+{{- end }}
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: {{ .Name }}
+spec:
+  host: {{ .GatewayHost }}
+  subsets:
+  - name: {{ .Name }}
+    trafficPolicy:
+      portLevelSettings:
+      - port:
+          number: {{ .GatewayPort }}
+        tls:
+{{- if .MutualTLS }}
+          mode: ISTIO_MUTUAL
+          sni: {{ .Host }}
+{{- else }}
+          mode: DISABLE
+{{- end }}
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: {{ .Name }}
+spec:
+  hosts:
+  - {{ .Host }}
+  gateways:
+  - mesh
+  - {{ .Name }}
+  {{ if .TCP }}tcp{{ else }}http{{ end }}:
+  - match:
+    - gateways:
+      - mesh
+      port: {{ .Port }}
+    route:
+    - destination:
+        host: {{ .GatewayHost }}
+        subset: {{ .Name }}
+        port:
+          number: {{ .GatewayPort }}
+  - match:
+    - gateways:
+      - {{ .Name }}
+      port: {{ .GatewayPort }}
+    route:
+    - destination:
+        host: {{ .Host }}
+        port:
+          number: {{ .Port }}
+`
+
+// RouteYAML returns the ServiceEntry, Gateway, DestinationRule and VirtualService which route the traffic
+// for the host from the sidecars through the named gateway. The configuration must be applied to the
+// namespace of the gateway, whose service has the given host name.
+func RouteYAML(r Route, gateway, gatewayHost string) (string, error) {
+	p, err := r.params(gateway, gatewayHost)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Evaluate(routeTemplate, p)
+}
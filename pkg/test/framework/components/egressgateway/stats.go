@@ -0,0 +1,56 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egressgateway
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type statEntry struct {
+	Name  string      `json:"name"`
+	Value json.Number `json:"value"`
+}
+
+type stats struct {
+	StatList []statEntry `json:"stats"`
+}
+
+// parseTraffic returns the traffic sent to the outbound cluster of the host and port, from the JSON stats
+// of an Envoy.
+func parseTraffic(statsJSON, host string, port int) (Traffic, error) {
+	var s stats
+	if err := json.Unmarshal([]byte(statsJSON), &s); err != nil {
+		return Traffic{}, fmt.Errorf("unable to unmarshal stats from json: %v", err)
+	}
+	prefix := fmt.Sprintf("cluster.outbound|%d||%s.", port, host)
+	var t Traffic
+	for _, e := range s.StatList {
+		if e.Value == "" {
+			continue
+		}
+		v, err := e.Value.Int64()
+		if err != nil {
+			continue
+		}
+		switch e.Name {
+		case prefix + "upstream_rq_total":
+			t.Requests = int(v)
+		case prefix + "upstream_cx_total":
+			t.Connections = int(v)
+		}
+	}
+	return t, nil
+}
@@ -16,10 +16,15 @@ package kube
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"net"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
 )
 
 var _ resource.Cluster = Cluster{}
@@ -30,6 +35,15 @@ type Cluster struct {
 	filename    string
 	networkName string
 	index       resource.ClusterIndex
+	// loadBalancer indicates that LoadBalancer services obtain an external IP, as set in the Settings.
+	loadBalancer bool
+}
+
+// capabilityGroupVersions are the capabilities detected from the APIs served by the cluster.
+var capabilityGroupVersions = map[resource.Capability]string{
+	resource.CRDv1:         "apiextensions.k8s.io/v1",
+	resource.GatewayAPI:    "networking.x-k8s.io/v1alpha1",
+	resource.EndpointSlice: "discovery.k8s.io/v1beta1",
 }
 
 func (c Cluster) String() string {
@@ -61,3 +75,58 @@ func (c Cluster) Name() string {
 func (c Cluster) Index() resource.ClusterIndex {
 	return c.index
 }
+
+// Capabilities detects the Kubernetes version and the capabilities of the cluster. Only a failure to detect the
+// version is returned, the capabilities which cannot be detected are left unset.
+func (c Cluster) Capabilities() (resource.Capabilities, error) {
+	info, err := c.GetKubernetesVersion()
+	if err != nil {
+		return resource.Capabilities{}, err
+	}
+	version, err := resource.ParseKubernetesVersion(info.Major + "." + info.Minor)
+	if err != nil {
+		return resource.Capabilities{}, err
+	}
+	caps := resource.Capabilities{
+		Version: version,
+		Supported: map[resource.Capability]bool{
+			resource.LoadBalancer: c.loadBalancer,
+		},
+	}
+	for cp, gv := range capabilityGroupVersions {
+		// An error is returned if the group version is not served.
+		if _, err := c.Discovery().ServerResourcesForGroupVersion(gv); err == nil {
+			caps.Supported[cp] = true
+		}
+	}
+	// Listing the nodes may not be permitted, which must not prevent checking the version.
+	nodes, err := c.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+	if err != nil {
+		scopes.Framework.Warnf("failed listing the nodes of %s, assuming it is not dual-stack: %v", c.Name(), err)
+		return caps, nil
+	}
+	caps.Supported[resource.DualStack] = len(nodes.Items) > 0
+	for _, n := range nodes.Items {
+		if !dualStack(n.Spec.PodCIDRs) {
+			caps.Supported[resource.DualStack] = false
+		}
+	}
+	return caps, nil
+}
+
+// dualStack returns true if the CIDRs include both an IPv4 and an IPv6 range.
+func dualStack(cidrs []string) bool {
+	var v4, v6 bool
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ip.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	return v4 && v6
+}
@@ -0,0 +1,135 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+// minCredentialRefreshInterval bounds how often the kubeconfig file is reloaded, when concurrent requests are
+// rejected with the same expired credentials.
+const minCredentialRefreshInterval = 10 * time.Second
+
+// credentialRefresher is the innermost transport of the clients of a cluster. When the API server rejects the
+// credentials of a request, it reloads the kubeconfig file, and sends the request again, as well as all the
+// following ones, through a transport built from the reloaded credentials. This keeps long-running suites alive
+// when short-lived tokens expire, or when exec plugins and auth providers rotate their credentials.
+type credentialRefresher struct {
+	kubeConfig string
+	base       http.RoundTripper
+	// newTransport builds a transport, including its credentials, from the kubeconfig file.
+	newTransport func(kubeConfig string) (http.RoundTripper, error)
+
+	mu          sync.RWMutex
+	refreshed   http.RoundTripper
+	refreshedAt time.Time
+}
+
+var _ http.RoundTripper = &credentialRefresher{}
+
+// withCredentialRefresh configures the rest config to refresh the credentials from the kubeconfig file.
+func withCredentialRefresh(kubeConfig string) func(*rest.Config) {
+	return func(config *rest.Config) {
+		config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &credentialRefresher{
+				kubeConfig:   kubeConfig,
+				base:         rt,
+				newTransport: newTransportFromKubeConfig,
+			}
+		})
+	}
+}
+
+func newTransportFromKubeConfig(kubeConfig string) (http.RoundTripper, error) {
+	config, err := istioKube.BuildClientConfig(kubeConfig, "")
+	if err != nil {
+		return nil, err
+	}
+	return rest.TransportFor(config)
+}
+
+func (r *credentialRefresher) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	retry, ok := rewind(req)
+	if !ok {
+		return resp, nil
+	}
+	if err := r.refresh(); err != nil {
+		scopes.Framework.Warnf("failed refreshing the credentials of %s: %v", r.kubeConfig, err)
+		return resp, nil
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return r.send(retry)
+}
+
+// send sends the request with the reloaded credentials once they were refreshed. The credentials set by the outer
+// transports are then stale, so they are removed.
+func (r *credentialRefresher) send(req *http.Request) (*http.Response, error) {
+	r.mu.RLock()
+	rt := r.refreshed
+	r.mu.RUnlock()
+	if rt == nil {
+		return r.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Del("Authorization")
+	return rt.RoundTrip(req)
+}
+
+func (r *credentialRefresher) refresh() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.refreshedAt) < minCredentialRefreshInterval {
+		return nil
+	}
+	rt, err := r.newTransport(r.kubeConfig)
+	if err != nil {
+		return err
+	}
+	r.refreshed = rt
+	r.refreshedAt = time.Now()
+	scopes.Framework.Infof("reloaded the credentials of %s after an unauthorized response", r.kubeConfig)
+	return nil
+}
+
+// rewind returns a copy of the request which can be sent again, or false if its body cannot be read again.
+func rewind(req *http.Request) (*http.Request, bool) {
+	out := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return out, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	out.Body = body
+	return out, true
+}
@@ -0,0 +1,96 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCredentialRefresher(t *testing.T) {
+	token := "expired"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	refreshes := 0
+	r := &credentialRefresher{
+		kubeConfig: "kubeconfig",
+		base:       withToken(http.DefaultTransport, "expired"),
+		newTransport: func(string) (http.RoundTripper, error) {
+			refreshes++
+			return withToken(http.DefaultTransport, "refreshed"), nil
+		},
+	}
+	client := &http.Client{Transport: withToken(r, "expired")}
+
+	if resp := post(t, client, srv.URL, "before"); resp != "before" {
+		t.Fatalf("got %q before the expiry", resp)
+	}
+	token = "refreshed"
+	for _, want := range []string{"retried", "after"} {
+		if resp := post(t, client, srv.URL, want); resp != want {
+			t.Fatalf("got %q, want %q", resp, want)
+		}
+	}
+	if refreshes != 1 {
+		t.Fatalf("got %d refreshes, want 1", refreshes)
+	}
+}
+
+func post(t *testing.T, client *http.Client, url, body string) string {
+	t.Helper()
+	resp, err := client.Post(url, "text/plain", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.Status
+	}
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+type tokenRoundTripper struct {
+	rt    http.RoundTripper
+	token string
+}
+
+// withToken sets the bearer token of the requests without one, as the client-go bearer auth transport does.
+func withToken(rt http.RoundTripper, token string) http.RoundTripper {
+	return &tokenRoundTripper{rt: rt, token: token}
+}
+
+func (t *tokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.rt.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.rt.RoundTrip(req)
+}
@@ -46,6 +46,14 @@ var (
 	networkTopology string
 	// hold configTopology from command line to parse later
 	configTopology string
+	// hold configOnlyClusters from command line to parse later
+	configOnlyClusters string
+	// hold kindTopology from command line to parse later
+	kindTopology string
+	// kind settings other than the topology
+	kindImage  string
+	kindConfig string
+	kindKeep   bool
 )
 
 // NewSettingsFromCommandLine returns Settings obtained from command-line flags.
@@ -57,6 +65,10 @@ func NewSettingsFromCommandLine() (*Settings, error) {
 
 	s := settingsFromCommandLine.clone()
 
+	if kindTopology != "" {
+		return newKindSettings(s)
+	}
+
 	// Process the kube configs.
 	var err error
 	s.KubeConfig, err = parseKubeConfigs(kubeConfigs, ",")
@@ -96,6 +108,43 @@ func NewSettingsFromCommandLine() (*Settings, error) {
 		return nil, err
 	}
 
+	s.ConfigOnlyClusters, err = parseConfigOnlyClusters()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateConfigOnlyClusters(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// newKindSettings returns settings for kind clusters created by the environment, whose kubeconfigs and
+// topologies are defined by the kind topology file.
+func newKindSettings(s *Settings) (*Settings, error) {
+	if kubeConfigs != "" || controlPlaneTopology != "" || networkTopology != "" || configTopology != "" ||
+		configOnlyClusters != "" {
+		return nil, fmt.Errorf("istio.test.kube.kind.topology cannot be used with the kube config and topology flags")
+	}
+	clusters, err := parseKindTopology(kindTopology)
+	if err != nil {
+		return nil, err
+	}
+	s.Kind = &KindSettings{
+		Clusters:      clusters,
+		Image:         kindImage,
+		Config:        kindConfig,
+		Keep:          kindKeep,
+		KubeConfigDir: filepath.Join(os.TempDir(), "istio-kind"),
+	}
+	s.KubeConfig = s.Kind.KubeConfigs()
+	s.ControlPlaneTopology, s.ConfigTopology, s.networkTopology = s.Kind.topologies()
+	s.ConfigOnlyClusters = s.Kind.configOnlyClusters()
+	if err := s.validateConfigOnlyClusters(); err != nil {
+		return nil, err
+	}
+	// MetalLB is installed in every cluster.
+	s.LoadBalancerSupported = true
 	return s, nil
 }
 
@@ -246,6 +295,21 @@ func parseConfigTopology() (clusterTopology, error) {
 	return out, nil
 }
 
+func parseConfigOnlyClusters() (map[resource.ClusterIndex]bool, error) {
+	out := make(map[resource.ClusterIndex]bool)
+	if configOnlyClusters == "" {
+		return out, nil
+	}
+	for _, v := range strings.Split(configOnlyClusters, ",") {
+		clusterIndex, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || clusterIndex < 0 {
+			return nil, fmt.Errorf("failed parsing config-only cluster index %s", v)
+		}
+		out[resource.ClusterIndex(clusterIndex)] = true
+	}
+	return out, nil
+}
+
 func parseNetworkTopology(kubeConfigs []string) (map[resource.ClusterIndex]string, error) {
 	out := make(map[resource.ClusterIndex]string)
 	if controlPlaneTopology == "" {
@@ -304,6 +368,10 @@ func init() {
 		"Indicates whether or not clusters in the environment support external IPs for LoadBalaner services. Used "+
 			"to obtain the right IP address for the Ingress Gateway. Set --istio.test.kube.loadbalancer=false for local KinD/Minikube tests."+
 			"without MetalLB installed.")
+	flag.BoolVar(&settingsFromCommandLine.MetalLB, "istio.test.kube.metallb", settingsFromCommandLine.MetalLB,
+		"Installs MetalLB to the kind clusters which don't have it yet, with address pools taken from the kind docker "+
+			"network, and routes the pools between the clusters, so that the gateways get LoadBalancer IPs reachable "+
+			"across clusters. Implies istio.test.kube.loadbalancer.")
 	flag.StringVar(&controlPlaneTopology, "istio.test.kube.controlPlaneTopology",
 		"", "Specifies the mapping for each cluster to the cluster hosting its control plane. The value is a "+
 			"comma-separated list of the form <clusterIndex>:<controlPlaneClusterIndex>, where the indexes refer to the order in which "+
@@ -318,4 +386,21 @@ func init() {
 		"", "Specifies the mapping for each cluster to the cluster hosting its config. The value is a "+
 			"comma-separated list of the form <clusterIndex>:<configClusterIndex>, where the indexes refer to the order in which "+
 			"a given cluster appears in the 'istio.test.kube.config' flag. If not specified, the default is every cluster maps to itself(e.g. 0:0,1:1,...).")
+	flag.StringVar(&configOnlyClusters, "istio.test.kube.configOnlyClusters",
+		"", "Specifies the clusters which only host Istio configuration for an external control plane, and run no "+
+			"workloads. The value is a comma-separated list of cluster indexes, which must be config clusters in "+
+			"'istio.test.kube.configTopology' and map to another cluster in 'istio.test.kube.controlPlaneTopology'.")
+	flag.StringVar(&kindTopology, "istio.test.kube.kind.topology", "",
+		"Path to a cluster topology file, in the format of prow/config/topology, describing kind clusters to create "+
+			"before running the tests and to delete afterwards. The topology defines the kube configs, control plane, "+
+			"config and network topologies, so the corresponding flags must not be set. Requires kind, docker and kubectl.")
+	flag.StringVar(&kindImage, "istio.test.kube.kind.image", defaultKindImage,
+		"Node image of the kind clusters created with istio.test.kube.kind.topology.")
+	flag.StringVar(&kindConfig, "istio.test.kube.kind.config", filepath.Join(env.IstioSrc, "prow/config/trustworthy-jwt.yaml"),
+		"Kind configuration used as a base for the clusters created with istio.test.kube.kind.topology.")
+	flag.BoolVar(&kindKeep, "istio.test.kube.kind.keep", false,
+		"Keep the kind clusters created with istio.test.kube.kind.topology after the tests, and reuse them on the next run.")
+	flag.BoolVar(&settingsFromCommandLine.OpenShift, "istio.test.kube.openshift", settingsFromCommandLine.OpenShift,
+		"Indicates that the clusters are OpenShift clusters. Test namespaces are then allowed the anyuid "+
+			"SecurityContextConstraints, and Istio is installed with CNI. Detected if not set.")
 }
@@ -0,0 +1,411 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/shell"
+)
+
+const (
+	defaultKindImage = "kindest/node:v1.19.1"
+	// kindNetwork is the docker network shared by all kind clusters.
+	kindNetwork = "kind"
+	// metalLBManifests install MetalLB, which hands out LoadBalancer IPs from the kind docker network.
+	metalLBManifests = "https://raw.githubusercontent.com/metallb/metallb/v0.9.3/manifests/"
+	// metalLBPoolSize is the number of LoadBalancer IPs given to each cluster, taken from the end of the kind
+	// docker network.
+	metalLBPoolSize = 10
+)
+
+// KindCluster is an entry of a kind topology file, in the same format as the files in prow/config/topology.
+type KindCluster struct {
+	Name              string `json:"cluster_name"`
+	PodSubnet         string `json:"pod_subnet"`
+	SvcSubnet         string `json:"svc_subnet"`
+	NetworkID         int    `json:"network_id"`
+	ControlPlaneIndex int    `json:"control_plane_index"`
+	ConfigIndex       int    `json:"config_index"`
+	// ConfigOnly clusters only host the Istio configuration of their external control plane.
+	ConfigOnly bool `json:"config_only"`
+}
+
+// KindSettings configure the kind clusters that the environment creates before running the tests, and deletes
+// once they completed.
+type KindSettings struct {
+	// Clusters to create, in the order of their cluster index.
+	Clusters []KindCluster
+
+	// Image of the kind nodes.
+	Image string
+
+	// Config is the kind cluster configuration file used as a base for all clusters. Their pod and service
+	// subnets are appended to it.
+	Config string
+
+	// Keep the clusters after the tests, and reuse existing clusters with the same name rather than recreating
+	// them.
+	Keep bool
+
+	// KubeConfigDir is where the kubeconfig files of the clusters are written.
+	KubeConfigDir string
+}
+
+// parseKindTopology reads a kind topology file.
+func parseKindTopology(file string) ([]KindCluster, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading kind topology: %v", err)
+	}
+	var out []KindCluster
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("failed parsing kind topology %s: %v", file, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("kind topology %s has no clusters", file)
+	}
+	for i, c := range out {
+		if c.Name == "" {
+			return nil, fmt.Errorf("kind topology %s: cluster %d has no name", file, i)
+		}
+		if c.ControlPlaneIndex >= len(out) || c.ConfigIndex >= len(out) {
+			return nil, fmt.Errorf("kind topology %s: cluster %s refers to a cluster index out of range", file, c.Name)
+		}
+	}
+	return out, nil
+}
+
+// KubeConfigs returns the kubeconfig files of the clusters, which only exist once they are provisioned.
+func (k *KindSettings) KubeConfigs() []string {
+	out := make([]string, 0, len(k.Clusters))
+	for _, c := range k.Clusters {
+		out = append(out, filepath.Join(k.KubeConfigDir, c.Name))
+	}
+	return out
+}
+
+// topologies returns the control plane, config and network topologies defined by the kind topology.
+func (k *KindSettings) topologies() (controlPlanes, configs clusterTopology, networks map[resource.ClusterIndex]string) {
+	controlPlanes = make(clusterTopology)
+	configs = make(clusterTopology)
+	networks = make(map[resource.ClusterIndex]string)
+	for i, c := range k.Clusters {
+		index := resource.ClusterIndex(i)
+		controlPlanes[index] = resource.ClusterIndex(c.ControlPlaneIndex)
+		configs[index] = resource.ClusterIndex(c.ConfigIndex)
+		networks[index] = fmt.Sprintf("test-network-%d", c.NetworkID)
+	}
+	return
+}
+
+// configOnlyClusters returns the indexes of the config-only clusters.
+func (k *KindSettings) configOnlyClusters() map[resource.ClusterIndex]bool {
+	out := make(map[resource.ClusterIndex]bool)
+	for i, c := range k.Clusters {
+		if c.ConfigOnly {
+			out[resource.ClusterIndex(i)] = true
+		}
+	}
+	return out
+}
+
+// provision creates the kind clusters, installs MetalLB in each of them, and routes the pod and service subnets of
+// the clusters sharing a network, as well as the LoadBalancer IPs of all clusters, between them.
+func (k *KindSettings) provision() error {
+	if err := os.MkdirAll(k.KubeConfigDir, os.ModePerm); err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	if k.Keep {
+		out, err := kind("get", "clusters")
+		if err != nil {
+			return err
+		}
+		for _, name := range strings.Fields(out) {
+			existing[name] = true
+		}
+	}
+
+	var mu sync.Mutex
+	var errs error
+	var wg sync.WaitGroup
+	for _, c := range k.Clusters {
+		c := c
+		if existing[c.Name] {
+			scopes.Framework.Infof("Reusing existing kind cluster %s", c.Name)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := k.createCluster(c); err != nil {
+				mu.Lock()
+				errs = multierror.Append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if errs != nil {
+		return errs
+	}
+
+	nodeIPs := make([]string, 0, len(k.Clusters))
+	for _, c := range k.Clusters {
+		ip, err := nodeIP(c.Name)
+		if err != nil {
+			return err
+		}
+		nodeIPs = append(nodeIPs, ip)
+		if err := k.writeKubeConfig(c, ip); err != nil {
+			return err
+		}
+	}
+
+	subnet, err := docker("network", "inspect", kindNetwork, "--format", "{{(index .IPAM.Config 0).Subnet}}")
+	if err != nil {
+		return err
+	}
+	pools, err := metalLBPools(strings.TrimSpace(subnet), len(k.Clusters), metalLBPoolSize)
+	if err != nil {
+		return err
+	}
+	for i, c := range k.Clusters {
+		if existing[c.Name] {
+			continue
+		}
+		if err := installMetalLB(filepath.Join(k.KubeConfigDir, c.Name), filepath.Join(k.KubeConfigDir, c.Name+"-metallb.yaml"),
+			pools[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, c := range k.Clusters {
+		for j, other := range k.Clusters {
+			if i == j {
+				continue
+			}
+			var cidrs []string
+			if c.NetworkID == other.NetworkID {
+				cidrs = append(cidrs, other.PodSubnet, other.SvcSubnet)
+			}
+			cidrs = append(cidrs, rangeToCIDRs(pools[j][0], pools[j][1])...)
+			for _, cidr := range cidrs {
+				// Replacing rather than adding keeps reused clusters working.
+				if _, err := docker("exec", c.Name+"-control-plane", "ip", "route", "replace", cidr, "via",
+					nodeIPs[j]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (k *KindSettings) createCluster(c KindCluster) error {
+	base, err := ioutil.ReadFile(k.Config)
+	if err != nil {
+		return fmt.Errorf("failed reading kind config: %v", err)
+	}
+	config := filepath.Join(k.KubeConfigDir, c.Name+"-config.yaml")
+	if err := ioutil.WriteFile(config, []byte(kindConfig(string(base), c)), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Delete leftovers of a previous run.
+	_, _ = kind("delete", "cluster", "--name", c.Name)
+	scopes.Framework.Infof("Creating kind cluster %s", c.Name)
+	if _, err := kind("create", "cluster", "--name", c.Name, "--config", config, "--image", k.Image,
+		"--kubeconfig", filepath.Join(k.KubeConfigDir, c.Name), "--wait", "60s"); err != nil {
+		return fmt.Errorf("failed creating kind cluster %s: %v", c.Name, err)
+	}
+	return nil
+}
+
+// kindConfig appends the subnets of the cluster to the base kind configuration.
+func kindConfig(base string, c KindCluster) string {
+	return fmt.Sprintf("%s\nnetworking:\n  podSubnet: %s\n  serviceSubnet: %s\n", strings.TrimRight(base, "\n"),
+		c.PodSubnet, c.SvcSubnet)
+}
+
+// writeKubeConfig writes a kubeconfig that reaches the API server through the address of the node on the kind
+// network, so that it can be used both from the host and from other clusters, for example in remote secrets.
+func (k *KindSettings) writeKubeConfig(c KindCluster, ip string) error {
+	out, err := kind("get", "kubeconfig", "--name", c.Name, "--internal")
+	if err != nil {
+		return err
+	}
+	out = strings.ReplaceAll(out, c.Name+"-control-plane", ip)
+	return ioutil.WriteFile(filepath.Join(k.KubeConfigDir, c.Name), []byte(out), 0600)
+}
+
+// installMetalLB installs MetalLB to the cluster of the kubeconfig, handing out the IPs of the pool. Its
+// configuration is written to configFile.
+func installMetalLB(kubeconfigFile, configFile string, pool [2]net.IP) error {
+	kubeconfig := "--kubeconfig=" + kubeconfigFile
+	for _, manifest := range []string{"namespace.yaml", "metallb.yaml"} {
+		if _, err := kubectl(kubeconfig, "apply", "-f", metalLBManifests+manifest); err != nil {
+			return err
+		}
+	}
+	key := make([]byte, 128)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if _, err := kubectl(kubeconfig, "create", "secret", "generic", "-n", "metallb-system", "memberlist",
+		"--from-literal=secretkey="+base64.StdEncoding.EncodeToString(key)); err != nil {
+		return err
+	}
+
+	config := fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  namespace: metallb-system
+  name: config
+data:
+  config: |
+    address-pools:
+    - name: default
+      protocol: layer2
+      addresses:
+      - %s-%s
+`, pool[0], pool[1])
+	if err := ioutil.WriteFile(configFile, []byte(config), os.ModePerm); err != nil {
+		return err
+	}
+	_, err := kubectl(kubeconfig, "apply", "-f", configFile)
+	return err
+}
+
+// teardown deletes the kind clusters, unless they are kept.
+func (k *KindSettings) teardown() error {
+	if k.Keep {
+		scopes.Framework.Infof("Keeping kind clusters, their kubeconfigs are in %s", k.KubeConfigDir)
+		return nil
+	}
+	var errs error
+	for _, c := range k.Clusters {
+		if _, err := kind("delete", "cluster", "--name", c.Name); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// metalLBPools splits the last IPs of the given IPv4 subnet into one range of the given size per cluster, as
+// [first, last] pairs. Docker hands out the IPs of its networks from the start of their subnet.
+func metalLBPools(subnet string, clusters, size int) ([][2]net.IP, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, err
+	}
+	if ipNet.IP.To4() == nil {
+		return nil, fmt.Errorf("kind network subnet %s is not IPv4", subnet)
+	}
+	ones, bits := ipNet.Mask.Size()
+	hosts := uint32(1)<<uint(bits-ones) - 2
+	if uint32(clusters*size) > hosts/2 {
+		return nil, fmt.Errorf("kind network subnet %s is too small for %d clusters", subnet, clusters)
+	}
+	// The last host, before the broadcast address.
+	last := binary.BigEndian.Uint32(ipNet.IP.To4()) + hosts
+	out := make([][2]net.IP, 0, clusters)
+	for i := 0; i < clusters; i++ {
+		first := last - uint32((clusters-i)*size) + 1
+		out = append(out, [2]net.IP{uint32ToIP(first), uint32ToIP(first + uint32(size) - 1)})
+	}
+	return out, nil
+}
+
+// rangeToCIDRs returns the smallest list of CIDRs covering the IPv4 range [first, last].
+func rangeToCIDRs(first, last net.IP) []string {
+	start := binary.BigEndian.Uint32(first.To4())
+	end := binary.BigEndian.Uint32(last.To4())
+	var out []string
+	for start <= end {
+		// The largest block aligned on start that does not go past end.
+		size := uint32(32)
+		for size > 0 {
+			mask := uint32(1)<<(33-size) - 1
+			if start&mask != 0 || start+mask > end {
+				break
+			}
+			size--
+		}
+		out = append(out, fmt.Sprintf("%s/%d", uint32ToIP(start), size))
+		block := uint32(1) << (32 - size)
+		if start+block < start {
+			break
+		}
+		start += block
+	}
+	return out
+}
+
+func uint32ToIP(v uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, v)
+	return ip
+}
+
+func nodeIP(cluster string) (string, error) {
+	return containerIP(cluster + "-control-plane")
+}
+
+// containerIP returns the IP of the docker container on the kind network.
+func containerIP(name string) (string, error) {
+	out, err := docker("inspect", name, "--format",
+		"{{ .NetworkSettings.Networks."+kindNetwork+".IPAddress }}")
+	return strings.TrimSpace(out), err
+}
+
+func kind(args ...string) (string, error) {
+	return run("kind", args...)
+}
+
+func docker(args ...string) (string, error) {
+	return run("docker", args...)
+}
+
+func kubectl(args ...string) (string, error) {
+	return run("kubectl", args...)
+}
+
+func run(name string, args ...string) (string, error) {
+	out, err := shell.ExecuteArgs(os.Environ(), false, name, args...)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			err = fmt.Errorf("%v: %s", err, exitErr.Stderr)
+		}
+		return "", fmt.Errorf("%s %s failed: %v", name, strings.Join(args, " "), err)
+	}
+	return out, nil
+}
@@ -0,0 +1,84 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+func TestParseKindTopology(t *testing.T) {
+	clusters, err := parseKindTopology(filepath.Join(env.IstioSrc, "prow/config/topology/multicluster.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &KindSettings{Clusters: clusters, KubeConfigDir: "/tmp/kind"}
+	if got := k.KubeConfigs(); len(got) != 5 || got[0] != "/tmp/kind/cluster1" {
+		t.Fatalf("unexpected kube configs %v", got)
+	}
+	controlPlanes, configs, networks := k.topologies()
+	if controlPlanes[1] != 0 || controlPlanes[3] != 3 || configs[2] != 2 {
+		t.Fatalf("unexpected topologies %v %v", controlPlanes, configs)
+	}
+	if networks[resource.ClusterIndex(0)] != "test-network-0" || networks[resource.ClusterIndex(4)] != "test-network-1" {
+		t.Fatalf("unexpected networks %v", networks)
+	}
+}
+
+func TestKindConfig(t *testing.T) {
+	got := kindConfig("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\n", KindCluster{
+		PodSubnet: "10.10.0.0/16",
+		SvcSubnet: "10.255.10.0/24",
+	})
+	want := `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+networking:
+  podSubnet: 10.10.0.0/16
+  serviceSubnet: 10.255.10.0/24
+`
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMetalLBPools(t *testing.T) {
+	pools, err := metalLBPools("172.18.0.0/16", 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]net.IP{
+		{net.ParseIP("172.18.255.235").To4(), net.ParseIP("172.18.255.244").To4()},
+		{net.ParseIP("172.18.255.245").To4(), net.ParseIP("172.18.255.254").To4()},
+	}
+	if !reflect.DeepEqual(pools, want) {
+		t.Fatalf("got %v, want %v", pools, want)
+	}
+	if _, err := metalLBPools("172.18.0.0/28", 2, 10); err == nil {
+		t.Fatal("expected a too small subnet to be rejected")
+	}
+}
+
+func TestRangeToCIDRs(t *testing.T) {
+	got := rangeToCIDRs(net.ParseIP("172.18.255.235"), net.ParseIP("172.18.255.244"))
+	want := []string{"172.18.255.235/32", "172.18.255.236/30", "172.18.255.240/30", "172.18.255.244/32"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
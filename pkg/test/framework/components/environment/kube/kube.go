@@ -16,6 +16,7 @@ package kube
 
 import (
 	"fmt"
+	"io"
 
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/scopes"
@@ -30,7 +31,10 @@ type Environment struct {
 	s            *Settings
 }
 
-var _ resource.Environment = &Environment{}
+var (
+	_ resource.Environment = &Environment{}
+	_ io.Closer            = &Environment{}
+)
 
 // New returns a new Kubernetes environment
 func New(ctx resource.Context, s *Settings) (resource.Environment, error) {
@@ -42,11 +46,24 @@ func New(ctx resource.Context, s *Settings) (resource.Environment, error) {
 	}
 	e.id = ctx.TrackResource(e)
 
+	if s.Kind != nil {
+		if err := s.Kind.provision(); err != nil {
+			return nil, fmt.Errorf("failed provisioning kind clusters: %v", err)
+		}
+	}
+
 	clients, err := s.NewClients()
 	if err != nil {
 		return nil, err
 	}
 
+	if s.MetalLB && s.Kind == nil {
+		if err := setupMetalLB(ctx, s.KubeConfig, clients); err != nil {
+			return nil, fmt.Errorf("failed setting up MetalLB: %v", err)
+		}
+		s.LoadBalancerSupported = true
+	}
+
 	e.KubeClusters = make([]Cluster, 0, len(clients))
 	for i := range clients {
 		client := clients[i]
@@ -55,13 +72,26 @@ func New(ctx resource.Context, s *Settings) (resource.Environment, error) {
 			networkName:    s.networkTopology[clusterIndex],
 			filename:       s.KubeConfig[i],
 			index:          clusterIndex,
+			loadBalancer:   s.LoadBalancerSupported,
 			ExtendedClient: client,
 		})
 	}
 
+	if !s.OpenShift && detectOpenShift(e.KubeClusters) {
+		s.OpenShift = true
+	}
+
 	return e, nil
 }
 
+// Close implements io.Closer. It deletes the kind clusters created by the environment, if any.
+func (e *Environment) Close() error {
+	if e.s.Kind == nil {
+		return nil
+	}
+	return e.s.Kind.teardown()
+}
+
 func (e *Environment) EnvironmentName() string {
 	return "Kube"
 }
@@ -121,6 +151,23 @@ func (e *Environment) IsConfigCluster(cluster resource.Cluster) bool {
 	return true
 }
 
+// IsConfigOnlyCluster returns true if the cluster only hosts the Istio configuration watched by its external
+// control plane, and runs no workloads.
+func (e *Environment) IsConfigOnlyCluster(cluster resource.Cluster) bool {
+	return e.Settings().ConfigOnlyClusters[cluster.Index()]
+}
+
+// WorkloadClusters returns the clusters which can run workloads, that is all but the config-only clusters.
+func (e *Environment) WorkloadClusters() resource.Clusters {
+	out := make(resource.Clusters, 0, len(e.KubeClusters))
+	for _, c := range e.KubeClusters {
+		if !e.IsConfigOnlyCluster(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 // GetControlPlaneCluster returns the cluster running the control plane for the given cluster based on the ControlPlaneTopology.
 // An error is returned if the given cluster isn't present in the topology, or the cluster in the topology isn't in KubeClusters.
 func (e *Environment) GetControlPlaneCluster(cluster resource.Cluster) (resource.Cluster, error) {
@@ -0,0 +1,185 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// metalLBNamespace and metalLBConfigMap hold the address pools of a MetalLB installation.
+	metalLBNamespace = "metallb-system"
+	metalLBConfigMap = "config"
+)
+
+// metalLBAddresses matches the first address range of a MetalLB configuration, in either the first-last or the
+// CIDR form.
+var metalLBAddresses = regexp.MustCompile(`(\d+\.\d+\.\d+\.\d+)(?:-(\d+\.\d+\.\d+\.\d+)|/(\d+))`)
+
+// setupMetalLB installs MetalLB to the kind clusters of the kube configs which don't have it yet, with address pools
+// taken from the end of the kind docker network which don't overlap the pools of the existing installations. The
+// nodes of each cluster are then routed the pools of the other clusters, so that the LoadBalancer IPs of all
+// clusters, such as the addresses of the east-west gateways, are reachable across clusters.
+func setupMetalLB(ctx resource.Context, kubeConfigs []string, clients []istioKube.ExtendedClient) error {
+	pools := make([][2]net.IP, len(clients))
+	installed := make([]bool, len(clients))
+	var used [][2]net.IP
+	for i, c := range clients {
+		cm, err := c.CoreV1().ConfigMaps(metalLBNamespace).Get(context.TODO(), metalLBConfigMap, kubeApiMeta.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed reading the MetalLB configuration of cluster-%d: %v", i, err)
+		}
+		pool, err := parseMetalLBPool(cm.Data["config"])
+		if err != nil {
+			return fmt.Errorf("cluster-%d: %v", i, err)
+		}
+		scopes.Framework.Infof("MetalLB is already installed to cluster-%d with addresses %s-%s", i, pool[0], pool[1])
+		pools[i] = pool
+		installed[i] = true
+		used = append(used, pool)
+	}
+
+	if missing := len(clients) - len(used); missing > 0 {
+		subnet, err := docker("network", "inspect", kindNetwork, "--format", "{{(index .IPAM.Config 0).Subnet}}")
+		if err != nil {
+			return err
+		}
+		free, err := freeMetalLBPools(strings.TrimSpace(subnet), used, missing, metalLBPoolSize)
+		if err != nil {
+			return err
+		}
+		workDir, err := ctx.CreateTmpDirectory("metallb")
+		if err != nil {
+			return err
+		}
+		for i := range clients {
+			if installed[i] {
+				continue
+			}
+			pools[i], free = free[0], free[1:]
+			scopes.Framework.Infof("Installing MetalLB to cluster-%d with addresses %s-%s", i, pools[i][0], pools[i][1])
+			if err := installMetalLB(kubeConfigs[i], filepath.Join(workDir, fmt.Sprintf("cluster-%d-metallb.yaml", i)),
+				pools[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	nodes := make([][]string, len(clients))
+	nodeIPs := make([]string, len(clients))
+	for i, c := range clients {
+		list, err := c.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed listing the nodes of cluster-%d: %v", i, err)
+		}
+		for _, n := range list.Items {
+			// The nodes of kind clusters are docker containers of the same name.
+			ip, err := containerIP(n.Name)
+			if err != nil || ip == "" {
+				return fmt.Errorf("node %s of cluster-%d is not a container on the %s docker network: %v",
+					n.Name, i, kindNetwork, err)
+			}
+			nodes[i] = append(nodes[i], n.Name)
+			if nodeIPs[i] == "" {
+				nodeIPs[i] = ip
+			}
+		}
+	}
+	for i := range clients {
+		for j := range clients {
+			if i == j || nodeIPs[j] == "" {
+				continue
+			}
+			for _, cidr := range rangeToCIDRs(pools[j][0], pools[j][1]) {
+				for _, node := range nodes[i] {
+					// Replacing rather than adding keeps the routes of a previous run working.
+					if _, err := docker("exec", node, "ip", "route", "replace", cidr, "via", nodeIPs[j]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseMetalLBPool returns the first address range of a MetalLB configuration, as a [first, last] pair.
+func parseMetalLBPool(config string) ([2]net.IP, error) {
+	m := metalLBAddresses.FindStringSubmatch(config)
+	if m == nil {
+		return [2]net.IP{}, fmt.Errorf("no IPv4 address range in MetalLB configuration %q", config)
+	}
+	if m[2] != "" {
+		return [2]net.IP{net.ParseIP(m[1]).To4(), net.ParseIP(m[2]).To4()}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(m[1] + "/" + m[3])
+	if err != nil {
+		return [2]net.IP{}, err
+	}
+	first := binary.BigEndian.Uint32(ipNet.IP.To4())
+	return [2]net.IP{uint32ToIP(first), uint32ToIP(first | ^binary.BigEndian.Uint32(ipNet.Mask))}, nil
+}
+
+// freeMetalLBPools returns count ranges of the given size from the end of the IPv4 subnet, as [first, last] pairs,
+// skipping those which overlap the used ranges. Like metalLBPools, it only takes IPs from the second half of the
+// subnet, since docker hands out the IPs of its networks from the start.
+func freeMetalLBPools(subnet string, used [][2]net.IP, count, size int) ([][2]net.IP, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, err
+	}
+	if ipNet.IP.To4() == nil {
+		return nil, fmt.Errorf("kind network subnet %s is not IPv4", subnet)
+	}
+	ones, bits := ipNet.Mask.Size()
+	hosts := uint32(1)<<uint(bits-ones) - 2
+	base := binary.BigEndian.Uint32(ipNet.IP.To4())
+	// The last host, before the broadcast address.
+	last := base + hosts
+	out := make([][2]net.IP, 0, count)
+	for end := last; len(out) < count; end -= uint32(size) {
+		first := end - uint32(size) + 1
+		if end < uint32(size) || first <= base+hosts/2 {
+			return nil, fmt.Errorf("kind network subnet %s has no room for %d more MetalLB pools", subnet, count)
+		}
+		overlaps := false
+		for _, u := range used {
+			if first <= binary.BigEndian.Uint32(u[1].To4()) && binary.BigEndian.Uint32(u[0].To4()) <= end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			out = append(out, [2]net.IP{uint32ToIP(first), uint32ToIP(end)})
+		}
+	}
+	return out, nil
+}
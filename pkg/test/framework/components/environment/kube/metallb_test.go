@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseMetalLBPool(t *testing.T) {
+	cases := []struct {
+		config string
+		want   [2]net.IP
+	}{
+		{
+			config: "address-pools:\n- name: default\n  protocol: layer2\n  addresses:\n  - 172.18.255.200-172.18.255.209\n",
+			want:   [2]net.IP{net.ParseIP("172.18.255.200").To4(), net.ParseIP("172.18.255.209").To4()},
+		},
+		{
+			config: "address-pools:\n- name: default\n  protocol: layer2\n  addresses:\n  - 172.18.255.240/28\n",
+			want:   [2]net.IP{net.ParseIP("172.18.255.240").To4(), net.ParseIP("172.18.255.255").To4()},
+		},
+	}
+	for _, c := range cases {
+		got, err := parseMetalLBPool(c.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("got %v, want %v", got, c.want)
+		}
+	}
+	if _, err := parseMetalLBPool("address-pools: []"); err == nil {
+		t.Fatal("expected a configuration without addresses to be rejected")
+	}
+}
+
+func TestFreeMetalLBPools(t *testing.T) {
+	used := [][2]net.IP{{net.ParseIP("172.18.255.240").To4(), net.ParseIP("172.18.255.249").To4()}}
+	pools, err := freeMetalLBPools("172.18.0.0/16", used, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]net.IP{
+		{net.ParseIP("172.18.255.225").To4(), net.ParseIP("172.18.255.234").To4()},
+		{net.ParseIP("172.18.255.215").To4(), net.ParseIP("172.18.255.224").To4()},
+	}
+	if !reflect.DeepEqual(pools, want) {
+		t.Fatalf("got %v, want %v", pools, want)
+	}
+	if _, err := freeMetalLBPools("172.18.0.0/28", nil, 2, 10); err == nil {
+		t.Fatal("expected a too small subnet to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// openShiftSecurityGroupVersion is served by all OpenShift clusters, and is used to detect them.
+	openShiftSecurityGroupVersion = "security.openshift.io/v1"
+
+	// anyUIDClusterRole allows the use of the anyuid SecurityContextConstraints. The sidecar runs with a fixed UID,
+	// which the default restricted SCC does not allow.
+	anyUIDClusterRole = "system:openshift:scc:anyuid"
+	anyUIDRoleBinding = "istio-test-anyuid"
+
+	// OpenShiftCNINetwork is the name of the NetworkAttachmentDefinition referenced by the injected pods. OpenShift
+	// does not allow the istio-init container to configure the traffic redirection, so Istio CNI is required, and
+	// Multus only invokes the plugin for the pods of namespaces which define the network.
+	OpenShiftCNINetwork = "istio-cni"
+)
+
+var networkAttachmentDefinitionGVR = schema.GroupVersionResource{
+	Group:    "k8s.cni.cncf.io",
+	Version:  "v1",
+	Resource: "network-attachment-definitions",
+}
+
+// IsOpenShift returns true if the clusters of the environment are OpenShift clusters, either because it was
+// set with istio.test.kube.openshift or because it was detected.
+func (e *Environment) IsOpenShift() bool {
+	return e.s.OpenShift
+}
+
+// detectOpenShift returns true if any of the clusters serves the OpenShift security API.
+func detectOpenShift(clusters []Cluster) bool {
+	for _, c := range clusters {
+		if _, err := c.Discovery().ServerResourcesForGroupVersion(openShiftSecurityGroupVersion); err == nil {
+			scopes.Framework.Infof("detected OpenShift cluster %s", c.Name())
+			return true
+		}
+	}
+	return false
+}
+
+// PrepareOpenShiftNamespace allows the service accounts of the namespace to run the pods created by the tests
+// on OpenShift, creating the namespace if needed. If inject is true, it also defines the Istio CNI network
+// required by the sidecars. It is a no-op on other clusters.
+func (e *Environment) PrepareOpenShiftNamespace(cluster Cluster, ns string, inject bool) error {
+	if !e.IsOpenShift() {
+		return nil
+	}
+	if _, err := cluster.CoreV1().Namespaces().Create(context.TODO(), &kubeApiCore.Namespace{
+		ObjectMeta: kubeApiMeta.ObjectMeta{Name: ns},
+	}, kubeApiMeta.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed creating namespace %s in %s: %v", ns, cluster.Name(), err)
+	}
+
+	if _, err := cluster.RbacV1().RoleBindings(ns).Create(context.TODO(), &rbacv1.RoleBinding{
+		ObjectMeta: kubeApiMeta.ObjectMeta{Name: anyUIDRoleBinding},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     anyUIDClusterRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.GroupKind,
+			Name:     "system:serviceaccounts:" + ns,
+		}},
+	}, kubeApiMeta.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed granting anyuid to %s in %s: %v", ns, cluster.Name(), err)
+	}
+
+	if !inject {
+		return nil
+	}
+	nad := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": networkAttachmentDefinitionGVR.GroupVersion().String(),
+		"kind":       "NetworkAttachmentDefinition",
+		"metadata": map[string]interface{}{
+			"name": OpenShiftCNINetwork,
+		},
+	}}
+	if _, err := cluster.Dynamic().Resource(networkAttachmentDefinitionGVR).Namespace(ns).
+		Create(context.TODO(), nad, kubeApiMeta.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed creating the %s network in %s/%s: %v", OpenShiftCNINetwork, cluster.Name(), ns, err)
+	}
+	return nil
+}
@@ -49,6 +49,10 @@ type Settings struct {
 	// MetalLB.
 	LoadBalancerSupported bool
 
+	// MetalLB installs MetalLB to the kind clusters of KubeConfig which don't have it yet, before any test runs,
+	// and implies LoadBalancerSupported. The clusters created from a kind topology always get MetalLB.
+	MetalLB bool
+
 	// ControlPlaneTopology maps each cluster to the cluster that runs its control plane. For replicated control
 	// plane cases (where each cluster has its own control plane), the cluster will map to itself (e.g. 0->0).
 	ControlPlaneTopology clusterTopology
@@ -61,6 +65,18 @@ type Settings struct {
 	// If the cluster runs its own config, the cluster will map to itself (e.g. 0->0)
 	// By default, we use the ControlPlaneTopology as the config topology.
 	ConfigTopology clusterTopology
+
+	// ConfigOnlyClusters are the clusters which only host the Istio configuration watched by their external
+	// control plane. No workloads, gateways or sidecar injection run in them.
+	ConfigOnlyClusters map[resource.ClusterIndex]bool
+
+	// Kind, if set, configures the kind clusters that the environment creates before running the tests. They
+	// define KubeConfig and the topologies.
+	Kind *KindSettings
+
+	// OpenShift indicates that the clusters are OpenShift clusters, which require the test namespaces to allow the
+	// anyuid SecurityContextConstraints, and Istio CNI for the sidecars. It is detected if not set.
+	OpenShift bool
 }
 
 type SetupSettingsFunc func(s *Settings, ctx resource.Context)
@@ -89,6 +105,24 @@ func (s *Settings) GetControlPlaneClusters() map[resource.ClusterIndex]bool {
 	return out
 }
 
+// validateConfigOnlyClusters checks that the config-only clusters provide their own config, and are served by the
+// control plane of another cluster.
+func (s *Settings) validateConfigOnlyClusters() error {
+	for index := range s.ConfigOnlyClusters {
+		if int(index) >= len(s.KubeConfig) {
+			return fmt.Errorf("config-only cluster index %d exceeds number of available clusters %d",
+				index, len(s.KubeConfig))
+		}
+		if configIndex, ok := s.ConfigTopology[index]; ok && configIndex != index {
+			return fmt.Errorf("config-only cluster %d uses the config of cluster %d", index, configIndex)
+		}
+		if controlPlaneIndex, ok := s.ControlPlaneTopology[index]; !ok || controlPlaneIndex == index {
+			return fmt.Errorf("config-only cluster %d must use the control plane of another cluster", index)
+		}
+	}
+	return nil
+}
+
 // NewClients creates the kubernetes clients for interacting with the configured clusters.
 func (s *Settings) NewClients() ([]istioKube.ExtendedClient, error) {
 	newClientsFn := s.ClientFactoryFunc
@@ -112,9 +146,15 @@ func (s *Settings) String() string {
 
 	result += fmt.Sprintf("KubeConfig:           %s\n", s.KubeConfig)
 	result += fmt.Sprintf("LoadBalancerSupported:      %v\n", s.LoadBalancerSupported)
+	result += fmt.Sprintf("MetalLB:              %v\n", s.MetalLB)
 	result += fmt.Sprintf("ControlPlaneTopology: %v\n", s.ControlPlaneTopology)
 	result += fmt.Sprintf("NetworkTopology:      %v\n", s.networkTopology)
 	result += fmt.Sprintf("ConfigTopology:      %v\n", s.ConfigTopology)
+	result += fmt.Sprintf("ConfigOnlyClusters:   %v\n", s.ConfigOnlyClusters)
+	result += fmt.Sprintf("OpenShift:            %v\n", s.OpenShift)
+	if s.Kind != nil {
+		result += fmt.Sprintf("KindClusters:         %d (keep=%v)\n", len(s.Kind.Clusters), s.Kind.Keep)
+	}
 	return result
 }
 
@@ -125,7 +165,7 @@ func newClients(kubeConfigs []string) ([]istioKube.ExtendedClient, error) {
 			rc, err := istioKube.DefaultRestConfig(cfg, "", func(config *rest.Config) {
 				config.QPS = 200
 				config.Burst = 400
-			})
+			}, withCredentialRefresh(cfg))
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,47 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+func TestValidateConfigOnlyClusters(t *testing.T) {
+	cases := []struct {
+		name       string
+		configOnly resource.ClusterIndex
+		valid      bool
+	}{
+		{name: "external control plane", configOnly: 1, valid: true},
+		{name: "own control plane", configOnly: 0},
+		{name: "config of another cluster", configOnly: 2},
+		{name: "out of range", configOnly: 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &Settings{
+				KubeConfig:           []string{"a", "b", "c"},
+				ControlPlaneTopology: clusterTopology{0: 0, 1: 0, 2: 0},
+				ConfigTopology:       clusterTopology{0: 0, 1: 1, 2: 1},
+				ConfigOnlyClusters:   map[resource.ClusterIndex]bool{c.configOnly: true},
+			}
+			if err := s.validateConfigOnlyClusters(); (err == nil) != c.valid {
+				t.Fatalf("got error %v, want valid=%v", err, c.valid)
+			}
+		})
+	}
+}
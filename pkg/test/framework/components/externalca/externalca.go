@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"crypto/x509"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	externalCA "istio.io/istio/pkg/test/externalca"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Instance represents a CA deployed on kube, outside of the control plane, which implements the Istio CA gRPC API.
+// It has its own intermediate certificate, signed by the root of the plugin CA of Istio, so that certificates it
+// issues are trusted throughout the mesh. Every certificate it issues is recorded.
+//
+// Istiod cannot delegate signing to an external CA, so the workloads request their certificates from the CA
+// directly: workloads annotated with ProxyConfig have their agent send CSRs to the CA instead of istiod.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the CA is deployed in.
+	Namespace() namespace.Instance
+
+	// Address of the Istio CA gRPC API of the CA, within the cluster.
+	Address() string
+
+	// ProxyConfig returns the value of the proxy.istio.io/config annotation which has the agent of a workload
+	// request its certificates from the CA.
+	ProxyConfig() string
+
+	// Cert returns the PEM encoded signing certificate of the CA.
+	Cert() []byte
+
+	// Issued returns the certificates issued by the CA, in the order they were issued.
+	Issued() ([]externalCA.Issued, error)
+
+	// IssuedOrFail calls Issued and fails the test if an error is returned.
+	IssuedOrFail(t test.Failer) []externalCA.Issued
+
+	// ClearIssued forgets the certificates issued so far.
+	ClearIssued() error
+
+	// WaitForIssued waits until the CA has issued a certificate for the given SPIFFE identity, and returns it.
+	WaitForIssued(identity string, opts ...retry.Option) (externalCA.Issued, error)
+
+	// CheckIssued checks that the leaf of the given chain was issued by the CA, and that the chain verifies against
+	// the root of the mesh.
+	CheckIssued(chain []*x509.Certificate) error
+}
+
+// Config for the external CA component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Istio is the control plane whose root the CA chains to. It must be deployed with istio.Config.PluginCA.
+	Istio istio.Instance
+
+	// MaxTTL caps the lifetime of the issued certificates. Defaults to 24h.
+	MaxTTL time.Duration
+}
+
+// New deploys an external CA and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("externalca.NewOrFail: %v", err)
+	}
+	return i
+}
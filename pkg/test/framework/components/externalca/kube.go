@@ -0,0 +1,314 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/cert/ca"
+	"istio.io/istio/pkg/test/env"
+	externalCA "istio.io/istio/pkg/test/externalca"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "externalca"
+	secretName  = "externalca-certs"
+	grpcPort    = 15012
+	adminPort   = 8080
+
+	// caConfTemplate is the openssl configuration of the CA certificate. The certificate is also used to serve the
+	// gRPC API, so it holds the DNS names of the service.
+	caConfTemplate = `
+[ req ]
+encrypt_key = no
+prompt = no
+utf8 = yes
+default_md = sha256
+default_bits = 4096
+req_extensions = req_ext
+x509_extensions = req_ext
+distinguished_name = req_dn
+[ req_ext ]
+subjectKeyIdentifier = hash
+basicConstraints = critical, CA:true, pathlen:0
+keyUsage = critical, digitalSignature, nonRepudiation, keyEncipherment, keyCertSign
+subjectAltName=@san
+[ san ]
+DNS.1 = {{ .Service }}.{{ .Namespace }}
+DNS.2 = {{ .Service }}.{{ .Namespace }}.svc
+DNS.3 = {{ .Service }}.{{ .Namespace }}.svc.cluster.local
+[ req_dn ]
+O = Istio
+CN = External CA
+`
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	ns        namespace.Instance
+	cert      []byte
+	roots     []byte
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Istio == nil {
+		return nil, fmt.Errorf("externalca: Config.Istio must be set")
+	}
+	pluginCA := cfg.Istio.PluginCA()
+	if pluginCA == nil {
+		return nil, fmt.Errorf("externalca: Istio must be deployed with a plugin CA")
+	}
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-external-ca",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the CA certificate, signed by the root of the mesh.
+	workDir, err := ctx.CreateTmpDirectory("externalca")
+	if err != nil {
+		return nil, err
+	}
+	conf, err := tmpl.Evaluate(caConfTemplate, map[string]interface{}{
+		"Service":   serviceName,
+		"Namespace": c.ns.Name(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	intermediate, err := ca.NewIntermediate(workDir, conf, pluginCA.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the external CA certificate: %v", err)
+	}
+	if c.cert, err = ioutil.ReadFile(intermediate.CertFile); err != nil {
+		return nil, err
+	}
+	if c.roots, err = pluginCA.TrustedRootCerts(); err != nil {
+		return nil, err
+	}
+	secret, err := intermediate.NewIstioCASecret()
+	if err != nil {
+		return nil, err
+	}
+	secret.Name = secretName
+	if _, err := c.cluster.CoreV1().Secrets(c.ns.Name()).Create(context.TODO(), secret,
+		kubeApiMeta.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed creating the external CA secret: %v", err)
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.ExternalCAInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	maxTTL := cfg.MaxTTL
+	if maxTTL == 0 {
+		maxTTL = 24 * time.Hour
+	}
+	yaml, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.BaseTag(),
+		"ImagePullPolicy": s.PullPolicy,
+		"GRPCPort":        grpcPort,
+		"AdminPort":       adminPort,
+		"Secret":          secretName,
+		"MaxTTL":          maxTTL.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The deployment and the secret are removed along with the namespace.
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.ns.Name(), "app="+serviceName))
+	if err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, adminPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	c.address = fmt.Sprintf("http://%s", forwarder.Address())
+	scopes.Framework.Debugf("initialized external CA port forwarder: %v", forwarder.Address())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Address() string {
+	// The agent verifies the CA against the DNS name it dials.
+	return fmt.Sprintf("%s.%s.svc:%d", serviceName, c.ns.Name(), grpcPort)
+}
+
+func (c *kubeComponent) ProxyConfig() string {
+	return fmt.Sprintf(`proxyMetadata:
+  CA_ADDR: %s
+`, c.Address())
+}
+
+func (c *kubeComponent) Cert() []byte {
+	return c.cert
+}
+
+// do sends a request to the admin API, and returns the response body.
+func (c *kubeComponent) do(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, c.address+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned non-ok status %v: %s", method, path, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+func (c *kubeComponent) Issued() ([]externalCA.Issued, error) {
+	body, err := c.do(http.MethodGet, externalCA.IssuedPath)
+	if err != nil {
+		return nil, err
+	}
+	var out []externalCA.Issued
+	err = json.Unmarshal(body, &out)
+	return out, err
+}
+
+func (c *kubeComponent) IssuedOrFail(t test.Failer) []externalCA.Issued {
+	t.Helper()
+	issued, err := c.Issued()
+	if err != nil {
+		t.Fatalf("externalca.IssuedOrFail: %v", err)
+	}
+	return issued
+}
+
+func (c *kubeComponent) ClearIssued() error {
+	_, err := c.do(http.MethodDelete, externalCA.IssuedPath)
+	return err
+}
+
+func (c *kubeComponent) WaitForIssued(identity string, opts ...retry.Option) (externalCA.Issued, error) {
+	var out externalCA.Issued
+	err := retry.UntilSuccess(func() error {
+		issued, err := c.Issued()
+		if err != nil {
+			return err
+		}
+		for _, i := range issued {
+			for _, id := range i.Identities {
+				if id == identity {
+					out = i
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no certificate issued for %s yet, %d issued in total", identity, len(issued))
+	}, opts...)
+	return out, err
+}
+
+func (c *kubeComponent) CheckIssued(chain []*x509.Certificate) error {
+	if err := mtls.CheckIssuedBy(chain, c.roots); err != nil {
+		return err
+	}
+	cert, err := mtls.ParsePEMChain(c.cert)
+	if err != nil {
+		return err
+	}
+	if len(chain) < 2 || !bytes.Equal(chain[1].Raw, cert[0].Raw) {
+		return fmt.Errorf("certificate of %s was not issued by the external CA", mtls.SPIFFEID(chain[0]))
+	}
+	issued, err := c.Issued()
+	if err != nil {
+		return err
+	}
+	serial := chain[0].SerialNumber.String()
+	for _, i := range issued {
+		if i.SerialNumber == serial {
+			return nil
+		}
+	}
+	return fmt.Errorf("certificate %s of %s is not recorded by the external CA", serial, mtls.SPIFFEID(chain[0]))
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalservice runs services outside of the mesh, reachable by arbitrary hostnames through a test DNS
+// server, for egress, ServiceEntry and TLS origination tests.
+package externalservice
+
+import (
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	// DefaultDomain is the domain of the hostname of the services which don't set Hostnames.
+	DefaultDomain = "external.test"
+
+	// ResolutionDNS and ResolutionStatic are the resolutions of the ServiceEntries returned by
+	// Instance.ServiceEntry.
+	ResolutionDNS    = "DNS"
+	ResolutionStatic = "STATIC"
+)
+
+// Instance is a set of services deployed without sidecars, and a DNS server resolving their hostnames to the
+// ClusterIPs of the services. The cluster DNS forwards the queries for the hostnames to the DNS server, so that all
+// pods, including the proxies resolving DNS ServiceEntries, reach the services by their hostnames.
+//
+// The cluster DNS must be CoreDNS. The hostnames must not be served by another Instance at the same time, since
+// CoreDNS rejects overlapping zones. New removes the hostnames left behind for namespaces that no longer exist, the
+// janitor removes the others.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the services and the DNS server are deployed in.
+	Namespace() namespace.Instance
+
+	// Hostnames returns the hostnames of the service with the given name.
+	Hostnames(service string) []string
+
+	// ClusterIP returns the IP the hostnames of the service resolve to.
+	ClusterIP(service string) string
+
+	// PodIPs returns the IPs of the pods of the service, for the endpoints of STATIC ServiceEntries.
+	PodIPs(service string) []string
+
+	// DNSAddress returns the IP of the DNS server, for pods which set it in their dnsConfig.
+	DNSAddress() string
+
+	// RootCert returns the PEM encoded root certificate of the certificates generated for the services, for
+	// DestinationRules originating TLS to them.
+	RootCert() []byte
+
+	// Resolve resolves the hostname through the cluster DNS, from a pod outside of the mesh.
+	Resolve(hostname string) ([]string, error)
+
+	// ServiceEntry returns a MESH_EXTERNAL ServiceEntry, with the given resolution, for the hostnames and ports
+	// of the service. STATIC ServiceEntries list the pods of the service as endpoints.
+	ServiceEntry(service, resolution string) string
+}
+
+// Config for the external services.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Services to run. At least one service is required.
+	Services []Service
+}
+
+// Service outside of the mesh. It is served by the echo app, so the calls of echo instances can be checked as
+// usual.
+type Service struct {
+	// Name of the service, which must be a valid Kubernetes name.
+	Name string
+
+	// Hostnames the DNS server resolves to the service. If not set, <Name>.<DefaultDomain> is used.
+	Hostnames []string
+
+	// Ports served by the service. If not set, HTTP is served on port 80. The echo app also serves its gRPC
+	// command port, 7070, which must not be used.
+	Ports []Port
+
+	// Cert and Key are the PEM encoded certificate and private key served on the HTTPS and TLS ports. If not set,
+	// a certificate for the hostnames, signed by Instance.RootCert, is generated.
+	Cert []byte
+	Key  []byte
+}
+
+// Port served by a Service.
+type Port struct {
+	// Name of the port. If not set, it is derived from the protocol and the port number.
+	Name string
+
+	// Protocol is protocol.HTTP, protocol.HTTPS, protocol.TCP, or protocol.TLS for TCP over TLS.
+	Protocol protocol.Instance
+
+	// Port number.
+	Port int
+}
+
+// New deploys the external services and their DNS server, and returns a handle to them.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("externalservice.NewOrFail: %v", err)
+	}
+	return i
+}
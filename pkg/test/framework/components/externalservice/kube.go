@@ -0,0 +1,580 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalservice
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeRetry "k8s.io/client-go/util/retry"
+
+	"istio.io/istio/pkg/config/protocol"
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/echo/proto"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	dnsServiceName = "external-dns"
+	dnsImage       = "coredns/coredns:1.7.0"
+	// grpcPort is the command port of the echo app, used to resolve the hostnames from within the cluster.
+	grpcPort = 7070
+	certTTL  = 24 * time.Hour
+
+	// The cluster DNS is configured by the Corefile of the coredns ConfigMap, which CoreDNS reloads on changes.
+	clusterDNSNamespace = "kube-system"
+	clusterDNSConfigMap = "coredns"
+	corefileKey         = "Corefile"
+	// stubDomainMarker prefixes the namespace of the external services in the marker of their Corefile block.
+	stubDomainMarker = "istio-test-externalservice "
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id         resource.ID
+	ns         namespace.Instance
+	cluster    resource.Cluster
+	services   map[string]*service
+	rootCert   []byte
+	dnsAddress string
+	// stubDomain marks the block of the cluster Corefile forwarding the hostnames to the DNS server.
+	stubDomain string
+	forwarder  istioKube.PortForwarder
+}
+
+// service is a deployed Service.
+type service struct {
+	Service
+	clusterIP string
+	podIPs    []string
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	services, err := validate(cfg.Services)
+	if err != nil {
+		return nil, err
+	}
+	c := &kubeComponent{
+		cluster:  ctx.Clusters().GetOrDefault(cfg.Cluster),
+		services: make(map[string]*service),
+	}
+	c.id = ctx.TrackResource(c)
+
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-external",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rootCert, rootKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         DefaultDomain,
+		Org:          "Istio Test",
+		NotBefore:    time.Now(),
+		TTL:          certTTL,
+		RSAKeySize:   2048,
+		IsCA:         true,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed generating the root certificate: %v", err)
+	}
+	c.rootCert = rootCert
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	params := make([]map[string]interface{}, 0, len(services))
+	for _, svc := range services {
+		p, err := templateParams(svc, rootCert, rootKey)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, p)
+	}
+	tpl, err := ioutil.ReadFile(env.ExternalServiceInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	yaml, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.BaseTag(),
+		"ImagePullPolicy": s.PullPolicy,
+		"GRPCPort":        grpcPort,
+		"Services":        params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The deployments are removed along with the namespace.
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+
+	type host struct {
+		IP       string
+		Hostname string
+	}
+	var hosts []host
+	for _, svc := range services {
+		pods, err := testKube.WaitUntilPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+svc.Name))
+		if err != nil {
+			return nil, err
+		}
+		deployed := &service{Service: svc}
+		for _, p := range pods {
+			deployed.podIPs = append(deployed.podIPs, p.Status.PodIP)
+		}
+		k8sSvc, err := c.cluster.CoreV1().Services(c.ns.Name()).Get(context.TODO(), svc.Name, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		deployed.clusterIP = k8sSvc.Spec.ClusterIP
+		for _, h := range svc.Hostnames {
+			hosts = append(hosts, host{IP: deployed.clusterIP, Hostname: h})
+		}
+		c.services[svc.Name] = deployed
+	}
+
+	tpl, err = ioutil.ReadFile(env.ExternalServiceDNSInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	yaml, err = tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Service": dnsServiceName,
+		"Image":   dnsImage,
+		"Hosts":   hosts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+	if _, err := testKube.WaitUntilPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+dnsServiceName)); err != nil {
+		return nil, err
+	}
+	dnsSvc, err := c.cluster.CoreV1().Services(c.ns.Name()).Get(context.TODO(), dnsServiceName, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c.dnsAddress = dnsSvc.Spec.ClusterIP
+
+	hostnames := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		hostnames = append(hostnames, h.Hostname)
+	}
+	sort.Strings(hostnames)
+	// The blocks left behind by runs that did not clean up may overlap with ours, which CoreDNS rejects.
+	stale, err := staleStubDomains(c.cluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(stale) > 0 {
+		scopes.Framework.Infof("removing the stale external services of namespaces %v from the cluster DNS of %s",
+			stale, c.cluster.Name())
+	}
+	marker := stubDomainMarker + c.ns.Name()
+	if err := updateCorefile(c.cluster, c.cluster.Name(), func(corefile string) string {
+		for _, ns := range stale {
+			corefile = removeStubDomain(corefile, stubDomainMarker+ns)
+		}
+		return addStubDomain(corefile, marker, hostnames, c.dnsAddress)
+	}); err != nil {
+		return nil, err
+	}
+	c.stubDomain = marker
+
+	pods, err := testKube.CheckPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+services[0].Name))
+	if err != nil {
+		return nil, err
+	}
+	forwarder, err := c.cluster.NewPortForwarder(pods[0].Name, pods[0].Namespace, "", 0, grpcPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+
+	// CoreDNS reloads its configuration periodically, so wait until the cluster DNS resolves every hostname.
+	for _, h := range hosts {
+		h := h
+		if err := retry.UntilSuccess(func() error {
+			addrs, err := c.Resolve(h.Hostname)
+			if err != nil {
+				return err
+			}
+			for _, a := range addrs {
+				if a == h.IP {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s resolved to %v, expected %s", h.Hostname, addrs, h.IP)
+		}, retry.Timeout(2*time.Minute), retry.Delay(2*time.Second)); err != nil {
+			return nil, fmt.Errorf("cluster DNS of %s does not resolve the external services: %v", c.cluster.Name(), err)
+		}
+	}
+	scopes.Framework.Infof("external services %v are resolved by the cluster DNS of %s", hostnames, c.cluster.Name())
+	return c, nil
+}
+
+// validate checks the services, and returns a copy of them with the defaults filled in.
+func validate(services []Service) ([]Service, error) {
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no external services configured")
+	}
+	names := map[string]bool{}
+	hostnames := map[string]bool{}
+	out := make([]Service, 0, len(services))
+	for _, s := range services {
+		if s.Name == "" {
+			return nil, fmt.Errorf("external service without name")
+		}
+		if s.Name == dnsServiceName || names[s.Name] {
+			return nil, fmt.Errorf("external service name %s is already used", s.Name)
+		}
+		names[s.Name] = true
+		if (len(s.Cert) == 0) != (len(s.Key) == 0) {
+			return nil, fmt.Errorf("external service %s: Cert and Key must be set together", s.Name)
+		}
+		if len(s.Hostnames) == 0 {
+			s.Hostnames = []string{s.Name + "." + DefaultDomain}
+		}
+		for _, h := range s.Hostnames {
+			if hostnames[h] {
+				return nil, fmt.Errorf("external service %s: hostname %s is already used", s.Name, h)
+			}
+			hostnames[h] = true
+		}
+		if len(s.Ports) == 0 {
+			s.Ports = []Port{{Protocol: protocol.HTTP, Port: 80}}
+		}
+		ports := make([]Port, 0, len(s.Ports))
+		for _, p := range s.Ports {
+			switch p.Protocol {
+			case protocol.HTTP, protocol.HTTPS, protocol.TCP, protocol.TLS:
+			default:
+				return nil, fmt.Errorf("external service %s: unsupported protocol %s", s.Name, p.Protocol)
+			}
+			if p.Port <= 0 || p.Port == grpcPort {
+				return nil, fmt.Errorf("external service %s: invalid port %d", s.Name, p.Port)
+			}
+			if p.Name == "" {
+				p.Name = fmt.Sprintf("%s-%d", strings.ToLower(string(p.Protocol)), p.Port)
+			}
+			ports = append(ports, p)
+		}
+		s.Ports = ports
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// templateParams returns the parameters of the service for the installation template. A certificate signed by the
+// root is generated for the services serving TLS without their own.
+func templateParams(s Service, rootCert, rootKey []byte) (map[string]interface{}, error) {
+	var httpPorts, tcpPorts, tlsPorts []int
+	for _, p := range s.Ports {
+		switch p.Protocol {
+		case protocol.HTTP:
+			httpPorts = append(httpPorts, p.Port)
+		case protocol.HTTPS:
+			httpPorts = append(httpPorts, p.Port)
+			tlsPorts = append(tlsPorts, p.Port)
+		case protocol.TCP:
+			tcpPorts = append(tcpPorts, p.Port)
+		case protocol.TLS:
+			tcpPorts = append(tcpPorts, p.Port)
+			tlsPorts = append(tlsPorts, p.Port)
+		}
+	}
+	cert, key := s.Cert, s.Key
+	if len(tlsPorts) > 0 && len(cert) == 0 {
+		signerCert, err := util.ParsePemEncodedCertificate(rootCert)
+		if err != nil {
+			return nil, err
+		}
+		signerKey, err := util.ParsePemEncodedKey(rootKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, key, err = util.GenCertKeyFromOptions(util.CertOptions{
+			Host:       strings.Join(s.Hostnames, ","),
+			Org:        "Istio Test",
+			NotBefore:  time.Now(),
+			TTL:        certTTL,
+			SignerCert: signerCert,
+			SignerPriv: signerKey,
+			RSAKeySize: 2048,
+			IsServer:   true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed generating the certificate of external service %s: %v", s.Name, err)
+		}
+		// Serve the chain, so that clients only need the root.
+		cert = append(cert, rootCert...)
+	}
+	p := map[string]interface{}{
+		"Name":      s.Name,
+		"Ports":     s.Ports,
+		"HTTPPorts": httpPorts,
+		"TCPPorts":  tcpPorts,
+		"TLSPorts":  tlsPorts,
+	}
+	if len(tlsPorts) > 0 {
+		p["Cert"] = base64.StdEncoding.EncodeToString(cert)
+		p["Key"] = base64.StdEncoding.EncodeToString(key)
+	}
+	return p, nil
+}
+
+// StubDomains returns the namespaces of the external services whose hostnames the cluster DNS forwards, including
+// those left behind by runs that did not clean up. It returns nothing if the cluster DNS is not CoreDNS.
+func StubDomains(client kubernetes.Interface) ([]string, error) {
+	cm, err := client.CoreV1().ConfigMaps(clusterDNSNamespace).Get(context.TODO(), clusterDNSConfigMap, kubeApiMeta.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stubDomainNamespaces(cm.Data[corefileKey]), nil
+}
+
+// RemoveStubDomains removes the hostnames of the external services in the given namespaces from the cluster DNS.
+func RemoveStubDomains(client kubernetes.Interface, clusterName string, namespaces ...string) error {
+	return updateCorefile(client, clusterName, func(corefile string) string {
+		for _, ns := range namespaces {
+			corefile = removeStubDomain(corefile, stubDomainMarker+ns)
+		}
+		return corefile
+	})
+}
+
+// staleStubDomains returns the namespaces of the external services forwarded by the cluster DNS which no longer
+// exist.
+func staleStubDomains(client kubernetes.Interface) ([]string, error) {
+	namespaces, err := StubDomains(client)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, ns := range namespaces {
+		_, err := client.CoreV1().Namespaces().Get(context.TODO(), ns, kubeApiMeta.GetOptions{})
+		if errors.IsNotFound(err) {
+			stale = append(stale, ns)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stale, nil
+}
+
+// updateCorefile updates the Corefile of the cluster DNS, retrying on conflicts.
+func updateCorefile(client kubernetes.Interface, clusterName string, update func(corefile string) string) error {
+	cms := client.CoreV1().ConfigMaps(clusterDNSNamespace)
+	err := kubeRetry.RetryOnConflict(kubeRetry.DefaultRetry, func() error {
+		cm, err := cms.Get(context.TODO(), clusterDNSConfigMap, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed reading the ConfigMap, the cluster DNS must be CoreDNS: %v", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[corefileKey] = update(cm.Data[corefileKey])
+		_, err = cms.Update(context.TODO(), cm, kubeApiMeta.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed updating the CoreDNS configuration of %s: %v", clusterName, err)
+	}
+	return nil
+}
+
+// addStubDomain adds a server block, delimited by the marker, to the Corefile, which forwards the queries for the
+// hostnames to the DNS server. A previous block with the same marker is replaced.
+func addStubDomain(corefile, marker string, hostnames []string, dnsAddress string) string {
+	return fmt.Sprintf("%s\n# BEGIN %s\n%s {\n    forward . %s\n}\n# END %s\n",
+		strings.TrimRight(removeStubDomain(corefile, marker), "\n"), marker, strings.Join(hostnames, " "), dnsAddress, marker)
+}
+
+// stubDomainNamespaces returns the namespaces of the external services with a block in the Corefile.
+func stubDomainNamespaces(corefile string) []string {
+	var out []string
+	for _, line := range strings.Split(corefile, "\n") {
+		if strings.HasPrefix(line, "# BEGIN "+stubDomainMarker) {
+			out = append(out, strings.TrimPrefix(line, "# BEGIN "+stubDomainMarker))
+		}
+	}
+	return out
+}
+
+// removeStubDomain removes the server block delimited by the marker from the Corefile.
+func removeStubDomain(corefile, marker string) string {
+	var out []string
+	skip := false
+	for _, line := range strings.Split(corefile, "\n") {
+		switch line {
+		case "# BEGIN " + marker:
+			skip = true
+			continue
+		case "# END " + marker:
+			skip = false
+			continue
+		}
+		if !skip {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Hostnames(name string) []string {
+	if s, ok := c.services[name]; ok {
+		return s.Hostnames
+	}
+	return nil
+}
+
+func (c *kubeComponent) ClusterIP(name string) string {
+	if s, ok := c.services[name]; ok {
+		return s.clusterIP
+	}
+	return ""
+}
+
+func (c *kubeComponent) PodIPs(name string) []string {
+	if s, ok := c.services[name]; ok {
+		return s.podIPs
+	}
+	return nil
+}
+
+func (c *kubeComponent) DNSAddress() string {
+	return c.dnsAddress
+}
+
+func (c *kubeComponent) RootCert() []byte {
+	return c.rootCert
+}
+
+func (c *kubeComponent) Resolve(hostname string) ([]string, error) {
+	cl, err := client.New(c.forwarder.Address(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cl.Close() }()
+	resp, err := cl.ForwardEcho(context.TODO(), &proto.ForwardEchoRequest{
+		Url:   fmt.Sprintf("%s://%s", scheme.DNS, hostname),
+		Count: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving %s: %v", hostname, err)
+	}
+	if len(resp) != 1 {
+		return nil, fmt.Errorf("failed resolving %s: expected 1 response, got %d", hostname, len(resp))
+	}
+	addrs := resp[0].ResolvedAddresses
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+func (c *kubeComponent) ServiceEntry(name, resolution string) string {
+	s, ok := c.services[name]
+	if !ok {
+		return ""
+	}
+	var endpoints []string
+	if resolution == ResolutionStatic {
+		endpoints = s.podIPs
+	}
+	return serviceEntry(s.Service, resolution, endpoints)
+}
+
+// serviceEntry returns a MESH_EXTERNAL ServiceEntry for the service, with the given endpoints.
+func serviceEntry(s Service, resolution string, endpoints []string) string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, `apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: %s
+spec:
+  hosts:`, s.Name)
+	for _, h := range s.Hostnames {
+		fmt.Fprintf(out, "\n  - %s", h)
+	}
+	fmt.Fprintf(out, "\n  location: MESH_EXTERNAL\n  resolution: %s\n  ports:", resolution)
+	for _, p := range s.Ports {
+		fmt.Fprintf(out, "\n  - number: %d\n    name: %s\n    protocol: %s", p.Port, p.Name, p.Protocol)
+	}
+	if len(endpoints) > 0 {
+		out.WriteString("\n  endpoints:")
+		for _, e := range endpoints {
+			fmt.Fprintf(out, "\n  - address: %s", e)
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Close implements io.Closer. It removes the hostnames from the cluster DNS, the deployments are removed along with
+// the namespace.
+func (c *kubeComponent) Close() error {
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	if c.stubDomain == "" {
+		return nil
+	}
+	return updateCorefile(c.cluster, c.cluster.Name(), func(corefile string) string {
+		return removeStubDomain(corefile, c.stubDomain)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalservice
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestValidate(t *testing.T) {
+	got, err := validate([]Service{
+		{Name: "plain"},
+		{Name: "secure", Hostnames: []string{"api.example.com"}, Ports: []Port{{Protocol: protocol.HTTPS, Port: 443}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Service{
+		{Name: "plain", Hostnames: []string{"plain.external.test"}, Ports: []Port{{Name: "http-80", Protocol: protocol.HTTP, Port: 80}}},
+		{Name: "secure", Hostnames: []string{"api.example.com"}, Ports: []Port{{Name: "https-443", Protocol: protocol.HTTPS, Port: 443}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	for name, services := range map[string][]Service{
+		"none":               nil,
+		"no name":            {{}},
+		"duplicate name":     {{Name: "a"}, {Name: "a"}},
+		"duplicate hostname": {{Name: "a", Hostnames: []string{"x.test"}}, {Name: "b", Hostnames: []string{"x.test"}}},
+		"cert without key":   {{Name: "a", Cert: []byte("cert")}},
+		"grpc protocol":      {{Name: "a", Ports: []Port{{Protocol: protocol.GRPC, Port: 8080}}}},
+		"command port":       {{Name: "a", Ports: []Port{{Protocol: protocol.TCP, Port: grpcPort}}}},
+	} {
+		if _, err := validate(services); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTemplateParams(t *testing.T) {
+	services, err := validate([]Service{{
+		Name:      "secure",
+		Hostnames: []string{"api.example.com", "www.example.com"},
+		Ports: []Port{
+			{Protocol: protocol.HTTP, Port: 80},
+			{Protocol: protocol.HTTPS, Port: 443},
+			{Protocol: protocol.TCP, Port: 9000},
+			{Protocol: protocol.TLS, Port: 9443},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCert, rootKey := generateRoot(t)
+	p, err := templateParams(services[0], rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p["HTTPPorts"], []int{80, 443}) || !reflect.DeepEqual(p["TCPPorts"], []int{9000, 9443}) ||
+		!reflect.DeepEqual(p["TLSPorts"], []int{443, 9443}) {
+		t.Fatalf("unexpected ports %v %v %v", p["HTTPPorts"], p["TCPPorts"], p["TLSPorts"])
+	}
+
+	cert, _ := base64.StdEncoding.DecodeString(p["Cert"].(string))
+	key, _ := base64.StdEncoding.DecodeString(p["Key"].(string))
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(rootCert)
+	for _, h := range services[0].Hostnames {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: h, Roots: roots}); err != nil {
+			t.Errorf("certificate not valid for %s: %v", h, err)
+		}
+	}
+
+	plain, err := templateParams(Service{Name: "plain", Ports: []Port{{Protocol: protocol.HTTP, Port: 80}}}, rootCert, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain["Cert"]; ok {
+		t.Fatal("expected no certificate for a service without TLS ports")
+	}
+}
+
+func generateRoot(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         DefaultDomain,
+		NotBefore:    time.Now(),
+		TTL:          time.Hour,
+		RSAKeySize:   2048,
+		IsCA:         true,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestStubDomain(t *testing.T) {
+	corefile := ".:53 {\n    forward . /etc/resolv.conf\n}\n"
+	added := addStubDomain(corefile, "test ns1", []string{"a.test", "b.test"}, "10.96.0.20")
+	want := `.:53 {
+    forward . /etc/resolv.conf
+}
+# BEGIN test ns1
+a.test b.test {
+    forward . 10.96.0.20
+}
+# END test ns1
+`
+	if added != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", added, want)
+	}
+	// Adding the block again replaces it.
+	if again := addStubDomain(added, "test ns1", []string{"a.test", "b.test"}, "10.96.0.20"); again != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", again, want)
+	}
+	other := addStubDomain(added, "test ns2", []string{"c.test"}, "10.96.0.30")
+	// Only the blocks of external services are attributed to a namespace.
+	withService := addStubDomain(other, stubDomainMarker+"ns3", []string{"d.test"}, "10.96.0.40")
+	if got := stubDomainNamespaces(withService); !reflect.DeepEqual(got, []string{"ns3"}) {
+		t.Fatalf("expected the blocks of namespaces [ns3], got %v", got)
+	}
+	if got := removeStubDomain(removeStubDomain(other, "test ns2"), "test ns1"); got != corefile {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, corefile)
+	}
+}
+
+func TestServiceEntry(t *testing.T) {
+	s := Service{
+		Name:      "api",
+		Hostnames: []string{"api.example.com"},
+		Ports:     []Port{{Name: "https-443", Protocol: protocol.HTTPS, Port: 443}},
+	}
+	got := serviceEntry(s, ResolutionStatic, []string{"10.244.0.5"})
+	want := `apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: api
+spec:
+  hosts:
+  - api.example.com
+  location: MESH_EXTERNAL
+  resolution: STATIC
+  ports:
+  - number: 443
+    name: https-443
+    protocol: HTTPS
+  endpoints:
+  - address: 10.244.0.5
+`
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gatewayconformance runs the upstream Gateway API conformance suite against the Istio deployed by the
+// framework, so that conformance regressions are reported alongside the other integration tests.
+package gatewayconformance
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	// DefaultGatewayClass is the name of the GatewayClass the suite runs against.
+	DefaultGatewayClass = "istio"
+	// Controller is the controller name of the Istio GatewayClass.
+	Controller = "istio.io/gateway-controller"
+)
+
+// Instance is the upstream conformance suite, set up to run against a cluster of the framework.
+type Instance interface {
+	resource.Resource
+
+	// GatewayClass the suite runs against.
+	GatewayClass() string
+
+	// Run runs the suite and returns the result of each of its tests. An error is only returned if the suite could
+	// not be run, failed tests are reported in the results.
+	Run() (Results, error)
+
+	// RunOrFail calls Run and fails the test if an error is returned.
+	RunOrFail(t test.Failer) Results
+}
+
+// Config for the conformance suite.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Dir is a checkout of the upstream conformance suite. Defaults to $GATEWAY_CONFORMANCE_DIR.
+	Dir string
+
+	// Package is the Go package of the suite, relative to Dir. Defaults to ./conformance.
+	Package string
+
+	// GatewayClass to run the suite against. It is created if it does not exist. Defaults to DefaultGatewayClass.
+	GatewayClass string
+
+	// Revision of Istio to run the suite against. The namespaces created by the suite are labeled for injection
+	// by this revision.
+	Revision string
+
+	// Run is a regular expression which selects the tests to run, as for go test -run. Defaults to all tests.
+	Run string
+
+	// Args are additional arguments passed to the suite.
+	Args []string
+}
+
+func (c *Config) fillDefaults() error {
+	if c.Dir == "" {
+		c.Dir = env.GATEWAY_CONFORMANCE_DIR.Value()
+	}
+	if c.Dir == "" {
+		return fmt.Errorf("no conformance suite: set %s to a checkout of the upstream suite", env.GATEWAY_CONFORMANCE_DIR)
+	}
+	if c.Package == "" {
+		c.Package = "./conformance"
+	}
+	if c.GatewayClass == "" {
+		c.GatewayClass = DefaultGatewayClass
+	}
+	return nil
+}
+
+// Available returns true if a checkout of the upstream suite is configured.
+func Available() bool {
+	return env.GATEWAY_CONFORMANCE_DIR.Value() != ""
+}
+
+// New sets up the conformance suite and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	if err := c.fillDefaults(); err != nil {
+		return nil, err
+	}
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("gatewayconformance.NewOrFail: %v", err)
+	}
+	return i
+}
+
+// Outcome of a conformance test.
+type Outcome string
+
+const (
+	Pass Outcome = "pass"
+	Fail Outcome = "fail"
+	Skip Outcome = "skip"
+)
+
+// Result of a single conformance test.
+type Result struct {
+	// Name of the test, including the names of its parents separated by '/'.
+	Name    string
+	Outcome Outcome
+	// Output the test logged.
+	Output string
+}
+
+// Results of a run of the conformance suite, in the order the tests completed.
+type Results []Result
+
+// Failed returns the tests which failed.
+func (r Results) Failed() Results {
+	var out Results
+	for _, res := range r {
+		if res.Outcome == Fail {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// CheckOK returns an error listing the failed tests, if any.
+func (r Results) CheckOK() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(failed))
+	for _, f := range failed {
+		names = append(names, f.Name)
+	}
+	return fmt.Errorf("%d of %d conformance tests failed: %s", len(failed), len(r), strings.Join(names, ", "))
+}
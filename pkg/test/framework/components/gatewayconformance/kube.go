@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayconformance
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"istio.io/api/label"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id      resource.ID
+	cfg     Config
+	cluster kube.Cluster
+	close   func()
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cfg:     cfg,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster).(kube.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	gatewayClass := fmt.Sprintf(`apiVersion: networking.x-k8s.io/v1alpha1
+kind: GatewayClass
+metadata:
+  name: %s
+spec:
+  controller: %s
+`, cfg.GatewayClass, Controller)
+	if err := ctx.Config(c.cluster).ApplyYAML("", gatewayClass); err != nil {
+		return nil, err
+	}
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML("", gatewayClass)
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) GatewayClass() string {
+	return c.cfg.GatewayClass
+}
+
+// args returns the arguments of go test. The suite cleans up the resources it creates when done.
+func (c *kubeComponent) args() []string {
+	args := []string{"test", "-json", "-count=1", "-timeout=1h"}
+	if c.cfg.Run != "" {
+		args = append(args, "-run", c.cfg.Run)
+	}
+	nsLabel := "istio-injection=enabled"
+	if c.cfg.Revision != "" {
+		nsLabel = fmt.Sprintf("%s=%s", label.IstioRev, c.cfg.Revision)
+	}
+	args = append(args, c.cfg.Package, "-args",
+		"-gateway-class="+c.cfg.GatewayClass,
+		"-cleanup-base-resources=true",
+		"-namespace-labels="+nsLabel)
+	return append(args, c.cfg.Args...)
+}
+
+func (c *kubeComponent) Run() (Results, error) {
+	cmd := exec.Command("go", c.args()...)
+	cmd.Dir = c.cfg.Dir
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+c.cluster.Filename())
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	scopes.Framework.Infof("running gateway conformance suite: go %s", strings.Join(cmd.Args[1:], " "))
+	runErr := cmd.Run()
+	results, err := parseTestEvents(stdout)
+	if err != nil {
+		return nil, err
+	}
+	// go test exits with an error when tests fail, which the results report. Without any results, the suite
+	// did not run at all, for example because it does not build.
+	if runErr != nil && len(results) == 0 {
+		return nil, fmt.Errorf("failed running the conformance suite: %v\n%s%s", runErr, stdout, stderr)
+	}
+	return results, nil
+}
+
+func (c *kubeComponent) RunOrFail(t test.Failer) Results {
+	t.Helper()
+	results, err := c.Run()
+	if err != nil {
+		t.Fatalf("gatewayconformance.RunOrFail: %v", err)
+	}
+	return results
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	return nil
+}
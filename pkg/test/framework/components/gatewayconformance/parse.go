@@ -0,0 +1,69 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayconformance
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// testEvent is an event of go test -json, see go doc test2json.
+type testEvent struct {
+	Action string
+	Test   string
+	Output string
+}
+
+// parseTestEvents returns the results of the tests in the output of go test -json. Lines which are not events,
+// such as build output, are ignored.
+func parseTestEvents(r io.Reader) (Results, error) {
+	var out Results
+	output := map[string]*strings.Builder{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		e := testEvent{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("failed parsing test event %q: %v", line, err)
+		}
+		if e.Test == "" {
+			continue
+		}
+		switch e.Action {
+		case "output":
+			if output[e.Test] == nil {
+				output[e.Test] = &strings.Builder{}
+			}
+			output[e.Test].WriteString(e.Output)
+		case "pass", "fail", "skip":
+			res := Result{Name: e.Test, Outcome: Outcome(e.Action)}
+			if o := output[e.Test]; o != nil {
+				res.Output = o.String()
+			}
+			out = append(out, res)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
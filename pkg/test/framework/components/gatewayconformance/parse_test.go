@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewayconformance
+
+import (
+	"strings"
+	"testing"
+)
+
+const events = `# sigs.k8s.io/gateway-api/conformance
+{"Action":"run","Test":"TestConformance"}
+{"Action":"run","Test":"TestConformance/HTTPRouteSimple"}
+{"Action":"output","Test":"TestConformance/HTTPRouteSimple","Output":"=== RUN   TestConformance/HTTPRouteSimple\n"}
+{"Action":"pass","Test":"TestConformance/HTTPRouteSimple","Elapsed":1.5}
+{"Action":"run","Test":"TestConformance/TLSRoute"}
+{"Action":"output","Test":"TestConformance/TLSRoute","Output":"    tls.go:10: unsupported\n"}
+{"Action":"skip","Test":"TestConformance/TLSRoute"}
+{"Action":"run","Test":"TestConformance/HTTPRouteHeaderMatching"}
+{"Action":"output","Test":"TestConformance/HTTPRouteHeaderMatching","Output":"    headers.go:42: expected 200, got 404\n"}
+{"Action":"fail","Test":"TestConformance/HTTPRouteHeaderMatching","Elapsed":30}
+{"Action":"fail","Test":"TestConformance","Elapsed":32}
+{"Action":"output","Output":"FAIL\n"}
+{"Action":"fail","Elapsed":32}
+`
+
+func TestParseTestEvents(t *testing.T) {
+	results, err := parseTestEvents(strings.NewReader(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %v", results)
+	}
+	if results[1].Outcome != Skip || results[1].Output != "    tls.go:10: unsupported\n" {
+		t.Errorf("unexpected result: %+v", results[1])
+	}
+	failed := results.Failed()
+	if len(failed) != 2 || failed[0].Name != "TestConformance/HTTPRouteHeaderMatching" {
+		t.Fatalf("unexpected failed tests: %v", failed)
+	}
+	if err := results.CheckOK(); err == nil || !strings.Contains(err.Error(), "2 of 4") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := results[:2].CheckOK(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dashboard is the subset of a Grafana dashboard needed to validate its queries.
+type Dashboard struct {
+	Title  string  `json:"title"`
+	Panels []Panel `json:"panels"`
+}
+
+// Panel is a single dashboard panel. Rows are panels that hold other panels.
+type Panel struct {
+	Title   string   `json:"title"`
+	Targets []Target `json:"targets"`
+	Panels  []Panel  `json:"panels"`
+}
+
+// Target is a single query of a panel.
+type Target struct {
+	Expr string `json:"expr"`
+}
+
+// Query is a query along with the panel it belongs to.
+type Query struct {
+	Panel string
+	Expr  string
+}
+
+func parseDashboard(js string) (Dashboard, error) {
+	d := Dashboard{}
+	if err := json.Unmarshal([]byte(js), &d); err != nil {
+		return Dashboard{}, fmt.Errorf("failed parsing dashboard: %v", err)
+	}
+	if len(d.Panels) == 0 {
+		return Dashboard{}, fmt.Errorf("dashboard %q has no panels", d.Title)
+	}
+	return d, nil
+}
+
+// Queries returns all of the queries in the dashboard, including those in collapsed rows.
+func (d Dashboard) Queries() []Query {
+	var out []Query
+	var walk func(panels []Panel)
+	walk = func(panels []Panel) {
+		for _, p := range panels {
+			for _, t := range p.Targets {
+				if t.Expr != "" {
+					out = append(out, Query{Panel: p.Title, Expr: t.Expr})
+				}
+			}
+			walk(p.Panels)
+		}
+	}
+	walk(d.Panels)
+	return out
+}
+
+var (
+	// Grafana's interval variables are used in range selectors, so they must be replaced with a duration.
+	intervalReplacer = strings.NewReplacer(
+		"$__rate_interval", "1m",
+		"$__interval", "1m",
+		"$__range", "1m",
+		"${__rate_interval}", "1m",
+		"${__interval}", "1m",
+		"${__range}", "1m")
+
+	// All other variables select label values; match anything instead.
+	variableRegex = regexp.MustCompile(`\$\{?[a-zA-Z_][a-zA-Z0-9_]*\}?`)
+)
+
+// prepareQuery makes a dashboard query executable by applying the given replacements and then replacing
+// dashboard variables.
+func prepareQuery(expr string, replacements []string) string {
+	if len(replacements) > 0 {
+		expr = strings.NewReplacer(replacements...).Replace(expr)
+	}
+	expr = intervalReplacer.Replace(expr)
+	return variableRegex.ReplaceAllString(expr, ".*")
+}
+
+func isExcluded(query string, excluded []string) bool {
+	for _, e := range excluded {
+		if strings.Contains(query, e) {
+			return true
+		}
+	}
+	return false
+}
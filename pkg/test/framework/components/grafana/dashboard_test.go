@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafana
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pkg/test/env"
+)
+
+func TestDashboardQueries(t *testing.T) {
+	d, err := parseDashboard(`{"title":"test","panels":[
+{"title":"a","targets":[{"expr":"sum(istio_requests_total)"}]},
+{"title":"row","type":"row","panels":[{"title":"b","targets":[{"expr":"up"},{"refId":"B"}]}]}
+]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Query{
+		{Panel: "a", Expr: "sum(istio_requests_total)"},
+		{Panel: "b", Expr: "up"},
+	}
+	if got := d.Queries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPrepareQuery(t *testing.T) {
+	got := prepareQuery(
+		`sum(rate(istio_requests_total{destination_workload_namespace=~"$namespace",reporter!="${reporter}"}[$__interval]))`,
+		[]string{`reporter!=`, `reporter=~`})
+	want := `sum(rate(istio_requests_total{destination_workload_namespace=~".*",reporter=~".*"}[1m]))`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// TestShippedDashboards ensures all of the dashboards we ship can be parsed and have queries.
+func TestShippedDashboards(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(env.IstioSrc, "manifests/addons/dashboards/*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no dashboards found")
+	}
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := parseDashboard(string(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(d.Queries()) == 0 {
+				t.Fatalf("dashboard %q has no queries", d.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafana
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/prometheus"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Instance represents a Grafana deployment, provisioned with the Istio dashboards, on kube.
+type Instance interface {
+	resource.Resource
+
+	// Dashboards returns the Istio dashboards shipped with the Grafana addon, keyed by file name
+	// (e.g. "istio-mesh-dashboard.json").
+	Dashboards() (map[string]Dashboard, error)
+
+	// LoadedDashboards returns the titles of the dashboards that Grafana has provisioned.
+	LoadedDashboards() ([]string, error)
+
+	// ValidateDashboard executes every query of the named dashboard against the given Prometheus.
+	// An error is returned for queries that fail to execute, or that return no samples unless they
+	// match one of opts.Excluded.
+	ValidateDashboard(name string, p prometheus.Instance, opts ValidateOptions) error
+	ValidateDashboardOrFail(t test.Failer, name string, p prometheus.Instance, opts ValidateOptions)
+}
+
+// Config for the Grafana component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+}
+
+// ValidateOptions control how dashboard queries are validated.
+type ValidateOptions struct {
+	// Excluded queries, matched by substring, are allowed to return no samples. This is useful for
+	// metrics the test environment cannot generate, such as errors that are not simulated.
+	Excluded []string
+
+	// Replacements are applied to each query, as old/new pairs, before it is executed. They are applied
+	// before dashboard variables (e.g. $namespace) are replaced with wildcards.
+	Replacements []string
+}
+
+// New deploys Grafana and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("grafana.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,236 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/prometheus"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	appName     = "grafana"
+	grafanaPort = 3000
+)
+
+var (
+	// dashboardConfigMaps hold the dashboards shipped with the Grafana addon.
+	dashboardConfigMaps = []string{
+		"istio-grafana-dashboards",
+		"istio-services-grafana-dashboards",
+	}
+
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	namespace string
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+	close     func()
+}
+
+func getGrafanaYaml() (string, error) {
+	yamlBytes, err := ioutil.ReadFile(filepath.Join(env.IstioSrc, "samples/addons/grafana.yaml"))
+	if err != nil {
+		return "", err
+	}
+	return string(yamlBytes), nil
+}
+
+func newKube(ctx resource.Context, cfgIn Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfgIn.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	cfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.namespace = cfg.TelemetryNamespace
+
+	yaml, err := getGrafanaYaml()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.namespace, yaml); err != nil {
+		return nil, err
+	}
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML(c.namespace, yaml)
+	}
+
+	fetchFn := testKube.NewSinglePodFetch(c.cluster, c.namespace, fmt.Sprintf("app=%s", appName))
+	pods, err := testKube.WaitUntilPodsAreReady(fetchFn)
+	if err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, grafanaPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	scopes.Framework.Debugf("initialized grafana port forwarder: %v", forwarder.Address())
+
+	c.address = fmt.Sprintf("http://%s", forwarder.Address())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Dashboards() (map[string]Dashboard, error) {
+	out := map[string]Dashboard{}
+	for _, name := range dashboardConfigMaps {
+		cm, err := c.cluster.CoreV1().ConfigMaps(c.namespace).Get(context.TODO(), name, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find dashboards %v: %v", name, err)
+		}
+		for file, js := range cm.Data {
+			d, err := parseDashboard(js)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %v", name, file, err)
+			}
+			out[file] = d
+		}
+	}
+	return out, nil
+}
+
+func (c *kubeComponent) LoadedDashboards() ([]string, error) {
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Get(c.address + "/api/search?type=dash-db")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("grafana api returned non-ok status: %v", resp.StatusCode)
+	}
+	var results []struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+	titles := make([]string, 0, len(results))
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+	return titles, nil
+}
+
+func (c *kubeComponent) ValidateDashboard(name string, p prometheus.Instance, opts ValidateOptions) error {
+	dashboards, err := c.Dashboards()
+	if err != nil {
+		return err
+	}
+	d, f := dashboards[name]
+	if !f {
+		return fmt.Errorf("failed to find expected dashboard: %v", name)
+	}
+	var errs error
+	for _, q := range d.Queries() {
+		if err := checkQuery(p, q, opts); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (c *kubeComponent) ValidateDashboardOrFail(t test.Failer, name string, p prometheus.Instance, opts ValidateOptions) {
+	t.Helper()
+	if err := c.ValidateDashboard(name, p, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkQuery(p prometheus.Instance, q Query, opts ValidateOptions) error {
+	query := prepareQuery(q.Expr, opts.Replacements)
+	value, _, err := p.API().QueryRange(context.Background(), query, promv1.Range{
+		Start: time.Now().Add(-time.Minute),
+		End:   time.Now(),
+		Step:  time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("panel %q: failure executing query (%s): %v", q.Panel, query, err)
+	}
+	if value == nil {
+		return fmt.Errorf("panel %q: returned value should not be nil for '%s'", q.Panel, query)
+	}
+	numSamples := 0
+	switch v := value.(type) {
+	case model.Vector:
+		numSamples = v.Len()
+	case model.Matrix:
+		numSamples = v.Len()
+	case *model.Scalar:
+		numSamples = 1
+	default:
+		return fmt.Errorf("panel %q: unknown metric value type: %T", q.Panel, v)
+	}
+	if isExcluded(query, opts.Excluded) {
+		if numSamples != 0 {
+			scopes.Framework.Infof("Filtered out metric '%v', but got samples: %v", query, value)
+		}
+		return nil
+	}
+	if numSamples == 0 {
+		return fmt.Errorf("panel %q: expected a metric value for '%s', found no samples", q.Panel, query)
+	}
+	return nil
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
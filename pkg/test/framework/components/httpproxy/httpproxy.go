@@ -0,0 +1,62 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpproxy deploys a forward HTTP proxy, for environments where the egress of the clusters must transit
+// a proxy configured with the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+package httpproxy
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Instance is a forward proxy deployed on kube. Plain HTTP requests are proxied, and other traffic, such as
+// HTTPS and gRPC, is tunneled with CONNECT.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the proxy is deployed in.
+	Namespace() namespace.Instance
+
+	// URL of the proxy within the cluster, as set in HTTP_PROXY and HTTPS_PROXY.
+	URL() string
+
+	// Requests returns the number of requests, including CONNECT tunnels, the proxy received so far.
+	Requests() (int, error)
+
+	// RequestsOrFail calls Requests and fails the test if an error is returned.
+	RequestsOrFail(t test.Failer) int
+}
+
+// Config for the forward proxy.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+}
+
+// New deploys a forward proxy and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("httpproxy.NewOrFail: %v", err)
+	}
+	return i
+}
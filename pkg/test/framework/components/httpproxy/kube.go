@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpproxy
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName   = "http-proxy"
+	containerName = "proxy"
+	port          = 3128
+	adminPort     = 15000
+	statPrefix    = "forward_proxy"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id      resource.ID
+	ns      namespace.Instance
+	cluster resource.Cluster
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-http-proxy",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.HTTPProxyInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.Tag,
+		"ImagePullPolicy": s.PullPolicy,
+		"Service":         serviceName,
+		"Container":       containerName,
+		"Port":            port,
+		"AdminPort":       adminPort,
+		"StatPrefix":      statPrefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The deployment is removed along with the namespace.
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), manifest); err != nil {
+		return nil, err
+	}
+	if _, err := testKube.WaitUntilPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+serviceName)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) URL() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", serviceName, c.ns.Name(), port)
+}
+
+func (c *kubeComponent) Requests() (int, error) {
+	pods, err := testKube.CheckPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+serviceName))
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, p := range pods {
+		stdout, stderr, err := c.cluster.PodExec(p.Name, p.Namespace, containerName,
+			"pilot-agent request GET stats?filter="+requestsStat)
+		if err != nil {
+			return 0, fmt.Errorf("failed reading the stats of %s: %v: %s", p.Name, err, stderr)
+		}
+		n, err := parseRequests(stdout)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (c *kubeComponent) RequestsOrFail(t test.Failer) int {
+	t.Helper()
+	n, err := c.Requests()
+	if err != nil {
+		t.Fatalf("httpproxy.RequestsOrFail: %v", err)
+	}
+	return n
+}
+
+// requestsStat is the Envoy counter of the requests received by the proxy.
+var requestsStat = "http." + statPrefix + ".downstream_rq_total"
+
+// parseRequests extracts the request counter from the output of the Envoy stats endpoint.
+func parseRequests(stats string) (int, error) {
+	for _, line := range strings.Split(stats, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == requestsStat {
+			return strconv.Atoi(strings.TrimSpace(parts[1]))
+		}
+	}
+	return 0, fmt.Errorf("stat %s not found", requestsStat)
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpproxy
+
+import "testing"
+
+func TestParseRequests(t *testing.T) {
+	stats := `http.forward_proxy.downstream_cx_total: 7
+http.forward_proxy.downstream_rq_total: 12
+http.forward_proxy.downstream_rq_total_extra: 3
+`
+	n, err := parseRequests(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 12 {
+		t.Fatalf("expected 12 requests, got %d", n)
+	}
+	if _, err := parseRequests("server.live: 1\n"); err == nil {
+		t.Fatal("expected error for missing stat")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"istio.io/istio/pkg/test/cert/ca"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// PluginCA holds the certificates generated by the framework when Istio is installed with a plugin CA, either
+// because Config.PluginCA is set or because the environment is multicluster. The intermediate CA of each cluster
+// is installed as the cacerts secret, which istiod uses instead of its self-signed root.
+type PluginCA struct {
+	// Root is the root CA shared by all clusters.
+	Root ca.Root
+
+	// Intermediates holds the intermediate CA of each cluster, keyed by cluster name.
+	Intermediates map[string]ca.Intermediate
+
+	// PreviousRoots holds the roots replaced by RotateCA, most recent first. They remain trusted by workloads.
+	PreviousRoots []ca.Root
+}
+
+// RootCert returns the PEM encoded certificate of the root CA, which all workload certificates chain to.
+func (p *PluginCA) RootCert() ([]byte, error) {
+	return ioutil.ReadFile(p.Root.CertFile)
+}
+
+// TrustedRootCerts returns the PEM encoded certificates of the current and previous roots, which make up the
+// trust bundle of the mesh.
+func (p *PluginCA) TrustedRootCerts() ([]byte, error) {
+	var out []byte
+	for _, r := range append([]ca.Root{p.Root}, p.PreviousRoots...) {
+		cert, err := ioutil.ReadFile(r.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cert...)
+	}
+	return out, nil
+}
+
+// IntermediateFor returns the intermediate CA of the given cluster.
+func (p *PluginCA) IntermediateFor(cluster resource.Cluster) (ca.Intermediate, error) {
+	out, ok := p.Intermediates[cluster.Name()]
+	if !ok {
+		return ca.Intermediate{}, fmt.Errorf("no intermediate CA was generated for cluster %s", cluster.Name())
+	}
+	return out, nil
+}
+
+// IntermediateCertFor returns the PEM encoded certificate of the intermediate CA of the given cluster.
+func (p *PluginCA) IntermediateCertFor(cluster resource.Cluster) ([]byte, error) {
+	in, err := p.IntermediateFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(in.CertFile)
+}
@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/pkg/test/cert/ca"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// CARotation selects which CAs RotateCA replaces.
+type CARotation int
+
+const (
+	// RotateIntermediate replaces the intermediate CA of every cluster with a new one, signed by the same root.
+	RotateIntermediate CARotation = iota
+
+	// RotateRoot replaces the root CA, and the intermediate CAs with ones signed by the new root. The previous
+	// roots stay in the trust bundle, so that certificates issued under the previous chain are accepted until they
+	// are re-issued.
+	RotateRoot
+)
+
+func (r CARotation) String() string {
+	if r == RotateRoot {
+		return "root"
+	}
+	return "intermediate"
+}
+
+// istiodRestartTimeout is the time allowed for istiod to roll out after a restart.
+var istiodRestartTimeout = retry.Timeout(2 * time.Minute)
+
+func (i *operatorComponent) RotateCA(rotation CARotation) (*PluginCA, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.pluginCA == nil {
+		return nil, fmt.Errorf("cannot rotate the CA: Istio was not deployed with a plugin CA")
+	}
+
+	i.caRotations++
+	certsDir := filepath.Join(i.workDir, fmt.Sprintf("cacerts-%d", i.caRotations))
+	if err := os.Mkdir(certsDir, 0700); err != nil {
+		return nil, err
+	}
+	next := &PluginCA{
+		Root:          i.pluginCA.Root,
+		Intermediates: map[string]ca.Intermediate{},
+		PreviousRoots: i.pluginCA.PreviousRoots,
+	}
+	if rotation == RotateRoot {
+		root, err := ca.NewRoot(certsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating the root CA: %v", err)
+		}
+		next.Root = root
+		next.PreviousRoots = append([]ca.Root{i.pluginCA.Root}, i.pluginCA.PreviousRoots...)
+	}
+	trustBundle, err := next.TrustedRootCerts()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes.Framework.Infof("=== BEGIN: Rotate %s CA ===", rotation)
+	for _, cluster := range i.environment.KubeClusters {
+		clusterDir := filepath.Join(certsDir, cluster.Name())
+		if err := os.Mkdir(clusterDir, 0700); err != nil {
+			return nil, err
+		}
+		caConfig, err := ca.NewIstioConfig(i.settings.SystemNamespace)
+		if err != nil {
+			return nil, err
+		}
+		clusterCA, err := ca.NewIntermediate(clusterDir, caConfig, next.Root)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating intermediate CA for cluster %s: %v", cluster.Name(), err)
+		}
+		next.Intermediates[cluster.Name()] = clusterCA
+
+		secret, err := clusterCA.NewIstioCASecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed creating intermediate CA secret for cluster %s: %v", cluster.Name(), err)
+		}
+		// istiod distributes root-cert.pem to the workloads as the trust bundle.
+		secret.Data["root-cert.pem"] = trustBundle
+		if _, err := cluster.CoreV1().Secrets(i.settings.SystemNamespace).Update(context.TODO(), secret,
+			kubeApiMeta.UpdateOptions{}); err != nil {
+			return nil, fmt.Errorf("failed updating CA secret for cluster %s: %v", cluster.Name(), err)
+		}
+	}
+
+	// istiod only reads the plugin CA at startup.
+	for _, cluster := range i.environment.ControlPlaneClusters() {
+		if err := restartIstiod(cluster, i.settings.SystemNamespace); err != nil {
+			scopes.Framework.Infof("=== FAILED: Rotate %s CA ===", rotation)
+			return nil, err
+		}
+	}
+	scopes.Framework.Infof("=== SUCCEEDED: Rotate %s CA ===", rotation)
+
+	i.pluginCA = next
+	return next, nil
+}
+
+// restartIstiod triggers a rolling restart of istiod, like kubectl rollout restart, and waits for it to complete.
+func restartIstiod(cluster resource.Cluster, namespace string) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`,
+		time.Now().Format(time.RFC3339))
+	if _, err := cluster.AppsV1().Deployments(namespace).Patch(context.TODO(), istiodSvcName, types.StrategicMergePatchType,
+		[]byte(patch), kubeApiMeta.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to restart istiod in %s: %v", cluster.Name(), err)
+	}
+
+	if err := retry.UntilSuccess(func() error {
+		d, err := cluster.AppsV1().Deployments(namespace).Get(context.TODO(), istiodSvcName, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return err
+		}
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		s := d.Status
+		if s.ObservedGeneration < d.Generation || s.UpdatedReplicas != replicas ||
+			s.AvailableReplicas != replicas || s.Replicas != replicas {
+			return fmt.Errorf("istiod rollout in progress: %d/%d updated, %d available, %d total",
+				s.UpdatedReplicas, replicas, s.AvailableReplicas, s.Replicas)
+		}
+		return nil
+	}, istiodRestartTimeout, componentDeployDelay); err != nil {
+		return fmt.Errorf("failed waiting for istiod to restart in %s: %v", cluster.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	kube2 "istio.io/istio/pkg/test/kube"
+)
+
+const (
+	// IstiodCertProvider has istiod sign its serving certificate with its own CA. This is the default.
+	IstiodCertProvider = "istiod"
+
+	// KubernetesCertProvider has istiod request its serving certificate through the Kubernetes CSR API. The
+	// certificate is signed by the cluster CA, through the kubernetes.io/legacy-unknown signer.
+	KubernetesCertProvider = "kubernetes"
+
+	// istiodTLSPort is the port istiod serves XDS and certificate requests over TLS on.
+	istiodTLSPort = 15012
+)
+
+// IstiodServingCert returns the certificate chain istiod presents on its TLS port in the given cluster, leaf first.
+// The chain is issued by the signer selected with Config.PilotCertProvider.
+func IstiodServingCert(cluster resource.Cluster, cfg Config) ([]*x509.Certificate, error) {
+	pods, err := kube2.WaitUntilPodsAreReady(kube2.NewPodFetch(cluster, cfg.SystemNamespace, "app=istiod"))
+	if err != nil {
+		return nil, err
+	}
+	forwarder, err := cluster.NewPortForwarder(pods[0].Name, pods[0].Namespace, "", 0, istiodTLSPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	defer forwarder.Close()
+
+	conn, err := tls.Dial("tcp", forwarder.Address(), &tls.Config{
+		ServerName: fmt.Sprintf("%s.%s.svc", istiodSvcName, cfg.SystemNamespace),
+		// The chain is returned to the caller for verification.
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed TLS handshake with istiod in %s: %v", cluster.Name(), err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// KubernetesCACert returns the PEM encoded CA certificate of the given cluster, which signs the certificates
+// requested through the Kubernetes CSR API when istiod is deployed with KubernetesCertProvider.
+func KubernetesCACert(cluster resource.Cluster, cfg Config) ([]byte, error) {
+	// The CA certificate is mounted into every pod along with the service account token.
+	secrets, err := cluster.CoreV1().Secrets(cfg.SystemNamespace).List(context.TODO(), kubeApiMeta.ListOptions{
+		FieldSelector: "type=" + string(kubeApiCore.SecretTypeServiceAccountToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range secrets.Items {
+		if ca, ok := s.Data[kubeApiCore.ServiceAccountRootCAKey]; ok && len(ca) > 0 {
+			return ca, nil
+		}
+	}
+	return nil, fmt.Errorf("no service account token with a CA certificate found in %s/%s",
+		cluster.Name(), cfg.SystemNamespace)
+}
@@ -129,6 +129,33 @@ type Config struct {
 	// Do not wait for the validation webhook before completing the deployment. This is useful for
 	// doing deployments without Galley.
 	SkipWaitForValidationWebhook bool
+
+	// PluginCA generates a root CA and an intermediate CA for each cluster, and installs the intermediate as the
+	// cacerts secret before deploying Istio. This is always done in multicluster environments, in order to
+	// establish a shared root of trust. The certificates are available from Instance.PluginCA.
+	PluginCA bool
+
+	// PilotCertProvider selects the signer of the certificate istiod serves with: IstiodCertProvider (the default)
+	// or KubernetesCertProvider. Workload certificates are signed by istiod in either case. Istiod submits its
+	// CSRs without a signer name, so only the legacy-unknown signer of the cluster is supported, which the
+	// ClusterRole of istiod is already allowed to approve.
+	PilotCertProvider string
+
+	// TrustDomains sets the trust domain of each cluster, keyed by cluster name. Clusters which are not listed use
+	// DefaultTrustDomain. The trust domains of all clusters are added to the trust domain aliases of each cluster,
+	// so that workloads are accepted across trust domains. Since the clusters share the plugin CA root in a
+	// multicluster environment, this federates the trust domains.
+	TrustDomains map[string]string
+
+	// TrustDomainAliases are added to the trust domain aliases of every cluster, for example the previous trust
+	// domain in a trust domain migration.
+	TrustDomainAliases []string
+
+	// EgressProxy deploys a forward proxy to each cluster before Istio, and sets the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables of istiod and the istio agents to it. Only the destinations outside the
+	// cluster, such as remote API servers and discovery servers, transit the proxy. Direct egress is not blocked,
+	// so tests verify the transit with the request count of Instance.EgressProxyFor.
+	EgressProxy bool
 }
 
 func (c *Config) IstioOperatorConfigYAML(iopYaml string) string {
@@ -285,6 +312,11 @@ func (c *Config) String() string {
 	result += fmt.Sprintf("Values:                         %v\n", c.Values)
 	result += fmt.Sprintf("IOPFile:                        %s\n", c.IOPFile)
 	result += fmt.Sprintf("SkipWaitForValidationWebhook:   %v\n", c.SkipWaitForValidationWebhook)
+	result += fmt.Sprintf("PluginCA:                       %v\n", c.PluginCA)
+	result += fmt.Sprintf("PilotCertProvider:              %s\n", c.PilotCertProvider)
+	result += fmt.Sprintf("TrustDomains:                   %v\n", c.TrustDomains)
+	result += fmt.Sprintf("TrustDomainAliases:             %v\n", c.TrustDomainAliases)
+	result += fmt.Sprintf("EgressProxy:                    %v\n", c.EgressProxy)
 	return result
 }
 
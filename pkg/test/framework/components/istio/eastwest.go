@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/pkg/test/framework/components/istioctl"
 	"istio.io/istio/pkg/test/framework/image"
 	"istio.io/istio/pkg/test/framework/resource"
+	kube2 "istio.io/istio/pkg/test/kube"
 	"istio.io/istio/pkg/test/scopes"
 	"istio.io/istio/pkg/test/util/retry"
 )
@@ -84,6 +85,9 @@ func (i *operatorComponent) deployEastWestGateway(cluster resource.Cluster) erro
 		"--set", "values.global.imagePullPolicy=" + imgSettings.PullPolicy,
 		"-f", iopFile,
 	}
+	if name := imgSettings.PullSecretName(); name != "" {
+		installSettings = append(installSettings, "--set", fmt.Sprintf("values.%s[0]=%s", image.ImagePullSecretsValuesKey, name))
+	}
 	scopes.Framework.Infof("Deploying eastwestgateway in %s: %v", cluster.Name(), installSettings)
 	gwYaml, stderr, err := istioCtl.Invoke(installSettings)
 	if err != nil {
@@ -114,8 +118,14 @@ func (i *operatorComponent) deployEastWestGateway(cluster resource.Cluster) erro
 				return nil
 			}
 		}
+		// Distinguish the pods still starting from those which cannot start, to fail fast.
+		for _, p := range pods.Items {
+			if err := kube2.CheckPodContainers(&p); err != nil {
+				return err
+			}
+		}
 		return fmt.Errorf("no ready pods for istio=" + eastWestIngressIstioLabel)
-	}, componentDeployTimeout, componentDeployDelay); err != nil {
+	}, componentDeployTimeout, componentDeployDelay, retry.Classify(kube2.ClassifyPodErrors)); err != nil {
 		return fmt.Errorf("failed waiting for %s to become ready: %v", eastWestIngressServiceName, err)
 	}
 
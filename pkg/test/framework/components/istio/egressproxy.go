@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/components/httpproxy"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+// deployEgressProxies deploys a forward proxy to each cluster, which the control plane and the proxies of the
+// cluster are configured to use for their egress.
+func (i *operatorComponent) deployEgressProxies() error {
+	for _, cluster := range i.environment.KubeClusters {
+		p, err := httpproxy.New(i.ctx, httpproxy.Config{Cluster: cluster})
+		if err != nil {
+			return fmt.Errorf("failed deploying the egress proxy of %s: %v", cluster.Name(), err)
+		}
+		i.egressProxies[cluster.Index()] = p
+	}
+	return nil
+}
+
+func (i *operatorComponent) EgressProxyFor(cluster resource.Cluster) httpproxy.Instance {
+	return i.egressProxies[i.ctx.Clusters().GetOrDefault(cluster).Index()]
+}
+
+// egressProxyIOPFile writes an IstioOperator overlay which sets the proxy environment variables of istiod and the
+// istio agents to the egress proxy of the cluster, and returns its path. It returns an empty path if there is no
+// egress proxy.
+func (i *operatorComponent) egressProxyIOPFile(cluster resource.Cluster) (string, error) {
+	p := i.egressProxies[cluster.Index()]
+	if p == nil {
+		return "", nil
+	}
+	noProxy, err := noProxyFor(cluster)
+	if err != nil {
+		return "", err
+	}
+	proxyEnv := map[string]string{
+		"HTTP_PROXY":  p.URL(),
+		"HTTPS_PROXY": p.URL(),
+		"NO_PROXY":    noProxy,
+	}
+	overlay := map[string]interface{}{
+		"apiVersion": "install.istio.io/v1alpha1",
+		"kind":       "IstioOperator",
+		"spec": map[string]interface{}{
+			"meshConfig": map[string]interface{}{
+				"defaultConfig": map[string]interface{}{
+					// The proxy metadata is set as environment variables of the istio agents.
+					"proxyMetadata": proxyEnv,
+				},
+			},
+			"values": map[string]interface{}{
+				"pilot": map[string]interface{}{
+					"env": proxyEnv,
+				},
+			},
+		},
+	}
+	out, err := yaml.Marshal(overlay)
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(i.workDir, fmt.Sprintf("%s-egress-proxy.yaml", cluster.Name()))
+	if err := ioutil.WriteFile(file, out, os.ModePerm); err != nil {
+		return "", err
+	}
+	scopes.Framework.Infof("installing cluster %s with egress proxy %s (no proxy: %s)", cluster.Name(), p.URL(), noProxy)
+	return file, nil
+}
+
+// noProxyFor returns the destinations reached directly from within the cluster: its services and pods, and its
+// API server, which istiod reaches by IP. Everything else, including the API servers of the other clusters and
+// the discovery servers of remote control planes, transits the proxy.
+func noProxyFor(cluster resource.Cluster) (string, error) {
+	out := []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+	svc, err := cluster.CoreV1().Services("default").Get(context.TODO(), "kubernetes", kubeApiMeta.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	out = append(out, svc.Spec.ClusterIP)
+	nodes, err := cluster.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes.Items {
+		out = append(out, n.Spec.PodCIDRs...)
+	}
+	return strings.Join(out, ","), nil
+}
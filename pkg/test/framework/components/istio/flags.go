@@ -42,6 +42,10 @@ func init() {
 		"Timeout applied to undeploying Istio from the target Kubernetes environment. Only applies if DeployIstio=true.")
 	flag.StringVar(&settingsFromCommandline.IOPFile, "istio.test.kube.helm.iopFile", settingsFromCommandline.IOPFile,
 		"IstioOperator spec file. This can be an absolute path or relative to repository root.")
+	flag.BoolVar(&settingsFromCommandline.PluginCA, "istio.test.kube.pluginCA", settingsFromCommandline.PluginCA,
+		"Install Istio with a generated plugin CA (cacerts secret), rather than its self-signed root CA.")
+	flag.BoolVar(&settingsFromCommandline.EgressProxy, "istio.test.kube.egressProxy", settingsFromCommandline.EgressProxy,
+		"Deploy a forward proxy to each cluster, and configure istiod and the proxies to use it for the egress of the cluster.")
 	flag.StringVar(&helmValues, "istio.test.kube.helm.values", helmValues,
 		"Manual overrides for Helm values file. Only valid when deploying Istio.")
 }
@@ -281,6 +281,59 @@ func (c *ingressImpl) CallWithRetryOrFail(t test.Failer, options ingress.CallOpt
 	return resp
 }
 
+func (c *ingressImpl) CallTCP(options ingress.TCPCallOptions) (ingress.TCPResponse, error) {
+	if len(options.Address.IP) == 0 {
+		options.Address = c.TCPAddress()
+	}
+	return ingress.DialTCP(options)
+}
+
+func (c *ingressImpl) CallTCPOrFail(t test.Failer, options ingress.TCPCallOptions) ingress.TCPResponse {
+	t.Helper()
+	resp, err := c.CallTCP(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func (c *ingressImpl) CallTCPWithRetry(options ingress.TCPCallOptions,
+	retryOptions ...retry.Option) (ingress.TCPResponse, error) {
+	return callWithRetry(c.CallTCP, options, retryOptions...)
+}
+
+func (c *ingressImpl) CallTLS(options ingress.TCPCallOptions) (ingress.TCPResponse, error) {
+	if len(options.Address.IP) == 0 {
+		options.Address = c.HTTPSAddress()
+	}
+	return ingress.DialTLS(options)
+}
+
+func (c *ingressImpl) CallTLSOrFail(t test.Failer, options ingress.TCPCallOptions) ingress.TCPResponse {
+	t.Helper()
+	resp, err := c.CallTLS(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func (c *ingressImpl) CallTLSWithRetry(options ingress.TCPCallOptions,
+	retryOptions ...retry.Option) (ingress.TCPResponse, error) {
+	return callWithRetry(c.CallTLS, options, retryOptions...)
+}
+
+func callWithRetry(call func(ingress.TCPCallOptions) (ingress.TCPResponse, error), options ingress.TCPCallOptions,
+	retryOptions ...retry.Option) (ingress.TCPResponse, error) {
+	var resp ingress.TCPResponse
+	err := retry.UntilSuccess(func() error {
+		var err error
+		resp, err = call(options)
+		return err
+	}, retryOptions...)
+	return resp, err
+}
+
 func (c *ingressImpl) ProxyStats() (map[string]int, error) {
 	var stats map[string]int
 	statsJSON, err := c.adminRequest("stats?format=json")
@@ -310,6 +363,18 @@ func (c *ingressImpl) PodID(i int) (string, error) {
 	return pods.Items[i].Name, nil
 }
 
+func (c *ingressImpl) Logs() (string, error) {
+	pods, err := c.cluster.PodsForSelector(context.TODO(), c.namespace, "istio="+c.istioLabel)
+	if err != nil {
+		return "", fmt.Errorf("unable to get ingress gateway pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no ingress gateway pods found with label istio=%s", c.istioLabel)
+	}
+	pod := pods.Items[0]
+	return c.cluster.PodLogs(context.TODO(), pod.Name, pod.Namespace, proxyContainerName, false)
+}
+
 // adminRequest makes a call to admin port at ingress gateway proxy and returns error on request failure.
 func (c *ingressImpl) adminRequest(path string) (string, error) {
 	pods, err := c.env.KubeClusters[0].PodsForSelector(context.TODO(), c.namespace, "istio=ingressgateway")
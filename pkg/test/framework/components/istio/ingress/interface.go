@@ -99,6 +99,17 @@ type Instance interface {
 	CallEcho(options echo.CallOptions) (client.ParsedResponses, error)
 	CallEchoWithRetry(options echo.CallOptions, retryOptions ...retry.Option) (client.ParsedResponses, error)
 
+	// CallTCP makes a raw TCP call through ingress, to TCPAddress unless an address is given.
+	CallTCP(options TCPCallOptions) (TCPResponse, error)
+	CallTCPOrFail(t test.Failer, options TCPCallOptions) TCPResponse
+	CallTCPWithRetry(options TCPCallOptions, retryOptions ...retry.Option) (TCPResponse, error)
+
+	// CallTLS makes a raw TLS call with the given SNI through ingress, to HTTPSAddress unless an address is given.
+	// It is meant for gateways which pass TLS through based on SNI, or terminate it and forward plain TCP.
+	CallTLS(options TCPCallOptions) (TCPResponse, error)
+	CallTLSOrFail(t test.Failer, options TCPCallOptions) TCPResponse
+	CallTLSWithRetry(options TCPCallOptions, retryOptions ...retry.Option) (TCPResponse, error)
+
 	// ProxyStats returns proxy stats, or error if failure happens.
 	ProxyStats() (map[string]int, error)
 
@@ -108,6 +119,9 @@ type Instance interface {
 	// PodID returns the name of the ingress gateway pod of index i. Returns error if failed to get the pod
 	// or the index is out of boundary.
 	PodID(i int) (string, error)
+
+	// Logs returns the logs of the proxy container of the first ingress gateway pod.
+	Logs() (string, error)
 }
 
 // CallResponse is the result of a call made through Istio Instance.
@@ -0,0 +1,219 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+const (
+	// DefaultTCPPayload is written to the connection if no payload is given. The echo server only responds once it
+	// received data.
+	DefaultTCPPayload = "hello"
+
+	defaultTCPTimeout = 10 * time.Second
+)
+
+// TCPCallOptions defines options for a raw TCP or TLS call through the ingress gateway.
+type TCPCallOptions struct {
+	// Address is the ingress gateway IP and port to call to. Defaults to the TCP address for TCP calls, and the
+	// HTTPS address for TLS calls.
+	Address net.TCPAddr
+
+	// Payload is written to the connection once established. Defaults to DefaultTCPPayload.
+	Payload string
+
+	// ServerName is the SNI of the TLS handshake, which the gateway routes passthrough traffic on. Required for
+	// TLS calls.
+	ServerName string
+
+	// ALPN protocols offered in the TLS handshake.
+	ALPN []string
+
+	// CaCert is the PEM encoded root certificate which must have issued the served certificate. If not set, the
+	// served certificate is not verified during the handshake, but can be checked on the response.
+	CaCert string
+	// PrivateKey is the PEM encoded private key of the client certificate.
+	PrivateKey string
+	// Cert is the PEM encoded client certificate.
+	Cert string
+
+	// Timeout of the whole call. Defaults to 10s.
+	Timeout time.Duration
+}
+
+func (o *TCPCallOptions) fillDefaults() {
+	if o.Payload == "" {
+		o.Payload = DefaultTCPPayload
+	}
+	if o.Timeout == 0 {
+		o.Timeout = defaultTCPTimeout
+	}
+}
+
+func (o TCPCallOptions) tlsConfig() (*tls.Config, error) {
+	if o.ServerName == "" {
+		return nil, errors.New("server name is required for TLS calls")
+	}
+	cfg := &tls.Config{
+		ServerName: o.ServerName,
+		NextProtos: o.ALPN,
+	}
+	if o.CaCert != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(o.CaCert)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+	} else {
+		// nolint: gosec
+		// The served certificate is returned for the caller to check.
+		cfg.InsecureSkipVerify = true
+	}
+	if o.Cert != "" || o.PrivateKey != "" {
+		cert, err := tls.X509KeyPair([]byte(o.Cert), []byte(o.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// TCPResponse is the result of a raw TCP or TLS call.
+type TCPResponse struct {
+	// Body is everything the upstream wrote to the connection.
+	Body string
+	// Echo is the body parsed as the response of an echo server.
+	Echo *client.ParsedResponse
+	// PeerCertificates served in the TLS handshake, leaf first. Empty for TCP calls.
+	PeerCertificates []*x509.Certificate
+	// NegotiatedProtocol is the ALPN protocol negotiated in the TLS handshake.
+	NegotiatedProtocol string
+}
+
+// CheckUpstream checks that the call reached an echo server of the given version. An empty version matches any
+// version.
+func (r TCPResponse) CheckUpstream(version string) error {
+	if !r.Echo.IsOK() {
+		return fmt.Errorf("the call did not reach an echo server, got %q", r.Body)
+	}
+	if version != "" && r.Echo.Version != version {
+		return fmt.Errorf("expected to reach version %s, got %s", version, r.Echo.Version)
+	}
+	return nil
+}
+
+// CheckServedCert checks that the certificate served in the TLS handshake is valid for dnsName and, if rootPEM is
+// set, was issued by it. This is the certificate of the gateway for TLS terminated at the gateway, or of the
+// upstream for passthrough.
+func (r TCPResponse) CheckServedCert(dnsName string, rootPEM string) error {
+	if len(r.PeerCertificates) == 0 {
+		return errors.New("no certificate was served")
+	}
+	leaf := r.PeerCertificates[0]
+	if err := leaf.VerifyHostname(dnsName); err != nil {
+		return err
+	}
+	if rootPEM == "" {
+		return nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(rootPEM)) {
+		return errors.New("failed to parse root certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range r.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		return fmt.Errorf("served certificate %s is not issued by the expected root: %v", leaf.Subject, err)
+	}
+	return nil
+}
+
+// DialTCP writes the payload to a plain TCP connection to the address and reads the response until the upstream
+// closes the connection.
+func DialTCP(options TCPCallOptions) (TCPResponse, error) {
+	options.fillDefaults()
+	conn, err := net.DialTimeout("tcp", options.Address.String(), options.Timeout)
+	if err != nil {
+		return TCPResponse{}, err
+	}
+	return roundTrip(conn.(*net.TCPConn), options)
+}
+
+// DialTLS does a TLS handshake, with the SNI set to the server name, over a TCP connection to the address, then
+// writes the payload and reads the response until the upstream closes the connection.
+func DialTLS(options TCPCallOptions) (TCPResponse, error) {
+	options.fillDefaults()
+	cfg, err := options.tlsConfig()
+	if err != nil {
+		return TCPResponse{}, err
+	}
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", options.Address.String(), cfg)
+	if err != nil {
+		return TCPResponse{}, err
+	}
+	resp, err := roundTrip(conn, options)
+	if err != nil {
+		return TCPResponse{}, err
+	}
+	state := conn.ConnectionState()
+	resp.PeerCertificates = state.PeerCertificates
+	resp.NegotiatedProtocol = state.NegotiatedProtocol
+	return resp, nil
+}
+
+type halfCloser interface {
+	net.Conn
+	CloseWrite() error
+}
+
+func roundTrip(conn halfCloser, options TCPCallOptions) (TCPResponse, error) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	if err := conn.SetDeadline(time.Now().Add(options.Timeout)); err != nil {
+		return TCPResponse{}, err
+	}
+	if _, err := conn.Write([]byte(options.Payload)); err != nil {
+		return TCPResponse{}, err
+	}
+	// Let the upstream know the request is complete, so that it closes the connection once it responded.
+	if err := conn.CloseWrite(); err != nil {
+		return TCPResponse{}, err
+	}
+	body, err := ioutil.ReadAll(conn)
+	var netErr net.Error
+	if err != nil && !(errors.As(err, &netErr) && netErr.Timeout() && len(body) > 0) {
+		return TCPResponse{}, err
+	}
+	if len(body) == 0 {
+		return TCPResponse{}, errors.New("connection closed without a response")
+	}
+	return TCPResponse{
+		Body: string(body),
+		Echo: client.ParseResponse(string(body)),
+	}, nil
+}
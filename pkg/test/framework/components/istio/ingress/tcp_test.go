@@ -0,0 +1,110 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingress
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveEcho responds like the TCP port of an echo server: the response fields, followed by the request.
+func serveEcho(t *testing.T, l net.Listener) {
+	t.Helper()
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				in, _ := ioutil.ReadAll(conn)
+				_, _ = conn.Write(append([]byte("StatusCode=200\nServiceVersion=v1\n"), in...))
+			}()
+		}
+	}()
+}
+
+func TestDialTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveEcho(t, l)
+
+	resp, err := DialTCP(TCPCallOptions{Address: *l.Addr().(*net.TCPAddr), Payload: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != "StatusCode=200\nServiceVersion=v1\nping" {
+		t.Fatalf("unexpected body %q", resp.Body)
+	}
+	if err := resp.CheckUpstream("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckUpstream("v2"); err == nil {
+		t.Fatal("expected version mismatch")
+	}
+	if err := resp.CheckServedCert("example.com", ""); err == nil {
+		t.Fatal("expected no served certificate")
+	}
+}
+
+func TestDialTLS(t *testing.T) {
+	// httptest provides a certificate for example.com.
+	srv := httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	defer srv.Close()
+	root := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveEcho(t, l)
+	addr := *l.Addr().(*net.TCPAddr)
+
+	if _, err := DialTLS(TCPCallOptions{Address: addr}); err == nil {
+		t.Fatal("expected the server name to be required")
+	}
+	resp, err := DialTLS(TCPCallOptions{Address: addr, ServerName: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckUpstream(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckServedCert("example.com", root); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.CheckServedCert("other.com", root); err == nil {
+		t.Fatal("expected a host name mismatch")
+	}
+
+	// With a CA, the served certificate is verified during the handshake.
+	if _, err := DialTLS(TCPCallOptions{Address: addr, ServerName: "example.com", CaCert: root}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DialTLS(TCPCallOptions{Address: addr, ServerName: "other.com", CaCert: root}); err == nil {
+		t.Fatal("expected the handshake to fail")
+	}
+}
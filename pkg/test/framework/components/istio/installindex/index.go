@@ -0,0 +1,188 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package installindex maintains the index of the Istio install manifests each test run saved for cleanup, in the
+// work directory shared by all runs. It lets a later run, or the janitor, delete the resources of a run that was
+// killed before it could clean up.
+package installindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	// File is the name of the index file in the work directory.
+	File = "istio-install-index.json"
+
+	// lockFile serializes the updates of the index across the runs sharing the work directory.
+	lockFile = File + ".lock"
+)
+
+// Record is the entry of the index for one run.
+type Record struct {
+	RunID string `json:"runId"`
+	Host  string `json:"host"`
+	PID   int    `json:"pid"`
+	// StartTime is the start time of the process, as reported by /proc. Together with the PID, it identifies the
+	// process even after the PID is reused. It is empty where /proc is not available.
+	StartTime string `json:"startTime,omitempty"`
+	// NoCleanup is set for the runs that keep their resources on purpose. They are only deleted by the janitor.
+	NoCleanup bool `json:"noCleanup,omitempty"`
+	// SystemNamespace is the namespace Istio was installed to.
+	SystemNamespace string `json:"systemNamespace"`
+	// Manifests holds the manifest files saved for each cluster, keyed by API server address, in the order
+	// they were applied.
+	Manifests map[string][]string `json:"manifests"`
+}
+
+// NewRecord returns a record for the run of the current process.
+func NewRecord(runID, systemNamespace string, noCleanup bool) *Record {
+	host, _ := os.Hostname()
+	startTime, _ := processStartTime(os.Getpid())
+	return &Record{
+		RunID:           runID,
+		Host:            host,
+		PID:             os.Getpid(),
+		StartTime:       startTime,
+		NoCleanup:       noCleanup,
+		SystemNamespace: systemNamespace,
+		Manifests:       map[string][]string{},
+	}
+}
+
+// Local returns true if the run that created the record ran on this host.
+func (r *Record) Local() bool {
+	host, err := os.Hostname()
+	return err == nil && host == r.Host
+}
+
+// Dead returns true if the run that created the record is known to no longer be running. Runs on other hosts are
+// never known to be dead.
+func (r *Record) Dead() bool {
+	if !r.Local() {
+		return false
+	}
+	if r.StartTime != "" {
+		startTime, err := processStartTime(r.PID)
+		if err == nil {
+			// A different start time means the PID was reused by another process.
+			return startTime != r.StartTime
+		}
+		if os.IsNotExist(err) {
+			return true
+		}
+	}
+	p, err := os.FindProcess(r.PID)
+	if err != nil {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err != nil && !errors.Is(err, syscall.EPERM)
+}
+
+// processStartTime returns the start time of the given process, in clock ticks since boot.
+func processStartTime(pid int) (string, error) {
+	b, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	// The command name is in parentheses and may contain spaces, so parse the fields after it. The start time is
+	// the 22nd field of the file, and the 20th after the command name.
+	stat := string(b)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("unexpected format of /proc/%d/stat: %q", pid, stat)
+	}
+	return fields[19], nil
+}
+
+// Read returns the index in dir, keyed by run ID.
+func Read(dir string) (map[string]*Record, error) {
+	index := map[string]*Record{}
+	b, err := ioutil.ReadFile(filepath.Join(dir, File))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// Update applies fn to the index in dir, and persists the result. The index is locked for the duration of the
+// update, so that the runs sharing the work directory do not lose each other's records.
+func Update(dir string, fn func(index map[string]*Record)) error {
+	lock, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Close() }()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer func() { _ = syscall.Flock(int(lock.Fd()), syscall.LOCK_UN) }()
+
+	index, err := Read(dir)
+	if err != nil {
+		return err
+	}
+	fn(index)
+
+	out, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a run killed while writing does not corrupt the index.
+	tmp, err := ioutil.TempFile(dir, File)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(out); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, File))
+}
+
+// Forget removes the manifests of the given run in the clusters with the given API server addresses from the index,
+// and the record of the run once it has no manifests left.
+func Forget(dir, runID string, servers ...string) error {
+	return Update(dir, func(index map[string]*Record) {
+		r := index[runID]
+		if r == nil {
+			return
+		}
+		for _, server := range servers {
+			delete(r.Manifests, server)
+		}
+		if len(r.Manifests) == 0 {
+			delete(index, runID)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installindex
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "installindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	for _, run := range []string{"a", "b"} {
+		run := run
+		if err := Update(dir, func(index map[string]*Record) {
+			r := NewRecord(run, "istio-system", run == "b")
+			r.Manifests["https://c0"] = []string{run + "-0.yaml", run + "-1.yaml"}
+			r.Manifests["https://c1"] = []string{run + "-0.yaml"}
+			index[run] = r
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Forget(dir, "a", "https://c0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Forget(dir, "b", "https://c0", "https://c1"); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 1 || index["a"] == nil {
+		t.Fatalf("expected only the record of run a, got %v", index)
+	}
+	expected := map[string][]string{"https://c1": {"a-0.yaml"}}
+	if !reflect.DeepEqual(index["a"].Manifests, expected) {
+		t.Fatalf("expected manifests %v, got %v", expected, index["a"].Manifests)
+	}
+}
+
+func TestDead(t *testing.T) {
+	self := NewRecord("self", "istio-system", false)
+	if self.Dead() {
+		t.Fatal("expected the current process to be running")
+	}
+
+	remote := NewRecord("remote", "istio-system", false)
+	remote.Host = "not-" + remote.Host
+	remote.PID = -1
+	if remote.Dead() {
+		t.Fatal("expected a run on another host to never be dead")
+	}
+
+	if self.StartTime == "" {
+		t.Skip("process start times are not available")
+	}
+	reused := NewRecord("reused", "istio-system", false)
+	reused.StartTime = "0"
+	if !reused.Dead() {
+		t.Fatal("expected a run whose PID was reused to be dead")
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/httpproxy"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/scopes"
@@ -41,6 +42,23 @@ type Instance interface {
 	RemoteDiscoveryAddressFor(cluster resource.Cluster) (net.TCPAddr, error)
 
 	Settings() Config
+
+	// PluginCA returns the CA certificates generated for the deployment, or nil if Istio was deployed with its
+	// self-signed root CA.
+	PluginCA() *PluginCA
+
+	// RotateCA replaces the plugin CA certificates in every cluster and restarts istiod to pick them up, returning
+	// the new certificates. Workloads keep their certificates until they are re-issued, e.g. when the pods are
+	// restarted. It fails if Istio was not deployed with a plugin CA.
+	RotateCA(rotation CARotation) (*PluginCA, error)
+
+	// SetTrustDomainAliases replaces the trust domain aliases in the mesh config of every control plane, which
+	// istiod pushes to the proxies without a restart. This is used to migrate workloads between trust domains.
+	SetTrustDomainAliases(aliases ...string) error
+
+	// EgressProxyFor returns the forward proxy the egress of the given cluster transits, or nil if Istio was not
+	// deployed with an egress proxy.
+	EgressProxyFor(cluster resource.Cluster) httpproxy.Instance
 }
 
 // SetupConfigFn is a setup function that specifies the overrides of the configuration to deploy Istio.
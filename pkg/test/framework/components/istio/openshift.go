@@ -0,0 +1,86 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// prepareOpenShift allows the Istio namespaces of all clusters to run the control plane and gateways on OpenShift.
+func prepareOpenShift(env *kube.Environment, cfg Config) error {
+	if !env.IsOpenShift() {
+		return nil
+	}
+	for _, cluster := range env.KubeClusters {
+		for _, ns := range istioNamespaces(cfg) {
+			if err := env.PrepareOpenShiftNamespace(cluster, ns, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// openShiftIOPFile writes an IstioOperator overlay which installs Istio CNI the way OpenShift requires it, and
+// returns its path. It returns an empty path if the cluster is not an OpenShift cluster.
+func (i *operatorComponent) openShiftIOPFile(cluster resource.Cluster) (string, error) {
+	if !i.environment.IsOpenShift() {
+		return "", nil
+	}
+	overlay := map[string]interface{}{
+		"apiVersion": "install.istio.io/v1alpha1",
+		"kind":       "IstioOperator",
+		"spec": map[string]interface{}{
+			"components": map[string]interface{}{
+				"cni": map[string]interface{}{
+					"enabled": true,
+				},
+			},
+			"values": map[string]interface{}{
+				"cni": map[string]interface{}{
+					// Multus owns the CNI configuration, and only invokes the plugin for the pods which
+					// reference its network.
+					"cniBinDir":         "/var/lib/cni/bin",
+					"cniConfDir":        "/etc/cni/multus/net.d",
+					"cniConfFileName":   "istio-cni.conf",
+					"chained":           false,
+					"excludeNamespaces": []string{"openshift-*", "kube-system"},
+				},
+				"sidecarInjectorWebhook": map[string]interface{}{
+					"injectedAnnotations": map[string]interface{}{
+						"k8s.v1.cni.cncf.io/networks": kube.OpenShiftCNINetwork,
+					},
+				},
+			},
+		},
+	}
+	out, err := yaml.Marshal(overlay)
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(i.workDir, fmt.Sprintf("%s-openshift.yaml", cluster.Name()))
+	if err := ioutil.WriteFile(file, out, os.ModePerm); err != nil {
+		return "", err
+	}
+	return file, nil
+}
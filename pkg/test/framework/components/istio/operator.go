@@ -40,6 +40,7 @@ import (
 	"istio.io/istio/pkg/test/cert/ca"
 	testenv "istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/httpproxy"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/components/istioctl"
 	"istio.io/istio/pkg/test/framework/image"
@@ -76,6 +77,12 @@ type operatorComponent struct {
 	installManifest map[string][]string
 	ingress         map[resource.ClusterIndex]map[string]ingress.Instance
 	workDir         string
+	pluginCA        *PluginCA
+	caRotations     int
+	egressProxies   map[resource.ClusterIndex]httpproxy.Instance
+	// createdNamespaces are the namespaces created for the pull secrets, other than the system namespace, keyed by
+	// cluster name. They are deleted on cleanup.
+	createdNamespaces map[string][]string
 }
 
 var _ io.Closer = &operatorComponent{}
@@ -91,6 +98,10 @@ func (i *operatorComponent) Settings() Config {
 	return i.settings
 }
 
+func (i *operatorComponent) PluginCA() *PluginCA {
+	return i.pluginCA
+}
+
 // When we cleanup, we should not delete CRDs. This will filter out all the crds
 func removeCRDs(istioYaml string) string {
 	allParts := yml.SplitString(istioYaml)
@@ -153,6 +164,13 @@ func (i *operatorComponent) Close() (err error) {
 				}
 			}
 
+			for _, ns := range i.createdNamespaces[cluster.Name()] {
+				if e := cluster.CoreV1().Namespaces().Delete(context.TODO(), ns,
+					kube2.DeleteOptionsForeground()); e != nil && !errors.IsNotFound(e) {
+					err = multierror.Append(err, e)
+				}
+			}
+
 			// Clean up dynamic leader election locks. This allows new test suites to become the leader without waiting 30s
 			for _, cm := range leaderElectionConfigMaps {
 				if e := cluster.CoreV1().ConfigMaps(i.settings.SystemNamespace).Delete(context.TODO(), cm,
@@ -168,8 +186,17 @@ func (i *operatorComponent) Close() (err error) {
 				if e := kube2.WaitForNamespaceDeletion(cluster, i.settings.SystemNamespace, retry.Timeout(time.Minute)); e != nil {
 					err = multierror.Append(err, e)
 				}
+			} else if i.pluginCA != nil {
+				// The system namespace is kept, so remove the plugin CA to not affect later deployments.
+				if e := cluster.CoreV1().Secrets(i.settings.SystemNamespace).Delete(context.TODO(), "cacerts",
+					kubeApiMeta.DeleteOptions{}); e != nil {
+					err = multierror.Append(err, e)
+				}
 			}
 		}
+		if err == nil {
+			i.forgetInstall()
+		}
 	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
@@ -192,6 +219,15 @@ func (i *operatorComponent) Dump(ctx resource.Context) {
 		return
 	}
 	kube2.DumpPods(ctx, d, ns)
+	// Gateways may be deployed outside of the system namespace.
+	for _, gwNs := range []string{i.settings.IngressNamespace, i.settings.EgressNamespace} {
+		if gwNs != ns {
+			kube2.DumpPods(ctx, d, gwNs)
+		}
+	}
+	for _, cluster := range ctx.Clusters() {
+		kube2.DumpProxyStatus(ctx, cluster, d)
+	}
 }
 
 // saveManifestForCleanup will ensure we delete the given yaml from the given cluster during cleanup.
@@ -199,6 +235,16 @@ func (i *operatorComponent) saveManifestForCleanup(clusterName string, yaml stri
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.installManifest[clusterName] = append(i.installManifest[clusterName], yaml)
+	if i.workDir != "" {
+		// Keep a copy on disk so that the manifests are available when triaging failures.
+		fname := filepath.Join(i.workDir, fmt.Sprintf("%s-manifest-%d.yaml", clusterName, len(i.installManifest[clusterName])))
+		if err := ioutil.WriteFile(fname, []byte(yaml), 0644); err != nil {
+			scopes.Framework.Warnf("failed writing install manifest to %s: %v", fname, err)
+		} else {
+			// Index the manifest, so that a later run can clean up if this one is killed.
+			i.recordManifest(clusterName, fname)
+		}
+	}
 }
 
 func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance, error) {
@@ -207,11 +253,13 @@ func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance,
 	scopes.Framework.Infof("================================")
 
 	i := &operatorComponent{
-		environment:     env,
-		settings:        cfg,
-		ctx:             ctx,
-		installManifest: map[string][]string{},
-		ingress:         map[resource.ClusterIndex]map[string]ingress.Instance{},
+		environment:       env,
+		settings:          cfg,
+		ctx:               ctx,
+		installManifest:   map[string][]string{},
+		ingress:           map[resource.ClusterIndex]map[string]ingress.Instance{},
+		egressProxies:     map[resource.ClusterIndex]httpproxy.Instance{},
+		createdNamespaces: map[string][]string{},
 	}
 	i.id = ctx.TrackResource(i)
 
@@ -227,15 +275,34 @@ func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance,
 	}
 	i.workDir = workDir
 
+	// Clean up after the runs killed before they could, so that their resources do not conflict with ours.
+	if err := reclaimDeadRuns(ctx, env); err != nil {
+		scopes.Framework.Warnf("failed reclaiming the installs of dead runs: %v", err)
+	}
+
 	//generate istioctl config files for config, control plane(primary) and remote clusters
 	istioctlConfigFiles, err := createIstioctlConfigFile(workDir, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := prepareOpenShift(env, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := i.createPullSecrets(); err != nil {
+		return nil, err
+	}
+
+	if cfg.EgressProxy {
+		if err := i.deployEgressProxies(); err != nil {
+			return nil, err
+		}
+	}
+
 	// For multicluster, create and push the CA certs to all clusters to establish a shared root of trust.
-	if env.IsMulticluster() {
-		if err := deployCACerts(workDir, env, cfg); err != nil {
+	if env.IsMulticluster() || cfg.PluginCA {
+		if i.pluginCA, err = deployCACerts(workDir, env, cfg); err != nil {
 			return nil, err
 		}
 	}
@@ -292,7 +359,7 @@ func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance,
 
 	if env.IsMultinetwork() {
 		// enable cross network traffic
-		for _, cluster := range env.KubeClusters {
+		for _, cluster := range env.WorkloadClusters() {
 			if err := i.applyCrossNetworkGateway(cluster); err != nil {
 				return nil, err
 			}
@@ -404,7 +471,8 @@ spec:
 }
 
 // installRemoteConfigCluster installs istio to a cluster that runs workloads and provides Istio configuration.
-// The installed components include gateway deployments, CRDs, Roles, etc. but not istiod.
+// The installed components include gateway deployments, CRDs, Roles, etc. but not istiod. Config-only clusters
+// get no gateways.
 func installRemoteConfigCluster(i *operatorComponent, cfg Config, cluster resource.Cluster, configIopFile string) error {
 	scopes.Framework.Infof("setting up %s as config cluster", cluster.Name())
 	// TODO move values out of external istiod test main into the ConfigClusterValues defaults
@@ -413,6 +481,12 @@ func installRemoteConfigCluster(i *operatorComponent, cfg Config, cluster resour
 	if err != nil {
 		return err
 	}
+	if i.environment.IsConfigOnlyCluster(cluster) {
+		// No workloads run in the cluster, so there is no traffic for the gateways.
+		installSettings = append(installSettings,
+			"--set", "components.ingressGateways[0].enabled=false",
+			"--set", "components.egressGateways[0].enabled=false")
+	}
 	// Create an istioctl to configure this cluster.
 	istioCtl, err := istioctl.New(i.ctx, istioctl.Config{
 		Cluster: cluster,
@@ -564,6 +638,9 @@ func (i *operatorComponent) generateCommonInstallSettings(cfg Config, cluster re
 		"--set", "values.global.imagePullPolicy=" + s.PullPolicy,
 		"--manifests", filepath.Join(testenv.IstioSrc, "manifests"),
 	}
+	if name := s.PullSecretName(); name != "" {
+		installSettings = append(installSettings, "--set", fmt.Sprintf("values.%s[0]=%s", image.ImagePullSecretsValuesKey, name))
+	}
 
 	if i.environment.IsMultinetwork() && cluster.NetworkName() != "" {
 		installSettings = append(installSettings,
@@ -571,6 +648,34 @@ func (i *operatorComponent) generateCommonInstallSettings(cfg Config, cluster re
 			"--set", "values.global.network="+cluster.NetworkName())
 	}
 
+	if cfg.PilotCertProvider != "" {
+		installSettings = append(installSettings, "--set", "values.global.pilotCertProvider="+cfg.PilotCertProvider)
+	}
+
+	tdFile, err := i.trustDomainIOPFile(cfg, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if tdFile != "" {
+		installSettings = append(installSettings, "-f", tdFile)
+	}
+
+	osFile, err := i.openShiftIOPFile(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if osFile != "" {
+		installSettings = append(installSettings, "-f", osFile)
+	}
+
+	proxyFile, err := i.egressProxyIOPFile(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if proxyFile != "" {
+		installSettings = append(installSettings, "-f", proxyFile)
+	}
+
 	// Include all user-specified values.
 	for k, v := range cfg.Values {
 		installSettings = append(installSettings, "--set", fmt.Sprintf("values.%s=%s", k, v))
@@ -692,41 +797,46 @@ func createRemoteSecret(ctx resource.Context, cluster resource.Cluster, cfg Conf
 	return out, nil
 }
 
-func deployCACerts(workDir string, env *kube.Environment, cfg Config) error {
+func deployCACerts(workDir string, env *kube.Environment, cfg Config) (*PluginCA, error) {
 	certsDir := filepath.Join(workDir, "cacerts")
 	if err := os.Mkdir(certsDir, 0700); err != nil {
-		return err
+		return nil, err
 	}
 
 	root, err := ca.NewRoot(certsDir)
 	if err != nil {
-		return fmt.Errorf("failed creating the root CA: %v", err)
+		return nil, fmt.Errorf("failed creating the root CA: %v", err)
+	}
+	out := &PluginCA{
+		Root:          root,
+		Intermediates: map[string]ca.Intermediate{},
 	}
 
 	for _, cluster := range env.KubeClusters {
 		// Create a subdir for the cluster certs.
 		clusterDir := filepath.Join(certsDir, cluster.Name())
 		if err := os.Mkdir(clusterDir, 0700); err != nil {
-			return err
+			return nil, err
 		}
 
 		// Create the new extensions config for the CA
 		caConfig, err := ca.NewIstioConfig(cfg.SystemNamespace)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Create the certs for the cluster.
 		clusterCA, err := ca.NewIntermediate(clusterDir, caConfig, root)
 		if err != nil {
-			return fmt.Errorf("failed creating intermediate CA for cluster %s: %v", cluster.Name(), err)
+			return nil, fmt.Errorf("failed creating intermediate CA for cluster %s: %v", cluster.Name(), err)
 		}
+		out.Intermediates[cluster.Name()] = clusterCA
 
 		// Create the CA secret for this cluster. Istio will use these certs for its CA rather
 		// than its autogenerated self-signed root.
 		secret, err := clusterCA.NewIstioCASecret()
 		if err != nil {
-			return fmt.Errorf("failed creating intermediate CA secret for cluster %s: %v", cluster.Name(), err)
+			return nil, fmt.Errorf("failed creating intermediate CA secret for cluster %s: %v", cluster.Name(), err)
 		}
 
 		// Create the system namespace.
@@ -746,6 +856,45 @@ func deployCACerts(workDir string, env *kube.Environment, cfg Config) error {
 				"multiple control planes. Error: %v", cluster.Name(), err)
 		}
 	}
+	return out, nil
+}
+
+// istioNamespaces returns the deduplicated namespaces of the Istio components.
+func istioNamespaces(cfg Config) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, ns := range []string{cfg.SystemNamespace, cfg.IstioNamespace, cfg.ConfigNamespace, cfg.TelemetryNamespace,
+		cfg.PolicyNamespace, cfg.IngressNamespace, cfg.EgressNamespace} {
+		if ns != "" && !seen[ns] {
+			seen[ns] = true
+			out = append(out, ns)
+		}
+	}
+	return out
+}
+
+// createPullSecrets creates the image pull secret in the Istio namespaces of all clusters, which the gateways and
+// istiod reference through the global.imagePullSecrets value.
+func (i *operatorComponent) createPullSecrets() error {
+	s, err := image.SettingsFromCommandLine()
+	if err != nil || s.PullSecret == "" {
+		return err
+	}
+	for _, cluster := range i.environment.KubeClusters {
+		for _, ns := range istioNamespaces(i.settings) {
+			_, err := cluster.CoreV1().Namespaces().Create(context.TODO(), &kubeApiCore.Namespace{
+				ObjectMeta: kubeApiMeta.ObjectMeta{Name: ns},
+			}, kubeApiMeta.CreateOptions{})
+			if err == nil && ns != i.settings.SystemNamespace {
+				i.createdNamespaces[cluster.Name()] = append(i.createdNamespaces[cluster.Name()], ns)
+			} else if err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed creating namespace %s in %s: %v", ns, cluster.Name(), err)
+			}
+			if err := s.CreatePullSecret(cluster, ns); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
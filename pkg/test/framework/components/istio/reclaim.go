@@ -0,0 +1,140 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/istio/installindex"
+	"istio.io/istio/pkg/test/framework/resource"
+	kube2 "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// recordManifest adds the manifest file saved for the given cluster to the record of this run.
+func (i *operatorComponent) recordManifest(clusterName, file string) {
+	var server string
+	for _, c := range i.environment.KubeClusters {
+		if c.Name() == clusterName {
+			server = c.RESTConfig().Host
+		}
+	}
+	if server == "" {
+		return
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	settings := i.ctx.Settings()
+	err := installindex.Update(settings.BaseDir, func(index map[string]*installindex.Record) {
+		r := index[settings.RunID.String()]
+		if r == nil {
+			r = installindex.NewRecord(settings.RunID.String(), i.settings.SystemNamespace, settings.NoCleanup)
+			index[r.RunID] = r
+		}
+		r.Manifests[server] = append(r.Manifests[server], file)
+	})
+	if err != nil {
+		scopes.Framework.Warnf("failed recording install manifest %s: %v", file, err)
+	}
+}
+
+// forgetInstall removes the record of this run, once its install has been cleaned up.
+func (i *operatorComponent) forgetInstall() {
+	settings := i.ctx.Settings()
+	if err := installindex.Update(settings.BaseDir, func(index map[string]*installindex.Record) {
+		delete(index, settings.RunID.String())
+	}); err != nil {
+		scopes.Framework.Warnf("failed removing the install record of run %s: %v", settings.RunID, err)
+	}
+}
+
+// reclaimDeadRuns deletes the resources installed in our clusters by earlier runs that were killed before they
+// could clean up, so that they do not conflict with this install. The runs that kept their resources on purpose
+// are left to the janitor. Records are kept until all of their manifests are deleted, clusters we do not have
+// access to included.
+func reclaimDeadRuns(ctx resource.Context, env *kube.Environment) error {
+	settings := ctx.Settings()
+	index, err := installindex.Read(settings.BaseDir)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for runID, r := range index {
+		if runID == settings.RunID.String() || r.NoCleanup || !r.Dead() {
+			continue
+		}
+		var reclaimed []string
+		for _, cluster := range env.KubeClusters {
+			server := cluster.RESTConfig().Host
+			manifests, ok := r.Manifests[server]
+			if !ok {
+				continue
+			}
+			scopes.Framework.Infof("Reclaiming the install of dead run %s from cluster %s", runID, cluster.Name())
+			if err := reclaimManifests(ctx, cluster, r.SystemNamespace, manifests); err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			reclaimed = append(reclaimed, server)
+		}
+		if err := installindex.Forget(settings.BaseDir, runID, reclaimed...); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// reclaimManifests deletes the resources of the given manifests, in the reverse order they were applied. If the
+// system namespace is deleted along with them, it waits for the deletion to finish, so that we do not install into
+// a terminating namespace.
+func reclaimManifests(ctx resource.Context, cluster resource.Cluster, systemNamespace string, manifests []string) error {
+	for idx := len(manifests) - 1; idx >= 0; idx-- {
+		b, err := ioutil.ReadFile(manifests[idx])
+		if os.IsNotExist(err) {
+			scopes.Framework.Warnf("install manifest %s is gone, its resources cannot be reclaimed", manifests[idx])
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Config(cluster).DeleteYAML("", removeCRDs(string(b))); err != nil {
+			return err
+		}
+	}
+
+	ns, err := cluster.CoreV1().Namespaces().Get(context.TODO(), systemNamespace, kubeApiMeta.GetOptions{})
+	if kubeErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ns.DeletionTimestamp == nil {
+		return nil
+	}
+	return kube2.WaitForNamespaceDeletion(cluster, systemNamespace, retry.Timeout(time.Minute))
+}
@@ -0,0 +1,155 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// DefaultTrustDomain is the trust domain of clusters which are not listed in Config.TrustDomains.
+	DefaultTrustDomain = "cluster.local"
+
+	meshConfigMapName = "istio"
+)
+
+// TrustDomainFor returns the trust domain Istio is installed with in the given cluster.
+func (c *Config) TrustDomainFor(cluster resource.Cluster) string {
+	if td, ok := c.TrustDomains[cluster.Name()]; ok && td != "" {
+		return td
+	}
+	return DefaultTrustDomain
+}
+
+// TrustDomainAliasesFor returns the trust domain aliases Istio is installed with in the given cluster: the
+// TrustDomainAliases, and the trust domains of the other clusters listed in TrustDomains.
+func (c *Config) TrustDomainAliasesFor(cluster resource.Cluster) []string {
+	own := c.TrustDomainFor(cluster)
+	seen := map[string]struct{}{own: {}}
+	var out []string
+	add := func(td string) {
+		if _, ok := seen[td]; ok || td == "" {
+			return
+		}
+		seen[td] = struct{}{}
+		out = append(out, td)
+	}
+	for _, td := range c.TrustDomainAliases {
+		add(td)
+	}
+	if len(c.TrustDomains) > 0 {
+		federated := make([]string, 0, len(c.TrustDomains)+1)
+		for _, td := range c.TrustDomains {
+			federated = append(federated, td)
+		}
+		// Clusters which are not listed use the default trust domain.
+		federated = append(federated, DefaultTrustDomain)
+		sort.Strings(federated)
+		for _, td := range federated {
+			add(td)
+		}
+	}
+	return out
+}
+
+// SPIFFEIDFor returns the SPIFFE ID of a workload in the given cluster.
+func (c *Config) SPIFFEIDFor(cluster resource.Cluster, namespace, serviceAccount string) string {
+	return spiffe.Identity{
+		TrustDomain:    c.TrustDomainFor(cluster),
+		Namespace:      namespace,
+		ServiceAccount: serviceAccount,
+	}.String()
+}
+
+// PrincipalFor returns the principal of a workload in the given cluster, as matched by AuthorizationPolicy.
+func (c *Config) PrincipalFor(cluster resource.Cluster, namespace, serviceAccount string) string {
+	return fmt.Sprintf("%s/ns/%s/sa/%s", c.TrustDomainFor(cluster), namespace, serviceAccount)
+}
+
+// trustDomainIOPFile writes an IstioOperator overlay which sets the trust domain and aliases of the given cluster,
+// and returns its path. It returns an empty path if the cluster uses the defaults.
+func (i *operatorComponent) trustDomainIOPFile(cfg Config, cluster resource.Cluster) (string, error) {
+	if len(cfg.TrustDomains) == 0 && len(cfg.TrustDomainAliases) == 0 {
+		return "", nil
+	}
+	td := cfg.TrustDomainFor(cluster)
+	aliases := cfg.TrustDomainAliasesFor(cluster)
+	overlay := map[string]interface{}{
+		"apiVersion": "install.istio.io/v1alpha1",
+		"kind":       "IstioOperator",
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"global": map[string]interface{}{
+					"trustDomain": td,
+				},
+				"meshConfig": map[string]interface{}{
+					"trustDomain":        td,
+					"trustDomainAliases": aliases,
+				},
+			},
+		},
+	}
+	out, err := yaml.Marshal(overlay)
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(i.workDir, fmt.Sprintf("%s-trust-domain.yaml", cluster.Name()))
+	if err := ioutil.WriteFile(file, out, os.ModePerm); err != nil {
+		return "", err
+	}
+	scopes.Framework.Infof("installing cluster %s with trust domain %s and aliases %v", cluster.Name(), td, aliases)
+	return file, nil
+}
+
+func (i *operatorComponent) SetTrustDomainAliases(aliases ...string) error {
+	for _, cluster := range i.environment.ControlPlaneClusters() {
+		cms := cluster.CoreV1().ConfigMaps(i.settings.SystemNamespace)
+		cm, err := cms.Get(context.TODO(), meshConfigMapName, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed reading the mesh config of %s: %v", cluster.Name(), err)
+		}
+		meshConfig := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(cm.Data["mesh"]), &meshConfig); err != nil {
+			return fmt.Errorf("failed parsing the mesh config of %s: %v", cluster.Name(), err)
+		}
+		if len(aliases) == 0 {
+			delete(meshConfig, "trustDomainAliases")
+		} else {
+			meshConfig["trustDomainAliases"] = aliases
+		}
+		out, err := yaml.Marshal(meshConfig)
+		if err != nil {
+			return err
+		}
+		cm.Data["mesh"] = string(out)
+		// istiod watches the mesh config, and pushes the change to the proxies.
+		if _, err := cms.Update(context.TODO(), cm, kubeApiMeta.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed updating the mesh config of %s: %v", cluster.Name(), err)
+		}
+	}
+	return nil
+}
@@ -154,11 +154,23 @@ func getRemoteServiceAddress(s *kube.Settings, cluster resource.Cluster, ns, lab
 		return nil, false, err
 	}
 
-	if len(svc.Status.LoadBalancer.Ingress) == 0 || svc.Status.LoadBalancer.Ingress[0].IP == "" {
+	if len(svc.Status.LoadBalancer.Ingress) == 0 {
 		return nil, false, fmt.Errorf("service %s is not available yet: %s/%s", svcName, svc.Namespace, svc.Name)
 	}
 
 	ip := svc.Status.LoadBalancer.Ingress[0].IP
+	if ip == "" && svc.Status.LoadBalancer.Ingress[0].Hostname != "" {
+		// Some cloud providers, such as AWS for OpenShift, expose the load balancer with a host name only.
+		hostname := svc.Status.LoadBalancer.Ingress[0].Hostname
+		ips, err := net.LookupIP(hostname)
+		if err != nil || len(ips) == 0 {
+			return nil, false, fmt.Errorf("service %s load balancer %s is not resolvable yet: %v", svcName, hostname, err)
+		}
+		ip = ips[0].String()
+	}
+	if ip == "" {
+		return nil, false, fmt.Errorf("service %s is not available yet: %s/%s", svcName, svc.Namespace, svc.Name)
+	}
 	return net.TCPAddr{IP: net.ParseIP(ip), Port: port}, true, nil
 }
 
@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kiali
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Instance represents a Kiali deployment on kube, backed by the test Prometheus.
+type Instance interface {
+	resource.Resource
+
+	// Status returns the Kiali status, including the versions of the components it detected.
+	Status() (Status, error)
+
+	// Graph returns the workload graph for the given namespaces, built from the traffic seen in the last duration.
+	Graph(namespaces []string, duration time.Duration) (Graph, error)
+	GraphOrFail(t test.Failer, namespaces []string, duration time.Duration) Graph
+
+	// WorkloadHealth returns the health of every workload in the namespace, keyed by workload name.
+	WorkloadHealth(namespace string, rateInterval time.Duration) (map[string]Health, error)
+	WorkloadHealthOrFail(t test.Failer, namespace string, rateInterval time.Duration) map[string]Health
+}
+
+// Config for the Kiali component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+}
+
+// New deploys Kiali and returns a handle to it. Kiali reads metrics from the Prometheus addon, which is
+// expected to be deployed in the same namespace (see prometheus.New).
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("kiali.NewOrFail: %v", err)
+	}
+	return i
+}
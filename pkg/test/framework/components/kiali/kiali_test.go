@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kiali
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const graphJSON = `{"elements":{"nodes":[
+{"data":{"id":"n1","nodeType":"workload","namespace":"ns","workload":"a-v1","app":"a","version":"v1"}},
+{"data":{"id":"n2","nodeType":"workload","namespace":"ns","workload":"b-v1","app":"b","version":"v1"}},
+{"data":{"id":"n3","nodeType":"service","namespace":"ns","service":"c"}}
+],"edges":[
+{"data":{"id":"e1","source":"n1","target":"n2","traffic":{"protocol":"http","rates":{"http":"1.00"}}}},
+{"data":{"id":"e2","source":"n2","target":"n3","traffic":{"protocol":"tcp","rates":{"tcp":"100.00"}}}}
+]}}`
+
+func TestGraph(t *testing.T) {
+	g, err := parseGraph([]byte(graphJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("unexpected graph: %+v", g)
+	}
+	if err := g.VerifyEdge("ns", "a-v1", "ns", "b-v1", "http"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.VerifyEdge("ns", "a-v1", "ns", "b-v1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.VerifyEdge("ns", "a-v1", "ns", "b-v1", "tcp"); err == nil {
+		t.Fatal("expected protocol mismatch")
+	}
+	if err := g.VerifyEdge("ns", "b-v1", "ns", "a-v1", ""); err == nil {
+		t.Fatal("expected reversed edge to be missing")
+	}
+	if err := g.VerifyEdge("ns", "a-v1", "other", "b-v1", ""); err == nil {
+		t.Fatal("expected unknown workload to fail")
+	}
+}
+
+func TestHealthy(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want bool
+	}{
+		{"no traffic", `{"workloadStatus":{"desiredReplicas":1,"availableReplicas":1},"requests":{"errorRatio":-1}}`, true},
+		{"unavailable", `{"workloadStatus":{"desiredReplicas":2,"availableReplicas":1},"requests":{"errorRatio":0}}`, false},
+		{"errors", `{"workloadStatus":{"desiredReplicas":1,"availableReplicas":1},"requests":{"errorRatio":0.1}}`, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Health
+			if err := json.Unmarshal([]byte(tt.json), &h); err != nil {
+				t.Fatal(err)
+			}
+			if got := h.Healthy(); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
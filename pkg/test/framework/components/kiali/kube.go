@@ -0,0 +1,206 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kiali
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	appName   = "kiali"
+	kialiPort = 20001
+	apiRoot   = "/kiali/api"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+	close     func()
+}
+
+func getKialiYaml() (string, error) {
+	yamlBytes, err := ioutil.ReadFile(filepath.Join(env.IstioSrc, "samples/addons/kiali.yaml"))
+	if err != nil {
+		return "", err
+	}
+	return string(yamlBytes), nil
+}
+
+func newKube(ctx resource.Context, cfgIn Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfgIn.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	cfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	yaml, err := getKialiYaml()
+	if err != nil {
+		return nil, err
+	}
+	// The addon includes both the MonitoringDashboard CRD and instances of it. Retry until the CRD
+	// is established and the instances can be created.
+	if err := retry.UntilSuccess(func() error {
+		return ctx.Config(c.cluster).ApplyYAML(cfg.TelemetryNamespace, yaml)
+	}, retry.Timeout(time.Minute), retry.Delay(time.Second)); err != nil {
+		return nil, err
+	}
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML(cfg.TelemetryNamespace, yaml)
+	}
+
+	fetchFn := testKube.NewSinglePodFetch(c.cluster, cfg.TelemetryNamespace, fmt.Sprintf("app=%s", appName))
+	pods, err := testKube.WaitUntilPodsAreReady(fetchFn)
+	if err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, kialiPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	scopes.Framework.Debugf("initialized kiali port forwarder: %v", forwarder.Address())
+
+	c.address = fmt.Sprintf("http://%s%s", forwarder.Address(), apiRoot)
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) get(path string, params url.Values) ([]byte, error) {
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	u := c.address + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	scopes.Framework.Debugf("querying kiali: %v", u)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kiali api %s returned non-ok status: %v: %s", path, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+func (c *kubeComponent) Status() (Status, error) {
+	body, err := c.get("/status", nil)
+	if err != nil {
+		return Status{}, err
+	}
+	var s Status
+	if err := json.Unmarshal(body, &s); err != nil {
+		return Status{}, fmt.Errorf("failed parsing kiali status: %v", err)
+	}
+	return s, nil
+}
+
+func (c *kubeComponent) Graph(namespaces []string, duration time.Duration) (Graph, error) {
+	params := url.Values{}
+	params.Set("namespaces", strings.Join(namespaces, ","))
+	params.Set("graphType", "workload")
+	params.Set("duration", fmt.Sprintf("%ds", int(duration.Seconds())))
+	body, err := c.get("/namespaces/graph", params)
+	if err != nil {
+		return Graph{}, err
+	}
+	return parseGraph(body)
+}
+
+func (c *kubeComponent) GraphOrFail(t test.Failer, namespaces []string, duration time.Duration) Graph {
+	t.Helper()
+	g, err := c.Graph(namespaces, duration)
+	if err != nil {
+		t.Fatalf("kiali.GraphOrFail: %v", err)
+	}
+	return g
+}
+
+func (c *kubeComponent) WorkloadHealth(namespace string, rateInterval time.Duration) (map[string]Health, error) {
+	params := url.Values{}
+	params.Set("type", "workload")
+	params.Set("rateInterval", fmt.Sprintf("%ds", int(rateInterval.Seconds())))
+	body, err := c.get(fmt.Sprintf("/namespaces/%s/health", namespace), params)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]Health{}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("failed parsing kiali health: %v", err)
+	}
+	return out, nil
+}
+
+func (c *kubeComponent) WorkloadHealthOrFail(t test.Failer, namespace string, rateInterval time.Duration) map[string]Health {
+	t.Helper()
+	h, err := c.WorkloadHealth(namespace, rateInterval)
+	if err != nil {
+		t.Fatalf("kiali.WorkloadHealthOrFail: %v", err)
+	}
+	return h
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
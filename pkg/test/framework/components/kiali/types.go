@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kiali
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Status is the response of the Kiali status API.
+type Status struct {
+	Status           map[string]string `json:"status"`
+	ExternalServices []ExternalService `json:"externalServices"`
+	WarningMessages  []string          `json:"warningMessages"`
+	IstioEnvironment map[string]bool   `json:"istioEnvironment"`
+}
+
+// ExternalService is a component Kiali connected to, such as Istio or Prometheus.
+type ExternalService struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Node is a node of the Kiali graph.
+type Node struct {
+	ID        string `json:"id"`
+	NodeType  string `json:"nodeType"`
+	Namespace string `json:"namespace"`
+	Workload  string `json:"workload"`
+	App       string `json:"app"`
+	Version   string `json:"version"`
+	Service   string `json:"service"`
+}
+
+func (n Node) String() string {
+	name := n.Workload
+	if name == "" {
+		name = n.Service
+	}
+	if name == "" {
+		name = n.App
+	}
+	return fmt.Sprintf("%s/%s(%s)", n.Namespace, name, n.NodeType)
+}
+
+// Traffic describes the traffic observed on an edge.
+type Traffic struct {
+	Protocol string            `json:"protocol"`
+	Rates    map[string]string `json:"rates"`
+}
+
+// Edge is an edge of the Kiali graph, from the Source node to the Target node.
+type Edge struct {
+	ID      string  `json:"id"`
+	Source  string  `json:"source"`
+	Target  string  `json:"target"`
+	Traffic Traffic `json:"traffic"`
+}
+
+// Graph is the Kiali traffic graph.
+type Graph struct {
+	Nodes map[string]Node
+	Edges []Edge
+}
+
+type rawGraph struct {
+	Elements struct {
+		Nodes []struct {
+			Data Node `json:"data"`
+		} `json:"nodes"`
+		Edges []struct {
+			Data Edge `json:"data"`
+		} `json:"edges"`
+	} `json:"elements"`
+}
+
+// parseGraph parses the cytoscape formatted response of the Kiali graph API.
+func parseGraph(body []byte) (Graph, error) {
+	var raw rawGraph
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return Graph{}, fmt.Errorf("failed parsing kiali graph: %v", err)
+	}
+	g := Graph{Nodes: make(map[string]Node, len(raw.Elements.Nodes))}
+	for _, n := range raw.Elements.Nodes {
+		g.Nodes[n.Data.ID] = n.Data
+	}
+	for _, e := range raw.Elements.Edges {
+		g.Edges = append(g.Edges, e.Data)
+	}
+	return g, nil
+}
+
+// FindWorkload returns the graph node for the given workload.
+func (g Graph) FindWorkload(namespace, workload string) (Node, bool) {
+	for _, n := range g.Nodes {
+		if n.Namespace == namespace && n.Workload == workload {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// VerifyEdge checks that the graph contains an edge between the given workloads, carrying traffic of the
+// given protocol (e.g. "http", "grpc" or "tcp"). An empty protocol matches any traffic.
+func (g Graph) VerifyEdge(srcNamespace, srcWorkload, dstNamespace, dstWorkload, protocol string) error {
+	src, ok := g.FindWorkload(srcNamespace, srcWorkload)
+	if !ok {
+		return fmt.Errorf("workload %s/%s not found in graph:\n%s", srcNamespace, srcWorkload, g)
+	}
+	dst, ok := g.FindWorkload(dstNamespace, dstWorkload)
+	if !ok {
+		return fmt.Errorf("workload %s/%s not found in graph:\n%s", dstNamespace, dstWorkload, g)
+	}
+	for _, e := range g.Edges {
+		if e.Source == src.ID && e.Target == dst.ID && (protocol == "" || e.Traffic.Protocol == protocol) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no %s edge from %s to %s found in graph:\n%s", protocol, src, dst, g)
+}
+
+// String returns one line per edge of the graph.
+func (g Graph) String() string {
+	lines := make([]string, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		lines = append(lines, fmt.Sprintf("%s -[%s]-> %s", g.Nodes[e.Source], e.Traffic.Protocol, g.Nodes[e.Target]))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// WorkloadStatus is the replica status of a workload.
+type WorkloadStatus struct {
+	Name              string `json:"name"`
+	DesiredReplicas   int    `json:"desiredReplicas"`
+	CurrentReplicas   int    `json:"currentReplicas"`
+	AvailableReplicas int    `json:"availableReplicas"`
+	SyncedProxies     int    `json:"syncedProxies"`
+}
+
+// Health is the health Kiali computed for a workload.
+type Health struct {
+	WorkloadStatus *WorkloadStatus `json:"workloadStatus"`
+	Requests       struct {
+		ErrorRatio         float64 `json:"errorRatio"`
+		InboundErrorRatio  float64 `json:"inboundErrorRatio"`
+		OutboundErrorRatio float64 `json:"outboundErrorRatio"`
+	} `json:"requests"`
+}
+
+// Healthy returns true if all desired replicas are available and, if there was any traffic, no requests failed.
+// Kiali reports an error ratio of -1 when there was no traffic.
+func (h Health) Healthy() bool {
+	if h.WorkloadStatus != nil && h.WorkloadStatus.AvailableReplicas < h.WorkloadStatus.DesiredReplicas {
+		return false
+	}
+	return h.Requests.ErrorRatio <= 0
+}
@@ -0,0 +1,160 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const containerName = "fortio"
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id       resource.ID
+	ns       namespace.Instance
+	cluster  resource.Cluster
+	podName  string
+	manifest string
+	ctx      resource.Context
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		ns:      cfg.Namespace,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		ctx:     ctx,
+	}
+	c.id = ctx.TrackResource(c)
+
+	if c.ns == nil {
+		var err error
+		c.ns, err = namespace.New(ctx, namespace.Config{
+			Prefix: "loadgen",
+			Inject: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.LoadGenInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	c.manifest, err = tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"ImagePullPolicy": s.PullPolicy,
+		"Container":       containerName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), c.manifest); err != nil {
+		return nil, err
+	}
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.ns.Name(), "app=loadgen"))
+	if err != nil {
+		return nil, err
+	}
+	c.podName = pods[0].Name
+	return c, nil
+}
+
+// command returns the fortio command line running the load with the given options, and writing its results
+// as JSON to stdout.
+func command(opts Options) (string, error) {
+	if opts.URL == "" {
+		return "", fmt.Errorf("load run requires a URL")
+	}
+	opts = opts.withDefaults()
+	ps := make([]string, 0, len(percentiles))
+	for _, p := range percentiles {
+		ps = append(ps, fmt.Sprint(p))
+	}
+	args := []string{
+		"fortio", "load", "-json", "-",
+		"-qps", fmt.Sprint(opts.QPS),
+		"-c", fmt.Sprint(opts.Connections),
+		"-t", opts.Duration.String(),
+		"-p", strings.Join(ps, ","),
+	}
+	for _, h := range opts.Headers {
+		if strings.ContainsAny(h, " \t\n") {
+			return "", fmt.Errorf("header %q must not contain whitespace", h)
+		}
+		args = append(args, "-H", h)
+	}
+	return strings.Join(append(args, opts.URL), " "), nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Run(opts Options) (Result, error) {
+	cmd, err := command(opts)
+	if err != nil {
+		return Result{}, err
+	}
+	scopes.Framework.Infof("running load: %s", cmd)
+	stdout, stderr, err := c.cluster.PodExec(c.podName, c.ns.Name(), containerName, cmd)
+	if err != nil {
+		return Result{}, fmt.Errorf("load run failed: %v: %s", err, stderr)
+	}
+	r, err := parseResult(stdout)
+	if err != nil {
+		return Result{}, err
+	}
+	scopes.Framework.Infof("load results: %s", r)
+	return r, nil
+}
+
+func (c *kubeComponent) RunOrFail(t test.Failer, opts Options) Result {
+	t.Helper()
+	r, err := c.Run(opts)
+	if err != nil {
+		t.Fatalf("loadgen.RunOrFail: %v", err)
+	}
+	return r
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	// The deployment is removed explicitly, as it may be in a namespace provided by the test.
+	return c.ctx.Config(c.cluster).DeleteYAML(c.ns.Name(), c.manifest)
+}
@@ -0,0 +1,119 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"fmt"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/istio/ingress"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	defaultQPS         = 100
+	defaultConnections = 8
+	defaultDuration    = 30 * time.Second
+)
+
+// Options of a load run.
+type Options struct {
+	// URL the requests are sent to. See EchoURL and IngressURL.
+	URL string
+
+	// QPS is the total rate of requests across all connections. Defaults to 100. A negative value sends
+	// requests as fast as possible.
+	QPS int
+
+	// Connections is the number of parallel connections. Defaults to 8.
+	Connections int
+
+	// Duration of the run. Defaults to 30s.
+	Duration time.Duration
+
+	// Headers added to the requests, as in "Host:example.com". They must not contain whitespace.
+	Headers []string
+}
+
+// Instance is a load generator deployed on kube, sending requests from within the mesh.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the load generator is deployed in.
+	Namespace() namespace.Instance
+
+	// Run sends requests with the given options and returns the results once the run completes.
+	Run(opts Options) (Result, error)
+
+	// RunOrFail calls Run and fails the test if an error is returned.
+	RunOrFail(t test.Failer, opts Options) Result
+}
+
+// Config for the load generator.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Namespace to deploy the load generator in. If not set, a new namespace with sidecar injection is
+	// created.
+	Namespace namespace.Instance
+}
+
+// New deploys a load generator and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("loadgen.NewOrFail: %v", err)
+	}
+	return i
+}
+
+// EchoURL returns the URL of the given path on the named HTTP port of the echo instance.
+func EchoURL(target echo.Instance, portName, path string) (string, error) {
+	p := target.Config().PortByName(portName)
+	if p == nil {
+		return "", fmt.Errorf("no port %s on %s", portName, target.Config().Service)
+	}
+	return fmt.Sprintf("http://%s:%d%s", target.Config().FQDN(), p.ServicePort, path), nil
+}
+
+// IngressURL returns the URL of the given path on the HTTP address of the ingress. The Host header of the requests
+// is usually set in Options.Headers to select the virtual host.
+func IngressURL(ing ingress.Instance, path string) string {
+	addr := ing.HTTPAddress()
+	return fmt.Sprintf("http://%s%s", addr.String(), path)
+}
+
+func (o Options) withDefaults() Options {
+	if o.QPS == 0 {
+		o.QPS = defaultQPS
+	}
+	if o.Connections == 0 {
+		o.Connections = defaultConnections
+	}
+	if o.Duration == 0 {
+		o.Duration = defaultDuration
+	}
+	return o
+}
@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"testing"
+	"time"
+)
+
+const fortioOutput = `{
+  "RunType": "HTTP",
+  "ActualQPS": 99.5,
+  "DurationHistogram": {
+    "Count": 1000,
+    "Avg": 0.0042,
+    "Percentiles": [
+      {"Percentile": 50, "Value": 0.003},
+      {"Percentile": 99, "Value": 0.025},
+      {"Percentile": 99.9, "Value": 0.08}
+    ]
+  },
+  "RetCodes": {"200": 990, "503": 8, "-1": 2}
+}`
+
+func TestParseResult(t *testing.T) {
+	r, err := parseResult(fortioOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Requests != 1000 || r.Errors != 10 {
+		t.Fatalf("unexpected requests/errors: %d/%d", r.Requests, r.Errors)
+	}
+	if got := r.ErrorRate(); got != 0.01 {
+		t.Fatalf("expected error rate 0.01, got %v", got)
+	}
+	if l, err := r.Latency(99); err != nil || l != 25*time.Millisecond {
+		t.Fatalf("unexpected p99 latency %v: %v", l, err)
+	}
+	if _, err := r.Latency(75); err == nil {
+		t.Fatal("expected error for missing percentile")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r, err := parseResult(fortioOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		slo   SLO
+		valid bool
+	}{
+		{"met", SLO{MaxLatency: map[float64]time.Duration{99: 50 * time.Millisecond}, MaxErrorRate: 0.02}, true},
+		{"latency", SLO{MaxLatency: map[float64]time.Duration{99.9: 50 * time.Millisecond}, MaxErrorRate: 0.02}, false},
+		{"errors", SLO{MaxErrorRate: 0.005}, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Check(tt.slo)
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+	if err := (Result{}).Check(SLO{}); err == nil {
+		t.Fatal("expected error for empty result")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	got, err := command(Options{URL: "http://b.ns.svc:80/", QPS: 50, Headers: []string{"Host:example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "fortio load -json - -qps 50 -c 8 -t 30s -p 50,75,90,99,99.9 -H Host:example.com http://b.ns.svc:80/"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err := command(Options{URL: "http://b/", Headers: []string{"Host: example.com"}}); err == nil {
+		t.Fatal("expected error for header with whitespace")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+)
+
+// percentiles reported by every run.
+var percentiles = []float64{50, 75, 90, 99, 99.9}
+
+// Result of a load run.
+type Result struct {
+	// Requests is the number of requests sent.
+	Requests int64
+	// Errors is the number of requests that failed or had a non 200 response.
+	Errors int64
+	// Codes counts the responses by status code. Connection errors are counted with code -1.
+	Codes map[int]int64
+	// ActualQPS is the rate of requests that was achieved.
+	ActualQPS float64
+	// Latencies by percentile, in [0, 100].
+	Latencies map[float64]time.Duration
+	// Average latency.
+	Average time.Duration
+}
+
+// fortioResult is the subset of the JSON results of fortio that is used.
+type fortioResult struct {
+	ActualQPS         float64
+	RetCodes          map[int]int64
+	DurationHistogram struct {
+		Count       int64
+		Avg         float64
+		Percentiles []struct {
+			Percentile float64
+			Value      float64
+		}
+	}
+}
+
+// parseResult parses the JSON results of a fortio load run.
+func parseResult(out string) (Result, error) {
+	var raw fortioResult
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		return Result{}, fmt.Errorf("failed parsing load results: %v", err)
+	}
+	r := Result{
+		Requests:  raw.DurationHistogram.Count,
+		Codes:     raw.RetCodes,
+		ActualQPS: raw.ActualQPS,
+		Latencies: make(map[float64]time.Duration, len(raw.DurationHistogram.Percentiles)),
+		Average:   seconds(raw.DurationHistogram.Avg),
+	}
+	for code, n := range raw.RetCodes {
+		if code != 200 {
+			r.Errors += n
+		}
+	}
+	for _, p := range raw.DurationHistogram.Percentiles {
+		r.Latencies[p.Percentile] = seconds(p.Value)
+	}
+	return r, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ErrorRate returns the fraction of requests that failed.
+func (r Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Latency returns the latency at the given percentile, which must be one of 50, 75, 90, 99 and 99.9.
+func (r Result) Latency(percentile float64) (time.Duration, error) {
+	l, ok := r.Latencies[percentile]
+	if !ok {
+		return 0, fmt.Errorf("latency percentile %v was not recorded", percentile)
+	}
+	return l, nil
+}
+
+func (r Result) String() string {
+	ps := make([]float64, 0, len(r.Latencies))
+	for p := range r.Latencies {
+		ps = append(ps, p)
+	}
+	sort.Float64s(ps)
+	latencies := make([]string, 0, len(ps))
+	for _, p := range ps {
+		latencies = append(latencies, fmt.Sprintf("p%v=%v", p, r.Latencies[p]))
+	}
+	return fmt.Sprintf("%d requests at %.1f qps, %.2f%% errors, %s", r.Requests, r.ActualQPS, 100*r.ErrorRate(),
+		strings.Join(latencies, " "))
+}
+
+// SLO is a set of objectives a load run must meet.
+type SLO struct {
+	// MaxLatency by percentile. For example {99: 100 * time.Millisecond} requires a p99 latency below 100ms.
+	MaxLatency map[float64]time.Duration
+	// MaxErrorRate is the highest acceptable fraction of failed requests.
+	MaxErrorRate float64
+}
+
+// Check returns an error describing every objective of the SLO the result does not meet.
+func (r Result) Check(slo SLO) error {
+	if r.Requests == 0 {
+		return fmt.Errorf("no requests were sent")
+	}
+	var violations []string
+	if rate := r.ErrorRate(); rate > slo.MaxErrorRate {
+		violations = append(violations, fmt.Sprintf("error rate %.2f%% above %.2f%%", 100*rate, 100*slo.MaxErrorRate))
+	}
+	for p, max := range slo.MaxLatency {
+		l, err := r.Latency(p)
+		if err != nil {
+			return err
+		}
+		if l > max {
+			violations = append(violations, fmt.Sprintf("p%v latency %v above %v", p, l, max))
+		}
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("SLO not met: %s (%s)", strings.Join(violations, ", "), r)
+	}
+	return nil
+}
+
+// CheckOrFail calls Check and fails the test if an error is returned.
+func (r Result) CheckOrFail(t test.Failer, slo SLO) {
+	t.Helper()
+	if err := r.Check(slo); err != nil {
+		t.Fatalf("loadgen.CheckOrFail: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	appName          = "loki"
+	collectorAppName = "fluent-bit"
+	lokiPort         = 3100
+
+	// queryLimit is the maximum number of entries returned by a single query.
+	queryLimit = 1000
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	namespace string
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+	close     func()
+}
+
+func getYaml() (string, error) {
+	b, err := ioutil.ReadFile(env.LokiInstallFilePath)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newKube(ctx resource.Context, cfgIn Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfgIn.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	cfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.namespace = cfg.TelemetryNamespace
+
+	yaml, err := getYaml()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.namespace, yaml); err != nil {
+		return nil, err
+	}
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML(c.namespace, yaml)
+	}
+
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.namespace, "app="+appName))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := testKube.WaitUntilPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.namespace, "app="+collectorAppName)); err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, lokiPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	scopes.Framework.Debugf("initialized loki port forwarder: %v", forwarder.Address())
+
+	c.address = fmt.Sprintf("http://%s", forwarder.Address())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) PushAddress() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/loki/api/v1/push", appName, c.namespace, lokiPort)
+}
+
+func (c *kubeComponent) Query(q Query, since time.Time) ([]Entry, error) {
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	params := url.Values{}
+	params.Set("query", q.String())
+	params.Set("start", fmt.Sprint(since.UnixNano()))
+	params.Set("end", fmt.Sprint(time.Now().UnixNano()))
+	params.Set("limit", fmt.Sprint(queryLimit))
+	params.Set("direction", "forward")
+	u := c.address + "/loki/api/v1/query_range?" + params.Encode()
+	scopes.Framework.Debugf("querying loki: %v", q)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loki api returned non-ok status: %v: %s", resp.StatusCode, string(body))
+	}
+	return parseEntries(body)
+}
+
+func (c *kubeComponent) QueryOrFail(t test.Failer, q Query, since time.Time) []Entry {
+	t.Helper()
+	entries, err := c.Query(q, since)
+	if err != nil {
+		t.Fatalf("loki.QueryOrFail: %v", err)
+	}
+	return entries
+}
+
+func (c *kubeComponent) WaitForLogs(q Query, since time.Time, count int, opts ...retry.Option) ([]Entry, error) {
+	// Logs go through Fluent Bit's tail and flush intervals before they arrive, so allow more time by default.
+	opts = append([]retry.Option{retry.Timeout(2 * time.Minute), retry.Delay(2 * time.Second)}, opts...)
+	var entries []Entry
+	err := retry.UntilSuccess(func() error {
+		var err error
+		entries, err = c.Query(q, since)
+		if err != nil {
+			return err
+		}
+		if len(entries) < count {
+			lines := make([]string, 0, len(entries))
+			for _, e := range entries {
+				lines = append(lines, e.String())
+			}
+			return fmt.Errorf("expected %d log entries for %s, got %d:\n%s", count, q, len(entries), strings.Join(lines, "\n"))
+		}
+		return nil
+	}, opts...)
+	return entries, err
+}
+
+func (c *kubeComponent) WaitForLogsOrFail(t test.Failer, q Query, since time.Time, count int, opts ...retry.Option) []Entry {
+	t.Helper()
+	entries, err := c.WaitForLogs(q, since, count, opts...)
+	if err != nil {
+		t.Fatalf("loki.WaitForLogsOrFail: %v", err)
+	}
+	return entries
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Instance represents a log pipeline on kube: Loki, with Fluent Bit shipping the logs of every
+// container in the cluster to it.
+type Instance interface {
+	resource.Resource
+
+	// PushAddress returns the in-cluster URL of the Loki push API, for log providers that write to Loki
+	// directly rather than through container logs.
+	PushAddress() string
+
+	// Query returns the log entries matching the query that were logged since the given time.
+	Query(q Query, since time.Time) ([]Entry, error)
+	QueryOrFail(t test.Failer, q Query, since time.Time) []Entry
+
+	// WaitForLogs waits until at least count entries matching the query have been logged since the given
+	// time, and returns them.
+	WaitForLogs(q Query, since time.Time, count int, opts ...retry.Option) ([]Entry, error)
+	WaitForLogsOrFail(t test.Failer, q Query, since time.Time, count int, opts ...retry.Option) []Entry
+}
+
+// Config for the log pipeline component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+}
+
+// New deploys the log pipeline and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("loki.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,140 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Labels attached by Fluent Bit to the logs of each container.
+const (
+	NamespaceLabel = "namespace"
+	PodLabel       = "pod"
+	ContainerLabel = "container"
+)
+
+// Query selects log entries. It is converted to a LogQL query of the form
+//
+//	{namespace="ns",container="istio-proxy"} |= "GET /foo"
+type Query struct {
+	// Labels the log stream must have. At least one label is required by Loki.
+	Labels map[string]string
+	// Contains are substrings that the log line must contain.
+	Contains []string
+}
+
+// ContainerLogs returns a query for the logs of the given container of all pods in the namespace whose
+// name starts with podPrefix. Empty values are not matched on.
+func ContainerLogs(namespace, podPrefix, container string) Query {
+	q := Query{Labels: map[string]string{NamespaceLabel: namespace}}
+	if container != "" {
+		q.Labels[ContainerLabel] = container
+	}
+	if podPrefix != "" {
+		q.Labels[PodLabel] = podPrefix + ".*"
+	}
+	return q
+}
+
+// WithLine returns a copy of the query that also requires the log line to contain the given substring.
+func (q Query) WithLine(substring string) Query {
+	return Query{
+		Labels:   q.Labels,
+		Contains: append(append([]string{}, q.Contains...), substring),
+	}
+}
+
+// String returns the LogQL for the query. Label values containing ".*" are matched as regular expressions.
+func (q Query) String() string {
+	keys := make([]string, 0, len(q.Labels))
+	for k := range q.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	matchers := make([]string, 0, len(keys))
+	for _, k := range keys {
+		op := "="
+		if strings.Contains(q.Labels[k], ".*") {
+			op = "=~"
+		}
+		matchers = append(matchers, k+op+strconv.Quote(q.Labels[k]))
+	}
+	sb := &strings.Builder{}
+	sb.WriteString("{" + strings.Join(matchers, ",") + "}")
+	for _, c := range q.Contains {
+		sb.WriteString(" |= " + strconv.Quote(c))
+	}
+	return sb.String()
+}
+
+// Entry is a single log line stored in Loki.
+type Entry struct {
+	Labels    map[string]string
+	Timestamp time.Time
+	Line      string
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s/%s: %s", e.Labels[PodLabel], e.Labels[ContainerLabel], e.Line)
+}
+
+// queryResponse is the response of the Loki /loki/api/v1/query_range API for log queries.
+type queryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// parseEntries parses the response of a Loki log query, returning the entries ordered by time.
+func parseEntries(body []byte) ([]Entry, error) {
+	var resp queryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed parsing loki response: %v", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("loki query failed with status %q", resp.Status)
+	}
+	if resp.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unexpected loki result type %q", resp.Data.ResultType)
+	}
+	var out []Entry
+	for _, s := range resp.Data.Result {
+		for _, v := range s.Values {
+			ns, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid loki timestamp %q: %v", v[0], err)
+			}
+			out = append(out, Entry{
+				Labels:    s.Stream,
+				Timestamp: time.Unix(0, ns),
+				Line:      v[1],
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out, nil
+}
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	cases := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{
+			"namespace",
+			ContainerLogs("ns", "", ""),
+			`{namespace="ns"}`,
+		},
+		{
+			"container and pod",
+			ContainerLogs("ns", "a-v1", "istio-proxy"),
+			`{container="istio-proxy",namespace="ns",pod=~"a-v1.*"}`,
+		},
+		{
+			"line filters",
+			ContainerLogs("ns", "", "istio-proxy").WithLine(`GET /foo`).WithLine(`"200"`),
+			`{container="istio-proxy",namespace="ns"} |= "GET /foo" |= "\"200\""`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.String(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithLineDoesNotModify(t *testing.T) {
+	base := ContainerLogs("ns", "", "").WithLine("a")
+	_ = base.WithLine("b")
+	_ = base.WithLine("c")
+	if len(base.Contains) != 1 {
+		t.Fatalf("base query was modified: %v", base)
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"streams","result":[
+{"stream":{"namespace":"ns","pod":"b-v1-xyz","container":"istio-proxy"},"values":[["3000000000","third"],["1000000000","first"]]},
+{"stream":{"namespace":"ns","pod":"a-v1-xyz","container":"istio-proxy"},"values":[["2000000000","second"]]}
+]}}`
+	entries, err := parseEntries([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %v", entries)
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if entries[i].Line != want {
+			t.Errorf("entry %d: got %q, want %q", i, entries[i].Line, want)
+		}
+	}
+	if entries[1].Labels[PodLabel] != "a-v1-xyz" {
+		t.Errorf("unexpected labels: %v", entries[1].Labels)
+	}
+
+	if _, err := parseEntries([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`)); err == nil {
+		t.Fatal("expected metric query results to be rejected")
+	}
+}
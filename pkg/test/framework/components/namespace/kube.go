@@ -27,11 +27,20 @@ import (
 
 	"istio.io/api/label"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/image"
 	"istio.io/istio/pkg/test/framework/resource"
 	kube2 "istio.io/istio/pkg/test/kube"
 	"istio.io/istio/pkg/test/scopes"
 )
 
+const (
+	// TestingLabel and TestingLabelValue mark the namespaces created by the test framework.
+	TestingLabel      = "istio-testing"
+	TestingLabelValue = "istio-test"
+	// RunIDLabel holds the ID of the run which created the namespace.
+	RunIDLabel = "istio-testing-run"
+)
+
 var (
 	idctr int64
 	rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -88,6 +97,10 @@ func (n *kubeNamespace) Close() (err error) {
 
 func claimKube(ctx resource.Context, name string, injectSidecar bool) (Instance, error) {
 	env := ctx.Environment().(*kube.Environment)
+	images, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
 
 	for _, cluster := range env.KubeClusters {
 		if !kube2.NamespaceExists(cluster, name) {
@@ -104,12 +117,22 @@ func claimKube(ctx resource.Context, name string, injectSidecar bool) (Instance,
 				return nil, err
 			}
 		}
+		if err := env.PrepareOpenShiftNamespace(cluster, name, injectSidecar); err != nil {
+			return nil, err
+		}
+		if err := images.CreatePullSecret(cluster, name); err != nil {
+			return nil, err
+		}
 	}
 	return &kubeNamespace{name: name}, nil
 }
 
 // NewNamespace allocates a new testing namespace.
 func newKube(ctx resource.Context, nsConfig *Config) (Instance, error) {
+	if err := validateConfig(nsConfig); err != nil {
+		return nil, err
+	}
+
 	mu.Lock()
 	idctr++
 	nsid := idctr
@@ -124,15 +147,31 @@ func newKube(ctx resource.Context, nsConfig *Config) (Instance, error) {
 	id := ctx.TrackResource(n)
 	n.id = id
 
+	env := ctx.Environment().(*kube.Environment)
+	images, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	labels := createNamespaceLabels(nsConfig)
+	labels[RunIDLabel] = ctx.Settings().RunID.String()
 	for _, cluster := range n.ctx.Clusters() {
 		if _, err := cluster.CoreV1().Namespaces().Create(context.TODO(), &kubeApiCore.Namespace{
 			ObjectMeta: kubeApiMeta.ObjectMeta{
 				Name:   ns,
-				Labels: createNamespaceLabels(nsConfig),
+				Labels: labels,
 			},
 		}, kubeApiMeta.CreateOptions{}); err != nil {
 			return nil, err
 		}
+		if err := env.PrepareOpenShiftNamespace(cluster.(kube.Cluster), ns, nsConfig.Inject); err != nil {
+			return nil, err
+		}
+		if err := images.CreatePullSecret(cluster, ns); err != nil {
+			return nil, err
+		}
+		if err := validateEnrollment(cluster, nsConfig, labels); err != nil {
+			return nil, fmt.Errorf("namespace %s: %v", ns, err)
+		}
 	}
 
 	return n, nil
@@ -141,13 +180,15 @@ func newKube(ctx resource.Context, nsConfig *Config) (Instance, error) {
 // createNamespaceLabels will take a namespace config and generate the proper k8s labels
 func createNamespaceLabels(cfg *Config) map[string]string {
 	l := make(map[string]string)
-	l["istio-testing"] = "istio-test"
+	l[TestingLabel] = TestingLabelValue
 	if cfg.Inject {
 		if cfg.Revision != "" {
 			l[label.IstioRev] = cfg.Revision
 		} else {
 			l["istio-injection"] = "enabled"
 		}
+	} else if cfg.DisableInjection {
+		l["istio-injection"] = "disabled"
 	}
 
 	// bring over supplied labels
@@ -27,6 +27,9 @@ type Config struct {
 	Inject bool
 	// Revision is the namespace of custom injector instance
 	Revision string
+	// DisableInjection explicitly disables sidecar injection with the istio-injection=disabled label, for
+	// namespaces which must not be injected even if the injector selects all namespaces by default.
+	DisableInjection bool
 	// Labels to be applied to namespace
 	Labels map[string]string
 }
@@ -51,7 +54,8 @@ func ClaimOrFail(t test.Failer, ctx resource.Context, name string) Instance {
 	return i
 }
 
-// New creates a new Namespace in all clusters.
+// New creates a new Namespace in all clusters. In clusters where Istio is installed, it fails unless a sidecar
+// injector of the requested revision selects the namespace.
 func New(ctx resource.Context, nsConfig Config) (i Instance, err error) {
 	if ctx.Settings().StableNamespaces {
 		return Claim(ctx, nsConfig.Prefix, nsConfig.Inject)
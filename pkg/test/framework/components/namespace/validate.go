@@ -0,0 +1,102 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/api/admissionregistration/v1beta1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/label"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	// injectorWebhookName is the name of the webhooks of the sidecar injectors of all revisions.
+	injectorWebhookName = "sidecar-injector.istio.io"
+	// defaultRevision is the value of the revision label of the injector webhook of the default revision.
+	defaultRevision = "default"
+)
+
+// validateConfig checks that the options of the namespace are consistent.
+func validateConfig(cfg *Config) error {
+	if cfg.DisableInjection && (cfg.Inject || cfg.Revision != "") {
+		return fmt.Errorf("namespace %q cannot both disable and request sidecar injection", cfg.Prefix)
+	}
+	return nil
+}
+
+// validateEnrollment checks that the injector webhook of the requested revision selects the namespace. Namespaces
+// are often created before Istio is installed, so it is only checked in clusters where an injector webhook is
+// installed.
+func validateEnrollment(cluster resource.Cluster, cfg *Config, labels map[string]string) error {
+	if !cfg.Inject {
+		return nil
+	}
+	whcs, err := cluster.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().List(context.TODO(),
+		kubeApiMeta.ListOptions{})
+	if err != nil {
+		return err
+	}
+	installed := false
+	var revisions []string
+	for _, whc := range whcs.Items {
+		rev := whc.Labels[label.IstioRev]
+		for _, wh := range whc.Webhooks {
+			if wh.Name != injectorWebhookName {
+				continue
+			}
+			installed = true
+			revisions = append(revisions, rev)
+			if matchesRevision(rev, cfg.Revision) && selects(wh, labels) {
+				return nil
+			}
+		}
+	}
+	if !installed {
+		return nil
+	}
+
+	revision := cfg.Revision
+	if revision == "" {
+		revision = defaultRevision
+	}
+	return fmt.Errorf("no sidecar injector of revision %q selects namespace with labels %v in %s (injector revisions: %s)",
+		revision, labels, cluster.Name(), strings.Join(revisions, ","))
+}
+
+// matchesRevision returns true if the injector of the given revision handles the requested revision.
+func matchesRevision(injector, requested string) bool {
+	if requested == "" {
+		return injector == "" || injector == defaultRevision
+	}
+	return injector == requested
+}
+
+// selects returns true if the namespace selector of the webhook matches the labels of the namespace.
+func selects(wh v1beta1.MutatingWebhook, labels map[string]string) bool {
+	if wh.NamespaceSelector == nil {
+		return true
+	}
+	sel, err := kubeApiMeta.LabelSelectorAsSelector(wh.NamespaceSelector)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(klabels.Set(labels))
+}
@@ -0,0 +1,73 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"testing"
+
+	"k8s.io/api/admissionregistration/v1beta1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+		err  bool
+	}{
+		{"inject", Config{Inject: true, Revision: "canary"}, false},
+		{"disabled", Config{DisableInjection: true}, false},
+		{"disabled with revision", Config{DisableInjection: true, Revision: "canary"}, true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateConfig(&tt.cfg); tt.err != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestInjectorSelects(t *testing.T) {
+	defaultInjector := v1beta1.MutatingWebhook{
+		Name: injectorWebhookName,
+		NamespaceSelector: &kubeApiMeta.LabelSelector{
+			MatchLabels: map[string]string{"istio-injection": "enabled"},
+		},
+	}
+	canaryInjector := v1beta1.MutatingWebhook{
+		Name: injectorWebhookName,
+		NamespaceSelector: &kubeApiMeta.LabelSelector{
+			MatchExpressions: []kubeApiMeta.LabelSelectorRequirement{
+				{Key: "istio-injection", Operator: kubeApiMeta.LabelSelectorOpDoesNotExist},
+				{Key: "istio.io/rev", Operator: kubeApiMeta.LabelSelectorOpIn, Values: []string{"canary"}},
+			},
+		},
+	}
+
+	injected := createNamespaceLabels(&Config{Inject: true})
+	canary := createNamespaceLabels(&Config{Inject: true, Revision: "canary"})
+	disabled := createNamespaceLabels(&Config{DisableInjection: true})
+
+	if !selects(defaultInjector, injected) || selects(defaultInjector, canary) || selects(defaultInjector, disabled) {
+		t.Fatal("default injector must only select the injected namespace")
+	}
+	if !selects(canaryInjector, canary) || selects(canaryInjector, injected) || selects(canaryInjector, disabled) {
+		t.Fatal("canary injector must only select the canary namespace")
+	}
+	if !matchesRevision("default", "") || !matchesRevision("canary", "canary") || matchesRevision("default", "canary") {
+		t.Fatal("unexpected revision matching")
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	istioKube "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	oidcServer "istio.io/istio/pkg/test/oidc"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "oidc"
+	port        = 8080
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	ns        namespace.Instance
+	address   string
+	forwarder istioKube.PortForwarder
+	cluster   resource.Cluster
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-oidc",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := ioutil.ReadFile(env.OIDCInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	yaml, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.BaseTag(),
+		"ImagePullPolicy": s.PullPolicy,
+		"Port":            port,
+		"Issuer":          c.Issuer(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The deployment is removed along with the namespace.
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.ns.Name(), "app="+serviceName))
+	if err != nil {
+		return nil, err
+	}
+	pod := pods[0]
+	forwarder, err := c.cluster.NewPortForwarder(pod.Name, pod.Namespace, "", 0, port)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.forwarder = forwarder
+	c.address = fmt.Sprintf("http://%s", forwarder.Address())
+	scopes.Framework.Debugf("initialized OIDC port forwarder: %v", forwarder.Address())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Issuer() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", serviceName, c.ns.Name(), port)
+}
+
+func (c *kubeComponent) JWKSURI() string {
+	return c.Issuer() + oidcServer.JWKSPath
+}
+
+// do sends a request to the provider, and returns the response body.
+func (c *kubeComponent) do(method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, c.address+path, body)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned non-ok status %v: %s", method, path, resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+func (c *kubeComponent) Token(claims map[string]interface{}) (string, error) {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	token, err := c.do(http.MethodPost, "/token", bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+func (c *kubeComponent) TokenOrFail(t test.Failer, claims map[string]interface{}) string {
+	t.Helper()
+	token, err := c.Token(claims)
+	if err != nil {
+		t.Fatalf("oidc.TokenOrFail: %v", err)
+	}
+	return token
+}
+
+func (c *kubeComponent) RotateKeys(keepPrevious bool) (oidcServer.RotateResponse, error) {
+	var out oidcServer.RotateResponse
+	body, err := c.do(http.MethodPost, fmt.Sprintf("/rotate?keep=%v", keepPrevious), nil)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(body, &out)
+	return out, err
+}
+
+func (c *kubeComponent) RotateKeysOrFail(t test.Failer, keepPrevious bool) oidcServer.RotateResponse {
+	t.Helper()
+	resp, err := c.RotateKeys(keepPrevious)
+	if err != nil {
+		t.Fatalf("oidc.RotateKeysOrFail: %v", err)
+	}
+	return resp
+}
+
+func (c *kubeComponent) JWKS() (string, error) {
+	body, err := c.do(http.MethodGet, oidcServer.JWKSPath, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (c *kubeComponent) Stats() (oidcServer.Stats, error) {
+	var out oidcServer.Stats
+	body, err := c.do(http.MethodGet, "/stats", nil)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(body, &out)
+	return out, err
+}
+
+func (c *kubeComponent) WaitForJWKSFetch(since oidcServer.Stats, opts ...retry.Option) error {
+	return retry.UntilSuccess(func() error {
+		stats, err := c.Stats()
+		if err != nil {
+			return err
+		}
+		if stats.JWKSFetches <= since.JWKSFetches {
+			return fmt.Errorf("JWKS not fetched yet, fetched %d times", stats.JWKSFetches)
+		}
+		return nil
+	}, opts...)
+}
+
+func (c *kubeComponent) RequestAuthentication(name string, selector map[string]string) string {
+	return c.requestAuthentication(name, selector, fmt.Sprintf("jwksUri: %q", c.JWKSURI()))
+}
+
+func (c *kubeComponent) RequestAuthenticationWithJWKS(name string, selector map[string]string) (string, error) {
+	jwks, err := c.JWKS()
+	if err != nil {
+		return "", err
+	}
+	// The JWKS is a JSON document, which is also valid YAML when quoted.
+	return c.requestAuthentication(name, selector, fmt.Sprintf("jwks: %q", jwks)), nil
+}
+
+func (c *kubeComponent) requestAuthentication(name string, selector map[string]string, keys string) string {
+	spec := &strings.Builder{}
+	if len(selector) > 0 {
+		labels := make([]string, 0, len(selector))
+		for k := range selector {
+			labels = append(labels, k)
+		}
+		sort.Strings(labels)
+		spec.WriteString("\n  selector:\n    matchLabels:")
+		for _, k := range labels {
+			fmt.Fprintf(spec, "\n      %s: %q", k, selector[k])
+		}
+	}
+	return fmt.Sprintf(`apiVersion: security.istio.io/v1beta1
+kind: RequestAuthentication
+metadata:
+  name: %s
+spec:%s
+  jwtRules:
+  - issuer: %q
+    %s
+    forwardOriginalToken: true
+`, name, spec, c.Issuer(), keys)
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.forwarder != nil {
+		c.forwarder.Close()
+	}
+	return nil
+}
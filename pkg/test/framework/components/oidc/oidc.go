@@ -0,0 +1,95 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	oidcServer "istio.io/istio/pkg/test/oidc"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Instance represents a mock OpenID Connect provider deployed on kube. It mints tokens with arbitrary claims and
+// rotates its signing keys on demand.
+//
+// Istiod resolves the JWKS of a jwksUri when the RequestAuthentication is pushed, but only refreshes it every 20
+// minutes afterwards. Tests that rotate keys can use RequestAuthenticationWithJWKS, and apply it again after the
+// rotation, to have the proxies pick up the new keys right away.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the provider is deployed in.
+	Namespace() namespace.Instance
+
+	// Issuer is the iss claim of the minted tokens, which is also the in-cluster URL of the provider.
+	Issuer() string
+
+	// JWKSURI is the in-cluster URL of the JWKS.
+	JWKSURI() string
+
+	// Token mints a token with the given claims, signed by the current signing key. The iss, iat and exp claims are
+	// added unless set.
+	Token(claims map[string]interface{}) (string, error)
+
+	// TokenOrFail calls Token and fails the test if an error is returned.
+	TokenOrFail(t test.Failer, claims map[string]interface{}) string
+
+	// RotateKeys generates a new signing key. If keepPrevious is set, the previous keys stay in the JWKS, so that
+	// tokens they signed remain valid; otherwise only the new key is published.
+	RotateKeys(keepPrevious bool) (oidcServer.RotateResponse, error)
+
+	// RotateKeysOrFail calls RotateKeys and fails the test if an error is returned.
+	RotateKeysOrFail(t test.Failer, keepPrevious bool) oidcServer.RotateResponse
+
+	// JWKS returns the current JWKS of the provider, as JSON.
+	JWKS() (string, error)
+
+	// Stats returns the number of discovery and JWKS requests received by the provider.
+	Stats() (oidcServer.Stats, error)
+
+	// WaitForJWKSFetch waits until the JWKS has been fetched more often than in the given stats, for example
+	// by istiod resolving a new RequestAuthentication.
+	WaitForJWKSFetch(since oidcServer.Stats, opts ...retry.Option) error
+
+	// RequestAuthentication returns a RequestAuthentication, with the given name, that has the workloads matching
+	// selector accept tokens of this provider, with the keys fetched from JWKSURI.
+	RequestAuthentication(name string, selector map[string]string) string
+
+	// RequestAuthenticationWithJWKS is the same as RequestAuthentication, with the current keys of the provider
+	// inlined instead.
+	RequestAuthenticationWithJWKS(name string, selector map[string]string) (string, error)
+}
+
+// Config for the OIDC component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+}
+
+// New deploys a mock OIDC provider and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("oidc.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -17,6 +17,7 @@ package opentelemetry
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/test/framework/components/istio"
@@ -25,15 +26,33 @@ import (
 )
 
 type otel struct {
-	id      resource.ID
-	cluster resource.Cluster
-	close   func()
+	id        resource.ID
+	cluster   resource.Cluster
+	namespace string
+	pod       string
+	close     func()
 }
 
 const (
 	appName = "opentelemetry-collector"
+
+	otlpGRPCPort = 4317
+	otlpHTTPPort = 4318
+	// openCensusPort is the port of the OpenCensus receiver, which the proxies report spans to.
+	openCensusPort = 55678
+
+	// readerContainer is the collector pod's container used to read exported data.
+	readerContainer = "reader"
+
+	tracesFile  = "/data/traces.json"
+	metricsFile = "/data/metrics.json"
+	logsFile    = "/data/logs.json"
+
+	serviceNameAttribute = "service.name"
 )
 
+var _ Instance = &otel{}
+
 func getYaml() (string, error) {
 	b, err := ioutil.ReadFile(env.OtelCollectorInstallFilePath)
 	if err != nil {
@@ -62,7 +81,7 @@ func newCollector(ctx resource.Context, c Config) (*otel, error) {
 	o := &otel{
 		cluster: ctx.Clusters().GetOrDefault(c.Cluster),
 	}
-	ctx.TrackResource(o)
+	o.id = ctx.TrackResource(o)
 
 	istioCfg, err := istio.DefaultConfig(ctx)
 	if err != nil {
@@ -70,6 +89,7 @@ func newCollector(ctx resource.Context, c Config) (*otel, error) {
 	}
 
 	ns := istioCfg.TelemetryNamespace
+	o.namespace = ns
 	if err := install(ctx, ns); err != nil {
 		return nil, err
 	}
@@ -79,10 +99,11 @@ func newCollector(ctx resource.Context, c Config) (*otel, error) {
 	}
 
 	f := testKube.NewSinglePodFetch(o.cluster, ns, fmt.Sprintf("app=%s", appName))
-	_, err = testKube.WaitUntilPodsAreReady(f)
+	pods, err := testKube.WaitUntilPodsAreReady(f)
 	if err != nil {
 		return nil, err
 	}
+	o.pod = pods[0].Name
 	return o, nil
 }
 
@@ -90,6 +111,59 @@ func (o *otel) ID() resource.ID {
 	return o.id
 }
 
+func (o *otel) host() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", appName, o.namespace)
+}
+
+func (o *otel) OTLPGRPCAddress() string {
+	return fmt.Sprintf("%s:%d", o.host(), otlpGRPCPort)
+}
+
+func (o *otel) OTLPHTTPAddress() string {
+	return fmt.Sprintf("%s:%d", o.host(), otlpHTTPPort)
+}
+
+func (o *otel) OpenCensusAgentAddress() string {
+	return fmt.Sprintf("dns:%s:%d", o.host(), openCensusPort)
+}
+
+// readExported returns the contents of the given file written by the collector's file exporter.
+func (o *otel) readExported(file string) (string, error) {
+	stdout, _, err := o.cluster.PodExec(o.pod, o.namespace, readerContainer, "cat "+file)
+	if err != nil {
+		// The file does not exist until the first export, so treat that as empty.
+		if strings.Contains(err.Error(), "No such file or directory") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed reading %s: %v", file, err)
+	}
+	return stdout, nil
+}
+
+func (o *otel) QuerySpans() ([]Span, error) {
+	data, err := o.readExported(tracesFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseSpans(data)
+}
+
+func (o *otel) QueryMetrics() ([]Metric, error) {
+	data, err := o.readExported(metricsFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(data)
+}
+
+func (o *otel) QueryLogs() ([]LogRecord, error) {
+	data, err := o.readExported(logsFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseLogs(data)
+}
+
 // Close implements io.Closer.
 func (o *otel) Close() error {
 	if o.close != nil {
@@ -30,6 +30,53 @@ type Config struct {
 // Instance represents a opencensus collector deployment on kubernetes.
 type Instance interface {
 	resource.Resource
+
+	// OTLPGRPCAddress returns the in-cluster address of the collector's OTLP gRPC receiver.
+	OTLPGRPCAddress() string
+
+	// OTLPHTTPAddress returns the in-cluster address of the collector's OTLP HTTP receiver.
+	OTLPHTTPAddress() string
+
+	// OpenCensusAgentAddress returns the address of the collector's OpenCensus receiver, in the form expected
+	// by meshConfig.defaultConfig.tracing.openCensusAgent.address. The proxies report spans to it when Istio is
+	// deployed with the values.global.proxy.tracer=openCensusAgent setting.
+	OpenCensusAgentAddress() string
+
+	// QuerySpans returns all of the spans received by the collector so far.
+	QuerySpans() ([]Span, error)
+
+	// QueryMetrics returns all of the metrics received by the collector so far.
+	QueryMetrics() ([]Metric, error)
+
+	// QueryLogs returns all of the log records received by the collector so far.
+	QueryLogs() ([]LogRecord, error)
+}
+
+// Span is a single span received by the collector.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	// ServiceName is taken from the "service.name" attribute of the span's resource.
+	ServiceName string
+	Attributes  map[string]string
+}
+
+// Metric is a single metric received by the collector. Data points are not retained.
+type Metric struct {
+	Name string
+	Unit string
+	// ServiceName is taken from the "service.name" attribute of the metric's resource.
+	ServiceName string
+}
+
+// LogRecord is a single log record received by the collector.
+type LogRecord struct {
+	Body string
+	// ServiceName is taken from the "service.name" attribute of the record's resource.
+	ServiceName string
+	Attributes  map[string]string
 }
 
 // New creates and returns a new instance of otel.
@@ -0,0 +1,212 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The collector's file exporter writes one OTLP JSON export request per line. The types below only
+// model the fields the tests care about. Both the older "instrumentationLibrary*" and the newer
+// "scope*" field names are accepted, since they differ across collector versions.
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+	IntValue    *json.Number `json:"intValue,omitempty"`
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+}
+
+func (v otlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return v.IntValue.String()
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+	}
+	return ""
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+}
+
+type otlpSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []struct {
+		Resource                    otlpResource `json:"resource"`
+		InstrumentationLibrarySpans []otlpSpans  `json:"instrumentationLibrarySpans"`
+		ScopeSpans                  []otlpSpans  `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpMetric struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+type otlpMetrics struct {
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetricsData struct {
+	ResourceMetrics []struct {
+		Resource                      otlpResource  `json:"resource"`
+		InstrumentationLibraryMetrics []otlpMetrics `json:"instrumentationLibraryMetrics"`
+		ScopeMetrics                  []otlpMetrics `json:"scopeMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpLogRecord struct {
+	Body       otlpAnyValue   `json:"body"`
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpLogs struct {
+	Logs       []otlpLogRecord `json:"logs"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpLogsData struct {
+	ResourceLogs []struct {
+		Resource                   otlpResource `json:"resource"`
+		InstrumentationLibraryLogs []otlpLogs   `json:"instrumentationLibraryLogs"`
+		ScopeLogs                  []otlpLogs   `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+func toMap(kvs []otlpKeyValue) map[string]string {
+	out := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		out[kv.Key] = kv.Value.String()
+	}
+	return out
+}
+
+// forEachLine invokes fn with each non-empty line of the exported data.
+func forEachLine(data string, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	// Export requests can be large; allow lines up to 16MB.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseSpans(data string) ([]Span, error) {
+	var out []Span
+	err := forEachLine(data, func(line []byte) error {
+		var t otlpTraces
+		if err := json.Unmarshal(line, &t); err != nil {
+			return fmt.Errorf("failed parsing exported traces: %v", err)
+		}
+		for _, rs := range t.ResourceSpans {
+			res := toMap(rs.Resource.Attributes)
+			for _, ss := range append(rs.InstrumentationLibrarySpans, rs.ScopeSpans...) {
+				for _, s := range ss.Spans {
+					out = append(out, Span{
+						TraceID:      s.TraceID,
+						SpanID:       s.SpanID,
+						ParentSpanID: s.ParentSpanID,
+						Name:         s.Name,
+						ServiceName:  res[serviceNameAttribute],
+						Attributes:   toMap(s.Attributes),
+					})
+				}
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+func parseMetrics(data string) ([]Metric, error) {
+	var out []Metric
+	err := forEachLine(data, func(line []byte) error {
+		var m otlpMetricsData
+		if err := json.Unmarshal(line, &m); err != nil {
+			return fmt.Errorf("failed parsing exported metrics: %v", err)
+		}
+		for _, rm := range m.ResourceMetrics {
+			res := toMap(rm.Resource.Attributes)
+			for _, sm := range append(rm.InstrumentationLibraryMetrics, rm.ScopeMetrics...) {
+				for _, metric := range sm.Metrics {
+					out = append(out, Metric{
+						Name:        metric.Name,
+						Unit:        metric.Unit,
+						ServiceName: res[serviceNameAttribute],
+					})
+				}
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+func parseLogs(data string) ([]LogRecord, error) {
+	var out []LogRecord
+	err := forEachLine(data, func(line []byte) error {
+		var l otlpLogsData
+		if err := json.Unmarshal(line, &l); err != nil {
+			return fmt.Errorf("failed parsing exported logs: %v", err)
+		}
+		for _, rl := range l.ResourceLogs {
+			res := toMap(rl.Resource.Attributes)
+			for _, sl := range append(rl.InstrumentationLibraryLogs, rl.ScopeLogs...) {
+				for _, r := range append(sl.Logs, sl.LogRecords...) {
+					out = append(out, LogRecord{
+						Body:        r.Body.String(),
+						ServiceName: res[serviceNameAttribute],
+						Attributes:  toMap(r.Attributes),
+					})
+				}
+			}
+		}
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,88 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpans(t *testing.T) {
+	data := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"a.ns"}}]},` +
+		`"instrumentationLibrarySpans":[{"spans":[{"traceId":"t1","spanId":"s1","name":"a.ns:80/*",` +
+		`"attributes":[{"key":"http.status_code","value":{"intValue":"200"}}]}]}]}]}
+
+{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"b.ns"}}]},` +
+		`"scopeSpans":[{"spans":[{"traceId":"t1","spanId":"s2","parentSpanId":"s1","name":"b.ns:80/*",` +
+		`"attributes":[{"key":"upstream","value":{"boolValue":true}}]}]}]}]}
+`
+	got, err := parseSpans(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Span{
+		{
+			TraceID:     "t1",
+			SpanID:      "s1",
+			Name:        "a.ns:80/*",
+			ServiceName: "a.ns",
+			Attributes:  map[string]string{"http.status_code": "200"},
+		},
+		{
+			TraceID:      "t1",
+			SpanID:       "s2",
+			ParentSpanID: "s1",
+			Name:         "b.ns:80/*",
+			ServiceName:  "b.ns",
+			Attributes:   map[string]string{"upstream": "true"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	data := `{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"a.ns"}}]},` +
+		`"instrumentationLibraryMetrics":[{"metrics":[{"name":"istio_requests_total","unit":"1"}]}]}]}`
+	got, err := parseMetrics(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Metric{{Name: "istio_requests_total", Unit: "1", ServiceName: "a.ns"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLogs(t *testing.T) {
+	data := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"a.ns"}}]},` +
+		`"instrumentationLibraryLogs":[{"logs":[{"body":{"stringValue":"GET /"},` +
+		`"attributes":[{"key":"response_code","value":{"doubleValue":200}}]}]}]}]}`
+	got, err := parseLogs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LogRecord{{Body: "GET /", ServiceName: "a.ns", Attributes: map[string]string{"response_code": "200"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := parseSpans("not json"); err == nil {
+		t.Fatal("expected error parsing invalid data")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	proxyContainer = "istio-proxy"
+	// envoyProfilePath is the admin profile_path of the sidecar bootstrap, which is on a writable volume.
+	envoyProfilePath = "/var/lib/istio/data/envoy.prof"
+)
+
+// podFromNodeID returns the name and namespace of the pod of a sidecar with the given node ID, which has the
+// form "sidecar~<ip>~<pod>.<namespace>~<namespace>.svc.cluster.local".
+func podFromNodeID(nodeID string) (name, namespace string, err error) {
+	parts := strings.Split(nodeID, "~")
+	if len(parts) != 4 {
+		return "", "", fmt.Errorf("invalid node ID %q", nodeID)
+	}
+	i := strings.LastIndex(parts[2], ".")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid node ID %q: no pod namespace", nodeID)
+	}
+	return parts[2][:i], parts[2][i+1:], nil
+}
+
+// latestHeapProfile returns the name of the most recent heap profile dump in the output of ls. Envoy numbers
+// the dumps with a sequence number, as in envoy.prof.0001.heap.
+func latestHeapProfile(ls string) (string, error) {
+	prefix := path.Base(envoyProfilePath) + "."
+	var dumps []string
+	for _, f := range strings.Fields(ls) {
+		if strings.HasPrefix(f, prefix) && strings.HasSuffix(f, ".heap") {
+			dumps = append(dumps, f)
+		}
+	}
+	if len(dumps) == 0 {
+		return "", fmt.Errorf("no heap profile found in %s", path.Dir(envoyProfilePath))
+	}
+	sort.Strings(dumps)
+	return dumps[len(dumps)-1], nil
+}
+
+// envoyProfile collects a profile of the given kind from a sidecar. Envoy profiles are collected over the given
+// duration: the heap profile only covers the allocations made while profiling.
+func envoyProfile(cluster resource.Cluster, pod, ns string, kind Kind, duration time.Duration) ([]byte, error) {
+	exec := func(cmd string) (string, error) {
+		stdout, stderr, err := cluster.PodExec(pod, ns, proxyContainer, cmd)
+		if err != nil {
+			return "", fmt.Errorf("%q failed on %s/%s: %v: %s", cmd, ns, pod, err, stderr)
+		}
+		return stdout, nil
+	}
+	endpoint := "cpuprofiler"
+	if kind == Heap {
+		endpoint = "heapprofiler"
+	}
+	if _, err := exec(fmt.Sprintf("pilot-agent request POST %s?enable=y", endpoint)); err != nil {
+		return nil, err
+	}
+	time.Sleep(duration)
+	if _, err := exec(fmt.Sprintf("pilot-agent request POST %s?enable=n", endpoint)); err != nil {
+		return nil, err
+	}
+
+	file := envoyProfilePath
+	if kind == Heap {
+		ls, err := exec("ls " + path.Dir(envoyProfilePath))
+		if err != nil {
+			return nil, err
+		}
+		name, err := latestHeapProfile(ls)
+		if err != nil {
+			return nil, err
+		}
+		file = path.Join(path.Dir(envoyProfilePath), name)
+	}
+	out, err := exec("cat " + file)
+	if err != nil {
+		return nil, err
+	}
+	// Remove the profile so that it is not mistaken for the next one.
+	_, _ = exec("rm -f " + file)
+	return []byte(out), nil
+}
@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// istiodDebugPort is the port istiod serves its debug endpoints on, including pprof.
+const istiodDebugPort = 8080
+
+// istiodProfilePath returns the pprof path of the profile of the given kind.
+func istiodProfilePath(kind Kind, duration time.Duration) string {
+	if kind == CPU {
+		return fmt.Sprintf("/debug/pprof/profile?seconds=%d", int(duration.Seconds()))
+	}
+	return "/debug/pprof/heap"
+}
+
+// istiodProfile collects a profile of the given kind from an istiod pod, through a port forward to its debug port.
+func istiodProfile(cluster resource.Cluster, pod, ns string, kind Kind, duration time.Duration) ([]byte, error) {
+	forwarder, err := cluster.NewPortForwarder(pod, ns, "", 0, istiodDebugPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := forwarder.Start(); err != nil {
+		return nil, err
+	}
+	defer forwarder.Close()
+
+	client := http.Client{
+		Timeout: duration + 30*time.Second,
+	}
+	resp, err := client.Get(fmt.Sprintf("http://%s%s", forwarder.Address(), istiodProfilePath(kind, duration)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("istiod %s/%s returned non-ok status for %s profile: %v", ns, pod, kind, resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
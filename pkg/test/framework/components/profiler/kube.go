@@ -0,0 +1,195 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+// unsafeChars matches the characters of capture labels that are replaced in file names.
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// target is a pod profiles are collected from.
+type target struct {
+	pod     string
+	ns      string
+	collect func(cluster resource.Cluster, pod, ns string, kind Kind, duration time.Duration) ([]byte, error)
+}
+
+type kubeComponent struct {
+	id          resource.ID
+	cfg         Config
+	cluster     resource.Cluster
+	istioNs     string
+	sidecars    []target
+	dir         string
+	captureLock sync.Mutex
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cfg:     cfg,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+	}
+	c.id = ctx.TrackResource(c)
+
+	if cfg.Istiod {
+		icfg, err := istio.DefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.istioNs = icfg.SystemNamespace
+	}
+	for _, w := range cfg.Sidecars {
+		if w.Sidecar() == nil {
+			return nil, fmt.Errorf("workload %s has no sidecar", w.Address())
+		}
+		pod, ns, err := podFromNodeID(w.Sidecar().NodeID())
+		if err != nil {
+			return nil, err
+		}
+		c.sidecars = append(c.sidecars, target{pod: pod, ns: ns, collect: envoyProfile})
+	}
+
+	var err error
+	if c.dir, err = ctx.CreateTmpDirectory("profiles"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Dir() string {
+	return c.dir
+}
+
+// targets returns the pods to profile. The istiod pods are looked up on every capture, as they may have been
+// replaced since the profiler was created.
+func (c *kubeComponent) targets() ([]target, error) {
+	out := append([]target{}, c.sidecars...)
+	if c.istioNs == "" {
+		return out, nil
+	}
+	pods, err := c.cluster.PodsForSelector(context.TODO(), c.istioNs, "app=istiod")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing istiod pods: %v", err)
+	}
+	for _, p := range pods.Items {
+		out = append(out, target{pod: p.Name, ns: p.Namespace, collect: istiodProfile})
+	}
+	return out, nil
+}
+
+func (c *kubeComponent) Capture(label string) ([]string, error) {
+	// Envoy only runs one profiler of each kind at a time.
+	c.captureLock.Lock()
+	defer c.captureLock.Unlock()
+
+	targets, err := c.targets()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu    sync.Mutex
+		files []string
+		errs  error
+		wg    sync.WaitGroup
+	)
+	for _, t := range targets {
+		for _, kind := range c.cfg.Kinds {
+			t, kind := t, kind
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				file, err := c.capture(label, t, kind)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("%s profile of %s/%s: %v", kind, t.ns, t.pod, err))
+					return
+				}
+				files = append(files, file)
+			}()
+		}
+	}
+	wg.Wait()
+	return files, errs
+}
+
+func (c *kubeComponent) capture(label string, t target, kind Kind) (string, error) {
+	profile, err := t.collect(c.cluster, t.pod, t.ns, kind, c.cfg.CPUDuration)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%s-%s.pprof", unsafeChars.ReplaceAllString(label, "-"), t.pod, kind)
+	file := filepath.Join(c.dir, name)
+	if err := ioutil.WriteFile(file, profile, 0644); err != nil {
+		return "", err
+	}
+	scopes.Framework.Infof("wrote %s profile of %s/%s to %s", kind, t.ns, t.pod, file)
+	return file, nil
+}
+
+func (c *kubeComponent) CaptureOrFail(t test.Failer, label string) []string {
+	t.Helper()
+	files, err := c.Capture(label)
+	if err != nil {
+		t.Fatalf("profiler.CaptureOrFail: %v", err)
+	}
+	return files
+}
+
+func (c *kubeComponent) CaptureIfSlow(label string, threshold time.Duration, fn func()) {
+	captured := make(chan struct{})
+	timer := time.AfterFunc(threshold, func() {
+		defer close(captured)
+		scopes.Framework.Infof("%s is still running after %v, capturing profiles", label, threshold)
+		if _, err := c.Capture(label); err != nil {
+			scopes.Framework.Warnf("failed capturing profiles for %s: %v", label, err)
+		}
+	})
+	fn()
+	if !timer.Stop() {
+		// The threshold was reached: wait for the profiles to be written.
+		<-captured
+	}
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Kind of profile.
+type Kind string
+
+const (
+	CPU  Kind = "cpu"
+	Heap Kind = "heap"
+)
+
+// DefaultCPUDuration is the time CPU profiles are sampled for, if not configured.
+const DefaultCPUDuration = 10 * time.Second
+
+// Instance captures profiles from istiod and sidecars, and writes them to the working directory of the context
+// it was created in. Profiles are named after the capture label, the pod and the kind, for example
+// "slow-push_istiod-5d8f7-cpu.pprof".
+type Instance interface {
+	resource.Resource
+
+	// Dir is the directory profiles are written to.
+	Dir() string
+
+	// Capture profiles from all the targets in parallel, and returns the paths of the written files. The call
+	// blocks while CPU profiles are sampled.
+	Capture(label string) ([]string, error)
+
+	// CaptureOrFail calls Capture and fails the test if an error is returned.
+	CaptureOrFail(t test.Failer, label string) []string
+
+	// CaptureIfSlow runs fn, and captures profiles labeled with the given label while fn is still running after the
+	// threshold. This profiles the code that is slow, rather than what happens once it is done. Capture errors
+	// are logged, as they are secondary to the outcome of fn.
+	CaptureIfSlow(label string, threshold time.Duration, fn func())
+}
+
+// Config for the profiler.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Kinds of profiles to capture. Defaults to CPU and Heap.
+	Kinds []Kind
+
+	// CPUDuration is the time CPU profiles are sampled for. Defaults to DefaultCPUDuration.
+	CPUDuration time.Duration
+
+	// Istiod enables profiling of the istiod pods.
+	Istiod bool
+
+	// Sidecars to profile, through the Envoy admin API. Envoy profiles cover the CPUDuration for both kinds,
+	// as its heap profiler only tracks the allocations made while it runs.
+	Sidecars []echo.Workload
+}
+
+// New returns a profiler of the configured targets.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	if len(c.Kinds) == 0 {
+		c.Kinds = []Kind{CPU, Heap}
+	}
+	if c.CPUDuration == 0 {
+		c.CPUDuration = DefaultCPUDuration
+	}
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("profiler.NewOrFail: %v", err)
+	}
+	return i
+}
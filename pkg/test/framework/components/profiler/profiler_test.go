@@ -0,0 +1,57 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPodFromNodeID(t *testing.T) {
+	pod, ns, err := podFromNodeID("sidecar~10.1.2.3~a-v1-5d8f7b-x2x.echo-1-23~echo-1-23.svc.cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod != "a-v1-5d8f7b-x2x" || ns != "echo-1-23" {
+		t.Fatalf("unexpected pod %s/%s", ns, pod)
+	}
+	for _, id := range []string{"sidecar~10.1.2.3~pod", "sidecar~10.1.2.3~pod~ns.svc.cluster.local"} {
+		if _, _, err := podFromNodeID(id); err == nil {
+			t.Errorf("expected error for %q", id)
+		}
+	}
+}
+
+func TestLatestHeapProfile(t *testing.T) {
+	got, err := latestHeapProfile("envoy-rev0.json\nenvoy.prof.0001.heap\nenvoy.prof.0003.heap\nenvoy.prof.0002.heap\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "envoy.prof.0003.heap" {
+		t.Fatalf("unexpected heap profile %s", got)
+	}
+	if _, err := latestHeapProfile("envoy-rev0.json\nenvoy.prof\n"); err == nil {
+		t.Fatal("expected error without heap profile")
+	}
+}
+
+func TestIstiodProfilePath(t *testing.T) {
+	if got := istiodProfilePath(CPU, 15*time.Second); got != "/debug/pprof/profile?seconds=15" {
+		t.Fatalf("unexpected cpu profile path %s", got)
+	}
+	if got := istiodProfilePath(Heap, 15*time.Second); got != "/debug/pprof/heap" {
+		t.Fatalf("unexpected heap profile path %s", got)
+	}
+}
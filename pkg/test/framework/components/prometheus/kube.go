@@ -300,6 +300,87 @@ func (c *kubeComponent) SumOrFail(t test.Failer, val model.Value, labels map[str
 	return v
 }
 
+func (c *kubeComponent) QuerySum(q Query) (float64, error) {
+	query := q.Sum()
+	v, _, err := c.api.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("error querying Prometheus (query: %q): %v", query, err)
+	}
+	if v.Type() != model.ValVector {
+		return 0, fmt.Errorf("value not a model.Vector; was %s (query: %q)", v.Type().String(), query)
+	}
+	sum := 0.0
+	for _, sample := range v.(model.Vector) {
+		sum += float64(sample.Value)
+	}
+	return sum, nil
+}
+
+func (c *kubeComponent) WaitForMetric(q Query) (float64, error) {
+	value, err := retry.Do(func() (interface{}, bool, error) {
+		v, _, err := c.api.Query(context.Background(), q.String(), time.Now())
+		if err != nil {
+			return nil, false, fmt.Errorf("error querying Prometheus: %v", err)
+		}
+		scopes.Framework.Debugf("WaitForMetric received: %v", v)
+		if v.Type() != model.ValVector {
+			return nil, true, fmt.Errorf("value not a model.Vector; was %s", v.Type().String())
+		}
+		vec := v.(model.Vector)
+		if len(vec) == 0 {
+			return nil, false, fmt.Errorf("no series found (query: %q)", q)
+		}
+		sum := 0.0
+		for _, sample := range vec {
+			sum += float64(sample.Value)
+		}
+		return sum, true, nil
+	}, retryTimeout, retryDelay)
+	if err != nil {
+		return 0, err
+	}
+	return value.(float64), nil
+}
+
+func (c *kubeComponent) WaitForMetricOrFail(t test.Failer, q Query) float64 {
+	t.Helper()
+	v, err := c.WaitForMetric(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (c *kubeComponent) WaitForMetricDelta(q Query, delta float64, fn func() error) error {
+	baseline, err := c.QuerySum(q)
+	if err != nil {
+		return err
+	}
+	scopes.Framework.Debugf("WaitForMetricDelta baseline for %q: %v", q, baseline)
+	if fn != nil {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return retry.UntilSuccess(func() error {
+		current, err := c.QuerySum(q)
+		if err != nil {
+			return err
+		}
+		if current-baseline < delta {
+			return fmt.Errorf("expected %q to increase by at least %v from %v, got %v", q, delta, baseline, current)
+		}
+		return nil
+	}, retryTimeout, retryDelay)
+}
+
+func (c *kubeComponent) WaitForMetricDeltaOrFail(t test.Failer, q Query, delta float64, fn func() error) {
+	t.Helper()
+	if err := c.WaitForMetricDelta(q, delta, fn); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // Close implements io.Closer.
 func (c *kubeComponent) Close() error {
 	c.forwarder.Close()
@@ -39,6 +39,19 @@ type Instance interface {
 	// Sum all the samples that has the given labels in the given vector value.
 	Sum(val prom.Value, labels map[string]string) (float64, error)
 	SumOrFail(t test.Failer, val prom.Value, labels map[string]string) float64
+
+	// QuerySum returns the sum of all the series matched by the query. If there are none, 0 is returned.
+	QuerySum(q Query) (float64, error)
+
+	// WaitForMetric polls until the query matches at least one series, and returns their sum.
+	WaitForMetric(q Query) (float64, error)
+	WaitForMetricOrFail(t test.Failer, q Query) float64
+
+	// WaitForMetricDelta records the current value of the query, runs fn, and then polls until the value
+	// has increased by at least delta. This avoids depending on absolute values, which can be affected by
+	// traffic sent by previous tests.
+	WaitForMetricDelta(q Query, delta float64, fn func() error) error
+	WaitForMetricDeltaOrFail(t test.Failer, q Query, delta float64, fn func() error)
 }
 
 type Config struct {
@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Standard Istio metric names.
+const (
+	RequestsTotal         = "istio_requests_total"
+	RequestDurationMillis = "istio_request_duration_milliseconds"
+	RequestBytes          = "istio_request_bytes"
+	ResponseBytes         = "istio_response_bytes"
+	TCPConnectionsOpened  = "istio_tcp_connections_opened_total"
+	TCPConnectionsClosed  = "istio_tcp_connections_closed_total"
+	TCPSentBytesTotal     = "istio_tcp_sent_bytes_total"
+	TCPReceivedBytesTotal = "istio_tcp_received_bytes_total"
+)
+
+// Values for the "reporter" label.
+const (
+	ReporterSource      = "source"
+	ReporterDestination = "destination"
+)
+
+const (
+	sourceWorkload         = "source_workload"
+	sourceWorkloadNs       = "source_workload_namespace"
+	destinationWorkload    = "destination_workload"
+	destinationWorkloadNs  = "destination_workload_namespace"
+	destinationServiceName = "destination_service_name"
+	destinationServiceNs   = "destination_service_namespace"
+)
+
+// Query is a typed builder for a PromQL instant vector selector, e.g.
+//
+//	prometheus.Requests().Reporter(prometheus.ReporterSource).Destination("b", ns).ResponseCode("200")
+//
+// Queries are immutable; every method returns a modified copy.
+type Query struct {
+	Metric string
+	Labels map[string]string
+}
+
+// NewQuery returns a query selecting the given metric.
+func NewQuery(metric string) Query {
+	return Query{Metric: metric}
+}
+
+// Requests returns a query for istio_requests_total.
+func Requests() Query {
+	return NewQuery(RequestsTotal)
+}
+
+// TCPConnections returns a query for istio_tcp_connections_opened_total.
+func TCPConnections() Query {
+	return NewQuery(TCPConnectionsOpened)
+}
+
+// WithLabel returns a copy of the query that also matches the given label value.
+func (q Query) WithLabel(name, value string) Query {
+	labels := make(map[string]string, len(q.Labels)+1)
+	for k, v := range q.Labels {
+		labels[k] = v
+	}
+	labels[name] = value
+	return Query{Metric: q.Metric, Labels: labels}
+}
+
+// Reporter restricts the query to metrics reported by the source or destination proxy.
+func (q Query) Reporter(reporter string) Query {
+	return q.WithLabel("reporter", reporter)
+}
+
+// Source restricts the query to traffic sent by the given workload.
+func (q Query) Source(workload, namespace string) Query {
+	return q.WithLabel(sourceWorkload, workload).WithLabel(sourceWorkloadNs, namespace)
+}
+
+// Destination restricts the query to traffic received by the given workload.
+func (q Query) Destination(workload, namespace string) Query {
+	return q.WithLabel(destinationWorkload, workload).WithLabel(destinationWorkloadNs, namespace)
+}
+
+// DestinationService restricts the query to traffic addressed to the given service.
+func (q Query) DestinationService(name, namespace string) Query {
+	return q.WithLabel(destinationServiceName, name).WithLabel(destinationServiceNs, namespace)
+}
+
+// ResponseCode restricts the query to requests with the given response code.
+func (q Query) ResponseCode(code string) Query {
+	return q.WithLabel("response_code", code)
+}
+
+// String returns the PromQL selector for this query.
+func (q Query) String() string {
+	if len(q.Labels) == 0 {
+		return q.Metric
+	}
+	keys := make([]string, 0, len(q.Labels))
+	for k := range q.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	matchers := make([]string, 0, len(keys))
+	for _, k := range keys {
+		matchers = append(matchers, k+"="+strconv.Quote(q.Labels[k]))
+	}
+	return q.Metric + "{" + strings.Join(matchers, ",") + "}"
+}
+
+// Sum returns the PromQL expression summing all series matched by this query.
+func (q Query) Sum() string {
+	return "sum(" + q.String() + ")"
+}
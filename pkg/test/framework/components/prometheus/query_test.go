@@ -0,0 +1,57 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{
+			name: "metric only",
+			q:    Requests(),
+			want: `istio_requests_total`,
+		},
+		{
+			name: "labels are sorted",
+			q:    Requests().Reporter(ReporterSource).Source("a", "ns").Destination("b", "ns").ResponseCode("200"),
+			want: `istio_requests_total{destination_workload="b",destination_workload_namespace="ns",` +
+				`reporter="source",response_code="200",source_workload="a",source_workload_namespace="ns"}`,
+		},
+		{
+			name: "values are escaped",
+			q:    NewQuery("m").WithLabel("l", `a"b`),
+			want: `m{l="a\"b"}`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.String(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryImmutable(t *testing.T) {
+	base := Requests().Reporter(ReporterSource)
+	_ = base.ResponseCode("200")
+	if got, want := base.Sum(), `sum(istio_requests_total{reporter="source"})`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+// Filter selects the proxies and requests to rate limit.
+type Filter struct {
+	// Name of the EnvoyFilter.
+	Name string
+
+	// Selector of the workloads whose proxies rate limit requests. If empty, all the proxies in the namespace
+	// the EnvoyFilter is applied to are selected.
+	Selector map[string]string
+
+	// Context of the patched listeners: SIDECAR_OUTBOUND, SIDECAR_INBOUND or GATEWAY. Defaults to
+	// SIDECAR_OUTBOUND.
+	Context string
+
+	// VirtualHost is the name of the virtual host, in the route configuration of the proxies, whose requests
+	// are rate limited. For example, outbound sidecar virtual hosts are named after the service and port,
+	// as in "svc.ns.svc.cluster.local:80".
+	VirtualHost string
+
+	// Actions producing the descriptor entries of each request, in order.
+	Actions []Action
+
+	// FailureModeAllow lets requests through when the rate limit service can't be reached. By default they
+	// are rejected, so that a misconfiguration does not go unnoticed.
+	FailureModeAllow bool
+}
+
+// Action produces a descriptor entry for a request.
+type Action struct {
+	// Header produces an entry with DescriptorKey as key and the value of this request header, for example
+	// ":path". Requests without the header are not rate limited.
+	Header        string
+	DescriptorKey string
+
+	// GenericKey produces an entry with the "generic_key" key and this value. It is used if Header is empty.
+	GenericKey string
+}
+
+const filterTemplate = `apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: {{ .Name }}
+spec:
+{{- if .Selector }}
+  workloadSelector:
+    labels:
+{{- range $k, $v := .Selector }}
+      {{ $k }}: {{ printf "%q" $v }}
+{{- end }}
+{{- end }}
+  configPatches:
+  - applyTo: HTTP_FILTER
+    match:
+      context: {{ .Context }}
+      listener:
+        filterChain:
+          filter:
+            name: envoy.filters.network.http_connection_manager
+            subFilter:
+              name: envoy.filters.http.router
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: envoy.filters.http.ratelimit
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.http.ratelimit.v3.RateLimit
+          domain: {{ .Domain }}
+          failure_mode_deny: {{ not .FailureModeAllow }}
+          rate_limit_service:
+            grpc_service:
+              envoy_grpc:
+                cluster_name: {{ .Cluster }}
+              timeout: 10s
+  - applyTo: VIRTUAL_HOST
+    match:
+      context: {{ .Context }}
+      routeConfiguration:
+        vhost:
+          name: {{ printf "%q" .VirtualHost }}
+          route:
+            action: ANY
+    patch:
+      operation: MERGE
+      value:
+        rate_limits:
+        - actions:
+{{- range .Actions }}
+{{- if .Header }}
+          - request_headers:
+              header_name: {{ printf "%q" .Header }}
+              descriptor_key: {{ printf "%q" .DescriptorKey }}
+{{- else }}
+          - generic_key:
+              descriptor_value: {{ printf "%q" .GenericKey }}
+{{- end }}
+{{- end }}
+`
+
+// envoyFilter renders the EnvoyFilter for the filter, rate limiting requests in the given domain with the
+// service behind the given Envoy cluster.
+func envoyFilter(f Filter, domain, cluster string) (string, error) {
+	if f.Name == "" {
+		return "", fmt.Errorf("rate limit filter requires a name")
+	}
+	if f.VirtualHost == "" {
+		return "", fmt.Errorf("rate limit filter %s requires a virtual host", f.Name)
+	}
+	if len(f.Actions) == 0 {
+		return "", fmt.Errorf("rate limit filter %s requires at least one action", f.Name)
+	}
+	if f.Context == "" {
+		f.Context = "SIDECAR_OUTBOUND"
+	}
+	return tmpl.Evaluate(filterTemplate, struct {
+		Filter
+		Domain  string
+		Cluster string
+	}{f, domain, cluster})
+}
+
+// ValidateRateLimited returns a Validator that checks that exactly allowed responses were successful, and
+// all the others were rejected by the rate limit with 429. The requests must all fall into the same rate
+// limit period, so limits per hour or day are the least prone to flakes.
+func ValidateRateLimited(allowed int) echo.Validator {
+	return func(responses client.ParsedResponses) error {
+		ok, limited := 0, 0
+		for i, r := range responses {
+			switch r.Code {
+			case response.StatusCodeOK:
+				ok++
+			case response.StatusCodeTooManyRequests:
+				limited++
+			default:
+				return fmt.Errorf("response[%d]: unexpected status code %s", i, r.Code)
+			}
+		}
+		if ok != allowed {
+			return fmt.Errorf("expected %d of %d requests to be allowed, got %d (%d rate limited)",
+				allowed, len(responses), ok, limited)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "ratelimit"
+	httpPort    = 8080
+	grpcPort    = 8081
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id      resource.ID
+	ns      namespace.Instance
+	domain  string
+	cluster resource.Cluster
+	ctx     resource.Context
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		domain:  cfg.Domain,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		ctx:     ctx,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-ratelimit",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.deploy(cfg.Descriptors); err != nil {
+		return nil, err
+	}
+	if _, err := testKube.WaitUntilPodsAreReady(testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app=redis")); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// serviceConfig returns the configuration file of the rate limit service.
+func serviceConfig(domain string, descriptors []Descriptor) (string, error) {
+	out, err := yaml.Marshal(struct {
+		Domain      string       `json:"domain"`
+		Descriptors []Descriptor `json:"descriptors"`
+	}{domain, descriptors})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// deploy applies the deployment with the given descriptors and waits for the rate limit service to be
+// ready with them. The hash of the configuration is part of the pod template, so that changing it restarts
+// the service.
+func (c *kubeComponent) deploy(descriptors []Descriptor) error {
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return err
+	}
+	config, err := serviceConfig(c.domain, descriptors)
+	if err != nil {
+		return err
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(config)))[:16]
+
+	tpl, err := ioutil.ReadFile(env.RateLimitInstallFilePath)
+	if err != nil {
+		return err
+	}
+	manifest, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"ImagePullPolicy": s.PullPolicy,
+		"Config":          config,
+		"ConfigHash":      hash,
+		"HTTPPort":        httpPort,
+		"GRPCPort":        grpcPort,
+	})
+	if err != nil {
+		return err
+	}
+	// The deployments are removed along with the namespace.
+	if err := c.ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), manifest); err != nil {
+		return err
+	}
+	fetch := testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+serviceName, "config-hash="+hash)
+	_, err = testKube.WaitUntilPodsAreReady(fetch)
+	return err
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Domain() string {
+	return c.domain
+}
+
+func (c *kubeComponent) host() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, c.ns.Name())
+}
+
+func (c *kubeComponent) GRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.host(), grpcPort)
+}
+
+func (c *kubeComponent) SetDescriptors(descriptors []Descriptor) error {
+	return c.deploy(descriptors)
+}
+
+func (c *kubeComponent) SetDescriptorsOrFail(t test.Failer, descriptors []Descriptor) {
+	t.Helper()
+	if err := c.SetDescriptors(descriptors); err != nil {
+		t.Fatalf("ratelimit.SetDescriptorsOrFail: %v", err)
+	}
+}
+
+func (c *kubeComponent) EnvoyFilter(f Filter) (string, error) {
+	return envoyFilter(f, c.domain, fmt.Sprintf("outbound|%d||%s", grpcPort, c.host()))
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Unit of the period a rate limit applies to.
+type Unit string
+
+const (
+	Second Unit = "second"
+	Minute Unit = "minute"
+	Hour   Unit = "hour"
+	Day    Unit = "day"
+)
+
+// DefaultDomain is the rate limit domain of the service, if none is configured.
+const DefaultDomain = "istio-test"
+
+// RateLimit allows RequestsPerUnit requests per Unit.
+type RateLimit struct {
+	Unit            Unit `json:"unit"`
+	RequestsPerUnit int  `json:"requests_per_unit"`
+}
+
+// Descriptor of the rate limit service configuration. A descriptor without a Value matches every value
+// of the Key, and each distinct value is limited separately.
+type Descriptor struct {
+	Key         string       `json:"key"`
+	Value       string       `json:"value,omitempty"`
+	RateLimit   *RateLimit   `json:"rate_limit,omitempty"`
+	Descriptors []Descriptor `json:"descriptors,omitempty"`
+}
+
+// Instance represents the Envoy rate limit service, backed by Redis, deployed on kube.
+//
+// This version of Istio has no rate limit API, so proxies are pointed at the service with EnvoyFilter.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the service is deployed in.
+	Namespace() namespace.Instance
+
+	// Domain of the rate limit configuration of the service.
+	Domain() string
+
+	// GRPCAddress is the in-cluster host:port of the gRPC rate limit API.
+	GRPCAddress() string
+
+	// SetDescriptors replaces the descriptors of the service and waits for it to be restarted with them. The
+	// counters kept in Redis are not reset.
+	SetDescriptors(descriptors []Descriptor) error
+
+	// SetDescriptorsOrFail calls SetDescriptors and fails the test if an error is returned.
+	SetDescriptorsOrFail(t test.Failer, descriptors []Descriptor)
+
+	// EnvoyFilter returns the EnvoyFilters that have the selected proxies rate limit the requests to a virtual
+	// host with this service. It is meant to be applied in the namespace of the selected workloads, or in the
+	// root namespace for gateways selected by label.
+	EnvoyFilter(f Filter) (string, error)
+}
+
+// Config for the rate limit component.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Domain of the rate limit configuration. Defaults to DefaultDomain.
+	Domain string
+
+	// Descriptors of the rate limit configuration.
+	Descriptors []Descriptor
+}
+
+// New deploys the rate limit service and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	if c.Domain == "" {
+		c.Domain = DefaultDomain
+	}
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("ratelimit.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+func TestServiceConfig(t *testing.T) {
+	got, err := serviceConfig("test", []Descriptor{
+		{Key: "PATH", Value: "/limited", RateLimit: &RateLimit{Unit: Hour, RequestsPerUnit: 5}},
+		{Key: "generic_key", Value: "all", Descriptors: []Descriptor{{Key: "PATH"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"domain: test", "value: /limited", "unit: hour", "requests_per_unit: 5", "key: generic_key"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestEnvoyFilter(t *testing.T) {
+	got, err := envoyFilter(Filter{
+		Name:        "ratelimit",
+		Selector:    map[string]string{"app": "a"},
+		VirtualHost: "b.ns.svc.cluster.local:80",
+		Actions: []Action{
+			{Header: ":path", DescriptorKey: "PATH"},
+			{GenericKey: "all"},
+		},
+	}, "test", "outbound|8081||ratelimit.ns.svc.cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"context: SIDECAR_OUTBOUND", `app: "a"`, "domain: test", "failure_mode_deny: true",
+		"cluster_name: outbound|8081||ratelimit.ns.svc.cluster.local", `name: "b.ns.svc.cluster.local:80"`,
+		`header_name: ":path"`, `descriptor_key: "PATH"`, `descriptor_value: "all"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	if _, err := envoyFilter(Filter{Name: "ratelimit", VirtualHost: "b"}, "test", "c"); err == nil {
+		t.Error("expected error for filter without actions")
+	}
+}
+
+func TestValidateRateLimited(t *testing.T) {
+	responses := client.ParsedResponses{{Code: "200"}, {Code: "429"}, {Code: "200"}, {Code: "429"}}
+	if err := ValidateRateLimited(2)(responses); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateRateLimited(3)(responses); err == nil {
+		t.Fatal("expected error for wrong number of allowed requests")
+	}
+	if err := ValidateRateLimited(1)(client.ParsedResponses{{Code: "200"}, {Code: "503"}}); err == nil {
+		t.Fatal("expected error for unexpected status code")
+	}
+}
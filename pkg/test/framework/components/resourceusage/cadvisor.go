@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceusage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	cadvisorMemoryMetric = "container_memory_working_set_bytes"
+	cadvisorCPUMetric    = "container_cpu_usage_seconds_total"
+)
+
+// cAdvisor reads usage from the kubelet of each node hosting a selected pod. CPU usage is reported by
+// cAdvisor as a cumulative counter, so it is computed from consecutive samples of the same container and
+// is 0 in the first sample.
+type cAdvisor struct {
+	mu      sync.Mutex
+	lastCPU map[string]cpuPoint
+}
+
+type cpuPoint struct {
+	time    time.Time
+	seconds float64
+}
+
+// containerUsage is the raw usage of a container reported by cAdvisor.
+type containerUsage struct {
+	pod        string
+	container  string
+	cpuSeconds float64
+	memory     int64
+}
+
+func newCAdvisor() *cAdvisor {
+	return &cAdvisor{lastCPU: map[string]cpuPoint{}}
+}
+
+func (c *cAdvisor) sample(t Target) ([]Sample, error) {
+	pods, err := t.Cluster.CoreV1().Pods(t.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: t.Selector})
+	if err != nil {
+		return nil, err
+	}
+	podNames := map[string]bool{}
+	nodes := map[string]bool{}
+	for _, p := range pods.Items {
+		if p.Spec.NodeName == "" {
+			continue
+		}
+		podNames[p.Name] = true
+		nodes[p.Spec.NodeName] = true
+	}
+
+	var out []Sample
+	for node := range nodes {
+		raw, err := t.Cluster.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", node, "proxy", "metrics", "cadvisor").
+			DoRaw(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed reading cadvisor metrics of node %s: %v", node, err)
+		}
+		now := time.Now()
+		usage, err := parseCAdvisor(string(raw), t.Namespace, podNames, t.Container)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range usage {
+			out = append(out, Sample{
+				Time:      now,
+				Target:    t.Name,
+				Pod:       u.pod,
+				Container: u.container,
+				CPUMillis: c.cpuMillis(t.Namespace+"/"+u.pod+"/"+u.container, now, u.cpuSeconds),
+				Memory:    u.memory,
+			})
+		}
+	}
+	return out, nil
+}
+
+// cpuMillis returns the average CPU usage since the previous sample of the container.
+func (c *cAdvisor) cpuMillis(key string, now time.Time, seconds float64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.lastCPU[key]
+	c.lastCPU[key] = cpuPoint{time: now, seconds: seconds}
+	if !ok || !now.After(last.time) || seconds < last.seconds {
+		return 0
+	}
+	return int64((seconds - last.seconds) / now.Sub(last.time).Seconds() * 1000)
+}
+
+// parseCAdvisor extracts the usage of the given pods' containers from cAdvisor metrics.
+func parseCAdvisor(text, namespace string, pods map[string]bool, container string) ([]containerUsage, error) {
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing cadvisor metrics: %v", err)
+	}
+	usage := map[string]*containerUsage{}
+	get := func(labels map[string]string) *containerUsage {
+		// cAdvisor also reports pod level cgroups (no container) and the pause container ("POD").
+		if labels["namespace"] != namespace || !pods[labels["pod"]] || labels["container"] == "" ||
+			labels["container"] == "POD" || (container != "" && labels["container"] != container) {
+			return nil
+		}
+		key := labels["pod"] + "/" + labels["container"]
+		if usage[key] == nil {
+			usage[key] = &containerUsage{pod: labels["pod"], container: labels["container"]}
+		}
+		return usage[key]
+	}
+	for _, m := range families[cadvisorMemoryMetric].GetMetric() {
+		if u := get(labelMap(m.GetLabel())); u != nil {
+			u.memory = int64(m.GetGauge().GetValue())
+		}
+	}
+	for _, m := range families[cadvisorCPUMetric].GetMetric() {
+		if u := get(labelMap(m.GetLabel())); u != nil {
+			u.cpuSeconds = m.GetCounter().GetValue()
+		}
+	}
+	out := make([]containerUsage, 0, len(usage))
+	for _, u := range usage {
+		out = append(out, *u)
+	}
+	return out, nil
+}
+
+func labelMap(pairs []*dto.LabelPair) map[string]string {
+	out := make(map[string]string, len(pairs))
+	for _, l := range pairs {
+		out[l.GetName()] = l.GetValue()
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceusage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// metricsServer reads usage from the metrics.k8s.io API.
+type metricsServer struct{}
+
+func (metricsServer) sample(t Target) ([]Sample, error) {
+	list, err := t.Cluster.Dynamic().Resource(podMetricsGVR).Namespace(t.Namespace).List(context.TODO(),
+		metav1.ListOptions{LabelSelector: t.Selector})
+	if err != nil {
+		return nil, err
+	}
+	return parsePodMetrics(t, list.Items)
+}
+
+// parsePodMetrics converts PodMetrics objects into samples.
+func parsePodMetrics(t Target, items []unstructured.Unstructured) ([]Sample, error) {
+	var out []Sample
+	for _, item := range items {
+		ts, _, _ := unstructured.NestedString(item.Object, "timestamp")
+		sampleTime, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			sampleTime = time.Now()
+		}
+		containers, _, err := unstructured.NestedSlice(item.Object, "containers")
+		if err != nil {
+			return nil, fmt.Errorf("invalid metrics for pod %s: %v", item.GetName(), err)
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			if t.Container != "" && name != t.Container {
+				continue
+			}
+			cpu, _, _ := unstructured.NestedString(container, "usage", "cpu")
+			mem, _, _ := unstructured.NestedString(container, "usage", "memory")
+			s := Sample{Time: sampleTime, Target: t.Name, Pod: item.GetName(), Container: name}
+			if cpu != "" {
+				q, err := resource.ParseQuantity(cpu)
+				if err != nil {
+					return nil, fmt.Errorf("invalid cpu usage %q for %s/%s: %v", cpu, item.GetName(), name, err)
+				}
+				s.CPUMillis = q.MilliValue()
+			}
+			if mem != "" {
+				q, err := resource.ParseQuantity(mem)
+				if err != nil {
+					return nil, fmt.Errorf("invalid memory usage %q for %s/%s: %v", mem, item.GetName(), name, err)
+				}
+				s.Memory = q.Value()
+			}
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resourceusage samples the CPU and memory usage of Istio components while a test runs, so that
+// tests can assert on resource consumption and the usage can be inspected after the fact.
+package resourceusage
+
+import (
+	"fmt"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Target selects the containers to sample.
+type Target struct {
+	// Name identifies the target in samples and assertions.
+	Name string
+	// Cluster to sample. Defaults to the default cluster.
+	Cluster resource.Cluster
+	// Namespace of the pods.
+	Namespace string
+	// Selector is a label selector for the pods. An empty selector selects every pod in the namespace.
+	Selector string
+	// Container, if set, restricts sampling to containers with this name.
+	Container string
+}
+
+// Istiod returns a target for the istiod containers of the control plane.
+func Istiod(cfg istio.Config) Target {
+	return Target{Name: "istiod", Namespace: cfg.SystemNamespace, Selector: "istio=pilot", Container: "discovery"}
+}
+
+// IngressGateway returns a target for the proxies of the default ingress gateway.
+func IngressGateway(cfg istio.Config) Target {
+	return Target{Name: "ingressgateway", Namespace: cfg.IngressNamespace, Selector: "istio=ingressgateway",
+		Container: "istio-proxy"}
+}
+
+// Sidecars returns a target for every sidecar proxy in the namespace.
+func Sidecars(namespace string) Target {
+	return Target{Name: "sidecars-" + namespace, Namespace: namespace, Container: "istio-proxy"}
+}
+
+// Sample is the resource usage of a single container at a point in time.
+type Sample struct {
+	Time      time.Time
+	Target    string
+	Pod       string
+	Container string
+	// CPU usage in millicores.
+	CPUMillis int64
+	// Memory is the working set of the container, in bytes.
+	Memory int64
+}
+
+// Source of resource usage.
+type Source string
+
+const (
+	// MetricsServer reads usage from the metrics.k8s.io API served by metrics-server.
+	MetricsServer Source = "metrics-server"
+	// CAdvisor reads usage from the cAdvisor endpoint of each node's kubelet, for clusters without
+	// metrics-server.
+	CAdvisor Source = "cadvisor"
+)
+
+// Config for the sampler.
+type Config struct {
+	// Targets to sample.
+	Targets []Target
+	// Interval between samples. Defaults to 10s.
+	Interval time.Duration
+	// Source of the usage data. Defaults to MetricsServer if it is available, otherwise CAdvisor.
+	Source Source
+}
+
+// Instance samples resource usage in the background until it is closed. The samples are written to the
+// work directory of the context that created it as resource-usage.csv when it is closed.
+type Instance interface {
+	resource.Resource
+
+	// Samples returns all of the samples collected so far.
+	Samples() []Sample
+
+	// Max returns the sample with the highest usage of the given resource for the named target.
+	// If container is non-empty, only samples for that container are considered.
+	Max(target, container string, r Resource) (Sample, bool)
+
+	// ExpectBelow checks that the usage of the given resource by every container of the named target
+	// stayed below limit. Memory limits are in bytes and CPU limits in millicores.
+	ExpectBelow(target string, r Resource, limit int64) error
+	ExpectBelowOrFail(t test.Failer, target string, r Resource, limit int64)
+}
+
+// Resource is a kind of resource that is sampled.
+type Resource string
+
+const (
+	CPU    Resource = "cpu"
+	Memory Resource = "memory"
+)
+
+func (s Sample) value(r Resource) int64 {
+	if r == CPU {
+		return s.CPUMillis
+	}
+	return s.Memory
+}
+
+func (r Resource) format(v int64) string {
+	if r == CPU {
+		return fmt.Sprintf("%dm", v)
+	}
+	return fmt.Sprintf("%.1fMiB", float64(v)/(1<<20))
+}
+
+// New starts sampling resource usage.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newSampler(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("resourceusage.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceusage
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParsePodMetrics(t *testing.T) {
+	items := []unstructured.Unstructured{{Object: map[string]interface{}{
+		"metadata":  map[string]interface{}{"name": "istiod-abc"},
+		"timestamp": "2021-03-01T00:00:00Z",
+		"containers": []interface{}{
+			map[string]interface{}{"name": "discovery", "usage": map[string]interface{}{"cpu": "250m", "memory": "64Mi"}},
+			map[string]interface{}{"name": "other", "usage": map[string]interface{}{"cpu": "1", "memory": "1Gi"}},
+		},
+	}}}
+	samples, err := parsePodMetrics(Target{Name: "istiod", Container: "discovery"}, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %v", samples)
+	}
+	s := samples[0]
+	if s.Pod != "istiod-abc" || s.Container != "discovery" || s.CPUMillis != 250 || s.Memory != 64<<20 {
+		t.Fatalf("unexpected sample: %+v", s)
+	}
+	if !s.Time.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time: %v", s.Time)
+	}
+}
+
+const cadvisorMetrics = `# TYPE container_memory_working_set_bytes gauge
+container_memory_working_set_bytes{container="istio-proxy",namespace="ns",pod="a-1"} 3.145728e+07
+container_memory_working_set_bytes{container="app",namespace="ns",pod="a-1"} 1e+07
+container_memory_working_set_bytes{container="POD",namespace="ns",pod="a-1"} 1e+06
+container_memory_working_set_bytes{container="",namespace="ns",pod="a-1"} 4.3e+07
+container_memory_working_set_bytes{container="istio-proxy",namespace="ns",pod="other"} 1e+06
+container_memory_working_set_bytes{container="istio-proxy",namespace="other",pod="a-1"} 1e+06
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{container="istio-proxy",namespace="ns",pod="a-1"} 12.5
+`
+
+func TestParseCAdvisor(t *testing.T) {
+	usage, err := parseCAdvisor(cadvisorMetrics, "ns", map[string]bool{"a-1": true}, "istio-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("expected 1 container, got %+v", usage)
+	}
+	u := usage[0]
+	if u.pod != "a-1" || u.container != "istio-proxy" || u.memory != 30<<20 || u.cpuSeconds != 12.5 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+
+	all, err := parseCAdvisor(cadvisorMetrics, "ns", map[string]bool{"a-1": true}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected the pause container and pod cgroup to be skipped, got %+v", all)
+	}
+}
+
+func TestCPUMillis(t *testing.T) {
+	c := newCAdvisor()
+	now := time.Now()
+	if got := c.cpuMillis("k", now, 10); got != 0 {
+		t.Fatalf("expected first sample to report 0, got %d", got)
+	}
+	if got := c.cpuMillis("k", now.Add(10*time.Second), 12); got != 200 {
+		t.Fatalf("expected 200m, got %d", got)
+	}
+	// The container restarted and the counter was reset.
+	if got := c.cpuMillis("k", now.Add(20*time.Second), 1); got != 0 {
+		t.Fatalf("expected counter reset to report 0, got %d", got)
+	}
+}
+
+func TestExpectBelow(t *testing.T) {
+	now := time.Now()
+	samples := []Sample{
+		{Time: now, Target: "sidecars", Pod: "a", Container: "istio-proxy", CPUMillis: 10, Memory: 40 << 20},
+		{Time: now.Add(time.Second), Target: "sidecars", Pod: "a", Container: "istio-proxy", CPUMillis: 50, Memory: 60 << 20},
+		{Time: now, Target: "istiod", Pod: "i", Container: "discovery", CPUMillis: 500, Memory: 200 << 20},
+	}
+	if m, _ := maxSample(samples, "sidecars", "", Memory); m.Memory != 60<<20 {
+		t.Fatalf("unexpected max: %+v", m)
+	}
+	if err := expectBelow(samples, "sidecars", Memory, 64<<20); err != nil {
+		t.Fatal(err)
+	}
+	if err := expectBelow(samples, "sidecars", CPU, 50); err == nil {
+		t.Fatal("expected cpu limit to be exceeded")
+	}
+	if err := expectBelow(samples, "gateways", CPU, 50); err == nil {
+		t.Fatal("expected missing target to fail")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	file := path.Join(t.TempDir(), outputFile)
+	samples := []Sample{
+		{Time: time.Unix(20, 0).UTC(), Target: "t", Pod: "p", Container: "c", CPUMillis: 2, Memory: 20},
+		{Time: time.Unix(10, 0).UTC(), Target: "t", Pod: "p", Container: "c", CPUMillis: 1, Memory: 10},
+	}
+	if err := writeCSV(file, samples); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	want := []string{
+		"time,target,pod,container,cpu_millis,memory_bytes",
+		"1970-01-01T00:00:10Z,t,p,c,1,10",
+		"1970-01-01T00:00:20Z,t,p,c,2,20",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected csv:\n%s", string(b))
+	}
+}
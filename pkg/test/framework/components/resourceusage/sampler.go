@@ -0,0 +1,222 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceusage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	outputFile      = "resource-usage.csv"
+)
+
+var (
+	_ Instance  = &sampler{}
+	_ io.Closer = &sampler{}
+)
+
+// usageSource reads the current usage of the containers selected by a target.
+type usageSource interface {
+	sample(t Target) ([]Sample, error)
+}
+
+type sampler struct {
+	id      resource.ID
+	cfg     Config
+	source  usageSource
+	workDir string
+
+	mu      sync.Mutex
+	samples []Sample
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSampler(ctx resource.Context, cfg Config) (*sampler, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("no targets to sample")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+	cfg.Targets = append([]Target{}, cfg.Targets...)
+	for i := range cfg.Targets {
+		cfg.Targets[i].Cluster = ctx.Clusters().GetOrDefault(cfg.Targets[i].Cluster)
+	}
+	source, err := newSource(cfg.Source, cfg.Targets[0])
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := ctx.CreateDirectory("resource-usage")
+	if err != nil {
+		return nil, err
+	}
+	s := &sampler{
+		cfg:     cfg,
+		source:  source,
+		workDir: workDir,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.id = ctx.TrackResource(s)
+	go s.run()
+	return s, nil
+}
+
+// newSource returns the requested source, or if none was requested, metrics-server if it serves the
+// target and cAdvisor otherwise.
+func newSource(src Source, probe Target) (usageSource, error) {
+	switch src {
+	case MetricsServer:
+		return metricsServer{}, nil
+	case CAdvisor:
+		return newCAdvisor(), nil
+	case "":
+		if _, err := (metricsServer{}).sample(probe); err != nil {
+			scopes.Framework.Infof("metrics-server is not available (%v), sampling resource usage from cAdvisor", err)
+			return newCAdvisor(), nil
+		}
+		return metricsServer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown resource usage source %q", src)
+	}
+}
+
+func (s *sampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		s.sampleAll()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *sampler) sampleAll() {
+	for _, t := range s.cfg.Targets {
+		samples, err := s.source.sample(t)
+		if err != nil {
+			scopes.Framework.Warnf("failed sampling resource usage of %s: %v", t.Name, err)
+			continue
+		}
+		s.mu.Lock()
+		s.samples = append(s.samples, samples...)
+		s.mu.Unlock()
+	}
+}
+
+func (s *sampler) ID() resource.ID {
+	return s.id
+}
+
+func (s *sampler) Samples() []Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sample{}, s.samples...)
+}
+
+func (s *sampler) Max(target, container string, r Resource) (Sample, bool) {
+	return maxSample(s.Samples(), target, container, r)
+}
+
+func maxSample(samples []Sample, target, container string, r Resource) (Sample, bool) {
+	var (
+		out   Sample
+		found bool
+	)
+	for _, sample := range samples {
+		if sample.Target != target || (container != "" && sample.Container != container) {
+			continue
+		}
+		if !found || sample.value(r) > out.value(r) {
+			out = sample
+			found = true
+		}
+	}
+	return out, found
+}
+
+func (s *sampler) ExpectBelow(target string, r Resource, limit int64) error {
+	return expectBelow(s.Samples(), target, r, limit)
+}
+
+func expectBelow(samples []Sample, target string, r Resource, limit int64) error {
+	m, found := maxSample(samples, target, "", r)
+	if !found {
+		return fmt.Errorf("no resource usage samples for %s", target)
+	}
+	if m.value(r) >= limit {
+		return fmt.Errorf("%s usage of %s/%s reached %s at %s, expected below %s",
+			r, m.Pod, m.Container, r.format(m.value(r)), m.Time.Format(time.RFC3339), r.format(limit))
+	}
+	return nil
+}
+
+func (s *sampler) ExpectBelowOrFail(t test.Failer, target string, r Resource, limit int64) {
+	t.Helper()
+	if err := s.ExpectBelow(target, r, limit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Close stops sampling and writes the samples collected to the work directory.
+func (s *sampler) Close() error {
+	close(s.stop)
+	<-s.done
+	return writeCSV(path.Join(s.workDir, outputFile), s.Samples())
+}
+
+func writeCSV(file string, samples []Sample) error {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"time", "target", "pod", "container", "cpu_millis", "memory_bytes"}); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		if err := w.Write([]string{
+			s.Time.Format(time.RFC3339), s.Target, s.Pod, s.Container,
+			strconv.FormatInt(s.CPUMillis, 10), strconv.FormatInt(s.Memory, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
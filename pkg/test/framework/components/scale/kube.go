@@ -0,0 +1,283 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+// serviceTemplate is a lightweight echo service, serving HTTP on a single port.
+const serviceTemplate = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Service }}
+  labels:
+    app: {{ .Service }}
+spec:
+  ports:
+  - name: http
+    port: 80
+    targetPort: 8080
+  selector:
+    app: {{ .Service }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Service }}
+  labels:
+    scale-topology: {{ .Prefix }}
+spec:
+  replicas: {{ .Replicas }}
+  selector:
+    matchLabels:
+      app: {{ .Service }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Service }}
+        version: v1
+        scale-topology: {{ .Prefix }}
+    spec:
+      containers:
+      - name: app
+        image: {{ .Hub }}/app:{{ .Tag }}
+        imagePullPolicy: {{ .PullPolicy }}
+        args: ["--port", "8080", "--version", "v1"]
+        ports:
+        - containerPort: 8080
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 8080
+          periodSeconds: 2
+        resources:
+          requests:
+            cpu: 10m
+            memory: 32Mi
+`
+
+const readyTimeout = 10 * time.Minute
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id        resource.ID
+	cfg       Config
+	ns        namespace.Instance
+	ownNs     bool
+	cluster   resource.Cluster
+	ctx       resource.Context
+	services  []ServiceData
+	manifests []string
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cfg:     cfg,
+		ns:      cfg.Namespace,
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		ctx:     ctx,
+	}
+	c.id = ctx.TrackResource(c)
+
+	if c.ns == nil {
+		var err error
+		c.ns, err = namespace.New(ctx, namespace.Config{
+			Prefix: cfg.Prefix,
+			Inject: cfg.Inject,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.ownNs = true
+	}
+	for i := 0; i < cfg.Services; i++ {
+		svc := fmt.Sprintf("%s-%d", cfg.Prefix, i)
+		c.services = append(c.services, ServiceData{
+			Index:     i,
+			Service:   svc,
+			Namespace: c.ns.Name(),
+			Host:      fmt.Sprintf("%s.%s.svc.cluster.local", svc, c.ns.Name()),
+		})
+	}
+	if err := c.deploy(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// batches splits the services in batches of the given size.
+func batches(services []ServiceData, size int) [][]ServiceData {
+	var out [][]ServiceData
+	for len(services) > size {
+		out = append(out, services[:size])
+		services = services[size:]
+	}
+	if len(services) > 0 {
+		out = append(out, services)
+	}
+	return out
+}
+
+// render returns the manifest of the given services with their config.
+func (c *kubeComponent) render(batch []ServiceData, templates []*template.Template, s *image.Settings) (string, error) {
+	var docs []string
+	for _, svc := range batch {
+		out, err := tmpl.Evaluate(serviceTemplate, map[string]interface{}{
+			"Service":    svc.Service,
+			"Prefix":     c.cfg.Prefix,
+			"Replicas":   c.cfg.Replicas,
+			"Hub":        s.Hub,
+			"Tag":        s.Tag,
+			"PullPolicy": s.PullPolicy,
+		})
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, out)
+		for _, t := range templates {
+			out, err := tmpl.Execute(t, svc)
+			if err != nil {
+				return "", fmt.Errorf("failed rendering config of %s: %v", svc.Service, err)
+			}
+			docs = append(docs, out)
+		}
+	}
+	return strings.Join(docs, "\n---\n"), nil
+}
+
+func (c *kubeComponent) deploy() error {
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return err
+	}
+	templates := make([]*template.Template, 0, len(c.cfg.ConfigTemplates))
+	for _, t := range c.cfg.ConfigTemplates {
+		parsed, err := tmpl.Parse(t)
+		if err != nil {
+			return err
+		}
+		templates = append(templates, parsed)
+	}
+
+	applied := 0
+	for _, batch := range batches(c.services, c.cfg.BatchSize) {
+		manifest, err := c.render(batch, templates, s)
+		if err != nil {
+			return err
+		}
+		if err := c.ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), manifest); err != nil {
+			return err
+		}
+		c.manifests = append(c.manifests, manifest)
+		if c.cfg.WaitReady {
+			if err := c.waitReady(names(batch), int32(c.cfg.Replicas)); err != nil {
+				return err
+			}
+		}
+		applied += len(batch)
+		c.cfg.Progress(applied, len(c.services))
+	}
+	return nil
+}
+
+func names(services []ServiceData) []string {
+	out := make([]string, 0, len(services))
+	for _, s := range services {
+		out = append(out, s.Service)
+	}
+	return out
+}
+
+// waitReady waits for the deployments of the given services to have exactly the given number of ready pods.
+func (c *kubeComponent) waitReady(services []string, replicas int32) error {
+	return retry.UntilSuccess(func() error {
+		for _, svc := range services {
+			d, err := c.cluster.AppsV1().Deployments(c.ns.Name()).Get(context.TODO(), svc, kubeApiMeta.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if d.Status.Replicas != replicas || d.Status.ReadyReplicas != replicas {
+				return fmt.Errorf("%s has %d/%d ready pods, expected %d", svc, d.Status.ReadyReplicas,
+					d.Status.Replicas, replicas)
+			}
+		}
+		return nil
+	}, retry.Timeout(readyTimeout), retry.Delay(2*time.Second))
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) Services() []ServiceData {
+	return c.services
+}
+
+func (c *kubeComponent) Scale(replicas int, services ...string) error {
+	if len(services) == 0 {
+		services = names(c.services)
+	}
+	deployments := c.cluster.AppsV1().Deployments(c.ns.Name())
+	for _, svc := range services {
+		s, err := deployments.GetScale(context.TODO(), svc, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return err
+		}
+		s.Spec.Replicas = int32(replicas)
+		if _, err := deployments.UpdateScale(context.TODO(), svc, s, kubeApiMeta.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed scaling %s to %d: %v", svc, replicas, err)
+		}
+	}
+	return c.waitReady(services, int32(replicas))
+}
+
+func (c *kubeComponent) ScaleOrFail(t test.Failer, replicas int, services ...string) {
+	t.Helper()
+	if err := c.Scale(replicas, services...); err != nil {
+		t.Fatalf("scale.ScaleOrFail: %v", err)
+	}
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.ownNs {
+		// Removed along with the namespace.
+		return nil
+	}
+	return c.ctx.Config(c.cluster).DeleteYAML(c.ns.Name(), c.manifests...)
+}
@@ -0,0 +1,120 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scale deploys large topologies of lightweight echo services, for control plane scale tests.
+package scale
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	defaultPrefix    = "scale"
+	defaultBatchSize = 50
+)
+
+// ServiceData is passed to the config templates for each service.
+type ServiceData struct {
+	// Index of the service, in [0, Services).
+	Index int
+	// Service name.
+	Service string
+	// Namespace of the service.
+	Namespace string
+	// Host is the fully qualified host name of the service.
+	Host string
+}
+
+// Config of a topology.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Namespace to deploy the topology in. If not set, a new namespace is created, with sidecar injection
+	// if Inject is set.
+	Namespace namespace.Instance
+
+	// Inject sidecars in the workloads of a created namespace. Without sidecars, the workloads only load the
+	// control plane with their endpoints.
+	Inject bool
+
+	// Prefix of the service names, which are named <prefix>-<index>. Defaults to "scale".
+	Prefix string
+
+	// Services is the number of services.
+	Services int
+
+	// Replicas is the number of pods of each service.
+	Replicas int
+
+	// ConfigTemplates are rendered for each service with its ServiceData, and applied along with it. For
+	// example, a VirtualService or DestinationRule per service.
+	ConfigTemplates []string
+
+	// BatchSize is the number of services applied at once. Defaults to 50.
+	BatchSize int
+
+	// WaitReady waits for the pods of each batch to be ready before applying the next one.
+	WaitReady bool
+
+	// Progress is called after each batch with the number of services applied so far. Defaults to logging.
+	Progress func(applied, total int)
+}
+
+// Instance is a deployed topology.
+type Instance interface {
+	resource.Resource
+
+	// Namespace of the topology.
+	Namespace() namespace.Instance
+
+	// Services returns the data of all the services of the topology.
+	Services() []ServiceData
+
+	// Scale changes the number of pods of the given services, or of all services if none is given, and waits
+	// for them to be ready.
+	Scale(replicas int, services ...string) error
+
+	// ScaleOrFail calls Scale and fails the test if an error is returned.
+	ScaleOrFail(t test.Failer, replicas int, services ...string)
+}
+
+// Deploy the topology with the given configuration.
+func Deploy(ctx resource.Context, c Config) (Instance, error) {
+	if c.Prefix == "" {
+		c.Prefix = defaultPrefix
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.Progress == nil {
+		c.Progress = func(applied, total int) {
+			scopes.Framework.Infof("scale topology: applied %d/%d services", applied, total)
+		}
+	}
+	return newKube(ctx, c)
+}
+
+// DeployOrFail calls Deploy and fails the test if an error is returned.
+func DeployOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := Deploy(ctx, c)
+	if err != nil {
+		t.Fatalf("scale.DeployOrFail: %v", err)
+	}
+	return i
+}
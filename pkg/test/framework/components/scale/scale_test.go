@@ -0,0 +1,36 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scale
+
+import (
+	"testing"
+)
+
+func TestBatches(t *testing.T) {
+	services := make([]ServiceData, 7)
+	for i := range services {
+		services[i].Index = i
+	}
+	got := batches(services, 3)
+	if len(got) != 3 || len(got[0]) != 3 || len(got[2]) != 1 || got[2][0].Index != 6 {
+		t.Fatalf("unexpected batches: %v", got)
+	}
+	if got := batches(services, 7); len(got) != 1 {
+		t.Fatalf("expected a single batch, got %d", len(got))
+	}
+	if got := batches(nil, 3); len(got) != 0 {
+		t.Fatalf("expected no batch, got %d", len(got))
+	}
+}
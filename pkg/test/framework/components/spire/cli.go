@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spire
+
+import (
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var entryIDRegex = regexp.MustCompile(`(?m)^Entry ID\s*:\s*(\S+)`)
+
+// parseEntryID returns the ID of the entry created by "spire-server entry create".
+func parseEntryID(out string) (string, error) {
+	m := entryIDRegex.FindStringSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("no entry ID found in output: %s", out)
+	}
+	return m[1], nil
+}
+
+// parseMintedSVID parses the output of "spire-server x509 mint", which is the SVID certificate chain followed by
+// its private key and then the root certificates of the bundle.
+func parseMintedSVID(out string) (SVID, error) {
+	var chain, key, bundle []string
+	rest := []byte(out)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		encoded := string(pem.EncodeToMemory(block))
+		switch {
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			key = append(key, encoded)
+		case block.Type != "CERTIFICATE":
+			return SVID{}, fmt.Errorf("unexpected PEM block %q in minted SVID", block.Type)
+		case len(key) == 0:
+			chain = append(chain, encoded)
+		default:
+			bundle = append(bundle, encoded)
+		}
+	}
+	if len(chain) == 0 || len(key) != 1 || len(bundle) == 0 {
+		return SVID{}, fmt.Errorf("expected certificates, a key and a bundle in minted SVID, got %d, %d and %d",
+			len(chain), len(key), len(bundle))
+	}
+	return SVID{
+		CertChain: strings.Join(chain, ""),
+		Key:       key[0],
+		Bundle:    strings.Join(bundle, ""),
+	}, nil
+}
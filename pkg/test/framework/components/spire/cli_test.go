@@ -0,0 +1,65 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spire
+
+import (
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func block(t string, data string) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: t, Bytes: []byte(data)}))
+}
+
+func TestParseEntryID(t *testing.T) {
+	out := `Entry ID         : 6e0a9e66-3b18-4a3c-8f4d-3b3d0ad2a5f9
+SPIFFE ID        : spiffe://cluster.local/ns/a/sa/default
+Parent ID        : spiffe://cluster.local/ns/istio-spire/sa/spire-agent
+`
+	id, err := parseEntryID(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "6e0a9e66-3b18-4a3c-8f4d-3b3d0ad2a5f9" {
+		t.Fatalf("unexpected entry ID %q", id)
+	}
+	if _, err := parseEntryID("Error: rpc error"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseMintedSVID(t *testing.T) {
+	out := block("CERTIFICATE", "leaf") + block("CERTIFICATE", "intermediate") +
+		block("PRIVATE KEY", "key") + block("CERTIFICATE", "root")
+	svid, err := parseMintedSVID(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svid.CertChain != block("CERTIFICATE", "leaf")+block("CERTIFICATE", "intermediate") {
+		t.Fatalf("unexpected cert chain:\n%s", svid.CertChain)
+	}
+	if svid.Key != block("PRIVATE KEY", "key") {
+		t.Fatalf("unexpected key:\n%s", svid.Key)
+	}
+	if svid.Bundle != block("CERTIFICATE", "root") {
+		t.Fatalf("unexpected bundle:\n%s", svid.Bundle)
+	}
+
+	if _, err := parseMintedSVID(block("CERTIFICATE", "leaf")); err == nil ||
+		!strings.Contains(err.Error(), "expected certificates") {
+		t.Fatalf("expected incomplete SVID to fail, got %v", err)
+	}
+}
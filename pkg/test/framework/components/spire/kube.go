@@ -0,0 +1,249 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spire
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	defaultTrustDomain = "cluster.local"
+
+	// serverSocket is the admin API socket of the server, used by the spire-server CLI.
+	serverSocket = "/tmp/spire-server/private/api.sock"
+	serverCLI    = "/opt/spire/bin/spire-server"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id          resource.ID
+	ns          namespace.Instance
+	trustDomain string
+	socketPath  string
+	serverPod   string
+	agentID     string
+	cluster     resource.Cluster
+	close       func()
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster:     ctx.Clusters().GetOrDefault(cfg.Cluster),
+		trustDomain: cfg.TrustDomain,
+	}
+	if c.trustDomain == "" {
+		c.trustDomain = defaultTrustDomain
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-spire",
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Keep the sockets of concurrent deployments on the same node apart.
+	c.socketPath = path.Join("/run/spire", c.ns.Name())
+
+	if cfg.UpstreamCA != nil {
+		if err := c.createUpstreamSecret(cfg); err != nil {
+			return nil, fmt.Errorf("failed creating upstream CA secret: %v", err)
+		}
+	}
+
+	tpl, err := ioutil.ReadFile(env.SPIREInstallFilePath)
+	if err != nil {
+		return nil, err
+	}
+	yaml, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"Namespace":      c.ns.Name(),
+		"TrustDomain":    c.trustDomain,
+		"ClusterName":    c.cluster.Name(),
+		"ServerSocket":   serverSocket,
+		"SocketHostPath": c.socketPath,
+		"UpstreamCA":     cfg.UpstreamCA != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), yaml); err != nil {
+		return nil, err
+	}
+	// The namespace is removed with its contents, but the cluster roles are not.
+	c.close = func() {
+		_ = ctx.Config(c.cluster).DeleteYAML(c.ns.Name(), yaml)
+	}
+
+	pods, err := testKube.WaitUntilPodsAreReady(testKube.NewSinglePodFetch(c.cluster, c.ns.Name(), "app=spire-server"))
+	if err != nil {
+		return nil, err
+	}
+	c.serverPod = pods[0].Name
+
+	// Register a single identity for all agents, which is used as the parent of the workload entries.
+	c.agentID = c.spiffeID(c.ns.Name(), "spire-agent")
+	if _, err := c.exec("entry create", "-node",
+		"-spiffeID", c.agentID,
+		"-selector", "k8s_psat:cluster:"+c.cluster.Name(),
+		"-selector", "k8s_psat:agent_ns:"+c.ns.Name(),
+		"-selector", "k8s_psat:agent_sa:spire-agent"); err != nil {
+		return nil, fmt.Errorf("failed registering agents: %v", err)
+	}
+
+	if _, err := testKube.WaitUntilPodsAreReady(testKube.NewPodFetch(c.cluster, c.ns.Name(), "app=spire-agent")); err != nil {
+		return nil, err
+	}
+	scopes.Framework.Infof("deployed SPIRE with trust domain %s in namespace %s", c.trustDomain, c.ns.Name())
+	return c, nil
+}
+
+func (c *kubeComponent) createUpstreamSecret(cfg Config) error {
+	cert, err := ioutil.ReadFile(cfg.UpstreamCA.Root.CertFile)
+	if err != nil {
+		return err
+	}
+	key, err := ioutil.ReadFile(cfg.UpstreamCA.Root.KeyFile)
+	if err != nil {
+		return err
+	}
+	_, err = c.cluster.CoreV1().Secrets(c.ns.Name()).Create(context.TODO(), &kubeApiCore.Secret{
+		ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name: "spire-upstream-ca",
+		},
+		Data: map[string][]byte{
+			"root-cert.pem": cert,
+			"root-key.pem":  key,
+		},
+	}, kubeApiMeta.CreateOptions{})
+	return err
+}
+
+// exec runs the given spire-server CLI command, e.g. "entry create", with the given arguments in the server pod.
+func (c *kubeComponent) exec(command string, args ...string) (string, error) {
+	cmd := append([]string{serverCLI, command, "-socketPath", serverSocket}, args...)
+	stdout, stderr, err := c.cluster.PodExec(c.serverPod, c.ns.Name(), "spire-server", strings.Join(cmd, " "))
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", command, err, stderr)
+	}
+	return stdout, nil
+}
+
+func (c *kubeComponent) spiffeID(namespace, serviceAccount string) string {
+	return fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", c.trustDomain, namespace, serviceAccount)
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) TrustDomain() string {
+	return c.trustDomain
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) SocketHostPath() string {
+	return c.socketPath
+}
+
+func (c *kubeComponent) WorkloadID(namespace, serviceAccount string) string {
+	return c.spiffeID(namespace, serviceAccount)
+}
+
+func (c *kubeComponent) RegisterWorkload(namespace, serviceAccount string, selectors ...string) (Entry, error) {
+	e := Entry{
+		SPIFFEID:  c.spiffeID(namespace, serviceAccount),
+		ParentID:  c.agentID,
+		Selectors: append([]string{"k8s:ns:" + namespace, "k8s:sa:" + serviceAccount}, selectors...),
+	}
+	args := []string{"-spiffeID", e.SPIFFEID, "-parentID", e.ParentID}
+	for _, s := range e.Selectors {
+		args = append(args, "-selector", s)
+	}
+	out, err := c.exec("entry create", args...)
+	if err != nil {
+		return Entry{}, err
+	}
+	if e.ID, err = parseEntryID(out); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+func (c *kubeComponent) RegisterWorkloadOrFail(t test.Failer, namespace, serviceAccount string, selectors ...string) Entry {
+	t.Helper()
+	e, err := c.RegisterWorkload(namespace, serviceAccount, selectors...)
+	if err != nil {
+		t.Fatalf("spire.RegisterWorkloadOrFail: %v", err)
+	}
+	return e
+}
+
+func (c *kubeComponent) DeleteEntry(id string) error {
+	_, err := c.exec("entry delete", "-entryID", id)
+	return err
+}
+
+func (c *kubeComponent) MintX509SVID(spiffeID string) (SVID, error) {
+	out, err := c.exec("x509 mint", "-spiffeID", spiffeID)
+	if err != nil {
+		return SVID{}, err
+	}
+	return parseMintedSVID(out)
+}
+
+func (c *kubeComponent) MintX509SVIDOrFail(t test.Failer, spiffeID string) SVID {
+	t.Helper()
+	svid, err := c.MintX509SVID(spiffeID)
+	if err != nil {
+		t.Fatalf("spire.MintX509SVIDOrFail: %v", err)
+	}
+	return svid
+}
+
+func (c *kubeComponent) Bundle() (string, error) {
+	return c.exec("bundle show")
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	return nil
+}
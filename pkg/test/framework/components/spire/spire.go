@@ -0,0 +1,109 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spire
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Instance represents a SPIRE server and agent deployed on kube.
+//
+// The agent serves SDS with the resource names Envoy in Istio requests ("default" and "ROOTCA"), on a socket under
+// SocketHostPath on every node. Note that in this version of Istio the pilot-agent always serves SDS to Envoy
+// itself, so sidecars do not take their certificates from SPIRE yet. To make SPIRE issued identities and mesh
+// identities trust each other, deploy SPIRE with Config.UpstreamCA set to the plugin CA of the Istio deployment.
+type Instance interface {
+	resource.Resource
+
+	// TrustDomain of the SPIRE server.
+	TrustDomain() string
+
+	// Namespace SPIRE is deployed in.
+	Namespace() namespace.Instance
+
+	// SocketHostPath is the directory on every node holding the socket of the agent (agent.sock), which serves
+	// both the SPIFFE Workload API and SDS.
+	SocketHostPath() string
+
+	// WorkloadID returns the SPIFFE ID Istio uses for workloads running as the given service account.
+	WorkloadID(namespace, serviceAccount string) string
+
+	// RegisterWorkload registers an entry for the pods running as the given service account, so that the agent
+	// issues them an SVID with the same SPIFFE ID Istio would use. Additional selectors (e.g. "k8s:pod-label:app:a")
+	// restrict the entry further.
+	RegisterWorkload(namespace, serviceAccount string, selectors ...string) (Entry, error)
+
+	// RegisterWorkloadOrFail calls RegisterWorkload and fails the test if an error is returned.
+	RegisterWorkloadOrFail(t test.Failer, namespace, serviceAccount string, selectors ...string) Entry
+
+	// DeleteEntry deletes the registration entry with the given ID.
+	DeleteEntry(id string) error
+
+	// MintX509SVID has the server sign an X509-SVID for the given SPIFFE ID, without attestation. This is useful to
+	// make calls with a SPIRE issued identity from outside of the mesh, for example with echo.CallOptions.Cert.
+	MintX509SVID(spiffeID string) (SVID, error)
+
+	// MintX509SVIDOrFail calls MintX509SVID and fails the test if an error is returned.
+	MintX509SVIDOrFail(t test.Failer, spiffeID string) SVID
+
+	// Bundle returns the PEM encoded trust bundle of the server.
+	Bundle() (string, error)
+}
+
+// Config for SPIRE.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// TrustDomain of the server. Defaults to "cluster.local", the default trust domain of Istio.
+	TrustDomain string
+
+	// UpstreamCA, if set, makes the server sign its CA certificates with the root of the given plugin CA, so that
+	// SVIDs issued by SPIRE and certificates issued by istiod chain to the same root.
+	UpstreamCA *istio.PluginCA
+}
+
+// Entry is a SPIRE registration entry.
+type Entry struct {
+	ID        string
+	SPIFFEID  string
+	ParentID  string
+	Selectors []string
+}
+
+// SVID is an X509-SVID together with its key and the trust bundle to verify it with, all PEM encoded.
+type SVID struct {
+	CertChain string
+	Key       string
+	Bundle    string
+}
+
+// New deploys SPIRE and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("spire.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"istio.io/istio/pkg/test/framework/components/accesslog"
+	"istio.io/istio/pkg/test/framework/components/prometheus"
+	"istio.io/istio/pkg/test/framework/components/zipkin"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// MetricAppears is observed once Prometheus has at least one series matching the query.
+func MetricAppears(p prometheus.Instance, q prometheus.Query) Effect {
+	return NewEffect(fmt.Sprintf("metric %s appears", q), func() error {
+		v, err := p.QuerySum(q)
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			return fmt.Errorf("no samples for %s", q)
+		}
+		return nil
+	})
+}
+
+// MetricLabel is observed once the metric selected by the query is reported with the given label value,
+// for example after a stats override adds a custom dimension.
+func MetricLabel(p prometheus.Instance, q prometheus.Query, label, value string) Effect {
+	return MetricAppears(p, q.WithLabel(label, value))
+}
+
+// AccessLogs is observed once the watched proxy logs an entry matching all of the matchers.
+func AccessLogs(w *accesslog.Watcher, matchers ...accesslog.Matcher) Effect {
+	return NewEffect(fmt.Sprintf("access log entry %v appears", matchers), func() error {
+		entries, err := w.Entries()
+		if err != nil {
+			return err
+		}
+		if len(accesslog.Filter(entries, matchers...)) == 0 {
+			return fmt.Errorf("no matching entries in %d new access log entries", len(entries))
+		}
+		return nil
+	})
+}
+
+// traceArrival is how long reported spans may take to become queryable in the tracing backend.
+const traceArrival = 15 * time.Second
+
+// TraceSampling is observed once the percentage of requests to the service that are traced is within
+// tolerance (in percentage points) of the expected percentage. Each check sends a new batch of requests
+// with send, which returns the number of requests sent, and counts the traces reported for them.
+func TraceSampling(z zipkin.Instance, service string, percentage, tolerance float64, send func() (int, error)) Effect {
+	desc := fmt.Sprintf("%.1f%% of requests to %s are traced", percentage, service)
+	return NewEffect(desc, func() error {
+		since := time.Now()
+		sent, err := send()
+		if err != nil {
+			return err
+		}
+		if sent == 0 {
+			return fmt.Errorf("no requests sent")
+		}
+		// Spans are reported asynchronously, so the number of traces only grows until all have arrived.
+		return retry.UntilSuccess(func() error {
+			// Query for more traces than requests sent, so that over-sampling is not hidden by the limit.
+			traces, err := z.QueryServiceTraces(service, "", since, 2*sent)
+			if err != nil {
+				return err
+			}
+			got := float64(len(traces)) * 100 / float64(sent)
+			if math.Abs(got-percentage) > tolerance {
+				return fmt.Errorf("%d of %d requests traced (%.1f%%)", len(traces), sent, got)
+			}
+			return nil
+		}, retry.Timeout(traceArrival), retry.Delay(time.Second))
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry provides helpers for applying telemetry configuration (stats filter overrides, tracing
+// and access logging settings) and waiting until its effect can be observed, rather than sleeping for an
+// arbitrary amount of time and hoping the configuration has propagated.
+package telemetry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+var (
+	defaultTimeout = retry.Timeout(2 * time.Minute)
+	defaultDelay   = retry.Delay(3 * time.Second)
+)
+
+// Effect is an observable consequence of telemetry configuration, such as a metric gaining a label.
+type Effect struct {
+	desc  string
+	check func() error
+}
+
+// NewEffect returns an Effect which is observed once check returns nil. The description is used in error
+// messages.
+func NewEffect(desc string, check func() error) Effect {
+	return Effect{desc: desc, check: check}
+}
+
+func (e Effect) String() string {
+	return e.desc
+}
+
+// Change is a telemetry configuration change, together with the effects that show it has been applied.
+type Change struct {
+	// Namespace to apply the configuration to.
+	Namespace string
+	// Config is the YAML to apply.
+	Config []string
+	// Traffic, if set, is called before the effects are checked, to generate the telemetry they look for.
+	Traffic func() error
+	// Effects that must all be observed for the change to be considered applied.
+	Effects []Effect
+}
+
+// Apply applies the configuration of the change, and waits until all of its effects are observed. The
+// configuration is not removed on failure.
+func Apply(ctx resource.Context, c Change, opts ...retry.Option) error {
+	if err := ctx.Config().ApplyYAML(c.Namespace, c.Config...); err != nil {
+		return err
+	}
+	return Wait(c, opts...)
+}
+
+// ApplyOrFail calls Apply and fails the test if it returns an error.
+func ApplyOrFail(t test.Failer, ctx resource.Context, c Change, opts ...retry.Option) {
+	t.Helper()
+	if err := Apply(ctx, c, opts...); err != nil {
+		t.Fatalf("telemetry.ApplyOrFail: %v", err)
+	}
+}
+
+// Remove deletes the configuration of the change, and waits until all of the given effects, which
+// should describe the state without the change, are observed.
+func Remove(ctx resource.Context, c Change, effects []Effect, opts ...retry.Option) error {
+	if err := ctx.Config().DeleteYAML(c.Namespace, c.Config...); err != nil {
+		return err
+	}
+	return Wait(Change{Traffic: c.Traffic, Effects: effects}, opts...)
+}
+
+// Wait waits until all of the effects of the change are observed, without applying its configuration.
+func Wait(c Change, opts ...retry.Option) error {
+	start := time.Now()
+	opts = append([]retry.Option{defaultTimeout, defaultDelay}, opts...)
+	err := retry.UntilSuccess(func() error {
+		if c.Traffic != nil {
+			if err := c.Traffic(); err != nil {
+				return fmt.Errorf("failed sending traffic: %v", err)
+			}
+		}
+		var errs error
+		for _, e := range c.Effects {
+			if err := e.check(); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("%s: %v", e, err))
+			}
+		}
+		return errs
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("telemetry change did not take effect: %v", err)
+	}
+	scopes.Framework.Infof("telemetry change took effect after %v", time.Since(start))
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestWait(t *testing.T) {
+	traffic := 0
+	c := Change{
+		Traffic: func() error {
+			traffic++
+			return nil
+		},
+		Effects: []Effect{
+			NewEffect("always", func() error { return nil }),
+			NewEffect("after traffic", func() error {
+				if traffic < 3 {
+					return errors.New("not yet")
+				}
+				return nil
+			}),
+		},
+	}
+	if err := Wait(c, retry.Delay(time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if traffic != 3 {
+		t.Fatalf("expected traffic to be sent 3 times, got %d", traffic)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	c := Change{
+		Effects: []Effect{
+			NewEffect("never", func() error { return errors.New("not observed") }),
+		},
+	}
+	err := Wait(c, retry.Delay(time.Millisecond), retry.Timeout(10*time.Millisecond))
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	if !strings.Contains(err.Error(), "never: not observed") {
+		t.Fatalf("expected error to name the missing effect, got: %v", err)
+	}
+}
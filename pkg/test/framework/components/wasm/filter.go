@@ -0,0 +1,180 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"fmt"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const listenersTypeURL = "type.googleapis.com/envoy.admin.v3.ListenersConfigDump"
+
+// Plugin selects the proxies that load a module, and how the module is run.
+type Plugin struct {
+	// Name of the EnvoyFilter, which is also the name of the HTTP filter running the module.
+	Name string
+
+	// Module is the name of the served module to load.
+	Module string
+
+	// Selector of the workloads whose proxies load the module. If empty, all the proxies in the namespace
+	// the EnvoyFilter is applied to are selected.
+	Selector map[string]string
+
+	// Context of the patched listeners: SIDECAR_INBOUND, SIDECAR_OUTBOUND or GATEWAY. Defaults to
+	// SIDECAR_INBOUND.
+	Context string
+
+	// RootID of the module, for modules with several root contexts.
+	RootID string
+
+	// Configuration passed to the module as a string.
+	Configuration string
+
+	// SHA256 overrides the checksum the proxies verify the module against. It defaults to the checksum of
+	// the served module, and can be set to test that modules with a mismatching checksum are rejected.
+	SHA256 string
+}
+
+const filterTemplate = `apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: {{ .Name }}
+spec:
+{{- if .Selector }}
+  workloadSelector:
+    labels:
+{{- range $k, $v := .Selector }}
+      {{ $k }}: {{ printf "%q" $v }}
+{{- end }}
+{{- end }}
+  configPatches:
+  - applyTo: HTTP_FILTER
+    match:
+      context: {{ .Context }}
+      listener:
+        filterChain:
+          filter:
+            name: envoy.filters.network.http_connection_manager
+            subFilter:
+              name: envoy.filters.http.router
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: {{ .Name }}
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.http.wasm.v3.Wasm
+          config:
+            name: {{ .Name }}
+            root_id: {{ printf "%q" .RootID }}
+{{- if .Configuration }}
+            configuration:
+              "@type": type.googleapis.com/google.protobuf.StringValue
+              value: {{ printf "%q" .Configuration }}
+{{- end }}
+            vm_config:
+              vm_id: {{ .Name }}
+              runtime: envoy.wasm.runtime.v8
+              code:
+                remote:
+                  http_uri:
+                    uri: {{ .URL }}
+                    cluster: {{ .Cluster }}
+                    timeout: 10s
+                  sha256: {{ .SHA256 }}
+`
+
+// envoyFilter renders the EnvoyFilter for the plugin, loading the module from the given URL through the given
+// Envoy cluster.
+func envoyFilter(p Plugin, url, cluster string) (string, error) {
+	if p.Name == "" {
+		return "", fmt.Errorf("wasm plugin requires a name")
+	}
+	if p.SHA256 == "" {
+		return "", fmt.Errorf("wasm plugin %s requires the checksum of module %q", p.Name, p.Module)
+	}
+	if p.Context == "" {
+		p.Context = "SIDECAR_INBOUND"
+	}
+	return tmpl.Evaluate(filterTemplate, struct {
+		Plugin
+		URL     string
+		Cluster string
+	}{p, url, cluster})
+}
+
+// Loaded returns an accept function for echo.Sidecar.WaitForConfig, accepting the configuration once an active
+// listener of the proxy runs the plugin with the given name. Envoy only activates listeners once their modules
+// have been fetched, verified and started.
+func Loaded(plugin string) func(*envoyAdmin.ConfigDump) (bool, error) {
+	return func(dump *envoyAdmin.ConfigDump) (bool, error) {
+		return hasHTTPFilter(dump, plugin)
+	}
+}
+
+// NotLoaded returns an accept function for echo.Sidecar.WaitForConfig, accepting the configuration once no
+// active listener of the proxy runs the plugin with the given name. Listeners whose modules can't be fetched
+// or don't match their checksum are rejected, so the plugin is never loaded.
+func NotLoaded(plugin string) func(*envoyAdmin.ConfigDump) (bool, error) {
+	return func(dump *envoyAdmin.ConfigDump) (bool, error) {
+		found, err := hasHTTPFilter(dump, plugin)
+		return !found, err
+	}
+}
+
+func hasHTTPFilter(dump *envoyAdmin.ConfigDump, name string) (bool, error) {
+	for _, c := range dump.Configs {
+		if c.TypeUrl != listenersTypeURL {
+			continue
+		}
+		listeners := &envoyAdmin.ListenersConfigDump{}
+		if err := ptypes.UnmarshalAny(c, listeners); err != nil {
+			return false, err
+		}
+		for _, dl := range listeners.DynamicListeners {
+			if dl.GetActiveState().GetListener() == nil {
+				continue
+			}
+			l := &listener.Listener{}
+			if err := ptypes.UnmarshalAny(dl.GetActiveState().GetListener(), l); err != nil {
+				return false, err
+			}
+			for _, fc := range l.FilterChains {
+				for _, f := range fc.Filters {
+					if f.Name != wellknown.HTTPConnectionManager || f.GetTypedConfig() == nil {
+						continue
+					}
+					m := &hcm.HttpConnectionManager{}
+					if err := ptypes.UnmarshalAny(f.GetTypedConfig(), m); err != nil {
+						return false, err
+					}
+					for _, hf := range m.HttpFilters {
+						if hf.Name == name {
+							return true, nil
+						}
+					}
+				}
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"sync"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "wasm-server"
+	port        = 80
+)
+
+// moduleName matches the names of modules that are valid ConfigMap keys and URL paths.
+var moduleName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id      resource.ID
+	ns      namespace.Instance
+	cluster resource.Cluster
+	ctx     resource.Context
+
+	mu       sync.Mutex
+	checksum map[string]string
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		cluster: ctx.Clusters().GetOrDefault(cfg.Cluster),
+		ctx:     ctx,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	c.ns, err = namespace.New(ctx, namespace.Config{
+		Prefix: "istio-wasm",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.deploy(cfg.Modules); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checksums returns the hex encoded checksum of each module.
+func checksums(modules map[string][]byte) (map[string]string, error) {
+	out := make(map[string]string, len(modules))
+	for name, content := range modules {
+		if !moduleName.MatchString(name) {
+			return nil, fmt.Errorf("invalid wasm module name %q", name)
+		}
+		out[name] = fmt.Sprintf("%x", sha256.Sum256(content))
+	}
+	return out, nil
+}
+
+// configHash returns a hash of the given module checksums, which changes whenever any module does.
+func configHash(sums map[string]string) string {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", name, sums[name])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// deploy applies the server with the given modules and waits for it to be ready with them. The hash of the
+// modules is part of the pod template, so that changing them restarts the server.
+func (c *kubeComponent) deploy(modules map[string][]byte) error {
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return err
+	}
+	sums, err := checksums(modules)
+	if err != nil {
+		return err
+	}
+	hash := configHash(sums)
+	encoded := make(map[string]string, len(modules))
+	for name, content := range modules {
+		encoded[name] = base64.StdEncoding.EncodeToString(content)
+	}
+
+	tpl, err := ioutil.ReadFile(env.WasmInstallFilePath)
+	if err != nil {
+		return err
+	}
+	manifest, err := tmpl.Evaluate(string(tpl), map[string]interface{}{
+		"ImagePullPolicy": s.PullPolicy,
+		"Service":         serviceName,
+		"Port":            port,
+		"Modules":         encoded,
+		"ConfigHash":      hash,
+	})
+	if err != nil {
+		return err
+	}
+	// The server is removed along with the namespace.
+	if err := c.ctx.Config(c.cluster).ApplyYAML(c.ns.Name(), manifest); err != nil {
+		return err
+	}
+	fetch := testKube.NewPodMustFetch(c.cluster, c.ns.Name(), "app="+serviceName, "config-hash="+hash)
+	if _, err := testKube.WaitUntilPodsAreReady(fetch); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.checksum = sums
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Namespace() namespace.Instance {
+	return c.ns
+}
+
+func (c *kubeComponent) host() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, c.ns.Name())
+}
+
+func (c *kubeComponent) URL(module string) string {
+	return fmt.Sprintf("http://%s/%s", c.host(), module)
+}
+
+func (c *kubeComponent) SHA256(module string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checksum[module]
+}
+
+func (c *kubeComponent) SetModules(modules map[string][]byte) error {
+	return c.deploy(modules)
+}
+
+func (c *kubeComponent) SetModulesOrFail(t test.Failer, modules map[string][]byte) {
+	t.Helper()
+	if err := c.SetModules(modules); err != nil {
+		t.Fatalf("wasm.SetModulesOrFail: %v", err)
+	}
+}
+
+func (c *kubeComponent) EnvoyFilter(p Plugin) (string, error) {
+	if p.SHA256 == "" {
+		p.SHA256 = c.SHA256(p.Module)
+	}
+	return envoyFilter(p, c.URL(p.Module), fmt.Sprintf("outbound|%d||%s", port, c.host()))
+}
+
+// Close implements io.Closer.
+func (c *kubeComponent) Close() error {
+	return nil
+}
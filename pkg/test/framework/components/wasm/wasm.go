@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Instance represents an in-cluster HTTP server that serves Wasm modules to the proxies.
+//
+// This version of Istio has no WasmPlugin API and the proxies can't pull modules from OCI registries, so
+// modules are fetched by Envoy itself with remote code sources configured through EnvoyFilter. As a
+// consequence, image pull secrets are not supported. Checksums are verified by Envoy.
+type Instance interface {
+	resource.Resource
+
+	// Namespace the server is deployed in.
+	Namespace() namespace.Instance
+
+	// URL of the module with the given name.
+	URL(module string) string
+
+	// SHA256 returns the hex encoded checksum of the module with the given name, or an empty string if the
+	// module is not served.
+	SHA256(module string) string
+
+	// SetModules replaces the served modules and waits for the server to be restarted with them.
+	SetModules(modules map[string][]byte) error
+
+	// SetModulesOrFail calls SetModules and fails the test if an error is returned.
+	SetModulesOrFail(t test.Failer, modules map[string][]byte)
+
+	// EnvoyFilter returns the EnvoyFilter that has the selected proxies load the module of the plugin from
+	// this server. It is meant to be applied in the namespace of the selected workloads, or in the root
+	// namespace for gateways selected by label.
+	EnvoyFilter(p Plugin) (string, error)
+}
+
+// Config for the Wasm module server.
+type Config struct {
+	// Cluster to be used in a multicluster environment
+	Cluster resource.Cluster
+
+	// Modules served, by name. The names are used in the module URLs. As modules are stored in a ConfigMap,
+	// their total size must stay below 1MiB.
+	Modules map[string][]byte
+}
+
+// New deploys the Wasm module server and returns a handle to it.
+func New(ctx resource.Context, c Config) (Instance, error) {
+	return newKube(ctx, c)
+}
+
+// NewOrFail calls New and fails the test if an error is returned.
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
+	t.Helper()
+	i, err := New(ctx, c)
+	if err != nil {
+		t.Fatalf("wasm.NewOrFail: %v", err)
+	}
+	return i
+}
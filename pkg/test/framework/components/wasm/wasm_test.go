@@ -0,0 +1,62 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvoyFilter(t *testing.T) {
+	got, err := envoyFilter(Plugin{
+		Name:          "header-injector",
+		Module:        "header.wasm",
+		Selector:      map[string]string{"app": "b"},
+		Configuration: `{"header":"x-wasm"}`,
+		SHA256:        "abc",
+	}, "http://wasm-server.ns.svc.cluster.local/header.wasm", "outbound|80||wasm-server.ns.svc.cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"name: header-injector", "context: SIDECAR_INBOUND", `app: "b"`, `value: "{\"header\":\"x-wasm\"}"`,
+		"uri: http://wasm-server.ns.svc.cluster.local/header.wasm",
+		"cluster: outbound|80||wasm-server.ns.svc.cluster.local", "sha256: abc",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	if _, err := envoyFilter(Plugin{Name: "missing", Module: "missing.wasm"}, "u", "c"); err == nil {
+		t.Error("expected error for plugin without checksum")
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	sums, err := checksums(map[string][]byte{"a.wasm": []byte("a"), "b.wasm": []byte("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sums["a.wasm"], "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"; got != want {
+		t.Fatalf("expected checksum %s, got %s", want, got)
+	}
+	if configHash(sums) == configHash(map[string]string{"a.wasm": sums["a.wasm"]}) {
+		t.Fatal("expected config hash to change with the modules")
+	}
+	if _, err := checksums(map[string][]byte{"dir/a.wasm": nil}); err == nil {
+		t.Fatal("expected error for invalid module name")
+	}
+}
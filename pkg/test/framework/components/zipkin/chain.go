@@ -0,0 +1,90 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// chainQueryLimit is the maximum number of traces searched for the trace of a chain.
+const chainQueryLimit = 100
+
+// ServiceName returns the zipkin service name reported by the sidecars of a Kubernetes service by default.
+func ServiceName(service, namespace string) string {
+	return service + "." + namespace
+}
+
+// FindTrace returns the trace with the given ID. IDs are compared ignoring case and leading zeros, which
+// zipkin strips from 64 bit IDs.
+func FindTrace(traces []Trace, id string) (Trace, bool) {
+	want := normalizeID(id)
+	for _, t := range traces {
+		if normalizeID(t.ID) == want {
+			return t, true
+		}
+	}
+	return Trace{}, false
+}
+
+func normalizeID(id string) string {
+	return strings.TrimLeft(strings.ToLower(id), "0")
+}
+
+// ExpectChain waits until zipkin reports the trace with the given ID, started at or after since, and it
+// contains the call chain through all of the given services (see Trace.VerifyChain). Spans are reported
+// asynchronously by every proxy, so this keeps querying until all hops have arrived. The trace is typically
+// started by the test with an echo.TraceContext, for example:
+//
+//	tc := echo.NewTraceContext()
+//	a.CallOrFail(t, echo.CallOptions{Target: b, PortName: "http", Trace: tc, Chain: []echo.Hop{{Target: c}}})
+//	zipkin.ExpectChainOrFail(t, z, tc.TraceID, since, "a.ns", "b.ns", "c.ns")
+func ExpectChain(inst Instance, traceID string, since time.Time, services []string, opts ...retry.Option) (Trace, error) {
+	if len(services) == 0 {
+		return Trace{}, fmt.Errorf("no services given for trace %s", traceID)
+	}
+	opts = append([]retry.Option{retry.Timeout(time.Minute), retry.Delay(2 * time.Second)}, opts...)
+	var out Trace
+	err := retry.UntilSuccess(func() error {
+		traces, err := inst.QueryServiceTraces(services[0], "", since, chainQueryLimit)
+		if err != nil {
+			return err
+		}
+		t, ok := FindTrace(traces, traceID)
+		if !ok {
+			return fmt.Errorf("trace %s not found in %d traces of %s", traceID, len(traces), services[0])
+		}
+		if err := t.VerifyChain(services...); err != nil {
+			return err
+		}
+		out = t
+		return nil
+	}, opts...)
+	return out, err
+}
+
+// ExpectChainOrFail calls ExpectChain and fails the test if an error is returned.
+func ExpectChainOrFail(t test.Failer, inst Instance, traceID string, since time.Time, services ...string) Trace {
+	t.Helper()
+	tr, err := ExpectChain(inst, traceID, since, services)
+	if err != nil {
+		t.Fatalf("zipkin.ExpectChainOrFail: %v", err)
+	}
+	return tr
+}
@@ -0,0 +1,97 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+type fakeZipkin struct {
+	queries int
+	traces  func(queries int) []Trace
+}
+
+func (f *fakeZipkin) ID() resource.ID {
+	return nil
+}
+
+func (f *fakeZipkin) QueryTraces(int, string, string) ([]Trace, error) {
+	return nil, nil
+}
+
+func (f *fakeZipkin) QueryServiceTraces(string, string, time.Time, int) ([]Trace, error) {
+	f.queries++
+	return f.traces(f.queries), nil
+}
+
+func TestFindTrace(t *testing.T) {
+	traces := []Trace{{ID: "1"}, {ID: "00ABC"}}
+	if tr, ok := FindTrace(traces, "0000000000000abc"); !ok || tr.ID != "00ABC" {
+		t.Fatalf("expected to find trace 00ABC, got %v %v", tr.ID, ok)
+	}
+	if _, ok := FindTrace(traces, "2"); ok {
+		t.Fatal("expected trace 2 not to be found")
+	}
+}
+
+func TestExpectChain(t *testing.T) {
+	full, err := extractTraces([]byte(zipkinChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := extractTraces([]byte(zipkinChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the spans of the first hop have arrived.
+	var spans []*Span
+	for _, s := range partial[0].Spans {
+		if s.ServiceName == "a.ns" {
+			spans = append(spans, &Span{TraceID: s.TraceID, SpanID: s.SpanID, ParentSpanID: s.ParentSpanID,
+				ServiceName: s.ServiceName, Name: s.Name, Start: s.Start})
+		}
+	}
+	partial[0] = newTrace("t", spans)
+
+	b := &fakeZipkin{traces: func(queries int) []Trace {
+		switch {
+		case queries < 2:
+			return nil
+		case queries < 3:
+			return partial
+		default:
+			return full
+		}
+	}}
+	tr, err := ExpectChain(b, "t", time.Time{}, []string{"a.ns", "b.ns", "c.ns"}, retry.Delay(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.queries != 3 {
+		t.Fatalf("expected 3 queries, got %d", b.queries)
+	}
+	if len(tr.Spans) != 5 {
+		t.Fatalf("expected the full trace, got:\n%s", tr)
+	}
+
+	if _, err := ExpectChain(b, "t", time.Time{}, []string{"a.ns", "c.ns", "b.ns"},
+		retry.Delay(time.Millisecond), retry.Timeout(10*time.Millisecond)); err == nil {
+		t.Fatal("expected wrong chain to fail")
+	}
+}
@@ -15,14 +15,13 @@
 package zipkin
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"path/filepath"
-	"sort"
 	"time"
 
 	istioKube "istio.io/istio/pkg/kube"
@@ -125,29 +124,49 @@ func (c *kubeComponent) ID() resource.ID {
 
 func (c *kubeComponent) QueryTraces(limit int, spanName, annotationQuery string) ([]Trace, error) {
 	// Get 100 most recent traces
+	traces, err := c.query(c.address + fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
+	if err != nil {
+		return nil, err
+	}
+	if len(traces) == 0 {
+		return nil, errors.New("cannot find any traces")
+	}
+	return traces, nil
+}
+
+func (c *kubeComponent) QueryServiceTraces(service, spanName string, since time.Time, limit int) ([]Trace, error) {
+	now := time.Now()
+	q := url.Values{}
+	q.Set("serviceName", service)
+	if spanName != "" {
+		q.Set("spanName", spanName)
+	}
+	q.Set("endTs", fmt.Sprint(now.UnixNano()/int64(time.Millisecond)))
+	q.Set("lookback", fmt.Sprint(now.Sub(since).Milliseconds()))
+	q.Set("limit", fmt.Sprint(limit))
+	return c.query(c.address + "/api/v2/traces?" + q.Encode())
+}
+
+func (c *kubeComponent) query(u string) ([]Trace, error) {
 	client := http.Client{
 		Timeout: 5 * time.Second,
 	}
-	scopes.Framework.Debugf("make get call to zipkin api %v", c.address+fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
-	resp, err := client.Get(c.address + fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
+	scopes.Framework.Debugf("make get call to zipkin api %v", u)
+	resp, err := client.Get(u)
 	if err != nil {
 		scopes.Framework.Debugf("zipking err %v", err)
 		return nil, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		scopes.Framework.Debugf("response err %v", resp.StatusCode)
 		return nil, fmt.Errorf("zipkin api returns non-ok: %v", resp.StatusCode)
 	}
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	traces, err := extractTraces(body)
-	if err != nil {
-		return nil, err
-	}
-	return traces, nil
+	return extractTraces(body)
 }
 
 // Close implements io.Closer.
@@ -158,59 +177,3 @@ func (c *kubeComponent) Close() error {
 	c.forwarder.Close()
 	return nil
 }
-
-func extractTraces(resp []byte) ([]Trace, error) {
-	var traceObjs []interface{}
-	if err := json.Unmarshal(resp, &traceObjs); err != nil {
-		return []Trace{}, err
-	}
-	var ret []Trace
-	for _, t := range traceObjs {
-		spanObjs, ok := t.([]interface{})
-		if !ok || len(spanObjs) == 0 {
-			scopes.Framework.Debugf("cannot parse or cannot find spans in trace object %+v", t)
-			continue
-		}
-		var spans []Span
-		for _, obj := range spanObjs {
-			newSpan := buildSpan(obj)
-			spans = append(spans, newSpan)
-		}
-		for p := range spans {
-			for c := range spans {
-				if spans[c].ParentSpanID == spans[p].SpanID {
-					spans[p].ChildSpans = append(spans[p].ChildSpans, &spans[c])
-				}
-			}
-			// make order of child spans deterministic
-			sort.Slice(spans[p].ChildSpans, func(i, j int) bool {
-				return spans[p].ChildSpans[i].Name < spans[p].ChildSpans[j].Name
-			})
-		}
-		ret = append(ret, Trace{Spans: spans})
-	}
-	if len(ret) > 0 {
-		return ret, nil
-	}
-	return []Trace{}, errors.New("cannot find any traces")
-}
-
-func buildSpan(obj interface{}) Span {
-	var s Span
-	spanSpec := obj.(map[string]interface{})
-	if spanID, ok := spanSpec["id"]; ok {
-		s.SpanID = spanID.(string)
-	}
-	if parentSpanID, ok := spanSpec["parentId"]; ok {
-		s.ParentSpanID = parentSpanID.(string)
-	}
-	if endpointObj, ok := spanSpec["localEndpoint"]; ok {
-		if em, ok := endpointObj.(map[string]interface{}); ok {
-			s.ServiceName = em["serviceName"].(string)
-		}
-	}
-	if name, ok := spanSpec["name"]; ok {
-		s.Name = name.(string)
-	}
-	return s
-}
@@ -0,0 +1,200 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// zipkinSpan is a span as returned by the zipkin v2 API.
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	Tags          map[string]string `json:"tags"`
+	LocalEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"localEndpoint"`
+}
+
+// extractTraces parses the response of the zipkin /api/v2/traces API.
+func extractTraces(resp []byte) ([]Trace, error) {
+	var raw [][]zipkinSpan
+	if err := json.Unmarshal(resp, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing zipkin traces: %v", err)
+	}
+	out := make([]Trace, 0, len(raw))
+	for _, t := range raw {
+		if len(t) == 0 {
+			continue
+		}
+		spans := make([]*Span, 0, len(t))
+		for _, s := range t {
+			spans = append(spans, &Span{
+				TraceID:      s.TraceID,
+				SpanID:       s.ID,
+				ParentSpanID: s.ParentID,
+				ServiceName:  s.LocalEndpoint.ServiceName,
+				Name:         s.Name,
+				Tags:         s.Tags,
+				Start:        time.Unix(0, s.Timestamp*int64(time.Microsecond)),
+				Duration:     time.Duration(s.Duration) * time.Microsecond,
+			})
+		}
+		out = append(out, newTrace(t[0].TraceID, spans))
+	}
+	return out, nil
+}
+
+// newTrace links the given spans into a tree and returns the resulting trace.
+func newTrace(id string, spans []*Span) Trace {
+	byID := make(map[string]*Span, len(spans))
+	for _, s := range spans {
+		byID[s.SpanID] = s
+	}
+	for _, s := range spans {
+		if p, ok := byID[s.ParentSpanID]; ok && p != s {
+			s.Parent = p
+			p.ChildSpans = append(p.ChildSpans, s)
+		}
+	}
+	for _, s := range spans {
+		// make order of child spans deterministic
+		sort.SliceStable(s.ChildSpans, func(i, j int) bool {
+			if s.ChildSpans[i].Name != s.ChildSpans[j].Name {
+				return s.ChildSpans[i].Name < s.ChildSpans[j].Name
+			}
+			return s.ChildSpans[i].Start.Before(s.ChildSpans[j].Start)
+		})
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Start.Before(spans[j].Start)
+	})
+	return Trace{ID: id, Spans: spans}
+}
+
+// Roots returns the spans of the trace with no known parent. A complete trace has exactly one.
+func (t Trace) Roots() []*Span {
+	var out []*Span
+	for _, s := range t.Spans {
+		if s.Parent == nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SpansFor returns the spans reported by the given service.
+func (t Trace) SpansFor(service string) []*Span {
+	var out []*Span
+	for _, s := range t.Spans {
+		if s.ServiceName == service {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// VerifyChain checks that the trace contains a chain of spans reported by each of the given services in order,
+// where every span in the chain is a descendant of the previous one. This is the shape produced by a
+// request that is forwarded through each of the services, for example an echo call chain a -> b -> c.
+func (t Trace) VerifyChain(services ...string) error {
+	if len(services) == 0 {
+		return nil
+	}
+	for _, s := range t.SpansFor(services[0]) {
+		if s.hasDescendantChain(services[1:]) {
+			return nil
+		}
+	}
+	return fmt.Errorf("trace %s does not contain the call chain %s; got:\n%s",
+		t.ID, strings.Join(services, " -> "), t.String())
+}
+
+func (s *Span) hasDescendantChain(services []string) bool {
+	if len(services) == 0 {
+		return true
+	}
+	for _, c := range s.ChildSpans {
+		if c.ServiceName == services[0] && c.hasDescendantChain(services[1:]) {
+			return true
+		}
+		// The child may be another span of the same hop (e.g. the client side span). Keep looking below it.
+		if c.hasDescendantChain(services) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyParent checks that the given span is the direct parent of this span.
+func (s *Span) VerifyParent(parent *Span) error {
+	if s.Parent != parent {
+		got := "<none>"
+		if s.Parent != nil {
+			got = s.Parent.String()
+		}
+		return fmt.Errorf("span %s: expected parent %s, got %s", s, parent, got)
+	}
+	return nil
+}
+
+// VerifyTags checks that the span has all of the given tags with the given values. An empty expected value
+// only checks that the tag is present.
+func (s *Span) VerifyTags(want map[string]string) error {
+	var missing []string
+	for k, v := range want {
+		got, ok := s.Tags[k]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s (missing)", k))
+		} else if v != "" && got != v {
+			missing = append(missing, fmt.Sprintf("%s (got %q, want %q)", k, got, v))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("span %s has unexpected tags: %s", s, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (s *Span) String() string {
+	return fmt.Sprintf("%s[%s]", s.ServiceName, s.Name)
+}
+
+// String returns an indented tree of the spans in the trace.
+func (t Trace) String() string {
+	sb := &strings.Builder{}
+	var write func(s *Span, depth int)
+	write = func(s *Span, depth int) {
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(s.String())
+		sb.WriteString("\n")
+		for _, c := range s.ChildSpans {
+			write(c, depth+1)
+		}
+	}
+	for _, r := range t.Roots() {
+		write(r, 0)
+	}
+	return sb.String()
+}
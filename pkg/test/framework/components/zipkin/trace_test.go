@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"testing"
+)
+
+// a -> b -> c, where each hop has a client span (reported by the caller) and a server span.
+const zipkinChain = `[[
+{"traceId":"t","id":"1","name":"a.ns.svc.cluster.local:80/*","timestamp":1,"localEndpoint":{"serviceName":"a.ns"}},
+{"traceId":"t","id":"2","parentId":"1","name":"b.ns.svc.cluster.local:80/*","timestamp":2,"localEndpoint":{"serviceName":"a.ns"}},
+{"traceId":"t","id":"3","parentId":"2","name":"b.ns.svc.cluster.local:80/*","timestamp":3,"localEndpoint":{"serviceName":"b.ns"},
+ "tags":{"http.status_code":"200","upstream_cluster":"inbound|80||"}},
+{"traceId":"t","id":"4","parentId":"3","name":"c.ns.svc.cluster.local:80/*","timestamp":4,"localEndpoint":{"serviceName":"b.ns"}},
+{"traceId":"t","id":"5","parentId":"4","name":"c.ns.svc.cluster.local:80/*","timestamp":5,"localEndpoint":{"serviceName":"c.ns"}}
+]]`
+
+func TestExtractTraces(t *testing.T) {
+	traces, err := extractTraces([]byte(zipkinChain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+	tr := traces[0]
+	if roots := tr.Roots(); len(roots) != 1 || roots[0].SpanID != "1" {
+		t.Fatalf("unexpected roots: %v", roots)
+	}
+	if err := tr.VerifyChain("a.ns", "b.ns", "c.ns"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.VerifyChain("c.ns", "a.ns"); err == nil {
+		t.Fatal("expected reversed chain to fail")
+	}
+	server := tr.SpansFor("b.ns")[0]
+	if err := server.VerifyParent(tr.Spans[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.VerifyTags(map[string]string{"http.status_code": "200", "upstream_cluster": ""}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.VerifyTags(map[string]string{"http.status_code": "503"}); err == nil {
+		t.Fatal("expected tag mismatch")
+	}
+}
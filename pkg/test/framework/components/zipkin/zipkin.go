@@ -15,8 +15,11 @@
 package zipkin
 
 import (
-	"testing"
+	"fmt"
+	"time"
 
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/resource"
 )
 
@@ -27,6 +30,11 @@ type Instance interface {
 	// QueryTraces gets at most number of limit most recent available traces from zipkin.
 	// spanName filters that only trace with the given span name will be included.
 	QueryTraces(limit int, spanName, annotationQuery string) ([]Trace, error)
+
+	// QueryServiceTraces returns at most limit of the traces that include a span reported by the given service since
+	// the given time. If spanName is non-empty, only traces with a span of that name reported by the service are
+	// returned.
+	QueryServiceTraces(service, spanName string, since time.Time, limit int) ([]Trace, error)
 }
 
 type Config struct {
@@ -37,16 +45,23 @@ type Config struct {
 // Span represents a single span, which includes span attributes for verification
 // TODO(bianpengyuan) consider using zipkin proto api https://github.com/istio/istio/issues/13926
 type Span struct {
+	TraceID      string
 	SpanID       string
 	ParentSpanID string
 	ServiceName  string
 	Name         string
-	ChildSpans   []*Span
+	Tags         map[string]string
+	Start        time.Time
+	Duration     time.Duration
+
+	Parent     *Span
+	ChildSpans []*Span
 }
 
 // Trace represents a trace by a collection of spans which all belong to that trace
 type Trace struct {
-	Spans []Span
+	ID    string
+	Spans []*Span
 }
 
 // New returns a new instance of zipkin.
@@ -55,7 +70,7 @@ func New(ctx resource.Context, c Config) (i Instance, err error) {
 }
 
 // NewOrFail returns a new zipkin instance or fails test.
-func NewOrFail(t *testing.T, ctx resource.Context, c Config) Instance {
+func NewOrFail(t test.Failer, ctx resource.Context, c Config) Instance {
 	t.Helper()
 	i, err := New(ctx, c)
 	if err != nil {
@@ -64,3 +79,15 @@ func NewOrFail(t *testing.T, ctx resource.Context, c Config) Instance {
 
 	return i
 }
+
+// ConfigureSampling returns a setup function for istio.Setup that enables tracing in the mesh and samples
+// the given percentage of requests. Tests asserting on specific traces will usually want 100.
+func ConfigureSampling(percentage float64) istio.SetupConfigFn {
+	return func(_ resource.Context, cfg *istio.Config) {
+		if cfg == nil {
+			return
+		}
+		cfg.Values["meshConfig.enableTracing"] = "true"
+		cfg.Values["pilot.traceSampling"] = fmt.Sprintf("%.1f", percentage)
+	}
+}
@@ -31,6 +31,7 @@ type configManager struct {
 	ctx      resource.Context
 	clusters []resource.Cluster
 	prefix   string
+	opts     resource.ApplyOptions
 }
 
 func newConfigManager(ctx resource.Context, clusters []resource.Cluster) resource.ConfigManager {
@@ -47,6 +48,9 @@ func (c *configManager) ApplyYAML(ns string, yamlText ...string) error {
 	if len(c.prefix) == 0 {
 		return c.WithFilePrefix("apply").ApplyYAML(ns, yamlText...)
 	}
+	if c.opts.ServerSide || c.opts.Prune {
+		return c.applyYAMLWithOptions(ns, yamlText...)
+	}
 
 	// Convert the content to files.
 	yamlFiles, err := c.ctx.WriteYAML(c.prefix, yamlText...)
@@ -80,9 +84,12 @@ func (c *configManager) DeleteYAML(ns string, yamlText ...string) error {
 		return err
 	}
 
-	for _, c := range c.clusters {
-		if err := c.DeleteYAMLFiles(ns, yamlFiles...); err != nil {
-			return fmt.Errorf("failed deleting YAML from cluster %s: %v", c.Name(), err)
+	for _, cl := range c.clusters {
+		if err := cl.DeleteYAMLFiles(ns, yamlFiles...); err != nil {
+			return fmt.Errorf("failed deleting YAML from cluster %s: %v", cl.Name(), err)
+		}
+		if c.opts.Prune {
+			c.forgetApplied(cl, ns, yamlText...)
 		}
 	}
 	return nil
@@ -96,6 +103,15 @@ func (c *configManager) DeleteYAMLOrFail(t test.Failer, ns string, yamlText ...s
 }
 
 func (c *configManager) ApplyYAMLDir(ns string, configDir string) error {
+	if c.opts.Prune {
+		// Each apply prunes the objects it does not contain, so the files are applied together to keep all of them.
+		contents, err := readYAMLDir(configDir)
+		if err != nil {
+			return err
+		}
+		return c.ApplyYAML(ns, contents...)
+	}
+
 	return filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
 		if info.IsDir() {
 			return nil
@@ -111,6 +127,28 @@ func (c *configManager) ApplyYAMLDir(ns string, configDir string) error {
 	})
 }
 
+// readYAMLDir returns the contents of the files in configDir and its subdirectories.
+func readYAMLDir(configDir string) ([]string, error) {
+	var out []string
+	err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		scopes.Framework.Debugf("Reading config file to: %v", path)
+		contents, readerr := ioutil.ReadFile(path)
+		if readerr != nil {
+			return readerr
+		}
+		out = append(out, string(contents))
+		return nil
+	})
+	return out, err
+}
+
 func (c *configManager) DeleteYAMLDir(ns string, configDir string) error {
 	return filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -134,5 +172,15 @@ func (c *configManager) WithFilePrefix(prefix string) resource.ConfigManager {
 		ctx:      c.ctx,
 		prefix:   prefix,
 		clusters: c.clusters,
+		opts:     c.opts,
+	}
+}
+
+func (c *configManager) WithApplyOptions(opts resource.ApplyOptions) resource.ConfigManager {
+	return &configManager{
+		ctx:      c.ctx,
+		prefix:   c.prefix,
+		clusters: c.clusters,
+		opts:     opts,
 	}
 }
@@ -0,0 +1,189 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/metrics"
+)
+
+const (
+	// ControlPlaneCostsFile is the name of the file, in the run directory of the suite, where the control plane
+	// cost of each top-level test is written when -istio.test.costreport is set.
+	ControlPlaneCostsFile = "control-plane-costs.yaml"
+
+	// costSampleInterval is how often istiod memory is sampled to track its high-water mark.
+	costSampleInterval = 5 * time.Second
+
+	// costliestTests is the number of tests logged at the end of the suite.
+	costliestTests = 5
+)
+
+var (
+	cpuSeconds  = metrics.Select("process_cpu_seconds_total")
+	memoryBytes = metrics.Select("process_resident_memory_bytes")
+	xdsPushes   = metrics.Select("pilot_xds_pushes")
+)
+
+// testCost is the control plane cost incurred while a test ran, summed over the control plane clusters. It is not
+// split between tests running in parallel: each of them is charged the full cost incurred while they overlap.
+type testCost struct {
+	Test                 string  `yaml:"test"`
+	DurationSeconds      float64 `yaml:"durationSeconds"`
+	CPUSeconds           float64 `yaml:"cpuSeconds"`
+	MemoryHighWaterBytes float64 `yaml:"memoryHighWaterBytes"`
+	Pushes               float64 `yaml:"pushes"`
+}
+
+func (c testCost) String() string {
+	return fmt.Sprintf("%s: %.1f istiod CPU seconds, %.0f MiB memory high-water mark, %.0f xDS pushes in %.0fs",
+		c.Test, c.CPUSeconds, c.MemoryHighWaterBytes/(1<<20), c.Pushes, c.DurationSeconds)
+}
+
+// costSampler snapshots the metrics of istiod when a test starts and ends, and samples its memory in between.
+type costSampler struct {
+	ctx      resource.Context
+	clusters []resource.Cluster
+	start    time.Time
+	before   []metrics.Snapshot
+
+	// highWater is only accessed by the sampling goroutine until stopped is closed.
+	highWater float64
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+// startCostSampling returns a sampler of the control plane of the environment, or nil if it is not supported or
+// the initial snapshot failed, in which case no cost is reported for the test.
+func startCostSampling(ctx resource.Context) *costSampler {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil
+	}
+	s := &costSampler{
+		ctx:      ctx,
+		clusters: env.ControlPlaneClusters(),
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for _, c := range s.clusters {
+		snap, err := metrics.Take(s.source(c))
+		if err != nil {
+			scopes.Framework.Warnf("failed taking control plane metrics of %s, not reporting test cost: %v", c.Name(), err)
+			return nil
+		}
+		s.before = append(s.before, snap)
+		s.highWater = math.Max(s.highWater, snap.Value(memoryBytes))
+	}
+	go s.sample()
+	return s
+}
+
+func (s *costSampler) source(c resource.Cluster) metrics.Source {
+	return func() (map[string]*dto.MetricFamily, error) {
+		return testKube.IstiodMetrics(s.ctx, c)
+	}
+}
+
+func (s *costSampler) sample() {
+	defer close(s.stopped)
+	t := time.NewTicker(costSampleInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			for _, c := range s.clusters {
+				if snap, err := metrics.Take(s.source(c)); err == nil {
+					s.highWater = math.Max(s.highWater, snap.Value(memoryBytes))
+				}
+			}
+		}
+	}
+}
+
+// finish stops sampling and returns the cost of the given test since the sampler started.
+func (s *costSampler) finish(test string) (testCost, error) {
+	close(s.stop)
+	<-s.stopped
+
+	out := testCost{
+		Test:            test,
+		DurationSeconds: time.Since(s.start).Seconds(),
+	}
+	for i, c := range s.clusters {
+		after, err := metrics.Take(s.source(c))
+		if err != nil {
+			return testCost{}, fmt.Errorf("failed taking control plane metrics of %s: %v", c.Name(), err)
+		}
+		d := metrics.Compare(s.before[i], after)
+		out.CPUSeconds += d.Delta(cpuSeconds)
+		out.Pushes += d.Delta(xdsPushes)
+		s.highWater = math.Max(s.highWater, after.Value(memoryBytes))
+	}
+	out.MemoryHighWaterBytes = s.highWater
+	return out, nil
+}
+
+// registerCost records the control plane cost of a test, and rewrites the costs file.
+func (s *suiteContext) registerCost(c testCost) error {
+	s.costMu.Lock()
+	defer s.costMu.Unlock()
+	s.costs = append(s.costs, c)
+	out, err := yaml.Marshal(s.costs)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(s.settings.RunDir(), ControlPlaneCostsFile), out, os.ModePerm)
+}
+
+// logCostliestTests logs the tests that used the most istiod CPU, if costs were recorded.
+func logCostliestTests(ctx *suiteContext) {
+	ctx.costMu.Lock()
+	costs := append([]testCost(nil), ctx.costs...)
+	ctx.costMu.Unlock()
+	if len(costs) == 0 {
+		return
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i].CPUSeconds > costs[j].CPUSeconds
+	})
+	if len(costs) > costliestTests {
+		costs = costs[:costliestTests]
+	}
+	lines := make([]string, 0, len(costs))
+	for _, c := range costs {
+		lines = append(lines, "  "+c.String())
+	}
+	scopes.Framework.Infof("=== Costliest tests of suite %q for the control plane ===\n%s", ctx.Settings().TestID,
+		strings.Join(lines, "\n"))
+}
@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+func TestTestCostString(t *testing.T) {
+	c := testCost{
+		Test:                 "TestTraffic",
+		DurationSeconds:      62.4,
+		CPUSeconds:           12.34,
+		MemoryHighWaterBytes: 256 << 20,
+		Pushes:               42,
+	}
+	want := "TestTraffic: 12.3 istiod CPU seconds, 256 MiB memory high-water mark, 42 xDS pushes in 62s"
+	if got := c.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterCost(t *testing.T) {
+	settings := resource.DefaultSettings()
+	settings.TestID = "cost"
+	settings.BaseDir = t.TempDir()
+	if err := os.MkdirAll(settings.RunDir(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	s := &suiteContext{settings: settings}
+	for _, c := range []testCost{{Test: "TestA", CPUSeconds: 1}, {Test: "TestB", Pushes: 3}} {
+		if err := s.registerCost(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path.Join(settings.RunDir(), ControlPlaneCostsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []testCost
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Test != "TestA" || got[0].CPUSeconds != 1 || got[1].Pushes != 3 {
+		t.Fatalf("unexpected costs file content:\n%s", b)
+	}
+}
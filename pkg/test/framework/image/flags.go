@@ -17,6 +17,7 @@ package image
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"istio.io/istio/pkg/test/env"
 )
@@ -26,6 +27,7 @@ var (
 	settingsFromCommandLine = &Settings{
 		Hub:        env.HUB.ValueOrDefault("gcr.io/istio-testing"),
 		Tag:        env.TAG.ValueOrDefault("latest"),
+		Variant:    env.VARIANT.Value(),
 		PullPolicy: env.PULL_POLICY.Value(),
 		BitnamiHub: env.BITNAMIHUB.ValueOrDefault("docker.io/bitnami"),
 	}
@@ -48,6 +50,19 @@ func SettingsFromCommandLine() (*Settings, error) {
 		return nil, fmt.Errorf("values for Hub & Tag are not detected. Please supply them through command-line or via environment")
 	}
 
+	if s.Variant != DefaultVariant {
+		known := false
+		for _, v := range variants {
+			known = known || v == s.Variant
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown image variant %q, expected one of %v", s.Variant, variants)
+		}
+		if !strings.HasSuffix(s.Tag, "-"+s.Variant) {
+			s.Tag += "-" + s.Variant
+		}
+	}
+
 	return s, nil
 }
 
@@ -56,8 +71,14 @@ func init() {
 		"Container registry hub to use")
 	flag.StringVar(&settingsFromCommandLine.Tag, "istio.test.tag", settingsFromCommandLine.Tag,
 		"Common Container tag to use when deploying container images")
+	flag.StringVar(&settingsFromCommandLine.Variant, "istio.test.variant", settingsFromCommandLine.Variant,
+		fmt.Sprintf("Variant of the Istio images to use, one of %v. The images must be pushed as <tag>-<variant>. "+
+			"Only the distroless variant is built by this repository; fips images must be supplied externally", variants))
 	flag.StringVar(&settingsFromCommandLine.PullPolicy, "istio.test.pullpolicy", settingsFromCommandLine.PullPolicy,
 		"Common image pull policy to use when deploying container images")
 	flag.StringVar(&settingsFromCommandLine.BitnamiHub, "istio.test.bitnamihub", settingsFromCommandLine.BitnamiHub,
 		"Container registry to use to download binami images for the redis tests")
+	flag.StringVar(&settingsFromCommandLine.PullSecret, "istio.test.imagePullSecret", settingsFromCommandLine.PullSecret,
+		"Path to a docker config JSON file with the credentials of a private registry. It is created as an image pull "+
+			"secret in every test namespace, and referenced by the deployed pods")
 }
@@ -0,0 +1,71 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// CreatePullSecret creates the image pull secret in the namespace, and adds it to the default service account of
+// the namespace, so that pods which don't reference it still pull from the private registry. It does nothing if
+// no pull secret is configured.
+func (s *Settings) CreatePullSecret(client kubernetes.Interface, ns string) error {
+	if s.PullSecret == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(s.PullSecret)
+	if err != nil {
+		return fmt.Errorf("failed reading the image pull secret: %v", err)
+	}
+	secret := &kubeApiCore.Secret{
+		ObjectMeta: kubeApiMeta.ObjectMeta{Name: pullSecretName},
+		Type:       kubeApiCore.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{kubeApiCore.DockerConfigJsonKey: data},
+	}
+	if _, err := client.CoreV1().Secrets(ns).Create(context.TODO(), secret, kubeApiMeta.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed creating the image pull secret in %s: %v", ns, err)
+		}
+		if _, err := client.CoreV1().Secrets(ns).Update(context.TODO(), secret, kubeApiMeta.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed updating the image pull secret in %s: %v", ns, err)
+		}
+	}
+
+	// The default service account is created asynchronously once the namespace exists.
+	return retry.UntilSuccess(func() error {
+		sa, err := client.CoreV1().ServiceAccounts(ns).Get(context.TODO(), "default", kubeApiMeta.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name == pullSecretName {
+				return nil
+			}
+		}
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, kubeApiCore.LocalObjectReference{Name: pullSecretName})
+		_, err = client.CoreV1().ServiceAccounts(ns).Update(context.TODO(), sa, kubeApiMeta.UpdateOptions{})
+		return err
+	}, retry.Timeout(time.Minute), retry.Delay(time.Second))
+}
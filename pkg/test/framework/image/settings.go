@@ -14,7 +14,10 @@
 
 package image
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	// HubValuesKey values key for the Docker image hub.
@@ -26,23 +29,74 @@ const (
 	// ImagePullPolicyValuesKey values key for the Docker image pull policy.
 	ImagePullPolicyValuesKey = "global.imagePullPolicy"
 
+	// ImagePullSecretsValuesKey values key for the secrets used to pull the Docker images.
+	ImagePullSecretsValuesKey = "global.imagePullSecrets"
+
+	// pullSecretName is the name of the secret created from Settings.PullSecret in the test namespaces.
+	pullSecretName = "istio-test-pull-secret"
+
 	// LatestTag value
 	LatestTag = "latest"
 )
 
+// Image variants. Images of a variant are tagged <tag>-<variant>.
+const (
+	// DefaultVariant is the regular image, tagged with the tag alone.
+	DefaultVariant = ""
+	// DistrolessVariant is built on top of a distroless base image.
+	DistrolessVariant = "distroless"
+	// FIPSVariant is built with FIPS 140-2 validated crypto (BoringCrypto for Go and BoringSSL-FIPS for Envoy),
+	// and must only negotiate FIPS approved TLS parameters. This tree does not build it: tools/istio-docker.mk
+	// only builds the default and distroless variants, so FIPS images must be built and pushed externally.
+	FIPSVariant = "fips"
+)
+
+var variants = []string{DistrolessVariant, FIPSVariant}
+
 // Settings provide kube-specific Settings from flags.
 type Settings struct {
 	// Hub value to use in Helm templates
 	Hub string
 
-	// Tag value to use in Helm templates
+	// Tag value to use in Helm templates. If Variant is set, it includes the variant suffix.
 	Tag string
 
+	// Variant of the Istio images to use, such as distroless or fips.
+	Variant string
+
 	// Image pull policy to use for deployments. If not specified, the defaults of each deployment will be used.
 	PullPolicy string
 
 	// BitnamiHub value to use in Helm templates for bitnami images
 	BitnamiHub string
+
+	// PullSecret is the path to a docker config JSON file with the credentials of a private registry. If set, it
+	// is created as the PullSecretName() secret in every namespace of the tests, and referenced by their pods.
+	PullSecret string
+}
+
+// BaseTag returns the tag without the variant suffix. Test images, such as the echo app, are only built for the
+// default variant.
+func (s *Settings) BaseTag() string {
+	tag := s.Tag
+	for _, v := range variants {
+		tag = strings.TrimSuffix(tag, "-"+v)
+	}
+	return tag
+}
+
+// IsFIPS returns true if FIPS images are used.
+func (s *Settings) IsFIPS() bool {
+	return s.Variant == FIPSVariant
+}
+
+// PullSecretName returns the name of the image pull secret of the test namespaces, or an empty string if no pull
+// secret is configured.
+func (s *Settings) PullSecretName() string {
+	if s.PullSecret == "" {
+		return ""
+	}
+	return pullSecretName
 }
 
 func (s *Settings) clone() *Settings {
@@ -56,8 +110,10 @@ func (s *Settings) String() string {
 
 	result += fmt.Sprintf("Hub:             %s\n", s.Hub)
 	result += fmt.Sprintf("Tag:             %s\n", s.Tag)
+	result += fmt.Sprintf("Variant:         %s\n", s.Variant)
 	result += fmt.Sprintf("PullPolicy:      %s\n", s.PullPolicy)
 	result += fmt.Sprintf("BitnamiHub:      %s\n", s.BitnamiHub)
+	result += fmt.Sprintf("PullSecret:      %s\n", s.PullSecret)
 
 	return result
 }
@@ -0,0 +1,150 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Capability of a cluster, which tests can require. Capabilities are detected when queried, as setup functions may
+// change them, for example by installing CRDs.
+type Capability string
+
+const (
+	// CRDv1 indicates that the cluster serves apiextensions.k8s.io/v1 (Kubernetes 1.16+).
+	CRDv1 Capability = "CRDv1"
+	// GatewayAPI indicates that the Gateway API CRDs are installed.
+	GatewayAPI Capability = "GatewayAPI"
+	// EndpointSlice indicates that the cluster serves discovery.k8s.io/v1beta1 EndpointSlices.
+	EndpointSlice Capability = "EndpointSlice"
+	// DualStack indicates that the nodes are assigned both IPv4 and IPv6 pod CIDRs.
+	DualStack Capability = "DualStack"
+	// LoadBalancer indicates that LoadBalancer services obtain an external IP.
+	LoadBalancer Capability = "LoadBalancer"
+)
+
+// KubernetesVersion is the major and minor version of a Kubernetes cluster.
+type KubernetesVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseKubernetesVersion parses a version such as "1.19", "v1.19.3", or "1.19+" as reported by some providers.
+func ParseKubernetesVersion(v string) (KubernetesVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) < 2 {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q", v)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q: %v", v, err)
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(parts[1], "+"))
+	if err != nil {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q: %v", v, err)
+	}
+	return KubernetesVersion{Major: major, Minor: minor}, nil
+}
+
+// AtLeast returns true if the version is the same or later than the given one.
+func (v KubernetesVersion) AtLeast(min KubernetesVersion) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	return v.Minor >= min.Minor
+}
+
+func (v KubernetesVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Capabilities of a cluster.
+type Capabilities struct {
+	Version   KubernetesVersion
+	Supported map[Capability]bool
+}
+
+// Has returns true if the cluster has all the given capabilities.
+func (c Capabilities) Has(caps ...Capability) bool {
+	return len(c.Missing(caps...)) == 0
+}
+
+// Missing returns the given capabilities which the cluster does not have.
+func (c Capabilities) Missing(caps ...Capability) []Capability {
+	var out []Capability
+	for _, cp := range caps {
+		if !c.Supported[cp] {
+			out = append(out, cp)
+		}
+	}
+	return out
+}
+
+// Requirements on the clusters of the environment, declared by suites and tests.
+type Requirements struct {
+	// MinVersion is the minimum Kubernetes version, if set.
+	MinVersion *KubernetesVersion
+	// Capabilities required.
+	Capabilities []Capability
+}
+
+// Check returns a reason for each cluster which does not meet the requirements. Clusters whose capabilities
+// cannot be detected do not meet them.
+func (r Requirements) Check(clusters Clusters) []string {
+	if r.MinVersion == nil && len(r.Capabilities) == 0 {
+		return nil
+	}
+	var out []string
+	for _, c := range clusters {
+		caps, err := c.Capabilities()
+		if err != nil {
+			out = append(out, fmt.Sprintf("%s: failed detecting capabilities: %v", c.Name(), err))
+			continue
+		}
+		if r.MinVersion != nil && !caps.Version.AtLeast(*r.MinVersion) {
+			out = append(out, fmt.Sprintf("%s: Kubernetes %v is older than required %v", c.Name(), caps.Version, *r.MinVersion))
+		}
+		if missing := caps.Missing(r.Capabilities...); len(missing) > 0 {
+			out = append(out, fmt.Sprintf("%s: missing capabilities %v", c.Name(), missing))
+		}
+	}
+	return out
+}
+
+// Supporting returns the clusters which have all the given capabilities, so that tests can adjust to
+// heterogeneous environments rather than skipping. Clusters whose capabilities cannot be detected are excluded.
+func (c Clusters) Supporting(caps ...Capability) Clusters {
+	var out Clusters
+	for _, cc := range c {
+		if cp, err := cc.Capabilities(); err == nil && cp.Has(caps...) {
+			out = append(out, cc)
+		}
+	}
+	return out
+}
+
+// AtLeastVersion returns the clusters whose Kubernetes version is the same or later than the given one.
+// Clusters whose version cannot be detected are excluded.
+func (c Clusters) AtLeastVersion(min KubernetesVersion) Clusters {
+	var out Clusters
+	for _, cc := range c {
+		if cp, err := cc.Capabilities(); err == nil && cp.Version.AtLeast(min) {
+			out = append(out, cc)
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKubernetesVersion(t *testing.T) {
+	cases := map[string]KubernetesVersion{
+		"1.19":     {Major: 1, Minor: 19},
+		"v1.16.3":  {Major: 1, Minor: 16},
+		"1.18+":    {Major: 1, Minor: 18},
+		"v1.9.0-1": {Major: 1, Minor: 9},
+	}
+	for in, expected := range cases {
+		got, err := ParseKubernetesVersion(in)
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got != expected {
+			t.Fatalf("%s: expected %v, got %v", in, expected, got)
+		}
+	}
+	if _, err := ParseKubernetesVersion("1"); err == nil {
+		t.Fatal("expected error for version without minor")
+	}
+}
+
+func TestKubernetesVersionAtLeast(t *testing.T) {
+	v := KubernetesVersion{Major: 1, Minor: 9}
+	if v.AtLeast(KubernetesVersion{Major: 1, Minor: 16}) {
+		t.Fatal("1.9 must be older than 1.16")
+	}
+	if !v.AtLeast(KubernetesVersion{Major: 1, Minor: 9}) || !v.AtLeast(KubernetesVersion{Major: 0, Minor: 20}) {
+		t.Fatal("1.9 must be at least 1.9 and 0.20")
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	oldCluster := FakeCluster{NameValue: "old", CapabilitiesValue: Capabilities{
+		Version:   KubernetesVersion{Major: 1, Minor: 15},
+		Supported: map[Capability]bool{LoadBalancer: true},
+	}}
+	newCluster := FakeCluster{NameValue: "new", CapabilitiesValue: Capabilities{
+		Version:   KubernetesVersion{Major: 1, Minor: 19},
+		Supported: map[Capability]bool{LoadBalancer: true, CRDv1: true, GatewayAPI: true},
+	}}
+	clusters := Clusters{oldCluster, newCluster}
+
+	if unmet := (Requirements{Capabilities: []Capability{LoadBalancer}}).Check(clusters); len(unmet) != 0 {
+		t.Fatalf("expected requirements to be met, got %v", unmet)
+	}
+	min := KubernetesVersion{Major: 1, Minor: 16}
+	unmet := Requirements{MinVersion: &min, Capabilities: []Capability{GatewayAPI}}.Check(clusters)
+	expected := []string{
+		"old: Kubernetes 1.15 is older than required 1.16",
+		"old: missing capabilities [GatewayAPI]",
+	}
+	if !reflect.DeepEqual(unmet, expected) {
+		t.Fatalf("expected %v, got %v", expected, unmet)
+	}
+
+	if got := clusters.Supporting(GatewayAPI); len(got) != 1 || got[0].Name() != "new" {
+		t.Fatalf("expected only the new cluster to support the Gateway API, got %v", got.Names())
+	}
+	if got := clusters.AtLeastVersion(min); len(got) != 1 || got[0].Name() != "new" {
+		t.Fatalf("expected only the new cluster to be at least 1.16, got %v", got.Names())
+	}
+}
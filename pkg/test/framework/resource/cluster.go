@@ -94,6 +94,9 @@ type Cluster interface {
 
 	// Index of this Cluster within the Environment
 	Index() ClusterIndex
+
+	// Capabilities detects the Kubernetes version and the capabilities of the cluster.
+	Capabilities() (Capabilities, error)
 }
 
 var _ Cluster = FakeCluster{}
@@ -102,9 +105,10 @@ var _ Cluster = FakeCluster{}
 type FakeCluster struct {
 	kube.ExtendedClient
 
-	NameValue        string
-	NetworkNameValue string
-	IndexValue       int
+	NameValue         string
+	NetworkNameValue  string
+	IndexValue        int
+	CapabilitiesValue Capabilities
 }
 
 func (m FakeCluster) String() string {
@@ -122,3 +126,7 @@ func (m FakeCluster) NetworkName() string {
 func (m FakeCluster) Index() ClusterIndex {
 	return ClusterIndex(m.IndexValue)
 }
+
+func (m FakeCluster) Capabilities() (Capabilities, error) {
+	return m.CapabilitiesValue, nil
+}
@@ -41,6 +41,23 @@ type ConfigManager interface {
 
 	// WithFilePrefix sets the prefix used for intermediate files.
 	WithFilePrefix(prefix string) ConfigManager
+
+	// WithApplyOptions returns a ConfigManager that applies config with the given options.
+	WithApplyOptions(opts ApplyOptions) ConfigManager
+}
+
+// ApplyOptions control how a ConfigManager applies config.
+type ApplyOptions struct {
+	// ServerSide applies config with server-side apply instead of a client-side three-way merge.
+	ServerSide bool
+
+	// FieldManager is the field manager used for server-side apply, and identifies the set of objects
+	// tracked for pruning. If empty, a field manager derived from the current test is used.
+	FieldManager string
+
+	// Prune deletes the objects previously applied with the same field manager, to the same namespace,
+	// that are missing from the newly applied config.
+	Prune bool
 }
 
 // Context is the core context interface that is used by resources.
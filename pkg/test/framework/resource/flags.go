@@ -18,6 +18,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"istio.io/istio/pkg/test/framework/label"
 )
@@ -42,6 +43,10 @@ func SettingsFromCommandLine(testID string) (*Settings, error) {
 	}
 	s.Selector = f
 
+	if s.MaxControlPlaneCPUPerTest > 0 {
+		s.CostReport = true
+	}
+
 	if s.FailOnDeprecation && s.NoCleanup {
 		return nil,
 			fmt.Errorf("checking for deprecation occurs at cleanup level, thus flags -istio.test.nocleanup and" +
@@ -76,4 +81,18 @@ func init() {
 
 	flag.BoolVar(&settingsFromCommandLine.FailOnDeprecation, "istio.test.deprecation_failure", settingsFromCommandLine.FailOnDeprecation,
 		"Make tests fail if any usage of deprecated stuff (e.g. Envoy flags) is detected.")
+
+	flag.BoolVar(&settingsFromCommandLine.CostReport, "istio.test.costreport", settingsFromCommandLine.CostReport,
+		"Attribute the CPU, memory high-water mark and xDS pushes of istiod to each test, and write them to the run dir. "+
+			"Tests running in parallel are each charged the full cost incurred while they overlap.")
+
+	flag.Float64Var(&settingsFromCommandLine.MaxControlPlaneCPUPerTest, "istio.test.maxControlPlaneCPUPerTest",
+		settingsFromCommandLine.MaxControlPlaneCPUPerTest,
+		"Fail tests during which istiod used more CPU seconds than this. Implies -istio.test.costreport.")
+
+	flag.DurationVar(&settingsFromCommandLine.SoakDuration, "istio.test.soak.duration", settingsFromCommandLine.SoakDuration,
+		"If set, run the soak scenarios of the suites for this long instead of their tests.")
+
+	flag.DurationVar(&settingsFromCommandLine.SoakCheckInterval, "istio.test.soak.checkInterval", 5*time.Minute,
+		"Interval between the health checks of soak mode.")
 }
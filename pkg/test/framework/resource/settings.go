@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -57,6 +58,22 @@ type Settings struct {
 	// This is useful when combined with NoCleanup, to allow quickly iterating on tests.
 	StableNamespaces bool
 
+	// If enabled, the CPU, memory high-water mark and xDS pushes of istiod are attributed to each top-level test
+	// and written to the run dir. Tests running in parallel are each charged the full cost incurred while they
+	// overlap.
+	CostReport bool
+
+	// If non-zero, top-level tests fail when istiod used more CPU seconds than this while they ran. Implies
+	// CostReport.
+	MaxControlPlaneCPUPerTest float64
+
+	// If non-zero, suites run in soak mode: after setup, their soak scenarios are looped for this long instead of
+	// running their tests, while the soak checks are asserted every SoakCheckInterval.
+	SoakDuration time.Duration
+
+	// SoakCheckInterval is the interval between soak checks.
+	SoakCheckInterval time.Duration
+
 	// The label selector that the user has specified.
 	SelectorString string
 
@@ -108,5 +125,9 @@ func (s *Settings) String() string {
 	result += fmt.Sprintf("CIMode:            %v\n", s.CIMode)
 	result += fmt.Sprintf("Retries:           %v\n", s.Retries)
 	result += fmt.Sprintf("StableNamespaces:  %v\n", s.StableNamespaces)
+	result += fmt.Sprintf("CostReport:        %v\n", s.CostReport)
+	result += fmt.Sprintf("MaxCPUPerTest:     %v\n", s.MaxControlPlaneCPUPerTest)
+	result += fmt.Sprintf("SoakDuration:      %v\n", s.SoakDuration)
+	result += fmt.Sprintf("SoakCheckInterval: %v\n", s.SoakCheckInterval)
 	return result
 }
@@ -0,0 +1,279 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/metrics"
+)
+
+const (
+	// SoakReportFile is the name of the file, in the run directory of the suite, where the time series of the soak
+	// checks is written in soak mode. It is rewritten after every round of checks.
+	SoakReportFile = "soak-report.yaml"
+
+	defaultSoakCheckInterval = 5 * time.Minute
+)
+
+// SoakScenario is a step of the soak loop, such as sending traffic for a while or churning config. The loop runs
+// the scenarios of the suite one after the other until the soak duration elapses, so a scenario should return
+// in a few minutes at most, and clean up the resources it creates.
+type SoakScenario struct {
+	Name string
+	Run  func(ctx resource.Context) error
+}
+
+// SoakCheck is asserted every soak check interval, and once more when the soak run ends. The values it returns,
+// such as the memory used by a proxy, are added to the time series of the report. The soak run fails as soon as a
+// check returns an error.
+type SoakCheck struct {
+	Name  string
+	Check func(ctx resource.Context) (map[string]float64, error)
+}
+
+// SoakConfig configures the soak mode of a suite.
+type SoakConfig struct {
+	Scenarios []SoakScenario
+	// Checks are asserted in addition to the health of the control plane.
+	Checks []SoakCheck
+}
+
+// SoakConfigFn returns the soak configuration of a suite. It is called once the setup functions of the suite ran,
+// so that the scenarios and checks can refer to the resources they created, such as echo instances.
+type SoakConfigFn func(ctx resource.Context) SoakConfig
+
+// soakSample is the result of a soak check at a point in time.
+type soakSample struct {
+	Time           time.Time          `yaml:"time"`
+	ElapsedSeconds float64            `yaml:"elapsedSeconds"`
+	Check          string             `yaml:"check"`
+	Values         map[string]float64 `yaml:"values,omitempty"`
+	Error          string             `yaml:"error,omitempty"`
+}
+
+// soakReport is the time series of a soak run.
+type soakReport struct {
+	Suite           string         `yaml:"suite"`
+	Start           time.Time      `yaml:"start"`
+	DurationSeconds float64        `yaml:"durationSeconds"`
+	Iterations      map[string]int `yaml:"iterations"`
+	Failure         string         `yaml:"failure,omitempty"`
+	Samples         []soakSample   `yaml:"samples"`
+}
+
+// soakRunner loops the scenarios of a suite while asserting its checks in the background.
+type soakRunner struct {
+	ctx        resource.Context
+	reportFile string
+	duration   time.Duration
+	interval   time.Duration
+	scenarios  []SoakScenario
+	checks     []SoakCheck
+
+	mu     sync.Mutex
+	report soakReport
+	failed chan struct{}
+	once   sync.Once
+}
+
+func newSoakRunner(ctx resource.Context, cfg SoakConfig) *soakRunner {
+	settings := ctx.Settings()
+	interval := settings.SoakCheckInterval
+	if interval <= 0 {
+		interval = defaultSoakCheckInterval
+	}
+	return &soakRunner{
+		ctx:        ctx,
+		reportFile: path.Join(settings.RunDir(), SoakReportFile),
+		duration:   settings.SoakDuration,
+		interval:   interval,
+		scenarios:  cfg.Scenarios,
+		checks:     append([]SoakCheck{controlPlaneHealthCheck()}, cfg.Checks...),
+		report: soakReport{
+			Suite:      settings.TestID,
+			Iterations: map[string]int{},
+		},
+		failed: make(chan struct{}),
+	}
+}
+
+// run loops the scenarios until the soak duration elapsed or a scenario or check failed.
+func (r *soakRunner) run() error {
+	start := time.Now()
+	r.report.Start = start
+	deadline := start.Add(r.duration)
+	scopes.Framework.Infof("=== BEGIN: Soak [Suite=%s] for %v, scenarios: %v ===", r.report.Suite, r.duration,
+		soakScenarioNames(r.scenarios))
+
+	r.runChecks(start)
+	stop := make(chan struct{})
+	checksDone := make(chan struct{})
+	go func() {
+		defer close(checksDone)
+		t := time.NewTicker(r.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				r.runChecks(start)
+			}
+		}
+	}()
+
+loop:
+	for time.Now().Before(deadline) {
+		for _, s := range r.scenarios {
+			select {
+			case <-r.failed:
+				break loop
+			default:
+			}
+			if !time.Now().Before(deadline) {
+				break loop
+			}
+			scopes.Framework.Infof("soak: running scenario %s", s.Name)
+			if err := s.Run(r.ctx); err != nil {
+				r.fail(fmt.Errorf("scenario %s failed: %v", s.Name, err))
+				break loop
+			}
+			r.mu.Lock()
+			r.report.Iterations[s.Name]++
+			r.mu.Unlock()
+		}
+	}
+	close(stop)
+	<-checksDone
+
+	// Assert the state the soak run ended in, unless it already failed.
+	select {
+	case <-r.failed:
+	default:
+		r.runChecks(start)
+	}
+
+	r.mu.Lock()
+	r.report.DurationSeconds = time.Since(start).Seconds()
+	failure := r.report.Failure
+	r.mu.Unlock()
+	if err := r.writeReport(); err != nil {
+		scopes.Framework.Warnf("failed writing soak report: %v", err)
+	}
+	scopes.Framework.Infof("=== DONE: Soak [Suite=%s], report written to %s ===", r.report.Suite, r.reportFile)
+	if failure != "" {
+		return fmt.Errorf("%s", failure)
+	}
+	return nil
+}
+
+// runChecks runs all checks once, records their samples and rewrites the report.
+func (r *soakRunner) runChecks(start time.Time) {
+	for _, c := range r.checks {
+		now := time.Now()
+		values, err := c.Check(r.ctx)
+		sample := soakSample{
+			Time:           now,
+			ElapsedSeconds: now.Sub(start).Seconds(),
+			Check:          c.Name,
+			Values:         values,
+		}
+		if err != nil {
+			sample.Error = err.Error()
+			r.fail(fmt.Errorf("check %s failed after %v: %v", c.Name, now.Sub(start).Round(time.Second), err))
+		}
+		r.mu.Lock()
+		r.report.Samples = append(r.report.Samples, sample)
+		r.mu.Unlock()
+	}
+	if err := r.writeReport(); err != nil {
+		scopes.Framework.Warnf("failed writing soak report: %v", err)
+	}
+}
+
+// fail records the first failure of the soak run and stops the loop.
+func (r *soakRunner) fail(err error) {
+	r.once.Do(func() {
+		scopes.Framework.Errorf("soak: %v", err)
+		r.mu.Lock()
+		r.report.Failure = err.Error()
+		r.mu.Unlock()
+		close(r.failed)
+	})
+}
+
+func (r *soakRunner) writeReport() error {
+	r.mu.Lock()
+	out, err := yaml.Marshal(r.report)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.reportFile, out, os.ModePerm)
+}
+
+// controlPlaneHealthCheck checks that the istiod pods of the control plane clusters are ready, and samples their
+// memory, CPU and xDS pushes.
+func controlPlaneHealthCheck() SoakCheck {
+	return SoakCheck{
+		Name: "control-plane",
+		Check: func(ctx resource.Context) (map[string]float64, error) {
+			env, ok := ctx.Environment().(*kube.Environment)
+			if !ok {
+				return nil, nil
+			}
+			out := map[string]float64{}
+			for _, c := range env.ControlPlaneClusters() {
+				if _, err := testKube.CheckPodsAreReady(testKube.NewPodFetch(c, "", "app=istiod")); err != nil {
+					return out, fmt.Errorf("istiod of %s is not ready: %v", c.Name(), err)
+				}
+				snap, err := metrics.Take(func() (map[string]*dto.MetricFamily, error) {
+					return testKube.IstiodMetrics(ctx, c)
+				})
+				if err != nil {
+					return out, fmt.Errorf("failed taking control plane metrics of %s: %v", c.Name(), err)
+				}
+				out[c.Name()+"/memoryBytes"] = snap.Value(memoryBytes)
+				out[c.Name()+"/cpuSeconds"] = snap.Value(cpuSeconds)
+				out[c.Name()+"/pushes"] = snap.Value(xdsPushes)
+			}
+			return out, nil
+		},
+	}
+}
+
+// soakScenarioNames returns the sorted names of the scenarios, for logging.
+func soakScenarioNames(scenarios []SoakScenario) []string {
+	out := make([]string, 0, len(scenarios))
+	for _, s := range scenarios {
+		out = append(out, s.Name)
+	}
+	sort.Strings(out)
+	return out
+}
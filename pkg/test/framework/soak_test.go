@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+func newTestSoakRunner(t *testing.T, duration time.Duration, scenarios []SoakScenario, checks ...SoakCheck) *soakRunner {
+	dir, err := ioutil.TempDir("", "soak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return &soakRunner{
+		reportFile: filepath.Join(dir, SoakReportFile),
+		duration:   duration,
+		interval:   20 * time.Millisecond,
+		scenarios:  scenarios,
+		checks:     checks,
+		report: soakReport{
+			Suite:      "soak",
+			Iterations: map[string]int{},
+		},
+		failed: make(chan struct{}),
+	}
+}
+
+func readSoakReport(t *testing.T, r *soakRunner) soakReport {
+	b, err := ioutil.ReadFile(r.reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := soakReport{}
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSoakRunner(t *testing.T) {
+	g := NewWithT(t)
+	var memory int64
+	r := newTestSoakRunner(t, 200*time.Millisecond, []SoakScenario{{
+		Name: "sleep",
+		Run: func(resource.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+	}}, SoakCheck{
+		Name: "memory",
+		Check: func(resource.Context) (map[string]float64, error) {
+			return map[string]float64{"bytes": float64(atomic.AddInt64(&memory, 1))}, nil
+		},
+	})
+	g.Expect(r.run()).To(Succeed())
+
+	report := readSoakReport(t, r)
+	g.Expect(report.Failure).To(BeEmpty())
+	g.Expect(report.Iterations["sleep"]).To(BeNumerically(">", 1))
+	g.Expect(report.DurationSeconds).To(BeNumerically(">=", 0.2))
+	// The checks run when the soak starts, periodically and when it ends.
+	g.Expect(len(report.Samples)).To(BeNumerically(">=", 3))
+	last := report.Samples[len(report.Samples)-1]
+	g.Expect(last.Check).To(Equal("memory"))
+	g.Expect(last.Values["bytes"]).To(Equal(float64(len(report.Samples))))
+}
+
+func TestSoakRunnerFailures(t *testing.T) {
+	noop := SoakScenario{
+		Name: "noop",
+		Run: func(resource.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		},
+	}
+	cases := []struct {
+		name      string
+		scenarios []SoakScenario
+		checks    []SoakCheck
+		err       string
+	}{
+		{
+			name:      "scenario",
+			scenarios: []SoakScenario{noop, {Name: "broken", Run: func(resource.Context) error { return fmt.Errorf("boom") }}},
+			err:       "scenario broken failed: boom",
+		},
+		{
+			name:      "check",
+			scenarios: []SoakScenario{noop},
+			checks: []SoakCheck{{
+				Name: "leak",
+				Check: func() func(resource.Context) (map[string]float64, error) {
+					calls := int64(0)
+					return func(resource.Context) (map[string]float64, error) {
+						if atomic.AddInt64(&calls, 1) > 2 {
+							return nil, fmt.Errorf("memory grew")
+						}
+						return nil, nil
+					}
+				}(),
+			}},
+			err: "check leak failed",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := newTestSoakRunner(t, time.Minute, tt.scenarios, tt.checks...)
+			start := time.Now()
+			err := r.run()
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(ContainSubstring(tt.err))
+			// A failure stops the soak run early.
+			g.Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+			g.Expect(readSoakReport(t, r).Failure).To(ContainSubstring(tt.err))
+		})
+	}
+}
@@ -90,10 +90,18 @@ type Suite interface {
 	RequireMaxClusters(maxClusters int) Suite
 	// RequireSingleCluster is a utility method that requires that there be exactly 1 cluster in the environment.
 	RequireSingleCluster() Suite
-	// RequireEnvironmentVersion validates the environment meets a minimum version
+	// RequireEnvironmentVersion validates that all the clusters run at least the given Kubernetes version, such
+	// as "1.18". Otherwise it stops test execution.
 	RequireEnvironmentVersion(version string) Suite
+	// RequireCapabilities validates that all the clusters have the given capabilities. Otherwise it stops test
+	// execution.
+	RequireCapabilities(caps ...resource.Capability) Suite
 	// Setup runs enqueues the given setup function to run before test execution.
 	Setup(fn resource.SetupFn) Suite
+	// Soak sets the scenarios and checks of the suite in soak mode (-istio.test.soak.duration). In soak mode, the
+	// scenarios returned by fn after setup are looped instead of running the tests, and suites without scenarios
+	// are skipped.
+	Soak(fn SoakConfigFn) Suite
 	// Run the suite. This method calls os.Exit and does not return.
 	Run()
 }
@@ -108,6 +116,7 @@ type suiteImpl struct {
 
 	requireFns []resource.SetupFn
 	setupFns   []resource.SetupFn
+	soak       SoakConfigFn
 
 	getSettings getSettingsFunc
 	envFactory  resource.EnvironmentFactory
@@ -223,15 +232,22 @@ func (s *suiteImpl) RequireSingleCluster() Suite {
 }
 
 func (s *suiteImpl) RequireEnvironmentVersion(version string) Suite {
+	v, err := resource.ParseKubernetesVersion(version)
+	if err != nil {
+		panic(fmt.Sprintf("invalid required Kubernetes version: %v", err))
+	}
+	return s.require(resource.Requirements{MinVersion: &v})
+}
+
+func (s *suiteImpl) RequireCapabilities(caps ...resource.Capability) Suite {
+	return s.require(resource.Requirements{Capabilities: caps})
+}
+
+// require skips the suite unless all the clusters meet the requirements.
+func (s *suiteImpl) require(r resource.Requirements) Suite {
 	fn := func(ctx resource.Context) error {
-		ver, err := ctx.Clusters()[0].GetKubernetesVersion()
-		if err != nil {
-			return fmt.Errorf("failed to get Kubernetes version: %v", err)
-		}
-		serverVersion := fmt.Sprintf("%s.%s", ver.Major, ver.Minor)
-		if serverVersion < version {
-			s.Skip(fmt.Sprintf("Required Kubernetes version (%v) is greater than current: %v",
-				version, serverVersion))
+		if unmet := r.Check(clusters(ctx)); len(unmet) > 0 {
+			s.Skip(strings.Join(unmet, "; "))
 		}
 		return nil
 	}
@@ -245,6 +261,11 @@ func (s *suiteImpl) Setup(fn resource.SetupFn) Suite {
 	return s
 }
 
+func (s *suiteImpl) Soak(fn SoakConfigFn) Suite {
+	s.soak = fn
+	return s
+}
+
 func (s *suiteImpl) runSetupFn(fn resource.SetupFn, ctx SuiteContext) (err error) {
 	defer func() {
 		// Dump if the setup function fails
@@ -300,12 +321,23 @@ func (s *suiteImpl) run() (errLevel int) {
 		return s.doSkip(ctx)
 	}
 
+	// In soak mode, only the suites with soak scenarios run.
+	if ctx.Settings().SoakDuration > 0 && s.soak == nil {
+		s.Skip("Soak mode: the suite has no soak scenarios")
+		return s.doSkip(ctx)
+	}
+
 	start := time.Now()
 
 	defer func() {
-		if errLevel != 0 && ctx.Settings().CIMode {
-			rt.Dump(ctx)
+		if errLevel != 0 {
+			// The triage bundle is built from the dumped resources, so dump them whenever it is written.
+			if ctx.Settings().CIMode || triageArtifactsDir() != "" {
+				rt.Dump(ctx)
+			}
+			writeTriageBundle(ctx)
 		}
+		logCostliestTests(ctx)
 
 		if err := rt.Close(); err != nil {
 			scopes.Framework.Errorf("Error during close: %v", err)
@@ -333,6 +365,19 @@ func (s *suiteImpl) run() (errLevel int) {
 		scopes.Framework.Infof("=== Suite %q run time: %v ===", ctx.Settings().TestID, end.Sub(start))
 	}()
 
+	if ctx.Settings().SoakDuration > 0 {
+		cfg := s.soak(ctx)
+		if len(cfg.Scenarios) == 0 {
+			s.Skip("Soak mode: the suite has no soak scenarios")
+			return s.doSkip(ctx)
+		}
+		if err := newSoakRunner(ctx, cfg).run(); err != nil {
+			scopes.Framework.Errorf("=== FAILED: Soak: '%s': %v ===", ctx.Settings().TestID, err)
+			return 1
+		}
+		return 0
+	}
+
 	attempt := 0
 	for attempt <= ctx.settings.Retries {
 		attempt++
@@ -57,6 +57,12 @@ type suiteContext struct {
 
 	outcomeMu    sync.RWMutex
 	testOutcomes []TestOutcome
+
+	benchmarkMu sync.Mutex
+	benchmarks  map[string]string
+
+	costMu sync.Mutex
+	costs  []testCost
 }
 
 func newSuiteContext(s *resource.Settings, envFn resource.EnvironmentFactory, labels label.Set) (*suiteContext, error) {
@@ -16,12 +16,14 @@ package framework
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/scopes"
 	"istio.io/pkg/log"
 )
@@ -40,6 +42,13 @@ type Test interface {
 	RequiresMaxClusters(maxClusters int) Test
 	// RequiresSingleCluster this a utility that requires the min/max clusters to both = 1.
 	RequiresSingleCluster() Test
+	// RequiresKubernetesVersion ensures that all the clusters run at least the given Kubernetes version, such as
+	// "1.18". Otherwise it stops test execution and skips the test.
+	RequiresKubernetesVersion(version string) Test
+	// RequiresCapabilities ensures that all the clusters have the given capabilities. Otherwise it stops test
+	// execution and skips the test. Tests which can run on a subset of the clusters should instead select them with
+	// Clusters().Supporting.
+	RequiresCapabilities(caps ...resource.Capability) Test
 	// Run the test, supplied as a lambda.
 	Run(fn func(ctx TestContext))
 	// RunParallel runs this test in parallel with other children of the same parent test/suite. Under the hood,
@@ -104,6 +113,7 @@ type testImpl struct {
 	s                   *suiteContext
 	requiredMinClusters int
 	requiredMaxClusters int
+	requirements        resource.Requirements
 
 	ctx *testContext
 
@@ -173,6 +183,20 @@ func (t *testImpl) RequiresSingleCluster() Test {
 	return t.RequiresMaxClusters(1).RequiresMinClusters(1)
 }
 
+func (t *testImpl) RequiresKubernetesVersion(version string) Test {
+	v, err := resource.ParseKubernetesVersion(version)
+	if err != nil {
+		panic(fmt.Sprintf("invalid required Kubernetes version: %v", err))
+	}
+	t.requirements.MinVersion = &v
+	return t
+}
+
+func (t *testImpl) RequiresCapabilities(caps ...resource.Capability) Test {
+	t.requirements.Capabilities = append(t.requirements.Capabilities, caps...)
+	return t
+}
+
 func (t *testImpl) Run(fn func(ctx TestContext)) {
 	t.runInternal(fn, false)
 }
@@ -231,8 +255,20 @@ func (t *testImpl) doRun(ctx *testContext, fn func(ctx TestContext), parallel bo
 		return
 	}
 
+	if unmet := t.requirements.Check(t.s.Environment().Clusters()); len(unmet) > 0 {
+		ctx.Done()
+		t.goTest.Skipf("Skipping %q: %s", t.goTest.Name(), strings.Join(unmet, "; "))
+		return
+	}
+
 	start := time.Now()
 
+	// Only top-level tests are attributed a control plane cost, as subtests share the cost of their parent.
+	var cost *costSampler
+	if t.parent == nil && rt.suiteContext().Settings().CostReport {
+		cost = startCostSampling(rt.suiteContext())
+	}
+
 	scopes.Framework.Infof("=== BEGIN: Test: '%s[%s]' ===", rt.suiteContext().Settings().TestID, t.goTest.Name())
 
 	// Initial setup if we're running in Parallel.
@@ -259,6 +295,9 @@ func (t *testImpl) doRun(ctx *testContext, fn func(ctx TestContext), parallel bo
 				rt.suiteContext().Settings().TestID,
 				t.goTest.Name(),
 				end.Sub(start))
+			if cost != nil {
+				t.reportCost(cost)
+			}
 			rt.suiteContext().registerOutcome(t)
 			ctx.Done()
 			if t.hasParallelChildren {
@@ -278,3 +317,25 @@ func (t *testImpl) doRun(ctx *testContext, fn func(ctx TestContext), parallel bo
 
 	fn(ctx)
 }
+
+// reportCost records the control plane cost of the test, and fails it if it exceeds the configured maximum.
+func (t *testImpl) reportCost(cost *costSampler) {
+	c, err := cost.finish(t.goTest.Name())
+	if err != nil {
+		scopes.Framework.Warnf("failed measuring the control plane cost of %s: %v", t.goTest.Name(), err)
+		return
+	}
+	scopes.Framework.Infof("Control plane cost of %s", c)
+	if err := rt.suiteContext().registerCost(c); err != nil {
+		scopes.Framework.Errorf("failed writing control plane costs: %v", err)
+	}
+	if max := rt.suiteContext().Settings().MaxControlPlaneCPUPerTest; max > 0 && c.CPUSeconds > max {
+		msg := fmt.Sprintf("istiod used %.1f CPU seconds during the test, above the maximum of %.1f", c.CPUSeconds, max)
+		if t.hasParallelChildren {
+			// The Go test already returned, so it can no longer be failed.
+			scopes.Framework.Errorf("%s: %s", t.goTest.Name(), msg)
+			return
+		}
+		t.goTest.Error(msg)
+	}
+}
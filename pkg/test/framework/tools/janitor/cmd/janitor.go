@@ -0,0 +1,282 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/framework/components/externalservice"
+	"istio.io/istio/pkg/test/framework/components/istio/installindex"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	kube2 "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+const (
+	// eastWestGatewayLabel selects the east-west gateways deployed by the istio component.
+	eastWestGatewayLabel = "istio=eastwestgateway"
+	// istiodLabel and injectorLabel select the control plane of a primary or remote cluster.
+	istiodLabel   = "app=istiod"
+	injectorLabel = "app=sidecar-injector"
+)
+
+type cluster struct {
+	name string
+	// server is the address of the API server, which the install index keys the manifests by.
+	server string
+	kube   kubernetes.Interface
+	// client deletes the install manifests.
+	client kube.ExtendedClient
+}
+
+// leftover is a resource left behind by a previous test run.
+type leftover struct {
+	cluster string
+	kind    string
+	name    string
+	age     time.Duration
+	// remove deletes the resource. It is nil if the resource cannot be deleted.
+	remove func() error
+}
+
+// liveRuns returns the IDs of the runs of the install index still in progress on this host.
+func liveRuns(index map[string]*installindex.Record) map[string]bool {
+	out := map[string]bool{}
+	for runID, r := range index {
+		if r.Local() && !r.Dead() {
+			out[runID] = true
+		}
+	}
+	return out
+}
+
+// findNamespaces lists the namespaces created by the test framework, except those of the given live runs.
+func findNamespaces(c cluster, live map[string]bool) ([]leftover, error) {
+	namespaces, err := c.kube.CoreV1().Namespaces().List(context.TODO(), kubeApiMeta.ListOptions{
+		LabelSelector: namespace.TestingLabel + "=" + namespace.TestingLabelValue,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var out []leftover
+	for _, ns := range namespaces.Items {
+		age := time.Since(ns.CreationTimestamp.Time)
+		if age < olderThan || ns.Status.Phase == kubeApiCore.NamespaceTerminating || live[ns.Labels[namespace.RunIDLabel]] {
+			continue
+		}
+		name := ns.Name
+		out = append(out, leftover{
+			cluster: c.name,
+			kind:    "Namespace",
+			name:    name,
+			age:     age,
+			remove: func() error {
+				return c.kube.CoreV1().Namespaces().Delete(context.TODO(), name, kube2.DeleteOptionsForeground())
+			},
+		})
+	}
+	return out, nil
+}
+
+// findEastWestGateways lists the east-west gateways of clusters that no longer run or point to a control plane.
+func findEastWestGateways(c cluster) ([]leftover, error) {
+	gateways, err := c.kube.AppsV1().Deployments(istioNamespace).List(context.TODO(), kubeApiMeta.ListOptions{
+		LabelSelector: eastWestGatewayLabel,
+	})
+	if err != nil || len(gateways.Items) == 0 {
+		return nil, err
+	}
+	istiods, err := c.kube.AppsV1().Deployments(istioNamespace).List(context.TODO(), kubeApiMeta.ListOptions{
+		LabelSelector: istiodLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	injectors, err := c.kube.AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(),
+		kubeApiMeta.ListOptions{LabelSelector: injectorLabel})
+	if err != nil {
+		return nil, err
+	}
+	if len(istiods.Items) > 0 || len(injectors.Items) > 0 {
+		return nil, nil
+	}
+
+	var out []leftover
+	for _, gw := range gateways.Items {
+		age := time.Since(gw.CreationTimestamp.Time)
+		if age < olderThan {
+			continue
+		}
+		name := gw.Name
+		out = append(out, leftover{
+			cluster: c.name,
+			kind:    "EastWestGateway",
+			name:    istioNamespace + "/" + name,
+			age:     age,
+			remove: func() error {
+				if err := c.kube.CoreV1().Services(istioNamespace).Delete(context.TODO(), name,
+					kubeApiMeta.DeleteOptions{}); err != nil && !kubeErrors.IsNotFound(err) {
+					return err
+				}
+				return c.kube.AppsV1().Deployments(istioNamespace).Delete(context.TODO(), name,
+					kube2.DeleteOptionsForeground())
+			},
+		})
+	}
+	return out, nil
+}
+
+// findStubDomains lists the hostnames the cluster DNS forwards for the external services of namespaces that no longer
+// exist or are listed for deletion. They would make CoreDNS reject the overlapping hostnames of later runs.
+func findStubDomains(c cluster, namespaces []leftover) ([]leftover, error) {
+	listed := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		listed[ns.name] = true
+	}
+	stubs, err := externalservice.StubDomains(c.kube)
+	if err != nil {
+		return nil, err
+	}
+	var out []leftover
+	for _, ns := range stubs {
+		if !listed[ns] {
+			_, err := c.kube.CoreV1().Namespaces().Get(context.TODO(), ns, kubeApiMeta.GetOptions{})
+			if err == nil {
+				continue
+			}
+			if !kubeErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		name := ns
+		out = append(out, leftover{
+			cluster: c.name,
+			kind:    "StubDomain",
+			name:    name,
+			remove: func() error {
+				return externalservice.RemoveStubDomains(c.kube, c.name, name)
+			},
+		})
+	}
+	return out, nil
+}
+
+// findManifests lists the installs of the runs recorded in the install index of the work directory, one per run
+// and cluster. The runs that cleaned up are no longer recorded, and the given live runs are skipped. Removing an
+// install deletes the resources of its manifests, except for the CRDs, in the reverse order they were applied, and
+// then removes it from the index.
+func findManifests(clusters []cluster, index map[string]*installindex.Record, live map[string]bool) []leftover {
+	byServer := make(map[string]cluster, len(clusters))
+	for _, c := range clusters {
+		byServer[c.server] = c
+	}
+
+	var out []leftover
+	for runID, r := range index {
+		if live[runID] {
+			continue
+		}
+		for server, manifests := range r.Manifests {
+			age, ok := manifestsAge(manifests)
+			if ok && age < olderThan {
+				continue
+			}
+			l := leftover{
+				cluster: server,
+				kind:    "Install",
+				name:    runID,
+				age:     age,
+			}
+			if c, ok := byServer[server]; ok {
+				l.cluster = c.name
+				runID, server, manifests := runID, server, manifests
+				l.remove = func() error {
+					for idx := len(manifests) - 1; idx >= 0; idx-- {
+						if err := deleteManifest(c.client, manifests[idx]); err != nil {
+							return err
+						}
+					}
+					return installindex.Forget(workDir, runID, server)
+				}
+			}
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// manifestsAge returns the time since the last of the given manifests was saved. It returns false if none of them
+// is left.
+func manifestsAge(manifests []string) (time.Duration, bool) {
+	var latest time.Time
+	for _, m := range manifests {
+		if info, err := os.Stat(m); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	if latest.IsZero() {
+		return 0, false
+	}
+	return time.Since(latest), true
+}
+
+func deleteManifest(client kube.ExtendedClient, file string) error {
+	contents, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		// Already deleted by an earlier attempt.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	parts, err := yml.Parse(string(contents))
+	if err != nil {
+		return err
+	}
+	// Keep the CRDs, deleting them would delete the config of every other install in the cluster.
+	nonCrds := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Descriptor.Kind != "CustomResourceDefinition" {
+			nonCrds = append(nonCrds, p.Contents)
+		}
+	}
+
+	tmp, err := ioutil.TempFile("", "janitor-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.WriteString(yml.JoinString(nonCrds...)); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := client.DeleteYAMLFiles("", tmp.Name()); err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
@@ -0,0 +1,92 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+// testNamespace returns a namespace created by the given run, the given time ago.
+func testNamespace(name, runID string, age time.Duration) *kubeApiCore.Namespace {
+	return &kubeApiCore.Namespace{
+		ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				namespace.TestingLabel: namespace.TestingLabelValue,
+				namespace.RunIDLabel:   runID,
+			},
+			CreationTimestamp: kubeApiMeta.NewTime(time.Now().Add(-age)),
+		},
+	}
+}
+
+func newTestCluster(objects ...runtime.Object) cluster {
+	return cluster{
+		name:   "cluster-0",
+		server: "https://cluster-0",
+		kube:   fake.NewSimpleClientset(objects...),
+	}
+}
+
+func TestFindNamespaces(t *testing.T) {
+	olderThan = time.Hour
+	terminating := testNamespace("terminating", "dead-run", 2*time.Hour)
+	terminating.Status.Phase = kubeApiCore.NamespaceTerminating
+	c := newTestCluster(
+		testNamespace("dead", "dead-run", 2*time.Hour),
+		testNamespace("unknown", "", 2*time.Hour),
+		testNamespace("live", "live-run", 2*time.Hour),
+		testNamespace("recent", "dead-run", time.Minute),
+		terminating,
+		&kubeApiCore.Namespace{ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name:              "not-a-test",
+			CreationTimestamp: kubeApiMeta.NewTime(time.Now().Add(-2 * time.Hour)),
+		}},
+	)
+
+	leftovers, err := findNamespaces(c, map[string]bool{"live-run": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, l := range leftovers {
+		names = append(names, l.name)
+	}
+	sort.Strings(names)
+	if expected := []string{"dead", "unknown"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected namespaces %v, got %v", expected, names)
+	}
+
+	for _, l := range leftovers {
+		if err := l.remove(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.kube.CoreV1().Namespaces().Get(context.TODO(), l.name, kubeApiMeta.GetOptions{}); !kubeErrors.IsNotFound(err) {
+			t.Fatalf("expected namespace %s to be deleted, got %v", l.name, err)
+		}
+	}
+}
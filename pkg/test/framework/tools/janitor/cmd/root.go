@@ -0,0 +1,154 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/framework/components/istio/installindex"
+)
+
+var (
+	kubeConfigs    string
+	workDir        string
+	istioNamespace string
+	olderThan      time.Duration
+	deleteLeftover bool
+
+	rootCmd = &cobra.Command{
+		Use:   "janitor [OPTIONS]",
+		Short: "Janitor lists and deletes the resources left behind by previous integration test runs",
+		Long: `Janitor lists the resources left behind by previous integration test runs: namespaces created by
+the test framework, Istio installs recorded in the install index of the work directory by runs that did not clean
+up, east-west gateways whose control plane is gone and the external service hostnames forwarded by the cluster DNS
+for namespaces that are gone. With --delete, the listed resources are also deleted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd)
+		},
+	}
+)
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&kubeConfigs, "kubeconfig", "",
+		"comma-separated kubeconfigs, in the same order as --istio.test.kube.config. Defaults to KUBECONFIG")
+	rootCmd.Flags().StringVar(&workDir, "work-dir", os.TempDir(),
+		"the --istio.test.work_dir of the runs to clean up, holding their install index")
+	rootCmd.Flags().StringVar(&istioNamespace, "istio-namespace", "istio-system",
+		"the namespace Istio was installed to")
+	rootCmd.Flags().DurationVar(&olderThan, "older-than", time.Hour,
+		"only consider resources older than this, to leave the runs still in progress alone")
+	rootCmd.Flags().BoolVar(&deleteLeftover, "delete", false, "delete the listed resources")
+}
+
+func run(cmd *cobra.Command) error {
+	clusters, err := newClusters()
+	if err != nil {
+		return err
+	}
+
+	index, err := installindex.Read(workDir)
+	if err != nil {
+		return fmt.Errorf("failed reading the install index in %s: %v", workDir, err)
+	}
+	live := liveRuns(index)
+
+	var leftovers []leftover
+	for _, c := range clusters {
+		namespaces, err := findNamespaces(c, live)
+		if err != nil {
+			return fmt.Errorf("failed listing namespaces in %s: %v", c.name, err)
+		}
+		leftovers = append(leftovers, namespaces...)
+
+		gateways, err := findEastWestGateways(c)
+		if err != nil {
+			return fmt.Errorf("failed listing east-west gateways in %s: %v", c.name, err)
+		}
+		leftovers = append(leftovers, gateways...)
+
+		stubs, err := findStubDomains(c, namespaces)
+		if err != nil {
+			return fmt.Errorf("failed listing the external service hostnames of the cluster DNS in %s: %v", c.name, err)
+		}
+		leftovers = append(leftovers, stubs...)
+	}
+	leftovers = append(leftovers, findManifests(clusters, index, live)...)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CLUSTER\tKIND\tNAME\tAGE")
+	for _, l := range leftovers {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.cluster, l.kind, l.name, l.age.Round(time.Second))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !deleteLeftover {
+		return nil
+	}
+
+	var failed []string
+	for _, l := range leftovers {
+		if l.remove == nil {
+			cmd.Printf("skipping %s %s: no kubeconfig for %s\n", l.kind, l.name, l.cluster)
+			continue
+		}
+		if err := l.remove(); err != nil {
+			failed = append(failed, fmt.Sprintf("%s %s in %s: %v", l.kind, l.name, l.cluster, err))
+			continue
+		}
+		cmd.Printf("deleted %s %s in %s\n", l.kind, l.name, l.cluster)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed deleting:\n%s", strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// newClusters creates a client for each kubeconfig, named the way the test framework names its clusters. It is
+// replaced by tests.
+var newClusters = func() ([]cluster, error) {
+	files := []string{""}
+	if kubeConfigs != "" {
+		files = strings.Split(kubeConfigs, ",")
+	}
+	out := make([]cluster, 0, len(files))
+	for i, f := range files {
+		client, err := kube.NewExtendedClient(kube.BuildClientCmd(f, ""), "")
+		if err != nil {
+			return nil, fmt.Errorf("failed creating client for %q: %v", f, err)
+		}
+		out = append(out, cluster{
+			name:   fmt.Sprintf("cluster-%d", i),
+			server: client.RESTConfig().Host,
+			kube:   client,
+			client: client,
+		})
+	}
+	return out, nil
+}
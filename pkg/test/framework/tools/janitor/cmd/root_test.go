@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/components/istio/installindex"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// The live run is the current process, the dead one a process with the same PID started at another time.
+	live := installindex.NewRecord("live-run", "istio-system", false)
+	live.Manifests["https://cluster-0"] = []string{filepath.Join(dir, "live-manifest-1.yaml")}
+	dead := installindex.NewRecord("dead-run", "istio-system", false)
+	dead.StartTime = "0"
+	// The manifest is gone, so removing the install only forgets it.
+	dead.Manifests["https://cluster-0"] = []string{filepath.Join(dir, "dead-manifest-1.yaml")}
+	if live.StartTime == "" {
+		t.Skip("process start times are not available")
+	}
+	if err := installindex.Update(dir, func(index map[string]*installindex.Record) {
+		index[live.RunID] = live
+		index[dead.RunID] = dead
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestCluster(
+		testNamespace("dead", "dead-run", 2*time.Hour),
+		testNamespace("live", "live-run", 2*time.Hour),
+	)
+	defer func(fn func() ([]cluster, error)) { newClusters = fn }(newClusters)
+	newClusters = func() ([]cluster, error) {
+		return []cluster{c}, nil
+	}
+
+	execute := func(args ...string) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(out)
+		rootCmd.SetArgs(append([]string{"--work-dir", dir}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return out.String()
+	}
+
+	out := execute()
+	for _, expected := range []string{`cluster-0\s+Namespace\s+dead\s`, `cluster-0\s+Install\s+dead-run\s`} {
+		if !regexp.MustCompile(expected).MatchString(out) {
+			t.Fatalf("expected %q in the output:\n%s", expected, out)
+		}
+	}
+	if regexp.MustCompile(`live`).MatchString(out) {
+		t.Fatalf("expected the live run to be skipped:\n%s", out)
+	}
+
+	execute("--delete")
+	if _, err := c.kube.CoreV1().Namespaces().Get(context.TODO(), "live", kubeApiMeta.GetOptions{}); err != nil {
+		t.Fatalf("expected the namespace of the live run to be kept: %v", err)
+	}
+	if _, err := c.kube.CoreV1().Namespaces().Get(context.TODO(), "dead", kubeApiMeta.GetOptions{}); err == nil {
+		t.Fatal("expected the namespace of the dead run to be deleted")
+	}
+	index, err := installindex.Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index["dead-run"]; ok || index["live-run"] == nil {
+		t.Fatalf("expected only the live run to be left in the index, got %v", index)
+	}
+}
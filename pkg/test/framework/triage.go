@@ -0,0 +1,140 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// failedWaitsFile is the name of the file, within the suite work dir, holding the retry timelines of
+	// waits that timed out.
+	failedWaitsFile = "failed-waits.yaml"
+
+	// triageBundleSuffix is appended to the suite name to form the name of the triage bundle.
+	triageBundleSuffix = "-triage.tar.gz"
+)
+
+// triageArtifactsDir returns the directory the triage bundle is written to, or an empty string if no bundle is
+// written.
+func triageArtifactsDir() string {
+	// the ARTIFACTS env var is set by prow, and uploaded to GCS as part of the job artifact
+	return os.Getenv("ARTIFACTS")
+}
+
+// writeTriageBundle assembles everything needed to debug a failed suite into a single compressed archive in
+// the artifacts directory. The archive contains the suite's run dir, which holds the rendered install and
+// cleanup manifests, along with anything written by resource dumpers (logs, events, proxy status, etc.), as
+// well as the retry timelines of any waits that timed out.
+func writeTriageBundle(ctx *suiteContext) {
+	artifactsPath := triageArtifactsDir()
+	if artifactsPath == "" {
+		scopes.Framework.Debugf("ARTIFACTS is not set, skipping triage bundle")
+		return
+	}
+
+	if err := writeFailedWaits(ctx.workDir); err != nil {
+		scopes.Framework.Errorf("failed writing retry timelines for triage: %v", err)
+	}
+
+	bundle := filepath.Join(artifactsPath, ctx.Settings().TestID+triageBundleSuffix)
+	if err := createTarGz(bundle, ctx.Settings().RunDir()); err != nil {
+		scopes.Framework.Errorf("failed writing triage bundle %s: %v", bundle, err)
+		return
+	}
+	scopes.Framework.Infof("=== Wrote triage bundle for suite %q to %s ===", ctx.Settings().TestID, bundle)
+}
+
+func writeFailedWaits(dir string) error {
+	waits := retry.FailedWaits()
+	if len(waits) == 0 {
+		return nil
+	}
+	out, err := yaml.Marshal(waits)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, failedWaitsFile), out, 0644)
+}
+
+// createTarGz writes the contents of srcDir into a gzip compressed tarball at dst.
+func createTarGz(dst, srcDir string) (err error) {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	defer func() {
+		if e := tw.Close(); e != nil && err == nil {
+			err = e
+		}
+		if e := gz.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	base := filepath.Base(srcDir)
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			// Skip sockets, symlinks, etc.
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(base, rel))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if _, err := io.Copy(tw, in); err != nil {
+			return fmt.Errorf("failed adding %s: %v", path, err)
+		}
+		return nil
+	})
+}
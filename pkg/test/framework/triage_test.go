@@ -0,0 +1,80 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+func TestWriteTriageBundle(t *testing.T) {
+	g := NewWithT(t)
+
+	artifacts, err := ioutil.TempDir("", "artifacts")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(artifacts)
+	base, err := ioutil.TempDir("", "triage")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(base)
+
+	s := resource.DefaultSettings()
+	s.TestID = "triage"
+	s.BaseDir = base
+	workDir := filepath.Join(s.RunDir(), "_suite_context")
+	g.Expect(os.MkdirAll(workDir, os.ModePerm)).To(BeNil())
+	g.Expect(ioutil.WriteFile(filepath.Join(workDir, "istiod.log"), []byte("logs"), 0644)).To(BeNil())
+
+	prev, set := os.LookupEnv("ARTIFACTS")
+	defer func() {
+		if set {
+			_ = os.Setenv("ARTIFACTS", prev)
+		} else {
+			_ = os.Unsetenv("ARTIFACTS")
+		}
+	}()
+	g.Expect(os.Setenv("ARTIFACTS", artifacts)).To(BeNil())
+
+	writeTriageBundle(&suiteContext{settings: s, workDir: workDir})
+
+	f, err := os.Open(filepath.Join(artifacts, "triage"+triageBundleSuffix))
+	g.Expect(err).To(BeNil())
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	g.Expect(err).To(BeNil())
+	tr := tar.NewReader(gz)
+
+	contents := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).To(BeNil())
+		b, err := ioutil.ReadAll(tr)
+		g.Expect(err).To(BeNil())
+		contents[hdr.Name] = string(b)
+	}
+	runDir := filepath.Base(s.RunDir())
+	g.Expect(contents).To(HaveKeyWithValue(runDir+"/_suite_context/istiod.log", "logs"))
+}
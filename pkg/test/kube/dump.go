@@ -24,6 +24,8 @@ import (
 	"sync"
 
 	"github.com/gogo/protobuf/jsonpb"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -254,6 +256,47 @@ func DumpNdsz(ctx resource.Context, c resource.Cluster, workDir string, namespac
 	}
 }
 
+// DumpProxyStatus dumps the control plane's view of the xDS sync state of each proxy it serves in the given
+// cluster. This is the same information reported by `istioctl proxy-status`.
+func DumpProxyStatus(ctx resource.Context, c resource.Cluster, workDir string) {
+	cp, istiod, err := getControlPlane(ctx, c)
+	if err != nil {
+		scopes.Framework.Errorf("failed dumping proxy status: %v", err)
+		return
+	}
+	out, err := dumpDebug(cp, istiod, "/debug/syncz")
+	if err != nil {
+		scopes.Framework.Errorf("failed dumping proxy status: %v", err)
+		return
+	}
+	dir := path.Join(workDir, c.Name())
+	if err := os.MkdirAll(dir, os.ModeDir|0700); err != nil {
+		scopes.Framework.Errorf("failed creating directory: %s", dir)
+		return
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "proxy-status.json"), []byte(out), 0644); err != nil {
+		scopes.Framework.Errorf("failed dumping proxy status: %v", err)
+	}
+}
+
+// IstiodMetrics returns the metrics currently exported by the istiod instance controlling the given cluster.
+func IstiodMetrics(ctx resource.Context, c resource.Cluster) (map[string]*dto.MetricFamily, error) {
+	cp, istiod, err := getControlPlane(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	out, err := dumpDebug(cp, istiod, "/metrics")
+	if err != nil {
+		return nil, err
+	}
+	parser := expfmt.TextParser{}
+	mfMap, err := parser.TextToMetricFamilies(strings.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing istiod metrics: %v", err)
+	}
+	return mfMap, nil
+}
+
 func dumpDebug(cp resource.Cluster, istiodPod corev1.Pod, endpoint string) (string, error) {
 
 	// exec to the control plane to run nds gen
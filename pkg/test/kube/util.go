@@ -16,12 +16,13 @@ package kube
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	kubeApiCore "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	kubeErrors "k8s.io/apimachinery/pkg/api/errors"
 	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -85,6 +86,66 @@ func NewPodMustFetch(a istioKube.ExtendedClient, namespace string, selectors ...
 	}
 }
 
+var (
+	// unrecoverableReasons are the waiting reasons of containers which cannot start without intervention.
+	unrecoverableReasons = map[string]bool{
+		"ErrImageNeverPull": true,
+		"InvalidImageName":  true,
+	}
+	// backoffReasons are the waiting reasons of containers which the kubelet retries with an exponential backoff.
+	// Pull failures are often transient, for example while an image is still being pushed or a registry is
+	// rate limiting, so they are retried too.
+	backoffReasons = map[string]bool{
+		"ErrImagePull":     true,
+		"ImagePullBackOff": true,
+		"CrashLoopBackOff": true,
+	}
+	// podBackoffDelay is the delay between checks of pods whose containers are in a backoff.
+	podBackoffDelay = time.Second * 10
+)
+
+// ContainerError is the error of a pod whose container is waiting for another reason than being created.
+type ContainerError struct {
+	Pod       string
+	Container string
+	Reason    string
+	Message   string
+}
+
+func (e *ContainerError) Error() string {
+	return fmt.Sprintf("%s: container %s: %s: %s", e.Pod, e.Container, e.Reason, e.Message)
+}
+
+// CheckPodContainers returns a ContainerError if a container of the pod is in a backoff, or cannot start.
+func CheckPodContainers(pod *kubeApiCore.Pod) error {
+	statuses := append(append([]kubeApiCore.ContainerStatus{}, pod.Status.InitContainerStatuses...),
+		pod.Status.ContainerStatuses...)
+	for _, c := range statuses {
+		if w := c.State.Waiting; w != nil && (unrecoverableReasons[w.Reason] || backoffReasons[w.Reason]) {
+			return &ContainerError{
+				Pod:       pod.Namespace + "/" + pod.Name,
+				Container: c.Name,
+				Reason:    w.Reason,
+				Message:   w.Message,
+			}
+		}
+	}
+	return nil
+}
+
+// ClassifyPodErrors is a retry.Classifier for waits on pods. It stops waiting for pods whose containers cannot
+// start, such as when their image name is invalid, and backs off while they are in a restart or pull backoff.
+func ClassifyPodErrors(err error) (retry.Class, time.Duration) {
+	var containerErr *ContainerError
+	if !errors.As(err, &containerErr) {
+		return retry.Retryable, 0
+	}
+	if unrecoverableReasons[containerErr.Reason] {
+		return retry.Fatal, 0
+	}
+	return retry.Backoff, podBackoffDelay
+}
+
 // CheckPodsAreReady checks whether the pods that are selected by the given function is in ready state or not.
 func CheckPodsAreReady(fetchFunc PodFetchFunc) ([]kubeApiCore.Pod, error) {
 	scopes.Framework.Infof("Checking pods ready...")
@@ -97,7 +158,10 @@ func CheckPodsAreReady(fetchFunc PodFetchFunc) ([]kubeApiCore.Pod, error) {
 
 	for i, p := range fetched {
 		msg := "Ready"
-		if e := istioKube.CheckPodReady(&p); e != nil {
+		if e := CheckPodContainers(&p); e != nil {
+			msg = e.Error()
+			err = multierror.Append(err, e)
+		} else if e := istioKube.CheckPodReady(&p); e != nil {
 			msg = e.Error()
 			err = multierror.Append(err, fmt.Errorf("%s/%s: %s", p.Namespace, p.Name, msg))
 		}
@@ -134,7 +198,7 @@ func WaitUntilPodsAreReady(fetchFunc PodFetchFunc, opts ...retry.Option) ([]kube
 		}
 		pods = fetched
 		return nil, true, nil
-	}, newRetryOptions(opts...)...)
+	}, newRetryOptions(append([]retry.Option{retry.Classify(ClassifyPodErrors)}, opts...)...)...)
 
 	return pods, err
 }
@@ -220,7 +284,7 @@ func WaitForNamespaceDeletion(a kubernetes.Interface, ns string, opts ...retry.O
 			return nil, false, nil
 		}
 
-		if errors.IsNotFound(err2) {
+		if kubeErrors.IsNotFound(err2) {
 			return nil, true, nil
 		}
 
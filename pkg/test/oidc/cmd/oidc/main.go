@@ -0,0 +1,90 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/pkg/test/oidc"
+	"istio.io/pkg/log"
+)
+
+var (
+	port   int
+	issuer string
+
+	loggingOptions = log.DefaultOptions()
+
+	rootCmd = &cobra.Command{
+		Use:               "oidc",
+		Short:             "Mock OpenID Connect provider.",
+		SilenceUsage:      true,
+		PersistentPreRunE: configureLogging,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if issuer == "" {
+				issuer = fmt.Sprintf("http://localhost:%d", port)
+			}
+			s, err := oidc.NewServer(issuer)
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: s.Handler()}
+			go func() {
+				if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+					log.Errorf("HTTP server failed: %v", err)
+				}
+			}()
+			defer srv.Close()
+			log.Infof("serving OIDC provider for issuer %s on %d", issuer, port)
+
+			// Wait for the process to be shutdown.
+			sigs := make(chan os.Signal, 1)
+			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+			<-sigs
+			return nil
+		},
+	}
+)
+
+func configureLogging(_ *cobra.Command, _ []string) error {
+	if err := log.Configure(loggingOptions); err != nil {
+		return err
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&port, "port", 8080, "HTTP port")
+	rootCmd.PersistentFlags().StringVar(&issuer, "issuer", "", "Issuer of the minted tokens, which must be the URL "+
+		"the server is reachable at. Defaults to http://localhost:<port>")
+
+	loggingOptions.AttachCobraFlags(rootCmd)
+
+	cmd.AddFlags(rootCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(-1)
+	}
+}
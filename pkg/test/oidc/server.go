@@ -0,0 +1,250 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidc implements a mock OpenID Connect provider for tests. It serves a discovery document and a JWKS,
+// mints tokens with arbitrary claims, and rotates its signing keys on demand.
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DiscoveryPath is the path of the OpenID Connect discovery document.
+	DiscoveryPath = "/.well-known/openid-configuration"
+	// JWKSPath is the path of the JSON Web Key Set.
+	JWKSPath = "/jwks"
+
+	// defaultExpiration is the lifetime of minted tokens which do not set the exp claim.
+	defaultExpiration = time.Hour
+	keySize           = 2048
+)
+
+var oidcLog = log.RegisterScope("oidc", "mock OIDC provider", 0)
+
+// Stats are counters of the requests received by the server. They show when the JWKS is refreshed.
+type Stats struct {
+	DiscoveryFetches int `json:"discoveryFetches"`
+	JWKSFetches      int `json:"jwksFetches"`
+}
+
+// RotateResponse is returned by the rotate API.
+type RotateResponse struct {
+	// KeyID of the new signing key.
+	KeyID string `json:"kid"`
+	// Published are the IDs of all keys in the JWKS after the rotation, the signing key first.
+	Published []string `json:"published"`
+}
+
+// Server is a mock OIDC provider. The first of its keys is used to sign tokens, all of them are published.
+type Server struct {
+	issuer string
+
+	mu    sync.Mutex
+	keys  []jose.JSONWebKey
+	stats Stats
+}
+
+// NewServer returns a server for the given issuer, with a single signing key.
+func NewServer(issuer string) (*Server, error) {
+	s := &Server{issuer: issuer}
+	if _, err := s.Rotate(false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Issuer returns the iss claim of the tokens minted by the server.
+func (s *Server) Issuer() string {
+	return s.issuer
+}
+
+func newKey() (jose.JSONWebKey, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	key := jose.JSONWebKey{Key: priv, Algorithm: string(jose.RS256), Use: "sig"}
+	thumb, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	key.KeyID = base64.RawURLEncoding.EncodeToString(thumb)
+	return key, nil
+}
+
+// Rotate generates a new signing key. If keepPrevious is set, the previous keys stay in the JWKS, so that tokens
+// they signed remain valid; otherwise only the new key is published.
+func (s *Server) Rotate(keepPrevious bool) (RotateResponse, error) {
+	key, err := newKey()
+	if err != nil {
+		return RotateResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if keepPrevious {
+		s.keys = append([]jose.JSONWebKey{key}, s.keys...)
+	} else {
+		s.keys = []jose.JSONWebKey{key}
+	}
+	resp := RotateResponse{KeyID: key.KeyID}
+	for _, k := range s.keys {
+		resp.Published = append(resp.Published, k.KeyID)
+	}
+	oidcLog.Infof("rotated signing key to %s, published keys: %v", key.KeyID, resp.Published)
+	return resp, nil
+}
+
+// JWKS returns the public keys of the server.
+func (s *Server) JWKS() jose.JSONWebKeySet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := jose.JSONWebKeySet{}
+	for _, k := range s.keys {
+		out.Keys = append(out.Keys, k.Public())
+	}
+	return out
+}
+
+// Token mints a token with the given claims, signed by the current signing key. The iss, iat and exp claims are
+// added unless set.
+func (s *Server) Token(claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	c := map[string]interface{}{
+		"iss": s.issuer,
+		"iat": now.Unix(),
+		"exp": now.Add(defaultExpiration).Unix(),
+	}
+	for k, v := range claims {
+		c[k] = v
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	key := s.keys[0]
+	s.mu.Unlock()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+// Stats returns the request counters of the server.
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Handler returns the HTTP API of the server:
+//
+//	GET  /.well-known/openid-configuration  returns the discovery document
+//	GET  /jwks                              returns the JWKS
+//	POST /token                             returns a token with the JSON claims in the request body
+//	POST /rotate?keep=true                  rotates the signing key, see Rotate
+//	GET  /stats                             returns the request counters
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		s.count(func(st *Stats) { st.DiscoveryFetches++ })
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                s.issuer,
+			"jwks_uri":                              s.issuer + JWKSPath,
+			"id_token_signing_alg_values_supported": []string{string(jose.RS256)},
+			"response_types_supported":              []string{"id_token"},
+			"subject_types_supported":               []string{"public"},
+		})
+	})
+	mux.HandleFunc(JWKSPath, func(w http.ResponseWriter, r *http.Request) {
+		s.count(func(st *Stats) { st.JWKSFetches++ })
+		writeJSON(w, s.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		claims := map[string]interface{}{}
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil && len(body) > 0 {
+			err = json.Unmarshal(body, &claims)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid claims: %v", err), http.StatusBadRequest)
+			return
+		}
+		token, err := s.Token(claims)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(token))
+	})
+	mux.HandleFunc("/rotate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		keep, _ := strconv.ParseBool(r.URL.Query().Get("keep"))
+		resp, err := s.Rotate(keep)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, resp)
+	})
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Stats())
+	})
+	return mux
+}
+
+func (s *Server) count(f func(*Stats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&s.stats)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
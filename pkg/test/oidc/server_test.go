@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// verify checks the signature of the token against the given JWKS and returns its claims.
+func verify(t *testing.T, token string, jwks jose.JSONWebKeySet) (map[string]interface{}, error) {
+	t.Helper()
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := jwks.Key(jws.Signatures[0].Header.KeyID)
+	if len(keys) == 0 {
+		return nil, errNoKey
+	}
+	payload, err := jws.Verify(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims, nil
+}
+
+var errNoKey = errors.New("signing key is not published")
+
+func TestTokenAndRotation(t *testing.T) {
+	s, err := NewServer("https://issuer.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.Token(map[string]interface{}{"sub": "alice", "groups": []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := verify(t, token, s.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["iss"] != "https://issuer.example.com" || claims["sub"] != "alice" || claims["exp"] == nil {
+		t.Fatalf("unexpected claims: %v", claims)
+	}
+
+	// The old key remains published, so the old token is still valid.
+	resp, err := s.Rotate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Published) != 2 || resp.Published[0] != resp.KeyID {
+		t.Fatalf("unexpected rotation: %+v", resp)
+	}
+	if _, err := verify(t, token, s.JWKS()); err != nil {
+		t.Fatalf("expected token signed by the previous key to be valid: %v", err)
+	}
+	newToken, err := s.Token(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verify(t, newToken, s.JWKS()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the old key invalidates its tokens.
+	if _, err := s.Rotate(false); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.JWKS().Keys) != 1 {
+		t.Fatalf("expected a single published key, got %d", len(s.JWKS().Keys))
+	}
+	if _, err := verify(t, token, s.JWKS()); err != errNoKey {
+		t.Fatalf("expected token signed by a retired key to be rejected, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s, err := NewServer("http://oidc.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	get := func(path string, out interface{}) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discovery := map[string]interface{}{}
+	get(DiscoveryPath, &discovery)
+	if discovery["issuer"] != "http://oidc.test" || discovery["jwks_uri"] != "http://oidc.test/jwks" {
+		t.Fatalf("unexpected discovery document: %v", discovery)
+	}
+
+	resp, err := http.Post(srv.URL+"/token", "application/json", bytes.NewReader([]byte(`{"sub":"bob"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	var jwks jose.JSONWebKeySet
+	get(JWKSPath, &jwks)
+	claims, err := verify(t, string(token), jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sub"] != "bob" {
+		t.Fatalf("unexpected claims: %v", claims)
+	}
+
+	resp, err = http.Post(srv.URL+"/rotate?keep=true", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotated RotateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rotated); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(rotated.Published) != 2 {
+		t.Fatalf("unexpected rotation: %+v", rotated)
+	}
+
+	var stats Stats
+	get("/stats", &stats)
+	if stats.DiscoveryFetches != 1 || stats.JWKSFetches != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides snapshots of Prometheus metrics, so that tests can assert on how counters
+// changed across an action rather than on their absolute values.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Source returns the current metrics of a component, for example echo.Sidecar.Stats or
+// kube.IstiodMetrics.
+type Source func() (map[string]*dto.MetricFamily, error)
+
+type series struct {
+	labels map[string]string
+	value  float64
+}
+
+// Snapshot holds the values of every series of a Source at a point in time.
+type Snapshot struct {
+	// series by metric name, then by label set.
+	series map[string]map[string]series
+}
+
+// Take returns a snapshot of the current metrics of the given source.
+func Take(src Source) (Snapshot, error) {
+	families, err := src()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return NewSnapshot(families), nil
+}
+
+// TakeOrFail calls Take and fails the test if it returns an error.
+func TakeOrFail(t test.Failer, src Source) Snapshot {
+	t.Helper()
+	s, err := Take(src)
+	if err != nil {
+		t.Fatalf("metrics.TakeOrFail: %v", err)
+	}
+	return s
+}
+
+// NewSnapshot returns a snapshot of the given metric families. Histograms and summaries are recorded as
+// their "_count" and "_sum" series.
+func NewSnapshot(families map[string]*dto.MetricFamily) Snapshot {
+	s := Snapshot{series: map[string]map[string]series{}}
+	for name, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			switch {
+			case m.Counter != nil:
+				s.add(name, labels, m.GetCounter().GetValue())
+			case m.Gauge != nil:
+				s.add(name, labels, m.GetGauge().GetValue())
+			case m.Untyped != nil:
+				s.add(name, labels, m.GetUntyped().GetValue())
+			case m.Histogram != nil:
+				s.add(name+"_count", labels, float64(m.GetHistogram().GetSampleCount()))
+				s.add(name+"_sum", labels, m.GetHistogram().GetSampleSum())
+			case m.Summary != nil:
+				s.add(name+"_count", labels, float64(m.GetSummary().GetSampleCount()))
+				s.add(name+"_sum", labels, m.GetSummary().GetSampleSum())
+			}
+		}
+	}
+	return s
+}
+
+func (s Snapshot) add(name string, labels map[string]string, value float64) {
+	if s.series[name] == nil {
+		s.series[name] = map[string]series{}
+	}
+	s.series[name][labelKey(labels)] = series{labels: labels, value: value}
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strconv.Quote(labels[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Value returns the sum of all series selected by the given selector.
+func (s Snapshot) Value(sel Selector) float64 {
+	var total float64
+	for _, ser := range s.series[sel.Metric] {
+		if sel.matches(ser.labels) {
+			total += ser.value
+		}
+	}
+	return total
+}
+
+// Selector selects the series of a metric whose labels contain all of the given label values.
+type Selector struct {
+	Metric string
+	Labels map[string]string
+}
+
+// Select returns a selector for the given metric and label name/value pairs, for example
+//
+//	metrics.Select("istio_requests_total", "response_code", "200")
+func Select(metric string, labelPairs ...string) Selector {
+	if len(labelPairs)%2 != 0 {
+		panic(fmt.Sprintf("metrics.Select: odd number of label arguments: %v", labelPairs))
+	}
+	labels := make(map[string]string, len(labelPairs)/2)
+	for i := 0; i < len(labelPairs); i += 2 {
+		labels[labelPairs[i]] = labelPairs[i+1]
+	}
+	return Selector{Metric: metric, Labels: labels}
+}
+
+func (sel Selector) matches(labels map[string]string) bool {
+	for k, v := range sel.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (sel Selector) String() string {
+	if len(sel.Labels) == 0 {
+		return sel.Metric
+	}
+	return sel.Metric + "{" + labelKey(sel.Labels) + "}"
+}
+
+// Diff is the change in metrics between two snapshots.
+type Diff struct {
+	Before Snapshot
+	After  Snapshot
+}
+
+// Compare returns the difference between two snapshots of the same source.
+func Compare(before, after Snapshot) Diff {
+	return Diff{Before: before, After: after}
+}
+
+// Measure takes a snapshot of the source, runs fn, and returns how the metrics changed while it ran.
+func Measure(src Source, fn func() error) (Diff, error) {
+	before, err := Take(src)
+	if err != nil {
+		return Diff{}, err
+	}
+	if err := fn(); err != nil {
+		return Diff{}, err
+	}
+	after, err := Take(src)
+	if err != nil {
+		return Diff{}, err
+	}
+	return Compare(before, after), nil
+}
+
+// MeasureOrFail calls Measure and fails the test if it returns an error.
+func MeasureOrFail(t test.Failer, src Source, fn func() error) Diff {
+	t.Helper()
+	d, err := Measure(src, fn)
+	if err != nil {
+		t.Fatalf("metrics.MeasureOrFail: %v", err)
+	}
+	return d
+}
+
+// Delta returns how much the sum of the selected series changed. A negative value usually means the
+// component restarted and its counters were reset.
+func (d Diff) Delta(sel Selector) float64 {
+	return d.After.Value(sel) - d.Before.Value(sel)
+}
+
+// Expect checks that the change in the selected series satisfies the given expectation.
+func (d Diff) Expect(sel Selector, e Expectation) error {
+	delta := d.Delta(sel)
+	if !e.match(delta) {
+		return fmt.Errorf("expected %s to change by %s, but it changed by %v (%v -> %v)",
+			sel, e.desc, delta, d.Before.Value(sel), d.After.Value(sel))
+	}
+	return nil
+}
+
+// ExpectOrFail calls Expect and fails the test if it returns an error.
+func (d Diff) ExpectOrFail(t test.Failer, sel Selector, e Expectation) {
+	t.Helper()
+	if err := d.Expect(sel, e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Expectation is a condition on the change of a metric.
+type Expectation struct {
+	desc  string
+	match func(delta float64) bool
+}
+
+// Unchanged expects the metric not to change.
+func Unchanged() Expectation {
+	return Exactly(0)
+}
+
+// Exactly expects the metric to change by exactly n.
+func Exactly(n float64) Expectation {
+	return Expectation{
+		desc:  fmt.Sprintf("exactly %v", n),
+		match: func(delta float64) bool { return delta == n },
+	}
+}
+
+// AtLeast expects the metric to increase by n or more.
+func AtLeast(n float64) Expectation {
+	return Expectation{
+		desc:  fmt.Sprintf("at least %v", n),
+		match: func(delta float64) bool { return delta >= n },
+	}
+}
+
+// Between expects the change in the metric to be within [min, max].
+func Between(min, max float64) Expectation {
+	return Expectation{
+		desc:  fmt.Sprintf("between %v and %v", min, max),
+		match: func(delta float64) bool { return delta >= min && delta <= max },
+	}
+}
+
+// Approximately expects the metric to change by n, give or take the given fraction of n. For example,
+// Approximately(10, 0.2) accepts any change between 8 and 12.
+func Approximately(n, tolerance float64) Expectation {
+	margin := math.Abs(n * tolerance)
+	return Expectation{
+		desc:  fmt.Sprintf("%v (+/- %v)", n, margin),
+		match: func(delta float64) bool { return math.Abs(delta-n) <= margin },
+	}
+}
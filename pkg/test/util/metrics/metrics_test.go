@@ -0,0 +1,105 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const before = `# TYPE pilot_xds_pushes counter
+pilot_xds_pushes{type="cds"} 10
+pilot_xds_pushes{type="eds"} 20
+# TYPE istio_requests_total counter
+istio_requests_total{response_code="200",destination_app="b"} 5
+# TYPE pilot_proxy_convergence_time histogram
+pilot_proxy_convergence_time_bucket{le="1"} 3
+pilot_proxy_convergence_time_bucket{le="+Inf"} 3
+pilot_proxy_convergence_time_sum 0.3
+pilot_proxy_convergence_time_count 3
+`
+
+const after = `# TYPE pilot_xds_pushes counter
+pilot_xds_pushes{type="cds"} 12
+pilot_xds_pushes{type="eds"} 29
+# TYPE istio_requests_total counter
+istio_requests_total{response_code="200",destination_app="b"} 15
+istio_requests_total{response_code="503",destination_app="b"} 1
+# TYPE pilot_proxy_convergence_time histogram
+pilot_proxy_convergence_time_bucket{le="1"} 4
+pilot_proxy_convergence_time_bucket{le="+Inf"} 4
+pilot_proxy_convergence_time_sum 0.5
+pilot_proxy_convergence_time_count 4
+`
+
+func parse(t *testing.T, text string) map[string]*dto.MetricFamily {
+	t.Helper()
+	parser := expfmt.TextParser{}
+	mf, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mf
+}
+
+func TestMeasure(t *testing.T) {
+	current := before
+	src := func() (map[string]*dto.MetricFamily, error) {
+		return parse(t, current), nil
+	}
+	d := MeasureOrFail(t, src, func() error {
+		current = after
+		return nil
+	})
+
+	cases := []struct {
+		name   string
+		sel    Selector
+		expect Expectation
+		ok     bool
+	}{
+		{"all pushes", Select("pilot_xds_pushes"), Exactly(11), true},
+		{"eds pushes", Select("pilot_xds_pushes", "type", "eds"), Approximately(10, 0.1), true},
+		{"eds pushes out of tolerance", Select("pilot_xds_pushes", "type", "eds"), Approximately(5, 0.1), false},
+		{"cds pushes", Select("pilot_xds_pushes", "type", "cds"), Between(1, 2), true},
+		{"successes", Select("istio_requests_total", "response_code", "200"), AtLeast(10), true},
+		{"no 5xx", Select("istio_requests_total", "response_code", "503"), Unchanged(), false},
+		{"no 404", Select("istio_requests_total", "response_code", "404"), Unchanged(), true},
+		{"histogram count", Select("pilot_proxy_convergence_time_count"), Exactly(1), true},
+		{"missing metric", Select("does_not_exist"), Unchanged(), true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.Expect(tt.sel, tt.expect)
+			if tt.ok && err != nil {
+				t.Fatal(err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatalf("expected %s to fail %s, delta was %v", tt.sel, tt.expect.desc, d.Delta(tt.sel))
+			}
+		})
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	got := Select("istio_requests_total", "response_code", "200", "destination_app", "b").String()
+	want := `istio_requests_total{destination_app="b",response_code="200"}`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
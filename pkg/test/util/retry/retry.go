@@ -16,6 +16,7 @@ package retry
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"istio.io/istio/pkg/test"
@@ -30,6 +31,13 @@ const (
 
 	// DefaultConverge the default converge, requiring something to succeed one time
 	DefaultConverge = 1
+
+	// maxFailedWaits is the number of failed wait timelines retained for triage.
+	maxFailedWaits = 100
+
+	// maxTimelineAttempts is the number of attempts retained in a single timeline. Only the most recent
+	// attempts are kept, since those are the ones that explain why the wait timed out.
+	maxTimelineAttempts = 50
 )
 
 var (
@@ -40,10 +48,103 @@ var (
 	}
 )
 
+var (
+	failedWaitsMu sync.Mutex
+	failedWaits   []Timeline
+)
+
+// Attempt records the outcome of a single invocation of a retried function.
+type Attempt struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Timeline records the attempts made by a retry operation that timed out or stopped on a fatal error.
+type Timeline struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Attempts holds the most recent attempts, oldest first.
+	Attempts []Attempt `json:"attempts"`
+	// Dropped is the number of older attempts that were not retained.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+func (t *Timeline) record(err error) {
+	a := Attempt{Time: time.Now()}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if len(t.Attempts) == maxTimelineAttempts {
+		t.Attempts = t.Attempts[1:]
+		t.Dropped++
+	}
+	t.Attempts = append(t.Attempts, a)
+}
+
+// FailedWaits returns the timelines of the most recent retry operations that timed out or stopped on a fatal
+// error.
+func FailedWaits() []Timeline {
+	failedWaitsMu.Lock()
+	defer failedWaitsMu.Unlock()
+	return append([]Timeline{}, failedWaits...)
+}
+
+func recordFailedWait(t Timeline) {
+	failedWaitsMu.Lock()
+	defer failedWaitsMu.Unlock()
+	if len(failedWaits) == maxFailedWaits {
+		failedWaits = failedWaits[1:]
+	}
+	failedWaits = append(failedWaits, t)
+}
+
+// Class of an error returned by a retried function, which determines whether and when it is retried.
+type Class int
+
+const (
+	// Retryable errors are retried after the configured delay. Errors are retryable unless classified otherwise.
+	Retryable Class = iota
+	// Backoff errors are retried after the delay returned by the classifier, for conditions known to last longer
+	// than the configured delay, such as a container in a restart backoff. The configured delay is used when the
+	// returned delay is not positive.
+	Backoff
+	// Fatal errors cannot be resolved by retrying, such as an invalid image name. The retries stop, and the error
+	// is returned immediately.
+	Fatal
+)
+
+// Classifier returns the class of an error returned by a retried function. The delay is only used for Backoff
+// errors.
+type Classifier func(err error) (class Class, delay time.Duration)
+
+// Error is returned when a retry operation times out or stops on a fatal error. It holds the attempts made, so
+// callers can report the history of the operation rather than its last error only.
+type Error struct {
+	// Timeline of the attempts of the operation.
+	Timeline Timeline
+	// Last is the last error returned by the retried function.
+	Last error
+	// Fatal indicates that the operation stopped on a fatal error rather than a timeout.
+	Fatal bool
+}
+
+func (e *Error) Error() string {
+	if e.Fatal {
+		return fmt.Sprintf("fatal error after %d attempts: %v", len(e.Timeline.Attempts)+e.Timeline.Dropped, e.Last)
+	}
+	return fmt.Sprintf("timeout while waiting (last error: %v)", e.Last)
+}
+
+// Unwrap returns the last error of the retried function.
+func (e *Error) Unwrap() error {
+	return e.Last
+}
+
 type config struct {
-	timeout  time.Duration
-	delay    time.Duration
-	converge int
+	timeout    time.Duration
+	delay      time.Duration
+	converge   int
+	classifier Classifier
 }
 
 // Option for a retry opteration.
@@ -72,6 +173,13 @@ func Converge(successes int) Option {
 	}
 }
 
+// Classify sets the classifier of the errors returned by the retried function.
+func Classify(classifier Classifier) Option {
+	return func(cfg *config) {
+		cfg.classifier = classifier
+	}
+}
+
 // RetriableFunc a function that can be retried.
 type RetriableFunc func() (result interface{}, completed bool, err error)
 
@@ -107,15 +215,22 @@ func Do(fn RetriableFunc, options ...Option) (interface{}, error) {
 
 	successes := 0
 	var lasterr error
+	timeline := Timeline{Start: time.Now()}
 	to := time.After(cfg.timeout)
+	timeout := func() error {
+		timeline.End = time.Now()
+		recordFailedWait(timeline)
+		return &Error{Timeline: timeline, Last: lasterr}
+	}
 	for {
 		select {
 		case <-to:
-			return nil, fmt.Errorf("timeout while waiting (last error: %v)", lasterr)
+			return nil, timeout()
 		default:
 		}
 
 		result, completed, err := fn()
+		timeline.record(err)
 		if completed {
 			if err == nil {
 				successes++
@@ -131,10 +246,29 @@ func Do(fn RetriableFunc, options ...Option) (interface{}, error) {
 		} else {
 			successes = 0
 		}
+		delay := cfg.delay
 		if err != nil {
 			lasterr = err
+			if cfg.classifier != nil {
+				class, backoff := cfg.classifier(err)
+				switch class {
+				case Fatal:
+					timeline.End = time.Now()
+					recordFailedWait(timeline)
+					return nil, &Error{Timeline: timeline, Last: err, Fatal: true}
+				case Backoff:
+					// A non-positive backoff would retry in a tight loop, so keep the configured delay instead.
+					if backoff > 0 {
+						delay = backoff
+					}
+				}
+			}
 		}
 
-		<-time.After(cfg.delay)
+		select {
+		case <-to:
+			return nil, timeout()
+		case <-time.After(delay):
+		}
 	}
 }
@@ -15,6 +15,7 @@
 package retry
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -49,3 +50,112 @@ func TestConverge(t *testing.T) {
 		}
 	})
 }
+
+func TestFailedWaits(t *testing.T) {
+	before := len(FailedWaits())
+	n := 0
+	err := UntilSuccess(func() error {
+		n++
+		return fmt.Errorf("attempt %d", n)
+	}, Timeout(time.Millisecond*20), Delay(time.Millisecond))
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	waits := FailedWaits()
+	if len(waits) != before+1 && len(waits) != maxFailedWaits {
+		t.Fatalf("expected the failed wait to be recorded, got %d timelines", len(waits))
+	}
+	tl := waits[len(waits)-1]
+	if len(tl.Attempts)+tl.Dropped != n {
+		t.Fatalf("expected %d attempts, got %d (+%d dropped)", n, len(tl.Attempts), tl.Dropped)
+	}
+	if got, want := tl.Attempts[len(tl.Attempts)-1].Error, fmt.Sprintf("attempt %d", n); got != want {
+		t.Fatalf("expected last error %q, got %q", want, got)
+	}
+	if tl.End.Before(tl.Start) {
+		t.Fatalf("timeline ends before it starts: %v", tl)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	errFatal := errors.New("image cannot be pulled")
+	errBackoff := errors.New("container restarting")
+
+	t.Run("fatal", func(t *testing.T) {
+		before := len(FailedWaits())
+		n := 0
+		err := UntilSuccess(func() error {
+			n++
+			if n == 3 {
+				return errFatal
+			}
+			return fmt.Errorf("attempt %d", n)
+		}, Classify(func(err error) (Class, time.Duration) {
+			if err == errFatal {
+				return Fatal, 0
+			}
+			return Retryable, 0
+		}), Timeout(time.Second*10), Delay(time.Millisecond))
+		var retryErr *Error
+		if !errors.As(err, &retryErr) || !retryErr.Fatal {
+			t.Fatalf("expected a fatal retry error, got %v", err)
+		}
+		if !errors.Is(err, errFatal) {
+			t.Fatalf("expected the last error to be the fatal error, got %v", retryErr.Last)
+		}
+		if n != 3 || len(retryErr.Timeline.Attempts) != 3 {
+			t.Fatalf("expected the retries to stop after 3 attempts, got %d (%d recorded)", n, len(retryErr.Timeline.Attempts))
+		}
+		if waits := FailedWaits(); len(waits) != before+1 && len(waits) != maxFailedWaits {
+			t.Fatalf("expected the fatal wait to be recorded, got %d timelines", len(waits))
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		n := 0
+		start := time.Now()
+		err := UntilSuccess(func() error {
+			n++
+			if n < 3 {
+				return errBackoff
+			}
+			return nil
+		}, Classify(func(err error) (Class, time.Duration) {
+			return Backoff, time.Millisecond * 50
+		}), Timeout(time.Second*10), Delay(time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Millisecond*100 {
+			t.Fatalf("expected the retries to back off for 100ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("non-positive backoff", func(t *testing.T) {
+		n := 0
+		err := UntilSuccess(func() error {
+			n++
+			return errBackoff
+		}, Classify(func(err error) (Class, time.Duration) {
+			return Backoff, 0
+		}), Timeout(time.Millisecond*100), Delay(time.Millisecond*20))
+		if err == nil {
+			t.Fatal("expected a timeout")
+		}
+		if n > 10 {
+			t.Fatalf("expected the retries to fall back to the configured delay, got %d attempts", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		err := UntilSuccess(func() error {
+			return errBackoff
+		}, Classify(func(err error) (Class, time.Duration) {
+			return Backoff, time.Hour
+		}), Timeout(time.Millisecond*20), Delay(time.Millisecond))
+		var retryErr *Error
+		if !errors.As(err, &retryErr) || retryErr.Fatal || retryErr.Last != errBackoff {
+			t.Fatalf("expected a timeout during the backoff, got %v", err)
+		}
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/gogo/protobuf/proto"
@@ -34,8 +35,12 @@ import (
 var (
 	fixupNumericJSONComparison = regexp.MustCompile(`([=<>]+)\s*([0-9]+)\s*\)`)
 	fixupAttributeReference    = regexp.MustCompile(`\[\s*'[^']+\s*'\s*]`)
+	bracedPath                 = regexp.MustCompile(`^\{(.*)\}$`)
 )
 
+// maxSubtreeLength is the length beyond which the subtrees shown in errors are truncated.
+const maxSubtreeLength = 2000
+
 type Instance struct {
 	structure     interface{}
 	isJSON        bool
@@ -68,6 +73,18 @@ func ForProto(proto proto.Message) *Instance {
 	return i
 }
 
+// ForJSON creates a structpath Instance evaluating over the given JSON document, such as an Envoy config dump.
+func ForJSON(text string) *Instance {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		return newErrorInstance(fmt.Errorf("failed to parse into JSON struct: %v", err))
+	}
+	return &Instance{
+		isJSON:    true,
+		structure: parsed,
+	}
+}
+
 func newErrorInstance(err error) *Instance {
 	return &Instance{
 		isJSON:        true,
@@ -294,11 +311,8 @@ func (i *Instance) findValue(path string) (interface{}, error) {
 		return nil, fmt.Errorf("invalid path: %v - %v", path, err)
 	}
 	values, err := parser.FindResults(i.structure)
-	if err != nil {
-		return nil, fmt.Errorf("err finding results for path: %v: %v. Structure: %v", path, err, i.structure)
-	}
-	if len(values) == 0 || len(values[0]) == 0 {
-		return nil, fmt.Errorf("no value for path: %v", path)
+	if err != nil || len(values) == 0 || len(values[0]) == 0 {
+		return nil, i.missingPathError(path)
 	}
 	return values[0][0].Interface(), nil
 }
@@ -333,3 +347,273 @@ func (i *Instance) fixPath(path string) string {
 
 	return result
 }
+
+// Values returns all the values matching the path, which may select several values with wildcards or filters,
+// such as {.listeners[*].name} or {.clusters[?(@.name=='outbound|80||a.default')].type}.
+func (i *Instance) Values(path string, args ...interface{}) ([]interface{}, error) {
+	if i.creationError != nil {
+		return nil, i.creationError
+	}
+	path = fmt.Sprintf(path, args...)
+	return i.findValues(path)
+}
+
+// Expect adds a constraint that the expression holds. An expression compares the values matching a path, or
+// their number, to a literal:
+//
+//	{.path} == 'text'
+//	{.listeners[*].address.socketAddress.portValue} >= 15000
+//	{.name} =~ '^outbound\|'
+//	len({.clusters[?(@.type=='EDS')]}) == 3
+//
+// The operators are ==, !=, <, <=, >, >= and =~, which matches a regular expression. Literals are quoted strings,
+// numbers, true, false or null. Comparisons must hold for every value matching the path, and there must be at
+// least one.
+func (i *Instance) Expect(expression string, args ...interface{}) *Instance {
+	expression = fmt.Sprintf(expression, args...)
+	return i.appendConstraint(func() error {
+		return i.evaluate(expression)
+	})
+}
+
+var expressionRegex = regexp.MustCompile(`^\s*(len\(\s*)?(\{.*\})(\s*\))?\s*(==|!=|<=|>=|=~|<|>)\s*(.*?)\s*$`)
+
+func (i *Instance) evaluate(expression string) error {
+	m := expressionRegex.FindStringSubmatch(expression)
+	if m == nil || (m[1] == "") != (m[3] == "") {
+		return fmt.Errorf("invalid expression: %v", expression)
+	}
+	isLen, path, op, literal := m[1] != "", m[2], m[4], parseLiteral(m[5])
+	values, err := i.findValues(path)
+	if err != nil {
+		return err
+	}
+	if isLen {
+		ok, err := compare(float64(len(values)), op, literal)
+		if err != nil {
+			return fmt.Errorf("invalid expression: %v: %v", expression, err)
+		}
+		if !ok {
+			return fmt.Errorf("expected %v, but %v matched %d values", expression, path, len(values))
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return i.missingPathError(path)
+	}
+	var failed []string
+	for idx, v := range values {
+		ok, err := compare(v, op, literal)
+		if err != nil {
+			return fmt.Errorf("invalid expression: %v: %v", expression, err)
+		}
+		if !ok {
+			failed = append(failed, fmt.Sprintf("[%d]: %s", idx, subtree(v)))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("expected %v, but %d of the %d values of %v did not match:\n%v",
+			expression, len(failed), len(values), path, strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// parseLiteral parses the literal of an expression. Unquoted literals which are not numbers, booleans or null are
+// kept as strings.
+func parseLiteral(literal string) interface{} {
+	if len(literal) >= 2 && (literal[0] == '\'' || literal[0] == '"') && literal[len(literal)-1] == literal[0] {
+		return literal[1 : len(literal)-1]
+	}
+	switch literal {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if f, err := strconv.ParseFloat(literal, 64); err == nil {
+		return f
+	}
+	return literal
+}
+
+// compare applies the operator to a value and a literal. Numbers are compared as floats, as JSON only has floats.
+func compare(value interface{}, op string, literal interface{}) (bool, error) {
+	if op == "=~" {
+		pattern, ok := literal.(string)
+		if !ok {
+			return false, fmt.Errorf("=~ requires a regular expression, got %v", literal)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		s, ok := value.(string)
+		if !ok {
+			s = subtree(value)
+		}
+		return re.MatchString(s), nil
+	}
+	value = normalizeNumber(value)
+	switch op {
+	case "==":
+		return reflect.DeepEqual(value, literal), nil
+	case "!=":
+		return !reflect.DeepEqual(value, literal), nil
+	}
+	var cmp int
+	switch v := value.(type) {
+	case float64:
+		l, ok := literal.(float64)
+		if !ok {
+			return false, fmt.Errorf("%v requires a number, got %v", op, literal)
+		}
+		cmp = compareFloats(v, l)
+	case string:
+		l, ok := literal.(string)
+		if !ok {
+			return false, fmt.Errorf("%v requires a string to compare with %q, got %v", op, v, literal)
+		}
+		cmp = strings.Compare(v, l)
+	default:
+		// Values which cannot be ordered, such as objects, don't match.
+		return false, nil
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// normalizeNumber converts the numbers of non-JSON structures to floats, so they compare with number literals.
+func normalizeNumber(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32:
+		return v.Float()
+	}
+	return value
+}
+
+func (i *Instance) findValues(path string) ([]interface{}, error) {
+	parser := jsonpath.New("path")
+	if err := parser.Parse(i.fixPath(path)); err != nil {
+		return nil, fmt.Errorf("invalid path: %v - %v", path, err)
+	}
+	results, err := parser.AllowMissingKeys(true).FindResults(i.structure)
+	if err != nil {
+		return nil, fmt.Errorf("err finding results for path: %v - %v", path, err)
+	}
+	var out []interface{}
+	for _, values := range results {
+		for _, v := range values {
+			out = append(out, v.Interface())
+		}
+	}
+	return out, nil
+}
+
+// missingPathError returns the error for a path without value. It shows the subtree of the longest prefix of the
+// path which has a value, as the path usually differs from the structure at its next segment.
+func (i *Instance) missingPathError(path string) error {
+	prefixes := pathPrefixes(path)
+	for k := len(prefixes) - 2; k >= 0; k-- {
+		values, err := i.findValues(prefixes[k])
+		if err != nil || len(values) == 0 {
+			continue
+		}
+		var nearest interface{} = values
+		if len(values) == 1 {
+			nearest = values[0]
+		}
+		return fmt.Errorf("no value for path: %v. The nearest match is %v:\n%v", path, prefixes[k], subtree(nearest))
+	}
+	return fmt.Errorf("no value for path: %v", path)
+}
+
+// pathPrefixes splits a path such as {.a.b[0].c} into its prefixes {.a}, {.a.b}, {.a.b[0]} and {.a.b[0].c}. Only
+// paths made of a single template are split.
+func pathPrefixes(path string) []string {
+	m := bracedPath.FindStringSubmatch(strings.TrimSpace(path))
+	if m == nil {
+		return nil
+	}
+	inner := m[1]
+	var segments []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+	depth := 0
+	var quote byte
+	for idx := 0; idx < len(inner); idx++ {
+		c := inner[idx]
+		switch {
+		case c == '\\' && idx+1 < len(inner):
+			current.WriteByte(c)
+			idx++
+			c = inner[idx]
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			if depth == 0 && c == '[' {
+				flush()
+			}
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '.' && depth == 0:
+			// Keep the .. of recursive descents in a single segment.
+			if current.String() != "." {
+				flush()
+			}
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	out := make([]string, 0, len(segments))
+	for k := range segments {
+		out = append(out, "{"+strings.Join(segments[:k+1], "")+"}")
+	}
+	return out
+}
+
+// subtree formats a value for errors, truncating large subtrees.
+func subtree(value interface{}) string {
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(out) > maxSubtreeLength {
+		return string(out[:maxSubtreeLength]) + "\n..."
+	}
+	return string(out)
+}
@@ -15,6 +15,7 @@
 package structpath_test
 
 import (
+	"strings"
 	"testing"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -63,3 +64,81 @@ func TestContainSubstring(t *testing.T) {
 		})
 	}
 }
+
+const configDump = `{
+  "listeners": [
+    {"name": "a", "port": 80, "filters": [{"name": "envoy.filters.network.http_connection_manager"}]},
+    {"name": "b", "port": 15001, "filters": [{"name": "envoy.filters.network.tcp_proxy"}]}
+  ]
+}`
+
+func TestExpect(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		err        string
+	}{
+		{name: "equals", expression: "{.listeners[0].name} == 'a'"},
+		{name: "filter", expression: "{.listeners[?(@.port==15001)].name} == 'b'"},
+		{name: "wildcard", expression: "{.listeners[*].port} >= 80"},
+		{name: "regex", expression: "{.listeners[*].filters[*].name} =~ '^envoy\\.filters\\.network\\.'"},
+		{name: "len", expression: "len({.listeners[*]}) == 2"},
+		{name: "len of missing", expression: "len({.listeners[?(@.name=='c')]}) == 0"},
+		{
+			name:       "wildcard mismatch",
+			expression: "{.listeners[*].port} < 1000",
+			err:        "1 of the 2 values of {.listeners[*].port} did not match",
+		},
+		{
+			name:       "len mismatch",
+			expression: "len({.listeners[*]}) > 2",
+			err:        "matched 2 values",
+		},
+		{
+			name:       "missing",
+			expression: "{.listeners[0].address} == 'x'",
+			err:        "The nearest match is {.listeners[0]}",
+		},
+		{
+			name:       "invalid",
+			expression: "{.listeners[0].name} ~ 'a'",
+			err:        "invalid expression",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := structpath.ForJSON(configDump).Expect(tt.expression).Check()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	values, err := structpath.ForJSON(configDump).Values("{.listeners[*].name}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != "a" || values[1] != "b" {
+		t.Fatalf("unexpected values %v", values)
+	}
+}
+
+func TestMissingPathError(t *testing.T) {
+	err := structpath.ForJSON(configDump).Exists("{.listeners[1].filters[0].typedConfig}").Check()
+	if err == nil {
+		t.Fatal("expected the path to be missing")
+	}
+	for _, want := range []string{"The nearest match is {.listeners[1].filters[0]}", "envoy.filters.network.tcp_proxy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in the error, got %v", want, err)
+		}
+	}
+}
@@ -51,6 +51,14 @@ func TestCrossClusterLoadbalancing(t *testing.T) {
 	multicluster.LoadbalancingTest(t, appCtx, "installation.multicluster.multimaster", "installation.multicluster.remote")
 }
 
+func TestImpairedNetwork(t *testing.T) {
+	multicluster.ImpairedNetworkTest(t, appCtx, "installation.multicluster.multimaster", "installation.multicluster.remote")
+}
+
+func TestEgressProxy(t *testing.T) {
+	multicluster.EgressProxyTest(t, appCtx, ist, "installation.multicluster.multimaster", "installation.multicluster.remote")
+}
+
 func TestClusterLocalService(t *testing.T) {
 	multicluster.ClusterLocalTest(t, appCtx, "installation.multicluster.multimaster", "installation.multicluster.remote")
 }
@@ -0,0 +1,58 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/features"
+	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// EgressProxyTest verifies that cross-cluster traffic flows when the egress of the clusters goes through a forward
+// proxy, and that the control planes reach the other clusters through it.
+func EgressProxyTest(t *testing.T, apps AppContext, ist istio.Instance, features ...features.Feature) {
+	framework.NewTest(t).
+		Label(label.Multicluster).
+		Features(features...).
+		Run(func(ctx framework.TestContext) {
+			if !ist.Settings().EgressProxy {
+				ctx.Skip("Istio was not deployed with an egress proxy")
+			}
+			for _, src := range apps.UniqueEchos {
+				for _, dst := range apps.UniqueEchos {
+					callOrFail(ctx, src, dst)
+				}
+			}
+			for _, cluster := range ctx.Clusters() {
+				proxy := ist.EgressProxyFor(cluster)
+				retry.UntilSuccessOrFail(ctx, func() error {
+					n, err := proxy.Requests()
+					if err != nil {
+						return err
+					}
+					if n == 0 {
+						return fmt.Errorf("no request transited the egress proxy of %s", cluster.Name())
+					}
+					return nil
+				})
+			}
+		})
+}
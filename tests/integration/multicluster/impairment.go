@@ -0,0 +1,50 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/chaos"
+	"istio.io/istio/pkg/test/framework/features"
+	"istio.io/istio/pkg/test/framework/label"
+)
+
+// ImpairedNetworkTest verifies that cross-cluster traffic is still load balanced across all clusters when the
+// east-west traffic leaving the source cluster is subject to WAN-like latency and packet loss.
+func ImpairedNetworkTest(t *testing.T, apps AppContext, features ...features.Feature) {
+	framework.NewTest(t).
+		Label(label.Multicluster).
+		Features(features...).
+		Run(func(ctx framework.TestContext) {
+			src := apps.LBEchos[0]
+			chaos.NewNetworkOrFail(ctx, ctx, chaos.NetworkConfig{
+				Cluster: src.Config().Cluster,
+				Impairment: chaos.Impairment{
+					Latency: 100 * time.Millisecond,
+					Jitter:  20 * time.Millisecond,
+					Loss:    5,
+				},
+			})
+			ctx.NewSubTest(fmt.Sprintf("from %s", src.Config().Cluster.Name())).
+				Run(func(ctx framework.TestContext) {
+					callOrFail(ctx, src, apps.LBEchos[0], checkReachedAllSubsets(apps.LBEchos))
+				})
+		})
+}
@@ -0,0 +1,63 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/chaos"
+	"istio.io/istio/pkg/test/framework/features"
+	"istio.io/istio/pkg/test/framework/label"
+)
+
+// NodeFailureTest verifies that cross-cluster traffic reaches all clusters again once the workloads of a
+// remote node are rescheduled, after the node is drained and after it fails. The node may host the control plane or
+// the gateways, so the test must run in its own suite.
+func NodeFailureTest(t *testing.T, apps AppContext, features ...features.Feature) {
+	framework.NewTest(t).
+		Label(label.Multicluster).
+		Features(features...).
+		Run(func(ctx framework.TestContext) {
+			src, dst := apps.LBEchos[0], apps.LBEchos[1]
+			nodes, err := dst.Config().Cluster.CoreV1().Nodes().List(context.TODO(), kubeApiMeta.ListOptions{})
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			if len(nodes.Items) < 2 {
+				ctx.Skipf("%s has a single node, workloads cannot be rescheduled", dst.Config().Cluster.Name())
+			}
+			cfg := chaos.NodeConfig{
+				Cluster:   dst.Config().Cluster,
+				Namespace: apps.Namespace.Name(),
+				Selector:  fmt.Sprintf("app=%s", dst.Config().Service),
+			}
+			ctx.NewSubTest("drain").
+				Run(func(ctx framework.TestContext) {
+					chaos.DrainNodeOrFail(ctx, ctx, cfg)
+					callOrFail(ctx, src, dst, checkReachedAllSubsets(apps.LBEchos))
+				})
+			ctx.NewSubTest("failure").
+				Run(func(ctx framework.TestContext) {
+					chaos.DeleteNodeOrFail(ctx, ctx, cfg)
+					callOrFail(ctx, src, dst, checkReachedAllSubsets(apps.LBEchos))
+				})
+		})
+}
@@ -0,0 +1,50 @@
+// +build integ
+//  Copyright Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package nodefailure
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/tests/integration/multicluster"
+)
+
+var (
+	ist    istio.Instance
+	appCtx multicluster.AppContext
+)
+
+// TestMain runs the node failure test in its own suite: deleting a node takes down every pod on it, including
+// the control plane and the gateways, which would fail the tests run after it.
+func TestMain(m *testing.M) {
+	framework.
+		NewSuite(m).
+		Label(label.Multicluster).
+		RequireMinClusters(2).
+		Setup(multicluster.Setup(&appCtx)).
+		Setup(istio.Setup(&ist, func(_ resource.Context, cfg *istio.Config) {
+			cfg.ControlPlaneValues = appCtx.ControlPlaneValues
+		})).
+		Setup(multicluster.SetupApps(&appCtx)).
+		Run()
+}
+
+func TestNodeFailure(t *testing.T) {
+	multicluster.NodeFailureTest(t, appCtx, "installation.multicluster.multimaster", "installation.multicluster.remote")
+}
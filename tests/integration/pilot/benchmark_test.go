@@ -0,0 +1,47 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// BenchmarkEchoCall measures the latency of requests between two sidecars, including the overhead of forwarding
+// them through the echo client.
+func BenchmarkEchoCall(b *testing.B) {
+	framework.
+		NewBenchmark(b).
+		RequiresSingleCluster().
+		Run(func(ctx framework.BenchmarkContext) {
+			// Warm up the connection outside of the measurement.
+			ctx.StopTimer()
+			apps.PodA[0].CallWithRetryOrFail(ctx, echo.CallOptions{
+				Target:   apps.PodB[0],
+				PortName: "http",
+			})
+			ctx.StartTimer()
+
+			for i := 0; i < ctx.N(); i++ {
+				apps.PodA[0].CallOrFail(ctx, echo.CallOptions{
+					Target:   apps.PodB[0],
+					PortName: "http",
+				})
+			}
+		})
+}
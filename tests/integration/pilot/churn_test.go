@@ -0,0 +1,74 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/churn"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/pilot/common"
+)
+
+// TestConnectionChurn opens many short lived connections from a sidecar while the endpoints of the destination are
+// added and removed, and verifies that requests keep succeeding and that the client proxy does not leak memory.
+func TestConnectionChurn(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.performance").
+		RequiresSingleCluster().
+		Run(func(ctx framework.TestContext) {
+			src, dst := apps.PodA[0], apps.PodB[0]
+			var sidecars []echo.Sidecar
+			for _, w := range src.WorkloadsOrFail(ctx) {
+				sidecars = append(sidecars, w.Sidecar())
+			}
+			opts := echo.CallOptions{
+				Target:   dst,
+				PortName: "http",
+			}
+			src.CallWithRetryOrFail(ctx, opts)
+
+			deployment := common.PodBSvc + "-v1"
+			rotate := churn.ScaleRotator(dst.Config().Cluster, apps.Namespace.Name(), deployment, 3, 1)
+			g, err := churn.NewGenerator(churn.Config{
+				Traffic:        traffic.Config{Source: src, Options: opts, Interval: 10 * time.Millisecond},
+				Rotate:         rotate,
+				RotateInterval: 15 * time.Second,
+				Sidecars:       sidecars,
+			}).Start()
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			time.Sleep(time.Minute)
+			res, err := g.Stop()
+			if err != nil {
+				ctx.Fatal(err)
+			}
+
+			// Leave the destination with a single replica, as the other tests expect.
+			retry.UntilSuccessOrFail(ctx, churn.ScaleRotator(dst.Config().Cluster, apps.Namespace.Name(), deployment, 1))
+			if res.RotationError != nil {
+				ctx.Fatal(res.RotationError)
+			}
+			res.Traffic.CheckSuccessRateOrFail(ctx, 0.99)
+			res.CheckMemoryGrowthOrFail(ctx, 1.5)
+		})
+}
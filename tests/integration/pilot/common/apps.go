@@ -22,6 +22,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/sidecarscope"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/components/namespace"
@@ -55,6 +56,11 @@ type EchoDeployments struct {
 	External echo.Instances
 
 	All echo.Instances
+
+	// Sidecar restricting the egress of the workloads in Namespace to it and istio-system
+	Sidecar sidecarscope.Sidecar
+	// Namespaces of the hosts of the ServiceEntries applied to Namespace
+	ServiceEntryHosts sidecarscope.HostNamespaces
 }
 
 const (
@@ -206,17 +212,12 @@ func SetupApps(ctx resource.Context, i istio.Instance, apps *EchoDeployments) er
 	apps.External = echos.Match(echo.Service(ExternalSvc))
 	apps.VM = echos.Match(echo.Service(VMSvc))
 
-	if err := ctx.Config().ApplyYAML(apps.Namespace.Name(), `
-apiVersion: networking.istio.io/v1alpha3
-kind: Sidecar
-metadata:
-  name: restrict-to-namespace
-spec:
-  egress:
-  - hosts:
-    - "./*"
-    - "istio-system/*"
-`); err != nil {
+	apps.Sidecar = sidecarscope.Sidecar{
+		Name:      "restrict-to-namespace",
+		Namespace: apps.Namespace.Name(),
+		Hosts:     []string{"./*", "istio-system/*"},
+	}
+	if err := ctx.Config().ApplyYAML(apps.Namespace.Name(), apps.Sidecar.YAML()); err != nil {
 		return err
 	}
 
@@ -244,6 +245,7 @@ spec:
 	if err := ctx.Config().ApplyYAML(apps.Namespace.Name(), se); err != nil {
 		return err
 	}
+	apps.ServiceEntryHosts = sidecarscope.HostNamespaces{externalHostname: apps.Namespace.Name()}
 	return nil
 }
 
@@ -0,0 +1,66 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/convergence"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const convergenceVirtualService = `
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: convergence
+spec:
+  hosts:
+  - {{.Host}}
+  http:
+  - route:
+    - destination:
+        host: {{.Host}}
+    timeout: 10s
+`
+
+// TestConfigConvergence verifies that a route change is acknowledged by the client proxies in a timely manner.
+func TestConfigConvergence(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.routing").
+		RequiresSingleCluster().
+		Run(func(ctx framework.TestContext) {
+			var proxies []string
+			for _, w := range apps.PodA[0].WorkloadsOrFail(ctx) {
+				proxies = append(proxies, w.Sidecar().NodeID())
+			}
+			c := convergence.NewOrFail(ctx, ctx, convergence.Config{
+				Proxies: proxies,
+				Types:   []convergence.Type{convergence.Route},
+			})
+			vs := tmpl.EvaluateOrFail(ctx, convergenceVirtualService, map[string]string{
+				"Host": apps.PodB[0].Config().FQDN(),
+			})
+			r := c.MeasureOrFail(ctx, "virtualservice", func() error {
+				applyAndCleanup(ctx, apps.Namespace.Name(), vs)
+				return nil
+			})
+			r.CheckOrFail(ctx, 100, 20*time.Second)
+		})
+}
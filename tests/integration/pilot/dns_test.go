@@ -0,0 +1,71 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const autoAllocateHost = "auto-allocate.example.com"
+
+const autoAllocateServiceEntry = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: dns-auto-allocate
+spec:
+  hosts:
+  - {{.Host}}
+  location: MESH_EXTERNAL
+  resolution: DNS
+  endpoints:
+  - address: external.{{.Namespace}}.svc.cluster.local
+  ports:
+  - name: http
+    number: 80
+    protocol: HTTP
+`
+
+// TestDNSAutoAllocate verifies that the sidecar DNS proxy resolves the host of a ServiceEntry without addresses
+// to a stable auto allocated VIP, and that traffic to the VIP is routed to the ServiceEntry endpoints.
+func TestDNSAutoAllocate(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.dns").
+		Run(func(ctx framework.TestContext) {
+			se := tmpl.EvaluateOrFail(ctx, autoAllocateServiceEntry, map[string]string{
+				"Host":      autoAllocateHost,
+				"Namespace": apps.ExternalNamespace.Name(),
+			})
+			applyAndCleanup(ctx, apps.Namespace.Name(), se)
+
+			retry.UntilSuccessOrFail(ctx, func() error {
+				_, err := echo.CheckAutoAllocatedVIP(apps.PodA, autoAllocateHost, 3)
+				return err
+			})
+			apps.PodA[0].CallWithRetryOrFail(ctx, echo.CallOptions{
+				Target:     apps.External[0],
+				PortName:   "http",
+				Host:       autoAllocateHost,
+				Validators: echo.NewValidators().WithOK(),
+			})
+		})
+}
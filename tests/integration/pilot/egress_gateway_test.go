@@ -0,0 +1,63 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/egressgateway"
+)
+
+// TestEgressGateway routes the traffic to an external host through a dedicated egress gateway and verifies
+// that it actually leaves the mesh through the gateway.
+func TestEgressGateway(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.egress.gateway").
+		Run(func(ctx framework.TestContext) {
+			gw := egressgateway.NewOrFail(t, ctx, egressgateway.Config{})
+			for _, mtls := range []bool{false, true} {
+				mtls := mtls
+				name := "plaintext"
+				if mtls {
+					name = "mtls"
+				}
+				ctx.NewSubTest(name).Run(func(ctx framework.TestContext) {
+					// The ServiceEntry for the host is set up with the apps, pointing at the external service.
+					gw.AddRouteOrFail(ctx, egressgateway.Route{
+						Name:      "external-" + name,
+						Host:      "fake.external.com",
+						Port:      80,
+						MutualTLS: mtls,
+					})
+					src := apps.PodA[0]
+					gw.CheckTransitOrFail(ctx, "fake.external.com", 80, func() error {
+						resp, err := src.CallWithRetry(echo.CallOptions{
+							Target:     apps.External[0],
+							PortName:   "http",
+							HostHeader: "fake.external.com",
+						})
+						if err != nil {
+							return err
+						}
+						return resp.CheckOK()
+					})
+				})
+			}
+		})
+}
@@ -0,0 +1,51 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/gatewayconformance"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// TestGatewayConformance runs the upstream Gateway API conformance suite, checked out at
+// $GATEWAY_CONFORMANCE_DIR, against the Istio of this suite. Each conformance test is reported as a subtest.
+func TestGatewayConformance(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.ingress.gateway-api-conformance").
+		RequiresCapabilities(resource.GatewayAPI).
+		Run(func(ctx framework.TestContext) {
+			if !gatewayconformance.Available() {
+				t.Skip("No conformance suite configured")
+			}
+			suite := gatewayconformance.NewOrFail(t, ctx, gatewayconformance.Config{})
+			results := suite.RunOrFail(t)
+			for _, r := range results {
+				r := r
+				ctx.NewSubTest(r.Name).Run(func(ctx framework.TestContext) {
+					switch r.Outcome {
+					case gatewayconformance.Fail:
+						ctx.Fatalf("conformance test failed:\n%s", r.Output)
+					case gatewayconformance.Skip:
+						ctx.Skipf("skipped by the conformance suite:\n%s", r.Output)
+					}
+				})
+			}
+		})
+}
@@ -22,6 +22,7 @@ import (
 
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
+	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/util/retry"
 	ingressutil "istio.io/istio/tests/integration/security/sds_ingress/util"
 )
@@ -29,10 +30,8 @@ import (
 func TestGateway(t *testing.T) {
 	framework.
 		NewTest(t).
+		RequiresCapabilities(resource.GatewayAPI).
 		Run(func(ctx framework.TestContext) {
-			if !supportsCRDv1(ctx) {
-				t.Skip("Not supported; requires CRDv1 support.")
-			}
 			ctx.Config().ApplyYAMLOrFail(ctx, apps.Namespace.Name(), `
 apiVersion: networking.x-k8s.io/v1alpha1
 kind: GatewayClass
@@ -133,23 +132,13 @@ spec:
 		})
 }
 
-func skipIfIngressClassUnsupported(ctx framework.TestContext) {
-	ver, err := ctx.Clusters().Default().GetKubernetesVersion()
-	if err != nil {
-		ctx.Fatalf("failed to get Kubernetes version: %v", err)
-	}
-	serverVersion := fmt.Sprintf("%s.%s", ver.Major, ver.Minor)
-	if serverVersion < "1.18" {
-		ctx.Skip("IngressClass not supported")
-	}
-}
-
 // TestIngress tests that we can route using standard Kubernetes Ingress objects.
 func TestIngress(t *testing.T) {
 	framework.
 		NewTest(t).
+		// IngressClass requires Kubernetes 1.18.
+		RequiresKubernetesVersion("1.18").
 		Run(func(ctx framework.TestContext) {
-			skipIfIngressClassUnsupported(ctx)
 			// Set up secret contain some TLS certs for *.example.com
 			// we will define one for foo.example.com and one for bar.example.com, to ensure both can co-exist
 			credName := "k8s-ingress-secret-foo"
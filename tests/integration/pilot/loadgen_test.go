@@ -0,0 +1,51 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/loadgen"
+)
+
+// TestLoadSmoke sends a moderate load from a sidecar to an echo service, and verifies that no request fails and
+// that latencies stay far below what would indicate a broken data path.
+func TestLoadSmoke(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.performance").
+		RequiresSingleCluster().
+		Run(func(ctx framework.TestContext) {
+			url, err := loadgen.EchoURL(apps.PodB[0], "http", "/")
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			lg := loadgen.NewOrFail(ctx, ctx, loadgen.Config{})
+			res := lg.RunOrFail(ctx, loadgen.Options{
+				URL:      url,
+				QPS:      50,
+				Duration: 20 * time.Second,
+			})
+			res.CheckOrFail(ctx, loadgen.SLO{
+				MaxLatency: map[float64]time.Duration{
+					50: 100 * time.Millisecond,
+					99: time.Second,
+				},
+			})
+		})
+}
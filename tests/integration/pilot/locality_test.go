@@ -29,6 +29,7 @@ import (
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/scopes"
 	"istio.io/istio/pkg/test/util/retry"
 	"istio.io/istio/tests/integration/pilot/common"
@@ -253,3 +254,100 @@ func runTemplate(t test.Failer, tmpl string, input interface{}) string {
 	}
 	return buf.String()
 }
+
+const localityDestinationRule = `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: {{.Host}}
+spec:
+  host: {{.Host}}
+  trafficPolicy:
+    loadBalancer:
+      simple: ROUND_ROBIN
+      localityLbSetting:
+{{.LocalitySetting | indent 8 }}
+    outlierDetection:
+      interval: 1s
+      baseEjectionTime: 3m
+      maxEjectionPercent: 100`
+
+// TestLocalityInstances deploys echo instances into different localities and verifies the locality aware
+// load balancing of traffic from a client in region/zone/subzone.
+func TestLocalityInstances(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.locality").
+		RequiresSingleCluster().
+		Run(func(ctx framework.TestContext) {
+			const (
+				local     = "region/zone/subzone"
+				nearLocal = "nearregion/zone/subzone"
+				remote    = "notregion/notzone/notsubzone"
+			)
+			var all, failover echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&all, echo.Config{
+					Service:   "locality",
+					Namespace: apps.Namespace,
+					Ports:     common.EchoPorts,
+					Subsets: []echo.SubsetConfig{
+						{Version: "v1", Locality: local},
+						{Version: "v2", Locality: nearLocal},
+						{Version: "v3", Locality: remote},
+					},
+				}).
+				With(&failover, echo.Config{
+					Service:   "locality-failover",
+					Namespace: apps.Namespace,
+					Ports:     common.EchoPorts,
+					Subsets: []echo.SubsetConfig{
+						{Version: "v2", Locality: nearLocal},
+						{Version: "v3", Locality: remote},
+					},
+				}).
+				BuildOrFail(ctx)
+
+			cases := []struct {
+				name      string
+				target    echo.Instance
+				setting   string
+				validator echo.Validator
+			}{
+				{
+					name:      "Prioritized",
+					target:    all,
+					setting:   localityFailover,
+					validator: echo.ValidateFailoverOrder(echo.Instances{all}, local, nearLocal, remote),
+				},
+				{
+					name:      "Failover",
+					target:    failover,
+					setting:   localityFailover,
+					validator: echo.ValidateFailoverOrder(echo.Instances{failover}, local, nearLocal, remote),
+				},
+				{
+					name:    "Distribute",
+					target:  all,
+					setting: localityDistribute,
+					validator: echo.ValidateLocalityDistribution(echo.Instances{all},
+						map[string]int{local: 20, nearLocal: 80}, 10),
+				},
+			}
+			for _, tt := range cases {
+				ctx.NewSubTest(tt.name).Run(func(ctx framework.TestContext) {
+					host := fmt.Sprintf("%s.%s.svc.cluster.local", tt.target.Config().Service, apps.Namespace.Name())
+					applyAndCleanup(ctx, apps.Namespace.Name(), runTemplate(ctx, localityDestinationRule, LocalityInput{
+						Host:            host,
+						LocalitySetting: tt.setting,
+					}))
+					apps.PodA[0].CallWithRetryOrFail(ctx, echo.CallOptions{
+						Target:     tt.target,
+						PortName:   "http",
+						Count:      sendCount,
+						Validators: echo.NewValidators().WithOK().With(tt.validator),
+					}, retry.Delay(250*time.Millisecond))
+				})
+			}
+		})
+}
@@ -16,13 +16,15 @@
 package pilot
 
 import (
-	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/soak"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/resource"
@@ -38,15 +40,6 @@ var (
 	apps = &common.EchoDeployments{}
 )
 
-func supportsCRDv1(ctx resource.Context) bool {
-	ver, err := ctx.Clusters()[0].GetKubernetesVersion()
-	if err != nil {
-		return true
-	}
-	serverVersion := fmt.Sprintf("%s.%s", ver.Major, ver.Minor)
-	return serverVersion >= "1.16"
-}
-
 // TestMain defines the entrypoint for pilot tests using a standard Istio installation.
 // If a test requires a custom install it should go into its own package, otherwise it should go
 // here to reuse a single install across tests.
@@ -54,14 +47,22 @@ func TestMain(m *testing.M) {
 	framework.
 		NewSuite(m).
 		Setup(func(ctx resource.Context) (err error) {
-			if supportsCRDv1(ctx) {
-				crd, err := ioutil.ReadFile("testdata/service-apis-crd.yaml")
-				if err != nil {
-					return err
+			// The Gateway API CRDs are only installed on the clusters which support them, or whose support cannot
+			// be detected. Tests using them must require the GatewayAPI capability.
+			var clusters resource.Clusters
+			for _, c := range ctx.Clusters() {
+				if caps, err := c.Capabilities(); err != nil || caps.Has(resource.CRDv1) {
+					clusters = append(clusters, c)
 				}
-				return ctx.Config().ApplyYAML("", string(crd))
 			}
-			return nil
+			if len(clusters) == 0 {
+				return nil
+			}
+			crd, err := ioutil.ReadFile("testdata/service-apis-crd.yaml")
+			if err != nil {
+				return err
+			}
+			return ctx.Config(clusters...).ApplyYAML("", string(crd))
 		}).
 		Setup(istio.Setup(&i, func(ctx resource.Context, cfg *istio.Config) {
 			cfg.Values["telemetry.v2.metadataExchange.wasmEnabled"] = "false"
@@ -71,9 +72,30 @@ func TestMain(m *testing.M) {
 		Setup(func(ctx resource.Context) error {
 			return common.SetupApps(ctx, i, apps)
 		}).
+		Soak(soakConfig).
 		Run()
 }
 
+// soakConfig keeps traffic flowing between the standard echo apps in soak mode, while checking that their
+// sidecars do not leak memory and keep renewing their certificates.
+func soakConfig(resource.Context) framework.SoakConfig {
+	scenarios := make([]framework.SoakScenario, 0, len(apps.PodA))
+	for _, a := range apps.PodA {
+		scenarios = append(scenarios, soak.Traffic("a-to-b-"+a.Config().Cluster.Name(), traffic.Config{
+			Source:  a,
+			Options: echo.CallOptions{Target: apps.PodB[0], PortName: "http"},
+		}, 5*time.Minute, 0.999))
+	}
+	sidecars := soak.Sidecars(apps.All)
+	return framework.SoakConfig{
+		Scenarios: scenarios,
+		Checks: []framework.SoakCheck{
+			soak.ProxyMemory(sidecars, 1.5),
+			soak.CertRenewal(sidecars),
+		},
+	}
+}
+
 func echoConfig(ns namespace.Instance, name string) echo.Config {
 	return echo.Config{
 		Service:   name,
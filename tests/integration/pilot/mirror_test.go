@@ -17,13 +17,10 @@ package pilot
 
 import (
 	"fmt"
-	"math"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/hashicorp/go-multierror"
-
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
@@ -154,13 +151,13 @@ func runMirrorTest(t *testing.T, options mirrorTestOptions) {
 								ctx.NewSubTest(string(proto)).Run(func(ctx framework.TestContext) {
 									retry.UntilSuccessOrFail(ctx, func() error {
 										testID := util.RandomString(16)
-										if err := sendTrafficMirror(podA, apps.PodB[0], proto, testID); err != nil {
-											return err
-										}
 										expected := c.expectedDestination
 										if expected == nil {
 											expected = apps.PodC
 										}
+										if err := sendTrafficMirror(podA, apps.PodB[0], expected, proto, testID); err != nil {
+											return err
+										}
 
 										return verifyTrafficMirror(apps.PodB, expected, c, testID)
 									}, retry.Delay(time.Second))
@@ -173,11 +170,12 @@ func runMirrorTest(t *testing.T, options mirrorTestOptions) {
 		})
 }
 
-func sendTrafficMirror(from, to echo.Instance, proto protocol.Instance, testID string) error {
+func sendTrafficMirror(from, to echo.Instance, mirror echo.Instances, proto protocol.Instance, testID string) error {
 	options := echo.CallOptions{
-		Target:   to,
-		Count:    100,
-		PortName: strings.ToLower(string(proto)),
+		Target:     to,
+		Count:      100,
+		PortName:   strings.ToLower(string(proto)),
+		Validators: echo.NewValidators().With(echo.ValidateMirrorDiscarded(mirror)),
 	}
 	switch proto {
 	case protocol.HTTP:
@@ -197,67 +195,11 @@ func sendTrafficMirror(from, to echo.Instance, proto protocol.Instance, testID s
 }
 
 func verifyTrafficMirror(dest, mirror echo.Instances, tc testCaseMirror, testID string) error {
-	countB, err := logCount(dest, testID)
-	if err != nil {
-		return err
-	}
-
-	countC, err := logCount(mirror, testID)
-	if err != nil {
-		return err
-	}
-
-	actualPercent := (countC / countB) * 100
-	deltaFromExpected := math.Abs(actualPercent - tc.percentage)
-
-	var merr *multierror.Error
-	if tc.threshold-deltaFromExpected < 0 {
-		err := fmt.Errorf("unexpected mirror traffic. Expected %g%%, got %.1f%% (threshold: %g%%, testID: %s)",
-			tc.percentage, actualPercent, tc.threshold, testID)
+	if err := echo.CheckMirrorPercentage(dest, mirror, testID, tc.percentage, tc.threshold); err != nil {
 		log.Infof("%v", err)
-		merr = multierror.Append(merr, err)
-	} else {
-		log.Infof("Got expected mirror traffic. Expected %g%%, got %.1f%% (threshold: %g%%, , testID: %s)",
-			tc.percentage, actualPercent, tc.threshold, testID)
-	}
-
-	//if tc.percentage < 100 {
-	//	if len(countsB) < len(dest.Clusters()) {
-	//		merr = multierror.Append(merr, fmt.Errorf("expected original destination in all clusters to be reached, but got: %v", countsB))
-	//	}
-	//}
-	//if tc.percentage > 0 {
-	//	if len(countsC) < len(mirror.Clusters()) {
-	//		merr = multierror.Append(merr, fmt.Errorf("expected mirror destination in all clusters to be reached, but got: %v", countsC))
-	//	}
-	//}
-
-	return merr.ErrorOrNil()
-}
-
-func logCount(instances echo.Instances, testID string) (float64, error) {
-	counts := map[string]float64{}
-	for _, instance := range instances {
-		workloads, err := instance.Workloads()
-		if err != nil {
-			return -1, fmt.Errorf("failed to get Subsets: %v", err)
-		}
-		var logs string
-		for _, w := range workloads {
-			l, err := w.Logs()
-			if err != nil {
-				return -1, fmt.Errorf("failed getting logs: %v", err)
-			}
-			logs += l
-		}
-		if c := float64(strings.Count(logs, testID)); c > 0 {
-			counts[instance.Config().Cluster.Name()] = c
-		}
-	}
-	var total float64
-	for _, c := range counts {
-		total += c
+		return err
 	}
-	// TODO(landow) mirorr split does not always hit all clusters
-	return total, nil
+	log.Infof("Got expected mirror traffic. Expected %g%% (threshold: %g%%, testID: %s)",
+		tc.percentage, tc.threshold, testID)
+	return nil
 }
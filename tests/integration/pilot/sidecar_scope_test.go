@@ -0,0 +1,37 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo/sidecarscope"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// TestSidecarScope checks that the proxies of the echo namespace only have clusters for the hosts allowed by the
+// Sidecar restricting it to itself and istio-system.
+func TestSidecarScope(t *testing.T) {
+	framework.NewTest(t).
+		Features("traffic.reachability").
+		Run(func(ctx framework.TestContext) {
+			// The Sidecar is applied during setup, so there is no snapshot of the clusters before it.
+			sidecarscope.WaitForScopeOrFail(ctx, apps.Sidecar, apps.ServiceEntryHosts, nil, apps.All,
+				retry.Timeout(time.Minute), retry.Delay(time.Second))
+		})
+}
@@ -0,0 +1,41 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/sniffing"
+)
+
+// TestProtocolSelection verifies that ports are handled with the protocol declared by their name or appProtocol,
+// and with the detected protocol otherwise.
+func TestProtocolSelection(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.sniffing").
+		Run(func(ctx framework.TestContext) {
+			var destination echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&destination, sniffing.Config("sniffing", apps.Namespace)).
+				BuildOrFail(ctx)
+
+			sniffing.Run(ctx, apps.PodA[0], destination, sniffing.Matrix)
+		})
+}
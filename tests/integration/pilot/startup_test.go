@@ -0,0 +1,57 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/churn"
+	"istio.io/istio/pkg/test/framework/components/echo/startup"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/pilot/common"
+)
+
+// TestSidecarStartup scales up an echo service and verifies that the new pod gets its sidecar ready and receives
+// traffic in a timely manner.
+func TestSidecarStartup(t *testing.T) {
+	framework.
+		NewTest(t).
+		Features("traffic.performance").
+		RequiresSingleCluster().
+		Run(func(ctx framework.TestContext) {
+			dst := apps.PodB[0]
+			cluster := dst.Config().Cluster
+			deployment := common.PodBSvc + "-v1"
+			r := startup.MeasureOrFail(ctx, startup.Config{
+				Cluster:   cluster,
+				Namespace: apps.Namespace.Name(),
+				Selector:  "app=" + common.PodBSvc,
+				Source:    apps.PodA[0],
+				Options: echo.CallOptions{
+					Target:   dst,
+					PortName: "http",
+				},
+			}, churn.ScaleRotator(cluster, apps.Namespace.Name(), deployment, 2))
+			// Leave the destination with a single replica, as the other tests expect.
+			retry.UntilSuccessOrFail(ctx, churn.ScaleRotator(cluster, apps.Namespace.Name(), deployment, 1))
+
+			r.CheckOrFail(ctx, startup.Ready, 100, time.Minute)
+			r.CheckOrFail(ctx, startup.FirstCall, 100, 90*time.Second)
+		})
+}
@@ -22,6 +22,7 @@ import (
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/kube"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/tests/integration/pilot/common"
 )
@@ -65,3 +66,36 @@ func TestVmOSPost(t *testing.T) {
 			}
 		})
 }
+
+// TestSimulatedVM onboards a VM running in a docker container outside of the cluster, rather than in a pod, and
+// checks traffic between it and the pods. It requires local docker, and is skipped unless the cluster nodes run on
+// the kind docker network.
+func TestSimulatedVM(t *testing.T) {
+	framework.
+		NewTest(t).
+		RequiresSingleCluster().
+		Features("traffic.reachability").
+		Label(label.Postsubmit).
+		Run(func(ctx framework.TestContext) {
+			if err := kube.SimulatedVMsSupported(ctx.Clusters().Default()); err != nil {
+				ctx.Skipf("simulated VMs are not supported: %v", err)
+			}
+
+			var vm echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&vm, echo.Config{
+					Service:     "simulated-vm",
+					Namespace:   apps.Namespace,
+					Ports:       common.EchoPorts,
+					DeployAsVM:  true,
+					SimulatedVM: true,
+					Subsets:     []echo.SubsetConfig{{}},
+					Cluster:     ctx.Clusters().Default(),
+				}).
+				BuildOrFail(ctx)
+
+			for _, tt := range common.VMTestCases(echo.Instances{vm}, apps) {
+				common.ExecuteTrafficTest(ctx, tt, apps.Namespace.Name())
+			}
+		})
+}
@@ -0,0 +1,149 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package carotation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/echo/traffic"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Workload certificates are re-issued at half of their lifetime, so they pick up a new CA within a minute.
+const proxyConfig = `proxyMetadata:
+  SECRET_TTL: 2m
+`
+
+// TestCARotation rotates the intermediate and then the root CA while a sends requests to b, and checks that no
+// request fails and that both workloads end up with certificates issued by the new CA.
+func TestCARotation(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.control-plane.ca-rotation").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "ca-rotation",
+				Inject: true,
+			})
+			config := func(name string) echo.Config {
+				return echo.Config{
+					Service:        name,
+					Namespace:      ns,
+					ServiceAccount: true,
+					Subsets: []echo.SubsetConfig{{
+						Annotations: echo.NewAnnotations().Set(echo.Annotation{
+							Name: annotation.ProxyConfig.Name,
+							Type: echo.WorkloadAnnotation,
+						}, proxyConfig),
+					}},
+					Ports: []echo.Port{{
+						Name:         "http",
+						Protocol:     protocol.HTTP,
+						InstancePort: 8090,
+					}},
+				}
+			}
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, config("a")).
+				With(&b, config("b")).
+				BuildOrFail(t)
+
+			filter := mtls.EnvoyFilter("forward-client-cert", map[string]string{"app": b.Config().Service})
+			ctx.Config().ApplyYAMLOrFail(t, ns.Name(), filter)
+			defer ctx.Config().DeleteYAMLOrFail(t, ns.Name(), filter)
+
+			opts := echo.CallOptions{
+				Target:   b,
+				PortName: "http",
+				Scheme:   scheme.HTTP,
+			}
+			a.CallWithRetryOrFail(t, opts)
+
+			for _, rotation := range []istio.CARotation{istio.RotateIntermediate, istio.RotateRoot} {
+				rotation := rotation
+				ctx.NewSubTest(rotation.String()).Run(func(ctx framework.TestContext) {
+					g := traffic.NewGenerator(traffic.Config{Source: a, Options: opts}).Start()
+
+					pluginCA, err := inst.RotateCA(rotation)
+					if err != nil {
+						ctx.Fatal(err)
+					}
+					intermediate, err := pluginCA.IntermediateCertFor(ctx.Clusters().Default())
+					if err != nil {
+						ctx.Fatal(err)
+					}
+					root, err := ioutil.ReadFile(pluginCA.Root.CertFile)
+					if err != nil {
+						ctx.Fatal(err)
+					}
+
+					// Wait for both workloads to be issued certificates by the new intermediate.
+					retry.UntilSuccessOrFail(ctx, func() error {
+						handshakes, err := mtls.Inspect(a, opts)
+						if err != nil {
+							return err
+						}
+						for _, h := range handshakes {
+							if err := checkIssuedBy(h.PeerChain, intermediate, root); err != nil {
+								return fmt.Errorf("client certificate: %v", err)
+							}
+						}
+						for _, w := range b.WorkloadsOrFail(ctx) {
+							chain, err := mtls.WorkloadChain(w.Sidecar())
+							if err != nil {
+								return err
+							}
+							if err := checkIssuedBy(chain, intermediate, root); err != nil {
+								return fmt.Errorf("server certificate: %v", err)
+							}
+						}
+						return nil
+					}, retry.Delay(5*time.Second), retry.Timeout(3*time.Minute))
+
+					g.Stop().CheckSuccessRateOrFail(ctx, 1)
+				})
+			}
+		})
+}
+
+// checkIssuedBy checks that the chain was issued by the given intermediate, and verifies against the given root.
+func checkIssuedBy(chain []*x509.Certificate, intermediatePEM, rootPEM []byte) error {
+	if err := mtls.CheckIssuedBy(chain, rootPEM); err != nil {
+		return err
+	}
+	intermediate, err := mtls.ParsePEMChain(intermediatePEM)
+	if err != nil {
+		return err
+	}
+	if len(chain) < 2 || !bytes.Equal(chain[1].Raw, intermediate[0].Raw) {
+		return fmt.Errorf("certificate of %s is not issued by the new intermediate CA yet", mtls.SPIFFEID(chain[0]))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/extauthz"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/authz"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+// TestAuthorization_ExtAuthz tests that requests to a workload are allowed, denied and mutated by an external
+// authorization server, over both the gRPC and the HTTP ext_authz API.
+func TestAuthorization_ExtAuthz(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.authorization.custom").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "ext-authz",
+				Inject: true,
+			})
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, util.EchoConfig("a", ns, false, nil)).
+				With(&b, util.EchoConfig("b", ns, false, nil)).
+				BuildOrFail(t)
+
+			server := authz.NewOrFail(t, ctx, authz.Config{
+				Server: extauthz.Config{
+					Rules: []extauthz.Rule{
+						{
+							Name:  "deny",
+							Match: extauthz.Match{PathPrefix: "/deny"},
+							Deny:  true,
+						},
+						{
+							Name:    "allow",
+							Match:   extauthz.Match{PathPrefix: "/allow"},
+							Headers: map[string]string{"x-ext-authz-user": "alice"},
+						},
+					},
+					DefaultDeny: true,
+				},
+			})
+
+			call := func(path string, check func(client.ParsedResponses) error) {
+				t.Helper()
+				retry.UntilSuccessOrFail(t, func() error {
+					resp, err := a.Call(echo.CallOptions{
+						Target:   b,
+						PortName: "http",
+						Scheme:   scheme.HTTP,
+						Path:     path,
+					})
+					if err != nil {
+						return err
+					}
+					return check(resp)
+				}, retry.Delay(250*time.Millisecond), retry.Timeout(30*time.Second))
+			}
+			denied := func(resp client.ParsedResponses) error {
+				return resp.Check(func(_ int, r *client.ParsedResponse) error {
+					if r.Code != response.StatusCodeForbidden {
+						return fmt.Errorf("expected code %s, got %s", response.StatusCodeForbidden, r.Code)
+					}
+					return nil
+				})
+			}
+			allowed := func(resp client.ParsedResponses) error {
+				if err := resp.CheckOK(); err != nil {
+					return err
+				}
+				return resp.Check(func(_ int, r *client.ParsedResponse) error {
+					// The header added by the server is passed on to b, which echoes it back.
+					if got := r.RawResponse["X-Ext-Authz-User"]; got != "alice" {
+						return fmt.Errorf("expected X-Ext-Authz-User header alice, got %q", got)
+					}
+					return nil
+				})
+			}
+
+			for _, api := range []authz.API{authz.GRPC, authz.HTTP} {
+				api := api
+				ctx.NewSubTest(string(api)).Run(func(ctx framework.TestContext) {
+					filter := server.EnvoyFilter("ext-authz-"+string(api), map[string]string{"app": b.Config().Service}, api)
+					ctx.Config().ApplyYAMLOrFail(ctx, ns.Name(), filter)
+					defer ctx.Config().DeleteYAMLOrFail(ctx, ns.Name(), filter)
+
+					// Requests are only denied once the filter is in place.
+					call("/deny", denied)
+					call("/other", denied)
+					if err := server.ClearRequests(); err != nil {
+						ctx.Fatal(err)
+					}
+					call("/allow", allowed)
+
+					reqs, err := server.WaitForRequests(1, func(r extauthz.CheckRequest) bool {
+						return r.Rule == "allow"
+					}, retry.Timeout(10*time.Second))
+					if err != nil {
+						ctx.Fatal(err)
+					}
+					want := extauthz.ProtocolGRPC
+					if api == authz.HTTP {
+						want = extauthz.ProtocolHTTP
+					}
+					if got := reqs[0].Protocol; got != want {
+						ctx.Fatalf("expected a check request over %s, got %s", want, got)
+					}
+				})
+			}
+		})
+}
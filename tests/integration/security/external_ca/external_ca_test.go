@@ -0,0 +1,121 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// TestExternalCA deploys a, which is issued its certificate by the external CA, and b, which is issued its
+// certificate by istiod, and checks that they can talk to each other over mTLS.
+func TestExternalCA(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.control-plane.external-ca").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "external-ca",
+				Inject: true,
+			})
+			config := func(name string, annotations echo.Annotations) echo.Config {
+				return echo.Config{
+					Service:        name,
+					Namespace:      ns,
+					ServiceAccount: true,
+					Subsets:        []echo.SubsetConfig{{Annotations: annotations}},
+					Ports: []echo.Port{{
+						Name:         "http",
+						Protocol:     protocol.HTTP,
+						InstancePort: 8090,
+					}},
+				}
+			}
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, config("a", echo.NewAnnotations().Set(echo.Annotation{
+					Name: annotation.ProxyConfig.Name,
+					Type: echo.WorkloadAnnotation,
+				}, ca.ProxyConfig()))).
+				With(&b, config("b", echo.NewAnnotations())).
+				BuildOrFail(t)
+
+			aID := fmt.Sprintf("spiffe://cluster.local/ns/%s/sa/a", ns.Name())
+			issued, err := ca.WaitForIssued(aID, retry.Timeout(time.Minute))
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			ctx.Logf("external CA issued certificate %s for %s", issued.SerialNumber, aID)
+
+			filter := mtls.EnvoyFilter("forward-client-cert", map[string]string{"app": b.Config().Service})
+			ctx.Config().ApplyYAMLOrFail(t, ns.Name(), filter)
+			defer ctx.Config().DeleteYAMLOrFail(t, ns.Name(), filter)
+
+			opts := echo.CallOptions{
+				Target:   b,
+				PortName: "http",
+				Scheme:   scheme.HTTP,
+			}
+			retry.UntilSuccessOrFail(ctx, func() error {
+				handshakes, err := mtls.Inspect(a, opts)
+				if err != nil {
+					return err
+				}
+				for _, h := range handshakes {
+					if err := mtls.CheckSPIFFEID(h.PeerChain, aID); err != nil {
+						return err
+					}
+					if err := ca.CheckIssued(h.PeerChain); err != nil {
+						return fmt.Errorf("client certificate: %v", err)
+					}
+				}
+				return nil
+			}, retry.Delay(time.Second), retry.Timeout(time.Minute))
+
+			// b keeps its certificate from istiod, and still accepts requests from a.
+			externalCert, err := mtls.ParsePEMChain(ca.Cert())
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			for _, w := range b.WorkloadsOrFail(ctx) {
+				chain, err := mtls.WorkloadChain(w.Sidecar())
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				if len(chain) > 1 && bytes.Equal(chain[1].Raw, externalCert[0].Raw) {
+					ctx.Fatalf("certificate of %s was unexpectedly issued by the external CA", mtls.SPIFFEID(chain[0]))
+				}
+			}
+
+			// And a accepts requests from b, whose certificate was issued by istiod.
+			b.CallWithRetryOrFail(t, echo.CallOptions{
+				Target:   a,
+				PortName: "http",
+				Scheme:   scheme.HTTP,
+			})
+		})
+}
@@ -0,0 +1,71 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/image"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+// TestFIPS checks that the sidecars only negotiate FIPS approved ciphers and curves. It only runs against FIPS
+// images, selected with --istio.test.variant=fips.
+func TestFIPS(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.peer.fips").
+		Run(func(ctx framework.TestContext) {
+			s, err := image.SettingsFromCommandLine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.IsFIPS() {
+				t.Skip("not running against FIPS images")
+			}
+
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "fips",
+				Inject: true,
+			})
+			annotations := echo.NewAnnotations().Set(echo.SidecarStatsInclusionRegexps, mtls.TLSStatsRegexp)
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, util.EchoConfig("a", ns, false, annotations)).
+				With(&b, util.EchoConfig("b", ns, false, annotations)).
+				BuildOrFail(t)
+
+			for _, opts := range []echo.CallOptions{
+				{Target: b, PortName: "http", Scheme: scheme.HTTP},
+				{Target: b, PortName: "tcp", Scheme: scheme.TCP},
+				{Target: b, PortName: "grpc", Scheme: scheme.GRPC},
+			} {
+				opts := opts
+				ctx.NewSubTest(opts.PortName).Run(func(ctx framework.TestContext) {
+					retry.UntilSuccessOrFail(ctx, func() error {
+						return mtls.CheckFIPSCall(a, opts)
+					}, retry.Delay(time.Second), retry.Timeout(30*time.Second))
+				})
+			}
+		})
+}
@@ -0,0 +1,117 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/components/oidc"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/authn"
+	"istio.io/istio/tests/integration/security/util/connection"
+)
+
+// TestRequestAuthentication_JWKSRotation tests that tokens are accepted or rejected as the signing keys of the
+// issuer are rolled over.
+func TestRequestAuthentication_JWKSRotation(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.authentication.jwks-rotation").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "jwks-rotation",
+				Inject: true,
+			})
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, util.EchoConfig("a", ns, false, nil)).
+				With(&b, util.EchoConfig("b", ns, false, nil)).
+				BuildOrFail(t)
+
+			provider := oidc.NewOrFail(t, ctx, oidc.Config{})
+			selector := map[string]string{"app": b.Config().Service}
+
+			check := func(ctx framework.TestContext, name, token, code string) {
+				tc := authn.TestCase{
+					Name: name,
+					Request: connection.Checker{
+						From: a,
+						Options: echo.CallOptions{
+							Target:   b,
+							PortName: "http",
+							Scheme:   scheme.HTTP,
+							Headers: map[string][]string{
+								authHeaderKey: {"Bearer " + token},
+							},
+						},
+					},
+					ExpectResponseCode: code,
+				}
+				retry.UntilSuccessOrFail(ctx, tc.CheckAuthn, retry.Delay(250*time.Millisecond), retry.Timeout(30*time.Second))
+			}
+			// applyJWKS has b trust the keys currently published by the provider.
+			applyJWKS := func(ctx framework.TestContext) {
+				policy, err := provider.RequestAuthenticationWithJWKS("jwks-rotation", selector)
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				ctx.Config().ApplyYAMLOrFail(ctx, ns.Name(), policy)
+			}
+
+			ctx.NewSubTest("jwks-uri").Run(func(ctx framework.TestContext) {
+				stats, err := provider.Stats()
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				policy := provider.RequestAuthentication("jwks-uri", selector)
+				ctx.Config().ApplyYAMLOrFail(ctx, ns.Name(), policy)
+				defer ctx.Config().DeleteYAMLOrFail(ctx, ns.Name(), policy)
+				if err := provider.WaitForJWKSFetch(stats, retry.Timeout(time.Minute)); err != nil {
+					ctx.Fatal(err)
+				}
+				check(ctx, "valid-token", provider.TokenOrFail(ctx, map[string]interface{}{"sub": "alice"}), response.StatusCodeOK)
+			})
+
+			ctx.NewSubTest("rollover").Run(func(ctx framework.TestContext) {
+				applyJWKS(ctx)
+				defer ctx.Config().DeleteYAMLOrFail(ctx, ns.Name(), provider.RequestAuthentication("jwks-rotation", selector))
+
+				oldToken := provider.TokenOrFail(ctx, map[string]interface{}{"sub": "alice"})
+				check(ctx, "old-token", oldToken, response.StatusCodeOK)
+
+				// While the previous key is still published, tokens of both keys are accepted.
+				provider.RotateKeysOrFail(ctx, true)
+				applyJWKS(ctx)
+				newToken := provider.TokenOrFail(ctx, map[string]interface{}{"sub": "alice"})
+				check(ctx, "new-token", newToken, response.StatusCodeOK)
+				check(ctx, "old-token-after-rotation", oldToken, response.StatusCodeOK)
+
+				// Once the previous keys are retired, only tokens of the current key are accepted.
+				provider.RotateKeysOrFail(ctx, false)
+				applyJWKS(ctx)
+				check(ctx, "old-token-after-retirement", oldToken, response.StatusUnauthorized)
+				check(ctx, "new-token-after-retirement", newToken, response.StatusUnauthorized)
+				check(ctx, "current-token", provider.TokenOrFail(ctx, map[string]interface{}{"sub": "alice"}), response.StatusCodeOK)
+			})
+		})
+}
@@ -0,0 +1,107 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+// TestMTLSHandshake checks the certificates presented in the mTLS handshake between two workloads: their
+// identities, the CA which issued them and their lifetime.
+func TestMTLSHandshake(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.peer.handshake").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "mtls-handshake",
+				Inject: true,
+			})
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, util.EchoConfig("a", ns, false, nil)).
+				With(&b, util.EchoConfig("b", ns, false, nil)).
+				BuildOrFail(t)
+
+			filter := mtls.EnvoyFilter("forward-client-cert", map[string]string{"app": b.Config().Service})
+			ctx.Config().ApplyYAMLOrFail(t, ns.Name(), filter)
+			defer ctx.Config().DeleteYAMLOrFail(t, ns.Name(), filter)
+
+			cm, err := ctx.Clusters().Default().CoreV1().ConfigMaps(ns.Name()).Get(context.TODO(),
+				"istio-ca-root-cert", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := []byte(cm.Data["root-cert.pem"])
+			spiffeID := func(sa string) string {
+				return fmt.Sprintf("spiffe://cluster.local/ns/%s/sa/%s", ns.Name(), sa)
+			}
+
+			var handshakes []mtls.Handshake
+			retry.UntilSuccessOrFail(t, func() error {
+				var err error
+				handshakes, err = mtls.Inspect(a, echo.CallOptions{
+					Target:   b,
+					PortName: "http",
+					Scheme:   scheme.HTTP,
+				})
+				return err
+			}, retry.Delay(250*time.Millisecond), retry.Timeout(30*time.Second))
+
+			for _, h := range handshakes {
+				if h.ServerID != spiffeID("b") {
+					t.Errorf("expected server identity %s, got %s", spiffeID("b"), h.ServerID)
+				}
+				if err := mtls.CheckSPIFFEID(h.PeerChain, spiffeID("a")); err != nil {
+					t.Error(err)
+				}
+				if err := mtls.CheckIssuedBy(h.PeerChain, root); err != nil {
+					t.Error(err)
+				}
+				// Workload certificates are issued for 24 hours by default.
+				if err := mtls.CheckLifetime(h.PeerChain, time.Hour, 25*time.Hour); err != nil {
+					t.Error(err)
+				}
+			}
+
+			// The certificate b presents is the one in its sidecar.
+			for _, w := range b.WorkloadsOrFail(t) {
+				chain, err := mtls.WorkloadChain(w.Sidecar())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := mtls.CheckSPIFFEID(chain, spiffeID("b")); err != nil {
+					t.Error(err)
+				}
+				if err := mtls.CheckIssuedBy(chain, root); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+}
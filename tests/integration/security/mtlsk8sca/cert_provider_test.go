@@ -0,0 +1,82 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtlsk8sca
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+// TestCertProvider checks that istiod serves with a certificate signed by the Kubernetes CA, while the workloads
+// are still issued certificates by istiod.
+func TestCertProvider(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.control-plane.k8s-certs.k8sca").
+		Run(func(ctx framework.TestContext) {
+			cfg := inst.Settings()
+			for _, cluster := range ctx.Clusters() {
+				kubeCA, err := istio.KubernetesCACert(cluster, cfg)
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				chain, err := istio.IstiodServingCert(cluster, cfg)
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				if err := mtls.CheckIssuedBy(chain, kubeCA); err != nil {
+					ctx.Fatalf("istiod serving certificate in %s: %v", cluster.Name(), err)
+				}
+			}
+
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "cert-provider",
+				Inject: true,
+			})
+			var a echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, util.EchoConfig("a", ns, false, nil)).
+				BuildOrFail(t)
+
+			// Istiod distributes its root to every namespace.
+			cm, err := a.Config().Cluster.CoreV1().ConfigMaps(ns.Name()).Get(context.TODO(), "istio-ca-root-cert",
+				kubeApiMeta.GetOptions{})
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			istiodRoot := []byte(cm.Data[constants.CACertNamespaceConfigMapDataName])
+			for _, w := range a.WorkloadsOrFail(ctx) {
+				chain, err := mtls.WorkloadChain(w.Sidecar())
+				if err != nil {
+					ctx.Fatal(err)
+				}
+				if err := mtls.CheckIssuedBy(chain, istiodRoot); err != nil {
+					ctx.Fatal(fmt.Errorf("workload certificate: %v", err))
+				}
+			}
+		})
+}
@@ -40,5 +40,5 @@ func setupConfig(_ resource.Context, cfg *istio.Config) {
 	if cfg == nil {
 		return
 	}
-	cfg.Values["global.pilotCertProvider"] = "kubernetes"
+	cfg.PilotCertProvider = istio.KubernetesCertProvider
 }
@@ -0,0 +1,137 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spire
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const policy = `
+apiVersion: security.istio.io/v1beta1
+kind: PeerAuthentication
+metadata:
+  name: server
+spec:
+  selector:
+    matchLabels:
+      app: server
+  mtls:
+    mode: STRICT
+---
+apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: server
+spec:
+  selector:
+    matchLabels:
+      app: server
+  rules:
+  - from:
+    - source:
+        principals: ["cluster.local/ns/%s/sa/allowed"]
+`
+
+// TestSPIREIdentity verifies that the mesh accepts identities issued by SPIRE when SPIRE is chained to the root
+// of the mesh, and that authorization policies apply to them like to the identities issued by istiod.
+func TestSPIREIdentity(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.peer.spire").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(ctx, ctx, namespace.Config{
+				Prefix: "spire",
+				Inject: true,
+			})
+			var naked, server echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&naked, echo.Config{
+					Namespace: ns,
+					Service:   "naked",
+					Subsets: []echo.SubsetConfig{{
+						Annotations: echo.NewAnnotations().SetBool(echo.SidecarInject, false),
+					}},
+				}).
+				With(&server, echo.Config{
+					Namespace:      ns,
+					Service:        "server",
+					ServiceAccount: true,
+					Subsets:        []echo.SubsetConfig{{}},
+					Ports: []echo.Port{{
+						Name:         "http",
+						Protocol:     protocol.HTTP,
+						InstancePort: 8090,
+					}},
+				}).
+				BuildOrFail(ctx)
+			ctx.Config().ApplyYAMLOrFail(ctx, ns.Name(), fmt.Sprintf(policy, ns.Name()))
+
+			root, err := inst.PluginCA().RootCert()
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			bundle, err := sp.Bundle()
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			if !strings.Contains(bundle, strings.TrimSpace(string(root))) {
+				ctx.Fatalf("SPIRE bundle does not contain the mesh root:\n%s", bundle)
+			}
+
+			cases := []struct {
+				serviceAccount string
+				code           string
+			}{
+				{"allowed", response.StatusCodeOK},
+				{"denied", response.StatusCodeForbidden},
+			}
+			for _, tc := range cases {
+				tc := tc
+				ctx.NewSubTest(tc.serviceAccount).Run(func(ctx framework.TestContext) {
+					svid := sp.MintX509SVIDOrFail(ctx, sp.WorkloadID(ns.Name(), tc.serviceAccount))
+					retry.UntilSuccessOrFail(ctx, func() error {
+						resp, err := naked.Call(echo.CallOptions{
+							Target:   server,
+							PortName: "http",
+							Scheme:   scheme.HTTPS,
+							Cert:     svid.CertChain,
+							Key:      svid.Key,
+						})
+						if err != nil {
+							return err
+						}
+						return resp.Check(func(_ int, r *client.ParsedResponse) error {
+							if r.Code != tc.code {
+								return fmt.Errorf("expected response code %s, got %s", tc.code, r.Code)
+							}
+							return nil
+						})
+					})
+				})
+			}
+		})
+}
@@ -0,0 +1,155 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustdomainfederation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/mtls"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+// TestTrustDomainFederation has a and c in every cluster call b, and checks that the mTLS handshakes carry the
+// trust domain of each cluster, and that an AuthorizationPolicy written for the default trust domain matches the
+// principal of a in every trust domain.
+func TestTrustDomainFederation(t *testing.T) {
+	framework.NewTest(t).
+		Features("security.control-plane.trust-domain-federation").
+		Run(func(ctx framework.TestContext) {
+			cfg := inst.Settings()
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "td-federation",
+				Inject: true,
+			})
+			builder := echoboot.NewBuilder(ctx)
+			for _, c := range ctx.Clusters() {
+				for _, name := range []string{"a", "b", "c"} {
+					echoCfg := util.EchoConfig(name, ns, false, nil)
+					echoCfg.Cluster = c
+					builder.With(nil, echoCfg)
+				}
+			}
+			echos := builder.BuildOrFail(t)
+			b := echos.Match(echo.Service("b"))
+
+			filter := mtls.EnvoyFilter("forward-client-cert", map[string]string{"app": "b"})
+			ctx.Config().ApplyYAMLOrFail(t, ns.Name(), filter)
+			defer ctx.Config().DeleteYAMLOrFail(t, ns.Name(), filter)
+
+			call := func(from echo.Instance, check func(client.ParsedResponses) error) {
+				t.Helper()
+				retry.UntilSuccessOrFail(t, func() error {
+					resp, err := from.Call(echo.CallOptions{
+						Target:   b[0],
+						PortName: "http",
+						Scheme:   scheme.HTTP,
+						// Reach b in every cluster.
+						Count: 4 * len(b),
+					})
+					if err != nil {
+						return err
+					}
+					return check(resp)
+				}, retry.Delay(time.Second), retry.Timeout(time.Minute))
+			}
+
+			ctx.NewSubTest("mtls").Run(func(ctx framework.TestContext) {
+				for _, a := range echos.Match(echo.Service("a")) {
+					td := cfg.TrustDomainFor(a.Config().Cluster)
+					call(a, func(resp client.ParsedResponses) error {
+						if err := resp.CheckOK(); err != nil {
+							return err
+						}
+						for _, r := range resp {
+							h, err := mtls.HandshakeFromResponse(r)
+							if err != nil {
+								return err
+							}
+							if err := mtls.CheckTrustDomain(h.PeerChain, td); err != nil {
+								return fmt.Errorf("client certificate: %v", err)
+							}
+							serverTD, err := spiffe.GetTrustDomainFromURISAN(h.ServerID)
+							if err != nil {
+								return err
+							}
+							if !isTrustDomain(cfg, serverTD) {
+								return fmt.Errorf("server certificate %s is in unexpected trust domain", h.ServerID)
+							}
+						}
+						return nil
+					})
+				}
+			})
+
+			ctx.NewSubTest("authorization").Run(func(ctx framework.TestContext) {
+				// The default trust domain is an alias of every cluster, so this principal matches a in every
+				// trust domain.
+				policy := fmt.Sprintf(`apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-a
+spec:
+  selector:
+    matchLabels:
+      app: b
+  rules:
+  - from:
+    - source:
+        principals: [%q]
+`, fmt.Sprintf("%s/ns/%s/sa/a", istio.DefaultTrustDomain, ns.Name()))
+				ctx.Config().ApplyYAMLOrFail(ctx, ns.Name(), policy)
+				defer ctx.Config().DeleteYAMLOrFail(ctx, ns.Name(), policy)
+
+				for _, a := range echos.Match(echo.Service("a")) {
+					call(a, func(resp client.ParsedResponses) error {
+						return resp.CheckOK()
+					})
+				}
+				for _, c := range echos.Match(echo.Service("c")) {
+					call(c, func(resp client.ParsedResponses) error {
+						return resp.Check(func(_ int, r *client.ParsedResponse) error {
+							if r.Code != response.StatusCodeForbidden {
+								return fmt.Errorf("expected code %s, got %s", response.StatusCodeForbidden, r.Code)
+							}
+							return nil
+						})
+					})
+				}
+			})
+		})
+}
+
+// isTrustDomain returns whether td is the trust domain of one of the clusters.
+func isTrustDomain(cfg istio.Config, td string) bool {
+	for _, want := range cfg.TrustDomains {
+		if td == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trustdomainfederation tests workloads in clusters with distinct trust domains, which share the root of
+// trust and accept each other's trust domains as aliases.
+package trustdomainfederation
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+var inst istio.Instance
+
+func TestMain(m *testing.M) {
+	framework.
+		NewSuite(m).
+		Label(label.CustomSetup).
+		Setup(istio.Setup(&inst, func(ctx resource.Context, cfg *istio.Config) {
+			cfg.PluginCA = true
+			cfg.TrustDomains = map[string]string{}
+			for _, c := range ctx.Clusters() {
+				cfg.TrustDomains[c.Name()] = fmt.Sprintf("td-%d.local", c.Index())
+			}
+		})).
+		Run()
+}
@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/echo/policy"
 	"istio.io/istio/pkg/test/framework/components/istioctl"
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/util/file"
@@ -122,6 +123,10 @@ func CreateContext(ctx framework.TestContext, buildVM bool) Context {
 	}
 }
 
+func (rc *Context) instances() echo.Instances {
+	return echo.Instances{rc.A, rc.B, rc.Multiversion, rc.Headless, rc.Naked, rc.VM, rc.HeadlessNaked}
+}
+
 // Run runs the given reachability test cases with the context.
 func (rc *Context) Run(testCases []TestCase) {
 	callOptions := []echo.CallOptions{
@@ -167,12 +172,24 @@ func (rc *Context) Run(testCases []TestCase) {
 				s, _, _ := ik.Invoke([]string{"ps"})
 				ctx.Logf("proxy status: %v", s)
 			}
-			// TODO(https://github.com/istio/istio/issues/25945) introducing istioctl wait in favor of a 10s sleep lead to flakes
-			// to work around this, we will temporarily make sure we are always sleeping at least 10s, even if istioctl wait is faster.
-			// This allows us to debug istioctl wait, while still ensuring tests are stable
-			sleep := time.Second*10 - time.Since(t0)
-			ctx.Logf("[%s] [%v] Wait for additional %v config propagate to endpoints...", testName, time.Now(), sleep)
-			time.Sleep(sleep)
+			policies, err := policy.Parse(c.Namespace.Name(), policyYAML)
+			if err != nil {
+				ctx.Fatal(err)
+			}
+			if len(policies) > 0 {
+				// istioctl wait is not enough, see https://github.com/istio/istio/issues/25945. Wait for the sidecars
+				// to enforce the policies instead.
+				ctx.Logf("[%s] [%v] Wait for policies to be enforced...", testName, time.Now())
+				policy.WaitForPolicyOrFail(ctx, ctx, c.Namespace.Name(), policyYAML, rc.instances(),
+					retry.Timeout(time.Minute))
+			} else {
+				// TODO(https://github.com/istio/istio/issues/25945) introducing istioctl wait in favor of a 10s sleep lead to flakes
+				// to work around this, we will temporarily make sure we are always sleeping at least 10s, even if istioctl wait is faster.
+				// This allows us to debug istioctl wait, while still ensuring tests are stable
+				sleep := time.Second*10 - time.Since(t0)
+				ctx.Logf("[%s] [%v] Wait for additional %v config propagate to endpoints...", testName, time.Now(), sleep)
+				time.Sleep(sleep)
+			}
 			ctx.Logf("[%s] [%v] Finish waiting. Continue testing.", testName, time.Now())
 			ctx.Cleanup(func() {
 				if err := retry.UntilSuccess(func() error {
@@ -17,42 +17,29 @@ package telemetry
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 
-	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
-	"github.com/prometheus/common/model"
 	"golang.org/x/sync/errgroup"
-	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pkg/config/protocol"
-	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
-	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/grafana"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/components/prometheus"
-	"istio.io/istio/pkg/test/scopes"
-	"istio.io/istio/pkg/test/util/retry"
-	"istio.io/istio/pkg/test/util/yml"
 	"istio.io/pkg/log"
 )
 
 var (
 	dashboards = []struct {
-		configmap string
-		name      string
-		excluded  []string
+		name     string
+		excluded []string
 	}{
 		{
-			"istio-grafana-dashboards",
 			"pilot-dashboard.json",
 			[]string{
 				"pilot_xds_push_errors",
@@ -72,7 +59,6 @@ var (
 			},
 		},
 		{
-			"istio-services-grafana-dashboards",
 			"istio-mesh-dashboard.json",
 			[]string{
 				"galley_",
@@ -80,21 +66,18 @@ var (
 			},
 		},
 		{
-			"istio-services-grafana-dashboards",
 			"istio-service-dashboard.json",
 			[]string{
 				"istio_tcp_",
 			},
 		},
 		{
-			"istio-services-grafana-dashboards",
 			"istio-workload-dashboard.json",
 			[]string{
 				"istio_tcp_",
 			},
 		},
 		{
-			"istio-grafana-dashboards",
 			"istio-performance-dashboard.json",
 			[]string{
 				// TODO add these back: https://github.com/istio/istio/issues/20175
@@ -113,32 +96,17 @@ func TestDashboard(t *testing.T) {
 		Run(func(ctx framework.TestContext) {
 
 			p := prometheus.NewOrFail(ctx, ctx, prometheus.Config{})
-			kenv := ctx.Environment().(*kube.Environment)
+			g := grafana.NewOrFail(ctx, ctx, grafana.Config{})
 			setupDashboardTest(ctx)
 			waitForMetrics(ctx, p)
 			for _, d := range dashboards {
 				d := d
 				ctx.NewSubTest(d.name).RunParallel(func(t framework.TestContext) {
-					cm, err := kenv.KubeClusters[0].CoreV1().ConfigMaps(i.Settings().TelemetryNamespace).Get(
-						context.TODO(), d.configmap, kubeApiMeta.GetOptions{})
-					if err != nil {
-						t.Fatalf("Failed to find dashboard %v: %v", d.configmap, err)
-					}
-
-					config, f := cm.Data[d.name]
-					if !f {
-						t.Fatalf("Failed to find expected dashboard: %v", d.name)
-					}
-
-					queries, err := extractQueries(config)
-					if err != nil {
-						t.Fatalf("Failed to extract queries: %v", err)
-					}
-
-					for _, query := range queries {
-						if err := checkMetric(p, query, d.excluded); err != nil {
-							t.Errorf("Check query failed: %v", err)
-						}
+					if err := g.ValidateDashboard(d.name, p, grafana.ValidateOptions{
+						Excluded:     d.excluded,
+						Replacements: replacements,
+					}); err != nil {
+						t.Errorf("Check query failed: %v", err)
 					}
 				})
 			}
@@ -146,17 +114,9 @@ func TestDashboard(t *testing.T) {
 }
 
 var (
-	// Some templates use replacement variables. Instead, replace those with wildcard
-	replacer = strings.NewReplacer(
-		"$dstns", ".*",
-		"$dstwl", ".*",
-		"$service", ".*",
-		"$srcns", ".*",
-		"$srcwl", ".*",
-		"$namespace", ".*",
-		"$workload", ".*",
-		"$dstsvc", ".*",
-		"$adapter", ".*",
+	// Some queries filter on values that the test traffic does not generate. Widen those to match anything.
+	// Template variables (e.g. $namespace) are replaced with wildcards by the grafana component.
+	replacements = []string{
 		// Just allow all mTLS settings rather than trying to send mtls and plaintext
 		`connection_security_policy="unknown"`, `connection_security_policy=~".*"`,
 		`connection_security_policy="mutual_tls"`, `connection_security_policy=~".*"`,
@@ -164,61 +124,22 @@ var (
 		// Test runs in istio-system
 		`destination_workload_namespace!="istio-system"`, `destination_workload_namespace=~".*"`,
 		`source_workload_namespace!="istio-system"`, `source_workload_namespace=~".*"`,
-	)
-)
-
-func checkMetric(p prometheus.Instance, query string, excluded []string) error {
-	query = replacer.Replace(query)
-	value, _, err := p.API().QueryRange(context.Background(), query, promv1.Range{
-		Start: time.Now().Add(-time.Minute),
-		End:   time.Now(),
-		Step:  time.Second,
-	})
-	if err != nil {
-		return fmt.Errorf("failure executing query (%s): %v", query, err)
 	}
-	if value == nil {
-		return fmt.Errorf("returned value should not be nil for '%s'", query)
-	}
-	numSamples := 0
-	switch v := value.(type) {
-	case model.Vector:
-		numSamples = v.Len()
-	case model.Matrix:
-		numSamples = v.Len()
-	case *model.Scalar:
-		numSamples = 1
-	default:
-		return fmt.Errorf("unknown metric value type: %T", v)
-	}
-	if includeQuery(query, excluded) {
-		if numSamples == 0 {
-			return fmt.Errorf("expected a metric value for '%s', found no samples: %#v", query, value)
-		}
-	} else {
-		if numSamples != 0 {
-			scopes.Framework.Infof("Filtered out metric '%v', but got samples: %v", query, value)
-		}
-	}
-	return nil
-}
+)
 
 // nolint: interfacer
 func waitForMetrics(t framework.TestContext, instance prometheus.Instance) {
 	// These are sentinel metrics that will be used to evaluate if prometheus
 	// scraping has occurred and data is available via promQL.
 	// We will retry these queries, but not future ones, otherwise failures will take too long
-	queries := []string{
-		`istio_requests_total`,
-		`istio_tcp_received_bytes_total`,
+	queries := []prometheus.Query{
+		prometheus.Requests(),
+		prometheus.NewQuery(prometheus.TCPReceivedBytesTotal),
 	}
 
 	for _, query := range queries {
-		err := retry.UntilSuccess(func() error {
-			return checkMetric(instance, query, nil)
-		})
 		// Do not fail here - this is just to let the metrics sync. We will fail on the test if query fails
-		if err != nil {
+		if _, err := instance.WaitForMetric(query); err != nil {
 			t.Logf("Sentinel query %v failed: %v", query, err)
 		}
 	}
@@ -281,13 +202,6 @@ func setupDashboardTest(t framework.TestContext) {
 	})
 	t.Config().ApplyYAMLOrFail(t, ns.Name(), fmt.Sprintf(gatewayConfig, ns.Name()))
 
-	// Apply just the grafana dashboards
-	cfg, err := ioutil.ReadFile(filepath.Join(env.IstioSrc, "samples/addons/grafana.yaml"))
-	if err != nil {
-		t.Fatal(err)
-	}
-	t.Config().ApplyYAMLOrFail(t, "istio-system", yml.SplitYamlByKind(string(cfg))["ConfigMap"])
-
 	var instance echo.Instance
 	echoboot.
 		NewBuilder(t).
@@ -352,51 +266,3 @@ func setupDashboardTest(t framework.TestContext) {
 		t.Fatalf("ingress call failed: %v", err)
 	}
 }
-
-// extractQueries pulls all prometheus queries out of a grafana dashboard
-// Rather than importing the entire grafana API just for this test, do some shoddy json parsing
-// Equivalent to jq command: '.panels[].targets[]?.expr'
-func extractQueries(dash string) ([]string, error) {
-	var queries []string
-	js := map[string]interface{}{}
-	if err := json.Unmarshal([]byte(dash), &js); err != nil {
-		return nil, err
-	}
-	panels, f := js["panels"]
-	if !f {
-		return nil, fmt.Errorf("failed to find panels in %v", dash)
-	}
-	panelsList, f := panels.([]interface{})
-	if !f {
-		return nil, fmt.Errorf("failed to find panelsList in type %T: %v", panels, panels)
-	}
-	for _, p := range panelsList {
-		pm := p.(map[string]interface{})
-		targets, f := pm["targets"]
-		if !f {
-			continue
-		}
-		targetsList, f := targets.([]interface{})
-		if !f {
-			return nil, fmt.Errorf("failed to find targetsList in type %T: %v", targets, targets)
-		}
-		for _, t := range targetsList {
-			tm := t.(map[string]interface{})
-			expr, f := tm["expr"]
-			if !f {
-				return nil, fmt.Errorf("failed to find expr in %v", t)
-			}
-			queries = append(queries, expr.(string))
-		}
-	}
-	return queries, nil
-}
-
-func includeQuery(query string, excluded []string) bool {
-	for _, f := range excluded {
-		if strings.Contains(query, f) {
-			return false
-		}
-	}
-	return true
-}
@@ -0,0 +1,96 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/kiali"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/components/prometheus"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// TestKiali verifies that the Kiali addon can build a graph and compute health from the metrics
+// generated by the current proxies.
+func TestKiali(t *testing.T) {
+	framework.NewTest(t).
+		Features("observability.telemetry.kiali").
+		Run(func(ctx framework.TestContext) {
+			_ = prometheus.NewOrFail(ctx, ctx, prometheus.Config{})
+			k := kiali.NewOrFail(ctx, ctx, kiali.Config{})
+
+			ns := namespace.NewOrFail(ctx, ctx, namespace.Config{
+				Prefix: "kiali",
+				Inject: true,
+			})
+			ports := []echo.Port{{
+				Name:         "http",
+				Protocol:     protocol.HTTP,
+				InstancePort: 8090,
+			}}
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, echo.Config{Service: "a", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				With(&b, echo.Config{Service: "b", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				BuildOrFail(ctx)
+
+			if s, err := k.Status(); err != nil {
+				ctx.Fatalf("failed to get kiali status: %v", err)
+			} else if len(s.WarningMessages) > 0 {
+				ctx.Logf("kiali reported warnings: %v", s.WarningMessages)
+			}
+
+			// Kiali builds the graph from the last minute of metrics, so keep sending traffic while waiting.
+			retry.UntilSuccessOrFail(ctx, func() error {
+				if _, err := a.Call(echo.CallOptions{
+					Target:     b,
+					PortName:   "http",
+					Scheme:     scheme.HTTP,
+					Count:      10,
+					Validators: echo.NewValidators().WithOK(),
+				}); err != nil {
+					return err
+				}
+				g, err := k.Graph([]string{ns.Name()}, time.Minute)
+				if err != nil {
+					return err
+				}
+				if err := g.VerifyEdge(ns.Name(), "a-v1", ns.Name(), "b-v1", "http"); err != nil {
+					return err
+				}
+				health, err := k.WorkloadHealth(ns.Name(), time.Minute)
+				if err != nil {
+					return err
+				}
+				h, ok := health["b-v1"]
+				if !ok {
+					return fmt.Errorf("no health reported for b-v1: %v", health)
+				}
+				if !h.Healthy() {
+					return fmt.Errorf("expected b-v1 to be healthy, got %+v", h)
+				}
+				return nil
+			}, retry.Timeout(5*time.Minute), retry.Delay(5*time.Second))
+		})
+}
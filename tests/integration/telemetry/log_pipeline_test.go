@@ -0,0 +1,70 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/loki"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+// TestAccessLogPipeline verifies that proxy access logs written to stdout are picked up by a real log
+// collector and can be found in the log store.
+func TestAccessLogPipeline(t *testing.T) {
+	framework.NewTest(t).
+		Features("observability.telemetry.logging").
+		Run(func(ctx framework.TestContext) {
+			l := loki.NewOrFail(ctx, ctx, loki.Config{})
+
+			ns := namespace.NewOrFail(ctx, ctx, namespace.Config{
+				Prefix: "log-pipeline",
+				Inject: true,
+			})
+			ports := []echo.Port{{
+				Name:         "http",
+				Protocol:     protocol.HTTP,
+				InstancePort: 8090,
+			}}
+			var a, b echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, echo.Config{Service: "a", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				With(&b, echo.Config{Service: "b", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				BuildOrFail(ctx)
+
+			start := time.Now()
+			path := fmt.Sprintf("/log-pipeline-%d", start.UnixNano())
+			a.CallWithRetryOrFail(ctx, echo.CallOptions{
+				Target:     b,
+				PortName:   "http",
+				Scheme:     scheme.HTTP,
+				Path:       path,
+				Count:      5,
+				Validators: echo.NewValidators().WithOK(),
+			})
+
+			// Both the client and the server proxy log each request.
+			l.WaitForLogsOrFail(ctx, loki.ContainerLogs(ns.Name(), "a-v1", "istio-proxy").WithLine(path), start, 5)
+			l.WaitForLogsOrFail(ctx, loki.ContainerLogs(ns.Name(), "b-v1", "istio-proxy").WithLine(path), start, 5)
+		})
+}
@@ -16,31 +16,33 @@
 package policy
 
 import (
-	"io/ioutil"
+	"fmt"
 	"testing"
-	"time"
 
 	"istio.io/istio/pkg/config/protocol"
-	"istio.io/istio/pkg/test/echo/common/response"
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/components/ratelimit"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
-	"istio.io/istio/pkg/test/kube"
-	"istio.io/istio/pkg/test/util/tmpl"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/util"
 )
 
+// requestsPerHour is the number of requests allowed for each path.
+const requestsPerHour = 5
+
 var (
-	ist         istio.Instance
-	echoNsInst  namespace.Instance
-	ratelimitNs namespace.Instance
-	ing         ingress.Instance
-	srv         echo.Instance
-	clt         echo.Instance
+	ist        istio.Instance
+	echoNsInst namespace.Instance
+	rls        ratelimit.Instance
+	ing        ingress.Instance
+	srv        echo.Instance
+	clt        echo.Instance
 )
 
 func TestRateLimiting(t *testing.T) {
@@ -48,9 +50,17 @@ func TestRateLimiting(t *testing.T) {
 		NewTest(t).
 		Features("traffic.ratelimit.envoy").
 		Run(func(ctx framework.TestContext) {
-			if !sendTrafficAndCheckIfRatelimited(t) {
-				t.Errorf("No request received StatusTooMantRequest Error.")
-			}
+			// Every attempt uses a new path, so that it starts with a fresh quota.
+			retry.UntilSuccessOrFail(t, func() error {
+				_, err := clt.Call(echo.CallOptions{
+					Target:     srv,
+					PortName:   "http",
+					Path:       "/" + util.RandomString(16),
+					Count:      2 * requestsPerHour,
+					Validators: echo.NewValidators().With(ratelimit.ValidateRateLimited(requestsPerHour)),
+				})
+				return err
+			})
 		})
 }
 
@@ -95,82 +105,27 @@ func testSetup(ctx resource.Context) (err error) {
 
 	ing = ist.IngressFor(ctx.Clusters().Default())
 
-	ratelimitNs, err = namespace.New(ctx, namespace.Config{
-		Prefix: "istio-ratelimit",
+	rls, err = ratelimit.New(ctx, ratelimit.Config{
+		Descriptors: []ratelimit.Descriptor{
+			{
+				Key:       "PATH",
+				RateLimit: &ratelimit.RateLimit{Unit: ratelimit.Hour, RequestsPerUnit: requestsPerHour},
+			},
+		},
 	})
 	if err != nil {
 		return
 	}
 
-	err = setupEnvoyFilter(ctx)
-	if err != nil {
-		return
-	}
-
-	yamlContent, err := ioutil.ReadFile("testdata/ratelimitservice.yaml")
-	if err != nil {
-		return
-	}
-
-	err = ctx.Config().ApplyYAML(ratelimitNs.Name(),
-		string(yamlContent),
-	)
-	if err != nil {
-		return
-	}
-
-	// Wait for redis and ratelimit service to be up.
-	fetchFn := kube.NewPodFetch(ctx.Clusters().Default(), ratelimitNs.Name(), "app=redis")
-	if _, err = kube.WaitUntilPodsAreReady(fetchFn); err != nil {
-		return
-	}
-	fetchFn = kube.NewPodFetch(ctx.Clusters().Default(), ratelimitNs.Name(), "app=ratelimit")
-	if _, err = kube.WaitUntilPodsAreReady(fetchFn); err != nil {
-		return
-	}
-
-	// TODO(gargnupur): Figure out a way to query, envoy is ready to talk to rate limit service.
-	// Also, change to use mock rate limit and redis service.
-	time.Sleep(time.Second * 60)
-
-	return nil
-}
-
-func setupEnvoyFilter(ctx resource.Context) error {
-	content, err := ioutil.ReadFile("testdata/enable_envoy_ratelimit.yaml")
-	if err != nil {
-		return err
-	}
-
-	con, err := tmpl.Evaluate(string(content), map[string]interface{}{
-		"EchoNamespace":      echoNsInst.Name(),
-		"RateLimitNamespace": ratelimitNs.Name(),
+	filter, err := rls.EnvoyFilter(ratelimit.Filter{
+		Name:     "filter-ratelimit",
+		Selector: map[string]string{"app": "clt"},
+		VirtualHost: fmt.Sprintf("srv.%s.svc.cluster.local:%d",
+			echoNsInst.Name(), srv.Config().Ports[0].ServicePort),
+		Actions: []ratelimit.Action{{Header: ":path", DescriptorKey: "PATH"}},
 	})
 	if err != nil {
-		return err
-	}
-
-	err = ctx.Config().ApplyYAML(ist.Settings().SystemNamespace, con)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func sendTrafficAndCheckIfRatelimited(t *testing.T) bool {
-	t.Helper()
-	t.Logf("Sending 300 requests...")
-	httpOpts := echo.CallOptions{
-		Target:   srv,
-		PortName: "http",
-		Count:    300,
-	}
-	if parsedResponse, err := clt.Call(httpOpts); err == nil {
-		for _, resp := range parsedResponse {
-			if response.StatusCodeTooManyRequests == resp.Code {
-				return true
-			}
-		}
+		return
 	}
-	return false
+	return ctx.Config().ApplyYAML(echoNsInst.Name(), filter)
 }
@@ -16,7 +16,6 @@
 package http
 
 import (
-	"fmt"
 	"testing"
 	"time"
 
@@ -137,28 +136,19 @@ func SendTraffic() error {
 
 func buildQuery() (sourceQuery, destinationQuery, appQuery string) {
 	ns := GetAppNamespace()
-	sourceQuery = `istio_requests_total{reporter="source",`
-	destinationQuery = `istio_requests_total{reporter="destination",`
-	labels := map[string]string{
-		"request_protocol":               "http",
-		"response_code":                  "200",
-		"destination_app":                "server",
-		"destination_version":            "v1",
-		"destination_service":            "server." + ns.Name() + ".svc.cluster.local",
-		"destination_service_name":       "server",
-		"destination_workload_namespace": ns.Name(),
-		"destination_service_namespace":  ns.Name(),
-		"source_app":                     "client",
-		"source_version":                 "v1",
-		"source_workload":                "client-v1",
-		"source_workload_namespace":      ns.Name(),
-	}
-	for k, v := range labels {
-		sourceQuery += fmt.Sprintf(`%s=%q,`, k, v)
-		destinationQuery += fmt.Sprintf(`%s=%q,`, k, v)
-	}
-	sourceQuery += "}"
-	destinationQuery += "}"
-	appQuery += `istio_echo_http_requests_total{kubernetes_namespace="` + ns.Name() + `"}`
+	q := prometheus.Requests().
+		Source("client-v1", ns.Name()).
+		DestinationService("server", ns.Name()).
+		ResponseCode("200").
+		WithLabel("request_protocol", "http").
+		WithLabel("destination_app", "server").
+		WithLabel("destination_version", "v1").
+		WithLabel("destination_service", "server."+ns.Name()+".svc.cluster.local").
+		WithLabel("destination_workload_namespace", ns.Name()).
+		WithLabel("source_app", "client").
+		WithLabel("source_version", "v1")
+	sourceQuery = q.Reporter(prometheus.ReporterSource).String()
+	destinationQuery = q.Reporter(prometheus.ReporterDestination).String()
+	appQuery = prometheus.NewQuery("istio_echo_http_requests_total").WithLabel("kubernetes_namespace", ns.Name()).String()
 	return
 }
@@ -26,12 +26,12 @@ import (
 	"istio.io/istio/pkg/test/framework/components/istio/ingress"
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/components/prometheus"
+	"istio.io/istio/pkg/test/framework/components/telemetry"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/util/file"
 	"istio.io/istio/pkg/test/util/retry"
 	util "istio.io/istio/tests/integration/telemetry"
-	util_prometheus "istio.io/istio/tests/integration/telemetry/stats/prometheus"
 )
 
 const (
@@ -70,28 +70,23 @@ func TestTcpMetric(t *testing.T) { // nolint:interfacer
 				t.Errorf("unable to load config %s, err:%v", cleanupFilterConfig, err)
 			}
 
-			ctx.Config().ApplyYAMLOrFail(
-				t,
-				systemNM.Name(),
-				cleanup,
-			)
-			defer ctx.Config().DeleteYAML(
-				systemNM.Name(),
-				cleanup,
-			)
-
-			util.AllowRuleSync(t)
-
+			// TODO(gargnupur): Use TCP metrics like in Telemetry V1 (https://github.com/istio/istio/issues/20283)
 			destinationQuery := buildQuery()
-			retry.UntilSuccessOrFail(t, func() error {
-				util.SendTraffic(ing, t, "Sending traffic", url, "", 200)
-				// TODO(gargnupur): Use TCP metrics like in Telemetry V1 (https://github.com/istio/istio/issues/20283)
-				if err := util_prometheus.QueryPrometheus(t, destinationQuery, prom); err != nil {
-					t.Logf("prometheus values for istio_tcp_connections_opened_total: \n%s", util.PromDump(prom, "istio_tcp_connections_opened_total"))
-					return err
-				}
-				return nil
-			}, retry.Delay(3*time.Second), retry.Timeout(80*time.Second))
+			change := telemetry.Change{
+				Namespace: systemNM.Name(),
+				Config:    []string{cleanup},
+				Traffic: func() error {
+					util.SendTraffic(ing, t, "Sending traffic", url, "", 200)
+					return nil
+				},
+				Effects: []telemetry.Effect{telemetry.MetricAppears(prom, destinationQuery)},
+			}
+			defer ctx.Config().DeleteYAML(systemNM.Name(), cleanup)
+			if err := telemetry.Apply(ctx, change, retry.Timeout(80*time.Second)); err != nil {
+				t.Logf("prometheus values for %s: \n%s", prometheus.TCPConnectionsOpened,
+					util.PromDump(prom, prometheus.TCPConnectionsOpened))
+				t.Fatal(err)
+			}
 		})
 }
 
@@ -138,25 +133,17 @@ func testsetup(ctx resource.Context) (err error) {
 	return nil
 }
 
-func buildQuery() (destinationQuery string) {
-	destinationQuery = `istio_tcp_connections_opened_total{reporter="destination",`
-	labels := map[string]string{
-		"request_protocol":               "tcp",
-		"destination_service_name":       "mongodb",
-		"destination_canonical_revision": "v1",
-		"destination_canonical_service":  "mongodb",
-		"destination_app":                "mongodb",
-		"destination_version":            "v1",
-		"destination_workload_namespace": bookinfoNs.Name(),
-		"destination_service_namespace":  bookinfoNs.Name(),
-		"source_app":                     "ratings",
-		"source_version":                 "v2",
-		"source_workload":                "ratings-v2",
-		"source_workload_namespace":      bookinfoNs.Name(),
-	}
-	for k, v := range labels {
-		destinationQuery += fmt.Sprintf(`%s=%q,`, k, v)
-	}
-	destinationQuery += "}"
-	return
+func buildQuery() prometheus.Query {
+	return prometheus.TCPConnections().
+		Reporter(prometheus.ReporterDestination).
+		Source("ratings-v2", bookinfoNs.Name()).
+		WithLabel("destination_workload_namespace", bookinfoNs.Name()).
+		DestinationService("mongodb", bookinfoNs.Name()).
+		WithLabel("request_protocol", "tcp").
+		WithLabel("destination_canonical_revision", "v1").
+		WithLabel("destination_canonical_service", "mongodb").
+		WithLabel("destination_app", "mongodb").
+		WithLabel("destination_version", "v1").
+		WithLabel("source_app", "ratings").
+		WithLabel("source_version", "v2")
 }
@@ -102,7 +102,7 @@ func VerifyBookinfoTraces(t *testing.T, namespace string, traces []zipkin.Trace)
 		// compare each candidate trace with the wanted trace
 		for _, s := range trace.Spans {
 			// find the root span of candidate trace and do recursive comparison
-			if s.ParentSpanID == "" && CompareTrace(t, s, wtr) {
+			if s.ParentSpanID == "" && CompareTrace(t, *s, wtr) {
 				return true
 			}
 		}
@@ -0,0 +1,75 @@
+// +build integ
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/components/zipkin"
+	"istio.io/istio/tests/integration/telemetry/tracing"
+)
+
+// TestTraceChain sends a request with a trace context started by the test through the call chain a -> b -> c,
+// and verifies that all of the hops are reported as a single trace. This catches trace context propagation
+// bugs in the proxies that only show up end to end.
+func TestTraceChain(t *testing.T) {
+	framework.NewTest(t).
+		Features("observability.telemetry.tracing.server").
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(ctx, ctx, namespace.Config{
+				Prefix: "trace-chain",
+				Inject: true,
+			})
+			ports := []echo.Port{{
+				Name:         "http",
+				Protocol:     protocol.HTTP,
+				InstancePort: 8090,
+			}}
+			var a, b, c echo.Instance
+			echoboot.NewBuilder(ctx).
+				With(&a, echo.Config{Service: "a", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				With(&b, echo.Config{Service: "b", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				With(&c, echo.Config{Service: "c", Namespace: ns, Ports: ports, Subsets: []echo.SubsetConfig{{}}}).
+				BuildOrFail(ctx)
+
+			opts := echo.CallOptions{
+				Target:     b,
+				PortName:   "http",
+				Scheme:     scheme.HTTP,
+				Chain:      []echo.Hop{{Target: c}},
+				Validators: echo.NewValidators().WithOK(),
+			}
+			// Wait for the chain to work before starting the trace, so that it has a single attempt.
+			a.CallWithRetryOrFail(ctx, opts)
+
+			since := time.Now()
+			tc := echo.NewTraceContext().WithBaggage("test", "trace-chain")
+			opts.Trace = tc
+			a.CallOrFail(ctx, opts)
+
+			zipkin.ExpectChainOrFail(ctx, tracing.GetZipkinInstance(), tc.TraceID, since,
+				zipkin.ServiceName("a", ns.Name()),
+				zipkin.ServiceName("b", ns.Name()),
+				zipkin.ServiceName("c", ns.Name()))
+		})
+}
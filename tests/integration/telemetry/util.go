@@ -20,7 +20,6 @@ import (
 	"os"
 	"strings"
 	"testing"
-	"time"
 
 	"fortio.org/fortio/fhttp"
 	"fortio.org/fortio/periodic"
@@ -76,8 +75,3 @@ func SendTraffic(ingress ingress.Instance, t *testing.T, msg, url, extraHeader s
 	}
 	return res
 }
-
-func AllowRuleSync(t *testing.T) {
-	t.Log("Sleeping to allow rules to take effect...")
-	time.Sleep(15 * time.Second)
-}
@@ -103,11 +103,14 @@ docker.pilot: pilot/docker/Dockerfile.pilot
 	$(DOCKER_RULE)
 
 # Test application
-docker.app: BUILD_PRE=&& chmod 755 server client
+docker.app: BUILD_PRE=&& chmod 755 server client extauthz oidc externalca
 docker.app: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
 docker.app: $(ECHO_DOCKER)/Dockerfile.app
 docker.app: $(ISTIO_OUT_LINUX)/client
 docker.app: $(ISTIO_OUT_LINUX)/server
+docker.app: $(ISTIO_OUT_LINUX)/extauthz
+docker.app: $(ISTIO_OUT_LINUX)/oidc
+docker.app: $(ISTIO_OUT_LINUX)/externalca
 docker.app: $(ISTIO_DOCKER)/certs
 	$(DOCKER_RULE)
 